@@ -0,0 +1,101 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/appvia/terranetes-controller/pkg/agent"
+	"github.com/appvia/terranetes-controller/pkg/schema"
+	k8sutils "github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+)
+
+// agentConfig is the configuration for the remote execution agent
+type agentConfig struct {
+	// Endpoint is the url of the agents server on the controller
+	Endpoint string
+	// TLSAuthority is the path to the certificate authority used to verify the controller
+	TLSAuthority string
+	// TLSCert is the path to the client certificate, the common name must be the agent name
+	TLSCert string
+	// TLSKey is the path to the client key
+	TLSKey string
+	// Options are the options for the agent
+	Options agent.Options
+}
+
+// newAgentCommand returns the command used to run a remote execution agent
+func newAgentCommand() *cobra.Command {
+	config := &agentConfig{}
+
+	cmd := &cobra.Command{
+		Use:   "agent",
+		Short: "Runs an agent which executes the jobs for the providers within the current cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if v, _ := cmd.Flags().GetBool("verbose"); v {
+				log.SetLevel(log.DebugLevel)
+			}
+
+			return runAgent(config)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.Bool("verbose", false, "Enable verbose logging")
+	flags.DurationVar(&config.Options.Interval, "interval", 10*time.Second, "The interval between pulling assignments and reporting on the jobs")
+	flags.StringSliceVar(&config.Options.Providers, "provider", []string{}, "The name of a provider the agent runs the jobs for")
+	flags.StringVar(&config.Endpoint, "endpoint", "", "The url of the agents server on the controller i.e. https://controller.example.com:10444")
+	flags.StringVar(&config.Options.Name, "name", "", "The name of the agent, referenced by the providers")
+	flags.StringVar(&config.TLSAuthority, "tls-ca", "", "The filename of the certificate authority used to verify the controller")
+	flags.StringVar(&config.TLSCert, "tls-cert", "", "The filename of the client certificate, the common name must match the agent name")
+	flags.StringVar(&config.TLSKey, "tls-key", "", "The filename of the client key")
+
+	return cmd
+}
+
+// runAgent is called to run the agent against the controller
+func runAgent(config *agentConfig) error {
+	tlsConfig, err := agent.NewClientTLSConfig(config.TLSCert, config.TLSKey, config.TLSAuthority)
+	if err != nil {
+		return err
+	}
+	broker, err := agent.NewClient(config.Endpoint, tlsConfig)
+	if err != nil {
+		return err
+	}
+
+	cc, err := k8sutils.NewRuntimeClient(schema.GetScheme())
+	if err != nil {
+		return err
+	}
+	kc, err := k8sutils.NewKubeClient()
+	if err != nil {
+		return err
+	}
+
+	svc, err := agent.New(cc, kc, broker, config.Options)
+	if err != nil {
+		return err
+	}
+
+	return svc.Run(ctrl.SetupSignalHandler())
+}
@@ -54,9 +54,15 @@ func main() {
 		},
 	}
 
+	cmd.AddCommand(newAgentCommand())
+
 	flags := cmd.Flags()
 	flags.Bool("verbose", false, "Enable verbose logging")
+	flags.BoolVar(&config.EnableAgents, "enable-agents", false, "Indicates the controller serves remote execution agents, requires the tls certificates and authority")
+	flags.BoolVar(&config.EnableAPIServerAuthentication, "enable-apiserver-authentication", false, "Indicates the apiserver requires callers to authenticate with a kubernetes bearer token")
+	flags.BoolVar(&config.EnableConfigurationHooks, "enable-configuration-hooks", false, "Indicates configurations are permitted to define their own hooks, policy hooks are always permitted")
 	flags.BoolVar(&config.EnableContextInjection, "enable-context-injection", false, "Indicates the controller should inject Configuration context into the terraform variables")
+	flags.BoolVar(&config.EnableNamespacedJobs, "enable-namespaced-jobs", false, "Indicates the jobs, configuration secrets and state are placed in the namespace of the configuration")
 	flags.BoolVar(&config.EnableNamespaceProtection, "enable-namespace-protection", false, "Indicates the controller should protect the controller namespace from being deleted")
 	flags.BoolVar(&config.EnableRevisionUpdateProtection, "enable-revision-update-protection", false, "Indicates we should protect the revisions in use from being updated")
 	flags.BoolVar(&config.EnableTerraformVersions, "enable-terraform-versions", true, "Indicates the terraform version can be overridden by configurations")
@@ -71,14 +77,20 @@ func main() {
 	flags.DurationVar(&config.RevisionExpiration, "revision-expiration", 0, "The duration a revision should be kept is not referenced or latest (zero means disabled)")
 	flags.Float64Var(&config.ConfigurationThreshold, "configurations-threshold", 0, "The maximum percentage of configurations that can be run at any one time")
 	flags.Float64Var(&config.DriftThreshold, "drift-threshold", 0.10, "The maximum percentage of configurations that can be run drift detection at any one time")
+	flags.IntVar(&config.AgentsPort, "agents-port", 10444, "The port the remote execution agents connect to using mutual tls")
 	flags.IntVar(&config.APIServerPort, "apiserver-port", 10080, "The port the apiserver should be listening on")
 	flags.IntVar(&config.BackoffLimit, "backoff-limit", 1, "The number of times we are willing to allow a terraform job to error before marking as a failure")
 	flags.IntVar(&config.MetricsPort, "metrics-port", 9090, "The port the metric endpoint binds to")
 	flags.IntVar(&config.WebhookPort, "webhooks-port", 10081, "The port the webhook endpoint binds to")
 	flags.StringSliceVar(&config.ExecutorSecrets, "executor-secret", []string{}, "Name of a secret in controller namespace which should be added to the job")
 	flags.StringSliceVar(&config.JobLabels, "job-label", []string{}, "A collection of key=values to add to all jobs")
+	flags.StringSliceVar(&config.SourceAllowedHosts, "source-allowed-host", []string{}, "A host module sources are permitted to be retrieved from, wildcards are permitted (i.e. *.example.com)")
+	flags.StringSliceVar(&config.SourceAllowedSchemes, "source-allowed-scheme", []string{}, "A scheme or getter module sources are permitted to use (i.e. git, s3, https)")
 	flags.StringVar(&config.BackendTemplate, "backend-template", "", "Name of secret in the controller namespace containing a template for the terraform state")
-	flags.StringVar(&config.BinaryPath, "binary-path", "/usr/local/bin/tofu", "The path of the terraform binary to use")
+	flags.StringVar(&config.BinaryPath, "binary-path", "/bin/terraform", "The path of the terraform binary used by the terraform engine")
+	flags.StringVar(&config.CacheVolume, "cache-volume", "", "Name of a persistent volume claim in the controller namespace used to cache module sources and provider plugins across jobs (ignored by namespaced jobs)")
+	flags.StringVar(&config.BuildLogsStore, "build-logs-store", "kubernetes", "The location used to retain the logs of completed jobs i.e. kubernetes, file:///path or s3://bucket/prefix (empty disables)")
+	flags.StringVar(&config.DefaultEngine, "default-engine", "opentofu", "The engine used when neither the configuration or provider defines one i.e. terraform or opentofu")
 	flags.StringVar(&config.ExecutorCPULimit, "executor-cpu-limit", "", "The default CPU limit for the executor container (default is no limit)")
 	flags.StringVar(&config.ExecutorCPURequest, "executor-cpu-request", "5m", "The default CPU request for the executor container")
 	flags.StringVar(&config.ExecutorImage, "executor-image", fmt.Sprintf("ghcr.io/appvia/terranetes-executor:%s", version.Version), "The image to use for the executor")
@@ -88,14 +100,24 @@ func main() {
 	flags.StringVar(&config.InfracostsSecretName, "cost-secret", "", "Name of the secret on the controller namespace containing your infracost token")
 	flags.StringVar(&config.JobTemplate, "job-template", "", "Name of configmap in the controller namespace containing a template for the job")
 	flags.StringVar(&config.Namespace, "namespace", os.Getenv("KUBE_NAMESPACE"), "The namespace the controller is running in and where jobs will run")
+	flags.StringVar(&config.OpenTofuBinaryPath, "opentofu-binary-path", "/usr/local/bin/tofu", "The path of the tofu binary used by the opentofu engine")
+	flags.StringVar(&config.OpenTofuImage, "opentofu-image", "ghcr.io/opentofu/opentofu:latest", "The image to use for the opentofu engine")
 	flags.StringVar(&config.PolicyImage, "policy-image", "bridgecrew/checkov:latest", "The image to use for the policy")
+	flags.StringVar(&config.SourceSigningKeys, "source-signing-keys", "", "Name of a secret in the controller namespace containing public keys used to verify the signature of git module sources")
+	flags.StringVar(&config.ProviderMirrorCASecret, "provider-mirror-ca-secret", "", "Name of a secret in the controller namespace containing the certificate authority (ca.pem) the jobs use to trust the provider mirror")
+	flags.StringVar(&config.ProviderMirrorDir, "provider-mirror-dir", "", "The directory holding the provider mirror, enables the provider network mirror and configures the jobs to use it")
+	flags.IntVar(&config.ProviderMirrorPort, "provider-mirror-port", 10443, "The port the provider mirror is served on, using the tls certificate")
+	flags.StringVar(&config.ProviderMirrorSigningKeys, "provider-mirror-signing-keys", "", "Path to the ascii armored keys used by the providers to sign their checksums, archives pushed into the mirror must be signed by one of these keys")
+	flags.StringVar(&config.ProviderMirrorURL, "provider-mirror-url", "", "Overrides the location of the provider mirror used by the jobs (defaults to the controller service)")
 	flags.StringVar(&config.PreloadImage, "preload-image", fmt.Sprintf("ghcr.io/appvia/terranetes-executor:%s", version.Version), "The image to use for the preload")
 	flags.StringVar(&config.TLSAuthority, "tls-ca", "", "The filename to the ca certificate")
 	flags.StringVar(&config.TLSCert, "tls-cert", "tls.pem", "The name of the file containing the TLS certificate")
 	flags.StringVar(&config.TLSDir, "tls-dir", "", "The directory the certificates are held")
 	flags.StringVar(&config.TLSKey, "tls-key", "tls-key.pem", "The name of the file containing the TLS key")
-	flags.StringVar(&config.TerraformImage, "terraform-image", "ghcr.io/opentofu/opentofu:latest", "The image to use for the terraform")
+	flags.StringVar(&config.TerraformImage, "terraform-image", "hashicorp/terraform:latest", "The image to use for the terraform engine")
 	flags.StringSliceVar(&config.NamespaceFilters, "namespace-filter", []string{}, "A list of namespaces to filter on")
+	flags.StringSliceVar(&config.OpenTofuVersions, "opentofu-versions", []string{}, "A collection of opentofu versions available to resolve version constraints against")
+	flags.StringSliceVar(&config.TerraformVersions, "terraform-versions", []string{}, "A collection of terraform versions available to resolve version constraints against")
 
 	crFlags := flag.NewFlagSet("controller-runtime", flag.ContinueOnError)
 	zapOpts.BindFlags(crFlags)
@@ -37,6 +37,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/appvia/terranetes-controller/pkg/utils"
+	"github.com/appvia/terranetes-controller/pkg/utils/cache"
+	"github.com/appvia/terranetes-controller/pkg/utils/integrity"
 	"github.com/appvia/terranetes-controller/pkg/utils/template"
 	"github.com/appvia/terranetes-controller/pkg/version"
 )
@@ -45,22 +47,44 @@ func init() {
 	log.SetFormatter(&log.TextFormatter{})
 }
 
+// detectors is the collection of go-getter detectors used to resolve the source
+var detectors = []getter.Detector{
+	new(getter.GitHubDetector),
+	new(getter.GitLabDetector),
+	new(getter.GitDetector),
+	new(getter.BitBucketDetector),
+	new(getter.GCSDetector),
+	new(getter.S3Detector),
+}
+
 var gitConfig = `
 [url "{{ .Source }}"]
   insteadOf = {{ .Destination }}
 `
 
+// Integrity defines the checks performed on the module source
+type Integrity struct {
+	// AllowList restricts the hosts and schemes the source can be retrieved from
+	AllowList integrity.AllowList
+	// Checksum is the expected checksum of the source, either of the module contents (h1:)
+	// or of the downloaded archive (sha256: etc)
+	Checksum string
+	// SigningKeys is a directory of public keys used to verify the signature of git sources
+	SigningKeys string
+}
+
 func main() {
-	var source, destination string
+	var source, destination, cacheDir string
 	var timeout time.Duration
 	var tmpDirectory bool
+	var checks Integrity
 
 	cmd := &cobra.Command{
 		Use:     "source [options]",
 		Short:   "Used to retrieve the source code for the terraform controller",
 		Version: version.Version,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return Run(context.Background(), source, destination, timeout, tmpDirectory)
+			return Run(context.Background(), source, destination, cacheDir, timeout, tmpDirectory, checks)
 		},
 	}
 
@@ -69,6 +93,11 @@ func main() {
 	flags.StringVarP(&source, "source", "s", "", "Source which needs to be downloaded")
 	flags.StringVarP(&destination, "dest", "d", "", "Directory where the source code to be saved")
 	flags.BoolVar(&tmpDirectory, "tmpdir", true, "Use a temporary directory to download the assets")
+	flags.StringVar(&cacheDir, "cache", "", "A directory used to cache sources pinned to a commit or checksum, consulted before downloading")
+	flags.StringSliceVar(&checks.AllowList.Hosts, "allowed-host", []string{}, "A host the source is permitted to be retrieved from, wildcards are permitted (i.e. *.example.com)")
+	flags.StringSliceVar(&checks.AllowList.Schemes, "allowed-scheme", []string{}, "A scheme or getter the source is permitted to use (i.e. git, s3, https)")
+	flags.StringVar(&checks.Checksum, "checksum", "", "The expected checksum of the module contents (h1:) or downloaded archive (i.e. sha256:)")
+	flags.StringVar(&checks.SigningKeys, "signing-keys", "", "A directory of public keys used to verify the signature of git sources")
 
 	if err := cmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "[error] failed to run: %s", err)
@@ -79,7 +108,7 @@ func main() {
 
 // Run is called to execute the action
 // nolint: gocyclo
-func Run(ctx context.Context, source, destination string, timeout time.Duration, tmpdir bool) error {
+func Run(ctx context.Context, source, destination, cacheDir string, timeout time.Duration, tmpdir bool, checks Integrity) error {
 	if source == "" {
 		return errors.New("no source defined")
 	}
@@ -90,6 +119,26 @@ func Run(ctx context.Context, source, destination string, timeout time.Duration,
 		return errors.New("timeout can not be less than zero")
 	}
 
+	// @step: extract any checksum which must be verified against the module contents, as
+	// go-getter only supports checksums on files
+	source, checksum, err := integrity.ExtractTreeChecksum(source)
+	if err != nil {
+		return fmt.Errorf("failed to parse source url: %w", err)
+	}
+	if checks.Checksum != "" {
+		if err := integrity.ValidateChecksum(checks.Checksum); err != nil {
+			return err
+		}
+		switch {
+		case integrity.IsTreeChecksum(checks.Checksum):
+			checksum = checks.Checksum
+		case strings.Contains(source, "?"):
+			source = fmt.Sprintf("%s&checksum=%s", source, checks.Checksum)
+		default:
+			source = fmt.Sprintf("%s?checksum=%s", source, checks.Checksum)
+		}
+	}
+
 	location := source
 
 	// @step: check for an ssh key in the environment variables and provision a configuration
@@ -172,6 +221,44 @@ func Run(ctx context.Context, source, destination string, timeout time.Duration,
 		"source": source,
 	}).Info("downloading the assets")
 
+	// @step: ensure the source is permitted by the allow list
+	detected, err := getter.Detect(location, pwd, detectors)
+	if err != nil {
+		return fmt.Errorf("failed to detect the source: %w", err)
+	}
+	if err := checks.AllowList.Verify(detected); err != nil {
+		return err
+	}
+
+	// @step: compute the cache key from the source, excluding any credentials
+	var store *cache.Cache
+	var cacheKey string
+	switch {
+	case cacheDir != "" && checks.SigningKeys != "":
+		// the signature is verified against the repository, so a cached copy cannot be trusted
+		log.WithField("source", source).Info("signing keys are configured, skipping the cache")
+
+	case cacheDir != "":
+		resolved := source
+		if strings.HasPrefix(resolved, "https://github.com") {
+			resolved = strings.Replace(resolved, "https://github.com", "git::https://github.com", 1)
+		}
+		if resolved, err = getter.Detect(resolved, pwd, detectors); err == nil {
+			cacheKey, err = cache.Key(resolved)
+		}
+		switch {
+		case err == nil:
+			store = cache.New(cacheDir)
+		case errors.Is(err, cache.ErrNotCacheable):
+			log.WithField("source", source).Info("source is not pinned to a commit or checksum, skipping the cache")
+		default:
+			log.WithError(err).Warn("failed to compute the cache key for the source, skipping the cache")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	// @step: create a temporary directory
 	dest := destination
 	if tmpdir {
@@ -182,24 +269,102 @@ func Run(ctx context.Context, source, destination string, timeout time.Duration,
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+	var cached bool
+	if store != nil {
+		if entry, found := store.Lookup(cacheKey); found {
+			if err := cache.CopyDir(entry, dest); err != nil {
+				return fmt.Errorf("failed to copy the source from the cache: %w", err)
+			}
+			cached = true
+
+			log.WithFields(log.Fields{
+				"key":    cacheKey,
+				"source": source,
+			}).Info("using the cached source")
+		}
+	}
+
+	switch {
+	case cached:
+		break
+
+	case checks.SigningKeys == "":
+		if err := fetch(ctx, location, dest, pwd); err != nil {
+			return err
+		}
+
+	default:
+		// @step: the signature can only be verified against the full repository, so we
+		// retrieve it and copy any subdirectory over ourselves
+		if !strings.HasPrefix(detected, "git::") {
+			return fmt.Errorf("%w: signature verification is only supported for git sources", integrity.ErrVerification)
+		}
+		repository, subdir := getter.SourceDirSubdir(detected)
+
+		var reference string
+		if uri, err := url.Parse(strings.TrimPrefix(repository, "git::")); err == nil {
+			reference = uri.Query().Get("ref")
+		}
+
+		clone := "/tmp/source-repository"
+		if err := os.RemoveAll(clone); err != nil {
+			return fmt.Errorf("failed to remove temporary directory: %w", err)
+		}
+		if err := fetch(ctx, repository, clone, pwd); err != nil {
+			return err
+		}
+		if err := integrity.VerifyGitSignature(ctx, clone, reference, checks.SigningKeys); err != nil {
+			return err
+		}
+		log.WithField("source", source).Info("successfully verified the signature of the source")
+
+		//nolint:gosec
+		if err := exec.Command("cp", []string{"-rT", path.Join(clone, subdir), dest}...).Run(); err != nil {
+			return fmt.Errorf("failed to copy the source: %w", err)
+		}
+	}
+	log.WithField("source", source).Info("successfully downloaded the source")
+
+	// @step: verify the checksum of the module contents, logging the value so it can be pinned
+	if computed, err := integrity.Checksum(dest); err == nil {
+		log.WithField("checksum", computed).Info("computed the checksum of the source")
+	}
+	if checksum != "" {
+		if err := integrity.VerifyChecksum(dest, checksum); err != nil {
+			return err
+		}
+		log.WithField("checksum", checksum).Info("successfully verified the checksum of the source")
+	}
 
+	// @step: store the verified source in the cache for subsequent jobs; the cache may be a
+	// read-only pre-seeded volume, hence failures are not fatal
+	if store != nil && !cached {
+		if err := store.Store(cacheKey, dest); err != nil {
+			log.WithError(err).Warn("failed to store the source in the cache")
+		} else {
+			log.WithField("key", cacheKey).Info("stored the source in the cache")
+		}
+	}
+
+	// @step: if we were using a temporary directory we need to copy the files over
+	if !tmpdir {
+		return nil
+	}
+
+	//nolint:gosec
+	return exec.Command("cp", []string{"-rT", "/tmp/source/", destination}...).Run()
+}
+
+// fetch is responsible for retrieving the source into the destination
+func fetch(ctx context.Context, source, destination, pwd string) error {
 	client := &getter.Client{
-		Ctx: ctx,
-		Dst: dest,
-		Detectors: []getter.Detector{
-			new(getter.GitHubDetector),
-			new(getter.GitLabDetector),
-			new(getter.GitDetector),
-			new(getter.BitBucketDetector),
-			new(getter.GCSDetector),
-			new(getter.S3Detector),
-		},
-		Mode:    getter.ClientModeAny,
-		Options: []getter.ClientOption{},
-		Pwd:     pwd,
-		Src:     location,
+		Ctx:       ctx,
+		Dst:       destination,
+		Detectors: detectors,
+		Mode:      getter.ClientModeAny,
+		Options:   []getter.ClientOption{},
+		Pwd:       pwd,
+		Src:       source,
 	}
 
 	doneCh := make(chan struct{})
@@ -219,38 +384,24 @@ func Run(ctx context.Context, source, destination string, timeout time.Duration,
 	ticker := time.NewTicker(3 * time.Second)
 	defer ticker.Stop()
 
-	err = func() error {
-		for {
-			select {
-			case <-ticker.C:
-				if size, err := utils.DirSize(dest); err == nil {
-					log.WithFields(log.Fields{
-						"bytes": utils.ByteCountSI(size),
-					}).Info("continuing to download the assets")
-				}
-			case <-sigCh:
-				return errors.New("received a signal, cancelling the download")
-			case <-ctx.Done():
-				return errors.New("download has timed out, cancelling the download")
-			case <-doneCh:
-				return nil
-			case err := <-errCh:
-				return fmt.Errorf("failed to download the source: %w", err)
+	for {
+		select {
+		case <-ticker.C:
+			if size, err := utils.DirSize(destination); err == nil {
+				log.WithFields(log.Fields{
+					"bytes": utils.ByteCountSI(size),
+				}).Info("continuing to download the assets")
 			}
+		case <-sigCh:
+			return errors.New("received a signal, cancelling the download")
+		case <-ctx.Done():
+			return errors.New("download has timed out, cancelling the download")
+		case <-doneCh:
+			return nil
+		case err := <-errCh:
+			return fmt.Errorf("failed to download the source: %w", err)
 		}
-	}()
-	if err != nil {
-		return err
-	}
-	log.WithField("source", source).Info("successfully downloaded the source")
-
-	// @step: if we were using a temporary directory we need to copy the files over
-	if !tmpdir {
-		return nil
 	}
-
-	//nolint:gosec
-	return exec.Command("cp", []string{"-rT", "/tmp/source/", destination}...).Run()
 }
 
 // sanitizeSource is responsible for sanitizing the source url
@@ -18,10 +18,23 @@
 package main
 
 import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/appvia/terranetes-controller/pkg/utils/integrity"
 )
 
 func TestSantizeSource(t *testing.T) {
@@ -220,3 +233,90 @@ func TestSetupNetRC(t *testing.T) {
 		})
 	}
 }
+
+// newModuleServer returns a server offering a module archive, with the contents of main.tf
+// read from the content pointer on every request
+func newModuleServer(t *testing.T, content *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gz := gzip.NewWriter(w)
+		tw := tar.NewWriter(gz)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "main.tf", Mode: 0644, Size: int64(len(*content))}))
+		_, err := tw.Write([]byte(*content))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+		require.NoError(t, gz.Close())
+	}))
+}
+
+// archiveChecksum returns the sha256 checksum of the archive offered by the server
+func archiveChecksum(t *testing.T, location string) string {
+	resp, err := http.Get(location) //nolint:gosec,noctx
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, resp.Body)
+	require.NoError(t, err)
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+func TestRunWithCache(t *testing.T) {
+	content := "# original"
+	server := newModuleServer(t, &content)
+	defer server.Close()
+
+	cacheDir := filepath.Join(t.TempDir(), "modules")
+	source := server.URL + "/module.tar.gz"
+	checks := Integrity{Checksum: archiveChecksum(t, source)}
+
+	first := t.TempDir()
+	require.NoError(t, Run(context.Background(), source, first, cacheDir, time.Minute, false, checks))
+
+	entries, err := os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// @note: the second run should be served from the cache rather than the source
+	content = "# changed"
+
+	second := t.TempDir()
+	require.NoError(t, Run(context.Background(), source, second, cacheDir, time.Minute, false, checks))
+
+	data, err := os.ReadFile(filepath.Join(second, "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, "# original", string(data))
+}
+
+func TestRunWithCacheUnpinned(t *testing.T) {
+	content := "# original"
+	server := newModuleServer(t, &content)
+	defer server.Close()
+
+	cacheDir := filepath.Join(t.TempDir(), "modules")
+	source := server.URL + "/module.tar.gz"
+
+	require.NoError(t, Run(context.Background(), source, t.TempDir(), cacheDir, time.Minute, false, Integrity{}))
+
+	// @note: an archive without a checksum can change underneath us, so is never cached
+	_, err := os.Stat(cacheDir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRunWithCacheAndSigningKeys(t *testing.T) {
+	content := "# original"
+	server := newModuleServer(t, &content)
+	defer server.Close()
+
+	cacheDir := filepath.Join(t.TempDir(), "modules")
+	source := server.URL + "/module.tar.gz"
+
+	checks := Integrity{Checksum: archiveChecksum(t, source)}
+	require.NoError(t, Run(context.Background(), source, t.TempDir(), cacheDir, time.Minute, false, checks))
+
+	// @note: the cached copy must not be served when the signature has to be verified
+	checks.SigningKeys = t.TempDir()
+	err := Run(context.Background(), source, t.TempDir(), cacheDir, time.Minute, false, checks)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, integrity.ErrVerification)
+}
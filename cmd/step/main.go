@@ -27,6 +27,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -37,6 +38,7 @@ import (
 
 	"github.com/appvia/terranetes-controller/pkg/utils"
 	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+	"github.com/appvia/terranetes-controller/pkg/utils/steps"
 	"github.com/appvia/terranetes-controller/pkg/version"
 )
 
@@ -64,8 +66,14 @@ func main() {
 	flags := cmd.Flags()
 	flags.DurationVar(&step.Timeout, "timeout", 30*time.Second, "Timeout for wait-on file to appear")
 	flags.StringVar(&step.Comment, "comment", "", "Adds a banner before executing the step")
+	flags.DurationVar(&step.CommandTimeout, "command-timeout", 0, "The maximum duration of each command, zero for no limit")
+	flags.BoolVar(&step.ContinueOnError, "continue-on-error", false, "Indicates a failure of the commands should not fail the step")
 	flags.StringVar(&step.ErrorFile, "on-error", "", "The path to a file to indicate we have failed")
+	flags.StringVar(&step.JobName, "job-name", os.Getenv("JOB_NAME"), "The name of the job the step is running in")
+	flags.StringVar(&step.Name, "name", "", "The name of the step, used when recording the results")
 	flags.StringVar(&step.Namespace, "namespace", os.Getenv("KUBE_NAMESPACE"), "Namespace to upload any secrets")
+	flags.StringVar(&step.ResultsFile, "results", "", "The path of the results file shared by the steps")
+	flags.StringVar(&step.ResultsSecret, "results-secret", "", "The name of the secret to upload the results file to")
 	flags.StringVar(&step.SuccessFile, "on-success", "", "The path of the file used to indicate the step was successful")
 	flags.StringVarP(&step.Shell, "shell", "s", "/bin/sh", "The shell to execute the command in")
 	flags.StringVar(&step.FailureFile, "is-failure", "", "The path of the file used to indicate failure above")
@@ -99,7 +107,7 @@ func Run(ctx context.Context, step Step) error {
 	}
 
 	var cc client.Client
-	if len(step.UploadFile) > 0 {
+	if len(step.UploadFile) > 0 || step.ResultsSecret != "" {
 		ci, err := kubernetes.NewRuntimeClient(nil)
 		if err != nil {
 			return err
@@ -140,12 +148,32 @@ func Run(ctx context.Context, step Step) error {
 		}
 	}
 
+	started := time.Now()
+	result := steps.Result{Name: step.Name}
+
+	// finish records the outcome of the step when a results file is shared by the steps
+	finish := func(err error, stderr string) {
+		if step.ResultsFile == "" {
+			return
+		}
+		result.Duration = time.Since(started).Round(time.Millisecond)
+		if err != nil {
+			result.ExitCode = exitCode(err)
+			result.Stderr = stderr
+		}
+		if err := recordResult(ctx, cc, step, result); err != nil {
+			log.WithError(err).WithField("file", step.ResultsFile).Warn("failed to record the result of the step")
+		}
+	}
+
 	for i, command := range step.Commands {
 		attempt := 0
 		var lastErr error
+		tail := steps.NewTailBuffer(steps.MaxTailSize)
 
 		for attempt <= step.RetryAttempts {
 			if attempt > 0 {
+				result.Retries++
 				backoff := calculateBackoff(step.RetryMinBackoff, step.RetryMaxJitter)
 				log.WithFields(log.Fields{
 					"attempt":       attempt,
@@ -160,40 +188,15 @@ func Run(ctx context.Context, step Step) error {
 				}
 			}
 
-			//nolint:gosec
-			cmd := exec.CommandContext(ctx, step.Shell, "-c", command)
-			cmd.Env = os.Environ()
+			// @note: the tail of stderr is retained for the results of the step
+			tail = steps.NewTailBuffer(steps.MaxTailSize)
 
 			logger := log.WithFields(log.Fields{
 				"command-index": i,
 				"attempt":       attempt,
 			})
 
-			stdout, err := cmd.StdoutPipe()
-			if err != nil {
-				logger.WithError(err).Error("failed to acquire stdout pipe on command")
-				return err
-			}
-			stderr, err := cmd.StderrPipe()
-			if err != nil {
-				logger.WithError(err).Error("failed to acquire stderr pipe on command")
-				return err
-			}
-
-			//nolint:errcheck
-			go io.Copy(os.Stdout, stdout)
-			//nolint:errcheck
-			go io.Copy(os.Stdout, stderr)
-
-			if err := cmd.Start(); err != nil {
-				logger.WithError(err).Error("failed to execute the command")
-				lastErr = err
-				attempt++
-				continue
-			}
-
-			// @step: wait for the command to finish
-			if err := cmd.Wait(); err != nil {
+			if err := execute(ctx, step, command, tail); err != nil {
 				logger.WithError(err).Error("command execution failed")
 				lastErr = err
 				attempt++
@@ -207,6 +210,15 @@ func Run(ctx context.Context, step Step) error {
 
 		// If we exhausted all retries and still have an error
 		if lastErr != nil {
+			if step.ContinueOnError {
+				result.Ignored = true
+				finish(lastErr, tail.String())
+				log.WithError(lastErr).Warn("command failed, continuing as errors are permitted")
+
+				return nil
+			}
+			finish(lastErr, tail.String())
+
 			if step.ErrorFile != "" {
 				if err := utils.TouchFile(step.ErrorFile); err != nil {
 					log.WithError(err).WithField("file", step.ErrorFile).Error("failed to create error file")
@@ -230,9 +242,12 @@ func Run(ctx context.Context, step Step) error {
 			return false, nil
 		})
 		if err != nil {
+			finish(err, fmt.Sprintf("failed to upload secret %s", name))
+
 			return err
 		}
 	}
+	finish(nil, "")
 
 	// @step: everything was good - lets touch the file
 	if step.SuccessFile != "" {
@@ -246,6 +261,48 @@ func Run(ctx context.Context, step Step) error {
 	return nil
 }
 
+// execute runs the command within the shell, failing the command if it exceeds the command timeout
+func execute(ctx context.Context, step Step, command string, stderr io.Writer) error {
+	if step.CommandTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, step.CommandTimeout)
+		defer cancel()
+	}
+
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, step.Shell, "-c", command)
+	cmd.Env = os.Environ()
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = io.MultiWriter(os.Stdout, stderr)
+	// @note: the command is run in its own process group, ensuring any processes spawned by the
+	// shell are also killed when the command is cancelled
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = 10 * time.Second
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("command exceeded the timeout of %s: %w", step.CommandTimeout, err)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// exitCode returns the exit code of the failed command, or -1 when the command could not be run
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	return -1
+}
+
 // uploadSecret is used to create a kubernetes secret from a file
 func uploadSecret(ctx context.Context, cc client.Client, namespace, name, path string) error {
 	if found, err := utils.FileExists(path); err != nil {
@@ -260,6 +317,11 @@ func uploadSecret(ctx context.Context, cc client.Client, namespace, name, path s
 		return err
 	}
 
+	return uploadSecretData(ctx, cc, namespace, name, filepath.Base(path), content)
+}
+
+// uploadSecretData is used to create or update the key within a kubernetes secret
+func uploadSecretData(ctx context.Context, cc client.Client, namespace, name, key string, content []byte) error {
 	secret := &v1.Secret{}
 	secret.Namespace = namespace
 	secret.Name = name
@@ -272,7 +334,7 @@ func uploadSecret(ctx context.Context, cc client.Client, namespace, name, path s
 	if secret.Data == nil {
 		secret.Data = make(map[string][]byte)
 	}
-	secret.Data[filepath.Base(path)] = content
+	secret.Data[key] = content
 
 	// @step: create or update the secret
 	if !found {
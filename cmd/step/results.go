@@ -0,0 +1,88 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/appvia/terranetes-controller/pkg/utils"
+	"github.com/appvia/terranetes-controller/pkg/utils/steps"
+)
+
+// recordResult appends the result of the step to the results file shared by the steps
+// within the job, and optionally uploads the results as a secret. The file is locked
+// throughout, as the steps of a job run concurrently, ensuring the last upload contains
+// the results of all steps
+func recordResult(ctx context.Context, cc client.Client, step Step, result steps.Result) error {
+	file, err := os.OpenFile(step.ResultsFile, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	//nolint:errcheck
+	defer syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	results, err := steps.Decode(content)
+	if err != nil {
+		return err
+	}
+	if results.Job == "" {
+		results.Job = step.JobName
+	}
+	results.Steps = append(results.Steps, result)
+
+	encoded, err := results.Encode()
+	if err != nil {
+		return err
+	}
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := file.WriteAt(encoded, 0); err != nil {
+		return err
+	}
+
+	if step.ResultsSecret == "" {
+		return nil
+	}
+
+	return utils.Retry(ctx, 2, true, 5*time.Second, func() (bool, error) {
+		err := uploadSecretData(ctx, cc, step.Namespace, step.ResultsSecret, steps.ResultsKey, encoded)
+		if err == nil {
+			return true, nil
+		}
+		log.WithError(err).WithField("secret", step.ResultsSecret).Error("failed to upload the step results")
+
+		return false, nil
+	})
+}
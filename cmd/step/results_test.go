@@ -0,0 +1,126 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/appvia/terranetes-controller/pkg/utils/steps"
+)
+
+func TestRecordResult(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	step := Step{JobName: "test-plan-1234", Name: "terraform", ResultsFile: path}
+
+	require.NoError(t, recordResult(context.Background(), nil, step, steps.Result{Name: "setup", Duration: time.Second}))
+	require.NoError(t, recordResult(context.Background(), nil, step, steps.Result{Name: "terraform", ExitCode: 1, Stderr: "Error"}))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	results, err := steps.Decode(content)
+	require.NoError(t, err)
+
+	assert.Equal(t, "test-plan-1234", results.Job)
+	assert.Equal(t, []steps.Result{
+		{Name: "setup", Duration: time.Second},
+		{Name: "terraform", ExitCode: 1, Stderr: "Error"},
+	}, results.Steps)
+}
+
+func TestRunRecordsFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	step := Step{
+		Commands:    []string{"echo first", "echo boom >&2; exit 3"},
+		Name:        "terraform",
+		ResultsFile: path,
+		Shell:       "/bin/sh",
+	}
+
+	err := Run(context.Background(), step)
+	require.Error(t, err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	results, err := steps.Decode(content)
+	require.NoError(t, err)
+
+	require.Len(t, results.Steps, 1)
+	assert.Equal(t, "terraform", results.Steps[0].Name)
+	assert.Equal(t, 3, results.Steps[0].ExitCode)
+	assert.Equal(t, "boom\n", results.Steps[0].Stderr)
+}
+
+func TestIsValidResults(t *testing.T) {
+	step := Step{Commands: []string{"true"}, ResultsFile: "/tmp/results.json"}
+	assert.Error(t, step.IsValid())
+
+	step.Name = "terraform"
+	assert.NoError(t, step.IsValid())
+
+	step.ResultsSecret = "steps"
+	assert.Error(t, step.IsValid())
+
+	step.Namespace = "terraform-system"
+	assert.NoError(t, step.IsValid())
+}
+
+func TestRunContinueOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.json")
+	errorFile := filepath.Join(t.TempDir(), "failed")
+	step := Step{
+		Commands:        []string{"exit 1"},
+		ContinueOnError: true,
+		ErrorFile:       errorFile,
+		Name:            "hook-lint",
+		ResultsFile:     path,
+		Shell:           "/bin/sh",
+	}
+	require.NoError(t, Run(context.Background(), step))
+
+	_, err := os.Stat(errorFile)
+	assert.True(t, os.IsNotExist(err))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	results, err := steps.Decode(content)
+	require.NoError(t, err)
+	require.Len(t, results.Steps, 1)
+	assert.Equal(t, 1, results.Steps[0].ExitCode)
+	assert.True(t, results.Steps[0].Ignored)
+}
+
+func TestRunCommandTimeout(t *testing.T) {
+	step := Step{
+		CommandTimeout: 100 * time.Millisecond,
+		Commands:       []string{"sleep 5"},
+		Shell:          "/bin/sh",
+	}
+
+	started := time.Now()
+	err := Run(context.Background(), step)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "command exceeded the timeout of 100ms")
+	assert.Less(t, time.Since(started), 5*time.Second)
+}
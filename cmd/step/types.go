@@ -36,12 +36,24 @@ type Step struct {
 	RetryAttempts int
 	// Comment adds a banner to the stage
 	Comment string
+	// CommandTimeout is the maximum duration of each command, zero for no limit
+	CommandTimeout time.Duration
+	// ContinueOnError indicates a failure of the commands should not fail the step
+	ContinueOnError bool
 	// ErrorFile is the path to a file which is created when the command failed
 	ErrorFile string
 	// FailureFile is the path to a file indicating failure
 	FailureFile string
+	// JobName is the name of the job the step is running within
+	JobName string
+	// Name is the name of the step, used when recording the results
+	Name string
 	// Namespace is the namespace to upload any files to as a secret
 	Namespace string
+	// ResultsFile is the path to the results file shared by the steps within the job
+	ResultsFile string
+	// ResultsSecret is the name of the secret to upload the results file to
+	ResultsSecret string
 	// Shell is the shell to execute the command in
 	Shell string
 	// SuccessFile is the path to a file which is created when the command ran successfully
@@ -63,6 +75,9 @@ func (s Step) IsValid() error {
 	case s.Timeout < 0:
 		return errors.New("timeout must be greater than 0")
 
+	case s.CommandTimeout < 0:
+		return errors.New("command timeout must be greater than or equal to 0")
+
 	case s.RetryAttempts < 0:
 		return errors.New("retry attempts must be greater than or equal to 0")
 
@@ -72,6 +87,15 @@ func (s Step) IsValid() error {
 	case s.RetryAttempts > 0 && s.RetryMaxJitter < 0:
 		return errors.New("maximum jitter must be greater than or equal to 0")
 
+	case s.ResultsFile != "" && s.Name == "":
+		return errors.New("name must be specified when recording results")
+
+	case s.ResultsSecret != "" && s.ResultsFile == "":
+		return errors.New("results file must be specified when uploading results")
+
+	case s.ResultsSecret != "" && s.Namespace == "":
+		return errors.New("namespace must be specified when uploading results")
+
 	case len(s.UploadFile) > 0 && s.Namespace == "":
 		return errors.New("namespace must be specified when uploading files")
 
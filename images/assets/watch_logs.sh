@@ -23,6 +23,7 @@ LOGFILE="/tmp/build.log"
 DELAY=10
 MAX_RETRIES=15
 LOGS_FETCHED=false
+TOKEN_FILE="/var/run/secrets/kubernetes.io/serviceaccount/token"
 FLAG_ENDPOINT=""
 FLAG_LOGFILE="${LOGFILE}"
 FLAG_DELAY=${DELAY}
@@ -60,7 +61,13 @@ stream_logs() {
     echo "[info] Waiting ${FLAG_DELAY} seconds for pod logs to be available (attempt ${i}/${FLAG_MAX_RETRIES}).."
     sleep ${FLAG_DELAY}
 
-    if curl --no-buffer --silent "${FLAG_ENDPOINT}" | tee ${FLAG_LOGFILE}; then
+    CURL_ARGS=(--no-buffer --silent)
+    # The service account token is used when the api server has authentication enabled
+    if [[ -f "${TOKEN_FILE}" ]]; then
+      CURL_ARGS+=(--header "Authorization: Bearer $(cat ${TOKEN_FILE})")
+    fi
+
+    if curl "${CURL_ARGS[@]}" "${FLAG_ENDPOINT}" | tee ${FLAG_LOGFILE}; then
       if grep "failed to retrieve the logs" "${FLAG_LOGFILE}"; then
         echo "[info] Terranetes Controller was unable to retrieve logs, the Job Pod may not be available yet."
         i=$((i + 1))
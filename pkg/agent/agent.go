@@ -0,0 +1,398 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	k8sclient "k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/utils"
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
+	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+	"github.com/appvia/terranetes-controller/pkg/version"
+)
+
+// Options are the options for the agent
+type Options struct {
+	// Interval is the interval between pulling assignments and reporting on the jobs
+	Interval time.Duration
+	// Name is the name of the agent, referenced by the providers
+	Name string
+	// Providers is the collection of providers the agent runs the jobs for
+	Providers []string
+}
+
+// Agent runs the jobs assigned by the controller within the cluster the agent is running in,
+// reporting the status, logs and outputs of the jobs back to the controller
+type Agent struct {
+	// broker is the controller the agent pulls the assignments from
+	broker Interface
+	// cc is the kubernetes client to the cluster the agent is running in
+	cc client.Client
+	// kc is the kubernetes clientset used to retrieve the logs of the jobs
+	kc k8sclient.Interface
+	// options are the options for the agent
+	options Options
+	// registered indicates the agent has registered with the controller
+	registered bool
+}
+
+// New returns an agent for the controller
+func New(cc client.Client, kc k8sclient.Interface, broker Interface, options Options) (*Agent, error) {
+	switch {
+	case cc == nil, kc == nil:
+		return nil, errors.New("kubernetes client is required")
+	case broker == nil:
+		return nil, errors.New("controller client is required")
+	case len(options.Providers) == 0:
+		return nil, errors.New("agent must serve at least one provider")
+	}
+	if errs := validation.IsDNS1123Label(options.Name); len(errs) > 0 {
+		return nil, fmt.Errorf("agent name is invalid, %s", strings.Join(errs, ", "))
+	}
+	if options.Interval <= 0 {
+		options.Interval = 10 * time.Second
+	}
+
+	return &Agent{broker: broker, cc: cc, kc: kc, options: options}, nil
+}
+
+// Run processes the assignments until the context is cancelled
+func (a *Agent) Run(ctx context.Context) error {
+	log.WithFields(log.Fields{
+		"name":      a.options.Name,
+		"providers": strings.Join(a.options.Providers, ","),
+	}).Info("starting the terranetes agent")
+
+	ticker := time.NewTicker(a.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := a.Sync(ctx); err != nil {
+			log.WithError(err).Error("failed to synchronize with the controller")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync registers the agent if required, starts any new assignments and reports on the jobs
+// running within the cluster
+func (a *Agent) Sync(ctx context.Context) error {
+	if !a.registered {
+		if err := a.broker.Register(ctx, Registration{
+			Name:      a.options.Name,
+			Providers: a.options.Providers,
+			Version:   version.GetVersion(),
+		}); err != nil {
+			return fmt.Errorf("failed to register with the controller, %w", err)
+		}
+		a.registered = true
+	}
+
+	assignments, err := a.broker.Assignments(ctx, a.options.Name)
+	if err != nil {
+		// @note: the controller has restarted and forgotten about us
+		if errors.Is(err, ErrNotRegistered) {
+			a.registered = false
+		}
+
+		return fmt.Errorf("failed to retrieve the assignments, %w", err)
+	}
+
+	for _, assignment := range assignments {
+		if err := a.start(ctx, assignment); err != nil {
+			return err
+		}
+	}
+
+	return a.report(ctx)
+}
+
+// start creates the job and the secrets it depends on within the cluster
+func (a *Agent) start(ctx context.Context, assignment Assignment) error {
+	if assignment.Job == nil {
+		return nil
+	}
+	logger := log.WithFields(log.Fields{
+		"job":       assignment.Job.Name,
+		"namespace": assignment.Job.Namespace,
+	})
+
+	job := newLocalJob(assignment.Job)
+	if found, err := kubernetes.GetIfExists(ctx, a.cc, job.DeepCopy()); err != nil {
+		return err
+	} else if found {
+		return nil
+	}
+
+	for _, x := range assignment.Secrets {
+		secret := &v1.Secret{}
+		secret.Namespace = job.Namespace
+		secret.Name = x.Name
+		secret.Annotations = x.Annotations
+		secret.Labels = utils.MergeStringMaps(x.Labels, map[string]string{
+			terraformv1alpha1.JobAgentAssignmentLabel: job.Name,
+		})
+		secret.Data = x.Data
+		secret.Type = x.Type
+
+		if err := kubernetes.CreateOrForceUpdate(ctx, a.cc, secret); err != nil {
+			if kerrors.IsNotFound(err) {
+				return a.reject(ctx, job, "namespace does not exist within the agent cluster")
+			}
+
+			return fmt.Errorf("failed to create the secret %q for the job, %w", x.Name, err)
+		}
+	}
+
+	if err := a.cc.Create(ctx, job); err != nil {
+		if kerrors.IsNotFound(err) {
+			return a.reject(ctx, job, "namespace does not exist within the agent cluster")
+		}
+
+		return fmt.Errorf("failed to create the job, %w", err)
+	}
+	logger.Info("started the job assigned by the controller")
+
+	return nil
+}
+
+// reject reports the assignment as failed without running it
+func (a *Agent) reject(ctx context.Context, job *batchv1.Job, message string) error {
+	log.WithFields(log.Fields{
+		"job":       job.Name,
+		"namespace": job.Namespace,
+	}).Warn("unable to run the job assigned by the controller, " + message)
+
+	return a.broker.Report(ctx, a.options.Name, Report{
+		Message:   message,
+		Name:      job.Name,
+		Namespace: job.Namespace,
+		Phase:     PhaseFailed,
+	})
+}
+
+// report reports the status, logs and outputs of the jobs back to the controller, removing
+// any finished jobs once reported
+func (a *Agent) report(ctx context.Context) error {
+	list := &batchv1.JobList{}
+	if err := a.cc.List(ctx, list, client.HasLabels{terraformv1alpha1.JobAgentAssignmentLabel}); err != nil {
+		return fmt.Errorf("failed to list the jobs, %w", err)
+	}
+
+	for i := 0; i < len(list.Items); i++ {
+		job := &list.Items[i]
+		logger := log.WithFields(log.Fields{
+			"job":       job.Name,
+			"namespace": job.Namespace,
+		})
+
+		report := Report{
+			Logs:      a.logs(ctx, job),
+			Name:      job.Name,
+			Namespace: job.Namespace,
+			Phase:     PhaseRunning,
+		}
+		switch {
+		case jobs.IsComplete(job):
+			report.Phase = PhaseSucceeded
+		case jobs.IsFailed(job):
+			report.Phase = PhaseFailed
+		}
+
+		if report.Phase.IsFinished() {
+			for _, name := range outputSecrets(job) {
+				secret, found, err := kubernetes.GetSecretIfExists(ctx, a.cc, job.Namespace, name)
+				if err != nil {
+					return err
+				}
+				if !found {
+					continue
+				}
+				report.Secrets = append(report.Secrets, v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: secret.Annotations,
+						Labels:      secret.Labels,
+						Name:        secret.Name,
+					},
+					Data: secret.Data,
+					Type: secret.Type,
+				})
+			}
+		}
+
+		err := a.broker.Report(ctx, a.options.Name, report)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			logger.Warn("job no longer exists within the controller, removing the job")
+		case err != nil:
+			logger.WithError(err).Error("failed to report on the job")
+
+			continue
+		case !report.Phase.IsFinished():
+			continue
+		}
+
+		if err := a.cleanup(ctx, job); err != nil {
+			logger.WithError(err).Error("failed to remove the job")
+		}
+	}
+
+	return nil
+}
+
+// cleanup removes the job, the secrets it depended on and its outputs, leaving any secrets
+// still required by other jobs
+func (a *Agent) cleanup(ctx context.Context, job *batchv1.Job) error {
+	list := &batchv1.JobList{}
+	if err := a.cc.List(ctx, list,
+		client.InNamespace(job.Namespace),
+		client.HasLabels{terraformv1alpha1.JobAgentAssignmentLabel},
+	); err != nil {
+		return err
+	}
+
+	var required []string
+	for i := 0; i < len(list.Items); i++ {
+		if list.Items[i].Name == job.Name {
+			continue
+		}
+		required = append(required, referencedSecrets(&list.Items[i].Spec.Template.Spec)...)
+		required = append(required, terraformStateSecret(&list.Items[i]))
+	}
+
+	secrets := &v1.SecretList{}
+	if err := a.cc.List(ctx, secrets,
+		client.InNamespace(job.Namespace),
+		client.MatchingLabels{terraformv1alpha1.JobAgentAssignmentLabel: job.Name},
+	); err != nil {
+		return err
+	}
+
+	names := outputSecrets(job)
+	for _, secret := range secrets.Items {
+		names = append(names, secret.Name)
+	}
+
+	for _, name := range utils.Unique(names) {
+		if utils.Contains(name, required) {
+			continue
+		}
+
+		secret := &v1.Secret{}
+		secret.Namespace = job.Namespace
+		secret.Name = name
+
+		if err := kubernetes.DeleteIfExists(ctx, a.cc, secret); err != nil {
+			return err
+		}
+	}
+
+	return a.cc.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+}
+
+// logs returns the logs of the latest pod for the job, any failure to retrieve the logs is
+// logged and the logs retrieved so far are returned
+func (a *Agent) logs(ctx context.Context, job *batchv1.Job) []byte {
+	pods, err := a.kc.CoreV1().Pods(job.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "job-name=" + job.Name,
+	})
+	if err != nil {
+		log.WithError(err).WithField("job", job.Name).Error("failed to list the pods for the job")
+
+		return nil
+	}
+	pod := kubernetes.FindLatestPod(pods)
+	if pod == nil {
+		return nil
+	}
+
+	logs := &bytes.Buffer{}
+	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		stream, err := a.kc.CoreV1().Pods(job.Namespace).GetLogs(pod.Name, &v1.PodLogOptions{
+			Container: container.Name,
+		}).Stream(ctx)
+		if err != nil {
+			// @note: the container has most likely not started yet
+			break
+		}
+		_, err = io.Copy(logs, stream)
+		stream.Close()
+		if err != nil {
+			log.WithError(err).WithField("container", container.Name).Error("failed to read the logs of the job")
+
+			break
+		}
+	}
+
+	return logs.Bytes()
+}
+
+// newLocalJob returns the job as it should be run within the agent cluster, removing the fields
+// which prevent it from running or are specific to the controller cluster
+func newLocalJob(assigned *batchv1.Job) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: utils.MergeStringMaps(assigned.Annotations),
+			Labels: utils.MergeStringMaps(assigned.Labels, map[string]string{
+				terraformv1alpha1.JobAgentAssignmentLabel: assigned.Name,
+			}),
+			Name:      assigned.Name,
+			Namespace: assigned.Namespace,
+		},
+		Spec: *assigned.Spec.DeepCopy(),
+	}
+	delete(job.Annotations, terraformv1alpha1.JobAgentHeartbeatAnnotation)
+
+	job.Spec.ManagedBy = nil
+	job.Spec.ManualSelector = nil
+	job.Spec.Selector = nil
+	job.Spec.Suspend = nil
+
+	// @step: remove the labels added by the job controller within the controller cluster
+	for _, label := range []string{
+		"batch.kubernetes.io/controller-uid",
+		"batch.kubernetes.io/job-name",
+		"controller-uid",
+		"job-name",
+	} {
+		delete(job.Spec.Template.Labels, label)
+	}
+
+	return job
+}
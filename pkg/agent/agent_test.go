@@ -0,0 +1,183 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/schema"
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
+)
+
+func TestNew(t *testing.T) {
+	cc := fake.NewClientBuilder().WithScheme(schema.GetScheme()).Build()
+	kc := kfake.NewSimpleClientset()
+	_, b := newTestBroker()
+
+	_, err := New(nil, kc, b, Options{Name: "remote", Providers: []string{"aws"}})
+	assert.Error(t, err)
+	_, err = New(cc, kc, nil, Options{Name: "remote", Providers: []string{"aws"}})
+	assert.Error(t, err)
+	_, err = New(cc, kc, b, Options{Name: "remote"})
+	assert.Error(t, err)
+	_, err = New(cc, kc, b, Options{Name: "Not_Valid", Providers: []string{"aws"}})
+	assert.Error(t, err)
+
+	a, err := New(cc, kc, b, Options{Name: "remote", Providers: []string{"aws"}})
+	require.NoError(t, err)
+	assert.NotZero(t, a.options.Interval)
+}
+
+func TestAgentSync(t *testing.T) {
+	ctx := context.Background()
+	key := client.ObjectKey{Namespace: "terraform-system", Name: "plan"}
+
+	central, b := newTestBroker(
+		newTestJob("plan", "remote", "aws"),
+		newTestJob("apply", "remote", "aws"),
+		newTestSecret("terraform-system", "aws"),
+		newTestSecret("terraform-system", "config-"+testUID),
+	)
+	local := fake.NewClientBuilder().WithScheme(schema.GetScheme()).Build()
+	kc := kfake.NewSimpleClientset(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "plan-pod",
+			Namespace: "terraform-system",
+			Labels:    map[string]string{"job-name": "plan"},
+		},
+		Spec: v1.PodSpec{Containers: []v1.Container{{Name: "terraform"}}},
+	})
+
+	a, err := New(local, kc, b, Options{Name: "remote", Providers: []string{"aws"}})
+	require.NoError(t, err)
+	require.NoError(t, a.Sync(ctx))
+	assert.True(t, a.registered)
+
+	// @step: the jobs should be running within the agent cluster
+	job := &batchv1.Job{}
+	require.NoError(t, local.Get(ctx, key, job))
+	assert.Nil(t, job.Spec.ManagedBy)
+	assert.Nil(t, job.Spec.Suspend)
+	assert.Nil(t, job.Spec.Selector)
+	assert.Equal(t, "plan", job.Labels[terraformv1alpha1.JobAgentAssignmentLabel])
+	assert.NotContains(t, job.Spec.Template.Labels, "controller-uid")
+	assert.NotContains(t, job.Spec.Template.Labels, "job-name")
+	require.NoError(t, local.Get(ctx, client.ObjectKey{Namespace: "terraform-system", Name: "apply"}, &batchv1.Job{}))
+
+	for _, name := range []string{"aws", "config-" + testUID} {
+		secret := &v1.Secret{}
+		require.NoError(t, local.Get(ctx, client.ObjectKey{Namespace: "terraform-system", Name: name}, secret))
+		assert.Equal(t, name, string(secret.Data["key"]))
+		assert.NotEmpty(t, secret.Labels[terraformv1alpha1.JobAgentAssignmentLabel])
+	}
+
+	// @step: the logs of the running job should have been reported
+	logs := &v1.Secret{}
+	require.NoError(t, central.Get(ctx, client.ObjectKey{Namespace: "terraform-system", Name: LogsSecretName("plan")}, logs))
+	assert.Equal(t, "fake logs", string(logs.Data[LogsSecretKey]))
+
+	// @step: complete the job within the agent cluster
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+	require.NoError(t, local.Status().Update(ctx, job))
+	require.NoError(t, local.Create(ctx, newTestSecret("terraform-system", "tfplan-json-"+testUID)))
+
+	require.NoError(t, a.Sync(ctx))
+
+	assigned := &batchv1.Job{}
+	require.NoError(t, central.Get(ctx, key, assigned))
+	assert.True(t, jobs.IsComplete(assigned))
+	require.NoError(t, central.Get(ctx, client.ObjectKey{Namespace: "terraform-system", Name: "tfplan-json-" + testUID}, &v1.Secret{}))
+
+	// @step: the job and its outputs should have been removed from the agent cluster, leaving
+	// the secrets still required by the apply
+	assert.Error(t, local.Get(ctx, key, &batchv1.Job{}))
+	assert.Error(t, local.Get(ctx, client.ObjectKey{Namespace: "terraform-system", Name: "tfplan-json-" + testUID}, &v1.Secret{}))
+	assert.NoError(t, local.Get(ctx, client.ObjectKey{Namespace: "terraform-system", Name: "aws"}, &v1.Secret{}))
+	assert.NoError(t, local.Get(ctx, client.ObjectKey{Namespace: "terraform-system", Name: "config-" + testUID}, &v1.Secret{}))
+}
+
+func TestAgentSyncJobRemoved(t *testing.T) {
+	ctx := context.Background()
+
+	central, b := newTestBroker(newTestJob("plan", "remote", "aws"))
+	local := fake.NewClientBuilder().WithScheme(schema.GetScheme()).Build()
+
+	a, err := New(local, kfake.NewSimpleClientset(), b, Options{Name: "remote", Providers: []string{"aws"}})
+	require.NoError(t, err)
+	require.NoError(t, a.Sync(ctx))
+	require.NoError(t, local.Get(ctx, client.ObjectKey{Namespace: "terraform-system", Name: "plan"}, &batchv1.Job{}))
+
+	// @step: the job is removed from the controller, the agent should remove its copy
+	require.NoError(t, central.Delete(ctx, newTestJob("plan", "remote", "aws")))
+	require.NoError(t, a.Sync(ctx))
+	assert.Error(t, local.Get(ctx, client.ObjectKey{Namespace: "terraform-system", Name: "plan"}, &batchv1.Job{}))
+}
+
+func TestAgentSyncReregisters(t *testing.T) {
+	ctx := context.Background()
+	central, b := newTestBroker()
+	local := fake.NewClientBuilder().WithScheme(schema.GetScheme()).Build()
+
+	a, err := New(local, kfake.NewSimpleClientset(), b, Options{Name: "remote", Providers: []string{"aws"}})
+	require.NoError(t, err)
+	require.NoError(t, a.Sync(ctx))
+
+	// @step: the registration of the agent has been removed
+	lease := &coordinationv1.Lease{}
+	lease.Namespace = "terraform-system"
+	lease.Name = RegistrationLeaseName("remote")
+	require.NoError(t, central.Delete(ctx, lease))
+	assert.ErrorIs(t, a.Sync(ctx), ErrNotRegistered)
+	assert.False(t, a.registered)
+	assert.NoError(t, a.Sync(ctx))
+}
+
+func TestFakeSync(t *testing.T) {
+	ctx := context.Background()
+	central, b := newTestBroker(newTestJob("plan", "remote", "aws"))
+
+	agent := &Fake{
+		Broker: b,
+		Logs:   []byte("terraform has failed"),
+		Name:   "remote",
+		Outputs: func(job *batchv1.Job) []v1.Secret {
+			return []v1.Secret{*newTestSecret("", "steps-"+job.Labels[terraformv1alpha1.ConfigurationUIDLabel])}
+		},
+		Phase:     PhaseFailed,
+		Providers: []string{"aws"},
+	}
+	require.NoError(t, agent.Sync(ctx))
+	require.Len(t, agent.Assignments, 1)
+
+	job := &batchv1.Job{}
+	require.NoError(t, central.Get(ctx, client.ObjectKey{Namespace: "terraform-system", Name: "plan"}, job))
+	assert.True(t, jobs.IsFailed(job))
+	require.NoError(t, central.Get(ctx, client.ObjectKey{Namespace: "terraform-system", Name: "steps-" + testUID}, &v1.Secret{}))
+}
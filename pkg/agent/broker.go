@@ -0,0 +1,467 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/utils"
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
+	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+)
+
+// DefaultHeartbeatTimeout is the duration after which a job claimed by an agent, which has
+// not been reported on, is offered again
+const DefaultHeartbeatTimeout = 5 * time.Minute
+
+// LogsSecretKey is the key within the logs secret holding the logs of the job
+const LogsSecretKey = "logs"
+
+// MaxLogsSize is the maximum size of the logs retained for a job, only the tail is kept
+const MaxLogsSize = 512 * 1024
+
+// LogsSecretName returns the name of the secret holding the logs reported for the job
+func LogsSecretName(job string) string {
+	return job + "-logs"
+}
+
+// RegistrationLeaseName returns the name of the lease holding the registration of the agent
+func RegistrationLeaseName(agent string) string {
+	return "agent-" + agent
+}
+
+// IsAgentJob returns true if the job is run by a remote agent
+func IsAgentJob(job *batchv1.Job) bool {
+	return job.GetLabels()[terraformv1alpha1.JobAgentLabel] != ""
+}
+
+type broker struct {
+	// cc is the kubernetes client to the cluster the controller is running in
+	cc client.Client
+	// namespace is the namespace the registrations of the agents are held in
+	namespace string
+	// timeout is the duration before a job claimed by an agent is offered again
+	timeout time.Duration
+}
+
+// NewBroker returns the controller side of the agents, handing out the jobs to the agents and
+// recording the progress they report against the jobs. The registrations of the agents are
+// persisted as leases within the namespace, so survive a restart of the controller
+func NewBroker(cc client.Client, namespace string) Interface {
+	return &broker{
+		cc:        cc,
+		namespace: namespace,
+		timeout:   DefaultHeartbeatTimeout,
+	}
+}
+
+// Register is called by an agent to register with the controller
+func (b *broker) Register(ctx context.Context, registration Registration) error {
+	if errs := validation.IsDNS1123Label(registration.Name); len(errs) > 0 {
+		return fmt.Errorf("agent name is invalid, %s", strings.Join(errs, ", "))
+	}
+	if len(registration.Providers) == 0 {
+		return errors.New("agent must serve at least one provider")
+	}
+
+	// @step: the agent is only permitted to serve the providers which reference it
+	for _, name := range registration.Providers {
+		provider := &terraformv1alpha1.Provider{}
+		provider.Name = name
+
+		found, err := kubernetes.GetIfExists(ctx, b.cc, provider)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("%w: provider %q does not exist", ErrNotPermitted, name)
+		}
+		if provider.Spec.Agent != registration.Name {
+			return fmt.Errorf("%w: provider %q is not served by the agent", ErrNotPermitted, name)
+		}
+	}
+
+	now := metav1.NewMicroTime(time.Now())
+
+	lease := &coordinationv1.Lease{}
+	lease.Namespace = b.namespace
+	lease.Name = RegistrationLeaseName(registration.Name)
+	lease.Labels = map[string]string{terraformv1alpha1.JobAgentLabel: registration.Name}
+	lease.Annotations = map[string]string{
+		terraformv1alpha1.AgentProvidersAnnotation: strings.Join(registration.Providers, ","),
+		terraformv1alpha1.AgentVersionAnnotation:   registration.Version,
+	}
+	lease.Spec.HolderIdentity = &registration.Name
+	lease.Spec.RenewTime = &now
+
+	if err := kubernetes.CreateOrForceUpdate(ctx, b.cc, lease); err != nil {
+		return fmt.Errorf("failed to persist the agent registration, %w", err)
+	}
+
+	log.WithFields(log.Fields{
+		"agent":     registration.Name,
+		"providers": strings.Join(registration.Providers, ","),
+		"version":   registration.Version,
+	}).Info("agent has registered with the controller")
+
+	return nil
+}
+
+// Assignments returns the jobs assigned to the agent, any job handed out is claimed by the agent
+// until the heartbeat expires
+func (b *broker) Assignments(ctx context.Context, agent string) ([]Assignment, error) {
+	registration, found, err := b.registration(ctx, agent)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotRegistered
+	}
+
+	list := &batchv1.JobList{}
+	if err := b.cc.List(ctx, list, client.MatchingLabels{terraformv1alpha1.JobAgentLabel: agent}); err != nil {
+		return nil, err
+	}
+
+	var assignments []Assignment
+	for i := 0; i < len(list.Items); i++ {
+		job := &list.Items[i]
+
+		switch {
+		case !jobs.IsActive(job):
+			continue
+		case !utils.Contains(job.Labels[terraformv1alpha1.JobProviderLabel], registration.Providers):
+			continue
+		case b.isClaimed(job):
+			continue
+		}
+
+		assignment, err := b.newAssignment(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+		if err := b.heartbeat(ctx, job); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, *assignment)
+	}
+
+	return assignments, nil
+}
+
+// Report is called by an agent to report on the progress of an assignment
+func (b *broker) Report(ctx context.Context, agent string, report Report) error {
+	if _, found, err := b.registration(ctx, agent); err != nil {
+		return err
+	} else if !found {
+		return ErrNotRegistered
+	}
+
+	job := &batchv1.Job{}
+	job.Namespace = report.Namespace
+	job.Name = report.Name
+
+	found, err := kubernetes.GetIfExists(ctx, b.cc, job)
+	if err != nil {
+		return err
+	}
+	if !found || job.Labels[terraformv1alpha1.JobAgentLabel] != agent {
+		return ErrNotFound
+	}
+
+	// @step: the job has already finished, we ignore any duplicate reports
+	if !jobs.IsActive(job) {
+		return nil
+	}
+
+	if err := b.heartbeat(ctx, job); err != nil {
+		return err
+	}
+	if err := b.saveLogs(ctx, job, report.Logs); err != nil {
+		return err
+	}
+	if !report.Phase.IsFinished() {
+		return nil
+	}
+
+	// @step: only the outputs of the job are accepted, the agent is not permitted to write
+	// any other secrets
+	outputs := outputSecrets(job)
+	for _, secret := range report.Secrets {
+		if !utils.Contains(secret.Name, outputs) {
+			log.WithFields(log.Fields{
+				"agent":  agent,
+				"job":    job.Name,
+				"secret": secret.Name,
+			}).Warn("agent returned a secret which is not an output of the job, ignoring")
+
+			continue
+		}
+
+		output := &v1.Secret{}
+		output.Namespace = job.Namespace
+		output.Name = secret.Name
+		output.Labels = secret.Labels
+		output.Annotations = secret.Annotations
+		output.Data = secret.Data
+		output.Type = secret.Type
+
+		if err := kubernetes.CreateOrForceUpdate(ctx, b.cc, output); err != nil {
+			return fmt.Errorf("failed to save the output secret %q, %w", secret.Name, err)
+		}
+	}
+
+	return b.finish(ctx, job, report)
+}
+
+// finish updates the status of the job to reflect the outcome reported by the agent
+func (b *broker) finish(ctx context.Context, job *batchv1.Job, report Report) error {
+	now := metav1.Now()
+	original := job.DeepCopy()
+
+	if job.Status.StartTime == nil {
+		job.Status.StartTime = &now
+	}
+
+	switch report.Phase {
+	case PhaseSucceeded:
+		job.Status.CompletionTime = &now
+		job.Status.Succeeded = 1
+		job.Status.Conditions = append(job.Status.Conditions,
+			newJobCondition(batchv1.JobSuccessCriteriaMet, batchv1.JobReasonCompletionsReached, report.Message, now),
+			newJobCondition(batchv1.JobComplete, batchv1.JobReasonCompletionsReached, report.Message, now),
+		)
+	default:
+		job.Status.Failed = 1
+		job.Status.Conditions = append(job.Status.Conditions,
+			newJobCondition(batchv1.JobFailureTarget, batchv1.JobReasonBackoffLimitExceeded, report.Message, now),
+			newJobCondition(batchv1.JobFailed, batchv1.JobReasonBackoffLimitExceeded, report.Message, now),
+		)
+	}
+
+	return b.cc.Status().Patch(ctx, job, client.MergeFrom(original))
+}
+
+// saveLogs persists the logs reported by the agent alongside the job
+func (b *broker) saveLogs(ctx context.Context, job *batchv1.Job, logs []byte) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	if len(logs) > MaxLogsSize {
+		logs = logs[len(logs)-MaxLogsSize:]
+	}
+
+	secret := &v1.Secret{}
+	secret.Namespace = job.Namespace
+	secret.Name = LogsSecretName(job.Name)
+	secret.Labels = map[string]string{
+		terraformv1alpha1.ConfigurationUIDLabel: job.Labels[terraformv1alpha1.ConfigurationUIDLabel],
+		terraformv1alpha1.JobAgentLabel:         job.Labels[terraformv1alpha1.JobAgentLabel],
+	}
+	secret.OwnerReferences = []metav1.OwnerReference{
+		*metav1.NewControllerRef(job, batchv1.SchemeGroupVersion.WithKind("Job")),
+	}
+	secret.Data = map[string][]byte{LogsSecretKey: logs}
+
+	return kubernetes.CreateOrForceUpdate(ctx, b.cc, secret)
+}
+
+// heartbeat records the agent has claimed or reported on the job
+func (b *broker) heartbeat(ctx context.Context, job *batchv1.Job) error {
+	original := job.DeepCopy()
+	if job.Annotations == nil {
+		job.Annotations = map[string]string{}
+	}
+	job.Annotations[terraformv1alpha1.JobAgentHeartbeatAnnotation] = time.Now().UTC().Format(time.RFC3339)
+
+	return b.cc.Patch(ctx, job, client.MergeFrom(original))
+}
+
+// isClaimed returns true if an agent has claimed or reported on the job within the timeout
+func (b *broker) isClaimed(job *batchv1.Job) bool {
+	value, found := job.GetAnnotations()[terraformv1alpha1.JobAgentHeartbeatAnnotation]
+	if !found {
+		return false
+	}
+	last, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false
+	}
+
+	return time.Since(last) < b.timeout
+}
+
+// newAssignment returns the assignment for the job, including the secrets the job depends on
+func (b *broker) newAssignment(ctx context.Context, job *batchv1.Job) (*Assignment, error) {
+	assignment := &Assignment{
+		Job: &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: job.Annotations,
+				Labels:      job.Labels,
+				Name:        job.Name,
+				Namespace:   job.Namespace,
+			},
+			Spec: *job.Spec.DeepCopy(),
+		},
+	}
+
+	// @note: the terraform state is included to ensure the kubernetes backend within the agent
+	// cluster is current
+	names := referencedSecrets(&job.Spec.Template.Spec)
+	names = append(names, terraformStateSecret(job))
+
+	for _, name := range utils.Sorted(utils.Unique(names)) {
+		secret, found, err := kubernetes.GetSecretIfExists(ctx, b.cc, job.Namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+
+		assignment.Secrets = append(assignment.Secrets, v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: secret.Annotations,
+				Labels:      secret.Labels,
+				Name:        secret.Name,
+				Namespace:   secret.Namespace,
+			},
+			Data: secret.Data,
+			Type: secret.Type,
+		})
+	}
+
+	return assignment, nil
+}
+
+// registration returns the persisted registration of the agent if any
+func (b *broker) registration(ctx context.Context, name string) (Registration, bool, error) {
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return Registration{}, false, nil
+	}
+
+	lease := &coordinationv1.Lease{}
+	lease.Namespace = b.namespace
+	lease.Name = RegistrationLeaseName(name)
+
+	found, err := kubernetes.GetIfExists(ctx, b.cc, lease)
+	if err != nil || !found {
+		return Registration{}, false, err
+	}
+
+	registration := Registration{
+		Name:    name,
+		Version: lease.Annotations[terraformv1alpha1.AgentVersionAnnotation],
+	}
+	if value := lease.Annotations[terraformv1alpha1.AgentProvidersAnnotation]; value != "" {
+		registration.Providers = strings.Split(value, ",")
+	}
+
+	return registration, true, nil
+}
+
+// newJobCondition returns a job condition
+func newJobCondition(kind batchv1.JobConditionType, reason, message string, now metav1.Time) batchv1.JobCondition {
+	return batchv1.JobCondition{
+		LastProbeTime:      now,
+		LastTransitionTime: now,
+		Message:            message,
+		Reason:             reason,
+		Status:             v1.ConditionTrue,
+		Type:               kind,
+	}
+}
+
+// configurationOf returns a configuration holding the identity of the configuration the job belongs to
+func configurationOf(job *batchv1.Job) *terraformv1alpha1.Configuration {
+	configuration := &terraformv1alpha1.Configuration{}
+	configuration.Namespace = job.Labels[terraformv1alpha1.ConfigurationNamespaceLabel]
+	configuration.Name = job.Labels[terraformv1alpha1.ConfigurationNameLabel]
+	configuration.UID = types.UID(job.Labels[terraformv1alpha1.ConfigurationUIDLabel])
+
+	return configuration
+}
+
+// terraformStateSecret returns the name of the secret holding the terraform state for the job
+func terraformStateSecret(job *batchv1.Job) string {
+	return configurationOf(job).GetTerraformStateSecretName()
+}
+
+// outputSecrets returns the secrets the job may produce, which are returned by the agent
+func outputSecrets(job *batchv1.Job) []string {
+	configuration := configurationOf(job)
+
+	return []string{
+		configuration.GetTerraformCostSecretName(),
+		configuration.GetTerraformPlanJSONSecretName(),
+		configuration.GetTerraformPlanOutSecretName(),
+		configuration.GetTerraformPolicySecretName(),
+		configuration.GetTerraformPreviewPlanJSONSecretName(),
+		configuration.GetTerraformPreviewPlanOutSecretName(),
+		configuration.GetTerraformPreviewStepResultsSecretName(),
+		configuration.GetTerraformStateSecretName(),
+		configuration.GetTerraformStepResultsSecretName(),
+	}
+}
+
+// referencedSecrets returns the names of all the secrets referenced by the pod spec
+func referencedSecrets(spec *v1.PodSpec) []string {
+	var names []string
+
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil {
+			names = append(names, volume.Secret.SecretName)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil {
+					names = append(names, source.Secret.Name)
+				}
+			}
+		}
+	}
+
+	for _, container := range append(spec.InitContainers, spec.Containers...) {
+		for _, from := range container.EnvFrom {
+			if from.SecretRef != nil {
+				names = append(names, from.SecretRef.Name)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				names = append(names, env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+
+	return names
+}
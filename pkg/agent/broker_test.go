@@ -0,0 +1,313 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/schema"
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
+)
+
+const testUID = "1234-122-1234-1234"
+
+// newTestJob returns a job assigned to the agent
+func newTestJob(name, agent, provider string) *batchv1.Job {
+	job := &batchv1.Job{}
+	job.Namespace = "terraform-system"
+	job.Name = name
+	job.Labels = map[string]string{
+		terraformv1alpha1.ConfigurationNameLabel:      "bucket",
+		terraformv1alpha1.ConfigurationNamespaceLabel: "apps",
+		terraformv1alpha1.ConfigurationStageLabel:     terraformv1alpha1.StageTerraformPlan,
+		terraformv1alpha1.ConfigurationUIDLabel:       testUID,
+		terraformv1alpha1.JobAgentLabel:               agent,
+		terraformv1alpha1.JobProviderLabel:            provider,
+	}
+	job.Spec.ManagedBy = ptr.To(terraformv1alpha1.JobAgentManager)
+	job.Spec.Suspend = ptr.To(true)
+	job.Spec.Template.Labels = map[string]string{"controller-uid": "abc", "job-name": name}
+	job.Spec.Template.Spec.Containers = []v1.Container{
+		{
+			Name:    "terraform",
+			EnvFrom: []v1.EnvFromSource{{SecretRef: &v1.SecretEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "aws"}}}},
+		},
+	}
+	job.Spec.Template.Spec.Volumes = []v1.Volume{
+		{Name: "config", VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: "config-" + testUID}}},
+		{Name: "missing", VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: "missing"}}},
+	}
+
+	return job
+}
+
+// newTestSecret returns a secret in the namespace
+func newTestSecret(namespace, name string) *v1.Secret {
+	secret := &v1.Secret{}
+	secret.Namespace = namespace
+	secret.Name = name
+	secret.Data = map[string][]byte{"key": []byte(name)}
+
+	return secret
+}
+
+// newTestProvider returns a provider served by the agent
+func newTestProvider(name, agent string) *terraformv1alpha1.Provider {
+	provider := &terraformv1alpha1.Provider{}
+	provider.Name = name
+	provider.Spec.Agent = agent
+
+	return provider
+}
+
+// newTestBroker returns a broker with the objects, the aws provider is served by the remote agent
+func newTestBroker(objects ...client.Object) (client.Client, *broker) {
+	objects = append(objects, newTestProvider("aws", "remote"))
+	cc := fake.NewClientBuilder().WithScheme(schema.GetScheme()).WithObjects(objects...).Build()
+
+	return cc, NewBroker(cc, "terraform-system").(*broker)
+}
+
+func TestRegister(t *testing.T) {
+	_, b := newTestBroker()
+
+	assert.Error(t, b.Register(context.Background(), Registration{Providers: []string{"aws"}}))
+	assert.Error(t, b.Register(context.Background(), Registration{Name: "Not_Valid", Providers: []string{"aws"}}))
+	assert.Error(t, b.Register(context.Background(), Registration{Name: "remote"}))
+	assert.NoError(t, b.Register(context.Background(), Registration{Name: "remote", Providers: []string{"aws"}}))
+}
+
+func TestRegisterProviderMismatch(t *testing.T) {
+	_, b := newTestBroker(newTestProvider("gcp", "other"), newTestProvider("azure", ""))
+
+	for _, provider := range []string{"gcp", "azure", "missing"} {
+		err := b.Register(context.Background(), Registration{Name: "remote", Providers: []string{"aws", provider}})
+		assert.ErrorIs(t, err, ErrNotPermitted, provider)
+	}
+
+	_, err := b.Assignments(context.Background(), "remote")
+	assert.ErrorIs(t, err, ErrNotRegistered)
+}
+
+func TestRegisterPersisted(t *testing.T) {
+	cc, b := newTestBroker(newTestJob("plan", "remote", "aws"))
+	require.NoError(t, b.Register(context.Background(), Registration{Name: "remote", Providers: []string{"aws"}, Version: "v1.0.0"}))
+
+	lease := &coordinationv1.Lease{}
+	require.NoError(t, cc.Get(context.Background(), client.ObjectKey{Namespace: "terraform-system", Name: RegistrationLeaseName("remote")}, lease))
+	assert.Equal(t, "remote", ptr.Deref(lease.Spec.HolderIdentity, ""))
+	assert.Equal(t, "aws", lease.Annotations[terraformv1alpha1.AgentProvidersAnnotation])
+	assert.Equal(t, "v1.0.0", lease.Annotations[terraformv1alpha1.AgentVersionAnnotation])
+
+	// @note: a restarted controller must honour the existing registration
+	restarted := NewBroker(cc, "terraform-system")
+
+	assignments, err := restarted.Assignments(context.Background(), "remote")
+	require.NoError(t, err)
+	assert.Len(t, assignments, 1)
+}
+
+func TestAssignmentsNotRegistered(t *testing.T) {
+	_, b := newTestBroker()
+
+	assignments, err := b.Assignments(context.Background(), "remote")
+	assert.ErrorIs(t, err, ErrNotRegistered)
+	assert.Empty(t, assignments)
+}
+
+func TestAssignments(t *testing.T) {
+	cc, b := newTestBroker(
+		newTestJob("plan", "remote", "aws"),
+		newTestJob("other-agent", "other", "aws"),
+		newTestJob("other-provider", "remote", "gcp"),
+		newTestSecret("terraform-system", "aws"),
+		newTestSecret("terraform-system", "config-"+testUID),
+		newTestSecret("terraform-system", "tfstate-default-"+testUID),
+		newTestSecret("terraform-system", "unrelated"),
+	)
+	require.NoError(t, b.Register(context.Background(), Registration{Name: "remote", Providers: []string{"aws"}}))
+
+	assignments, err := b.Assignments(context.Background(), "remote")
+	require.NoError(t, err)
+	require.Len(t, assignments, 1)
+
+	assignment := assignments[0]
+	assert.Equal(t, "plan", assignment.Job.Name)
+	assert.Equal(t, "terraform-system", assignment.Job.Namespace)
+	assert.Empty(t, assignment.Job.ResourceVersion)
+
+	var names []string
+	for _, secret := range assignment.Secrets {
+		names = append(names, secret.Name)
+	}
+	assert.Equal(t, []string{"aws", "config-" + testUID, "tfstate-default-" + testUID}, names)
+
+	// @step: the job should have been claimed by the agent
+	job := &batchv1.Job{}
+	require.NoError(t, cc.Get(context.Background(), client.ObjectKey{Namespace: "terraform-system", Name: "plan"}, job))
+	assert.NotEmpty(t, job.Annotations[terraformv1alpha1.JobAgentHeartbeatAnnotation])
+
+	assignments, err = b.Assignments(context.Background(), "remote")
+	require.NoError(t, err)
+	assert.Empty(t, assignments)
+
+	// @step: the job should be offered again once the heartbeat has expired
+	b.timeout = 0
+	assignments, err = b.Assignments(context.Background(), "remote")
+	require.NoError(t, err)
+	assert.Len(t, assignments, 1)
+}
+
+func TestReportNotFound(t *testing.T) {
+	_, b := newTestBroker(newTestJob("plan", "other", "aws"))
+	require.NoError(t, b.Register(context.Background(), Registration{Name: "remote", Providers: []string{"aws"}}))
+
+	err := b.Report(context.Background(), "remote", Report{Namespace: "terraform-system", Name: "plan", Phase: PhaseRunning})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	err = b.Report(context.Background(), "remote", Report{Namespace: "terraform-system", Name: "missing", Phase: PhaseRunning})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestReportRunning(t *testing.T) {
+	cc, b := newTestBroker(newTestJob("plan", "remote", "aws"))
+	require.NoError(t, b.Register(context.Background(), Registration{Name: "remote", Providers: []string{"aws"}}))
+
+	require.NoError(t, b.Report(context.Background(), "remote", Report{
+		Logs:      []byte("terraform is running"),
+		Name:      "plan",
+		Namespace: "terraform-system",
+		Phase:     PhaseRunning,
+	}))
+
+	job := &batchv1.Job{}
+	require.NoError(t, cc.Get(context.Background(), client.ObjectKey{Namespace: "terraform-system", Name: "plan"}, job))
+	assert.True(t, jobs.IsActive(job))
+	assert.NotEmpty(t, job.Annotations[terraformv1alpha1.JobAgentHeartbeatAnnotation])
+
+	secret := &v1.Secret{}
+	require.NoError(t, cc.Get(context.Background(), client.ObjectKey{Namespace: "terraform-system", Name: LogsSecretName("plan")}, secret))
+	assert.Equal(t, "terraform is running", string(secret.Data[LogsSecretKey]))
+	assert.Equal(t, "remote", secret.Labels[terraformv1alpha1.JobAgentLabel])
+	require.Len(t, secret.OwnerReferences, 1)
+	assert.Equal(t, "plan", secret.OwnerReferences[0].Name)
+}
+
+func TestReportSucceeded(t *testing.T) {
+	cc, b := newTestBroker(newTestJob("plan", "remote", "aws"))
+	require.NoError(t, b.Register(context.Background(), Registration{Name: "remote", Providers: []string{"aws"}}))
+
+	require.NoError(t, b.Report(context.Background(), "remote", Report{
+		Logs:      []byte("terraform has finished"),
+		Name:      "plan",
+		Namespace: "terraform-system",
+		Phase:     PhaseSucceeded,
+		Secrets: []v1.Secret{
+			*newTestSecret("", "tfplan-json-"+testUID),
+			*newTestSecret("", "tfstate-default-"+testUID),
+			*newTestSecret("", "aws"),
+		},
+	}))
+
+	job := &batchv1.Job{}
+	require.NoError(t, cc.Get(context.Background(), client.ObjectKey{Namespace: "terraform-system", Name: "plan"}, job))
+	assert.True(t, jobs.IsComplete(job))
+	assert.Equal(t, int32(1), job.Status.Succeeded)
+	assert.NotNil(t, job.Status.CompletionTime)
+
+	for _, name := range []string{"tfplan-json-" + testUID, "tfstate-default-" + testUID} {
+		secret := &v1.Secret{}
+		require.NoError(t, cc.Get(context.Background(), client.ObjectKey{Namespace: "terraform-system", Name: name}, secret))
+		assert.Equal(t, name, string(secret.Data["key"]))
+	}
+
+	// @step: the agent is not permitted to write secrets which are not outputs of the job
+	err := cc.Get(context.Background(), client.ObjectKey{Namespace: "terraform-system", Name: "aws"}, &v1.Secret{})
+	assert.Error(t, err)
+
+	// @step: any further reports are ignored
+	require.NoError(t, b.Report(context.Background(), "remote", Report{
+		Name:      "plan",
+		Namespace: "terraform-system",
+		Phase:     PhaseFailed,
+	}))
+	require.NoError(t, cc.Get(context.Background(), client.ObjectKey{Namespace: "terraform-system", Name: "plan"}, job))
+	assert.True(t, jobs.IsComplete(job))
+	assert.False(t, jobs.IsFailed(job))
+}
+
+func TestReportFailed(t *testing.T) {
+	cc, b := newTestBroker(newTestJob("plan", "remote", "aws"))
+	require.NoError(t, b.Register(context.Background(), Registration{Name: "remote", Providers: []string{"aws"}}))
+
+	require.NoError(t, b.Report(context.Background(), "remote", Report{
+		Message:   "namespace does not exist within the agent cluster",
+		Name:      "plan",
+		Namespace: "terraform-system",
+		Phase:     PhaseFailed,
+	}))
+
+	job := &batchv1.Job{}
+	require.NoError(t, cc.Get(context.Background(), client.ObjectKey{Namespace: "terraform-system", Name: "plan"}, job))
+	assert.True(t, jobs.IsFailed(job))
+	assert.Equal(t, int32(1), job.Status.Failed)
+
+	err := cc.Get(context.Background(), client.ObjectKey{Namespace: "terraform-system", Name: LogsSecretName("plan")}, &v1.Secret{})
+	assert.Error(t, err)
+}
+
+func TestSaveLogsTruncated(t *testing.T) {
+	cc, b := newTestBroker(newTestJob("plan", "remote", "aws"))
+
+	logs := make([]byte, MaxLogsSize+10)
+	logs[len(logs)-1] = 'x'
+	require.NoError(t, b.saveLogs(context.Background(), newTestJob("plan", "remote", "aws"), logs))
+
+	secret := &v1.Secret{}
+	require.NoError(t, cc.Get(context.Background(), client.ObjectKey{Namespace: "terraform-system", Name: LogsSecretName("plan")}, secret))
+	assert.Len(t, secret.Data[LogsSecretKey], MaxLogsSize)
+	assert.Equal(t, byte('x'), secret.Data[LogsSecretKey][MaxLogsSize-1])
+}
+
+func TestIsClaimed(t *testing.T) {
+	_, b := newTestBroker()
+	job := newTestJob("plan", "remote", "aws")
+
+	assert.False(t, b.isClaimed(job))
+
+	job.Annotations = map[string]string{terraformv1alpha1.JobAgentHeartbeatAnnotation: "bad"}
+	assert.False(t, b.isClaimed(job))
+
+	job.Annotations[terraformv1alpha1.JobAgentHeartbeatAnnotation] = time.Now().Add(-10 * time.Minute).UTC().Format(time.RFC3339)
+	assert.False(t, b.isClaimed(job))
+
+	job.Annotations[terraformv1alpha1.JobAgentHeartbeatAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	assert.True(t, b.isClaimed(job))
+}
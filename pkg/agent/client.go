@@ -0,0 +1,123 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type remote struct {
+	// endpoint is the location of the controller
+	endpoint string
+	// hc is the http client
+	hc *http.Client
+}
+
+// NewClient returns a client to the agents api of the controller, authenticating with the
+// certificate within the tls configuration
+func NewClient(endpoint string, config *tls.Config) (Interface, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid controller endpoint, %w", err)
+	}
+	switch {
+	case u.Scheme != "https":
+		return nil, errors.New("controller endpoint must be https")
+	case config == nil:
+		return nil, errors.New("tls configuration is required")
+	}
+
+	return &remote{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		hc: &http.Client{
+			Timeout:   time.Minute,
+			Transport: &http.Transport{TLSClientConfig: config},
+		},
+	}, nil
+}
+
+// Register is called by an agent to register with the controller
+func (r *remote) Register(ctx context.Context, registration Registration) error {
+	return r.do(ctx, http.MethodPost, "/v1/agents/"+registration.Name+"/register", registration, nil)
+}
+
+// Assignments returns the jobs assigned to the agent
+func (r *remote) Assignments(ctx context.Context, agent string) ([]Assignment, error) {
+	var assignments []Assignment
+
+	if err := r.do(ctx, http.MethodGet, "/v1/agents/"+agent+"/assignments", nil, &assignments); err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+// Report is called by an agent to report on the progress of an assignment
+func (r *remote) Report(ctx context.Context, agent string, report Report) error {
+	return r.do(ctx, http.MethodPost, "/v1/agents/"+agent+"/reports", report, nil)
+}
+
+// do performs the request against the controller
+func (r *remote) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, r.endpoint+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusConflict:
+		return ErrNotRegistered
+	case http.StatusNotFound:
+		return ErrNotFound
+	default:
+		return fmt.Errorf("unexpected response from the controller, status: %d", resp.StatusCode)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package agent
+
+import (
+	"context"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Fake is an in-process agent which completes the assignments without running them, reporting
+// the configured outcome back to the controller. It is intended to be used within tests
+type Fake struct {
+	// Assignments is a record of all the assignments received by the agent
+	Assignments []Assignment
+	// Broker is the controller the agent pulls the assignments from
+	Broker Interface
+	// Logs are the logs reported for the assignments
+	Logs []byte
+	// Message is the message reported for the assignments
+	Message string
+	// Name is the name of the agent
+	Name string
+	// Outputs is an optional method returning the outputs reported for the job
+	Outputs func(job *batchv1.Job) []v1.Secret
+	// Phase is the outcome reported for the assignments, defaulting to succeeded
+	Phase Phase
+	// Providers is the collection of providers the agent serves
+	Providers []string
+}
+
+// Sync registers the agent, pulls any assignments and reports the outcome for each
+func (f *Fake) Sync(ctx context.Context) error {
+	if err := f.Broker.Register(ctx, Registration{Name: f.Name, Providers: f.Providers}); err != nil {
+		return err
+	}
+
+	assignments, err := f.Broker.Assignments(ctx, f.Name)
+	if err != nil {
+		return err
+	}
+
+	phase := f.Phase
+	if phase == "" {
+		phase = PhaseSucceeded
+	}
+
+	for _, assignment := range assignments {
+		f.Assignments = append(f.Assignments, assignment)
+
+		report := Report{
+			Logs:      f.Logs,
+			Message:   f.Message,
+			Name:      assignment.Job.Name,
+			Namespace: assignment.Job.Namespace,
+			Phase:     phase,
+		}
+		if f.Outputs != nil {
+			report.Secrets = f.Outputs(assignment.Job)
+		}
+
+		if err := f.Broker.Report(ctx, f.Name, report); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
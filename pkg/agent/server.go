@@ -0,0 +1,145 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appvia/terranetes-controller/pkg/apiserver/logging"
+	"github.com/appvia/terranetes-controller/pkg/apiserver/recovery"
+)
+
+// MaxRequestSize is the maximum size of a request from an agent
+const MaxRequestSize = 32 * 1024 * 1024
+
+// NewHandler returns the http handler serving the agents. The handler is expected to be served
+// over mutual tls, with the common name of the client certificate identifying the agent
+func NewHandler(broker Interface) http.Handler {
+	router := mux.NewRouter()
+	router.Use(recovery.Recovery())
+	router.Use(logging.Logger())
+	router.Use(authorize)
+
+	router.HandleFunc("/v1/agents/{agent}/register", func(w http.ResponseWriter, req *http.Request) {
+		registration := Registration{}
+		if err := decode(w, req, &registration); err != nil {
+			return
+		}
+		if registration.Name != mux.Vars(req)["agent"] {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		if err := broker.Register(req.Context(), registration); err != nil {
+			log.WithError(err).WithField("agent", registration.Name).Error("failed to register the agent")
+			switch {
+			case errors.Is(err, ErrNotPermitted):
+				w.WriteHeader(http.StatusForbidden)
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+			}
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodPost)
+
+	router.HandleFunc("/v1/agents/{agent}/assignments", func(w http.ResponseWriter, req *http.Request) {
+		assignments, err := broker.Assignments(req.Context(), mux.Vars(req)["agent"])
+		if err != nil {
+			writeError(w, err)
+
+			return
+		}
+		if assignments == nil {
+			assignments = []Assignment{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(assignments); err != nil {
+			log.WithError(err).Error("failed to encode the assignments")
+		}
+	}).Methods(http.MethodGet)
+
+	router.HandleFunc("/v1/agents/{agent}/reports", func(w http.ResponseWriter, req *http.Request) {
+		report := Report{}
+		if err := decode(w, req, &report); err != nil {
+			return
+		}
+		if err := broker.Report(req.Context(), mux.Vars(req)["agent"], report); err != nil {
+			writeError(w, err)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}).Methods(http.MethodPost)
+
+	return router
+}
+
+// authorize ensures the caller presented a client certificate for the agent
+func authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if name := req.TLS.PeerCertificates[0].Subject.CommonName; name != mux.Vars(req)["agent"] {
+			log.WithField("agent", mux.Vars(req)["agent"]).WithField("certificate", name).Warn("agent certificate does not match the agent")
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// decode decodes the request body, writing a bad request on failure
+func decode(w http.ResponseWriter, req *http.Request, value interface{}) error {
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, MaxRequestSize)).Decode(value); err != nil {
+		log.WithError(err).Error("received an invalid request from the agent")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return err
+	}
+
+	return nil
+}
+
+// writeError writes the status code for the error
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotRegistered):
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		log.WithError(err).Error("failed to handle the agent request")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,226 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package agent
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
+)
+
+// testPKI is a certificate authority used to sign the certificates within the tests
+type testPKI struct {
+	ca     *x509.Certificate
+	key    *ecdsa.PrivateKey
+	caFile string
+	dir    string
+}
+
+// newTestPKI returns a certificate authority
+func newTestPKI(t *testing.T) *testPKI {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		NotAfter:              time.Now().Add(time.Hour),
+		NotBefore:             time.Now().Add(-time.Minute),
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+
+	return &testPKI{ca: ca, key: key, caFile: caFile, dir: dir}
+}
+
+// issue returns the filenames of a certificate and key signed by the authority
+func (p *testPKI) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		DNSNames:     []string{"localhost"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		NotAfter:     time.Now().Add(time.Hour),
+		NotBefore:    time.Now().Add(-time.Minute),
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, p.ca, &key.PublicKey, p.key)
+	require.NoError(t, err)
+	encoded, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(p.dir, name+".pem")
+	keyFile := filepath.Join(p.dir, name+"-key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: encoded}), 0600))
+
+	return certFile, keyFile
+}
+
+// newTestServer returns a controller serving the agents over mutual tls
+func newTestServer(t *testing.T, pki *testPKI, objects ...client.Object) (client.Client, *httptest.Server) {
+	cc, b := newTestBroker(objects...)
+
+	certFile, keyFile := pki.issue(t, "controller", x509.ExtKeyUsageServerAuth)
+	config, err := NewServerTLSConfig(certFile, keyFile, pki.caFile)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(NewHandler(b))
+	server.TLS = config
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return cc, server
+}
+
+// newTestClient returns a client for the agent
+func newTestClient(t *testing.T, pki *testPKI, endpoint, name string) Interface {
+	certFile, keyFile := pki.issue(t, name, x509.ExtKeyUsageClientAuth)
+	config, err := NewClientTLSConfig(certFile, keyFile, pki.caFile)
+	require.NoError(t, err)
+
+	remote, err := NewClient(endpoint, config)
+	require.NoError(t, err)
+
+	return remote
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	pki := newTestPKI(t)
+	certFile, keyFile := pki.issue(t, "controller", x509.ExtKeyUsageServerAuth)
+
+	_, err := NewServerTLSConfig("", keyFile, pki.caFile)
+	assert.Error(t, err)
+	_, err = NewServerTLSConfig(certFile, "", pki.caFile)
+	assert.Error(t, err)
+	_, err = NewServerTLSConfig(certFile, keyFile, "")
+	assert.Error(t, err)
+	_, err = NewServerTLSConfig(certFile, keyFile, filepath.Join(pki.dir, "missing.pem"))
+	assert.Error(t, err)
+	_, err = NewServerTLSConfig(certFile, keyFile, keyFile)
+	assert.Error(t, err)
+
+	config, err := NewServerTLSConfig(certFile, keyFile, pki.caFile)
+	require.NoError(t, err)
+	assert.NotNil(t, config.ClientCAs)
+	assert.Nil(t, config.RootCAs)
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := NewClient("http://localhost", nil)
+	assert.Error(t, err)
+	_, err = NewClient("https://localhost", nil)
+	assert.Error(t, err)
+}
+
+func TestHandlerRequiresCertificate(t *testing.T) {
+	_, b := newTestBroker()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/agents/remote/assignments", nil)
+	w := httptest.NewRecorder()
+	NewHandler(b).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestClientServer(t *testing.T) {
+	ctx := context.Background()
+	pki := newTestPKI(t)
+	cc, server := newTestServer(t, pki, newTestJob("plan", "remote", "aws"))
+	remote := newTestClient(t, pki, server.URL, "remote")
+
+	_, err := remote.Assignments(ctx, "remote")
+	assert.ErrorIs(t, err, ErrNotRegistered)
+
+	assert.Error(t, remote.Register(ctx, Registration{Name: "remote"}))
+	require.NoError(t, remote.Register(ctx, Registration{Name: "remote", Providers: []string{"aws"}}))
+
+	assignments, err := remote.Assignments(ctx, "remote")
+	require.NoError(t, err)
+	require.Len(t, assignments, 1)
+	assert.Equal(t, "plan", assignments[0].Job.Name)
+
+	err = remote.Report(ctx, "remote", Report{Namespace: "terraform-system", Name: "missing", Phase: PhaseRunning})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, remote.Report(ctx, "remote", Report{
+		Logs:      []byte("terraform has finished"),
+		Name:      "plan",
+		Namespace: "terraform-system",
+		Phase:     PhaseSucceeded,
+	}))
+
+	job := &batchv1.Job{}
+	require.NoError(t, cc.Get(ctx, client.ObjectKey{Namespace: "terraform-system", Name: "plan"}, job))
+	assert.True(t, jobs.IsComplete(job))
+}
+
+func TestClientServerImpersonation(t *testing.T) {
+	ctx := context.Background()
+	pki := newTestPKI(t)
+	_, server := newTestServer(t, pki)
+
+	// @step: an agent can only act under the name in its certificate
+	remote := newTestClient(t, pki, server.URL, "other")
+	assert.Error(t, remote.Register(ctx, Registration{Name: "remote", Providers: []string{"aws"}}))
+
+	_, err := remote.Assignments(ctx, "remote")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotRegistered)
+}
+
+func TestClientServerUntrusted(t *testing.T) {
+	ctx := context.Background()
+	_, server := newTestServer(t, newTestPKI(t))
+
+	// @step: a certificate signed by another authority is rejected
+	remote := newTestClient(t, newTestPKI(t), server.URL, "remote")
+	assert.Error(t, remote.Register(ctx, Registration{Name: "remote", Providers: []string{"aws"}}))
+}
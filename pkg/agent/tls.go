@@ -0,0 +1,78 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package agent
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// NewServerTLSConfig returns the tls configuration for the controller, the agents must present a
+// client certificate signed by the certificate authority
+func NewServerTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	config, err := newTLSConfig(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	config.ClientCAs = config.RootCAs
+	config.RootCAs = nil
+
+	return config, nil
+}
+
+// NewClientTLSConfig returns the tls configuration for the agents, the controller must present a
+// certificate signed by the certificate authority
+func NewClientTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	return newTLSConfig(certFile, keyFile, caFile)
+}
+
+// newTLSConfig returns a tls configuration with the key pair and certificate authority
+func newTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	switch {
+	case certFile == "":
+		return nil, errors.New("tls certificate is required")
+	case keyFile == "":
+		return nil, errors.New("tls key is required")
+	case caFile == "":
+		return nil, errors.New("tls certificate authority is required")
+	}
+
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the tls key pair, %w", err)
+	}
+
+	ca, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the certificate authority, %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("certificate authority does not contain any certificates")
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+		RootCAs:      pool,
+	}, nil
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package agent
+
+import (
+	"context"
+	"errors"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Phase is the phase of an assignment as reported by the agent
+type Phase string
+
+const (
+	// PhaseRunning indicates the job is running within the agent cluster
+	PhaseRunning Phase = "Running"
+	// PhaseSucceeded indicates the job has completed successfully
+	PhaseSucceeded Phase = "Succeeded"
+	// PhaseFailed indicates the job has failed
+	PhaseFailed Phase = "Failed"
+)
+
+// IsFinished returns true if the phase is terminal
+func (p Phase) IsFinished() bool {
+	return p == PhaseSucceeded || p == PhaseFailed
+}
+
+var (
+	// ErrNotRegistered is returned when the agent has not registered with the controller
+	ErrNotRegistered = errors.New("agent is not registered")
+	// ErrNotFound is returned when the assignment does not exist or is not assigned to the agent
+	ErrNotFound = errors.New("assignment not found")
+	// ErrNotPermitted is returned when the agent registers for a provider which does not reference it
+	ErrNotPermitted = errors.New("agent is not permitted")
+)
+
+// Registration is sent by an agent to register with the controller
+type Registration struct {
+	// Name is the name of the agent, referenced by the providers it serves
+	Name string `json:"name"`
+	// Providers is the collection of providers the agent is willing to run jobs for
+	Providers []string `json:"providers"`
+	// Version is the version of the agent
+	Version string `json:"version,omitempty"`
+}
+
+// Assignment is a job assigned to an agent
+type Assignment struct {
+	// Job is the job the agent should run, the agent runs the job under the same namespace
+	// and name
+	Job *batchv1.Job `json:"job"`
+	// Secrets is the collection of secrets the job depends on
+	Secrets []v1.Secret `json:"secrets,omitempty"`
+}
+
+// Report is sent by an agent to report on the progress of an assignment
+type Report struct {
+	// Namespace is the namespace of the job
+	Namespace string `json:"namespace"`
+	// Name is the name of the job
+	Name string `json:"name"`
+	// Phase is the phase of the job within the agent cluster
+	Phase Phase `json:"phase"`
+	// Message is an optional message describing the phase
+	Message string `json:"message,omitempty"`
+	// Logs are the logs of the job so far
+	Logs []byte `json:"logs,omitempty"`
+	// Secrets are the outputs of the job i.e. the terraform state, plan and reports, returned
+	// once the job has finished
+	Secrets []v1.Secret `json:"secrets,omitempty"`
+}
+
+// Interface is the contract between the agents and the controller
+type Interface interface {
+	// Register is called by an agent to register with the controller
+	Register(ctx context.Context, registration Registration) error
+	// Assignments returns the jobs assigned to the agent
+	Assignments(ctx context.Context, agent string) ([]Assignment, error)
+	// Report is called by an agent to report on the progress of an assignment
+	Report(ctx context.Context, agent string, report Report) error
+}
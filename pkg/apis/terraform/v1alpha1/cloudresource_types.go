@@ -87,6 +87,11 @@ type CloudResourceSpec struct {
 	// for any drift between the expected and current state. If any drift is detected the
 	// status is changed and a kubernetes event raised.
 	EnableDriftDetection bool `json:"enableDriftDetection,omitempty"`
+	// Engine is the engine used to execute the configuration, i.e. terraform or opentofu. When
+	// not defined the engine of the revision is used
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=terraform;opentofu
+	Engine string `json:"engine,omitempty"`
 	// Plan is the reference to the plan which this cloud resource is associated with. This
 	// field is required, and needs both the name and version the plan revision to use
 	// +kubebuilder:validation:Required
@@ -66,6 +66,23 @@ func (v *ValueFromList) HasContextReferences() bool {
 	return false
 }
 
+// HasContextReference returns true if the configuration references any of the keys
+// within the named context
+func (v *ValueFromList) HasContextReference(name string, keys []string) bool {
+	for _, x := range *v {
+		if x.Context == nil || *x.Context != name {
+			continue
+		}
+		for _, key := range keys {
+			if x.Key == key {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // HasSecretReferences returns true if the configuration has secret references
 func (v *ValueFromList) HasSecretReferences() bool {
 	for _, x := range *v {
@@ -80,8 +97,16 @@ func (v *ValueFromList) HasSecretReferences() bool {
 const (
 	// ApplyAnnotation is the annotation used to mark a resource as a plan rather than apply
 	ApplyAnnotation = "terraform.appvia.io/apply"
+	// BuildLogsAnnotation is the annotation used to mark the logs of a job as captured
+	BuildLogsAnnotation = "terraform.appvia.io/build-logs"
 	// DriftAnnotation is the annotation used to mark a resource for drift detection
 	DriftAnnotation = "terraform.appvia.io/drift"
+	// ForceUnlockAnnotation is the annotation used to request the release of a terraform state lock,
+	// the value being the id of the lock
+	ForceUnlockAnnotation = "terraform.appvia.io/force-unlock"
+	// PreviewVersionAnnotation is the annotation used to request a plan of the configuration under
+	// another version of the engine, the value being the version
+	PreviewVersionAnnotation = "terraform.appvia.io/preview-version"
 	// ReconcileAnnotation is the label used control reconciliation
 	ReconcileAnnotation = "terraform.appvia.io/reconcile"
 	// RetryAnnotation is the annotation used to mark a resource for retry
@@ -126,6 +151,8 @@ const (
 	ConfigurationNamespaceLabel = "terraform.appvia.io/namespace"
 	// ConfigurationStageLabel is the label used to identify a configuration stage
 	ConfigurationStageLabel = "terraform.appvia.io/stage"
+	// ConfigurationEngineLabel is the label used to identify the engine which ran the job
+	ConfigurationEngineLabel = "terraform.appvia.io/engine"
 	// ConfigurationPlanLabel is the label which contains the plan name for a configuration
 	ConfigurationPlanLabel = RevisionPlanNameLabel
 	// ConfigurationRevisionLabelName is the name of the revision being used
@@ -134,6 +161,12 @@ const (
 	ConfigurationRevisionVersion = "terranetes.appvia.io/revision-version"
 )
 
+const (
+	// ErrorDetectorsLabel is the label used to identify configmaps in the controller namespace
+	// which contain additional error detectors
+	ErrorDetectorsLabel = "terraform.appvia.io/error-detectors"
+)
+
 const (
 	// JobPlanIDLabel is a label used on the apply Job and holds the timestamp
 	// of when the plan was generated.
@@ -141,6 +174,36 @@ const (
 	// JobTemplateHashLabel is the label used to hold a hash of the current Job
 	// template, this allows re-running the plan Jobs when the template changes.
 	JobTemplateHashLabel = "terraform.appvia.io/template-hash"
+	// JobPreviewVersionLabel is the label used on a plan Job which previews the configuration under
+	// another version of the engine
+	JobPreviewVersionLabel = PreviewVersionAnnotation
+	// JobWatcherLabel is the label used on the Jobs which stream the logs of a build into the
+	// namespace of the configuration
+	JobWatcherLabel = "terraform.appvia.io/watcher"
+	// JobSecretCopyLabel is the label used on secrets copied from the controller namespace into
+	// the namespace the Jobs are run in
+	JobSecretCopyLabel = "terraform.appvia.io/copied-from"
+	// JobAgentLabel is the label used on the Jobs which are run by a remote agent, holding the
+	// name of the agent
+	JobAgentLabel = "terraform.appvia.io/agent"
+	// JobAgentAssignmentLabel is the label used by an agent on the resources it creates for an
+	// assignment, holding the identifier of the assignment
+	JobAgentAssignmentLabel = "terraform.appvia.io/assignment"
+	// AgentProvidersAnnotation is the annotation on the lease holding the registration of a remote
+	// agent, listing the providers the agent serves
+	AgentProvidersAnnotation = "terraform.appvia.io/agent-providers"
+	// AgentVersionAnnotation is the annotation on the lease holding the registration of a remote
+	// agent, recording the version of the agent
+	AgentVersionAnnotation = "terraform.appvia.io/agent-version"
+	// JobAgentHeartbeatAnnotation is the annotation used on Jobs run by a remote agent, holding
+	// the time the agent last reported on the Job
+	JobAgentHeartbeatAnnotation = "terraform.appvia.io/agent-heartbeat"
+	// JobAgentManager is the value of the managedBy field on Jobs run by a remote agent, ensuring
+	// the Job is not run by the cluster the controller is running in
+	JobAgentManager = "terraform.appvia.io/agent"
+	// JobProviderLabel is the label used on the Jobs run by a remote agent, holding the name of
+	// the provider the Job is using
+	JobProviderLabel = "terraform.appvia.io/provider"
 )
 
 const (
@@ -150,6 +213,8 @@ const (
 	StageTerraformDestroy = "destroy"
 	// StageTerraformPlan is the stage for a terraform plan
 	StageTerraformPlan = "plan"
+	// StageTerraformUnlock is the stage for releasing a terraform state lock
+	StageTerraformUnlock = "unlock"
 	// StageTerraformVerify is the stage for a verify
 	StageTerraformVerify = "verify"
 )
@@ -367,11 +432,27 @@ type ConfigurationSpec struct {
 	// for any drift between the expected and current state. If any drift is detected the
 	// status is changed and a kubernetes event raised.
 	EnableDriftDetection bool `json:"enableDriftDetection,omitempty"`
+	// Engine is the engine used to execute the configuration, i.e. terraform or opentofu. When not
+	// defined the engine of the provider is used, else the default engine of the controller. Note,
+	// changing the engine of an existing configuration is only permitted when the state written
+	// by the previous engine can be read by the new one
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=terraform;opentofu
+	Engine string `json:"engine,omitempty"`
+	// Hooks is a collection of custom steps executed within the terraform jobs, before or
+	// after the plan, apply and destroy stages
+	// +kubebuilder:validation:Optional
+	Hooks []Hook `json:"hooks,omitempty"`
 	// Module is the URL to the source of the terraform module. The format of the URL is
 	// a direct implementation of terraform's module reference. Please see the following
 	// repository for more details https://github.com/hashicorp/go-getter
 	// +kubebuilder:validation:Required
 	Module string `json:"module"`
+	// ModuleChecksum is the expected checksum of the module source, used to pin the module to
+	// known contents. This is either a checksum of the module contents (h1:<hash>), as logged
+	// by the controller when retrieving the source, or of a downloaded archive (i.e. sha256:<hex>)
+	// +kubebuilder:validation:Optional
+	ModuleChecksum string `json:"moduleChecksum,omitempty"`
 	// Plan is an optional reference to a plan this configuration is associated with. If
 	// not set and a policy exists to enforce a plan, the configuration will be rejected.
 	// +kubebuilder:validation:Optional
@@ -380,6 +461,10 @@ type ConfigurationSpec struct {
 	// configuration.
 	// +kubebuilder:validation:Optional
 	ProviderRef *ProviderReference `json:"providerRef,omitempty"`
+	// Retry defines a policy for automatically retrying the configuration when a terraform
+	// job fails due to a transient error, such as api throttling or state lock contention
+	// +kubebuilder:validation:Optional
+	Retry *RetryPolicy `json:"retry,omitempty"`
 	// WriteConnectionSecretToRef is the name for a secret. On execution of the terraform module
 	// any module outputs are written to this secret. The outputs are automatically uppercased
 	// and ready to be consumed as environment variables.
@@ -403,6 +488,10 @@ type ConfigurationSpec struct {
 	// TerraformVersion provides the ability to override the default terraform version. Before
 	// changing this field its best to consult with platform administrator. As the
 	// value of this field is used to change the tag of the terraform container image.
+	// Note the version is the version of the engine in use, i.e. the OpenTofu version when
+	// the engine is opentofu. The version can also be a constraint (i.e. ~> 1.6, >= 1.5, < 1.7)
+	// which is resolved to the latest matching version from those made available by the
+	// platform administrator
 	// +kubebuilder:validation:Optional
 	TerraformVersion string `json:"terraformVersion,omitempty"`
 }
@@ -469,6 +558,89 @@ type ConfigurationRevisionStatus struct {
 	Revision string `json:"revision,omitempty"`
 }
 
+// StateLockStatus provides the details of a terraform state lock
+type StateLockStatus struct {
+	// Created is the time the lock was acquired
+	// +kubebuilder:validation:Optional
+	Created *metav1.Time `json:"created,omitempty"`
+	// ID is the unique identifier of the lock
+	// +kubebuilder:validation:Optional
+	ID string `json:"id,omitempty"`
+	// Operation is the terraform operation which acquired the lock
+	// +kubebuilder:validation:Optional
+	Operation string `json:"operation,omitempty"`
+	// Path is the location of the state which is locked
+	// +kubebuilder:validation:Optional
+	Path string `json:"path,omitempty"`
+	// Who is the holder of the lock
+	// +kubebuilder:validation:Optional
+	Who string `json:"who,omitempty"`
+}
+
+// StepStatus provides the outcome of a step within a job
+type StepStatus struct {
+	// Name is the name of the step
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// Duration is the time taken to run the step
+	// +kubebuilder:validation:Optional
+	Duration metav1.Duration `json:"duration,omitempty"`
+	// ExitCode is the exit code of the step
+	// +kubebuilder:validation:Optional
+	ExitCode int `json:"exitCode,omitempty"`
+	// Ignored indicates the step failed but was permitted to continue, i.e. a hook with
+	// continueOnError enabled
+	// +kubebuilder:validation:Optional
+	Ignored bool `json:"ignored,omitempty"`
+	// Message is the tail of the error output when the step failed
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+	// Retries is the number of times the step was retried
+	// +kubebuilder:validation:Optional
+	Retries int `json:"retries,omitempty"`
+}
+
+const (
+	// PreviewInProgress indicates the preview plan is running
+	PreviewInProgress = "InProgress"
+	// PreviewComplete indicates the preview plan has completed
+	PreviewComplete = "Complete"
+	// PreviewFailed indicates the preview plan has failed
+	PreviewFailed = "Failed"
+)
+
+// PreviewStatus is the outcome of planning the configuration under another version of the engine
+type PreviewStatus struct {
+	// Changes indicates the plan under the version would change the managed resources
+	// +kubebuilder:validation:Optional
+	Changes bool `json:"changes,omitempty"`
+	// Generation is the generation of the configuration which was planned
+	// +kubebuilder:validation:Optional
+	Generation int64 `json:"generation,omitempty"`
+	// Message provides a human readable description of the outcome
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+	// Status is the status of the preview, i.e. InProgress, Complete or Failed
+	// +kubebuilder:validation:Optional
+	Status string `json:"status,omitempty"`
+	// Version is the version of the engine the configuration was planned under
+	// +kubebuilder:validation:Optional
+	Version string `json:"version,omitempty"`
+}
+
+// StepsStatus provides the outcome of the steps within the last job run
+type StepsStatus struct {
+	// FailedStep is the name of the step which failed the job, if any
+	// +kubebuilder:validation:Optional
+	FailedStep string `json:"failedStep,omitempty"`
+	// Stage is the stage of the job i.e. plan, apply or destroy
+	// +kubebuilder:validation:Optional
+	Stage string `json:"stage,omitempty"`
+	// Steps is the outcome of the steps, in the order they finished
+	// +kubebuilder:validation:Optional
+	Steps []StepStatus `json:"steps,omitempty"`
+}
+
 // ConfigurationStatus defines the observed state of a terraform
 // +k8s:openapi-gen=true
 type ConfigurationStatus struct {
@@ -480,6 +652,12 @@ type ConfigurationStatus struct {
 	// DriftTimestamp is the timestamp of the last drift detection
 	// +kubebuilder:validation:Optional
 	DriftTimestamp string `json:"driftTimestamp,omitempty"`
+	// Engine is the engine which last applied the configuration and wrote the terraform state
+	// +kubebuilder:validation:Optional
+	Engine string `json:"engine,omitempty"`
+	// Preview is the outcome of the last plan of the configuration under another version of the engine
+	// +kubebuilder:validation:Optional
+	Preview *PreviewStatus `json:"preview,omitempty"`
 	// Resources is the number of managed cloud resources which are currently under management.
 	// This field is taken from the terraform state itself.
 	// +kubebuilder:validation:Optional
@@ -487,6 +665,15 @@ type ConfigurationStatus struct {
 	// ResourceStatus indicates the status of the resources and if the resources are insync with the
 	// configuration
 	ResourceStatus ResourceStatus `json:"resourceStatus,omitempty"`
+	// Retry tracks the automatic retries of the configuration for the current generation
+	// +kubebuilder:validation:Optional
+	Retry *RetryStatus `json:"retry,omitempty"`
+	// StateLock is the terraform state lock which prevented the last run from completing
+	// +kubebuilder:validation:Optional
+	StateLock *StateLockStatus `json:"stateLock,omitempty"`
+	// Steps is the outcome of the steps within the last job run for this configuration
+	// +kubebuilder:validation:Optional
+	Steps *StepsStatus `json:"steps,omitempty"`
 	// TerraformVersion is the version of terraform which was last used to run this
 	// configuration
 	// +kubebuilder:validation:Optional
@@ -595,6 +782,20 @@ func (c *Configuration) IsRetryable() bool {
 	return tm.After(c.Status.LastReconcile.Time.Time)
 }
 
+// GetRetryDelay returns the time remaining until a scheduled retry is due, or zero
+// when the retry annotation is absent or has already passed
+func (c *Configuration) GetRetryDelay(now time.Time) time.Duration {
+	timestamp, err := strconv.ParseInt(c.GetAnnotations()[RetryAnnotation], 10, 64)
+	if err != nil {
+		return 0
+	}
+	if delay := time.Unix(timestamp, 0).Sub(now); delay > 0 {
+		return delay
+	}
+
+	return 0
+}
+
 // HasApproval returns true if the configuration has an approval
 func (c *Configuration) HasApproval() bool {
 	return c.GetAnnotations()[ApplyAnnotation] == "true"
@@ -639,6 +840,12 @@ func (c *Configuration) GetTerraformCostSecretName() string {
 	return fmt.Sprintf("costs-%s", string(c.GetUID()))
 }
 
+// GetTerraformStepResultsSecretName returns the name of the secret holding the results
+// of the steps within the last job
+func (c *Configuration) GetTerraformStepResultsSecretName() string {
+	return fmt.Sprintf("steps-%s", string(c.GetUID()))
+}
+
 // GetTerraformPlanOutSecretName returns the name of the secret holding the
 // terraform plan binary output
 func (c *Configuration) GetTerraformPlanOutSecretName() string {
@@ -651,6 +858,24 @@ func (c *Configuration) GetTerraformPlanJSONSecretName() string {
 	return fmt.Sprintf("tfplan-json-%s", string(c.GetUID()))
 }
 
+// GetTerraformPreviewPlanOutSecretName returns the name of the secret holding the terraform plan
+// produced when previewing another version of the engine
+func (c *Configuration) GetTerraformPreviewPlanOutSecretName() string {
+	return fmt.Sprintf("tfplan-out-preview-%s", string(c.GetUID()))
+}
+
+// GetTerraformPreviewPlanJSONSecretName returns the name of the secret holding the terraform plan
+// in json produced when previewing another version of the engine
+func (c *Configuration) GetTerraformPreviewPlanJSONSecretName() string {
+	return fmt.Sprintf("tfplan-json-preview-%s", string(c.GetUID()))
+}
+
+// GetTerraformPreviewStepResultsSecretName returns the name of the secret holding the step results
+// of the job previewing another version of the engine
+func (c *Configuration) GetTerraformPreviewStepResultsSecretName() string {
+	return fmt.Sprintf("steps-preview-%s", string(c.GetUID()))
+}
+
 // GetCommonStatus returns the common status
 func (c *Configuration) GetCommonStatus() *corev1alpha1.CommonStatus {
 	return &c.Status.CommonStatus
@@ -21,7 +21,13 @@ import (
 	corev1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/core/v1alpha1"
 )
 
+const (
+	// ConditionContextSources indicate the status of the context sources
+	ConditionContextSources corev1alpha1.ConditionType = "SourcesReady"
+)
+
 // DefaultInputsConditions are the default conditions for all contexts
 var DefaultInputsConditions = []corev1alpha1.ConditionSpec{
 	{Type: corev1alpha1.ConditionReady, Name: "Ready"},
+	{Type: ConditionContextSources, Name: "Context Sources"},
 }
@@ -20,6 +20,7 @@ package v1alpha1
 import (
 	"bytes"
 	"encoding/json"
+	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -31,6 +32,19 @@ import (
 // ContextKind is the kind for a Context
 const ContextKind = "Context"
 
+const (
+	// ContextSourceAnnotation is the annotation used to indicate a secret is non-sensitive and
+	// permitted to be used as a source for a context variable
+	ContextSourceAnnotation = "terraform.appvia.io/context-source"
+)
+
+const (
+	// ContextSourceClusterNodeRegions is a cluster fact for the regions of the nodes
+	ContextSourceClusterNodeRegions = "NodeRegions"
+	// ContextSourceClusterNodeZones is a cluster fact for the availability zones of the nodes
+	ContextSourceClusterNodeZones = "NodeZones"
+)
+
 const (
 	// ContextDescription is the description field name
 	ContextDescription = "description"
@@ -59,6 +73,142 @@ type ContextSpec struct {
 	// a description and a value.
 	// +kubebuilder:validation:Required
 	Variables map[string]runtime.RawExtension `json:"variables"`
+	// Sources is a collection of dynamic sources for variables within the context. The values
+	// are resolved by the controller and kept in sync within the variables
+	// +kubebuilder:validation:Optional
+	Sources []ContextSource `json:"sources,omitempty"`
+	// Secrets is a collection of sensitive variables. Rather than holding the value, each entry
+	// references a secret in the controller namespace, which is only resolved when building the
+	// configuration for the terraform job
+	// +kubebuilder:validation:Optional
+	Secrets map[string]ContextSecret `json:"secrets,omitempty"`
+}
+
+// ContextSecret defines a sensitive variable whose value is held in a secret
+type ContextSecret struct {
+	// Description is a description for the variable
+	// +kubebuilder:validation:Required
+	Description string `json:"description"`
+	// SecretRef is a reference to the key within a secret in the controller namespace
+	// +kubebuilder:validation:Required
+	SecretRef ContextSecretKeyReference `json:"secretRef"`
+	// Selector provides the ability to filter who can consume the variable. If empty, all
+	// configurations in the cluster are permitted to use it. Otherwise you can specify a
+	// selector which can use namespace and resource labels
+	// +kubebuilder:validation:Optional
+	Selector *Selector `json:"selector,omitempty"`
+}
+
+// ContextSecretKeyReference is a reference to a key within a secret in the controller namespace
+type ContextSecretKeyReference struct {
+	// Name is the name of the secret
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// Key is the key within the secret
+	// +kubebuilder:validation:Required
+	Key string `json:"key"`
+}
+
+// GetSecret returns the sensitive variable if it exists
+func (c *ContextSpec) GetSecret(name string) (ContextSecret, bool) {
+	if len(c.Secrets) == 0 {
+		return ContextSecret{}, false
+	}
+	secret, found := c.Secrets[name]
+
+	return secret, found
+}
+
+// HasSecret returns true if the context has a sensitive variable with the name
+func (c *ContextSpec) HasSecret(name string) bool {
+	_, found := c.GetSecret(name)
+
+	return found
+}
+
+// ContextSource defines a variable whose value is retrieved from another resource
+type ContextSource struct {
+	// Name is the name of the variable within the context
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// Description is a description for the variable
+	// +kubebuilder:validation:Required
+	Description string `json:"description"`
+	// ConfigMap is a reference to a key within a configmap
+	// +kubebuilder:validation:Optional
+	ConfigMap *ContextSourceKeyReference `json:"configMap,omitempty"`
+	// Secret is a reference to a key within a secret. The secret must be annotated with
+	// terraform.appvia.io/context-source=true to indicate it is non-sensitive.
+	// +kubebuilder:validation:Optional
+	Secret *ContextSourceKeyReference `json:"secret,omitempty"`
+	// Configuration is a reference to an output of another configuration
+	// +kubebuilder:validation:Optional
+	Configuration *ContextSourceConfigurationReference `json:"configuration,omitempty"`
+	// Cluster is a fact retrieved from the cluster, i.e. NodeZones or NodeRegions
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=NodeRegions;NodeZones
+	Cluster string `json:"cluster,omitempty"`
+}
+
+// ContextSourceKeyReference is a reference to a key within a resource
+type ContextSourceKeyReference struct {
+	// Namespace is the namespace of the resource
+	// +kubebuilder:validation:Required
+	Namespace string `json:"namespace"`
+	// Name is the name of the resource
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// Key is the key within the resource
+	// +kubebuilder:validation:Required
+	Key string `json:"key"`
+}
+
+// ContextSourceConfigurationReference is a reference to an output of a configuration
+type ContextSourceConfigurationReference struct {
+	// Namespace is the namespace of the configuration
+	// +kubebuilder:validation:Required
+	Namespace string `json:"namespace"`
+	// Name is the name of the configuration
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// Output is the name of the terraform output
+	// +kubebuilder:validation:Required
+	Output string `json:"output"`
+}
+
+// HasSources returns true if the context has any dynamic sources
+func (c *ContextSpec) HasSources() bool {
+	return len(c.Sources) > 0
+}
+
+// HasConfigurationSource returns true if the context has a source referencing the configuration
+func (c *ContextSpec) HasConfigurationSource(namespace, name string) bool {
+	for _, x := range c.Sources {
+		if x.Configuration != nil && x.Configuration.Namespace == namespace && x.Configuration.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CountSourceTypes returns the number of source types defined on the source
+func (c *ContextSource) CountSourceTypes() int {
+	var count int
+	if c.ConfigMap != nil {
+		count++
+	}
+	if c.Secret != nil {
+		count++
+	}
+	if c.Configuration != nil {
+		count++
+	}
+	if c.Cluster != "" {
+		count++
+	}
+
+	return count
 }
 
 // GetVariable returns the variable value if it exists
@@ -129,6 +279,86 @@ type Context struct {
 // +k8s:openapi-gen=true
 type ContextStatus struct {
 	corev1alpha1.CommonStatus `json:",inline"`
+	// Preload holds the details of the changes made to the context by the last preload
+	// +kubebuilder:validation:Optional
+	Preload *ContextPreloadStatus `json:"preload,omitempty"`
+	// Sources is the resolution state of the dynamic sources
+	// +kubebuilder:validation:Optional
+	Sources []ContextSourceStatus `json:"sources,omitempty"`
+}
+
+// ContextSourceStatus is the resolution state of a dynamic source
+type ContextSourceStatus struct {
+	// Name is the name of the variable
+	Name string `json:"name"`
+	// Resolved indicates the value of the source was resolved
+	Resolved bool `json:"resolved"`
+	// Message provides a reason when the source could not be resolved
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+	// LastResolved is the last time the source was successfully resolved
+	// +kubebuilder:validation:Optional
+	LastResolved *metav1.Time `json:"lastResolved,omitempty"`
+}
+
+// ContextPreloadStatus is a summary of the changes made to the context by the preloader
+type ContextPreloadStatus struct {
+	// Added is a list of variables which were added by the last change
+	// +kubebuilder:validation:Optional
+	Added []string `json:"added,omitempty"`
+	// Changed is a list of variables whose value was changed by the last change
+	// +kubebuilder:validation:Optional
+	Changed []string `json:"changed,omitempty"`
+	// Checksums is a map of variable name to a checksum of the value loaded by the
+	// preloader, and used to detect changes between runs
+	// +kubebuilder:validation:Optional
+	Checksums map[string]string `json:"checksums,omitempty"`
+	// LastChangeTime is the last time the preloader changed the context
+	// +kubebuilder:validation:Optional
+	LastChangeTime *metav1.Time `json:"lastChangeTime,omitempty"`
+	// Removed is a list of variables which were removed by the last change
+	// +kubebuilder:validation:Optional
+	Removed []string `json:"removed,omitempty"`
+}
+
+// GetPreloadKeys returns the variables within the context which were written by the preloader
+func (c *Context) GetPreloadKeys() []string {
+	value := c.GetAnnotations()[PreloadKeysAnnotation]
+	if value == "" {
+		return nil
+	}
+
+	return strings.Split(value, ",")
+}
+
+// HasPreloadChecksums returns true if the context has a record of the preloaded values
+func (c *Context) HasPreloadChecksums() bool {
+	return c.Status.Preload != nil && len(c.Status.Preload.Checksums) > 0
+}
+
+// GetPreloadChecksums returns the checksums of the preloaded values if any
+func (c *Context) GetPreloadChecksums() map[string]string {
+	if !c.HasPreloadChecksums() {
+		return nil
+	}
+
+	return c.Status.Preload.Checksums
+}
+
+// GetCommonStatus returns the common status
+func (c *Context) GetCommonStatus() *corev1alpha1.CommonStatus {
+	return &c.Status.CommonStatus
+}
+
+// GetSourceStatus returns the status of the source if found
+func (c *Context) GetSourceStatus(name string) (ContextSourceStatus, bool) {
+	for _, x := range c.Status.Sources {
+		if x.Name == name {
+			return x, true
+		}
+	}
+
+	return ContextSourceStatus{}, false
 }
 
 // GetNamespacedName returns the namespaced resource type
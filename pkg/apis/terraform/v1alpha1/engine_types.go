@@ -0,0 +1,35 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package v1alpha1
+
+const (
+	// EngineOpenTofu indicates the configuration is executed using OpenTofu
+	EngineOpenTofu = "opentofu"
+	// EngineTerraform indicates the configuration is executed using HashiCorp Terraform
+	EngineTerraform = "terraform"
+)
+
+// IsValidEngine returns true if the engine is supported
+func IsValidEngine(engine string) bool {
+	switch engine {
+	case EngineOpenTofu, EngineTerraform:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,165 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// HookPrePlan indicates the hook is executed before the terraform plan
+	HookPrePlan = "pre-plan"
+	// HookPostPlan indicates the hook is executed after a successful terraform plan
+	HookPostPlan = "post-plan"
+	// HookPreApply indicates the hook is executed before the terraform apply
+	HookPreApply = "pre-apply"
+	// HookPostApply indicates the hook is executed after a successful terraform apply
+	HookPostApply = "post-apply"
+	// HookPreDestroy indicates the hook is executed before the terraform destroy
+	HookPreDestroy = "pre-destroy"
+	// HookPostDestroy indicates the hook is executed after a successful terraform destroy
+	HookPostDestroy = "post-destroy"
+)
+
+// HookMaxNameLength is the maximum length of a hook name, as it forms part of the container name
+const HookMaxNameLength = 40
+
+// Hook defines a custom step executed within the terraform job of a stage, such as linting the
+// module before a plan or running a smoke test after an apply
+type Hook struct {
+	// Name is the name of the hook, which must be unique within the configuration
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=40
+	Name string `json:"name"`
+	// When is the point the hook is executed i.e. pre-plan, post-plan, pre-apply, post-apply,
+	// pre-destroy or post-destroy. Pre hooks are executed in order, while post hooks are executed
+	// concurrently and only when the stage was successful
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=pre-plan;post-plan;pre-apply;post-apply;pre-destroy;post-destroy
+	When string `json:"when"`
+	// Image is the container image the hook is executed in, the image must provide a shell
+	// +kubebuilder:validation:Required
+	Image string `json:"image"`
+	// Commands is a collection of shell commands executed in order by the hook. The module
+	// source is available in the working directory
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	Commands []string `json:"commands"`
+	// ContinueOnError indicates a failure of the hook should not fail the stage
+	// +kubebuilder:validation:Optional
+	ContinueOnError bool `json:"continueOnError,omitempty"`
+	// Retries is the number of times the commands are retried on failure
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	Retries int `json:"retries,omitempty"`
+	// Timeout is the maximum duration of each command, after which the command is failed
+	// +kubebuilder:validation:Optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// GetStage returns the stage the hook is executed within
+func (h *Hook) GetStage() string {
+	_, stage, _ := strings.Cut(h.When, "-")
+
+	return stage
+}
+
+// IsPre returns true if the hook is executed before the stage
+func (h *Hook) IsPre() bool {
+	return strings.HasPrefix(h.When, "pre-")
+}
+
+// IsValid returns an error if the hook is invalid
+func (h *Hook) IsValid(path string) error {
+	switch {
+	case h.Name == "":
+		return fmt.Errorf("%s.name is required", path)
+	case len(h.Name) > HookMaxNameLength:
+		return fmt.Errorf("%s.name must be no more than %d characters", path, HookMaxNameLength)
+	case len(validation.IsDNS1123Label(h.Name)) > 0:
+		return fmt.Errorf("%s.name must be a valid dns label", path)
+	case h.Image == "":
+		return fmt.Errorf("%s.image is required", path)
+	case len(h.Commands) == 0:
+		return fmt.Errorf("%s.commands is required", path)
+	case h.Retries < 0:
+		return fmt.Errorf("%s.retries must be greater than or equal to 0", path)
+	case h.Timeout != nil && h.Timeout.Duration <= 0:
+		return fmt.Errorf("%s.timeout must be greater than 0", path)
+	}
+
+	switch h.When {
+	case HookPrePlan, HookPostPlan, HookPreApply, HookPostApply, HookPreDestroy, HookPostDestroy:
+	default:
+		return fmt.Errorf("%s.when must be one of pre-plan, post-plan, pre-apply, post-apply, pre-destroy or post-destroy", path)
+	}
+
+	for i, command := range h.Commands {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("%s.commands[%d] cannot be empty", path, i)
+		}
+	}
+
+	return nil
+}
+
+// HookList is a collection of hooks
+type HookList []Hook
+
+// IsValid returns an error if any of the hooks are invalid, or the names are not unique
+func (l HookList) IsValid(path string) error {
+	names := make(map[string]bool)
+
+	for i := range l {
+		if err := l[i].IsValid(fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+		if names[l[i].Name] {
+			return fmt.Errorf("%s[%d].name %q is not unique", path, i, l[i].Name)
+		}
+		names[l[i].Name] = true
+	}
+
+	return nil
+}
+
+// HookDefaults provides platform administrators the ability to inject hooks into the
+// jobs of the matching configurations
+type HookDefaults struct {
+	// Selector is used to determine which configurations the hooks are injected into
+	// +kubebuilder:validation:Required
+	Selector DefaultVariablesSelector `json:"selector"`
+	// Hooks is a collection of hooks to inject into the matching configurations
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	Hooks []Hook `json:"hooks"`
+}
+
+// IsValid returns an error if the hook defaults are invalid
+func (h *HookDefaults) IsValid(path string) error {
+	if len(h.Hooks) == 0 {
+		return errors.New(path + ".hooks is required")
+	}
+
+	return HookList(h.Hooks).IsValid(path + ".hooks")
+}
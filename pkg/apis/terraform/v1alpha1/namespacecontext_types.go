@@ -0,0 +1,137 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	corev1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/core/v1alpha1"
+)
+
+// NamespaceContextKind is the kind for a NamespaceContext
+const NamespaceContextKind = "NamespaceContext"
+
+// NewNamespaceContext creates a new NamespaceContext
+func NewNamespaceContext(namespace, name string) *NamespaceContext {
+	return &NamespaceContext{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       NamespaceContextKind,
+			APIVersion: SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+}
+
+// NamespaceContextSpec defines the desired state for a namespace context
+// +k8s:openapi-gen=true
+type NamespaceContextSpec struct {
+	// Variables is a list of variables which can be used by the configurations within the
+	// namespace. The structure of the variables is a map of key/value pairs, which MUST have
+	// both a description and a value.
+	// +kubebuilder:validation:Required
+	Variables map[string]runtime.RawExtension `json:"variables"`
+}
+
+// GetVariable returns the variable value if it exists
+func (c *NamespaceContextSpec) GetVariable(key string) (interface{}, bool, error) {
+	return c.toContextSpec().GetVariable(key)
+}
+
+// GetVariableValue returns the string value of the a variable
+func (c *NamespaceContextSpec) GetVariableValue(name string) (runtime.RawExtension, bool) {
+	return c.toContextSpec().GetVariableValue(name)
+}
+
+// HasVariables returns true if the context has variables defined
+func (c *NamespaceContextSpec) HasVariables() bool {
+	return c.toContextSpec().HasVariables()
+}
+
+// HasVariable returns true if the context has the variable defined
+func (c *NamespaceContextSpec) HasVariable(name string) bool {
+	return c.toContextSpec().HasVariable(name)
+}
+
+// toContextSpec returns the variables as a context spec, so we can share the helpers
+func (c *NamespaceContextSpec) toContextSpec() *ContextSpec {
+	return &ContextSpec{Variables: c.Variables}
+}
+
+// +kubebuilder:webhook:name=namespacecontexts.terraform.appvia.io,mutating=false,path=/validate/terraform.appvia.io/namespacecontexts,verbs=create;delete;update,groups="terraform.appvia.io",resources=namespacecontexts,versions=v1alpha1,failurePolicy=fail,sideEffects=None,admissionReviewVersions=v1
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NamespaceContext is the schema for a context scoped to a namespace. Values defined here
+// take precedence over a cluster Context of the same name
+// +k8s:openapi-gen=true
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=namespacecontexts,scope=Namespaced,categories={terraform}
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type NamespaceContext struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NamespaceContextSpec   `json:"spec,omitempty"`
+	Status NamespaceContextStatus `json:"status,omitempty"`
+}
+
+// NamespaceContextStatus defines the observed state of a namespace context
+// +k8s:openapi-gen=true
+type NamespaceContextStatus struct {
+	corev1alpha1.CommonStatus `json:",inline"`
+}
+
+// GetCommonStatus returns the common status
+func (c *NamespaceContext) GetCommonStatus() *corev1alpha1.CommonStatus {
+	return &c.Status.CommonStatus
+}
+
+// GetNamespacedName returns the namespaced resource type
+func (c *NamespaceContext) GetNamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Namespace: c.Namespace,
+		Name:      c.Name,
+	}
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NamespaceContextList contains a list of namespace contexts
+type NamespaceContextList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NamespaceContext `json:"items"`
+}
+
+// GetItem returns the item if the list contains the item name
+func (c *NamespaceContextList) GetItem(name string) (NamespaceContext, bool) {
+	for _, item := range c.Items {
+		if item.Name == name {
+			return item, true
+		}
+	}
+
+	return NamespaceContext{}, false
+}
@@ -0,0 +1,37 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// PodTemplateDefaults provides platform administrators the ability to customise the pods of the
+// jobs for the matching configurations
+type PodTemplateDefaults struct {
+	// Selector is used to determine which configurations the overrides are applied to
+	// +kubebuilder:validation:Required
+	Selector DefaultVariablesSelector `json:"selector"`
+	// Template is a partial pod template which is strategically merged onto the pod template
+	// of the jobs, i.e. node selectors, tolerations, affinity, priority class, additional volumes,
+	// sidecars or the resources of the terraform container
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Template runtime.RawExtension `json:"template"`
+}
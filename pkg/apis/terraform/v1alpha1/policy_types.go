@@ -140,6 +140,19 @@ type PolicySpec struct {
 	// resource labels and automatically inject variables into the configurations.
 	// +kubebuilder:validation:Optional
 	Defaults []DefaultVariables `json:"defaults,omitempty"`
+	// Hooks provides the ability to target specific terraform modules based on namespace or
+	// module and inject hooks into the jobs, in addition to those defined by the configuration
+	// +kubebuilder:validation:Optional
+	Hooks []HookDefaults `json:"hooks,omitempty"`
+	// PodTemplateOverrides provides the ability to target specific terraform modules based on
+	// namespace or module and customise the pods of the jobs, without replacing the job template
+	// +kubebuilder:validation:Optional
+	PodTemplateOverrides []PodTemplateDefaults `json:"podTemplateOverrides,omitempty"`
+	// Retries provides the ability to target specific terraform modules based on namespace or
+	// module and apply an automatic retry policy for transient failures. Configurations which
+	// define their own retry policy take precedence.
+	// +kubebuilder:validation:Optional
+	Retries []RetryDefaults `json:"retries,omitempty"`
 }
 
 // +kubebuilder:webhook:name=policies.terraform.appvia.io,mutating=false,path=/validate/terraform.appvia.io/policies,verbs=create;delete;update,groups="terraform.appvia.io",resources=policies,versions=v1alpha1,failurePolicy=fail,sideEffects=None,admissionReviewVersions=v1
@@ -51,6 +51,8 @@ var (
 	DefaultProviderAnnotation = "terranetes.appvia.io/default-provider"
 	// PreloadJobLabel is used to label the preload job
 	PreloadJobLabel = "terranetes.appvia.io/preload-job"
+	// PreloadKeysAnnotation is used to record the variables in a context written by the preloader
+	PreloadKeysAnnotation = "terranetes.appvia.io/preload-keys"
 	// PreloadProviderLabel is used to label the preload provider
 	PreloadProviderLabel = "terranetes.appvia.io/preload-provider-name"
 )
@@ -114,6 +116,15 @@ type PreloadConfiguration struct {
 	// Region is the cloud region the cluster is location in
 	// +kubebuilder:validation:Optional
 	Region string `json:"region,omitempty"`
+	// ReplanOnChange indicates any configurations which reference a context variable
+	// changed by the preloader should be automatically retried
+	// +kubebuilder:validation:Optional
+	ReplanOnChange *bool `json:"replanOnChange,omitempty"`
+}
+
+// IsReplanOnChangeEnabled returns true if configurations should be retried on changes
+func (p *PreloadConfiguration) IsReplanOnChangeEnabled() bool {
+	return ptr.Deref(p.ReplanOnChange, false)
 }
 
 // GetIntervalOrDefault returns the interval or the default
@@ -128,6 +139,11 @@ func (p *PreloadConfiguration) GetIntervalOrDefault(value time.Duration) time.Du
 // ProviderSpec defines the desired state of a provider
 // +k8s:openapi-gen=true
 type ProviderSpec struct {
+	// Agent is the name of a remote execution agent which runs the jobs for the configurations
+	// using this provider, rather than the cluster the controller is running in. The agent must
+	// be registered with the controller and permitted to serve the provider.
+	// +kubebuilder:validation:Optional
+	Agent string `json:"agent,omitempty"`
 	// Configuration is optional configuration to the provider. This is terraform provider specific.
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:pruning:PreserveUnknownFields
@@ -138,10 +154,23 @@ type ProviderSpec struct {
 	// single field 'backend.tf' which contains the backend template.
 	// +kubebuilder:validation:Optional
 	BackendTemplate *v1.SecretReference `json:"backendTemplate,omitempty"`
+	// Engine is the default engine used to execute the configurations which use this provider,
+	// i.e. terraform or opentofu. Configurations are able to override this via their own engine
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=terraform;opentofu
+	Engine string `json:"engine,omitempty"`
 	// Job defined a custom collection of labels and annotations to be applied to all jobs
 	// which are created and 'use' this provider.
 	// +kubebuilder:validation:Optional
 	Job *JobMetadata `json:"job,omitempty"`
+	// PodTemplateOverrides is a partial pod template which is strategically merged onto the pod
+	// template of all jobs which use this provider, i.e. node selectors, tolerations, affinity,
+	// priority class, additional volumes, sidecars or the resources of the terraform container.
+	// These are applied after any overrides from the policies, hence take precedence.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	PodTemplateOverrides *runtime.RawExtension `json:"podTemplateOverrides,omitempty"`
 	// Preload defines the configuration for the preloading of contextual data from the cloud vendor.
 	// +kubebuilder:validation:Optional
 	Preload *PreloadConfiguration `json:"preload,omitempty"`
@@ -252,6 +281,11 @@ func (p *Provider) HasBackendTemplate() bool {
 	return p.Spec.BackendTemplate != nil
 }
 
+// HasAgent returns true if the jobs using the provider are run by a remote agent
+func (p *Provider) HasAgent() bool {
+	return p.Spec.Agent != ""
+}
+
 // IsPreloadingEnabled returns true if the provider is enabled for preloading
 func (p *Provider) IsPreloadingEnabled() bool {
 	if p.Spec.Preload != nil && ptr.Deref(p.Spec.Preload.Enabled, false) {
@@ -0,0 +1,121 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package v1alpha1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultRetryBackoff is the initial delay before retrying a failed configuration
+	DefaultRetryBackoff = 30 * time.Second
+	// DefaultRetryMaxBackoff is the maximum delay between retries of a failed configuration
+	DefaultRetryMaxBackoff = 10 * time.Minute
+	// MinimumRetryBackoff is the smallest delay permitted between retries
+	MinimumRetryBackoff = 5 * time.Second
+)
+
+// RetryPolicy defines how a configuration should be automatically retried when a terraform
+// job fails due to a transient error, such as api throttling or state lock contention
+type RetryPolicy struct {
+	// Backoff is the initial delay before retrying, doubled on every subsequent attempt. Defaults
+	// to 30 seconds
+	// +kubebuilder:validation:Optional
+	Backoff *metav1.Duration `json:"backoff,omitempty"`
+	// Classes is a collection of error classes (e.g. throttling, state-lock) which are permitted
+	// to be retried. When empty, any error classified by a detector as retryable is retried.
+	// +kubebuilder:validation:Optional
+	Classes []string `json:"classes,omitempty"`
+	// MaxAttempts is the maximum number of automatic retries for a given generation of the
+	// configuration
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	MaxAttempts int `json:"maxAttempts"`
+	// MaxBackoff is the upper limit on the delay between retries. Defaults to 10 minutes
+	// +kubebuilder:validation:Optional
+	MaxBackoff *metav1.Duration `json:"maxBackoff,omitempty"`
+}
+
+// IsRetryable returns true if the error class is permitted to be retried by the policy
+func (r *RetryPolicy) IsRetryable(class string, retryable bool) bool {
+	if len(r.Classes) == 0 {
+		return retryable
+	}
+	for _, x := range r.Classes {
+		if x == class {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetBackoff returns the delay before the given attempt, starting from zero
+func (r *RetryPolicy) GetBackoff(attempt int) time.Duration {
+	backoff := DefaultRetryBackoff
+	if r.Backoff != nil {
+		backoff = r.Backoff.Duration
+	}
+	limit := DefaultRetryMaxBackoff
+	if r.MaxBackoff != nil {
+		limit = r.MaxBackoff.Duration
+	}
+	if backoff < MinimumRetryBackoff {
+		backoff = MinimumRetryBackoff
+	}
+
+	for i := 0; i < attempt && backoff < limit; i++ {
+		backoff *= 2
+	}
+	if backoff > limit {
+		backoff = limit
+	}
+
+	return backoff
+}
+
+// RetryDefaults provides platform administrators the ability to apply a retry policy to
+// configurations which do not define their own
+type RetryDefaults struct {
+	// Selector is used to determine which configurations the retry policy applies to
+	// +kubebuilder:validation:Required
+	Selector DefaultVariablesSelector `json:"selector"`
+	// Policy is the retry policy to apply to the matching configurations
+	// +kubebuilder:validation:Required
+	Policy RetryPolicy `json:"policy"`
+}
+
+// RetryStatus tracks the automatic retries of a configuration
+type RetryStatus struct {
+	// Attempts is the number of automatic retries made for this generation
+	// +kubebuilder:validation:Optional
+	Attempts int `json:"attempts,omitempty"`
+	// Class is the error class which caused the last retry
+	// +kubebuilder:validation:Optional
+	Class string `json:"class,omitempty"`
+	// Generation is the generation of the configuration the attempts relate to
+	// +kubebuilder:validation:Optional
+	Generation int64 `json:"generation,omitempty"`
+	// Job is the name of the last failed job which was retried
+	// +kubebuilder:validation:Optional
+	Job string `json:"job,omitempty"`
+	// NextAttempt is the time the next retry is scheduled for
+	// +kubebuilder:validation:Optional
+	NextAttempt *metav1.Time `json:"nextAttempt,omitempty"`
+}
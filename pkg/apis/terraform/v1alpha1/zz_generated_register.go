@@ -64,6 +64,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ConfigurationList{},
 		&Context{},
 		&ContextList{},
+		&NamespaceContext{},
+		&NamespaceContextList{},
 		&Plan{},
 		&PlanList{},
 		&Policy{},
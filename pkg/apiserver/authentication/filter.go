@@ -0,0 +1,145 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package authentication
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
+)
+
+// AttributesFunc returns the resource attributes the caller must be authorized against
+type AttributesFunc func(req *http.Request) authorizationv1.ResourceAttributes
+
+// userKey is the context key for the authenticated user
+type userKey struct{}
+
+// GetUser returns the authenticated user from the request context
+func GetUser(ctx context.Context) (authenticationv1.UserInfo, bool) {
+	user, found := ctx.Value(userKey{}).(authenticationv1.UserInfo)
+
+	return user, found
+}
+
+// Filter returns a middleware which authenticates the bearer token using a TokenReview and
+// authorizes the caller against the resource attributes using a SubjectAccessReview. The watcher
+// service account is permitted to read the configurations within its own namespace, this allows
+// the watcher jobs to retrieve the logs for the configurations
+func Filter(client kubernetes.Interface, attributes AttributesFunc) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			token := strings.TrimSpace(strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "))
+			if token == "" || !strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ") {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			review, err := client.AuthenticationV1().TokenReviews().Create(req.Context(), &authenticationv1.TokenReview{
+				Spec: authenticationv1.TokenReviewSpec{Token: token},
+			}, metav1.CreateOptions{})
+			if err != nil {
+				log.WithError(err).Error("failed to review the token")
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+			if !review.Status.Authenticated {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+			user := review.Status.User
+			attrs := attributes(req)
+
+			fields := log.Fields{
+				"namespace": attrs.Namespace,
+				"resource":  attrs.Resource,
+				"username":  user.Username,
+				"verb":      attrs.Verb,
+			}
+
+			allowed, err := isAllowed(req.Context(), client, user, attrs)
+			if err != nil {
+				log.WithFields(fields).WithError(err).Error("failed to review the access")
+				w.WriteHeader(http.StatusInternalServerError)
+
+				return
+			}
+			if !allowed {
+				log.WithFields(fields).Warn("caller is not permitted to access the resource")
+				w.WriteHeader(http.StatusForbidden)
+
+				return
+			}
+
+			next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), userKey{}, user)))
+		})
+	}
+}
+
+// isAllowed checks if the user is permitted to perform the action on the resource
+func isAllowed(ctx context.Context, client kubernetes.Interface, user authenticationv1.UserInfo, attrs authorizationv1.ResourceAttributes) (bool, error) {
+	if isWatcher(user, attrs) {
+		return true, nil
+	}
+
+	extra := make(map[string]authorizationv1.ExtraValue)
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+
+	review, err := client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			Extra:              extra,
+			Groups:             user.Groups,
+			ResourceAttributes: &attrs,
+			UID:                user.UID,
+			User:               user.Username,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return review.Status.Allowed, nil
+}
+
+// isWatcher checks if the user is the watcher service account reading the configurations within
+// its own namespace
+func isWatcher(user authenticationv1.UserInfo, attrs authorizationv1.ResourceAttributes) bool {
+	switch {
+	case attrs.Namespace == "":
+		return false
+	case attrs.Resource != "configurations" || attrs.Verb != "get":
+		return false
+	}
+
+	return user.Username == "system:serviceaccount:"+attrs.Namespace+":"+jobs.WatcherServiceAccount
+}
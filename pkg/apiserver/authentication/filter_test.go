@@ -0,0 +1,138 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package authentication
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newFakeClient returns a client which authenticates the token "valid" as the user and
+// only permits the user "admin"
+func newFakeClient(username string) *fake.Clientset {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if review.Spec.Token == "error" {
+			return true, nil, errors.New("failed")
+		}
+		if review.Spec.Token == "valid" {
+			review.Status.Authenticated = true
+			review.Status.User = authenticationv1.UserInfo{Username: username, Groups: []string{"developers"}}
+		}
+
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		review.Status.Allowed = review.Spec.User == "admin" &&
+			review.Spec.ResourceAttributes.Resource == "configurations" &&
+			review.Spec.ResourceAttributes.Namespace == "apps"
+
+		return true, review, nil
+	})
+
+	return client
+}
+
+// serve performs a request with the token against the filter
+func serve(client *fake.Clientset, authorization string) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.Use(Filter(client, func(_ *http.Request) authorizationv1.ResourceAttributes {
+		return authorizationv1.ResourceAttributes{Namespace: "apps", Resource: "configurations", Verb: "get"}
+	}))
+	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		user, _ := GetUser(r.Context())
+		_, _ = w.Write([]byte(user.Username))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	return resp
+}
+
+func TestFilterNoToken(t *testing.T) {
+	resp := serve(newFakeClient("admin"), "")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Equal(t, "Bearer", resp.Header().Get("WWW-Authenticate"))
+}
+
+func TestFilterNotBearer(t *testing.T) {
+	resp := serve(newFakeClient("admin"), "Basic dXNlcjpwYXNz")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+func TestFilterInvalidToken(t *testing.T) {
+	resp := serve(newFakeClient("admin"), "Bearer invalid")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+func TestFilterTokenReviewError(t *testing.T) {
+	resp := serve(newFakeClient("admin"), "Bearer error")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+}
+
+func TestFilterAuthorized(t *testing.T) {
+	resp := serve(newFakeClient("admin"), "Bearer valid")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "admin", resp.Body.String())
+}
+
+func TestFilterForbidden(t *testing.T) {
+	resp := serve(newFakeClient("developer"), "Bearer valid")
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
+
+func TestFilterWatcherSameNamespace(t *testing.T) {
+	resp := serve(newFakeClient("system:serviceaccount:apps:terranetes-watcher"), "Bearer valid")
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestFilterWatcherOtherNamespace(t *testing.T) {
+	resp := serve(newFakeClient("system:serviceaccount:other:terranetes-watcher"), "Bearer valid")
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
+
+func TestFilterServiceAccountSameNamespace(t *testing.T) {
+	resp := serve(newFakeClient("system:serviceaccount:apps:default"), "Bearer valid")
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
+
+func TestIsWatcher(t *testing.T) {
+	user := authenticationv1.UserInfo{Username: "system:serviceaccount:apps:terranetes-watcher"}
+
+	assert.True(t, isWatcher(user, authorizationv1.ResourceAttributes{Namespace: "apps", Resource: "configurations", Verb: "get"}))
+	assert.False(t, isWatcher(user, authorizationv1.ResourceAttributes{Namespace: "apps", Resource: "configurations", Verb: "watch"}))
+	assert.False(t, isWatcher(user, authorizationv1.ResourceAttributes{Namespace: "apps", Resource: "cloudresources", Verb: "get"}))
+	assert.False(t, isWatcher(user, authorizationv1.ResourceAttributes{Resource: "configurations", Verb: "get"}))
+}
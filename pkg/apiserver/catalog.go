@@ -0,0 +1,389 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package apiserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/utils"
+	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+	"github.com/appvia/terranetes-controller/pkg/utils/policies"
+)
+
+const (
+	// DefaultCatalogLimit is the default number of plans returned per page
+	DefaultCatalogLimit = 20
+	// MaxCatalogLimit is the maximum number of plans returned per page
+	MaxCatalogLimit = 100
+)
+
+// CatalogPlanList is a page of plans available to a namespace
+type CatalogPlanList struct {
+	// Continue is the token used to retrieve the next page, empty on the last page
+	Continue string `json:"continue,omitempty"`
+	// Items is a collection of plans
+	Items []CatalogPlan `json:"items"`
+}
+
+// CatalogPlan is a plan available to a namespace
+type CatalogPlan struct {
+	// Name is the name of the plan
+	Name string `json:"name"`
+	// Latest is the latest revision of the plan available to the namespace
+	Latest string `json:"latest,omitempty"`
+	// Revisions is a collection of revisions available to the namespace
+	Revisions []CatalogRevision `json:"revisions"`
+}
+
+// CatalogRevision is a revision of a plan available to a namespace
+type CatalogRevision struct {
+	// Name is the name of the revision resource
+	Name string `json:"name"`
+	// Revision is the version of the revision
+	Revision string `json:"revision"`
+	// Description is a short description of the revision
+	Description string `json:"description"`
+	// Categories is a list of categories the revision is grouped by
+	Categories []string `json:"categories,omitempty"`
+	// ChangeLog is a human readable list of changes for the revision
+	ChangeLog string `json:"changeLog,omitempty"`
+	// Dependencies is a collection of dependencies for the revision
+	Dependencies []terraformv1alpha1.RevisionDependency `json:"dependencies,omitempty"`
+	// Inputs is a collection of inputs the consumer can or must provide
+	Inputs []terraformv1alpha1.RevisionInput `json:"inputs,omitempty"`
+	// InUse is the number of cloud resources using the revision
+	InUse int `json:"inUse"`
+}
+
+// handleCatalogPlans is http handler for listing the plans available to a namespace
+func (s *Server) handleCatalogPlans(w http.ResponseWriter, req *http.Request) {
+	namespace := mux.Vars(req)["namespace"]
+	if err := validateInput("namespace", namespace); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	limit := DefaultCatalogLimit
+	if value := req.URL.Query().Get("limit"); value != "" {
+		v, err := strconv.Atoi(value)
+		if err != nil || v <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		limit = v
+	}
+	if limit > MaxCatalogLimit {
+		limit = MaxCatalogLimit
+	}
+	token := req.URL.Query().Get("continue")
+	if token != "" {
+		if err := validateInput("continue", token); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	plans, found, err := s.findCatalog(req.Context(), namespace)
+	if err != nil {
+		log.WithError(err).WithField("namespace", namespace).Error("failed to retrieve the catalog")
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	// @step: the plans are sorted by name, the continue token is the last plan returned
+	list := &CatalogPlanList{Items: []CatalogPlan{}}
+	for _, x := range plans {
+		if token != "" && x.Name <= token {
+			continue
+		}
+		if len(list.Items) == limit {
+			list.Continue = list.Items[len(list.Items)-1].Name
+			break
+		}
+		list.Items = append(list.Items, x)
+	}
+
+	writeJSON(w, req, list)
+}
+
+// handleCatalogPlan is http handler for retrieving a single plan available to a namespace
+func (s *Server) handleCatalogPlan(w http.ResponseWriter, req *http.Request) {
+	namespace := mux.Vars(req)["namespace"]
+	name := mux.Vars(req)["name"]
+
+	for key, value := range map[string]string{"namespace": namespace, "name": name} {
+		if err := validateInput(key, value); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	plans, found, err := s.findCatalog(req.Context(), namespace)
+	if err != nil {
+		log.WithError(err).WithField("namespace", namespace).Error("failed to retrieve the catalog")
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+	if found {
+		for _, x := range plans {
+			if x.Name == name {
+				writeJSON(w, req, x)
+
+				return
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// findCatalog returns the plans, sorted by name, and the revisions the namespace is permitted
+// to use. A revision is permitted when a provider it requires is available to the namespace and
+// the module passes any module constraints which apply to the namespace
+func (s *Server) findCatalog(ctx context.Context, name string) ([]CatalogPlan, bool, error) {
+	namespace := &v1.Namespace{}
+	namespace.Name = name
+
+	if found, err := kubernetes.GetIfExists(ctx, s.CC, namespace); err != nil {
+		return nil, false, err
+	} else if !found {
+		return nil, false, nil
+	}
+
+	plans := &terraformv1alpha1.PlanList{}
+	if err := s.CC.List(ctx, plans); err != nil {
+		return nil, false, err
+	}
+	revisions := &terraformv1alpha1.RevisionList{}
+	if err := s.CC.List(ctx, revisions); err != nil {
+		return nil, false, err
+	}
+	providers := &terraformv1alpha1.ProviderList{}
+	if err := s.CC.List(ctx, providers); err != nil {
+		return nil, false, err
+	}
+	list := &terraformv1alpha1.PolicyList{}
+	if err := s.CC.List(ctx, list); err != nil {
+		return nil, false, err
+	}
+
+	// @step: find the providers available to the namespace
+	available := make(map[string]*terraformv1alpha1.Provider)
+	for i := 0; i < len(providers.Items); i++ {
+		provider := &providers.Items[i]
+		if provider.Spec.Selector != nil {
+			matched, err := isNamespaceMatch(provider.Spec.Selector, namespace)
+			if err != nil {
+				return nil, false, err
+			}
+			if !matched {
+				continue
+			}
+		}
+		available[provider.Name] = provider
+	}
+
+	// @step: find the module constraints which apply to the namespace
+	var constraints []terraformv1alpha1.Policy
+	for _, x := range policies.FindModuleConstraints(list) {
+		if x.Spec.Constraints.Modules.Selector != nil {
+			matched, err := isNamespaceMatch(x.Spec.Constraints.Modules.Selector, namespace)
+			if err != nil {
+				return nil, false, err
+			}
+			if !matched {
+				continue
+			}
+		}
+		constraints = append(constraints, x)
+	}
+
+	var catalog []CatalogPlan
+
+	for _, plan := range plans.Items {
+		item := CatalogPlan{Name: plan.Name, Revisions: []CatalogRevision{}}
+
+		for _, reference := range plan.Spec.Revisions {
+			revision, found := findRevision(revisions, reference.Name)
+			if !found {
+				continue
+			}
+
+			if permitted, err := isRevisionPermitted(revision, providers, available, constraints); err != nil {
+				return nil, false, err
+			} else if !permitted {
+				continue
+			}
+
+			item.Revisions = append(item.Revisions, CatalogRevision{
+				Categories:   revision.Spec.Plan.Categories,
+				ChangeLog:    revision.Spec.Plan.ChangeLog,
+				Dependencies: revision.Spec.Dependencies,
+				Description:  revision.Spec.Plan.Description,
+				InUse:        revision.Status.InUse,
+				Inputs:       revision.Spec.Inputs,
+				Name:         revision.Name,
+				Revision:     revision.Spec.Plan.Revision,
+			})
+		}
+		if len(item.Revisions) == 0 {
+			continue
+		}
+
+		var versions []string
+		for _, x := range item.Revisions {
+			versions = append(versions, x.Revision)
+		}
+		latest, err := utils.LatestSemverVersion(versions)
+		if err != nil {
+			return nil, false, err
+		}
+		item.Latest = latest
+
+		catalog = append(catalog, item)
+	}
+
+	sort.Slice(catalog, func(i, j int) bool {
+		return catalog[i].Name < catalog[j].Name
+	})
+
+	return catalog, true, nil
+}
+
+// findRevision returns the revision from the list
+func findRevision(list *terraformv1alpha1.RevisionList, name string) (*terraformv1alpha1.Revision, bool) {
+	for i := 0; i < len(list.Items); i++ {
+		if list.Items[i].Name == name {
+			return &list.Items[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// isRevisionPermitted checks the namespace has access to the providers required by the revision
+// and the module is permitted by the module constraints
+func isRevisionPermitted(
+	revision *terraformv1alpha1.Revision,
+	providers *terraformv1alpha1.ProviderList,
+	available map[string]*terraformv1alpha1.Provider,
+	constraints []terraformv1alpha1.Policy) (bool, error) {
+
+	// @step: build the list of cloud providers required by the revision
+	var required []terraformv1alpha1.ProviderType
+	for _, x := range revision.Spec.Dependencies {
+		if x.Provider != nil {
+			required = append(required, terraformv1alpha1.ProviderType(x.Provider.Cloud))
+		}
+	}
+
+	// @step: a provider referenced by the revision can be overridden by the cloud resource, so we
+	// only need access to a provider of the same type
+	if ref := revision.Spec.Configuration.ProviderRef; ref != nil {
+		if _, found := available[ref.Name]; !found {
+			var referenced *terraformv1alpha1.Provider
+			for i := 0; i < len(providers.Items); i++ {
+				if providers.Items[i].Name == ref.Name {
+					referenced = &providers.Items[i]
+				}
+			}
+			if referenced == nil {
+				return false, nil
+			}
+			required = append(required, referenced.Spec.Provider)
+		}
+	}
+
+	for _, cloud := range required {
+		var found bool
+		for _, provider := range available {
+			if provider.Spec.Provider == cloud {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+
+	if len(constraints) == 0 {
+		return true, nil
+	}
+	for _, x := range constraints {
+		if matched, err := x.Spec.Constraints.Modules.Matches(revision.Spec.Configuration.Module); err != nil {
+			return false, fmt.Errorf("failed to compile the policy: %s, error: %w", x.Name, err)
+		} else if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// isNamespaceMatch checks the namespace selector only; resource labels are chosen by the consumer
+// so cannot be evaluated until the resource is created
+func isNamespaceMatch(selector *terraformv1alpha1.Selector, namespace *v1.Namespace) (bool, error) {
+	return kubernetes.IsSelectorMatch(terraformv1alpha1.Selector{Namespace: selector.Namespace}, nil, namespace.GetLabels())
+}
+
+// writeJSON encodes the response with an etag, returning not modified if the caller already has it
+func writeJSON(w http.ResponseWriter, req *http.Request, value interface{}) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		log.WithError(err).Error("failed to encode the response")
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(encoded))
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", etag)
+
+	if req.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	_, _ = w.Write(encoded)
+}
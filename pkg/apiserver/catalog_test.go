@@ -0,0 +1,201 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/schema"
+	"github.com/appvia/terranetes-controller/test/fixtures"
+)
+
+// newCatalogServer returns a server with a bucket plan at two revisions
+func newCatalogServer(objects ...client.Object) *Server {
+	first := fixtures.NewAWSBucketRevisionAtVersion("bucket.v1", "1.0.0")
+	second := fixtures.NewAWSBucketRevisionAtVersion("bucket.v2", "2.0.0")
+	second.Status.InUse = 3
+
+	objects = append(objects,
+		fixtures.NewNamespace("apps"),
+		fixtures.NewNamespace("other"),
+		fixtures.NewValidAWSProvider("aws", nil),
+		fixtures.NewPlan("bucket", first, second),
+		first,
+		second,
+	)
+
+	return &Server{
+		CC: fake.NewClientBuilder().WithScheme(schema.GetScheme()).WithObjects(objects...).Build(),
+	}
+}
+
+// getCatalog performs the request against the server
+func getCatalog(t *testing.T, s *Server, uri string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, uri, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	s.Serve().ServeHTTP(w, req)
+
+	return w
+}
+
+// decodePlans decodes the plans from the response
+func decodePlans(t *testing.T, w *httptest.ResponseRecorder) *CatalogPlanList {
+	list := &CatalogPlanList{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(list))
+
+	return list
+}
+
+func TestCatalogPlans(t *testing.T) {
+	w := getCatalog(t, newCatalogServer(), "/v1/catalog/apps/plans", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+
+	list := decodePlans(t, w)
+	require.Len(t, list.Items, 1)
+	assert.Empty(t, list.Continue)
+
+	plan := list.Items[0]
+	assert.Equal(t, "bucket", plan.Name)
+	assert.Equal(t, "2.0.0", plan.Latest)
+	require.Len(t, plan.Revisions, 2)
+	assert.Equal(t, "1.0.0", plan.Revisions[0].Revision)
+	assert.Equal(t, "Creates an S3 bucket", plan.Revisions[0].Description)
+	assert.Equal(t, "bucket_name", plan.Revisions[0].Inputs[0].Key)
+	assert.Equal(t, "aws", plan.Revisions[0].Dependencies[0].Provider.Cloud)
+	assert.Equal(t, 3, plan.Revisions[1].InUse)
+}
+
+func TestCatalogNamespaceNotFound(t *testing.T) {
+	w := getCatalog(t, newCatalogServer(), "/v1/catalog/missing/plans", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCatalogInvalidInput(t *testing.T) {
+	for _, uri := range []string{
+		"/v1/catalog/apps/plans?limit=bad",
+		"/v1/catalog/apps/plans?limit=0",
+		"/v1/catalog/apps/plans?continue=%24%24",
+		"/v1/catalog/a%24b/plans",
+	} {
+		w := getCatalog(t, newCatalogServer(), uri, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "case: %s", uri)
+	}
+}
+
+func TestCatalogPagination(t *testing.T) {
+	revision := fixtures.NewAWSBucketRevisionAtVersion("database.v1", "1.0.0")
+	revision.Spec.Plan.Name = "database"
+	s := newCatalogServer(fixtures.NewPlan("database", revision), revision)
+
+	w := getCatalog(t, s, "/v1/catalog/apps/plans?limit=1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	list := decodePlans(t, w)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "bucket", list.Items[0].Name)
+	assert.Equal(t, "bucket", list.Continue)
+
+	w = getCatalog(t, s, "/v1/catalog/apps/plans?limit=1&continue="+list.Continue, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	list = decodePlans(t, w)
+	require.Len(t, list.Items, 1)
+	assert.Equal(t, "database", list.Items[0].Name)
+	assert.Empty(t, list.Continue)
+}
+
+func TestCatalogETag(t *testing.T) {
+	s := newCatalogServer()
+
+	w := getCatalog(t, s, "/v1/catalog/apps/plans", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+
+	w = getCatalog(t, s, "/v1/catalog/apps/plans", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = getCatalog(t, s, "/v1/catalog/apps/plans", map[string]string{"If-None-Match": `"stale"`})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCatalogProviderSelector(t *testing.T) {
+	s := newCatalogServer()
+
+	provider := &terraformv1alpha1.Provider{}
+	require.NoError(t, s.CC.Get(context.TODO(), client.ObjectKey{Name: "aws"}, provider))
+	provider.Spec.Selector = &terraformv1alpha1.Selector{
+		Namespace: &metav1.LabelSelector{MatchLabels: map[string]string{"name": "apps"}},
+	}
+	require.NoError(t, s.CC.Update(context.TODO(), provider))
+
+	w := getCatalog(t, s, "/v1/catalog/apps/plans", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, decodePlans(t, w).Items, 1)
+
+	w = getCatalog(t, s, "/v1/catalog/other/plans", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, decodePlans(t, w).Items)
+}
+
+func TestCatalogModuleConstraints(t *testing.T) {
+	policy := fixtures.NewMatchAllModuleConstraint("modules")
+	policy.Spec.Constraints.Modules.Allowed = []string{"^https://github.com/appvia/.*"}
+	policy.Spec.Constraints.Modules.Selector = &terraformv1alpha1.Selector{
+		Namespace: &metav1.LabelSelector{MatchLabels: map[string]string{"name": "apps"}},
+	}
+	s := newCatalogServer(policy)
+
+	w := getCatalog(t, s, "/v1/catalog/apps/plans", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, decodePlans(t, w).Items)
+
+	w = getCatalog(t, s, "/v1/catalog/other/plans", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, decodePlans(t, w).Items, 1)
+}
+
+func TestCatalogPlan(t *testing.T) {
+	s := newCatalogServer()
+
+	w := getCatalog(t, s, "/v1/catalog/apps/plans/bucket", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+
+	plan := &CatalogPlan{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(plan))
+	assert.Equal(t, "bucket", plan.Name)
+	assert.Len(t, plan.Revisions, 2)
+
+	w = getCatalog(t, s, "/v1/catalog/apps/plans/missing", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
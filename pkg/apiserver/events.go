@@ -0,0 +1,112 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+// eventsKeepAlive is the interval between keepalive comments on the event stream
+var eventsKeepAlive = 15 * time.Second
+
+// handleEvents streams the lifecycle events of configurations and cloudresources within the
+// namespace as server-sent events. An optional name restricts the events to a single resource
+func (s *Server) handleEvents(w http.ResponseWriter, req *http.Request) {
+	if s.Events == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+
+		return
+	}
+
+	namespace := mux.Vars(req)["namespace"]
+	if err := validateInput("namespace", namespace); err != nil {
+		log.WithError(err).Error("received an invalid request")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+	name := req.URL.Query().Get("name")
+	if name != "" {
+		if err := validateInput("name", name); err != nil {
+			log.WithError(err).Error("received an invalid request")
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		log.Error("response writer does not support flushing")
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	events, unsubscribe := s.Events.Subscribe(namespace)
+	defer unsubscribe()
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprint(w, ": watching events\n\n")
+	flusher.Flush()
+
+	ticker := time.NewTicker(eventsKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if name != "" && event.Name != name {
+				continue
+			}
+
+			encoded, err := json.Marshal(event)
+			if err != nil {
+				log.WithError(err).Error("failed to encode the event")
+
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, encoded); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package apiserver
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/appvia/terranetes-controller/pkg/apiserver/stream"
+)
+
+func TestEventsNoBroker(t *testing.T) {
+	w := getCatalog(t, &Server{}, "/v1/events/apps", nil)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
+
+func TestEventsInvalidName(t *testing.T) {
+	w := getCatalog(t, &Server{Events: stream.NewBroker()}, "/v1/events/apps?name=bad%2Fname", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestEventsStream(t *testing.T) {
+	broker := stream.NewBroker()
+	server := httptest.NewServer((&Server{Events: broker}).Serve())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/v1/events/apps?name=bucket")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": watching events\n", line)
+
+	require.Eventually(t, func() bool { return broker.Subscribers() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	broker.Publish(stream.Event{Type: stream.EventStage, Namespace: "apps", Name: "other", Stage: "plan"})
+	broker.Publish(stream.Event{Type: stream.EventStage, Namespace: "apps", Name: "bucket", Stage: "apply"})
+
+	var lines []string
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	assert.Equal(t, "id: 2", lines[0])
+	assert.Equal(t, "event: stage", lines[1])
+	assert.Contains(t, lines[2], `"name":"bucket"`)
+	assert.Contains(t, lines[2], `"stage":"apply"`)
+}
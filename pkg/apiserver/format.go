@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/appvia/terranetes-controller/pkg/utils/buildlog"
+)
+
+const (
+	// FormatText is the plain text format for the build logs
+	FormatText = "text"
+	// FormatJSON is the newline-delimited json format for the build logs
+	FormatJSON = "json"
+)
+
+// buildWriter writes the build logs in the requested format
+type buildWriter struct {
+	// encoder is used to write the records in json format
+	encoder *json.Encoder
+	// parser is the parser for the current container
+	parser *buildlog.Parser
+	// stage is the stage of the build
+	stage string
+	// w is the response writer
+	w http.ResponseWriter
+}
+
+// newBuildWriter returns a writer for the format, setting the content type on the response
+func newBuildWriter(w http.ResponseWriter, format, stage string) *buildWriter {
+	b := &buildWriter{stage: stage, w: w}
+
+	if format == FormatJSON {
+		b.encoder = json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		w.Header().Set("Content-Type", "text/plain")
+	}
+
+	return b
+}
+
+// isJSON returns true if the records are written as json
+func (b *buildWriter) isJSON() bool {
+	return b.encoder != nil
+}
+
+// status writes a status message from the controller
+func (b *buildWriter) status(level, message string) {
+	if b.isJSON() {
+		_ = b.encoder.Encode(&buildlog.Record{
+			Type:    buildlog.RecordStatus,
+			Stage:   b.stage,
+			Level:   level,
+			Message: message,
+			Time:    time.Now().UTC(),
+		})
+
+		return
+	}
+	_, _ = fmt.Fprintf(b.w, "[%s] %s\n", level, message)
+}
+
+// progress indicates we are still waiting on the build
+func (b *buildWriter) progress() {
+	if !b.isJSON() {
+		_, _ = b.w.Write([]byte("."))
+	}
+}
+
+// container indicates we are starting to stream the logs of a container
+func (b *buildWriter) container(name string) {
+	b.parser = buildlog.NewParser(b.stage, name, true)
+	if b.isJSON() {
+		_ = b.encoder.Encode(b.parser.Container())
+	}
+}
+
+// line writes a line of output from the current container
+func (b *buildWriter) line(line string) {
+	if !b.isJSON() {
+		_, _ = fmt.Fprintf(b.w, "%s\n", line)
+
+		return
+	}
+	if record, ok := b.parser.Parse(line); ok {
+		_ = b.encoder.Encode(record)
+	}
+}
+
+// completed indicates the build logs have completed
+func (b *buildWriter) completed() {
+	if b.isJSON() {
+		b.status(buildlog.LevelInfo, "completed")
+
+		return
+	}
+	_, _ = b.w.Write([]byte("[build] completed\n"))
+}
@@ -19,6 +19,8 @@ package apiserver
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -27,12 +29,17 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/appvia/terranetes-controller/pkg/agent"
 	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
 	"github.com/appvia/terranetes-controller/pkg/utils"
+	"github.com/appvia/terranetes-controller/pkg/utils/buildlog"
 	"github.com/appvia/terranetes-controller/pkg/utils/filters"
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
 	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
 )
 
@@ -56,7 +63,9 @@ func (s *Server) handleHealth(w http.ResponseWriter, req *http.Request) {
 	_, _ = w.Write([]byte("OK\n"))
 }
 
-// handleBuilds is http handler for the logs endpoint
+// handleBuilds is http handler for the logs endpoint. By default the logs are returned as plain
+// text; a format of json returns newline-delimited records tagged with the stage, container,
+// step, timestamp and severity
 //
 //nolint:errcheck
 func (s *Server) handleBuilds(w http.ResponseWriter, req *http.Request) {
@@ -81,6 +90,18 @@ func (s *Server) handleBuilds(w http.ResponseWriter, req *http.Request) {
 		values[key] = value
 	}
 
+	format := req.URL.Query().Get("format")
+	switch format {
+	case "", FormatText, FormatJSON:
+	default:
+		log.WithField("format", format).Error("received an invalid request")
+
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+	out := newBuildWriter(w, format, values["stage"])
+
 	fields := log.Fields{
 		"generation": values["generation"],
 		"name":       values["name"],
@@ -99,21 +120,21 @@ func (s *Server) handleBuilds(w http.ResponseWriter, req *http.Request) {
 	}
 
 	var pod *v1.Pod
+	var remote *batchv1.Job
+	namespace := s.jobNamespace(values["namespace"])
 
 	// @step: try and find the pod running the terraform job: We have to assume also
 	// the pods hasn't been scheduled yet
-	w.Header().Set("Content-Type", "text/plain")
-
-	w.Write([]byte("[info] waiting for the job to be scheduled\n"))
-	w.Write([]byte(fmt.Sprintf("[info] watching build: %s, generation: %s for the job to be scheduled\n", values["name"], values["generation"])))
+	out.status(buildlog.LevelInfo, "waiting for the job to be scheduled")
+	out.status(buildlog.LevelInfo, fmt.Sprintf("watching build: %s, generation: %s for the job to be scheduled", values["name"], values["generation"]))
 
 	// @step: we query the jobs using the labels and find the latest job for the configuration at stage x, generation y. We then
 	// find the associated pods and stream the logs back to the caller
 	err := utils.RetryWithTimeout(req.Context(), 3*time.Minute, 2*time.Second, func() (bool, error) {
-		w.Write([]byte("."))
+		out.progress()
 
 		// @step: find the matching job
-		list, err := s.Client.BatchV1().Jobs(s.Namespace).List(req.Context(), metav1.ListOptions{
+		list, err := s.Client.BatchV1().Jobs(namespace).List(req.Context(), metav1.ListOptions{
 			LabelSelector: strings.Join(labels, ","),
 		})
 		if err != nil {
@@ -133,6 +154,8 @@ func (s *Server) handleBuilds(w http.ResponseWriter, req *http.Request) {
 			WithNamespace(values["namespace"]).
 			WithStage(values["stage"]).
 			WithUID(values["uid"]).
+			WithoutLabel(terraformv1alpha1.JobPreviewVersionLabel).
+			WithoutLabel(terraformv1alpha1.JobWatcherLabel).
 			Latest()
 		if !found || latest == nil {
 			log.WithFields(fields).Debug("no matching job found")
@@ -141,8 +164,15 @@ func (s *Server) handleBuilds(w http.ResponseWriter, req *http.Request) {
 		}
 		log.WithFields(fields).Warn("found zero matching jobs for the build")
 
+		// @step: jobs run by a remote agent have no pods, the logs are reported by the agent
+		if agent.IsAgentJob(latest) {
+			remote = latest
+
+			return true, nil
+		}
+
 		// @step: find the latest pod associated to the job
-		pods, err := s.Client.CoreV1().Pods(s.Namespace).List(req.Context(), metav1.ListOptions{
+		pods, err := s.Client.CoreV1().Pods(namespace).List(req.Context(), metav1.ListOptions{
 			LabelSelector: "job-name=" + latest.Name,
 		})
 		if err != nil {
@@ -166,7 +196,21 @@ func (s *Server) handleBuilds(w http.ResponseWriter, req *http.Request) {
 	})
 	if err != nil {
 		log.WithFields(fields).WithError(err).Error("failed to find the pod")
-		w.Write([]byte("[error] failed to find associated pod in time\n"))
+		out.status(buildlog.LevelError, "failed to find associated pod in time")
+
+		return
+	}
+
+	if remote != nil {
+		log.WithFields(fields).WithField("job", remote.Name).Debug("found the job run by the agent")
+
+		if err := s.streamAgentLogs(req.Context(), w, out, remote); err != nil {
+			log.WithFields(fields).WithError(err).Error("failed to stream the logs reported by the agent")
+			out.status(buildlog.LevelError, "failed to retrieve the logs")
+
+			return
+		}
+		out.completed()
 
 		return
 	}
@@ -174,24 +218,28 @@ func (s *Server) handleBuilds(w http.ResponseWriter, req *http.Request) {
 
 	err = func() error {
 		for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
-			stream, err := s.Client.CoreV1().Pods(s.Namespace).GetLogs(pod.Name, &v1.PodLogOptions{
-				Container: container.Name,
-				Follow:    true,
+			stream, err := s.Client.CoreV1().Pods(namespace).GetLogs(pod.Name, &v1.PodLogOptions{
+				Container:  container.Name,
+				Follow:     true,
+				Timestamps: out.isJSON(),
 			}).Stream(req.Context())
 			if err != nil {
 				return err
 			}
+			out.container(container.Name)
 
 			// @step: we either copy or flush line by line the output
-			if flush, ok := w.(http.Flusher); !ok {
+			if flush, ok := w.(http.Flusher); !ok && !out.isJSON() {
 				if _, err := io.Copy(w, stream); err != nil {
 					return err
 				}
 			} else {
 				scanner := bufio.NewScanner(stream)
 				for scanner.Scan() {
-					w.Write([]byte(fmt.Sprintf("%s\n", scanner.Text())))
-					flush.Flush()
+					out.line(scanner.Text())
+					if ok {
+						flush.Flush()
+					}
 				}
 			}
 			stream.Close()
@@ -203,10 +251,63 @@ func (s *Server) handleBuilds(w http.ResponseWriter, req *http.Request) {
 		log.WithFields(fields).WithError(err).Error("failed to stream the logs")
 
 		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("[error] failed to retrieve the logs\n"))
+		out.status(buildlog.LevelError, "failed to retrieve the logs")
 
 		return
 	}
 
-	w.Write([]byte("[build] completed\n"))
+	out.completed()
+}
+
+// streamAgentLogs streams the logs reported by the agent for a job run by a remote agent, until
+// the job has finished
+func (s *Server) streamAgentLogs(ctx context.Context, w http.ResponseWriter, out *buildWriter, job *batchv1.Job) error {
+	flush, _ := w.(http.Flusher)
+	out.container(jobs.TerraformContainerName)
+
+	var offset int
+	for {
+		// @step: we check the job before the logs to ensure the final logs are read
+		current, err := s.Client.BatchV1().Jobs(job.Namespace).Get(ctx, job.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		finished := !jobs.IsActive(current)
+
+		secret, err := s.Client.CoreV1().Secrets(job.Namespace).Get(ctx, agent.LogsSecretName(job.Name), metav1.GetOptions{})
+		switch {
+		case kerrors.IsNotFound(err):
+		case err != nil:
+			return err
+		default:
+			logs := secret.Data[agent.LogsSecretKey]
+			if len(logs) < offset {
+				offset = 0
+			}
+			pending := logs[offset:]
+
+			// @note: only complete lines are written until the job has finished
+			if !finished {
+				pending = pending[:bytes.LastIndexByte(pending, '\n')+1]
+			}
+			scanner := bufio.NewScanner(bytes.NewReader(pending))
+			for scanner.Scan() {
+				out.line(scanner.Text())
+			}
+			offset += len(pending)
+
+			if flush != nil {
+				flush.Flush()
+			}
+		}
+		if finished {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
 }
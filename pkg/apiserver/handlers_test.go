@@ -0,0 +1,176 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package apiserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/appvia/terranetes-controller/pkg/agent"
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/utils/buildlog"
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
+	"github.com/appvia/terranetes-controller/test/fixtures"
+)
+
+// newBuildsServer returns a server with a running terraform plan for the bucket configuration
+func newBuildsServer() (*Server, string) {
+	return newBuildsServerInNamespace("terraform-system")
+}
+
+// newBuildsServerInNamespace returns a server with a running terraform plan for the bucket
+// configuration, with the job run in the given namespace
+func newBuildsServerInNamespace(namespace string) (*Server, string) {
+	configuration := fixtures.NewValidBucketConfiguration("apps", "bucket")
+	job := fixtures.NewTerraformJob(configuration, namespace, terraformv1alpha1.StageTerraformPlan)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.Name + "-abcde",
+			Namespace: namespace,
+			Labels:    map[string]string{"job-name": job.Name},
+		},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{{Name: "setup"}},
+			Containers:     []v1.Container{{Name: "terraform"}},
+		},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}
+
+	uri := fmt.Sprintf("/v1/builds/apps/bucket/logs?generation=0&name=bucket&namespace=apps&stage=plan&uid=%s", configuration.GetUID())
+
+	return &Server{Client: fake.NewSimpleClientset(job, pod), Namespace: "terraform-system"}, uri
+}
+
+func TestBuildsInvalidFormat(t *testing.T) {
+	s, uri := newBuildsServer()
+
+	w := getCatalog(t, s, uri+"&format=xml", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBuildsText(t *testing.T) {
+	s, uri := newBuildsServer()
+
+	w := getCatalog(t, s, uri, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, strings.Join([]string{
+		"[info] waiting for the job to be scheduled",
+		"[info] watching build: bucket, generation: 0 for the job to be scheduled",
+		".fake logs",
+		"fake logs",
+		"[build] completed",
+		"",
+	}, "\n"), w.Body.String())
+}
+
+func TestBuildsNamespacedJobs(t *testing.T) {
+	s, uri := newBuildsServerInNamespace("apps")
+	s.EnableNamespacedJobs = true
+
+	// the watcher shares the labels of the build, but must never be streamed
+	watcher := jobs.New(fixtures.NewValidBucketConfiguration("apps", "bucket"), nil).
+		NewJobWatch("terraform-system", terraformv1alpha1.StageTerraformPlan, "executor")
+	watcher.CreationTimestamp = metav1.NewTime(time.Now())
+	require.NoError(t, s.Client.(*fake.Clientset).Tracker().Add(watcher))
+
+	w := getCatalog(t, s, uri, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strings.Join([]string{
+		"[info] waiting for the job to be scheduled",
+		"[info] watching build: bucket, generation: 0 for the job to be scheduled",
+		".fake logs",
+		"fake logs",
+		"[build] completed",
+		"",
+	}, "\n"), w.Body.String())
+}
+
+func TestBuildsJSON(t *testing.T) {
+	s, uri := newBuildsServer()
+
+	w := getCatalog(t, s, uri+"&format=json", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+	var records []buildlog.Record
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		record := buildlog.Record{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 7)
+
+	expected := []struct {
+		Type      string
+		Container string
+		Message   string
+	}{
+		{buildlog.RecordStatus, "", "waiting for the job to be scheduled"},
+		{buildlog.RecordStatus, "", "watching build: bucket, generation: 0 for the job to be scheduled"},
+		{buildlog.RecordContainer, "setup", "setup"},
+		{buildlog.RecordLog, "setup", "fake logs"},
+		{buildlog.RecordContainer, "terraform", "terraform"},
+		{buildlog.RecordLog, "terraform", "fake logs"},
+		{buildlog.RecordStatus, "", "completed"},
+	}
+	for i, x := range expected {
+		assert.Equal(t, x.Type, records[i].Type, "record: %d", i)
+		assert.Equal(t, x.Container, records[i].Container, "record: %d", i)
+		assert.Equal(t, x.Message, records[i].Message, "record: %d", i)
+		assert.Equal(t, terraformv1alpha1.StageTerraformPlan, records[i].Stage, "record: %d", i)
+		assert.Equal(t, buildlog.LevelInfo, records[i].Level, "record: %d", i)
+	}
+}
+
+func TestBuildsAgentJob(t *testing.T) {
+	configuration := fixtures.NewValidBucketConfiguration("apps", "bucket")
+	job := fixtures.NewTerraformJob(configuration, "terraform-system", terraformv1alpha1.StageTerraformPlan)
+	job.Labels[terraformv1alpha1.JobAgentLabel] = "remote"
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: agent.LogsSecretName(job.Name), Namespace: job.Namespace},
+		Data:       map[string][]byte{agent.LogsSecretKey: []byte("line 1\nline 2\n")},
+	}
+	s := &Server{Client: fake.NewSimpleClientset(job, secret), Namespace: "terraform-system"}
+	uri := fmt.Sprintf("/v1/builds/apps/bucket/logs?generation=0&name=bucket&namespace=apps&stage=plan&uid=%s", configuration.GetUID())
+
+	w := getCatalog(t, s, uri, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, strings.Join([]string{
+		"[info] waiting for the job to be scheduled",
+		"[info] watching build: bucket, generation: 0 for the job to be scheduled",
+		".line 1",
+		"line 2",
+		"[build] completed",
+		"",
+	}, "\n"), w.Body.String())
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package apiserver
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/utils/logstore"
+)
+
+// historyStages is the order the stages are returned when no stage is requested
+var historyStages = []string{
+	terraformv1alpha1.StageTerraformPlan,
+	terraformv1alpha1.StageTerraformApply,
+	terraformv1alpha1.StageTerraformDestroy,
+}
+
+// handleBuildHistory is http handler for the retained logs of a configuration generation. The
+// uid is optional and defaults to the current configuration, while the stage is optional and
+// defaults to all stages
+func (s *Server) handleBuildHistory(w http.ResponseWriter, req *http.Request) {
+	if s.LogStore == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+
+		return
+	}
+
+	namespace := mux.Vars(req)["namespace"]
+	name := mux.Vars(req)["name"]
+	uid := req.URL.Query().Get("uid")
+	stage := req.URL.Query().Get("stage")
+
+	for key, value := range map[string]string{"namespace": namespace, "name": name} {
+		if err := validateInput(key, value); err != nil {
+			log.WithError(err).Error("received an invalid request")
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+	}
+	for key, value := range map[string]string{"uid": uid, "stage": stage} {
+		if value == "" {
+			continue
+		}
+		if err := validateInput(key, value); err != nil {
+			log.WithError(err).Error("received an invalid request")
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	generation, err := strconv.ParseInt(req.URL.Query().Get("generation"), 10, 64)
+	if err != nil || generation < 0 {
+		log.WithError(err).Error("received an invalid generation")
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	// @step: default to the uid of the current configuration
+	if uid == "" {
+		configuration := &terraformv1alpha1.Configuration{}
+		err := s.CC.Get(req.Context(), types.NamespacedName{Namespace: namespace, Name: name}, configuration)
+		switch {
+		case kerrors.IsNotFound(err):
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		case err != nil:
+			log.WithError(err).Error("failed to retrieve the configuration")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+		uid = string(configuration.GetUID())
+	}
+
+	stages := historyStages
+	if stage != "" {
+		stages = []string{stage}
+	}
+
+	var logs []byte
+	for _, x := range stages {
+		content, err := s.LogStore.Get(req.Context(), logstore.Key{
+			Generation: generation,
+			Name:       name,
+			Namespace:  namespace,
+			Stage:      x,
+			UID:        uid,
+		})
+		switch {
+		case errors.Is(err, logstore.ErrNotFound):
+			continue
+		case err != nil:
+			log.WithError(err).Error("failed to retrieve the build logs")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+		logs = append(logs, content...)
+	}
+	if logs == nil {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write(logs)
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package apiserver
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/schema"
+	"github.com/appvia/terranetes-controller/pkg/utils/logstore"
+	"github.com/appvia/terranetes-controller/test/fixtures"
+)
+
+// newHistoryServer returns a server with logs retained for the plan and apply of a configuration
+func newHistoryServer(t *testing.T) *Server {
+	configuration := fixtures.NewValidBucketConfiguration("apps", "bucket")
+	cc := fake.NewClientBuilder().WithScheme(schema.GetScheme()).WithObjects(configuration).Build()
+
+	store, err := logstore.New("file://"+t.TempDir(), nil, "")
+	require.NoError(t, err)
+
+	for stage, content := range map[string]string{
+		terraformv1alpha1.StageTerraformPlan:  "plan logs\n",
+		terraformv1alpha1.StageTerraformApply: "apply logs\n",
+	} {
+		require.NoError(t, store.Save(context.Background(), logstore.Key{
+			Generation: 2,
+			Name:       "bucket",
+			Namespace:  "apps",
+			Stage:      stage,
+			UID:        string(configuration.GetUID()),
+		}, []byte(content)))
+	}
+
+	return &Server{CC: cc, LogStore: store}
+}
+
+func TestBuildHistoryNoStore(t *testing.T) {
+	w := getCatalog(t, &Server{}, "/v1/builds/apps/bucket/history?generation=2", nil)
+	assert.Equal(t, http.StatusNotImplemented, w.Code)
+}
+
+func TestBuildHistoryInvalidGeneration(t *testing.T) {
+	for _, generation := range []string{"", "bad", "-1"} {
+		w := getCatalog(t, newHistoryServer(t), "/v1/builds/apps/bucket/history?generation="+generation, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "generation: %q", generation)
+	}
+}
+
+func TestBuildHistoryInvalidStage(t *testing.T) {
+	w := getCatalog(t, newHistoryServer(t), "/v1/builds/apps/bucket/history?generation=2&stage=../x", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBuildHistoryAllStages(t *testing.T) {
+	w := getCatalog(t, newHistoryServer(t), "/v1/builds/apps/bucket/history?generation=2", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, "plan logs\napply logs\n", w.Body.String())
+}
+
+func TestBuildHistoryStage(t *testing.T) {
+	w := getCatalog(t, newHistoryServer(t), "/v1/builds/apps/bucket/history?generation=2&stage=apply", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "apply logs\n", w.Body.String())
+}
+
+func TestBuildHistoryByUID(t *testing.T) {
+	w := getCatalog(t, newHistoryServer(t), "/v1/builds/apps/bucket/history?generation=2&uid=1234-122-1234-1234", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = getCatalog(t, newHistoryServer(t), "/v1/builds/apps/bucket/history?generation=2&uid=deleted", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestBuildHistoryNotFound(t *testing.T) {
+	w := getCatalog(t, newHistoryServer(t), "/v1/builds/apps/bucket/history?generation=3", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = getCatalog(t, newHistoryServer(t), "/v1/builds/apps/missing/history?generation=2", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package apiserver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appvia/terranetes-controller/pkg/utils/mirror"
+)
+
+// MaxProviderArchiveSize is the maximum size of a provider archive which can be pushed
+const MaxProviderArchiveSize = 512 << 20
+
+// ProviderVersions is the response listing the versions of a provider held in the mirror
+type ProviderVersions struct {
+	// Versions is a collection of versions, the values are reserved by the protocol
+	Versions map[string]struct{} `json:"versions"`
+}
+
+// ProviderArchives is the response listing the archives for a version of a provider
+type ProviderArchives struct {
+	// Archives is a collection of archives keyed by the platform i.e. linux_amd64
+	Archives map[string]mirror.Archive `json:"archives"`
+}
+
+// providerFromRequest returns the provider from the path of the request
+func providerFromRequest(req *http.Request) (mirror.Provider, error) {
+	provider := mirror.Provider{
+		Hostname:  mux.Vars(req)["hostname"],
+		Namespace: mux.Vars(req)["namespace"],
+		Type:      mux.Vars(req)["type"],
+	}
+
+	return provider, provider.Validate()
+}
+
+// handleProviderMirror implements the provider network mirror protocol, serving the versions,
+// archives and the archive contents held in the mirror
+func (s *Server) handleProviderMirror(w http.ResponseWriter, req *http.Request) {
+	provider, err := providerFromRequest(req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+	filename := mux.Vars(req)["file"]
+
+	switch {
+	case filename == "index.json":
+		versions, err := s.Mirror.Versions(provider)
+		if err != nil {
+			writeMirrorError(w, provider, err)
+
+			return
+		}
+		response := &ProviderVersions{Versions: make(map[string]struct{}, len(versions))}
+		for _, version := range versions {
+			response.Versions[version] = struct{}{}
+		}
+		writeJSON(w, req, response)
+
+	case strings.HasSuffix(filename, ".json"):
+		archives, err := s.Mirror.Archives(provider, strings.TrimSuffix(filename, ".json"))
+		if err != nil {
+			writeMirrorError(w, provider, err)
+
+			return
+		}
+		writeJSON(w, req, &ProviderArchives{Archives: archives})
+
+	default:
+		file, err := s.Mirror.Open(provider, filename)
+		if err != nil {
+			writeMirrorError(w, provider, err)
+
+			return
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			writeMirrorError(w, provider, err)
+
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+
+		http.ServeContent(w, req, filename, info.ModTime(), file)
+	}
+}
+
+// handleProviderPush adds a provider archive to the mirror
+func (s *Server) handleProviderPush(w http.ResponseWriter, req *http.Request) {
+	provider, err := providerFromRequest(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+	version, platform, err := mirror.ParseArchiveName(provider, mux.Vars(req)["file"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	checksums, err := checksumsFromRequest(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	archive, err := s.Mirror.Push(provider, version, platform, http.MaxBytesReader(w, req.Body, MaxProviderArchiveSize), checksums)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"platform": platform,
+			"provider": provider.String(),
+			"version":  version,
+		}).WithError(err).Error("failed to push the provider archive")
+
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"platform": platform,
+		"provider": provider.String(),
+		"version":  version,
+	}).Info("added the provider archive to the mirror")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	_ = json.NewEncoder(w).Encode(archive)
+}
+
+// checksumsFromRequest returns the published checksums of the archive from the request headers
+func checksumsFromRequest(req *http.Request) (mirror.Checksums, error) {
+	var checksums mirror.Checksums
+	for _, x := range []struct {
+		header  string
+		content *[]byte
+	}{
+		{mirror.ChecksumsHeader, &checksums.Document},
+		{mirror.ChecksumsSignatureHeader, &checksums.Signature},
+	} {
+		value := req.Header.Get(x.header)
+		if value == "" {
+			return mirror.Checksums{}, fmt.Errorf("the %s header is required", x.header)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return mirror.Checksums{}, fmt.Errorf("the %s header is not base64 encoded", x.header)
+		}
+		*x.content = decoded
+	}
+
+	return checksums, nil
+}
+
+// writeMirrorError writes the response for an error from the mirror
+func writeMirrorError(w http.ResponseWriter, provider mirror.Provider, err error) {
+	if errors.Is(err, mirror.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+	log.WithField("provider", provider.String()).WithError(err).Error("failed to retrieve the provider from the mirror")
+
+	w.WriteHeader(http.StatusInternalServerError)
+}
@@ -0,0 +1,249 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package apiserver
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/appvia/terranetes-controller/pkg/utils/mirror"
+	"github.com/appvia/terranetes-controller/test/fixtures"
+)
+
+// newProviderArchive returns a zip archive containing a provider binary
+func newProviderArchive(t *testing.T, name string) []byte {
+	buffer := &bytes.Buffer{}
+	writer := zip.NewWriter(buffer)
+	w, err := writer.Create(name)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("binary"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buffer.Bytes()
+}
+
+// newMirrorServer returns a server with an empty provider mirror trusting the signing key. The
+// token "admin" is permitted to push into the mirror, while "viewer" is not
+func newMirrorServer(t *testing.T) (*Server, *openpgp.Entity) {
+	key, err := fixtures.NewProviderSigningKey()
+	require.NoError(t, err)
+	store, err := mirror.New(t.TempDir())
+	require.NoError(t, err)
+	store.Keyring = openpgp.EntityList{key}
+
+	client := kfake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if review.Spec.Token == "admin" || review.Spec.Token == "viewer" {
+			review.Status.Authenticated = true
+			review.Status.User = authenticationv1.UserInfo{Username: review.Spec.Token}
+		}
+
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		review.Status.Allowed = review.Spec.User == "admin" &&
+			review.Spec.ResourceAttributes.Resource == "providers" &&
+			review.Spec.ResourceAttributes.Subresource == "mirror"
+
+		return true, review, nil
+	})
+
+	return &Server{Client: client, Mirror: store}, key
+}
+
+// doMirror performs the request against the mirror
+func doMirror(s *Server, method, uri string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, uri, bytes.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	s.ServeMirror().ServeHTTP(w, req)
+
+	return w
+}
+
+// pushHeaders returns the headers to push the archive as the user, with the checksums of
+// the archives signed by the key
+func pushHeaders(t *testing.T, token string, key *openpgp.Entity, archives map[string][]byte) map[string]string {
+	document, signature, err := fixtures.NewSignedProviderChecksums(key, archives)
+	require.NoError(t, err)
+
+	return map[string]string{
+		"Authorization":                 "Bearer " + token,
+		mirror.ChecksumsHeader:          base64.StdEncoding.EncodeToString(document),
+		mirror.ChecksumsSignatureHeader: base64.StdEncoding.EncodeToString(signature),
+	}
+}
+
+func TestProviderMirrorDisabled(t *testing.T) {
+	s := &Server{}
+
+	w := doMirror(s, http.MethodGet, "/v1/providers/registry.terraform.io/hashicorp/aws/index.json", nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestProviderMirrorNotServedByAPIServer(t *testing.T) {
+	s, key := newMirrorServer(t)
+	uri := "/v1/providers/registry.terraform.io/hashicorp/aws/terraform-provider-aws_5.31.0_linux_amd64.zip"
+	archive := newProviderArchive(t, "terraform-provider-aws")
+
+	req := httptest.NewRequest(http.MethodPut, uri, bytes.NewReader(archive))
+	for k, v := range pushHeaders(t, "admin", key, map[string][]byte{"terraform-provider-aws_5.31.0_linux_amd64.zip": archive}) {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	s.Serve().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestProviderMirror(t *testing.T) {
+	s, key := newMirrorServer(t)
+	base := "/v1/providers/registry.terraform.io/hashicorp/aws/"
+	filename := "terraform-provider-aws_5.31.0_linux_amd64.zip"
+
+	w := doMirror(s, http.MethodGet, base+"index.json", nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	archive := newProviderArchive(t, "terraform-provider-aws_v5.31.0_x5")
+	w = doMirror(s, http.MethodPut, base+filename, archive, pushHeaders(t, "admin", key, map[string][]byte{filename: archive}))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	pushed := mirror.Archive{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&pushed))
+	assert.Equal(t, filename, pushed.URL)
+	assert.Len(t, pushed.Hashes, 2)
+
+	w = doMirror(s, http.MethodGet, base+"index.json", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"versions":{"5.31.0":{}}}`, w.Body.String())
+
+	w = doMirror(s, http.MethodGet, base+"5.31.0.json", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	archives := &ProviderArchives{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(archives))
+	assert.Equal(t, map[string]mirror.Archive{"linux_amd64": pushed}, archives.Archives)
+
+	w = doMirror(s, http.MethodGet, base+"1.0.0.json", nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doMirror(s, http.MethodGet, base+filename, nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	assert.Equal(t, archive, w.Body.Bytes())
+
+	w = doMirror(s, http.MethodGet, base+"terraform-provider-aws_5.31.0_darwin_arm64.zip", nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestProviderMirrorPushInvalid(t *testing.T) {
+	s, key := newMirrorServer(t)
+
+	cases := []struct {
+		URI  string
+		Body []byte
+	}{
+		{
+			URI:  "/v1/providers/registry.terraform.io/hashicorp/aws/terraform-provider-aws_latest_linux_amd64.zip",
+			Body: newProviderArchive(t, "terraform-provider-aws"),
+		},
+		{
+			URI:  "/v1/providers/registry.terraform.io/hashicorp/aws/terraform-provider-google_1.0.0_linux_amd64.zip",
+			Body: newProviderArchive(t, "terraform-provider-google"),
+		},
+		{
+			URI:  "/v1/providers/registry.terraform.io/hashicorp/aws/terraform-provider-aws_1.0.0_linux_amd64.zip",
+			Body: []byte("not a zip"),
+		},
+		{
+			URI:  "/v1/providers/registry.terraform.io/hashicorp/aws/terraform-provider-aws_1.0.0_linux_amd64.zip",
+			Body: newProviderArchive(t, "README.md"),
+		},
+	}
+	for _, c := range cases {
+		filename := c.URI[strings.LastIndex(c.URI, "/")+1:]
+		w := doMirror(s, http.MethodPut, c.URI, c.Body, pushHeaders(t, "admin", key, map[string][]byte{filename: c.Body}))
+		assert.Equal(t, http.StatusBadRequest, w.Code, c.URI)
+	}
+}
+
+func TestProviderMirrorPushUnverified(t *testing.T) {
+	s, key := newMirrorServer(t)
+	uri := "/v1/providers/registry.terraform.io/hashicorp/aws/terraform-provider-aws_5.31.0_linux_amd64.zip"
+	archive := newProviderArchive(t, "terraform-provider-aws")
+
+	other, err := fixtures.NewProviderSigningKey()
+	require.NoError(t, err)
+
+	w := doMirror(s, http.MethodPut, uri, archive, map[string]string{"Authorization": "Bearer admin"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doMirror(s, http.MethodPut, uri, archive,
+		pushHeaders(t, "admin", other, map[string][]byte{"terraform-provider-aws_5.31.0_linux_amd64.zip": archive}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doMirror(s, http.MethodPut, uri, archive,
+		pushHeaders(t, "admin", key, map[string][]byte{"terraform-provider-aws_5.31.0_linux_amd64.zip": []byte("other")}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doMirror(s, http.MethodGet, "/v1/providers/registry.terraform.io/hashicorp/aws/index.json", nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestProviderMirrorPushRequiresAuthentication(t *testing.T) {
+	// @note: authentication of the push does not depend on the api server authentication
+	s, key := newMirrorServer(t)
+	s.EnableAuthentication = false
+	base := "/v1/providers/registry.terraform.io/hashicorp/aws/"
+	filename := "terraform-provider-aws_5.31.0_linux_amd64.zip"
+	archive := newProviderArchive(t, "terraform-provider-aws")
+
+	headers := pushHeaders(t, "admin", key, map[string][]byte{filename: archive})
+	delete(headers, "Authorization")
+	w := doMirror(s, http.MethodPut, base+filename, archive, headers)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doMirror(s, http.MethodPut, base+filename, archive, pushHeaders(t, "unknown", key, map[string][]byte{filename: archive}))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doMirror(s, http.MethodPut, base+filename, archive, pushHeaders(t, "viewer", key, map[string][]byte{filename: archive}))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// @note: terraform within the jobs has no credentials, so retrieval is not authenticated
+	w = doMirror(s, http.MethodGet, base+"index.json", nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
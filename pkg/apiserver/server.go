@@ -21,20 +21,50 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/apiserver/authentication"
 	"github.com/appvia/terranetes-controller/pkg/apiserver/logging"
 	"github.com/appvia/terranetes-controller/pkg/apiserver/recovery"
+	"github.com/appvia/terranetes-controller/pkg/apiserver/stream"
+	"github.com/appvia/terranetes-controller/pkg/utils/logstore"
+	"github.com/appvia/terranetes-controller/pkg/utils/mirror"
 )
 
 // Server is the api server
 type Server struct {
+	// CC is the controller-runtime client used to retrieve the catalog
+	CC client.Client
 	// Client is the controller-runtime client
 	Client kubernetes.Interface
+	// EnableAuthentication indicates callers must present a bearer token which is reviewed
+	// by the kubernetes api and authorized against the resources requested
+	EnableAuthentication bool
+	// EnableNamespacedJobs indicates the jobs are run in the namespace of the configuration
+	// rather than the controller namespace
+	EnableNamespacedJobs bool
+	// Events is an optional broker for the lifecycle events of resources
+	Events *stream.Broker
+	// LogStore is an optional store holding the retained logs of previous builds
+	LogStore logstore.Interface
+	// Mirror is an optional provider mirror served via the provider network mirror protocol
+	Mirror *mirror.Store
 	// Namespace is the kubernetes namespace where the jobs are run
 	Namespace string
 }
 
+// jobNamespace returns the namespace the jobs of a configuration in the given namespace are run in
+func (s *Server) jobNamespace(namespace string) string {
+	if s.EnableNamespacedJobs {
+		return namespace
+	}
+
+	return s.Namespace
+}
+
 // Serve returns the http handler: is externally facing and called from the user namespace
 // to retrieve the logs from the builds
 func (s *Server) Serve() http.Handler {
@@ -43,7 +73,108 @@ func (s *Server) Serve() http.Handler {
 	router.Use(logging.Logger())
 
 	router.HandleFunc("/healthz", s.handleHealth).Methods(http.MethodGet)
-	router.HandleFunc("/v1/builds/{namespace}/{name}/logs", s.handleBuilds).Methods(http.MethodGet)
+	router.Handle("/v1/builds/{namespace}/{name}/logs",
+		s.authorize(buildAttributes, s.handleBuilds)).Methods(http.MethodGet)
+	router.Handle("/v1/builds/{namespace}/{name}/history",
+		s.authorize(historyAttributes, s.handleBuildHistory)).Methods(http.MethodGet)
+	router.Handle("/v1/events/{namespace}",
+		s.authorize(eventsAttributes, s.handleEvents)).Methods(http.MethodGet)
+	router.Handle("/v1/catalog/{namespace}/plans",
+		s.authorize(catalogAttributes, s.handleCatalogPlans)).Methods(http.MethodGet)
+	router.Handle("/v1/catalog/{namespace}/plans/{name}",
+		s.authorize(catalogAttributes, s.handleCatalogPlan)).Methods(http.MethodGet)
+
+	return router
+}
+
+// ServeMirror returns the http handler for the provider mirror, which is served over tls. Pushing
+// archives into the mirror always requires authentication, regardless of the api server settings
+func (s *Server) ServeMirror() http.Handler {
+	router := mux.NewRouter()
+	router.Use(recovery.Recovery())
+	router.Use(logging.Logger())
+
+	router.HandleFunc("/healthz", s.handleHealth).Methods(http.MethodGet)
+
+	if s.Mirror != nil {
+		// @note: the mirror is consumed by terraform within the jobs, which has no credentials,
+		// and only offers the publicly available provider archives
+		router.HandleFunc("/v1/providers/{hostname}/{namespace}/{type}/{file}",
+			s.handleProviderMirror).Methods(http.MethodGet, http.MethodHead)
+		router.Handle("/v1/providers/{hostname}/{namespace}/{type}/{file}",
+			authentication.Filter(s.Client, mirrorAttributes)(http.HandlerFunc(s.handleProviderPush))).Methods(http.MethodPut)
+	}
 
 	return router
 }
+
+// authorize wraps the handler with authentication and authorization when enabled
+func (s *Server) authorize(attributes authentication.AttributesFunc, handler http.HandlerFunc) http.Handler {
+	if !s.EnableAuthentication {
+		return handler
+	}
+
+	return authentication.Filter(s.Client, attributes)(handler)
+}
+
+// buildAttributes returns the attributes for the builds, the caller must be permitted to get the
+// configuration. Note, the query parameters are used as these determine the logs returned
+func buildAttributes(req *http.Request) authorizationv1.ResourceAttributes {
+	return authorizationv1.ResourceAttributes{
+		Group:     terraformv1alpha1.SchemeGroupVersion.Group,
+		Name:      req.URL.Query().Get("name"),
+		Namespace: req.URL.Query().Get("namespace"),
+		Resource:  "configurations",
+		Verb:      "get",
+		Version:   terraformv1alpha1.SchemeGroupVersion.Version,
+	}
+}
+
+// historyAttributes returns the attributes for the build history, the caller must be permitted
+// to get the configuration
+func historyAttributes(req *http.Request) authorizationv1.ResourceAttributes {
+	return authorizationv1.ResourceAttributes{
+		Group:     terraformv1alpha1.SchemeGroupVersion.Group,
+		Name:      mux.Vars(req)["name"],
+		Namespace: mux.Vars(req)["namespace"],
+		Resource:  "configurations",
+		Verb:      "get",
+		Version:   terraformv1alpha1.SchemeGroupVersion.Version,
+	}
+}
+
+// eventsAttributes returns the attributes for the event stream, the caller must be permitted
+// to watch the configurations within the namespace
+func eventsAttributes(req *http.Request) authorizationv1.ResourceAttributes {
+	return authorizationv1.ResourceAttributes{
+		Group:     terraformv1alpha1.SchemeGroupVersion.Group,
+		Namespace: mux.Vars(req)["namespace"],
+		Resource:  "configurations",
+		Verb:      "watch",
+		Version:   terraformv1alpha1.SchemeGroupVersion.Version,
+	}
+}
+
+// catalogAttributes returns the attributes for the catalog, the caller must be permitted to
+// list the cloud resources within the namespace
+func catalogAttributes(req *http.Request) authorizationv1.ResourceAttributes {
+	return authorizationv1.ResourceAttributes{
+		Group:     terraformv1alpha1.SchemeGroupVersion.Group,
+		Namespace: mux.Vars(req)["namespace"],
+		Resource:  "cloudresources",
+		Verb:      "list",
+		Version:   terraformv1alpha1.SchemeGroupVersion.Version,
+	}
+}
+
+// mirrorAttributes returns the attributes for pushing to the provider mirror, the caller must
+// be permitted to create the mirror subresource of providers
+func mirrorAttributes(_ *http.Request) authorizationv1.ResourceAttributes {
+	return authorizationv1.ResourceAttributes{
+		Group:       terraformv1alpha1.SchemeGroupVersion.Group,
+		Resource:    "providers",
+		Subresource: "mirror",
+		Verb:        "create",
+		Version:     terraformv1alpha1.SchemeGroupVersion.Version,
+	}
+}
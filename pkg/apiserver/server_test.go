@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestServerHTTP(t *testing.T) {
@@ -39,3 +40,25 @@ func TestHealthHandler(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
 	assert.Equal(t, "OK\n", w.Body.String())
 }
+
+func TestServerAuthentication(t *testing.T) {
+	svc := &Server{Client: fake.NewSimpleClientset(), EnableAuthentication: true}
+
+	for _, uri := range []string{
+		"/v1/builds/apps/test/logs?namespace=apps&name=test",
+		"/v1/builds/apps/test/history?generation=1",
+		"/v1/events/apps",
+		"/v1/catalog/apps/plans",
+		"/v1/catalog/apps/plans/bucket",
+	} {
+		req := httptest.NewRequest(http.MethodGet, uri, nil)
+		w := httptest.NewRecorder()
+		svc.Serve().ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "case: %s", uri)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+	svc.Serve().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package stream
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultBufferSize is the number of events buffered for a subscriber before it is
+// deemed too slow and disconnected
+const DefaultBufferSize = 100
+
+// Broker fans out lifecycle events to the subscribers of a namespace
+type Broker struct {
+	// BufferSize is the number of events buffered per subscriber
+	BufferSize int
+	// mutex protects the fields below
+	mutex sync.Mutex
+	// id is the identifier of the last event published
+	id uint64
+	// subscribers is the collection of current subscribers
+	subscribers map[*subscriber]struct{}
+}
+
+// subscriber is a consumer of events for a namespace
+type subscriber struct {
+	// namespace is the namespace the subscriber is interested in
+	namespace string
+	// events is the channel events are delivered on
+	events chan Event
+}
+
+// NewBroker returns a broker for the lifecycle events
+func NewBroker() *Broker {
+	return &Broker{
+		BufferSize:  DefaultBufferSize,
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Publish delivers the event to all subscribers of the namespace. Subscribers which are unable
+// to keep up are disconnected, permitting the client to reconnect rather than miss events
+func (b *Broker) Publish(event Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.id++
+	event.ID = b.id
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	for s := range b.subscribers {
+		if s.namespace != event.Namespace {
+			continue
+		}
+
+		select {
+		case s.events <- event:
+		default:
+			log.WithField("namespace", s.namespace).Warn("event subscriber is too slow, disconnecting")
+
+			delete(b.subscribers, s)
+			close(s.events)
+		}
+	}
+}
+
+// Subscribe returns a channel of events for the namespace and a function to unsubscribe. The
+// channel is closed when the subscriber is removed
+func (b *Broker) Subscribe(namespace string) (<-chan Event, func()) {
+	size := b.BufferSize
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	s := &subscriber{namespace: namespace, events: make(chan Event, size)}
+
+	b.mutex.Lock()
+	b.subscribers[s] = struct{}{}
+	b.mutex.Unlock()
+
+	return s.events, func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		if _, found := b.subscribers[s]; found {
+			delete(b.subscribers, s)
+			close(s.events)
+		}
+	}
+}
+
+// Subscribers returns the number of current subscribers
+func (b *Broker) Subscribers() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return len(b.subscribers)
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package stream
+
+import (
+	"context"
+	"strconv"
+
+	batchv1 "k8s.io/api/batch/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	corev1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/core/v1alpha1"
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
+)
+
+// Watch registers the broker against the informers for configurations, cloudresources and
+// the jobs within the controller namespace. The informers are shared with the controllers so
+// no additional requests are made to the kubernetes api
+func (b *Broker) Watch(ctx context.Context, informers cache.Informers, namespace string) error {
+	configurations, err := informers.GetInformer(ctx, &terraformv1alpha1.Configuration{})
+	if err != nil {
+		return err
+	}
+	if _, err := configurations.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(before, after interface{}) {
+			o, ok1 := before.(*terraformv1alpha1.Configuration)
+			n, ok2 := after.(*terraformv1alpha1.Configuration)
+			if ok1 && ok2 {
+				b.handleConditions(terraformv1alpha1.ConfigurationKind, n.Namespace, n.Name, n.GetGeneration(),
+					o.Status.Conditions, n.Status.Conditions)
+			}
+		},
+	}); err != nil {
+		return err
+	}
+
+	cloudresources, err := informers.GetInformer(ctx, &terraformv1alpha1.CloudResource{})
+	if err != nil {
+		return err
+	}
+	if _, err := cloudresources.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(before, after interface{}) {
+			o, ok1 := before.(*terraformv1alpha1.CloudResource)
+			n, ok2 := after.(*terraformv1alpha1.CloudResource)
+			if ok1 && ok2 {
+				b.handleConditions(terraformv1alpha1.CloudResourceKind, n.Namespace, n.Name, n.GetGeneration(),
+					o.Status.Conditions, n.Status.Conditions)
+			}
+		},
+	}); err != nil {
+		return err
+	}
+
+	jobInformer, err := informers.GetInformer(ctx, &batchv1.Job{})
+	if err != nil {
+		return err
+	}
+	_, err = jobInformer.AddEventHandler(toolscache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			job, ok := obj.(*batchv1.Job)
+
+			return ok && job.Namespace == namespace && job.GetLabels()[terraformv1alpha1.ConfigurationNameLabel] != ""
+		},
+		Handler: toolscache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				b.handleJob(nil, obj.(*batchv1.Job))
+			},
+			UpdateFunc: func(before, after interface{}) {
+				b.handleJob(before.(*batchv1.Job), after.(*batchv1.Job))
+			},
+		},
+	})
+
+	return err
+}
+
+// handleConditions publishes an event for each condition which has transitioned
+func (b *Broker) handleConditions(kind, namespace, name string, generation int64, before, after corev1alpha1.Conditions) {
+	existing := make(map[corev1alpha1.ConditionType]corev1alpha1.Condition, len(before))
+	for _, condition := range before {
+		existing[condition.Type] = condition
+	}
+
+	for _, condition := range after {
+		previous, found := existing[condition.Type]
+		if found &&
+			previous.Status == condition.Status &&
+			previous.Reason == condition.Reason &&
+			previous.Message == condition.Message {
+			continue
+		}
+
+		b.Publish(Event{
+			Type:       EventCondition,
+			Kind:       kind,
+			Namespace:  namespace,
+			Name:       name,
+			Generation: generation,
+			Condition: &Condition{
+				Type:    string(condition.Type),
+				Status:  string(condition.Status),
+				Reason:  condition.Reason,
+				Message: condition.Message,
+			},
+		})
+	}
+}
+
+// handleJob publishes the stage and job events for a terraform job; before is nil when
+// the job has been added
+func (b *Broker) handleJob(before, after *batchv1.Job) {
+	labels := after.GetLabels()
+	generation, _ := strconv.ParseInt(labels[terraformv1alpha1.ConfigurationGenerationLabel], 10, 64)
+
+	event := Event{
+		Kind:       terraformv1alpha1.ConfigurationKind,
+		Namespace:  labels[terraformv1alpha1.ConfigurationNamespaceLabel],
+		Name:       labels[terraformv1alpha1.ConfigurationNameLabel],
+		Generation: generation,
+		Job:        after.Name,
+		Stage:      labels[terraformv1alpha1.ConfigurationStageLabel],
+	}
+
+	switch {
+	case before == nil:
+		// we ignore jobs which have already finished, i.e. those seen on the initial sync
+		if !jobs.IsActive(after) {
+			return
+		}
+		event.Type = EventStage
+		b.Publish(event)
+
+		if after.Status.StartTime != nil {
+			event.Type = EventJobStarted
+			b.Publish(event)
+		}
+
+	case before.Status.StartTime == nil && after.Status.StartTime != nil:
+		event.Type = EventJobStarted
+		b.Publish(event)
+	}
+
+	if before != nil && jobs.IsActive(before) && !jobs.IsActive(after) {
+		event.Type = EventJobFinished
+		event.Result = ResultSucceeded
+		if jobs.IsFailed(after) {
+			event.Result = ResultFailed
+		}
+		b.Publish(event)
+	}
+}
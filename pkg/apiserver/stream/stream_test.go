@@ -0,0 +1,166 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	corev1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/core/v1alpha1"
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/test/fixtures"
+)
+
+// drain returns the events currently buffered on the channel
+func drain(events <-chan Event) []Event {
+	var list []Event
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return list
+			}
+			list = append(list, e)
+		default:
+			return list
+		}
+	}
+}
+
+func TestBrokerPublish(t *testing.T) {
+	b := NewBroker()
+	apps, unsubscribe := b.Subscribe("apps")
+	defer unsubscribe()
+	other, cancel := b.Subscribe("other")
+	defer cancel()
+
+	b.Publish(Event{Type: EventStage, Namespace: "apps", Name: "bucket"})
+	b.Publish(Event{Type: EventStage, Namespace: "apps", Name: "database"})
+
+	list := drain(apps)
+	require.Len(t, list, 2)
+	assert.Equal(t, uint64(1), list[0].ID)
+	assert.Equal(t, uint64(2), list[1].ID)
+	assert.False(t, list[0].Time.IsZero())
+	assert.Empty(t, drain(other))
+}
+
+func TestBrokerUnsubscribe(t *testing.T) {
+	b := NewBroker()
+	events, unsubscribe := b.Subscribe("apps")
+	assert.Equal(t, 1, b.Subscribers())
+
+	unsubscribe()
+	unsubscribe()
+	assert.Equal(t, 0, b.Subscribers())
+
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestBrokerSlowSubscriber(t *testing.T) {
+	b := NewBroker()
+	b.BufferSize = 1
+	events, unsubscribe := b.Subscribe("apps")
+	defer unsubscribe()
+
+	b.Publish(Event{Namespace: "apps"})
+	b.Publish(Event{Namespace: "apps"})
+	assert.Equal(t, 0, b.Subscribers())
+
+	list := drain(events)
+	assert.Len(t, list, 1)
+}
+
+func TestHandleConditions(t *testing.T) {
+	b := NewBroker()
+	events, unsubscribe := b.Subscribe("apps")
+	defer unsubscribe()
+
+	before := corev1alpha1.Conditions{
+		{Type: terraformv1alpha1.ConditionTerraformPlan, Status: metav1.ConditionFalse, Reason: corev1alpha1.ReasonInProgress},
+		{Type: terraformv1alpha1.ConditionTerraformApply, Status: metav1.ConditionFalse, Reason: corev1alpha1.ReasonNotDetermined},
+	}
+	after := corev1alpha1.Conditions{
+		{Type: terraformv1alpha1.ConditionTerraformPlan, Status: metav1.ConditionTrue, Reason: corev1alpha1.ReasonReady, Message: "Terraform plan is complete"},
+		{Type: terraformv1alpha1.ConditionTerraformApply, Status: metav1.ConditionFalse, Reason: corev1alpha1.ReasonNotDetermined},
+	}
+	b.handleConditions(terraformv1alpha1.ConfigurationKind, "apps", "bucket", 2, before, after)
+
+	list := drain(events)
+	require.Len(t, list, 1)
+	assert.Equal(t, EventCondition, list[0].Type)
+	assert.Equal(t, terraformv1alpha1.ConfigurationKind, list[0].Kind)
+	assert.Equal(t, "bucket", list[0].Name)
+	assert.Equal(t, int64(2), list[0].Generation)
+	assert.Equal(t, &Condition{
+		Type:    string(terraformv1alpha1.ConditionTerraformPlan),
+		Status:  "True",
+		Reason:  corev1alpha1.ReasonReady,
+		Message: "Terraform plan is complete",
+	}, list[0].Condition)
+}
+
+func TestHandleJob(t *testing.T) {
+	b := NewBroker()
+	events, unsubscribe := b.Subscribe("apps")
+	defer unsubscribe()
+
+	configuration := fixtures.NewValidBucketConfiguration("apps", "bucket")
+	configuration.Generation = 3
+
+	created := fixtures.NewTerraformJob(configuration, "terraform-system", terraformv1alpha1.StageTerraformApply)
+	b.handleJob(nil, created)
+
+	started := created.DeepCopy()
+	started.Status.StartTime = &metav1.Time{Time: time.Now()}
+	b.handleJob(created, started)
+
+	finished := started.DeepCopy()
+	finished.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue}}
+	b.handleJob(started, finished)
+	b.handleJob(finished, finished)
+
+	list := drain(events)
+	require.Len(t, list, 3)
+	for i, expected := range []string{EventStage, EventJobStarted, EventJobFinished} {
+		assert.Equal(t, expected, list[i].Type)
+		assert.Equal(t, "bucket", list[i].Name)
+		assert.Equal(t, int64(3), list[i].Generation)
+		assert.Equal(t, terraformv1alpha1.StageTerraformApply, list[i].Stage)
+		assert.Equal(t, created.Name, list[i].Job)
+	}
+	assert.Equal(t, ResultFailed, list[2].Result)
+}
+
+func TestHandleJobAlreadyFinished(t *testing.T) {
+	b := NewBroker()
+	events, unsubscribe := b.Subscribe("apps")
+	defer unsubscribe()
+
+	configuration := fixtures.NewValidBucketConfiguration("apps", "bucket")
+	b.handleJob(nil, fixtures.NewCompletedTerraformJob(configuration, terraformv1alpha1.StageTerraformPlan))
+
+	assert.Empty(t, drain(events))
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package stream
+
+import (
+	"time"
+)
+
+const (
+	// EventCondition indicates a condition on the resource has transitioned
+	EventCondition = "condition"
+	// EventStage indicates the configuration has moved into a stage i.e. a plan, apply or destroy
+	EventStage = "stage"
+	// EventJobStarted indicates the job for a stage has started running
+	EventJobStarted = "job.started"
+	// EventJobFinished indicates the job for a stage has finished
+	EventJobFinished = "job.finished"
+)
+
+const (
+	// ResultSucceeded indicates the job completed successfully
+	ResultSucceeded = "succeeded"
+	// ResultFailed indicates the job has failed
+	ResultFailed = "failed"
+)
+
+// Event is a lifecycle event for a resource
+type Event struct {
+	// ID is a unique and incrementing identifier for the event
+	ID uint64 `json:"id"`
+	// Type is the type of event i.e. condition, stage, job.started or job.finished
+	Type string `json:"type"`
+	// Kind is the kind of resource the event relates to
+	Kind string `json:"kind"`
+	// Namespace is the namespace of the resource
+	Namespace string `json:"namespace"`
+	// Name is the name of the resource
+	Name string `json:"name"`
+	// Generation is the generation of the resource
+	Generation int64 `json:"generation,omitempty"`
+	// Condition is the condition which has transitioned
+	Condition *Condition `json:"condition,omitempty"`
+	// Job is the name of the job for job events
+	Job string `json:"job,omitempty"`
+	// Result is the result of a finished job i.e. succeeded or failed
+	Result string `json:"result,omitempty"`
+	// Stage is the stage of the configuration i.e. plan, apply or destroy
+	Stage string `json:"stage,omitempty"`
+	// Time is the time the event was observed
+	Time time.Time `json:"time"`
+}
+
+// Condition is the state of a condition
+type Condition struct {
+	// Type is the type of condition
+	Type string `json:"type"`
+	// Status is the status of the condition
+	Status string `json:"status"`
+	// Reason is the reason for the condition
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable message
+	Message string `json:"message,omitempty"`
+}
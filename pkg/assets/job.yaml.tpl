@@ -62,6 +62,29 @@ spec:
               - key: variables.tfvars
                 path: variables.tfvars
               {{- end }}
+        {{- if .Cache.Volume }}
+        # Shared cache of module sources and provider plugins, used by all jobs
+        - name: cache
+          persistentVolumeClaim:
+            claimName: {{ .Cache.Volume }}
+        {{- end }}
+        {{- if .Mirror.CASecret }}
+        # Contains the certificate authority used to trust the provider mirror
+        - name: mirror-ca
+          secret:
+            secretName: {{ .Mirror.CASecret }}
+            optional: false
+            items:
+              - key: ca.pem
+                path: ca.pem
+        {{- end }}
+        {{- if .Source.SigningKeys }}
+        # Contains the public keys used to verify the signature of the module source
+        - name: signing-keys
+          secret:
+            secretName: {{ .Source.SigningKeys }}
+            optional: false
+        {{- end }}
         {{- if eq .Stage "apply" }}
         - name: planout
           secret:
@@ -82,21 +105,50 @@ spec:
         {{- end }}
 
       initContainers:
-        - name: setup
+        - name: {{ .SetupContainerName }}
           image: {{ .Images.Executor }}
           imagePullPolicy: {{ .ImagePullPolicy }}
           command:
             - /bin/step
           args:
             - --comment='Setting up the environment'
+            - --name=setup
+            - --namespace=$(KUBE_NAMESPACE)
+            - --results=/run/steps/results.json
+            - --results-secret=$(STEP_RESULTS_NAME)
             - --command=/bin/mkdir -p /run/bin
             - --command=/bin/mkdir -p /run/steps
             - --command=/bin/cp /run/config/* /data
             - --command=/bin/cp /bin/step /run/bin/step
+            {{- if .Cache.Volume }}
+            - --command=/bin/mkdir -p /cache/modules /cache/providers
+            # providers pre-seeded in the mirror are installed from there in preference to the registry
+            - --command=if [ -d /cache/mirror ]; then /bin/mkdir -p /data/.terraform.d && /bin/ln -s /cache/mirror /data/.terraform.d/plugins; fi
+            {{- end }}
+            {{- if .Mirror.URL }}
+            # configure terraform to install the providers from the provider mirror, note the quotes are
+            # escaped as the commands are parsed as csv
+            - --command=printf 'provider_installation {\n  network_mirror {\n    url = \042%s\042\n  }\n}\n' {{ .Mirror.URL }} > /data/.terraformrc
+            {{- end }}
             - --command=/bin/source --dest=/data --source={{ .Configuration.Module }}
+              {{- if .Cache.Volume }} --cache=/cache/modules{{ end }}
+              {{- if .Configuration.ModuleChecksum }} --checksum={{ .Configuration.ModuleChecksum }}{{ end }}
+              {{- range .Source.AllowedHosts }} --allowed-host='{{ . }}'{{ end }}
+              {{- range .Source.AllowedSchemes }} --allowed-scheme={{ . }}{{ end }}
+              {{- if .Source.SigningKeys }} --signing-keys=/run/keys{{ end }}
           env:
             - name: HOME
               value: /data
+            - name: JOB_NAME
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.labels['job-name']
+            - name: KUBE_NAMESPACE
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.namespace
+            - name: STEP_RESULTS_NAME
+              value: {{ .Secrets.StepResults }}
           envFrom:
           {{- if .Secrets.Config }}
             - secretRef:
@@ -117,6 +169,8 @@ spec:
             allowPrivilegeEscalation: false
             capabilities:
               drop: [ALL]
+          # surface any source verification failures on the pod status
+          terminationMessagePolicy: FallbackToLogsOnError
           volumeMounts:
             - name: config
               mountPath: /run/config
@@ -125,6 +179,62 @@ spec:
               mountPath: /run
             - name: source
               mountPath: /data
+            {{- if .Source.SigningKeys }}
+            - name: signing-keys
+              mountPath: /run/keys
+              readOnly: true
+            {{- end }}
+            {{- if .Cache.Volume }}
+            - name: cache
+              mountPath: /cache
+            {{- end }}
+
+        {{- if .Cache.Volume }}
+        # populates the shared provider cache, this is the only container outside of the setup
+        # permitted to write to the cache; the providers are linked into the source directory
+        - name: cache
+          image: {{ .Images.Image }}
+          workingDir: /data
+          command:
+            - {{ $binary }}
+          args:
+            - init
+            - -backend=false
+            - -input=false
+          env:
+            - name: HOME
+              value: /data
+            - name: TF_PLUGIN_CACHE_DIR
+              value: /cache/providers
+            {{- if .Mirror.URL }}
+            - name: TF_CLI_CONFIG_FILE
+              value: /data/.terraformrc
+            {{- end }}
+            {{- if .Mirror.CASecret }}
+            - name: SSL_CERT_DIR
+              value: /run/mirror
+            {{- end }}
+          envFrom:
+          {{- range .Secrets.AdditionalSecrets }}
+            - secretRef:
+                name: {{ . }}
+                optional: false
+          {{- end }}
+          securityContext:
+            allowPrivilegeEscalation: false
+            capabilities:
+              drop: [ALL]
+          volumeMounts:
+            - name: source
+              mountPath: /data
+            - name: cache
+              mountPath: /cache
+            {{- if .Mirror.CASecret }}
+            - name: mirror-ca
+              mountPath: /run/mirror
+              readOnly: true
+            {{- end }}
+        {{- end }}
 
         - name: init
           image: {{ .Images.Image }}
@@ -136,6 +246,14 @@ spec:
           env:
             - name: HOME
               value: /data
+            {{- if .Mirror.URL }}
+            - name: TF_CLI_CONFIG_FILE
+              value: /data/.terraformrc
+            {{- end }}
+            {{- if .Mirror.CASecret }}
+            - name: SSL_CERT_DIR
+              value: /run/mirror
+            {{- end }}
           envFrom:
           {{- range .Secrets.AdditionalSecrets }}
             - secretRef:
@@ -149,6 +267,16 @@ spec:
           volumeMounts:
             - name: source
               mountPath: /data
+            {{- if .Cache.Volume }}
+            - name: cache
+              mountPath: /cache
+              readOnly: true
+            {{- end }}
+            {{- if .Mirror.CASecret }}
+            - name: mirror-ca
+              mountPath: /run/mirror
+              readOnly: true
+            {{- end }}
 
         {{- if and (.Policy) (.Policy.Source) (eq .Stage "plan") }}
         - name: policy-source
@@ -207,6 +335,63 @@ spec:
         {{- end }}
         {{- end }}
 
+        #
+        # @step: the hooks executed in order before the stage
+        #
+        {{- range .Hooks.Pre }}
+        - name: {{ .Name }}
+          image: {{ .Image | toJson }}
+          imagePullPolicy: {{ $.ImagePullPolicy }}
+          workingDir: /data
+          command:
+            - /run/bin/step
+          args:
+            - --comment=Executing the {{ .Name }}
+            {{- range .Commands }}
+            - {{ printf "--command=%s" . | toJson }}
+            {{- end }}
+            {{- if .ContinueOnError }}
+            - --continue-on-error
+            {{- end }}
+            {{- if .Timeout }}
+            - --command-timeout={{ .Timeout }}
+            {{- end }}
+            {{- if .Retries }}
+            - --retry-attempts={{ .Retries }}
+            - --retry-min-backoff=5s
+            {{- end }}
+            - --name={{ .Name }}
+            - --namespace=$(KUBE_NAMESPACE)
+            - --results=/run/steps/results.json
+            - --results-secret=$(STEP_RESULTS_NAME)
+          env:
+            - name: HOME
+              value: /data
+            - name: CONFIGURATION_NAME
+              value: {{ $.Configuration.Name }}
+            - name: CONFIGURATION_NAMESPACE
+              value: {{ $.Configuration.Namespace }}
+            - name: JOB_NAME
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.labels['job-name']
+            - name: KUBE_NAMESPACE
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.namespace
+            - name: STEP_RESULTS_NAME
+              value: {{ $.Secrets.StepResults }}
+          securityContext:
+            allowPrivilegeEscalation: false
+            capabilities:
+              drop: [ALL]
+          volumeMounts:
+            - name: run
+              mountPath: /run
+            - name: source
+              mountPath: /data
+        {{- end }}
+
       containers:
       - name: {{ .TerraformContainerName }}
         image: {{ .Images.Image }}
@@ -215,7 +400,7 @@ spec:
         command:
           - /run/bin/step
         args:
-          - --comment=Executing Terraform
+          - --comment=Executing {{ default "Terraform" .EngineName }}
           {{- if eq .Stage "plan" }}
           - --command={{ $binary }} plan {{ .TerraformArguments }} -out=/run/plan.out -lock=false -no-color -input=false
           # We need to retain a uncompressed version, for checkov and infracosts
@@ -223,7 +408,6 @@ spec:
           - --command=/bin/cp /run/tfplan.json /run/plan.json
           - --command=/bin/gzip /run/plan.json
           - --command=/bin/mv /run/plan.json.gz /run/plan.json
-          - --namespace=$(KUBE_NAMESPACE)
           - --upload=$(TERRAFORM_PLAN_JSON_NAME)=/run/plan.json
           - --upload=$(TERRAFORM_PLAN_OUT_NAME)=/run/plan.out
           {{- end }}
@@ -233,15 +417,21 @@ spec:
           - --command={{ $binary }} state pull > /run/tfstate
           - --command=/bin/gzip /run/tfstate
           - --command=/bin/mv /run/tfstate.gz /run/tfstate
-          - --namespace=$(KUBE_NAMESPACE)
           - --upload=$(TERRAFORM_STATE_NAME)=/run/tfstate
           {{- end }}
           {{- end }}
           {{- if eq .Stage "destroy" }}
           - --command={{ $binary }} destroy {{ .TerraformArguments }} -auto-approve
           {{- end }}
+          {{- if eq .Stage "unlock" }}
+          - --command={{ $binary }} force-unlock -force {{ .StateLockID }}
+          {{- end }}
+          - --name=terraform
+          - --namespace=$(KUBE_NAMESPACE)
           - --on-error=/run/steps/terraform.failed
           - --on-success=/run/steps/terraform.complete
+          - --results=/run/steps/results.json
+          - --results-secret=$(STEP_RESULTS_NAME)
         env:
           - name: HOME
             value: /data
@@ -261,6 +451,20 @@ spec:
             value: {{ .Secrets.TerraformPlanOut }}
           - name: TERRAFORM_PLAN_JSON_NAME
             value: {{ .Secrets.TerraformPlanJSON }}
+          - name: JOB_NAME
+            valueFrom:
+              fieldRef:
+                fieldPath: metadata.labels['job-name']
+          - name: STEP_RESULTS_NAME
+            value: {{ .Secrets.StepResults }}
+          {{- if .Mirror.URL }}
+          - name: TF_CLI_CONFIG_FILE
+            value: /data/.terraformrc
+          {{- end }}
+          {{- if .Mirror.CASecret }}
+          - name: SSL_CERT_DIR
+            value: /run/mirror
+          {{- end }}
         envFrom:
         {{- if eq .Provider.Source "secret" }}
           - secretRef:
@@ -303,6 +507,17 @@ spec:
             mountPath: /run
           - name: source
             mountPath: /data
+          {{- if .Cache.Volume }}
+          # the providers installed by init are linked from the cache
+          - name: cache
+            mountPath: /cache
+            readOnly: true
+          {{- end }}
+          {{- if .Mirror.CASecret }}
+          - name: mirror-ca
+            mountPath: /run/mirror
+            readOnly: true
+          {{- end }}
 
       {{- if and (.EnableInfraCosts) (eq .Stage "plan") }}
       - name: costs
@@ -312,10 +527,13 @@ spec:
           - /run/bin/step
         args:
           - --comment=Evaluating the costs
+          - --name=costs
           - --command=/usr/bin/infracost breakdown --path /run/tfplan.json
           - --command=/usr/bin/infracost breakdown --path /run/tfplan.json --format json > /run/costs.json
           - --namespace=$(KUBE_NAMESPACE)
           - --upload=$(COST_REPORT_NAME)=/run/costs.json
+          - --results=/run/steps/results.json
+          - --results-secret=$(STEP_RESULTS_NAME)
           - --is-failure=/run/steps/terraform.failed
           - --timeout=5m
           - --wait-on=/run/steps/terraform.complete
@@ -324,10 +542,16 @@ spec:
             value: {{ .Secrets.InfracostsReport }}
           - name: INFRACOST_SKIP_UPDATE_CHECK
             value: "true"
+          - name: JOB_NAME
+            valueFrom:
+              fieldRef:
+                fieldPath: metadata.labels['job-name']
           - name: KUBE_NAMESPACE
             valueFrom:
               fieldRef:
                 fieldPath: metadata.namespace
+          - name: STEP_RESULTS_NAME
+            value: {{ .Secrets.StepResults }}
         envFrom:
         {{- if .Secrets.Infracosts }}
           - secretRef:
@@ -363,19 +587,28 @@ spec:
           - /run/bin/step
         args:
           - --comment=Evaluating Against Security Policy
+          - --name=policy
           - --command=/usr/local/bin/checkov --config {{ $configfile }} {{ $options }} >/dev/null
           - --command=/bin/cat /run/results_cli.txt
           - --namespace=$(KUBE_NAMESPACE)
           - --upload=$(POLICY_REPORT_NAME)=/run/results_json.json
+          - --results=/run/steps/results.json
+          - --results-secret=$(STEP_RESULTS_NAME)
           - --is-failure=/run/steps/terraform.failed
           - --wait-on=/run/steps/terraform.complete
         env:
+          - name: JOB_NAME
+            valueFrom:
+              fieldRef:
+                fieldPath: metadata.labels['job-name']
           - name: KUBE_NAMESPACE
             valueFrom:
               fieldRef:
                 fieldPath: metadata.namespace
           - name: POLICY_REPORT_NAME
             value: {{ .Secrets.PolicyReport }}
+          - name: STEP_RESULTS_NAME
+            value: {{ .Secrets.StepResults }}
         envFrom:
         {{- if .Secrets.Policy }}
           - secretRef:
@@ -401,3 +634,63 @@ spec:
           - name: source
             mountPath: /data
       {{- end }}
+
+      #
+      # @step: the hooks executed concurrently once the stage has completed successfully
+      #
+      {{- range .Hooks.Post }}
+      - name: {{ .Name }}
+        image: {{ .Image | toJson }}
+        imagePullPolicy: {{ $.ImagePullPolicy }}
+        workingDir: /data
+        command:
+          - /run/bin/step
+        args:
+          - --comment=Executing the {{ .Name }}
+          {{- range .Commands }}
+          - {{ printf "--command=%s" . | toJson }}
+          {{- end }}
+          {{- if .ContinueOnError }}
+          - --continue-on-error
+          {{- end }}
+          {{- if .Timeout }}
+          - --command-timeout={{ .Timeout }}
+          {{- end }}
+          {{- if .Retries }}
+          - --retry-attempts={{ .Retries }}
+          - --retry-min-backoff=5s
+          {{- end }}
+          - --is-failure=/run/steps/terraform.failed
+          - --timeout=24h
+          - --wait-on=/run/steps/terraform.complete
+          - --name={{ .Name }}
+          - --namespace=$(KUBE_NAMESPACE)
+          - --results=/run/steps/results.json
+          - --results-secret=$(STEP_RESULTS_NAME)
+        env:
+          - name: HOME
+            value: /data
+          - name: CONFIGURATION_NAME
+            value: {{ $.Configuration.Name }}
+          - name: CONFIGURATION_NAMESPACE
+            value: {{ $.Configuration.Namespace }}
+          - name: JOB_NAME
+            valueFrom:
+              fieldRef:
+                fieldPath: metadata.labels['job-name']
+          - name: KUBE_NAMESPACE
+            valueFrom:
+              fieldRef:
+                fieldPath: metadata.namespace
+          - name: STEP_RESULTS_NAME
+            value: {{ $.Secrets.StepResults }}
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop: [ALL]
+        volumeMounts:
+          - name: run
+            mountPath: /run
+          - name: source
+            mountPath: /data
+      {{- end }}
@@ -24,7 +24,9 @@ import (
 
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	k8sclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
 
 	"github.com/appvia/terranetes-controller/pkg/schema"
 	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
@@ -41,6 +43,8 @@ type Factory interface {
 	GetClient() (client.Client, error)
 	// GetKubeClient returns the kubernetes client
 	GetKubeClient() (k8sclient.Interface, error)
+	// GetRESTConfig returns the configuration used to connect to the kubernetes api
+	GetRESTConfig() (*rest.Config, error)
 	// GetStreams returns the input and output streams for the command
 	GetStreams() genericclioptions.IOStreams
 	// Printf prints a message to the output stream
@@ -136,6 +140,16 @@ func (f *factory) GetKubeClient() (k8sclient.Interface, error) {
 	return kubernetes.NewKubeClient()
 }
 
+// GetRESTConfig returns the configuration used to connect to the kubernetes api
+func (f *factory) GetRESTConfig() (*rest.Config, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find kubeconfig: %w", err)
+	}
+
+	return cfg, nil
+}
+
 // GetClient returns the client for the kubernetes api
 func (f *factory) GetClient() (client.Client, error) {
 	if f.cc != nil {
@@ -27,6 +27,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 
 	"github.com/appvia/terranetes-controller/pkg/utils"
+	load "github.com/appvia/terranetes-controller/pkg/utils/preload"
 	"github.com/appvia/terranetes-controller/pkg/version"
 )
 
@@ -71,8 +72,10 @@ func New() *cobra.Command {
 	flags.StringVar(&o.Config.Cloud, "cloud", os.Getenv("CLOUD"), "Is the cloud vendor we are retrieving data from")
 	flags.StringVar(&o.Config.Cluster, "cluster", os.Getenv("CLUSTER"), "Is the cluster name we are retrieving data from")
 	flags.StringVar(&o.Config.Context, "context", os.Getenv("CONTEXT"), "Is the context name we will provision the data into")
+	flags.StringVar(&o.Config.Project, "project", os.Getenv("GOOGLE_PROJECT"), "Is the google project the cluster resides in")
 	flags.StringVar(&o.Config.Provider, "provider", os.Getenv("PROVIDER"), "Is the provider name which triggered the preloading")
 	flags.StringVar(&o.Config.Region, "region", os.Getenv("REGION"), "Is the region we are retrieving data from")
+	flags.StringVar(&o.Config.Subscription, "subscription", os.Getenv("ARM_SUBSCRIPTION_ID"), "Is the azure subscription the cluster resides in")
 	flags.Bool("verbose", true, "Enable verbose logging")
 
 	return cmd
@@ -89,7 +92,7 @@ func (c *Command) Run(ctx context.Context) error {
 		return fmt.Errorf("provider is required")
 	case c.Region == "":
 		return fmt.Errorf("region is required")
-	case c.Cloud != "aws":
+	case !load.IsSupported(c.Cloud):
 		return fmt.Errorf("%s cloud is not supported", c.Cloud)
 	}
 
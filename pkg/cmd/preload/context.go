@@ -18,11 +18,8 @@
 package preload
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 
-	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
@@ -41,44 +38,34 @@ func (c *Command) makeContext(ctx context.Context, data preload.Data) error {
 		return err
 	}
 
-	// @step: create the new context resource
-	encoded := &bytes.Buffer{}
-	if err := data.MarshalTo(encoded); err != nil {
-		return err
-	}
-	txt := &terraformv1alpha1.Context{}
-	if err := json.NewDecoder(encoded).Decode(&txt.Spec.Variables); err != nil {
-		return err
-	}
-	txt.Name = c.Context
-	txt.Labels = map[string]string{
-		terraformv1alpha1.PreloadProviderLabel: c.Provider,
-	}
-
 	// @step: next we need to grab the current context if any
-	current := &terraformv1alpha1.Context{}
-	current.Name = txt.Name
+	current := terraformv1alpha1.NewContext(c.Context)
 
 	// @step: check if the context exists
 	found, err := kubernetes.GetIfExists(ctx, cc, current)
 	if err != nil {
 		return err
 	}
-	if !found {
-		c.logger.Info("no existing context found, creating a new one")
+	original := current.DeepCopy()
 
-		return cc.Create(ctx, txt)
+	// @step: update the values in the context, removing any variables we previously loaded
+	// which no longer exist
+	removed, err := data.ApplyTo(current)
+	if err != nil {
+		return err
+	}
+	for _, key := range removed {
+		c.logger.WithField("key", key).Info("removing variable no longer found in the cloud vendor")
 	}
 
-	// @step: update the values in the context
-	original := current.DeepCopy()
+	if !found {
+		c.logger.Info("no existing context found, creating a new one")
 
-	for _, key := range data.Keys() {
-		encoded, err := data.Get(key).Marshal()
-		if err != nil {
-			return err
+		current.Labels = map[string]string{
+			terraformv1alpha1.PreloadProviderLabel: c.Provider,
 		}
-		current.Spec.Variables[key] = runtime.RawExtension{Raw: encoded}
+
+		return cc.Create(ctx, current)
 	}
 	if err := cc.Patch(ctx, current, client.MergeFrom(original)); err != nil {
 		return err
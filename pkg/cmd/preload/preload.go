@@ -20,16 +20,46 @@ package preload
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
 
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
 	load "github.com/appvia/terranetes-controller/pkg/utils/preload"
+	"github.com/appvia/terranetes-controller/pkg/utils/preload/aks"
+	aksapi "github.com/appvia/terranetes-controller/pkg/utils/preload/aks/api"
 	"github.com/appvia/terranetes-controller/pkg/utils/preload/eks"
+	"github.com/appvia/terranetes-controller/pkg/utils/preload/gke"
 )
 
 // preload is responsible for retrieving the data from the cloud vendor
 func (c *Command) preload(ctx context.Context) (load.Data, error) {
+	var pe load.Interface
+	var err error
+
+	// @step: create the preloader for the cloud vendor
+	switch terraformv1alpha1.ProviderType(c.Cloud) {
+	case terraformv1alpha1.AWSProviderType:
+		pe, err = c.newEKSPreloader()
+	case terraformv1alpha1.AzureProviderType:
+		pe, err = c.newAKSPreloader(ctx)
+	case terraformv1alpha1.GCPProviderType:
+		pe, err = c.newGKEPreloader(ctx)
+	default:
+		return nil, fmt.Errorf("%s cloud is not supported", c.Cloud)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create preloader for cloud: %w", err)
+	}
+
+	return pe.Load(ctx)
+}
+
+// newEKSPreloader is responsible for creating the preloader for eks clusters
+func (c *Command) newEKSPreloader() (load.Interface, error) {
 	session, err := session.NewSession(&aws.Config{
 		Region: aws.String(c.Region),
 	})
@@ -37,14 +67,54 @@ func (c *Command) preload(ctx context.Context) (load.Data, error) {
 		return nil, fmt.Errorf("failed to create aws session, error: %w", err)
 	}
 
-	// @step: create the preloader for eks clusters
-	pe, err := eks.New(eks.Config{
+	return eks.New(eks.Config{
 		ClusterName: c.Cluster,
 		Session:     session,
 	})
+}
+
+// newAKSPreloader is responsible for creating the preloader for aks clusters
+func (c *Command) newAKSPreloader(ctx context.Context) (load.Interface, error) {
+	hc, err := aksapi.NewHTTPClient(ctx, aksapi.NewCredentialsFromEnv())
 	if err != nil {
-		return nil, fmt.Errorf("failed to create preloader for cloud: %w", err)
+		return nil, fmt.Errorf("failed to create azure client, error: %w", err)
 	}
 
-	return pe.Load(ctx)
+	return aks.New(aks.Config{
+		ClusterName:    c.Cluster,
+		Client:         hc,
+		Region:         c.Region,
+		SubscriptionID: c.Subscription,
+	})
+}
+
+// newGKEPreloader is responsible for creating the preloader for gke clusters
+func (c *Command) newGKEPreloader(ctx context.Context) (load.Interface, error) {
+	scope := "https://www.googleapis.com/auth/cloud-platform"
+
+	// @step: the google provider secrets carry the service account in GOOGLE_CREDENTIALS, else we
+	// fallback to the application default credentials i.e. workload identity
+	var credentials *google.Credentials
+	var err error
+
+	if encoded := os.Getenv("GOOGLE_CREDENTIALS"); encoded != "" {
+		credentials, err = google.CredentialsFromJSON(ctx, []byte(encoded), scope)
+	} else {
+		credentials, err = google.FindDefaultCredentials(ctx, scope)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve google credentials, error: %w", err)
+	}
+
+	project := c.Project
+	if project == "" {
+		project = credentials.ProjectID
+	}
+
+	return gke.New(gke.Config{
+		ClusterName: c.Cluster,
+		Client:      oauth2.NewClient(ctx, credentials.TokenSource),
+		Project:     project,
+		Region:      c.Region,
+	})
 }
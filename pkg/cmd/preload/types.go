@@ -28,8 +28,13 @@ type Config struct {
 	Context string
 	// EnableOverride is a flag to enable overriding the context if it already exists
 	EnableOverride bool
+	// Project is the google project the cluster resides in, defaults to the project
+	// associated to the credentials
+	Project string
 	// Provider is the provider name which triggered the preloading
 	Provider string
 	// Region is the cloud vendor region we are dealing with
 	Region string
+	// Subscription is the azure subscription the cluster resides in
+	Subscription string
 }
@@ -0,0 +1,252 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bitbucket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/appvia/terranetes-controller/pkg/cmd"
+	"github.com/appvia/terranetes-controller/pkg/cmd/search"
+	"github.com/appvia/terranetes-controller/pkg/utils"
+	"github.com/appvia/terranetes-controller/pkg/version"
+)
+
+// DefaultAPI is the default bitbucket api endpoint
+const DefaultAPI = "https://api.bitbucket.org/2.0"
+
+type bbClient struct {
+	// api is the bitbucket api endpoint
+	api string
+	// endpoint is the bitbucket endpoint
+	endpoint string
+	// hc is the http client
+	hc *http.Client
+	// password is the bitbucket app password
+	password string
+	// username is the bitbucket username
+	username string
+	// workspace is the bitbucket workspace to search
+	workspace string
+}
+
+// repository is a bitbucket repository
+type repository struct {
+	CreatedOn   time.Time `json:"created_on"`
+	Description string    `json:"description"`
+	FullName    string    `json:"full_name"`
+	IsPrivate   bool      `json:"is_private"`
+	Links       struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+	Slug string `json:"slug"`
+	UUID string `json:"uuid"`
+}
+
+// page is a paginated bitbucket response
+type page struct {
+	// Next is the location of the next page
+	Next string `json:"next"`
+	// Values is the raw values within the page
+	Values json.RawMessage `json:"values"`
+}
+
+// tag is a bitbucket repository tag
+type tag struct {
+	Name string `json:"name"`
+}
+
+var filter = regexp.MustCompile(`^terraform\-[\w]+\-[\w]+`)
+
+// IsHandle returns true if the given string is a valid bitbucket handle
+func IsHandle(source string) bool {
+	switch {
+	case strings.HasPrefix(source, "bitbucket.org/"), strings.HasPrefix(source, "https://bitbucket.org/"):
+		return true
+	}
+
+	return false
+}
+
+// New creates and returns a bitbucket client for the workspace i.e. bitbucket.org/WORKSPACE
+func New(endpoint, username, password string) (search.Interface, error) {
+	switch {
+	case endpoint == "":
+		return nil, cmd.ErrMissingArgument("endpoint")
+	case username != "" && password == "":
+		return nil, errors.New("bitbucket app password required when username is set")
+	}
+
+	workspace := strings.Trim(strings.TrimPrefix(strings.TrimPrefix(endpoint, "https://"), "bitbucket.org"), "/")
+	if workspace == "" || strings.Contains(workspace, "/") {
+		return nil, errors.New("must be a bitbucket workspace i.e. bitbucket.org/WORKSPACE")
+	}
+
+	return &bbClient{
+		api:       DefaultAPI,
+		endpoint:  endpoint,
+		hc:        &http.Client{Timeout: 30 * time.Second},
+		password:  password,
+		username:  username,
+		workspace: workspace,
+	}, nil
+}
+
+// Source returns the source of the given module
+func (b *bbClient) Source() string {
+	return b.endpoint
+}
+
+// ResolveSource returns the source of the given module
+func (b *bbClient) ResolveSource(_ context.Context, module search.Module) (string, error) {
+	source := module.Source
+	if module.Private {
+		source = fmt.Sprintf("git::ssh://git@%s", strings.TrimPrefix(module.Source, "https://"))
+	}
+
+	return fmt.Sprintf("%s?ref=%s", source, module.Version), nil
+}
+
+// Find returns the repositories within the workspace matching the given search term
+func (b *bbClient) Find(ctx context.Context, query search.Query) ([]search.Module, error) {
+	var modules []search.Module
+
+	params := url.Values{}
+	params.Set("pagelen", "100")
+	params.Set("q", `name ~ "terraform-"`)
+
+	var list []repository
+
+	location := fmt.Sprintf("%s/repositories/%s?%s", b.api, url.PathEscape(b.workspace), params.Encode())
+	for location != "" {
+		var results []repository
+
+		next, err := b.get(ctx, location, &results)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, results...)
+		location = next
+	}
+
+	for i := 0; i < len(list); i++ {
+		switch {
+		case !filter.MatchString(list[i].Slug):
+			continue
+		case query.Query != "" && !containsTerms(query.Query, list[i]):
+			continue
+		}
+
+		modules = append(modules, search.Module{
+			CreatedAt:    list[i].CreatedOn,
+			Description:  list[i].Description,
+			ID:           list[i].FullName,
+			Name:         list[i].Slug,
+			Namespace:    b.workspace,
+			Private:      list[i].IsPrivate,
+			Registry:     b.endpoint,
+			RegistryType: "BB",
+			Source:       list[i].Links.HTML.Href,
+		})
+	}
+
+	return modules, nil
+}
+
+// Versions returns a list of tags from the module
+func (b *bbClient) Versions(ctx context.Context, module search.Module) ([]string, error) {
+	var versions []string
+
+	location := fmt.Sprintf("%s/repositories/%s/refs/tags?pagelen=100&sort=-target.date", b.api, module.ID)
+	for location != "" {
+		var results []tag
+
+		next, err := b.get(ctx, location, &results)
+		if err != nil {
+			return nil, err
+		}
+		for _, x := range results {
+			versions = append(versions, x.Name)
+		}
+		location = next
+	}
+	if len(versions) == 0 {
+		return nil, errors.New("no tags found in source repository")
+	}
+
+	return versions, nil
+}
+
+// get performs a request against the bitbucket api, decoding the values and returning
+// the location of the next page if any
+func (b *bbClient) get(ctx context.Context, location string, out interface{}) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("%s/%s", version.Name, version.Version))
+	if b.username != "" {
+		req.SetBasicAuth(b.username, b.password)
+	}
+
+	resp, err := b.hc.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", fmt.Errorf("%q not found", b.workspace)
+	default:
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var p page
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		return "", err
+	}
+	if len(p.Values) > 0 {
+		if err := json.Unmarshal(p.Values, out); err != nil {
+			return "", err
+		}
+	}
+
+	return p.Next, nil
+}
+
+// containsTerms returns true if the given string is contained in the repository terms
+func containsTerms(query string, r repository) bool {
+	terms := strings.ToLower(strings.ReplaceAll(r.Description, ",", " "))
+	terms = terms + " " + strings.ToLower(strings.ReplaceAll(r.Slug, "-", " "))
+
+	lower := strings.ToLower(query)
+
+	return utils.ContainsList(strings.Split(lower, " "), strings.Split(terms, " "))
+}
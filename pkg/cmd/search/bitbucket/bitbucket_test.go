@@ -0,0 +1,174 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bitbucket
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/appvia/terranetes-controller/pkg/cmd/search"
+)
+
+// newTestClient returns a client pointed at a server replaying the recorded bitbucket responses
+func newTestClient(t *testing.T, username, password string) *bbClient {
+	var server *httptest.Server
+
+	fixture := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if user, pass, _ := r.BasicAuth(); user != "user" || pass != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+			content, err := os.ReadFile("testdata/" + name)
+			require.NoError(t, err)
+
+			_, _ = w.Write([]byte(strings.ReplaceAll(string(content), "{{ .Server }}", server.URL)))
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repositories/appvia", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fixture("repositories-2.json")(w, r)
+
+			return
+		}
+		fixture("repositories-1.json")(w, r)
+	})
+	mux.HandleFunc("/repositories/appvia/terraform-aws-s3/refs/tags", fixture("tags.json"))
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	c, err := New("bitbucket.org/appvia", username, password)
+	require.NoError(t, err)
+	c.(*bbClient).api = server.URL
+
+	return c.(*bbClient)
+}
+
+func TestNew(t *testing.T) {
+	c, err := New("bitbucket.org/appvia", "", "")
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+	assert.Equal(t, "bitbucket.org/appvia", c.Source())
+	assert.Equal(t, "appvia", c.(*bbClient).workspace)
+}
+
+func TestNewInvalid(t *testing.T) {
+	cases := []struct {
+		Endpoint string
+		Username string
+		Password string
+		Expected string
+	}{
+		{Expected: `missing required argument: "endpoint"`},
+		{Endpoint: "bitbucket.org", Expected: "must be a bitbucket workspace i.e. bitbucket.org/WORKSPACE"},
+		{Endpoint: "bitbucket.org/appvia/repo", Expected: "must be a bitbucket workspace i.e. bitbucket.org/WORKSPACE"},
+		{Endpoint: "bitbucket.org/appvia", Username: "user", Expected: "bitbucket app password required when username is set"},
+	}
+	for _, c := range cases {
+		client, err := New(c.Endpoint, c.Username, c.Password)
+		assert.Error(t, err)
+		assert.Nil(t, client)
+		assert.Contains(t, err.Error(), c.Expected)
+	}
+}
+
+func TestIsHandle(t *testing.T) {
+	cases := []struct {
+		Source   string
+		Expected bool
+	}{
+		{Source: ""},
+		{Source: "github.com/appvia"},
+		{Source: "bitbucket.org/appvia", Expected: true},
+		{Source: "https://bitbucket.org/appvia", Expected: true},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.Expected, IsHandle(c.Source), "case: %s", c.Source)
+	}
+}
+
+func TestFind(t *testing.T) {
+	c := newTestClient(t, "user", "pass")
+
+	modules, err := c.Find(context.Background(), search.Query{})
+	assert.NoError(t, err)
+	assert.Len(t, modules, 2)
+	assert.Equal(t, "terraform-aws-s3", modules[0].Name)
+	assert.Equal(t, "appvia/terraform-aws-s3", modules[0].ID)
+	assert.Equal(t, "BB", modules[0].RegistryType)
+	assert.Equal(t, "appvia", modules[0].Namespace)
+	assert.False(t, modules[0].Private)
+	assert.Equal(t, "terraform-aws-rds", modules[1].Name)
+	assert.True(t, modules[1].Private)
+}
+
+func TestFindWithQuery(t *testing.T) {
+	c := newTestClient(t, "user", "pass")
+
+	modules, err := c.Find(context.Background(), search.Query{Query: "database"})
+	assert.NoError(t, err)
+	assert.Len(t, modules, 1)
+	assert.Equal(t, "terraform-aws-rds", modules[0].Name)
+}
+
+func TestFindUnauthorized(t *testing.T) {
+	c := newTestClient(t, "", "")
+
+	modules, err := c.Find(context.Background(), search.Query{})
+	assert.Error(t, err)
+	assert.Equal(t, "unexpected status code: 401", err.Error())
+	assert.Nil(t, modules)
+}
+
+func TestVersions(t *testing.T) {
+	c := newTestClient(t, "user", "pass")
+
+	versions, err := c.Versions(context.Background(), search.Module{ID: "appvia/terraform-aws-s3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v1.1.0", "v1.0.0"}, versions)
+}
+
+func TestResolveSource(t *testing.T) {
+	c, err := New("bitbucket.org/appvia", "", "")
+	require.NoError(t, err)
+
+	source, err := c.ResolveSource(context.Background(), search.Module{
+		Source:  "https://bitbucket.org/appvia/terraform-aws-s3",
+		Version: "v1.0.0",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://bitbucket.org/appvia/terraform-aws-s3?ref=v1.0.0", source)
+
+	source, err = c.ResolveSource(context.Background(), search.Module{
+		Private: true,
+		Source:  "https://bitbucket.org/appvia/terraform-aws-s3",
+		Version: "v1.0.0",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "git::ssh://git@bitbucket.org/appvia/terraform-aws-s3?ref=v1.0.0", source)
+}
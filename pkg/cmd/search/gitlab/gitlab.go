@@ -0,0 +1,277 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/appvia/terranetes-controller/pkg/cmd"
+	"github.com/appvia/terranetes-controller/pkg/cmd/search"
+	"github.com/appvia/terranetes-controller/pkg/utils"
+	"github.com/appvia/terranetes-controller/pkg/version"
+)
+
+type glClient struct {
+	// baseURL is the gitlab api base url
+	baseURL string
+	// endpoint is the gitlab endpoint
+	endpoint string
+	// group is the gitlab group to search
+	group string
+	// hc is the http client
+	hc *http.Client
+	// token is the gitlab token
+	token string
+	// topics is an optional collection of topics to filter projects by
+	topics []string
+}
+
+// project is a gitlab project
+type project struct {
+	CreatedAt     time.Time `json:"created_at"`
+	Description   string    `json:"description"`
+	HTTPURLToRepo string    `json:"http_url_to_repo"`
+	ID            int       `json:"id"`
+	Namespace     struct {
+		FullPath string `json:"full_path"`
+	} `json:"namespace"`
+	Path         string   `json:"path"`
+	SSHURLToRepo string   `json:"ssh_url_to_repo"`
+	StarCount    int      `json:"star_count"`
+	Topics       []string `json:"topics"`
+	Visibility   string   `json:"visibility"`
+	WebURL       string   `json:"web_url"`
+}
+
+// tag is a gitlab repository tag
+type tag struct {
+	Name string `json:"name"`
+}
+
+var filter = regexp.MustCompile(`^terraform\-[\w]+\-[\w]+`)
+
+// IsHandle returns true if the given string is a valid gitlab handle
+func IsHandle(source string) bool {
+	switch {
+	case strings.HasPrefix(source, "gitlab://"):
+		return true
+	case strings.HasPrefix(source, "gitlab.com/"), strings.HasPrefix(source, "https://gitlab.com/"):
+		return true
+	}
+
+	return false
+}
+
+// New creates and returns a gitlab client. The endpoint is the group to search, i.e.
+// gitlab.com/GROUP or gitlab://HOST/GROUP for self-hosted instances, optionally filtered
+// by topics i.e. gitlab.com/GROUP?topic=terraform
+func New(endpoint, token string) (search.Interface, error) {
+	switch {
+	case endpoint == "":
+		return nil, cmd.ErrMissingArgument("endpoint")
+	}
+
+	location := endpoint
+	if !strings.Contains(location, "://") {
+		location = fmt.Sprintf("https://%s", location)
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Trim(u.Path, "/") == "" {
+		return nil, errors.New("must be a gitlab group i.e. gitlab.com/GROUP")
+	}
+	scheme := u.Scheme
+	if scheme == "gitlab" {
+		scheme = "https"
+	}
+
+	var topics []string
+	for _, x := range u.Query()["topic"] {
+		topics = append(topics, strings.Split(x, ",")...)
+	}
+
+	return &glClient{
+		baseURL:  fmt.Sprintf("%s://%s/api/v4", scheme, u.Host),
+		endpoint: endpoint,
+		group:    strings.Trim(u.Path, "/"),
+		hc:       &http.Client{Timeout: 30 * time.Second},
+		token:    token,
+		topics:   topics,
+	}, nil
+}
+
+// Source returns the source of the given module
+func (g *glClient) Source() string {
+	return g.endpoint
+}
+
+// ResolveSource returns the source of the given module
+func (g *glClient) ResolveSource(_ context.Context, module search.Module) (string, error) {
+	source := module.Source
+	if module.Private {
+		source = fmt.Sprintf("git::ssh://git@%s", strings.TrimPrefix(module.Source, "https://"))
+	}
+
+	return fmt.Sprintf("%s?ref=%s", source, module.Version), nil
+}
+
+// Find returns the projects within the group matching the given search term
+func (g *glClient) Find(ctx context.Context, query search.Query) ([]search.Module, error) {
+	var modules []search.Module
+
+	params := url.Values{}
+	params.Set("include_subgroups", "true")
+	params.Set("per_page", "100")
+	if len(g.topics) > 0 {
+		params.Set("topic", strings.Join(g.topics, ","))
+	}
+
+	var list []project
+
+	location := fmt.Sprintf("%s/groups/%s/projects?%s", g.baseURL, url.PathEscape(g.group), params.Encode())
+	for location != "" {
+		var results []project
+
+		next, err := g.get(ctx, location, &results)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, results...)
+		location = next
+	}
+
+	for i := 0; i < len(list); i++ {
+		switch {
+		case query.Namespace != "" && list[i].Namespace.FullPath != query.Namespace:
+			continue
+		case len(g.topics) == 0 && !filter.MatchString(list[i].Path):
+			continue
+		case query.Query != "" && !containsTerms(query.Query, list[i]):
+			continue
+		}
+
+		modules = append(modules, search.Module{
+			CreatedAt:    list[i].CreatedAt,
+			Description:  list[i].Description,
+			ID:           fmt.Sprintf("%d", list[i].ID),
+			Name:         list[i].Path,
+			Namespace:    list[i].Namespace.FullPath,
+			Private:      list[i].Visibility != "public",
+			Registry:     g.endpoint,
+			RegistryType: "GL",
+			Source:       list[i].WebURL,
+			Stars:        list[i].StarCount,
+		})
+	}
+
+	return modules, nil
+}
+
+// Versions returns a list of tags from the module
+func (g *glClient) Versions(ctx context.Context, module search.Module) ([]string, error) {
+	var versions []string
+
+	location := fmt.Sprintf("%s/projects/%s/repository/tags?per_page=100", g.baseURL, url.PathEscape(module.ID))
+	for location != "" {
+		var results []tag
+
+		next, err := g.get(ctx, location, &results)
+		if err != nil {
+			return nil, err
+		}
+		for _, x := range results {
+			versions = append(versions, x.Name)
+		}
+		location = next
+	}
+	if len(versions) == 0 {
+		return nil, errors.New("no tags found in source repository")
+	}
+
+	return versions, nil
+}
+
+// get performs a request against the gitlab api, decoding the response and returning
+// the location of the next page if any
+func (g *glClient) get(ctx context.Context, location string, out interface{}) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("%s/%s", version.Name, version.Version))
+	if g.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.token)
+	}
+
+	resp, err := g.hc.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", fmt.Errorf("%q not found", g.group)
+	default:
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return "", err
+	}
+
+	return nextPage(resp.Header.Get("Link")), nil
+}
+
+// nextPage returns the location of the next page from the link header
+func nextPage(link string) string {
+	for _, x := range strings.Split(link, ",") {
+		parts := strings.Split(strings.TrimSpace(x), ";")
+		if len(parts) != 2 || strings.TrimSpace(parts[1]) != `rel="next"` {
+			continue
+		}
+
+		return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+	}
+
+	return ""
+}
+
+// containsTerms returns true if the given string is contained in the project terms
+func containsTerms(query string, p project) bool {
+	terms := strings.ToLower(strings.ReplaceAll(p.Description, ",", " "))
+	terms = terms + " " + strings.ToLower(strings.Join(p.Topics, " "))
+	terms = terms + " " + strings.ToLower(strings.ReplaceAll(p.Path, "-", " "))
+
+	lower := strings.ToLower(query)
+
+	return utils.ContainsList(strings.Split(lower, " "), strings.Split(terms, " "))
+}
@@ -0,0 +1,210 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gitlab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/appvia/terranetes-controller/pkg/cmd/search"
+)
+
+// newTestServer returns a server replaying the recorded gitlab responses
+func newTestServer(t *testing.T) *httptest.Server {
+	projects, err := os.ReadFile("testdata/projects.json")
+	require.NoError(t, err)
+	tags, err := os.ReadFile("testdata/tags.json")
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/groups/appvia/projects", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+		if r.URL.Query().Get("topic") == "missing" {
+			_, _ = w.Write([]byte("[]"))
+
+			return
+		}
+		_, _ = w.Write(projects)
+	})
+	mux.HandleFunc("/api/v4/projects/1001/repository/tags", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(tags)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestNew(t *testing.T) {
+	c, err := New("gitlab.com/appvia", "")
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+	assert.Equal(t, "gitlab.com/appvia", c.Source())
+}
+
+func TestNewNoGroup(t *testing.T) {
+	c, err := New("gitlab.com", "")
+	assert.Error(t, err)
+	assert.Nil(t, c)
+}
+
+func TestNewWithTopics(t *testing.T) {
+	c, err := New("gitlab://gitlab.example.com/appvia?topic=terraform,aws", "")
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+	assert.Equal(t, []string{"terraform", "aws"}, c.(*glClient).topics)
+	assert.Equal(t, "https://gitlab.example.com/api/v4", c.(*glClient).baseURL)
+}
+
+func TestIsHandle(t *testing.T) {
+	cases := []struct {
+		Source   string
+		Expected bool
+	}{
+		{Source: ""},
+		{Source: "github.com/appvia"},
+		{Source: "gitlab.com/appvia", Expected: true},
+		{Source: "https://gitlab.com/appvia", Expected: true},
+		{Source: "gitlab://gitlab.example.com/appvia", Expected: true},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.Expected, IsHandle(c.Source), "case: %s", c.Source)
+	}
+}
+
+func TestFind(t *testing.T) {
+	server := newTestServer(t)
+
+	c, err := New(server.URL+"/appvia", "token")
+	require.NoError(t, err)
+
+	modules, err := c.Find(context.Background(), search.Query{})
+	assert.NoError(t, err)
+	assert.Len(t, modules, 2)
+	assert.Equal(t, "terraform-aws-s3", modules[0].Name)
+	assert.Equal(t, "1001", modules[0].ID)
+	assert.Equal(t, "GL", modules[0].RegistryType)
+	assert.False(t, modules[0].Private)
+	assert.Equal(t, 12, modules[0].Stars)
+	assert.True(t, modules[1].Private)
+	assert.Equal(t, "appvia/platform", modules[1].Namespace)
+}
+
+func TestFindWithQuery(t *testing.T) {
+	server := newTestServer(t)
+
+	c, err := New(server.URL+"/appvia", "token")
+	require.NoError(t, err)
+
+	modules, err := c.Find(context.Background(), search.Query{Query: "database"})
+	assert.NoError(t, err)
+	assert.Len(t, modules, 1)
+	assert.Equal(t, "terraform-aws-rds", modules[0].Name)
+}
+
+func TestFindWithNamespace(t *testing.T) {
+	server := newTestServer(t)
+
+	c, err := New(server.URL+"/appvia", "token")
+	require.NoError(t, err)
+
+	modules, err := c.Find(context.Background(), search.Query{Namespace: "appvia/platform"})
+	assert.NoError(t, err)
+	assert.Len(t, modules, 1)
+}
+
+func TestFindWithTopicsSkipsNameFilter(t *testing.T) {
+	server := newTestServer(t)
+
+	c, err := New(server.URL+"/appvia?topic=terraform", "token")
+	require.NoError(t, err)
+
+	modules, err := c.Find(context.Background(), search.Query{})
+	assert.NoError(t, err)
+	assert.Len(t, modules, 3)
+}
+
+func TestFindNoMatchingTopics(t *testing.T) {
+	server := newTestServer(t)
+
+	c, err := New(server.URL+"/appvia?topic=missing", "token")
+	require.NoError(t, err)
+
+	modules, err := c.Find(context.Background(), search.Query{})
+	assert.NoError(t, err)
+	assert.Empty(t, modules)
+}
+
+func TestFindUnauthorized(t *testing.T) {
+	server := newTestServer(t)
+
+	c, err := New(server.URL+"/appvia", "")
+	require.NoError(t, err)
+
+	modules, err := c.Find(context.Background(), search.Query{})
+	assert.Error(t, err)
+	assert.Equal(t, "unexpected status code: 401", err.Error())
+	assert.Nil(t, modules)
+}
+
+func TestVersions(t *testing.T) {
+	server := newTestServer(t)
+
+	c, err := New(server.URL+"/appvia", "token")
+	require.NoError(t, err)
+
+	versions, err := c.Versions(context.Background(), search.Module{ID: "1001"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v0.2.0", "v0.1.0"}, versions)
+}
+
+func TestResolveSource(t *testing.T) {
+	c, err := New("gitlab.com/appvia", "")
+	require.NoError(t, err)
+
+	source, err := c.ResolveSource(context.Background(), search.Module{
+		Source:  "https://gitlab.com/appvia/terraform-aws-s3",
+		Version: "v0.1.0",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://gitlab.com/appvia/terraform-aws-s3?ref=v0.1.0", source)
+
+	source, err = c.ResolveSource(context.Background(), search.Module{
+		Private: true,
+		Source:  "https://gitlab.com/appvia/terraform-aws-s3",
+		Version: "v0.1.0",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "git::ssh://git@gitlab.com/appvia/terraform-aws-s3?ref=v0.1.0", source)
+}
+
+func TestNextPage(t *testing.T) {
+	assert.Equal(t, "", nextPage(""))
+	assert.Equal(t, "https://gitlab.com/api/v4/groups?page=2",
+		nextPage(`<https://gitlab.com/api/v4/groups?page=2>; rel="next", <https://gitlab.com/api/v4/groups?page=5>; rel="last"`))
+}
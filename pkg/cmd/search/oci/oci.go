@@ -0,0 +1,336 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/appvia/terranetes-controller/pkg/cmd"
+	"github.com/appvia/terranetes-controller/pkg/cmd/search"
+	"github.com/appvia/terranetes-controller/pkg/utils"
+	"github.com/appvia/terranetes-controller/pkg/version"
+)
+
+type ociClient struct {
+	// endpoint is the oci endpoint
+	endpoint string
+	// hc is the http client
+	hc *http.Client
+	// host is the registry host
+	host string
+	// namespace is the namespace within the registry holding the modules
+	namespace string
+	// password is the registry password
+	password string
+	// scheme is the scheme used to talk to the registry
+	scheme string
+	// token is the bearer token retrieved from the registry token service
+	token string
+	// username is the registry username
+	username string
+}
+
+// catalog is the response from the registry catalog api
+type catalog struct {
+	Repositories []string `json:"repositories"`
+}
+
+// tags is the response from the registry tags api
+type tags struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// challengeRegex is used to extract the parameters from an authentication challenge
+var challengeRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// IsHandle returns true if the given string is a valid oci registry handle
+func IsHandle(source string) bool {
+	return strings.HasPrefix(source, "oci://")
+}
+
+// New creates and returns a client for modules published as OCI artifacts, i.e.
+// oci://HOST/NAMESPACE
+func New(endpoint, username, password string) (search.Interface, error) {
+	switch {
+	case endpoint == "":
+		return nil, cmd.ErrMissingArgument("endpoint")
+	case !IsHandle(endpoint):
+		return nil, errors.New("must be an oci registry i.e. oci://HOST/NAMESPACE")
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, errors.New("must be an oci registry i.e. oci://HOST/NAMESPACE")
+	}
+
+	scheme := "https"
+	if u.Query().Get("insecure") == "true" {
+		scheme = "http"
+	}
+
+	return &ociClient{
+		endpoint:  endpoint,
+		hc:        &http.Client{Timeout: 30 * time.Second},
+		host:      u.Host,
+		namespace: strings.Trim(u.Path, "/"),
+		password:  password,
+		scheme:    scheme,
+		username:  username,
+	}, nil
+}
+
+// Source returns the source of the given module
+func (o *ociClient) Source() string {
+	return o.endpoint
+}
+
+// ResolveSource returns the source of the given module
+func (o *ociClient) ResolveSource(_ context.Context, module search.Module) (string, error) {
+	return fmt.Sprintf("%s?tag=%s", module.Source, module.Version), nil
+}
+
+// Find returns the repositories within the namespace matching the given search term
+func (o *ociClient) Find(ctx context.Context, query search.Query) ([]search.Module, error) {
+	var modules []search.Module
+	var list []string
+
+	location := o.makeURL("/v2/_catalog?n=100")
+	for location != "" {
+		var results catalog
+
+		next, err := o.get(ctx, location, "registry:catalog:*", &results)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, results.Repositories...)
+		location = next
+	}
+
+	for _, repository := range list {
+		name := path.Base(repository)
+		namespace := path.Dir(repository)
+		if namespace == "." {
+			namespace = ""
+		}
+
+		switch {
+		case o.namespace != "" && repository != o.namespace && !strings.HasPrefix(repository, o.namespace+"/"):
+			continue
+		case query.Namespace != "" && namespace != query.Namespace:
+			continue
+		case query.Query != "" && !containsTerms(query.Query, repository):
+			continue
+		}
+
+		modules = append(modules, search.Module{
+			ID:           repository,
+			Name:         name,
+			Namespace:    namespace,
+			Private:      o.username != "",
+			Registry:     o.endpoint,
+			RegistryType: "OCI",
+			Source:       fmt.Sprintf("oci://%s/%s", o.host, repository),
+		})
+	}
+
+	return modules, nil
+}
+
+// Versions returns a list of tags from the module
+func (o *ociClient) Versions(ctx context.Context, module search.Module) ([]string, error) {
+	var versions []string
+
+	location := o.makeURL(fmt.Sprintf("/v2/%s/tags/list?n=100", module.ID))
+	for location != "" {
+		var results tags
+
+		next, err := o.get(ctx, location, fmt.Sprintf("repository:%s:pull", module.ID), &results)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, results.Tags...)
+		location = next
+	}
+	if len(versions) == 0 {
+		return nil, errors.New("no tags found in source repository")
+	}
+
+	return versions, nil
+}
+
+// makeURL returns the full url for the path on the registry
+func (o *ociClient) makeURL(uri string) string {
+	return fmt.Sprintf("%s://%s%s", o.scheme, o.host, uri)
+}
+
+// get performs a request against the registry, handling any bearer token challenge, decoding
+// the response and returning the location of the next page if any
+func (o *ociClient) get(ctx context.Context, location, scope string, out interface{}) (string, error) {
+	resp, err := o.do(ctx, location)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		if err := o.authorize(ctx, challenge, scope); err != nil {
+			return "", err
+		}
+		if resp, err = o.do(ctx, location); err != nil {
+			return "", err
+		}
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", fmt.Errorf("%q not found", location)
+	default:
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return "", err
+	}
+
+	return o.nextPage(resp.Header.Get("Link")), nil
+}
+
+// do performs the request against the registry
+func (o *ociClient) do(ctx context.Context, location string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("%s/%s", version.Name, version.Version))
+
+	switch {
+	case o.token != "":
+		req.Header.Set("Authorization", "Bearer "+o.token)
+	case o.username != "":
+		req.SetBasicAuth(o.username, o.password)
+	}
+
+	return o.hc.Do(req)
+}
+
+// authorize retrieves a bearer token from the token service advertised in the challenge
+func (o *ociClient) authorize(ctx context.Context, challenge, scope string) error {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return errors.New("registry returned unauthorized, check the credentials")
+	}
+	params := parseChallenge(challenge[len("bearer "):])
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return errors.New("registry returned an invalid authentication challenge")
+	}
+	values := realm.Query()
+	if params["service"] != "" {
+		values.Set("service", params["service"])
+	}
+	if params["scope"] != "" {
+		scope = params["scope"]
+	}
+	values.Set("scope", scope)
+	realm.RawQuery = values.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if o.username != "" {
+		req.SetBasicAuth(o.username, o.password)
+	}
+
+	resp, err := o.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to retrieve registry token, status code: %d", resp.StatusCode)
+	}
+
+	token := struct {
+		AccessToken string `json:"access_token"`
+		Token       string `json:"token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return err
+	}
+
+	switch {
+	case token.Token != "":
+		o.token = token.Token
+	case token.AccessToken != "":
+		o.token = token.AccessToken
+	default:
+		return errors.New("registry token service did not return a token")
+	}
+
+	return nil
+}
+
+// nextPage returns the location of the next page from the link header
+func (o *ociClient) nextPage(link string) string {
+	if link == "" || !strings.Contains(link, `rel="next"`) {
+		return ""
+	}
+	location := strings.Trim(strings.TrimSpace(strings.Split(link, ";")[0]), "<>")
+	if strings.HasPrefix(location, "/") {
+		return o.makeURL(location)
+	}
+
+	return location
+}
+
+// parseChallenge returns the key value pairs from the authentication challenge
+func parseChallenge(challenge string) map[string]string {
+	params := make(map[string]string)
+
+	for _, x := range challengeRegex.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(x[1])] = x[2]
+	}
+
+	return params
+}
+
+// containsTerms returns true if the given string is contained in the repository
+func containsTerms(query, repository string) bool {
+	terms := strings.ToLower(strings.NewReplacer("-", " ", "/", " ", "_", " ").Replace(repository))
+
+	return utils.ContainsList(strings.Split(strings.ToLower(query), " "), strings.Split(terms, " "))
+}
@@ -0,0 +1,199 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package oci
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/appvia/terranetes-controller/pkg/cmd/search"
+)
+
+// newTestServer returns a registry replaying the recorded responses, requiring a bearer
+// token issued by the token service for the given credentials
+func newTestServer(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+
+	fixture := func(w http.ResponseWriter, name string) {
+		content, err := os.ReadFile("testdata/" + name)
+		require.NoError(t, err)
+
+		_, _ = w.Write(content)
+	}
+	authorized := func(w http.ResponseWriter, r *http.Request, scope string) bool {
+		if r.Header.Get("Authorization") == "Bearer registry-token" {
+			return true
+		}
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry.test",scope="%s"`, server.URL, scope))
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return false
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+		if r.URL.Query().Get("service") != "registry.test" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		fixture(w, "token.json")
+	})
+	mux.HandleFunc("/v2/_catalog", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r, "registry:catalog:*") {
+			return
+		}
+		if r.URL.Query().Get("last") != "" {
+			fixture(w, "catalog-2.json")
+
+			return
+		}
+		w.Header().Set("Link", `</v2/_catalog?last=modules%2Faws%2Fterraform-aws-s3&n=100>; rel="next"`)
+		fixture(w, "catalog-1.json")
+	})
+	mux.HandleFunc("/v2/modules/aws/terraform-aws-s3/tags/list", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r, "repository:modules/aws/terraform-aws-s3:pull") {
+			return
+		}
+		fixture(w, "tags.json")
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// newTestClient returns a client for the test server
+func newTestClient(t *testing.T, server *httptest.Server, namespace, username, password string) search.Interface {
+	c, err := New(fmt.Sprintf("oci://%s/%s?insecure=true", strings.TrimPrefix(server.URL, "http://"), namespace), username, password)
+	require.NoError(t, err)
+
+	return c
+}
+
+func TestNew(t *testing.T) {
+	c, err := New("oci://ghcr.io/appvia", "", "")
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+	assert.Equal(t, "oci://ghcr.io/appvia", c.Source())
+	assert.Equal(t, "appvia", c.(*ociClient).namespace)
+	assert.Equal(t, "https", c.(*ociClient).scheme)
+}
+
+func TestNewInvalid(t *testing.T) {
+	for _, endpoint := range []string{"ghcr.io/appvia", "https://ghcr.io/appvia", "oci://"} {
+		c, err := New(endpoint, "", "")
+		assert.Error(t, err, "case: %s", endpoint)
+		assert.Nil(t, c)
+	}
+}
+
+func TestIsHandle(t *testing.T) {
+	assert.False(t, IsHandle(""))
+	assert.False(t, IsHandle("ghcr.io/appvia"))
+	assert.True(t, IsHandle("oci://ghcr.io/appvia"))
+}
+
+func TestFind(t *testing.T) {
+	server := newTestServer(t)
+	c := newTestClient(t, server, "modules", "user", "pass")
+
+	modules, err := c.Find(context.Background(), search.Query{})
+	assert.NoError(t, err)
+	require.Len(t, modules, 3)
+	assert.Equal(t, "terraform-aws-s3", modules[0].Name)
+	assert.Equal(t, "modules/aws", modules[0].Namespace)
+	assert.Equal(t, "modules/aws/terraform-aws-s3", modules[0].ID)
+	assert.Equal(t, "OCI", modules[0].RegistryType)
+	assert.True(t, modules[0].Private)
+	assert.Equal(t, "terraform-aws-rds", modules[1].Name)
+	assert.Equal(t, "terraform-azure-storage", modules[2].Name)
+}
+
+func TestFindWithQuery(t *testing.T) {
+	server := newTestServer(t)
+	c := newTestClient(t, server, "modules", "user", "pass")
+
+	modules, err := c.Find(context.Background(), search.Query{Query: "rds"})
+	assert.NoError(t, err)
+	require.Len(t, modules, 1)
+	assert.Equal(t, "terraform-aws-rds", modules[0].Name)
+}
+
+func TestFindWithNamespace(t *testing.T) {
+	server := newTestServer(t)
+	c := newTestClient(t, server, "modules", "user", "pass")
+
+	modules, err := c.Find(context.Background(), search.Query{Namespace: "modules/azure"})
+	assert.NoError(t, err)
+	require.Len(t, modules, 1)
+	assert.Equal(t, "terraform-azure-storage", modules[0].Name)
+}
+
+func TestFindBadCredentials(t *testing.T) {
+	server := newTestServer(t)
+	c := newTestClient(t, server, "modules", "user", "bad")
+
+	modules, err := c.Find(context.Background(), search.Query{})
+	assert.Error(t, err)
+	assert.Equal(t, "failed to retrieve registry token, status code: 401", err.Error())
+	assert.Nil(t, modules)
+}
+
+func TestVersions(t *testing.T) {
+	server := newTestServer(t)
+	c := newTestClient(t, server, "modules", "user", "pass")
+
+	versions, err := c.Versions(context.Background(), search.Module{ID: "modules/aws/terraform-aws-s3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v0.1.0", "v0.2.0", "v0.10.0"}, versions)
+}
+
+func TestResolveSource(t *testing.T) {
+	c, err := New("oci://ghcr.io/appvia", "", "")
+	require.NoError(t, err)
+
+	source, err := c.ResolveSource(context.Background(), search.Module{
+		Source:  "oci://ghcr.io/appvia/terraform-aws-s3",
+		Version: "v0.1.0",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "oci://ghcr.io/appvia/terraform-aws-s3?tag=v0.1.0", source)
+}
+
+func TestParseChallenge(t *testing.T) {
+	params := parseChallenge(`realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:a/b:pull,push"`)
+	assert.Equal(t, map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:a/b:pull,push",
+	}, params)
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+)
+
+// cliConfig is the subset of the terraform cli configuration we care about
+type cliConfig struct {
+	// Credentials is a collection of credentials blocks
+	Credentials []struct {
+		// Host is the hostname of the registry
+		Host string `hcl:"host,label"`
+		// Token is the token used to authenticate
+		Token string `hcl:"token"`
+	} `hcl:"credentials,block"`
+	// Remain is everything else in the configuration
+	Remain hcl.Body `hcl:",remain"`
+}
+
+// credentialsFile is the format of the terraform credentials file
+type credentialsFile struct {
+	// Credentials is a map of hostnames to credentials
+	Credentials map[string]struct {
+		// Token is the token used to authenticate
+		Token string `json:"token"`
+	} `json:"credentials"`
+}
+
+// LookupToken returns the token for the registry host, following the same order of precedence
+// as the terraform cli; the TF_TOKEN_<host> environment variable, the cli configuration file
+// (TF_CLI_CONFIG_FILE or ~/.terraformrc) and finally ~/.terraform.d/credentials.tfrc.json
+func LookupToken(host string) (string, error) {
+	if token := os.Getenv(tokenEnvName(host)); token != "" {
+		return token, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	filename := os.Getenv("TF_CLI_CONFIG_FILE")
+	if filename == "" {
+		filename = filepath.Join(home, ".terraformrc")
+	}
+	token, err := lookupCLIConfigToken(filename, host)
+	if err != nil || token != "" {
+		return token, err
+	}
+
+	return lookupCredentialsFileToken(filepath.Join(home, ".terraform.d", "credentials.tfrc.json"), host)
+}
+
+// tokenEnvName returns the name of the environment variable holding the token for the host,
+// i.e. app.terraform.io becomes TF_TOKEN_app_terraform_io
+func tokenEnvName(host string) string {
+	return "TF_TOKEN_" + strings.NewReplacer(".", "_", "-", "__").Replace(host)
+}
+
+// lookupCLIConfigToken returns the token from the credentials blocks in the cli configuration
+func lookupCLIConfigToken(filename, host string) (string, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	file, diags := hclparse.NewParser().ParseHCL(content, filename)
+	if diags.HasErrors() {
+		return "", fmt.Errorf("failed to parse terraform cli configuration: %w", diags)
+	}
+
+	config := &cliConfig{}
+	if diags := gohcl.DecodeBody(file.Body, nil, config); diags.HasErrors() {
+		return "", fmt.Errorf("failed to decode terraform cli configuration: %w", diags)
+	}
+
+	for _, x := range config.Credentials {
+		if x.Host == host {
+			return x.Token, nil
+		}
+	}
+
+	return "", nil
+}
+
+// lookupCredentialsFileToken returns the token from the credentials file written by terraform login
+func lookupCredentialsFileToken(filename, host string) (string, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	file := &credentialsFile{}
+	if err := json.Unmarshal(content, file); err != nil {
+		return "", fmt.Errorf("failed to decode terraform credentials file: %w", err)
+	}
+
+	return file.Credentials[host].Token, nil
+}
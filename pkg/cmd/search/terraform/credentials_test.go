@@ -0,0 +1,116 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupHome points the home directory at an empty temporary directory
+func setupHome(t *testing.T) string {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("TF_CLI_CONFIG_FILE", "")
+
+	return home
+}
+
+func TestTokenEnvName(t *testing.T) {
+	assert.Equal(t, "TF_TOKEN_app_terraform_io", tokenEnvName("app.terraform.io"))
+	assert.Equal(t, "TF_TOKEN_my__registry_example_com", tokenEnvName("my-registry.example.com"))
+}
+
+func TestLookupTokenNone(t *testing.T) {
+	setupHome(t)
+
+	token, err := LookupToken("app.terraform.io")
+	assert.NoError(t, err)
+	assert.Empty(t, token)
+}
+
+func TestLookupTokenEnvironment(t *testing.T) {
+	setupHome(t)
+	t.Setenv("TF_TOKEN_app_terraform_io", "env")
+
+	token, err := LookupToken("app.terraform.io")
+	assert.NoError(t, err)
+	assert.Equal(t, "env", token)
+}
+
+func TestLookupTokenCLIConfig(t *testing.T) {
+	home := setupHome(t)
+
+	config := `
+plugin_cache_dir = "$HOME/.terraform.d/plugin-cache"
+
+credentials "app.terraform.io" {
+  token = "cli"
+}
+
+credentials "other.example.com" {
+  token = "other"
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".terraformrc"), []byte(config), 0600))
+
+	token, err := LookupToken("app.terraform.io")
+	assert.NoError(t, err)
+	assert.Equal(t, "cli", token)
+}
+
+func TestLookupTokenCLIConfigOverride(t *testing.T) {
+	setupHome(t)
+
+	filename := filepath.Join(t.TempDir(), "terraform.rc")
+	require.NoError(t, os.WriteFile(filename, []byte(`credentials "app.terraform.io" { token = "override" }`), 0600))
+	t.Setenv("TF_CLI_CONFIG_FILE", filename)
+
+	token, err := LookupToken("app.terraform.io")
+	assert.NoError(t, err)
+	assert.Equal(t, "override", token)
+}
+
+func TestLookupTokenCLIConfigInvalid(t *testing.T) {
+	home := setupHome(t)
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".terraformrc"), []byte(`credentials {`), 0600))
+
+	token, err := LookupToken("app.terraform.io")
+	assert.Error(t, err)
+	assert.Empty(t, token)
+}
+
+func TestLookupTokenCredentialsFile(t *testing.T) {
+	home := setupHome(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".terraform.d"), 0700))
+
+	content := `{"credentials": {"app.terraform.io": {"token": "login"}}}`
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".terraform.d", "credentials.tfrc.json"), []byte(content), 0600))
+
+	token, err := LookupToken("app.terraform.io")
+	assert.NoError(t, err)
+	assert.Equal(t, "login", token)
+
+	token, err = LookupToken("missing.example.com")
+	assert.NoError(t, err)
+	assert.Empty(t, token)
+}
@@ -18,20 +18,22 @@
 package terraform
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 
 	"github.com/appvia/terranetes-controller/pkg/cmd/search"
 	"github.com/appvia/terranetes-controller/pkg/version"
 )
 
+// DiscoveryPath is the path of the service discovery document on a registry host
+const DiscoveryPath = "/.well-known/terraform.json"
+
 type registry struct {
 	// hc is the http client
 	hc *http.Client
@@ -39,11 +41,21 @@ type registry struct {
 	endpoint string
 	// baseURL is the registry baseURL
 	baseURL string
+	// host is the hostname of the registry
+	host string
+	// modulesURL is the discovered location of the modules api
+	modulesURL string
 	// namespace scopes the requests to namespace
 	namespace string
+	// token is an optional token used to authenticate to the registry
+	token string
+	// lock is used to guard the service discovery
+	lock sync.Mutex
 }
 
-// New creates and returns a terraform registry lookup provider
+// New creates and returns a terraform registry lookup provider. Credentials for private
+// registries are retrieved in the same manner as the terraform cli, i.e. TF_TOKEN_<host>
+// environment variables or the terraform cli configuration and credentials files
 func New(endpoint string) (search.Interface, error) {
 	var namespace string
 
@@ -51,8 +63,15 @@ func New(endpoint string) (search.Interface, error) {
 	if err != nil {
 		return nil, err
 	}
+	if u.Host == "" {
+		return nil, errors.New("invalid endpoint, must include the registry hostname")
+	}
 
-	baseURL := fmt.Sprintf("https://%s", u.Host)
+	scheme := "https"
+	if u.Scheme == "http" {
+		scheme = "http"
+	}
+	baseURL := fmt.Sprintf("%s://%s", scheme, u.Host)
 
 	if u.Path != "" {
 		items := strings.Split(strings.TrimSuffix(u.Path, "/"), "/")
@@ -64,11 +83,18 @@ func New(endpoint string) (search.Interface, error) {
 		namespace = items[2]
 	}
 
+	token, err := LookupToken(u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
 	return &registry{
 		baseURL:   baseURL,
 		endpoint:  endpoint,
 		hc:        &http.Client{},
+		host:      u.Hostname(),
 		namespace: namespace,
+		token:     token,
 	}, nil
 }
 
@@ -80,6 +106,9 @@ func IsHandle(source string) bool {
 
 	case strings.HasPrefix(source, "https://registry.terraform.io"):
 		return true
+
+	case strings.HasPrefix(source, "https://app.terraform.io"):
+		return true
 	}
 
 	return false
@@ -92,30 +121,24 @@ func (r *registry) Source() string {
 
 // Versions returns a lists of version for a specific module
 func (r *registry) Versions(ctx context.Context, module search.Module) ([]string, error) {
-	location := fmt.Sprintf("%s/v1/modules/%s/%s/%s/versions", r.baseURL, module.Namespace, module.Name, module.Provider)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	base, err := r.discover(ctx)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", fmt.Sprintf("%s/%s", version.Name, version.Version))
+	location := fmt.Sprintf("%s%s/%s/%s/versions", base, module.Namespace, module.Name, module.Provider)
 
-	resp, err := r.hc.Do(req)
+	resp, err := r.do(ctx, location)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
 	result := &versionsResult{}
-	if err := json.NewDecoder(bytes.NewReader(body)).Decode(result); err != nil {
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
 		return nil, err
 	}
 
@@ -132,34 +155,61 @@ func (r *registry) Versions(ctx context.Context, module search.Module) ([]string
 // ResolveSource is used to resolve the source of the module, this is only really required for
 // terraform registries
 func (r *registry) ResolveSource(ctx context.Context, module search.Module) (string, error) {
-	location := fmt.Sprintf("%s/v1/modules/%s/%s/%s/%s/download",
-		r.baseURL, module.Namespace, module.Name, module.Provider, module.Version)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	base, err := r.discover(ctx)
 	if err != nil {
 		return "", err
 	}
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "terranetes-controller/"+version.Version)
+	location := fmt.Sprintf("%s%s/%s/%s/%s/download", base, module.Namespace, module.Name, module.Provider, module.Version)
 
-	resp, err := r.hc.Do(req)
+	resp, err := r.do(ctx, location)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusNoContent {
+	var source string
+
+	// @step: the registry can return the location either in the header or the body
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		source = resp.Header.Get("X-Terraform-Get")
+		if source == "" {
+			return "", fmt.Errorf("unexpected response, no X-Terraform-Get header")
+		}
+
+	case http.StatusOK:
+		result := &downloadResult{}
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return "", err
+		}
+		if result.Location == "" {
+			return "", fmt.Errorf("unexpected response, no location in body")
+		}
+		source = result.Location
+
+	default:
 		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
 	}
 
-	source := resp.Header.Get("X-Terraform-Get")
-	if source == "" {
-		return "", fmt.Errorf("unexpected response, no X-Terraform-Get header")
+	// @step: relative locations are resolved against the download endpoint
+	if strings.HasPrefix(source, "/") || strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+		u, err := url.Parse(location)
+		if err != nil {
+			return "", err
+		}
+		ref, err := url.Parse(source)
+		if err != nil {
+			return "", err
+		}
+		source = u.ResolveReference(ref).String()
+	}
+
+	// we keep the git protocol for ssh sources, otherwise it can be removed
+	if !strings.HasPrefix(source, "git::ssh://") {
+		source = strings.Replace(source, "git::", "", -1)
 	}
 
-	return strings.Replace(source, "git::", "", -1), nil
+	return source, nil
 }
 
 // Find returns the terraform registry lookup provider
@@ -189,7 +239,7 @@ func (r *registry) Find(ctx context.Context, query search.Query) ([]search.Modul
 				Downloads:    results.Modules[i].Downloads,
 				Name:         results.Modules[i].Name,
 				Namespace:    results.Modules[i].Namespace,
-				Private:      false,
+				Private:      r.token != "",
 				Provider:     results.Modules[i].Provider,
 				Registry:     r.endpoint,
 				RegistryType: "TF",
@@ -210,17 +260,15 @@ func (r *registry) Find(ctx context.Context, query search.Query) ([]search.Modul
 
 // search performs a search on a terraform registry
 func (r *registry) search(ctx context.Context, query search.Query, offset int) (*searchResult, error) {
-	location := fmt.Sprintf("%s/v1/modules", r.baseURL)
-	if query.Query != "" {
-		location = fmt.Sprintf("%s/search", location)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	location, err := r.discover(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if query.Query != "" {
+		location = fmt.Sprintf("%ssearch", location)
+	}
 
-	q := req.URL.Query()
+	q := url.Values{}
 	q.Set("offset", fmt.Sprintf("%d", offset))
 	q.Set("limit", fmt.Sprintf("%d", 20))
 
@@ -233,14 +281,8 @@ func (r *registry) search(ctx context.Context, query search.Query, offset int) (
 	if query.Namespace != "" {
 		q.Set("namespace", query.Namespace)
 	}
-	req.URL.RawQuery = q.Encode()
-
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "terranetes-controller/"+version.Version)
 
-	resp, err := r.hc.Do(req)
+	resp, err := r.do(ctx, location+"?"+q.Encode())
 	if err != nil {
 		return nil, err
 	}
@@ -257,3 +299,68 @@ func (r *registry) search(ctx context.Context, query search.Query, offset int) (
 
 	return results, nil
 }
+
+// discover retrieves the location of the modules api from the registry service discovery
+// document, the result is cached for subsequent calls
+func (r *registry) discover(ctx context.Context) (string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.modulesURL != "" {
+		return r.modulesURL, nil
+	}
+
+	resp, err := r.do(ctx, r.baseURL+DiscoveryPath)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", fmt.Errorf("registry %q does not support service discovery", r.host)
+	default:
+		return "", fmt.Errorf("unexpected status code from service discovery: %d", resp.StatusCode)
+	}
+
+	services := &discoveryResult{}
+	if err := json.NewDecoder(resp.Body).Decode(services); err != nil {
+		return "", fmt.Errorf("failed to decode service discovery document: %w", err)
+	}
+	if services.ModulesV1 == "" {
+		return "", fmt.Errorf("registry %q does not support the module registry protocol", r.host)
+	}
+
+	base, err := url.Parse(r.baseURL + DiscoveryPath)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(services.ModulesV1)
+	if err != nil {
+		return "", err
+	}
+	r.modulesURL = base.ResolveReference(ref).String()
+	if !strings.HasSuffix(r.modulesURL, "/") {
+		r.modulesURL += "/"
+	}
+
+	return r.modulesURL, nil
+}
+
+// do performs a request against the registry, adding the credentials if required
+func (r *registry) do(ctx context.Context, location string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("%s/%s", version.Name, version.Version))
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	return r.hc.Do(req)
+}
@@ -18,9 +18,17 @@
 package terraform
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/appvia/terranetes-controller/pkg/cmd/search"
 )
 
 func TestNew(t *testing.T) {
@@ -65,3 +73,129 @@ func TestIsHandle(t *testing.T) {
 		assert.Equal(t, c.Expected, IsHandle(c.Source))
 	}
 }
+
+// newTestRegistry returns a private registry replaying the recorded responses, the
+// registry requires the token "secret"
+func newTestRegistry(t *testing.T) *httptest.Server {
+	fixture := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+			content, err := os.ReadFile("testdata/" + name)
+			require.NoError(t, err)
+
+			_, _ = w.Write(content)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(DiscoveryPath, fixture("discovery.json"))
+	mux.HandleFunc("/api/registry/v1/modules/search", fixture("search.json"))
+	mux.HandleFunc("/api/registry/v1/modules/appvia/s3/aws/versions", fixture("versions.json"))
+	mux.HandleFunc("/api/registry/v1/modules/appvia/s3/aws/1.1.0/download", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Terraform-Get", "git::ssh://git@github.com/appvia/terraform-aws-s3?ref=v1.1.0")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/api/registry/v1/modules/appvia/s3/aws/1.0.0/download", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"location": "/archives/appvia/s3/aws/1.0.0.tar.gz"}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// newTestRegistryClient returns a client for the private registry using the token from the
+// environment
+func newTestRegistryClient(t *testing.T, server *httptest.Server, token string) search.Interface {
+	setupHome(t)
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	t.Setenv(tokenEnvName(u.Hostname()), token)
+
+	c, err := New(server.URL)
+	require.NoError(t, err)
+
+	return c
+}
+
+func TestNewMissingHost(t *testing.T) {
+	c, err := New("registry.terraform.io")
+	assert.Error(t, err)
+	assert.Nil(t, c)
+}
+
+func TestPrivateRegistryFind(t *testing.T) {
+	server := newTestRegistry(t)
+	c := newTestRegistryClient(t, server, "secret")
+
+	modules, err := c.Find(context.Background(), search.Query{Query: "s3"})
+	assert.NoError(t, err)
+	require.Len(t, modules, 1)
+	assert.Equal(t, "s3", modules[0].Name)
+	assert.Equal(t, "appvia", modules[0].Namespace)
+	assert.Equal(t, "aws", modules[0].Provider)
+	assert.True(t, modules[0].Private)
+}
+
+func TestPrivateRegistryUnauthorized(t *testing.T) {
+	server := newTestRegistry(t)
+	c := newTestRegistryClient(t, server, "")
+
+	modules, err := c.Find(context.Background(), search.Query{Query: "s3"})
+	assert.Error(t, err)
+	assert.Equal(t, "unexpected status code from service discovery: 401", err.Error())
+	assert.Nil(t, modules)
+}
+
+func TestPrivateRegistryVersions(t *testing.T) {
+	server := newTestRegistry(t)
+	c := newTestRegistryClient(t, server, "secret")
+
+	versions, err := c.Versions(context.Background(), search.Module{Namespace: "appvia", Name: "s3", Provider: "aws"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1.1.0", "1.0.0"}, versions)
+}
+
+func TestPrivateRegistryResolveSourceHeader(t *testing.T) {
+	server := newTestRegistry(t)
+	c := newTestRegistryClient(t, server, "secret")
+
+	source, err := c.ResolveSource(context.Background(), search.Module{
+		Namespace: "appvia",
+		Name:      "s3",
+		Provider:  "aws",
+		Version:   "1.1.0",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "git::ssh://git@github.com/appvia/terraform-aws-s3?ref=v1.1.0", source)
+}
+
+func TestPrivateRegistryResolveSourceBody(t *testing.T) {
+	server := newTestRegistry(t)
+	c := newTestRegistryClient(t, server, "secret")
+
+	source, err := c.ResolveSource(context.Background(), search.Module{
+		Namespace: "appvia",
+		Name:      "s3",
+		Provider:  "aws",
+		Version:   "1.0.0",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/archives/appvia/s3/aws/1.0.0.tar.gz", source)
+}
+
+func TestDiscoveryNotSupported(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+	c := newTestRegistryClient(t, server, "")
+
+	versions, err := c.Versions(context.Background(), search.Module{Namespace: "appvia", Name: "s3", Provider: "aws"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support service discovery")
+	assert.Nil(t, versions)
+}
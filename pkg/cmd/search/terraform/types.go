@@ -59,3 +59,13 @@ type moduleVersions struct {
 	// Version is the version of the module
 	Version string `json:"version"`
 }
+
+type discoveryResult struct {
+	// ModulesV1 is the location of the modules api
+	ModulesV1 string `json:"modules.v1"`
+}
+
+type downloadResult struct {
+	// Location is the source of the module
+	Location string `json:"location"`
+}
@@ -30,17 +30,34 @@ import (
 var addSourceLong = `
 Sources are the URL locations for terraform modules. By default if
 no sources are defined we use the public terraform registry. We currently
-support aggregating modules from any terraform registry and Github.
+support aggregating modules from any terraform registry, Github, GitLab,
+Bitbucket and modules published as OCI artifacts.
 
 Add a terraform registry to the source
 $ tnctl config sources add https://registry.terraform.io
 
+Add a private registry, the token is read from TF_TOKEN_<host> environment
+variables or the terraform cli configuration and credentials files
+$ tnctl config sources add terraform://registry.example.com
+
 Add a Github organization or user to the source
 $ tnctl config sources add github.com/appvia/terranetes-controller
 
 Note, skipping the name github organization or user requires your GITHUB_TOKEN
 is exported as the CLI will use this to authenticate to the github and
 search any repositories you are a member, contributor or owner of.
+
+Add a GitLab group, optionally filtering projects by topic. For self-hosted
+instances use gitlab://HOST/GROUP. Export GITLAB_TOKEN for private projects.
+$ tnctl config sources add gitlab.com/appvia?topic=terraform
+
+Add a Bitbucket workspace. Export BITBUCKET_USERNAME and BITBUCKET_APP_PASSWORD
+for private repositories.
+$ tnctl config sources add bitbucket.org/appvia
+
+Add an OCI registry namespace. Export OCI_USERNAME and OCI_PASSWORD if the
+registry requires authentication.
+$ tnctl config sources add oci://ghcr.io/appvia
 `
 
 // AddSourceCommand are the options for the command
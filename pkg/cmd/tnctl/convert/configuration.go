@@ -399,6 +399,9 @@ func (o *ConfigurationCommand) resolveConfiguration(ctx context.Context) error {
 				if !found {
 					return fmt.Errorf("context: %q not found in cluster, unable to resolve", *x.Context)
 				}
+				if txt.Spec.HasSecret(x.Key) {
+					return fmt.Errorf("context: %q key: %q is sensitive and cannot be resolved", *x.Context, x.Key)
+				}
 				value, found, err := txt.Spec.GetVariable(x.Key)
 				if err != nil {
 					return err
@@ -44,6 +44,24 @@ Secret:         {{ .Object.Namespace }}/{{ .Object.Spec.WriteConnectionSecretToR
 {{- else }}
 Secret:         None
 {{- end }}
+{{- if .Object.Status.StateLock }}
+
+State Lock:
+==========
+ID:             {{ .Object.Status.StateLock.ID }}
+Holder:         {{ default "Unknown" .Object.Status.StateLock.Who }}
+Operation:      {{ default "Unknown" .Object.Status.StateLock.Operation }}
+Age:            {{ default "Unknown" .StateLockAge }}
+{{- end }}
+{{- if .ContextValues }}
+
+Context Values:
+==============
+{{ printf "%-24s %-24s %s" "Name" "Key" "Source" }}
+{{- range $value := .ContextValues }}
+{{ printf "%-24s %-24s %s" $value.Name $value.Key $value.Source }}
+{{- end }}
+{{- end }}
 
 {{- if .Policy }}
 
@@ -22,9 +22,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
@@ -160,6 +162,20 @@ func (o *Command) Run(ctx context.Context) error {
 		"Object":             configuration,
 	}
 
+	// @step: include the age of any state lock held against the configuration
+	if lock := configuration.Status.StateLock; lock != nil && lock.Created != nil {
+		data["StateLockAge"] = duration.HumanDuration(time.Since(lock.Created.Time))
+	}
+
+	// @step: resolve where any context values are sourced from
+	if configuration.Spec.ValueFrom.HasContextReferences() {
+		values, err := findContextValues(ctx, cc, configuration)
+		if err != nil {
+			return err
+		}
+		data["ContextValues"] = values
+	}
+
 	// @step: check if the configuration has a policy report
 	if report, found := findPolicyReport(configuration); found {
 		data["Policy"] = report
@@ -177,3 +193,49 @@ func (o *Command) Run(ctx context.Context) error {
 
 	return nil
 }
+
+// findContextValues is responsible for resolving where the context references of the configuration
+// are sourced from, a namespace context takes precedence over a cluster context
+func findContextValues(ctx context.Context, cc client.Client, configuration *terraformv1alpha1.Configuration) ([]map[string]string, error) {
+	namespaced := &terraformv1alpha1.NamespaceContextList{}
+	if err := cc.List(ctx, namespaced, client.InNamespace(configuration.Namespace)); err != nil {
+		return nil, err
+	}
+	contexts := &terraformv1alpha1.ContextList{}
+	if err := cc.List(ctx, contexts); err != nil {
+		return nil, err
+	}
+
+	var values []map[string]string
+
+	for _, x := range configuration.Spec.ValueFrom {
+		if x.Context == nil {
+			continue
+		}
+		source := "Missing"
+
+		local, found := namespaced.GetItem(*x.Context)
+		switch {
+		case found && local.Spec.HasVariable(x.Key):
+			source = fmt.Sprintf("NamespaceContext %s/%s", local.Namespace, local.Name)
+
+		default:
+			if txt, found := contexts.GetItem(*x.Context); found {
+				switch {
+				case txt.Spec.HasSecret(x.Key):
+					source = fmt.Sprintf("Context %s (sensitive)", txt.Name)
+				case txt.Spec.HasVariable(x.Key):
+					source = fmt.Sprintf("Context %s", txt.Name)
+				}
+			}
+		}
+
+		values = append(values, map[string]string{
+			"Name":   x.GetName(),
+			"Key":    x.Key,
+			"Source": source,
+		})
+	}
+
+	return values, nil
+}
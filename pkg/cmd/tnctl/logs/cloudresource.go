@@ -40,8 +40,14 @@ type CloudResourceLogsCommand struct {
 	Namespace string
 	// Stage is the stage to show logs for
 	Stage string
+	// ControllerNamespace is the namespace the controller is running in
+	ControllerNamespace string
 	// Follow indicates we should follow the logs
 	Follow bool
+	// Generation is an optional generation to retrieve the retained logs for
+	Generation int64
+	// Output is an optional format to render the logs i.e. json or folded
+	Output string
 	// WaitInterval is the interval to wait for the logs
 	WaitInterval time.Duration
 }
@@ -68,6 +74,9 @@ func NewCloudResourceLogsCommand(factory cmd.Factory) *cobra.Command {
 
 	flags := c.Flags()
 	flags.BoolVarP(&o.Follow, "follow", "f", false, "Indicates we should follow the logs")
+	flags.Int64Var(&o.Generation, "generation", 0, "Retrieve the retained logs for a previous generation of the resource")
+	flags.StringVar(&o.ControllerNamespace, "controller-namespace", "terraform-system", "The namespace the controller is running in")
+	flags.StringVarP(&o.Output, "output", "o", "", "Optional structured output retrieved from the controller i.e. json or folded (grouped by step)")
 	flags.DurationVar(&o.WaitInterval, "timeout", 3*time.Second, "The interval to wait for the logs")
 	flags.StringVarP(&o.Namespace, "namespace", "n", "default", "The namespace of the resource")
 	flags.StringVar(&o.Stage, "stage", "", "Select the stage to show logs for, else defaults to the current resource state")
@@ -115,11 +124,14 @@ func (o *CloudResourceLogsCommand) Run(ctx context.Context) error {
 	}
 
 	return (&Command{
-		Factory:      o.Factory,
-		Follow:       o.Follow,
-		Name:         cloudresource.Status.ConfigurationName,
-		Namespace:    o.Namespace,
-		Stage:        o.Stage,
-		WaitInterval: o.WaitInterval,
+		ControllerNamespace: o.ControllerNamespace,
+		Factory:             o.Factory,
+		Follow:              o.Follow,
+		Generation:          o.Generation,
+		Name:                cloudresource.Status.ConfigurationName,
+		Namespace:           o.Namespace,
+		Output:              o.Output,
+		Stage:               o.Stage,
+		WaitInterval:        o.WaitInterval,
 	}).Run(ctx)
 }
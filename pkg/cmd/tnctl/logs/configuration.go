@@ -47,6 +47,9 @@ func NewConfigurationLogsCommand(factory cmd.Factory) *cobra.Command {
 
 	flags := c.Flags()
 	flags.BoolVarP(&o.Follow, "follow", "f", false, "Indicates we should follow the logs")
+	flags.Int64Var(&o.Generation, "generation", 0, "Retrieve the retained logs for a previous generation of the resource")
+	flags.StringVar(&o.ControllerNamespace, "controller-namespace", "terraform-system", "The namespace the controller is running in")
+	flags.StringVarP(&o.Output, "output", "o", "", "Optional structured output retrieved from the controller i.e. json or folded (grouped by step)")
 	flags.DurationVar(&o.WaitInterval, "timeout", 3*time.Second, "Indicates how long we should wait for logs to be available")
 	flags.StringVar(&o.Stage, "stage", "", "Select the stage to show logs for, else defaults to the current state")
 	flags.StringVarP(&o.Namespace, "namespace", "n", "default", "The namespace of the resource")
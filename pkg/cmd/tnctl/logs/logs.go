@@ -27,6 +27,7 @@ import (
 
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	corev1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/core/v1alpha1"
@@ -44,6 +45,18 @@ $ tnctl logs configuration NAME --follow
 
 Viewing the logs for a cloudresource
 $ tnctl logs cloudresource NAME --follow
+
+Viewing the logs grouped by step, folding the steps which succeeded; the
+structured logs are retrieved from the controller
+$ tnctl logs configuration NAME --output folded
+
+Viewing the logs as newline-delimited json records
+$ tnctl logs configuration NAME --output json
+
+Viewing the retained logs for a previous generation of a configuration,
+these are served by the controller and remain available after the pods
+have been removed
+$ tnctl logs configuration NAME --generation 2 --stage apply
 `
 
 // Command represents the options
@@ -53,8 +66,14 @@ type Command struct {
 	Name string
 	// Namespace is the namespace of the resource
 	Namespace string
+	// ControllerNamespace is the namespace the controller is running in
+	ControllerNamespace string
 	// Follow indicates we should follow the logs
 	Follow bool
+	// Generation is an optional generation to retrieve the retained logs for
+	Generation int64
+	// Output is an optional format to render the logs i.e. json or folded
+	Output string
 	// Stage override the stage to look for
 	Stage string
 	// WaitInterval is the interval to wait for the logs
@@ -97,6 +116,15 @@ func (o *Command) Run(ctx context.Context) error {
 		terraformv1alpha1.StageTerraformPlan,
 	}):
 		return errors.New("invalid stage (must be one of: plan, apply or destroy)")
+
+	case o.Output != "" && !utils.Contains(o.Output, []string{OutputJSON, OutputFolded}):
+		return errors.New("invalid output (must be one of: json or folded)")
+
+	case o.Generation < 0:
+		return errors.New("generation must be a positive number")
+
+	case o.Generation > 0 && o.Follow:
+		return errors.New("cannot follow the logs of a previous generation")
 	}
 
 	// @step: retrieve the configuration
@@ -115,6 +143,10 @@ func (o *Command) Run(ctx context.Context) error {
 		return fmt.Errorf("resource %q not found", o.Name)
 	}
 
+	if o.Generation > 0 {
+		return o.showHistory(ctx, configuration)
+	}
+
 	if o.Stage != "" {
 		return o.showLogs(ctx, o.Stage, configuration)
 	}
@@ -142,8 +174,87 @@ func (o *Command) Run(ctx context.Context) error {
 	return errors.New("neither plan, apply or destroy have been run for this resource")
 }
 
+// showHistory retrieves the retained logs for a generation of the configuration via the controller
+func (o *Command) showHistory(ctx context.Context, configuration *terraformv1alpha1.Configuration) error {
+	kc, err := o.GetKubeClient()
+	if err != nil {
+		return err
+	}
+
+	params := map[string]string{
+		"generation": fmt.Sprintf("%d", o.Generation),
+		"uid":        string(configuration.GetUID()),
+	}
+	if o.Stage != "" {
+		params["stage"] = o.Stage
+	}
+	path := fmt.Sprintf("/v1/builds/%s/%s/history", configuration.Namespace, configuration.Name)
+
+	stream, err := kc.CoreV1().Services(o.ControllerNamespace).
+		ProxyGet("http", "controller", "builds", path, params).
+		Stream(ctx)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return fmt.Errorf("no logs retained for generation %d of resource %q", o.Generation, configuration.Name)
+		}
+
+		return fmt.Errorf("failed to retrieve the logs from the controller: %w", err)
+	}
+	defer stream.Close()
+
+	if o.Output == "" {
+		_, err = io.Copy(o.Stdout(), stream)
+
+		return err
+	}
+
+	r := newRenderer(o.Stdout(), o.Output)
+	if err := r.render(o.Stage, "", false, stream); err != nil {
+		return err
+	}
+
+	return r.close()
+}
+
+// showRecords retrieves the structured logs for the stage from the controller and renders them
+func (o *Command) showRecords(ctx context.Context, stage string, configuration *terraformv1alpha1.Configuration) error {
+	kc, err := o.GetKubeClient()
+	if err != nil {
+		return err
+	}
+
+	params := map[string]string{
+		"format":     "json",
+		"generation": fmt.Sprintf("%d", configuration.GetGeneration()),
+		"name":       configuration.Name,
+		"namespace":  configuration.Namespace,
+		"stage":      stage,
+		"uid":        string(configuration.GetUID()),
+	}
+	path := fmt.Sprintf("/v1/builds/%s/%s/logs", configuration.Namespace, configuration.Name)
+
+	stream, err := kc.CoreV1().Services(o.ControllerNamespace).
+		ProxyGet("http", "controller", "builds", path, params).
+		Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the logs from the controller: %w", err)
+	}
+	defer stream.Close()
+
+	r := newRenderer(o.Stdout(), o.Output)
+	if err := r.decode(stream); err != nil {
+		return err
+	}
+
+	return r.close()
+}
+
 // showLogs is a helper function to show the logs for all the containers under a build
 func (o *Command) showLogs(ctx context.Context, stage string, configuration *terraformv1alpha1.Configuration) error {
+	if o.Output != "" {
+		return o.showRecords(ctx, stage, configuration)
+	}
+
 	cc, err := o.GetKubeClient()
 	if err != nil {
 		return err
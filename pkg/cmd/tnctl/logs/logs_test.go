@@ -32,6 +32,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -43,6 +45,20 @@ import (
 	"github.com/appvia/terranetes-controller/test/fixtures"
 )
 
+// fakeProxy is a fake response from the service proxy
+type fakeProxy struct {
+	body string
+	err  error
+}
+
+func (f *fakeProxy) DoRaw(context.Context) ([]byte, error) {
+	return []byte(f.body), f.err
+}
+
+func (f *fakeProxy) Stream(context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(f.body)), f.err
+}
+
 func TestLogsCommand(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Running Test Suite")
@@ -57,7 +73,7 @@ var _ = Describe("Logs Command", func() {
 	var configuration *terraformv1alpha1.Configuration
 	var cloudresource *terraformv1alpha1.CloudResource
 	var command *cobra.Command
-	var stderr *bytes.Buffer
+	var stdout, stderr *bytes.Buffer
 	var err error
 
 	BeforeEach(func() {
@@ -66,7 +82,7 @@ var _ = Describe("Logs Command", func() {
 			WithStatusSubresource(&terraformv1alpha1.Configuration{}).
 			Build()
 		kc = k8sfake.NewSimpleClientset()
-		streams, _, _, stderr = genericclioptions.NewTestIOStreams()
+		streams, _, stdout, stderr = genericclioptions.NewTestIOStreams()
 		configuration = fixtures.NewValidBucketConfiguration("default", "bucket")
 		cloudresource = fixtures.NewCloudResource("default", "bucket")
 		cloudresource.Status.ConfigurationName = configuration.Name
@@ -132,6 +148,152 @@ var _ = Describe("Logs Command", func() {
 			})
 		})
 
+		Context("retrieving the structured logs", func() {
+			var action k8stesting.ProxyGetAction
+
+			BeforeEach(func() {
+				records := strings.Join([]string{
+					`{"type":"status","stage":"plan","level":"info","message":"waiting for the job to be scheduled"}`,
+					`{"type":"container","stage":"plan","container":"setup","level":"info","message":"setup"}`,
+					`{"type":"step","stage":"plan","container":"setup","step":"SETTING UP THE ENVIRONMENT","level":"info","message":"SETTING UP THE ENVIRONMENT"}`,
+					`{"type":"log","stage":"plan","container":"setup","step":"SETTING UP THE ENVIRONMENT","level":"info","message":"copied files"}`,
+					`{"type":"log","stage":"plan","container":"setup","step":"SETTING UP THE ENVIRONMENT","level":"info","message":"retrieved source"}`,
+					`{"type":"container","stage":"plan","container":"terraform","level":"info","message":"terraform"}`,
+					`{"type":"step","stage":"plan","container":"terraform","step":"EXECUTING TERRAFORM","level":"info","message":"EXECUTING TERRAFORM"}`,
+					`{"type":"log","stage":"plan","container":"terraform","step":"EXECUTING TERRAFORM","level":"info","message":"Plan: 1 to add"}`,
+					`{"type":"status","stage":"plan","level":"info","message":"completed"}`,
+				}, "\n")
+
+				kc.PrependProxyReactor("services", func(a k8stesting.Action) (bool, rest.ResponseWrapper, error) {
+					action = a.(k8stesting.ProxyGetAction)
+
+					return true, &fakeProxy{body: records}, nil
+				})
+			})
+
+			Context("and the output is invalid", func() {
+				BeforeEach(func() {
+					command.SetArgs([]string{"--namespace", configuration.Namespace, "configuration", configuration.Name, "--stage", "plan", "--output", "xml"})
+
+					err = command.ExecuteContext(context.Background())
+				})
+
+				It("should return an error", func() {
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("invalid output (must be one of: json or folded)"))
+				})
+			})
+
+			Context("and the output is folded", func() {
+				BeforeEach(func() {
+					command.SetArgs([]string{"--namespace", configuration.Namespace, "configuration", configuration.Name, "--stage", "plan", "--output", "folded"})
+
+					err = command.ExecuteContext(context.Background())
+				})
+
+				It("should not error", func() {
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("should have requested the json logs from the controller", func() {
+					Expect(action.GetPath()).To(Equal("/v1/builds/default/bucket/logs"))
+					Expect(action.GetParams()).To(HaveKeyWithValue("format", "json"))
+					Expect(action.GetParams()).To(HaveKeyWithValue("stage", "plan"))
+				})
+
+				It("should fold the completed steps", func() {
+					Expect(stdout.String()).To(Equal(strings.Join([]string{
+						"▸ setup: SETTING UP THE ENVIRONMENT (2 lines)",
+						"▾ terraform: EXECUTING TERRAFORM",
+						"  Plan: 1 to add",
+						"",
+					}, "\n")))
+				})
+			})
+
+			Context("and the output is json", func() {
+				BeforeEach(func() {
+					command.SetArgs([]string{"--namespace", configuration.Namespace, "configuration", configuration.Name, "--stage", "plan", "--output", "json"})
+
+					err = command.ExecuteContext(context.Background())
+				})
+
+				It("should not error", func() {
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("should print the records", func() {
+					Expect(strings.Split(strings.TrimSpace(stdout.String()), "\n")).To(HaveLen(9))
+				})
+			})
+		})
+
+		Context("retrieving the logs of a previous generation", func() {
+			var action k8stesting.ProxyGetAction
+
+			BeforeEach(func() {
+				kc.PrependProxyReactor("services", func(a k8stesting.Action) (bool, rest.ResponseWrapper, error) {
+					action = a.(k8stesting.ProxyGetAction)
+
+					return true, &fakeProxy{body: "retained logs\n"}, nil
+				})
+			})
+
+			Context("and the generation is invalid", func() {
+				BeforeEach(func() {
+					command.SetArgs([]string{"--namespace", configuration.Namespace, "configuration", configuration.Name, "--generation", "-1"})
+
+					err = command.ExecuteContext(context.Background())
+				})
+
+				It("should return an error", func() {
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("generation must be a positive number"))
+				})
+			})
+
+			Context("and we are asked to follow", func() {
+				BeforeEach(func() {
+					command.SetArgs([]string{"--namespace", configuration.Namespace, "configuration", configuration.Name, "--generation", "2", "--follow"})
+
+					err = command.ExecuteContext(context.Background())
+				})
+
+				It("should return an error", func() {
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("cannot follow the logs of a previous generation"))
+				})
+			})
+
+			Context("and the logs are retained", func() {
+				BeforeEach(func() {
+					command.SetArgs([]string{"--namespace", configuration.Namespace, "configuration", configuration.Name, "--generation", "2", "--stage", "apply"})
+
+					err = command.ExecuteContext(context.Background())
+				})
+
+				It("should not error", func() {
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("should have requested the logs from the controller", func() {
+					Expect(action.GetNamespace()).To(Equal("terraform-system"))
+					Expect(action.GetName()).To(Equal("controller"))
+					Expect(action.GetPort()).To(Equal("builds"))
+					Expect(action.GetPath()).To(Equal("/v1/builds/default/bucket/history"))
+					Expect(action.GetParams()).To(Equal(map[string]string{
+						"generation": "2",
+						"stage":      "apply",
+						"uid":        string(configuration.GetUID()),
+					}))
+				})
+
+				It("should print the logs", func() {
+					Expect(stdout.String()).To(Equal("retained logs\n"))
+				})
+			})
+		})
+
 		for _, stage := range []corev1alpha1.ConditionType{
 			terraformv1alpha1.ConditionTerraformPlan,
 			terraformv1alpha1.ConditionTerraformApply,
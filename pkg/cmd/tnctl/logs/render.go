@@ -0,0 +1,187 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/appvia/terranetes-controller/pkg/utils/buildlog"
+)
+
+const (
+	// OutputJSON renders the logs as newline-delimited json records
+	OutputJSON = "json"
+	// OutputFolded renders the logs grouped by step, folding the steps which succeeded
+	OutputFolded = "folded"
+)
+
+// renderer writes the build records in the requested format
+type renderer struct {
+	// out is the writer to render to
+	out io.Writer
+	// encoder is used for the json output
+	encoder *json.Encoder
+	// container is the current container
+	container string
+	// step is the current step within the container
+	step string
+	// lines are the lines of the current step
+	lines []string
+	// failed indicates the current step has errors
+	failed bool
+}
+
+// newRenderer returns a renderer for the output format
+func newRenderer(out io.Writer, output string) *renderer {
+	r := &renderer{out: out}
+	if output == OutputJSON {
+		r.encoder = json.NewEncoder(out)
+	}
+
+	return r
+}
+
+// render parses the stream from a container and writes the records
+func (r *renderer) render(stage, container string, timestamps bool, stream io.Reader) error {
+	parser := buildlog.NewParser(stage, container, timestamps)
+	if container != "" {
+		if err := r.write(parser.Container()); err != nil {
+			return err
+		}
+	}
+
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		if record, ok := parser.Parse(scanner.Text()); ok {
+			if err := r.write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// decode reads the newline-delimited records from the controller and writes them
+func (r *renderer) decode(stream io.Reader) error {
+	scanner := bufio.NewScanner(stream)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		record := buildlog.Record{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("failed to decode the log record: %w", err)
+		}
+		if err := r.write(record); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// write renders the record; in folded mode the lines are buffered until the step is complete
+func (r *renderer) write(record buildlog.Record) error {
+	if r.encoder != nil {
+		return r.encoder.Encode(record)
+	}
+
+	switch record.Type {
+	case buildlog.RecordContainer:
+		if err := r.fold(false); err != nil {
+			return err
+		}
+		r.container, r.step = record.Container, ""
+
+	case buildlog.RecordStep:
+		if err := r.fold(false); err != nil {
+			return err
+		}
+		r.step = record.Step
+
+	case buildlog.RecordStatus:
+		if record.Level == buildlog.LevelError {
+			_, err := fmt.Fprintf(r.out, "[error] %s\n", record.Message)
+
+			return err
+		}
+
+	default:
+		if strings.TrimSpace(record.Message) == "" {
+			return nil
+		}
+		r.lines = append(r.lines, record.Message)
+		if record.Level == buildlog.LevelError {
+			r.failed = true
+		}
+	}
+
+	return nil
+}
+
+// close flushes the final step, which is always expanded
+func (r *renderer) close() error {
+	if r.encoder != nil {
+		return nil
+	}
+
+	return r.fold(true)
+}
+
+// fold writes the current step, expanding it when it has errors or is the last step
+func (r *renderer) fold(expand bool) error {
+	defer func() {
+		r.lines, r.failed = nil, false
+	}()
+
+	if len(r.lines) == 0 {
+		return nil
+	}
+
+	var title []string
+	for _, x := range []string{r.container, r.step} {
+		if x != "" {
+			title = append(title, x)
+		}
+	}
+	if len(title) == 0 {
+		title = append(title, "output")
+	}
+
+	if !expand && !r.failed {
+		_, err := fmt.Fprintf(r.out, "▸ %s (%d lines)\n", strings.Join(title, ": "), len(r.lines))
+
+		return err
+	}
+
+	if _, err := fmt.Fprintf(r.out, "▾ %s\n", strings.Join(title, ": ")); err != nil {
+		return err
+	}
+	for _, line := range r.lines {
+		if _, err := fmt.Fprintf(r.out, "  %s\n", line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mirror
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/appvia/terranetes-controller/pkg/cmd"
+)
+
+var longDesc = `
+The controller can host a provider network mirror, permitting clusters
+without internet access to install the terraform providers. These commands
+are used to populate the mirror with the provider archives.
+`
+
+// NewCommand returns a new instance of the command
+func NewCommand(factory cmd.Factory) *cobra.Command {
+	c := &cobra.Command{
+		Use:   "mirror [COMMAND]",
+		Long:  longDesc,
+		Short: "Used to manage the provider mirror hosted by the controller",
+	}
+
+	c.AddCommand(
+		NewPushCommand(factory),
+	)
+
+	return c
+}
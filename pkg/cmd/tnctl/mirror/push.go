@@ -0,0 +1,319 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mirror
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/appvia/terranetes-controller/pkg/cmd"
+	"github.com/appvia/terranetes-controller/pkg/utils/mirror"
+)
+
+// ChecksumsFunc is used to retrieve the checksums published for a version and platform of a provider
+type ChecksumsFunc func(ctx context.Context, provider mirror.Provider, version, platform string) (mirror.Checksums, error)
+
+// UploadFunc is used to upload an archive, along with its published checksums, to the path on the controller
+type UploadFunc func(ctx context.Context, path string, checksums mirror.Checksums, body io.Reader) error
+
+// PushCommand are the options for the command
+type PushCommand struct {
+	cmd.Factory
+	// CASecret is the name of the secret in the controller namespace holding the certificate
+	// authority (ca.pem) used to trust the mirror endpoint
+	CASecret string
+	// Checksums is used to retrieve the published checksums, defaulting to the provider registry
+	Checksums ChecksumsFunc
+	// ControllerNamespace is the namespace the controller is running in
+	ControllerNamespace string
+	// Endpoint is the location of the provider mirror hosted by the controller
+	Endpoint string
+	// Paths is a collection of provider archives or mirror directories to push
+	Paths []string
+	// Source is the provider address the archives belong to
+	Source string
+	// Upload is used to upload the archives, defaulting to the controller endpoint
+	Upload UploadFunc
+	// client is the http client used to upload to the controller
+	client *http.Client
+}
+
+// archive is a provider archive to be pushed
+type archive struct {
+	// filename is the location of the archive
+	filename string
+	// platform is the os and architecture of the archive
+	platform string
+	// provider is the provider the archive belongs to
+	provider mirror.Provider
+	// version is the version of the provider
+	version string
+}
+
+var longPushHelp = `
+Pushes provider archives into the provider mirror hosted by the controller.
+The archives can either be pushed individually, in which case the provider
+address must be provided, or a directory created by 'terraform providers
+mirror' can be pushed in its entirety.
+
+Note, OpenTofu and Terraform use different registry hostnames by default
+(registry.opentofu.org and registry.terraform.io), the archives must be
+pushed under the hostname the jobs request them from.
+
+The checksums and signature published by the registry are retrieved and
+pushed alongside each archive, the controller refuses any archive which is
+not signed by one of its trusted provider signing keys. The archives are
+pushed directly to the mirror endpoint of the controller, authenticating
+with the token from your kubeconfig, which must be permitted to create
+the providers/mirror subresource.
+
+# Expose the mirror endpoint of the controller
+$ kubectl -n terraform-system port-forward svc/controller 10443
+
+# Push all the providers required by a module, on a machine with internet access
+$ tofu providers mirror -platform=linux_amd64 ./providers
+$ tnctl mirror push ./providers
+
+# Push an individual provider archive
+$ tnctl mirror push --source registry.opentofu.org/hashicorp/aws terraform-provider-aws_5.31.0_linux_amd64.zip
+`
+
+// NewPushCommand creates and returns the command
+func NewPushCommand(factory cmd.Factory) *cobra.Command {
+	o := &PushCommand{Factory: factory}
+
+	c := &cobra.Command{
+		Use:   "push PATH... [OPTIONS]",
+		Long:  longPushHelp,
+		Short: "Pushes provider archives into the provider mirror",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Paths = args
+
+			return o.Run(cmd.Context())
+		},
+	}
+
+	flags := c.Flags()
+	flags.StringVar(&o.CASecret, "ca-secret", "ca", "Name of the secret in the controller namespace containing the certificate authority (ca.pem) of the mirror endpoint")
+	flags.StringVar(&o.ControllerNamespace, "controller-namespace", "terraform-system", "The namespace the controller is running in")
+	flags.StringVar(&o.Endpoint, "endpoint", "https://localhost:10443", "The location of the provider mirror hosted by the controller")
+	flags.StringVar(&o.Source, "source", "", "The provider address of the archives i.e. registry.opentofu.org/hashicorp/aws")
+
+	return c
+}
+
+// Run implements the command
+func (o *PushCommand) Run(ctx context.Context) error {
+	if len(o.Paths) == 0 {
+		return errors.New("at least one provider archive or mirror directory is required")
+	}
+
+	// @step: find and validate all the archives before uploading any of them
+	var archives []archive
+	for _, path := range o.Paths {
+		found, err := o.findArchives(path)
+		if err != nil {
+			return err
+		}
+		archives = append(archives, found...)
+	}
+	if len(archives) == 0 {
+		return errors.New("no provider archives found")
+	}
+
+	checksums := o.Checksums
+	if checksums == nil {
+		checksums = (&mirror.Registry{}).Checksums
+	}
+	upload := o.Upload
+	if upload == nil {
+		upload = o.uploadToController
+	}
+
+	for _, x := range archives {
+		if err := o.push(ctx, checksums, upload, x); err != nil {
+			return err
+		}
+		o.Println("%s Pushed %s %s (%s)", cmd.IconGood, x.provider, x.version, x.platform)
+	}
+
+	return nil
+}
+
+// push uploads the archive to the mirror, along with the checksums published by the registry
+func (o *PushCommand) push(ctx context.Context, checksums ChecksumsFunc, upload UploadFunc, x archive) error {
+	published, err := checksums(ctx, x.provider, x.version, x.platform)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the published checksums for %s: %w", x.filename, err)
+	}
+
+	file, err := os.Open(x.filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	path := fmt.Sprintf("/v1/providers/%s/%s/%s/%s",
+		x.provider.Hostname, x.provider.Namespace, x.provider.Type, filepath.Base(x.filename))
+
+	if err := upload(ctx, path, published, file); err != nil {
+		return fmt.Errorf("failed to push %s: %w", x.filename, err)
+	}
+
+	return nil
+}
+
+// findArchives returns the archives for the path, which is either an archive or a directory
+// using the layout HOSTNAME/NAMESPACE/TYPE/terraform-provider-TYPE_VERSION_OS_ARCH.zip
+func (o *PushCommand) findArchives(path string) ([]archive, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		if o.Source == "" {
+			return nil, fmt.Errorf("the provider address (--source) is required to push the archive %s", path)
+		}
+		provider, err := mirror.ParseProvider(o.Source)
+		if err != nil {
+			return nil, err
+		}
+		version, platform, err := mirror.ParseArchiveName(provider, filepath.Base(path))
+		if err != nil {
+			return nil, err
+		}
+
+		return []archive{{filename: path, platform: platform, provider: provider, version: version}}, nil
+	}
+
+	var list []archive
+	err = filepath.WalkDir(path, func(name string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(name) != ".zip" {
+			return nil
+		}
+
+		relative, err := filepath.Rel(path, name)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(relative), "/")
+		if len(parts) != 4 {
+			return fmt.Errorf("archive %s is not within a HOSTNAME/NAMESPACE/TYPE directory", name)
+		}
+
+		provider := mirror.Provider{Hostname: parts[0], Namespace: parts[1], Type: parts[2]}
+		if err := provider.Validate(); err != nil {
+			return fmt.Errorf("archive %s: %w", name, err)
+		}
+		version, platform, err := mirror.ParseArchiveName(provider, parts[3])
+		if err != nil {
+			return err
+		}
+		list = append(list, archive{filename: name, platform: platform, provider: provider, version: version})
+
+		return nil
+	})
+
+	return list, err
+}
+
+// uploadToController uploads the archive directly to the mirror endpoint of the controller
+func (o *PushCommand) uploadToController(ctx context.Context, path string, checksums mirror.Checksums, body io.Reader) error {
+	if o.client == nil {
+		client, err := o.newControllerClient(ctx)
+		if err != nil {
+			return err
+		}
+		o.client = client
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, strings.TrimSuffix(o.Endpoint, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/zip")
+	req.Header.Set(mirror.ChecksumsHeader, base64.StdEncoding.EncodeToString(checksums.Document))
+	req.Header.Set(mirror.ChecksumsSignatureHeader, base64.StdEncoding.EncodeToString(checksums.Signature))
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+		return fmt.Errorf("controller responded with %s %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	return nil
+}
+
+// newControllerClient returns a http client trusting the certificate authority of the controller,
+// which authenticates using the token credentials from the kubeconfig
+func (o *PushCommand) newControllerClient(ctx context.Context) (*http.Client, error) {
+	cfg, err := o.GetRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.BearerToken == "" && cfg.BearerTokenFile == "" && cfg.ExecProvider == nil && cfg.AuthProvider == nil {
+		return nil, errors.New("the kubeconfig has no token credentials, which are required to authenticate to the controller")
+	}
+
+	kc, err := o.GetKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := kc.CoreV1().Secrets(o.ControllerNamespace).Get(ctx, o.CASecret, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the certificate authority of the controller: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(secret.Data["ca.pem"]) {
+		return nil, fmt.Errorf("secret %s/%s does not contain a valid certificate authority (ca.pem)", o.ControllerNamespace, o.CASecret)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: pool}
+
+	wrapped, err := rest.HTTPWrappersForConfig(cfg, transport)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{Transport: wrapped}, nil
+}
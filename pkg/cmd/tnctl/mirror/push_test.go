@@ -0,0 +1,303 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mirror
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/pem"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/appvia/terranetes-controller/pkg/apiserver"
+	"github.com/appvia/terranetes-controller/pkg/cmd"
+	"github.com/appvia/terranetes-controller/pkg/utils/mirror"
+	"github.com/appvia/terranetes-controller/test/fixtures"
+)
+
+var _ = Describe("Pushing provider archives", func() {
+	logrus.SetOutput(io.Discard)
+
+	var factory cmd.Factory
+	var stdout *bytes.Buffer
+	var command *PushCommand
+	var uploaded []string
+	var directory string
+	var err error
+
+	BeforeEach(func() {
+		var streams genericclioptions.IOStreams
+		streams, _, stdout, _ = genericclioptions.NewTestIOStreams()
+		factory = &fixtures.Factory{Streams: streams}
+
+		directory, err = os.MkdirTemp("", "mirror")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() { os.RemoveAll(directory) })
+
+		uploaded = nil
+		command = &PushCommand{
+			Factory: factory,
+			Checksums: func(_ context.Context, _ mirror.Provider, _, _ string) (mirror.Checksums, error) {
+				return mirror.Checksums{Document: []byte("checksums"), Signature: []byte("signature")}, nil
+			},
+			Upload: func(_ context.Context, path string, checksums mirror.Checksums, _ io.Reader) error {
+				Expect(string(checksums.Document)).To(Equal("checksums"))
+				uploaded = append(uploaded, path)
+
+				return nil
+			},
+		}
+	})
+
+	// createArchive creates an empty provider archive under the directory
+	createArchive := func(path string) string {
+		filename := filepath.Join(directory, path)
+		Expect(os.MkdirAll(filepath.Dir(filename), 0750)).To(Succeed())
+		Expect(os.WriteFile(filename, []byte("archive"), 0600)).To(Succeed())
+
+		return filename
+	}
+
+	When("pushing an archive without a provider address", func() {
+		BeforeEach(func() {
+			command.Paths = []string{createArchive("terraform-provider-aws_5.31.0_linux_amd64.zip")}
+			err = command.Run(context.Background())
+		})
+
+		It("should error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("the provider address (--source) is required"))
+		})
+
+		It("should not upload anything", func() {
+			Expect(uploaded).To(BeEmpty())
+		})
+	})
+
+	When("pushing an archive with an invalid name", func() {
+		BeforeEach(func() {
+			command.Source = "registry.opentofu.org/hashicorp/aws"
+			command.Paths = []string{createArchive("terraform-provider-google_5.31.0_linux_amd64.zip")}
+			err = command.Run(context.Background())
+		})
+
+		It("should error", func() {
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should not upload anything", func() {
+			Expect(uploaded).To(BeEmpty())
+		})
+	})
+
+	When("pushing an individual archive", func() {
+		BeforeEach(func() {
+			command.Source = "registry.opentofu.org/hashicorp/aws"
+			command.Paths = []string{createArchive("terraform-provider-aws_5.31.0_linux_amd64.zip")}
+			err = command.Run(context.Background())
+		})
+
+		It("should not error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should upload the archive", func() {
+			Expect(uploaded).To(Equal([]string{
+				"/v1/providers/registry.opentofu.org/hashicorp/aws/terraform-provider-aws_5.31.0_linux_amd64.zip",
+			}))
+		})
+
+		It("should print the pushed archive", func() {
+			Expect(stdout.String()).To(ContainSubstring("Pushed registry.opentofu.org/hashicorp/aws 5.31.0 (linux_amd64)"))
+		})
+	})
+
+	When("pushing a mirror directory", func() {
+		BeforeEach(func() {
+			createArchive("registry.opentofu.org/hashicorp/aws/terraform-provider-aws_5.31.0_linux_amd64.zip")
+			createArchive("registry.opentofu.org/hashicorp/random/terraform-provider-random_3.6.0_linux_amd64.zip")
+			createArchive("registry.opentofu.org/hashicorp/random/5.31.0.json")
+			command.Paths = []string{directory}
+			err = command.Run(context.Background())
+		})
+
+		It("should not error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should upload all the archives", func() {
+			Expect(uploaded).To(Equal([]string{
+				"/v1/providers/registry.opentofu.org/hashicorp/aws/terraform-provider-aws_5.31.0_linux_amd64.zip",
+				"/v1/providers/registry.opentofu.org/hashicorp/random/terraform-provider-random_3.6.0_linux_amd64.zip",
+			}))
+		})
+	})
+
+	When("the mirror directory has an unexpected layout", func() {
+		BeforeEach(func() {
+			createArchive("hashicorp/aws/terraform-provider-aws_5.31.0_linux_amd64.zip")
+			command.Paths = []string{directory}
+			err = command.Run(context.Background())
+		})
+
+		It("should error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("is not within a HOSTNAME/NAMESPACE/TYPE directory"))
+		})
+	})
+
+	When("pushing to the controller with authentication enabled", func() {
+		var store *mirror.Store
+		var filename string
+
+		BeforeEach(func() {
+			key, err := fixtures.NewProviderSigningKey()
+			Expect(err).ToNot(HaveOccurred())
+			store, err = mirror.New(filepath.Join(directory, "store"))
+			Expect(err).ToNot(HaveOccurred())
+			store.Keyring = openpgp.EntityList{key}
+
+			// @step: create a valid provider archive and the checksums published by the registry
+			buffer := &bytes.Buffer{}
+			writer := zip.NewWriter(buffer)
+			w, err := writer.Create("terraform-provider-aws_v5.31.0_x5")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = w.Write([]byte("binary"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(writer.Close()).To(Succeed())
+
+			filename = createArchive("terraform-provider-aws_5.31.0_linux_amd64.zip")
+			Expect(os.WriteFile(filename, buffer.Bytes(), 0600)).To(Succeed())
+			document, signature, err := fixtures.NewSignedProviderChecksums(key, map[string][]byte{
+				"terraform-provider-aws_5.31.0_linux_amd64.zip": buffer.Bytes(),
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			// @step: the token "admin" is permitted to push into the mirror, while "viewer" is not
+			kc := kfake.NewSimpleClientset()
+			kc.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+				review.Status.Authenticated = true
+				review.Status.User = authenticationv1.UserInfo{Username: review.Spec.Token}
+
+				return true, review, nil
+			})
+			kc.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+				review.Status.Allowed = review.Spec.User == "admin" && review.Spec.ResourceAttributes.Subresource == "mirror"
+
+				return true, review, nil
+			})
+
+			server := httptest.NewTLSServer((&apiserver.Server{Client: kc, Mirror: store}).ServeMirror())
+			DeferCleanup(server.Close)
+
+			_, err = kc.CoreV1().Secrets("terraform-system").Create(context.Background(), &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "terraform-system"},
+				Data: map[string][]byte{
+					"ca.pem": pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+				},
+			}, metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			factory = &fixtures.Factory{Streams: factory.GetStreams(), KubeClient: kc, RESTConfig: &rest.Config{BearerToken: "admin"}}
+			command = &PushCommand{
+				Factory:             factory,
+				CASecret:            "ca",
+				ControllerNamespace: "terraform-system",
+				Endpoint:            server.URL,
+				Source:              "registry.opentofu.org/hashicorp/aws",
+				Paths:               []string{filename},
+				Checksums: func(_ context.Context, _ mirror.Provider, _, _ string) (mirror.Checksums, error) {
+					return mirror.Checksums{Document: document, Signature: signature}, nil
+				},
+			}
+		})
+
+		Context("and the user is permitted to push", func() {
+			BeforeEach(func() {
+				err = command.Run(context.Background())
+			})
+
+			It("should not error", func() {
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should have added the archive to the mirror", func() {
+				versions, err := store.Versions(mirror.Provider{Hostname: "registry.opentofu.org", Namespace: "hashicorp", Type: "aws"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(versions).To(Equal([]string{"5.31.0"}))
+			})
+		})
+
+		Context("and the user is not permitted to push", func() {
+			BeforeEach(func() {
+				factory.(*fixtures.Factory).RESTConfig.BearerToken = "viewer"
+				err = command.Run(context.Background())
+			})
+
+			It("should error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("403 Forbidden"))
+			})
+		})
+
+		Context("and the kubeconfig has no token", func() {
+			BeforeEach(func() {
+				factory.(*fixtures.Factory).RESTConfig.BearerToken = ""
+				err = command.Run(context.Background())
+			})
+
+			It("should error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("the kubeconfig has no token credentials"))
+			})
+		})
+
+		Context("and the checksums are not signed by a trusted key", func() {
+			BeforeEach(func() {
+				command.Checksums = func(_ context.Context, _ mirror.Provider, _, _ string) (mirror.Checksums, error) {
+					return mirror.Checksums{Document: []byte("checksums"), Signature: []byte("signature")}, nil
+				}
+				err = command.Run(context.Background())
+			})
+
+			It("should error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("400 Bad Request"))
+			})
+		})
+	})
+})
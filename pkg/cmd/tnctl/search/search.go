@@ -34,7 +34,10 @@ import (
 
 	"github.com/appvia/terranetes-controller/pkg/cmd"
 	"github.com/appvia/terranetes-controller/pkg/cmd/search"
+	"github.com/appvia/terranetes-controller/pkg/cmd/search/bitbucket"
 	"github.com/appvia/terranetes-controller/pkg/cmd/search/github"
+	"github.com/appvia/terranetes-controller/pkg/cmd/search/gitlab"
+	"github.com/appvia/terranetes-controller/pkg/cmd/search/oci"
 	"github.com/appvia/terranetes-controller/pkg/cmd/search/terraform"
 	"github.com/appvia/terranetes-controller/pkg/cmd/tnctl/create/configuration"
 	"github.com/appvia/terranetes-controller/pkg/utils"
@@ -45,8 +48,9 @@ Searches the sources, determined by the configuration file (tnctl config view)
 for modules which match the required terms. Once selected the command will
 generate the Configuration CRD required to use the module as a source.
 
-At present we support using the Terraform registry and GitHub user / organizations
-as a source for terraform modules.
+At present we support using the Terraform registry, GitHub user / organizations,
+GitLab groups, Bitbucket workspaces and OCI registries as a source for terraform
+modules.
 
 Note, you can lookup the available providers available to you by selecting the
 'check available' option. This option will use the currently configured kubeconfig
@@ -58,9 +62,22 @@ $ tnctl config sources add https://registry.terraform.io
 Scope the terraform registry searches to a specific namespace
 $ tnctl config sources add https://registry.terraform.io/namespaces/appvia
 
+Adding a private registry or Terraform Enterprise organization, credentials are
+read from TF_TOKEN_<host> or the terraform cli configuration (terraform login)
+$ tnctl config sources add terraform://app.terraform.io/namespaces/appvia
+
 Adding a GitHub user or organization
 $ tnctl config sources add https://github.com/appvia
 
+Adding a GitLab group, optionally filtering the projects by topic
+$ tnctl config sources add gitlab.com/appvia?topic=terraform
+
+Adding a Bitbucket workspace
+$ tnctl config sources add bitbucket.org/appvia
+
+Adding modules published as OCI artifacts to a registry
+$ tnctl config sources add oci://ghcr.io/appvia
+
 # Search for all modules which have the term database using an 'aws' provider
 $ tnctl search database -p aws
 
@@ -71,6 +88,10 @@ For private repositories on Github you will need to export your token
 to the environment variable GITHUB_TOKEN.
 $ export GITHUB_TOKEN=TOKEN
 
+Likewise GITLAB_TOKEN is used for GitLab, BITBUCKET_USERNAME and
+BITBUCKET_APP_PASSWORD for Bitbucket, and OCI_USERNAME and OCI_PASSWORD
+for OCI registries.
+
 This command assumes credentials have already been setup. For the Terraform
 registry, nothing is required, but for private repositories on Github your
 environment must already be setup to git clone the repository.
@@ -460,6 +481,27 @@ func (o *Command) makeSourceHandlers(_ context.Context, sources []string) (map[s
 				return nil, err
 			}
 			searches[source] = h
+
+		case gitlab.IsHandle(source):
+			h, err := gitlab.New(source, os.Getenv("GITLAB_TOKEN"))
+			if err != nil {
+				return nil, err
+			}
+			searches[source] = h
+
+		case bitbucket.IsHandle(source):
+			h, err := bitbucket.New(source, os.Getenv("BITBUCKET_USERNAME"), os.Getenv("BITBUCKET_APP_PASSWORD"))
+			if err != nil {
+				return nil, err
+			}
+			searches[source] = h
+
+		case oci.IsHandle(source):
+			h, err := oci.New(source, os.Getenv("OCI_USERNAME"), os.Getenv("OCI_PASSWORD"))
+			if err != nil {
+				return nil, err
+			}
+			searches[source] = h
 		}
 	}
 
@@ -26,7 +26,6 @@ import (
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/duration"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
 	"github.com/appvia/terranetes-controller/pkg/cmd"
@@ -83,9 +82,9 @@ func (o *CleanCommand) Run(ctx context.Context) error {
 		return err
 	}
 
-	// @step: retrieve all the secrets within the controller namespace
-	secrets := &v1.SecretList{}
-	if err := cc.List(ctx, secrets, client.InNamespace(o.ControllerNamespace)); err != nil {
+	// @step: retrieve all the secrets within the controller and configuration namespaces
+	secrets, err := listConfigurationSecrets(ctx, cc, o.ControllerNamespace, configurationNamespaces(list))
+	if err != nil {
 		return err
 	}
 
@@ -102,6 +101,7 @@ func (o *CleanCommand) Run(ctx context.Context) error {
 
 	// @step: iterate the secrets and remove any orphaned secrets
 	var data [][]string
+	var orphaned []v1.Secret
 	for _, secret := range secrets.Items {
 		if !ConfigurationSecretRegex.MatchString(secret.Name) {
 			continue
@@ -123,6 +123,7 @@ func (o *CleanCommand) Run(ctx context.Context) error {
 			}
 			row = append(row, duration.HumanDuration(time.Since(secret.GetCreationTimestamp().Time)))
 			data = append(data, row)
+			orphaned = append(orphaned, secret)
 		}
 	}
 
@@ -150,14 +151,8 @@ func (o *CleanCommand) Run(ctx context.Context) error {
 		}
 	}
 
-	for _, row := range data {
-		name := row[0]
-
-		secret := &v1.Secret{}
-		secret.Namespace = o.ControllerNamespace
-		secret.Name = name
-
-		if err := cc.Delete(ctx, secret); err != nil {
+	for i := 0; i < len(orphaned); i++ {
+		if err := cc.Delete(ctx, &orphaned[i]); err != nil {
 			return err
 		}
 	}
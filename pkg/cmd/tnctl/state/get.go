@@ -23,7 +23,6 @@ import (
 	"io"
 
 	"github.com/spf13/cobra"
-	v1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
@@ -73,9 +72,7 @@ func (o *GetCommand) Run(ctx context.Context) error {
 	}
 
 	// @step: retrieve the secret for this configuration
-	secrets := &v1.SecretList{}
-	err = cc.List(ctx, secrets,
-		client.InNamespace(o.ControllerNamespace),
+	secrets, err := listConfigurationSecrets(ctx, cc, o.ControllerNamespace, []string{o.Namespace},
 		client.MatchingLabels(map[string]string{
 			terraformv1alpha1.ConfigurationNameLabel:      o.Name,
 			terraformv1alpha1.ConfigurationNamespaceLabel: o.Namespace,
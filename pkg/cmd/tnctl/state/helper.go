@@ -18,10 +18,14 @@
 package state
 
 import (
+	"context"
 	"regexp"
 	"strings"
 
 	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
 )
 
 // SecretPrefixes is a list of secret prefixes the controller uses
@@ -52,3 +56,41 @@ func findSecretByPrefix(prefix, id string, secrets *v1.SecretList) (string, bool
 
 	return "", false
 }
+
+// configurationNamespaces returns the unique namespaces of the configurations
+func configurationNamespaces(list *terraformv1alpha1.ConfigurationList) []string {
+	var namespaces []string
+	seen := make(map[string]bool)
+
+	for _, x := range list.Items {
+		if !seen[x.Namespace] {
+			namespaces = append(namespaces, x.Namespace)
+			seen[x.Namespace] = true
+		}
+	}
+
+	return namespaces
+}
+
+// listConfigurationSecrets returns the secrets within the controller namespace, followed by those within
+// the namespaces of the configurations, as the controller can run the jobs in the namespace of the
+// configuration instead
+func listConfigurationSecrets(ctx context.Context, cc client.Client, controllerNamespace string, namespaces []string, options ...client.ListOption) (*v1.SecretList, error) {
+	secrets := &v1.SecretList{}
+	seen := make(map[string]bool)
+
+	for _, namespace := range append([]string{controllerNamespace}, namespaces...) {
+		if seen[namespace] {
+			continue
+		}
+		seen[namespace] = true
+
+		list := &v1.SecretList{}
+		if err := cc.List(ctx, list, append([]client.ListOption{client.InNamespace(namespace)}, options...)...); err != nil {
+			return nil, err
+		}
+		secrets.Items = append(secrets.Items, list.Items...)
+	}
+
+	return secrets, nil
+}
@@ -22,7 +22,6 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
-	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/duration"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -78,9 +77,9 @@ func (o *ListCommand) Run(ctx context.Context) error {
 		return nil
 	}
 
-	// @step: retrieve all the secrets within the controller namespace
-	secrets := &v1.SecretList{}
-	if err := cc.List(ctx, secrets, client.InNamespace(o.ControllerNamespace)); err != nil {
+	// @step: retrieve all the secrets within the controller and configuration namespaces
+	secrets, err := listConfigurationSecrets(ctx, cc, o.ControllerNamespace, configurationNamespaces(list))
+	if err != nil {
 		return err
 	}
 
@@ -125,4 +125,30 @@ var _ = Describe("Listing the state", func() {
 			Expect(stdout.String()).To(ContainSubstring("tfstate-default-4845842d-f29b-4d12-8f6a-b73c7bf82836"))
 		})
 	})
+
+	When("we have configuration secrets present in the configuration namespace", func() {
+		BeforeEach(func() {
+			cfg := fixtures.NewValidBucketConfiguration("apps", "test")
+			cfg.UID = types.UID(testUID)
+			cc.Create(context.Background(), cfg)
+
+			for _, prefix := range SecretPrefixes {
+				secret := &v1.Secret{}
+				secret.Name = fmt.Sprintf("%s%v", prefix, testUID)
+				secret.Namespace = "apps"
+				Expect(cc.Create(context.Background(), secret)).ToNot(HaveOccurred())
+			}
+			os.Args = []string{"state", "list"}
+			err = command.Execute()
+		})
+
+		It("should not error", func() {
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should list the configurations", func() {
+			Expect(stdout.String()).To(ContainSubstring("tfstate-default-4845842d-f29b-4d12-8f6a-b73c7bf82836"))
+			Expect(stdout.String()).To(ContainSubstring("cost-4845842d-f29b-4d12-8f6a-b73c7bf82836"))
+		})
+	})
 })
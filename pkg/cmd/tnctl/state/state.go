@@ -31,7 +31,8 @@ type Command struct {
 var longDesc = `
 When using the kubernetes backend to store the terraform state, this
 command provides the ability to list, clean and match up state secrets
-against the Configuration CRD which are using them.
+against the Configuration CRD which are using them, as well as release
+state locks left behind by interrupted runs.
 `
 
 // NewCommand returns a new instance of the command
@@ -46,6 +47,7 @@ func NewCommand(factory cmd.Factory) *cobra.Command {
 		NewGetCommand(factory),
 		NewListCommand(factory),
 		NewCleanCommand(factory),
+		NewUnlockCommand(factory),
 	)
 
 	return c
@@ -0,0 +1,125 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/cmd"
+	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+)
+
+// UnlockCommand is the options for the unlock command
+type UnlockCommand struct {
+	cmd.Factory
+	// LockID is the id of the state lock to release
+	LockID string
+	// Name is the name of the configuration
+	Name string
+	// Namespace is the namespace of the configuration
+	Namespace string
+}
+
+var longUnlockHelp = `
+When a terraform run is interrupted the remote backend can retain the
+state lock, causing all subsequent runs to fail. The unlock command
+requests the controller to release the lock via a terraform force-unlock
+job, which is only run when no other job for the configuration is active.
+
+The lock id can be found via 'tnctl describe configuration NAME'.
+
+# Release the state lock for a configuration
+$ tnctl state unlock NAME --lock-id LOCK_ID
+`
+
+// NewUnlockCommand creates and returns a new unlock command
+func NewUnlockCommand(factory cmd.Factory) *cobra.Command {
+	o := &UnlockCommand{Factory: factory}
+
+	c := &cobra.Command{
+		Use:   "unlock NAME [OPTIONS]",
+		Long:  longUnlockHelp,
+		Short: "Releases a terraform state lock held against a configuration",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Name = args[0]
+
+			return o.Run(cmd.Context())
+		},
+		ValidArgsFunction: cmd.AutoCompleteConfigurations(factory),
+	}
+
+	flags := c.Flags()
+	flags.StringVar(&o.LockID, "lock-id", "", "The id of the state lock to release")
+	flags.StringVarP(&o.Namespace, "namespace", "n", "default", "The namespace of the configuration")
+
+	cmd.RegisterFlagCompletionFunc(c, "namespace", cmd.AutoCompleteNamespaces(factory))
+
+	return c
+}
+
+// Run implements the command
+func (o *UnlockCommand) Run(ctx context.Context) error {
+	switch {
+	case o.LockID == "":
+		return errors.New("lock id is required")
+	case len(validation.IsValidLabelValue(o.LockID)) > 0:
+		return fmt.Errorf("invalid lock id: %s", strings.Join(validation.IsValidLabelValue(o.LockID), ", "))
+	}
+
+	cc, err := o.GetClient()
+	if err != nil {
+		return err
+	}
+
+	configuration := &terraformv1alpha1.Configuration{}
+	configuration.Namespace = o.Namespace
+	configuration.Name = o.Name
+
+	if found, err := kubernetes.GetIfExists(ctx, cc, configuration); err != nil {
+		return err
+	} else if !found {
+		return fmt.Errorf("configuration (%s/%s) does not exist", o.Namespace, o.Name)
+	}
+
+	// @step: guard against releasing a lock other than the one recorded by the controller
+	if lock := configuration.Status.StateLock; lock != nil && lock.ID != o.LockID {
+		return fmt.Errorf("lock id does not match the state lock %q held against the configuration", lock.ID)
+	}
+
+	original := configuration.DeepCopy()
+	if configuration.Annotations == nil {
+		configuration.Annotations = map[string]string{}
+	}
+	configuration.Annotations[terraformv1alpha1.ForceUnlockAnnotation] = o.LockID
+
+	if err := cc.Patch(ctx, configuration, client.MergeFrom(original)); err != nil {
+		return err
+	}
+	o.Println("%s Configuration %q has been marked to release the state lock", cmd.IconGood, o.Name)
+	o.Println("Once released, use 'tnctl retry configuration %s' to run the configuration again", o.Name)
+
+	return nil
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package state
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/cmd"
+	"github.com/appvia/terranetes-controller/pkg/schema"
+	"github.com/appvia/terranetes-controller/test/fixtures"
+)
+
+var _ = Describe("Unlocking the state", func() {
+	logrus.SetOutput(io.Discard)
+	lockID := "3c2d1f6e-0e4a-5b7c-8d9e-1f2a3b4c5d6e"
+
+	var cc client.Client
+	var factory cmd.Factory
+	var streams genericclioptions.IOStreams
+	var stdout *bytes.Buffer
+	var command *cobra.Command
+	var configuration *terraformv1alpha1.Configuration
+	var err error
+
+	BeforeEach(func() {
+		configuration = fixtures.NewValidBucketConfiguration("default", "test")
+		cc = fake.NewClientBuilder().
+			WithScheme(schema.GetScheme()).
+			WithStatusSubresource(&terraformv1alpha1.Configuration{}).
+			Build()
+
+		streams, _, stdout, _ = genericclioptions.NewTestIOStreams()
+		factory = &fixtures.Factory{
+			RuntimeClient: cc,
+			Streams:       streams,
+		}
+		command = NewCommand(factory)
+	})
+
+	When("no lock id is provided", func() {
+		BeforeEach(func() {
+			os.Args = []string{"state", "unlock", "test"}
+			err = command.Execute()
+		})
+
+		It("should error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("lock id is required"))
+		})
+	})
+
+	When("the lock id is invalid", func() {
+		BeforeEach(func() {
+			os.Args = []string{"state", "unlock", "test", "--lock-id", "not a valid id"}
+			err = command.Execute()
+		})
+
+		It("should error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid lock id"))
+		})
+	})
+
+	When("the configuration does not exist", func() {
+		BeforeEach(func() {
+			os.Args = []string{"state", "unlock", "test", "--lock-id", lockID}
+			err = command.Execute()
+		})
+
+		It("should error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("configuration (default/test) does not exist"))
+		})
+	})
+
+	When("the lock id does not match the recorded state lock", func() {
+		BeforeEach(func() {
+			configuration.Status.StateLock = &terraformv1alpha1.StateLockStatus{ID: "another"}
+			Expect(cc.Create(context.Background(), configuration)).To(Succeed())
+
+			os.Args = []string{"state", "unlock", "test", "--lock-id", lockID}
+			err = command.Execute()
+		})
+
+		It("should error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(`lock id does not match the state lock "another" held against the configuration`))
+		})
+	})
+
+	When("the lock id matches the recorded state lock", func() {
+		BeforeEach(func() {
+			configuration.Status.StateLock = &terraformv1alpha1.StateLockStatus{ID: lockID}
+			Expect(cc.Create(context.Background(), configuration)).To(Succeed())
+
+			os.Args = []string{"state", "unlock", "test", "--lock-id", lockID}
+			err = command.Execute()
+		})
+
+		It("should not error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should annotate the configuration", func() {
+			Expect(cc.Get(context.Background(), configuration.GetNamespacedName(), configuration)).To(Succeed())
+			Expect(configuration.GetAnnotations()).To(HaveKeyWithValue(terraformv1alpha1.ForceUnlockAnnotation, lockID))
+		})
+
+		It("should indicate the configuration has been marked", func() {
+			Expect(stdout.String()).To(ContainSubstring(`Configuration "test" has been marked to release the state lock`))
+		})
+	})
+})
@@ -39,9 +39,11 @@ import (
 	"github.com/appvia/terranetes-controller/pkg/cmd/tnctl/get"
 	"github.com/appvia/terranetes-controller/pkg/cmd/tnctl/kubectl"
 	"github.com/appvia/terranetes-controller/pkg/cmd/tnctl/logs"
+	"github.com/appvia/terranetes-controller/pkg/cmd/tnctl/mirror"
 	"github.com/appvia/terranetes-controller/pkg/cmd/tnctl/retry"
 	"github.com/appvia/terranetes-controller/pkg/cmd/tnctl/search"
 	"github.com/appvia/terranetes-controller/pkg/cmd/tnctl/state"
+	"github.com/appvia/terranetes-controller/pkg/cmd/tnctl/upgrade"
 	"github.com/appvia/terranetes-controller/pkg/cmd/tnctl/verify"
 	"github.com/appvia/terranetes-controller/pkg/version"
 )
@@ -91,6 +93,8 @@ func New(factory cmd.Factory) *cobra.Command {
 		verify.NewCommand(factory),
 		retry.NewCommand(factory),
 		logs.NewCommand(factory),
+		mirror.NewCommand(factory),
+		upgrade.NewCommand(factory),
 	)
 
 	flags := command.PersistentFlags()
@@ -0,0 +1,259 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/cmd"
+	"github.com/appvia/terranetes-controller/pkg/utils"
+	"github.com/appvia/terranetes-controller/pkg/utils/terraform"
+)
+
+// TerraformCommand are the options for the command
+type TerraformCommand struct {
+	cmd.Factory
+	// AllNamespaces indicates we should plan the configurations in all namespaces
+	AllNamespaces bool
+	// Names is an optional collection of configurations to plan
+	Names []string
+	// Namespace is the namespace of the configurations
+	Namespace string
+	// Selector is an optional label selector used to filter the configurations
+	Selector string
+	// Timeout is the maximum amount of time to wait for the plans to complete
+	Timeout time.Duration
+	// Version is the version of the engine to plan the configurations under
+	Version string
+	// WaitInterval is the interval between checking the configurations
+	WaitInterval time.Duration
+}
+
+var longTerraformHelp = `
+Plans each of the Configurations under a new version of the engine and
+reports which of them would change. The plans are never applied, and
+do not affect any plan awaiting approval. The version can be an exact
+version or a constraint, resolved against the versions made available
+by the platform administrator.
+
+Note, the command will refuse to plan a Configuration under a version
+older than the one which last wrote its state.
+
+# Check the impact of upgrading all the configurations in a namespace
+$ tnctl upgrade terraform 1.8.5 -n apps
+
+# Check the impact of upgrading specific configurations
+$ tnctl upgrade terraform 1.8.5 -n apps bucket database
+
+# Check the impact across all namespaces using a constraint
+$ tnctl upgrade terraform "~> 1.8" -A
+`
+
+// NewTerraformCommand creates and returns the command
+func NewTerraformCommand(factory cmd.Factory) *cobra.Command {
+	o := &TerraformCommand{Factory: factory}
+
+	c := &cobra.Command{
+		Use:   "terraform VERSION [NAME...] [OPTIONS]",
+		Long:  longTerraformHelp,
+		Short: "Reports which configurations would change under a new version",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			o.Version = args[0]
+			o.Names = args[1:]
+
+			return o.Run(cmd.Context())
+		},
+	}
+
+	flags := c.Flags()
+	flags.BoolVarP(&o.AllNamespaces, "all-namespaces", "A", false, "Plan the configurations in all namespaces")
+	flags.DurationVar(&o.Timeout, "timeout", 30*time.Minute, "The maximum amount of time to wait for the plans to complete")
+	flags.DurationVar(&o.WaitInterval, "wait-interval", 5*time.Second, "The interval between checking the configurations")
+	flags.StringVarP(&o.Namespace, "namespace", "n", "default", "The namespace of the configurations")
+	flags.StringVarP(&o.Selector, "selector", "l", "", "A label selector used to filter the configurations")
+
+	cmd.RegisterFlagCompletionFunc(c, "namespace", cmd.AutoCompleteNamespaces(factory))
+
+	return c
+}
+
+// Run implements the command
+func (o *TerraformCommand) Run(ctx context.Context) error {
+	switch {
+	case o.Version == "":
+		return errors.New("version is required")
+	case len(o.Names) > 0 && o.AllNamespaces:
+		return errors.New("configuration names cannot be used with all namespaces")
+	}
+	if terraform.IsVersionConstraint(o.Version) {
+		if _, err := terraform.ParseVersionConstraint(o.Version); err != nil {
+			return fmt.Errorf("invalid version constraint %q, %w", o.Version, err)
+		}
+	}
+
+	cc, err := o.GetClient()
+	if err != nil {
+		return err
+	}
+
+	list, err := o.listConfigurations(ctx, cc)
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return errors.New("no configurations found")
+	}
+
+	// @step: request a preview plan of each configuration under the version
+	for i := range list {
+		if err := o.annotate(ctx, cc, &list[i], o.Version); err != nil {
+			return err
+		}
+	}
+	o.Println("%s Requested a plan of %d configuration(s) under version %s", cmd.IconGood, len(list), o.Version)
+
+	// @step: wait for the plans to complete
+	werr := utils.RetryWithTimeout(ctx, o.Timeout, o.WaitInterval, func() (bool, error) {
+		completed := true
+
+		for i := range list {
+			if isPreviewComplete(&list[i], o.Version) {
+				continue
+			}
+			if err := cc.Get(ctx, client.ObjectKeyFromObject(&list[i]), &list[i]); err != nil {
+				return false, err
+			}
+			if !isPreviewComplete(&list[i], o.Version) {
+				completed = false
+			}
+		}
+
+		return completed, nil
+	})
+
+	// @step: report the outcome and remove the annotation
+	changes := 0
+	tw := cmd.NewTableWriter(o.GetStreams().Out)
+	tw.SetHeader([]string{"Namespace", "Name", "Status", "Changes", "Message"})
+	for i := range list {
+		status, message, changed := terraformv1alpha1.PreviewInProgress, "", false
+		if preview := list[i].Status.Preview; isPreviewComplete(&list[i], o.Version) {
+			status, message, changed = preview.Status, preview.Message, preview.Changes
+		}
+		if changed {
+			changes++
+		}
+		tw.Append([]string{list[i].Namespace, list[i].Name, status, fmt.Sprintf("%t", changed), message})
+
+		if err := o.annotate(ctx, cc, &list[i], ""); err != nil {
+			return err
+		}
+	}
+	tw.Render()
+
+	if werr != nil {
+		return fmt.Errorf("failed waiting for the plans to complete, %w", werr)
+	}
+	if changes == 0 {
+		o.Println("%s No configurations would change under version %s", cmd.IconGood, o.Version)
+	} else {
+		o.Println("%s %d configuration(s) would change under version %s", cmd.IconBad, changes, o.Version)
+	}
+
+	return nil
+}
+
+// listConfigurations returns the configurations to plan
+func (o *TerraformCommand) listConfigurations(ctx context.Context, cc client.Client) ([]terraformv1alpha1.Configuration, error) {
+	if len(o.Names) > 0 {
+		var list []terraformv1alpha1.Configuration
+
+		for _, name := range o.Names {
+			configuration := terraformv1alpha1.Configuration{}
+			if err := cc.Get(ctx, client.ObjectKey{Namespace: o.Namespace, Name: name}, &configuration); err != nil {
+				return nil, fmt.Errorf("failed to retrieve the configuration (%s/%s), %w", o.Namespace, name, err)
+			}
+			list = append(list, configuration)
+		}
+
+		return list, nil
+	}
+
+	var options []client.ListOption
+	if !o.AllNamespaces {
+		options = append(options, client.InNamespace(o.Namespace))
+	}
+	if o.Selector != "" {
+		selector, err := labels.Parse(o.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q, %w", o.Selector, err)
+		}
+		options = append(options, client.MatchingLabelsSelector{Selector: selector})
+	}
+
+	list := &terraformv1alpha1.ConfigurationList{}
+	if err := cc.List(ctx, list, options...); err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
+// annotate sets or removes the preview annotation on the configuration
+func (o *TerraformCommand) annotate(ctx context.Context, cc client.Client, configuration *terraformv1alpha1.Configuration, version string) error {
+	original := configuration.DeepCopy()
+
+	if configuration.Annotations == nil {
+		configuration.Annotations = map[string]string{}
+	}
+	if version == "" {
+		delete(configuration.Annotations, terraformv1alpha1.PreviewVersionAnnotation)
+	} else {
+		configuration.Annotations[terraformv1alpha1.PreviewVersionAnnotation] = version
+	}
+
+	if err := cc.Patch(ctx, configuration, client.MergeFrom(original)); err != nil {
+		return fmt.Errorf("failed to update the configuration (%s/%s), %w",
+			configuration.Namespace, configuration.Name, err)
+	}
+
+	return nil
+}
+
+// isPreviewComplete returns true if the plan under the version has finished
+func isPreviewComplete(configuration *terraformv1alpha1.Configuration, version string) bool {
+	preview := configuration.Status.Preview
+
+	switch {
+	case preview == nil:
+		return false
+	case preview.Version != version, preview.Generation != configuration.GetGeneration():
+		return false
+	}
+
+	return preview.Status != terraformv1alpha1.PreviewInProgress
+}
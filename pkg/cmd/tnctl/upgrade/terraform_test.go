@@ -0,0 +1,176 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package upgrade
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/schema"
+	"github.com/appvia/terranetes-controller/test/fixtures"
+)
+
+var _ = Describe("Upgrade Terraform Command", func() {
+	var cc client.Client
+	var stdout *bytes.Buffer
+	var command *TerraformCommand
+	var bucket, database *terraformv1alpha1.Configuration
+	var err error
+
+	BeforeEach(func() {
+		var streams genericclioptions.IOStreams
+		streams, _, stdout, _ = genericclioptions.NewTestIOStreams()
+		cc = fake.NewClientBuilder().WithScheme(schema.GetScheme()).Build()
+
+		bucket = fixtures.NewValidBucketConfiguration("default", "bucket")
+		database = fixtures.NewValidBucketConfiguration("default", "database")
+
+		command = &TerraformCommand{
+			Factory:      &fixtures.Factory{RuntimeClient: cc, Streams: streams},
+			Namespace:    "default",
+			Timeout:      200 * time.Millisecond,
+			Version:      "1.8.5",
+			WaitInterval: 10 * time.Millisecond,
+		}
+	})
+
+	When("no version is provided", func() {
+		BeforeEach(func() {
+			command.Version = ""
+			err = command.Run(context.Background())
+		})
+
+		It("should return an error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("version is required"))
+		})
+	})
+
+	When("the version constraint is invalid", func() {
+		BeforeEach(func() {
+			command.Version = "~> bad"
+			err = command.Run(context.Background())
+		})
+
+		It("should return an error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid version constraint \"~> bad\""))
+		})
+	})
+
+	When("no configurations are found", func() {
+		BeforeEach(func() {
+			err = command.Run(context.Background())
+		})
+
+		It("should return an error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("no configurations found"))
+		})
+	})
+
+	When("the plans have completed", func() {
+		BeforeEach(func() {
+			bucket.Status.Preview = &terraformv1alpha1.PreviewStatus{
+				Changes: true,
+				Message: "Terraform plan under version 1.8.5 has changes",
+				Status:  terraformv1alpha1.PreviewComplete,
+				Version: "1.8.5",
+			}
+			database.Status.Preview = &terraformv1alpha1.PreviewStatus{
+				Message: "Terraform plan under version 1.8.5 has no changes",
+				Status:  terraformv1alpha1.PreviewComplete,
+				Version: "1.8.5",
+			}
+			Expect(cc.Create(context.Background(), bucket)).To(Succeed())
+			Expect(cc.Create(context.Background(), database)).To(Succeed())
+
+			err = command.Run(context.Background())
+		})
+
+		It("should not return an error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should report the configurations which would change", func() {
+			Expect(stdout.String()).To(ContainSubstring("Requested a plan of 2 configuration(s) under version 1.8.5"))
+			Expect(stdout.String()).To(ContainSubstring("Terraform plan under version 1.8.5 has changes"))
+			Expect(stdout.String()).To(ContainSubstring("Terraform plan under version 1.8.5 has no changes"))
+			Expect(stdout.String()).To(ContainSubstring("1 configuration(s) would change under version 1.8.5"))
+		})
+
+		It("should have removed the annotation", func() {
+			Expect(cc.Get(context.Background(), bucket.GetNamespacedName(), bucket)).To(Succeed())
+			Expect(bucket.Annotations).ToNot(HaveKey(terraformv1alpha1.PreviewVersionAnnotation))
+		})
+	})
+
+	When("planning specific configurations", func() {
+		BeforeEach(func() {
+			database.Status.Preview = &terraformv1alpha1.PreviewStatus{
+				Message: "Terraform plan under version 1.8.5 has no changes",
+				Status:  terraformv1alpha1.PreviewComplete,
+				Version: "1.8.5",
+			}
+			Expect(cc.Create(context.Background(), bucket)).To(Succeed())
+			Expect(cc.Create(context.Background(), database)).To(Succeed())
+
+			command.Names = []string{"database"}
+			err = command.Run(context.Background())
+		})
+
+		It("should not return an error", func() {
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should only have planned the named configurations", func() {
+			Expect(stdout.String()).To(ContainSubstring("Requested a plan of 1 configuration(s) under version 1.8.5"))
+			Expect(stdout.String()).To(ContainSubstring("No configurations would change under version 1.8.5"))
+		})
+	})
+
+	When("the plans do not complete in time", func() {
+		BeforeEach(func() {
+			Expect(cc.Create(context.Background(), bucket)).To(Succeed())
+
+			err = command.Run(context.Background())
+		})
+
+		It("should return an error", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("failed waiting for the plans to complete"))
+		})
+
+		It("should report the plan as in progress", func() {
+			Expect(stdout.String()).To(ContainSubstring(terraformv1alpha1.PreviewInProgress))
+		})
+
+		It("should have removed the annotation", func() {
+			Expect(cc.Get(context.Background(), bucket.GetNamespacedName(), bucket)).To(Succeed())
+			Expect(bucket.Annotations).ToNot(HaveKey(terraformv1alpha1.PreviewVersionAnnotation))
+		})
+	})
+})
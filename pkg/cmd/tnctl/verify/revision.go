@@ -18,6 +18,7 @@
 package verify
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -62,6 +63,11 @@ you have the appropriate cloud credentials configured within your terminal
 environment.
 $ tnctl verify revision revision.yaml --use-terraform-plan
 
+The plan is generated using the engine (terraform or opentofu) of the Revision, else
+the Provider or the default engine of the controller. The image providing the engine is
+checked to ensure it matches, and can be overridden using the --engine flag
+$ tnctl verify revision revision.yaml --use-terraform-plan --engine terraform
+
 To speed up multiple iterations of this command it's useful to use the --directory
 flag. This instructs the command to reuse the directory, rather then creating a
 an ephemeral one each time (and downloading, terraform provider, if --use-terraform-plan
@@ -74,6 +80,12 @@ it against terraform itself
 $ tnctl convert revision revision.yaml | terraform plan -out plan.out
 `
 
+// defaultEngineImages are the images used for the engines when not discovered from the cluster
+var defaultEngineImages = map[string]string{
+	terraformv1alpha1.EngineOpenTofu:  "ghcr.io/opentofu/opentofu:latest",
+	terraformv1alpha1.EngineTerraform: "hashicorp/terraform:latest",
+}
+
 // RevisionCommand are the options for the command
 type RevisionCommand struct {
 	cmd.Factory
@@ -83,6 +95,8 @@ type RevisionCommand struct {
 	SourceDir string
 	// CheckovImage is the version of checkov image to use when validating the security policy
 	CheckovImage string
+	// Engine is the engine used to generate the plan, i.e. terraform or opentofu
+	Engine string
 	// TerraformImage is the version of terraform to use when validating the security policy
 	TerraformImage string
 	// Directory is the temporary directory used to store the converted files
@@ -133,6 +147,7 @@ func NewRevisionCommand(factory cmd.Factory) *cobra.Command {
 	flags.BoolVar(&o.KeepTempDir, "keep-temp-dir", false, "Indicates if we should keep the temporary directory")
 	flags.BoolVar(&o.ShowGuidelines, "show-guidelines", true, "Indicates if we should show the guidelines in the output")
 	flags.StringVar(&o.CheckovImage, "checkov-image", "", "The docker image of checkov to use when validating the security policy")
+	flags.StringVar(&o.Engine, "engine", "", "The engine used to generate a plan i.e. terraform or opentofu (defaults to the engine used in the cluster)")
 	flags.StringVar(&o.TerraformImage, "terraform-image", "", "The docker image of terraform to use when generating a plan")
 	flags.StringVarP(&o.Directory, "directory", "d", "", "Path to a directory to store temporary files")
 	flags.StringVarP(&o.SourceDir, "source-dir", "s", "", "Path to a directory containing additional (or overrides) files i.e. Contexts, Policies, Plans etc")
@@ -191,7 +206,7 @@ func (o *RevisionCommand) Run(ctx context.Context) error {
 		return err
 	}
 	// @step: check for the terraform version
-	if err := o.retrieveTerraformVersion(ctx); err != nil {
+	if err := o.retrieveTerraformVersion(ctx, revision); err != nil {
 		return err
 	}
 	// @step: check if the cloudresource is permitted by the policy
@@ -333,60 +348,103 @@ func (o *RevisionCommand) retrieveCheckovVersion(ctx context.Context) error {
 	})
 }
 
-// retrieveTerraformVersion is responsible for retrieving the terraform version from the cluster
-// nolint:dupl
-func (o *RevisionCommand) retrieveTerraformVersion(ctx context.Context) error {
-	if o.TerraformImage != "" {
-		return nil
+// retrieveTerraformVersion is responsible for retrieving the engine and terraform version from
+// the cluster, the engine is taken from the flag, the revision, the provider or else the default
+// engine of the controller
+func (o *RevisionCommand) retrieveTerraformVersion(ctx context.Context, revision *terraformv1alpha1.Revision) error {
+	if o.Engine == "" {
+		o.Engine = revision.Spec.Configuration.Engine
+	}
+	if o.Engine == "" && o.Providers != nil && revision.Spec.Configuration.ProviderRef != nil {
+		if provider, found := o.Providers.GetItem(revision.Spec.Configuration.ProviderRef.Name); found {
+			o.Engine = provider.Spec.Engine
+		}
 	}
-	o.TerraformImage = "latest"
 
 	return o.Verify.Check("Retrieving Terraform Version", func(v CheckInterface) error {
-		latest := "Unable discover Terraform version from cluster, defaulting to latest"
-
-		// @step: retrieve a client
-		cc, err := o.GetClient()
-		if err != nil {
-			v.Warning(latest)
+		if o.Engine != "" && !terraformv1alpha1.IsValidEngine(o.Engine) {
+			v.Failed("Engine %q is not supported, must be %s or %s", o.Engine, terraformv1alpha1.EngineOpenTofu, terraformv1alpha1.EngineTerraform)
 
 			return nil
 		}
 
-		// @step: attempt to retrieve the checkov version
-		controller := &appsv1.Deployment{}
-		controller.Namespace = "terraform-system"
-		controller.Name = "terranetes-controller"
+		// @step: attempt to retrieve the engine and images from the controller
+		args := o.retrieveControllerArgs(ctx)
+		if o.Engine == "" {
+			o.Engine = args["--default-engine"]
+		}
+		if o.Engine == "" {
+			o.Engine = terraformv1alpha1.EngineOpenTofu
+		}
 
-		if found, err := kubernetes.GetIfExists(ctx, cc, controller); err != nil || !found {
-			v.Warning(latest)
+		if o.TerraformImage == "" {
+			o.TerraformImage = args["--"+o.Engine+"-image"]
+			if o.TerraformImage == "" {
+				o.TerraformImage = defaultEngineImages[o.Engine]
+				v.Info("Unable to discover %s version from cluster, using: %q", terraform.EngineName(o.Engine), o.TerraformImage)
+			} else {
+				v.Passed("Discovered %s version: %q", terraform.EngineName(o.Engine), o.TerraformImage)
+			}
+		}
+		if !o.EnableTerraformPlan {
 			return nil
 		}
-		if len(controller.Spec.Template.Spec.Containers) == 0 {
-			v.Warning(latest)
+
+		// @step: ensure the image provides the expected engine
+		options := []string{"run", "--rm", o.TerraformImage, "version"}
+		combined, err := exec.CommandContext(ctx, "docker", options...).CombinedOutput()
+		if err != nil {
+			v.Warning("Unable to retrieve the version from the image: %q, output: %s", o.TerraformImage, string(combined))
+
 			return nil
 		}
+		engine, version, err := terraform.ParseEngineVersion(bytes.NewReader(combined))
+		if err != nil {
+			v.Warning("Unable to parse the version from the image: %q", o.TerraformImage)
 
-		var found bool
-		for _, x := range controller.Spec.Template.Spec.Containers[0].Args {
-			switch {
-			case !strings.Contains(x, "--terraform-image="):
-				continue
-			case len(strings.Split(x, "=")) != 2:
-				continue
-			}
-			o.TerraformImage = strings.Split(x, "=")[1]
-			found = true
+			return nil
 		}
-		if !found {
-			v.Info("Unable to discover Terraform version from cluster, using: %q", o.TerraformImage)
-		} else {
-			v.Passed("Discovered Terraform version: %q", o.TerraformImage)
+		if engine != o.Engine {
+			v.Failed("The image %q provides %s, but the engine is %s", o.TerraformImage, terraform.EngineName(engine), terraform.EngineName(o.Engine))
+
+			return nil
 		}
+		v.Passed("Using %s version: %q", terraform.EngineName(engine), version)
 
 		return nil
 	})
 }
 
+// retrieveControllerArgs returns the arguments of the controller in the cluster, or an empty
+// map when the cluster or controller is unavailable
+func (o *RevisionCommand) retrieveControllerArgs(ctx context.Context) map[string]string {
+	args := make(map[string]string)
+
+	cc, err := o.GetClient()
+	if err != nil {
+		return args
+	}
+
+	controller := &appsv1.Deployment{}
+	controller.Namespace = "terraform-system"
+	controller.Name = "terranetes-controller"
+
+	if found, err := kubernetes.GetIfExists(ctx, cc, controller); err != nil || !found {
+		return args
+	}
+	if len(controller.Spec.Template.Spec.Containers) == 0 {
+		return args
+	}
+
+	for _, x := range controller.Spec.Template.Spec.Containers[0].Args {
+		if key, value, found := strings.Cut(x, "="); found {
+			args[key] = value
+		}
+	}
+
+	return args
+}
+
 // checkRevisionInputs is responsible for checking the inputs
 func (o *RevisionCommand) checkRevisionInputs(revision *terraformv1alpha1.Revision) error {
 	return o.Verify.Check("Validating Revision Inputs", func(v CheckInterface) error {
@@ -531,7 +589,7 @@ func (o *RevisionCommand) checkTerraformPlan(ctx context.Context, revision *terr
 			"--workdir", "/source",
 			"--entrypoint", "sh",
 			o.TerraformImage,
-			"-c", "terraform show -json plan.tfplan > plan.json",
+			"-c", terraform.EngineBinary(o.Engine) + " show -json plan.tfplan > plan.json",
 		}
 
 		cmd = exec.CommandContext(ctx, "docker", options...)
@@ -685,7 +743,7 @@ func (o *RevisionCommand) checkValueFromReferences(revision *terraformv1alpha1.R
 			} else {
 				v.Passed("Revision references a context %q, key %q which exists", txt.Name, x.Key)
 
-				if _, found := txt.Spec.Variables[x.Key]; !found {
+				if _, found := txt.Spec.Variables[x.Key]; !found && !txt.Spec.HasSecret(x.Key) {
 					v.Failed("Revision references key %q, in Context %q which does not exist", x.Key, *x.Context)
 				}
 			}
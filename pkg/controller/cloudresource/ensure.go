@@ -178,6 +178,10 @@ func (c *Controller) ensureConfigurationExists(cloudresource *terraformv1alpha1.
 
 		configuration.Spec.EnableAutoApproval = cloudresource.Spec.EnableAutoApproval
 		configuration.Spec.EnableDriftDetection = cloudresource.Spec.EnableDriftDetection
+		configuration.Spec.Engine = revision.Spec.Configuration.Engine
+		if cloudresource.Spec.Engine != "" {
+			configuration.Spec.Engine = cloudresource.Spec.Engine
+		}
 		configuration.Spec.Module = revision.Spec.Configuration.Module
 		configuration.Spec.Plan = &terraformv1alpha1.PlanReference{
 			Name:     cloudresource.Spec.Plan.Name,
@@ -46,7 +46,9 @@ import (
 
 	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
 	"github.com/appvia/terranetes-controller/pkg/handlers/configurations"
+	"github.com/appvia/terranetes-controller/pkg/utils"
 	ksutils "github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+	"github.com/appvia/terranetes-controller/pkg/utils/logstore"
 	"github.com/appvia/terranetes-controller/pkg/utils/policies"
 )
 
@@ -73,14 +75,25 @@ type Controller struct {
 	// BackoffLimit is the amount of times we are allowing a job to failed before deeming
 	// it a failure
 	BackoffLimit int
-	// BinaryPath is the name of the binary to use to run the terraform commands
+	// BinaryPath is the name of the binary to use to run the commands for the terraform engine
 	BinaryPath string
+	// CacheVolume is the name of a persistent volume claim used to cache module sources and
+	// provider plugins across jobs
+	CacheVolume string
+	// DefaultEngine is the engine used when neither the configuration or provider defines one
+	DefaultEngine string
+	// EnableConfigurationHooks indicates configurations are permitted to define their own hooks
+	EnableConfigurationHooks bool
 	// EnableContextInjection enables the injection of the context into the terraform configuration
 	// variables. This means we shall inject an number of default variables into the configuration
 	// such as namespace, name and labels
 	EnableContextInjection bool
 	// EnableInfracosts enables the cost analytics via infracost
 	EnableInfracosts bool
+	// EnableNamespacedJobs indicates the jobs, configuration secrets and state are placed in the
+	// namespace of the configuration, using a per-namespace service account, rather than the
+	// controller namespace
+	EnableNamespacedJobs bool
 	// EnableTerraformVersions enables the use of the configuration's Terraform version
 	EnableTerraformVersions bool
 	// EnableWatchers indicates we should create watcher jobs in the user namespace
@@ -111,10 +124,34 @@ type Controller struct {
 	ControllerJobLabels map[string]string
 	// JobTemplate is a custom override for the template to use
 	JobTemplate string
+	// LogStore is an optional store used to retain the logs of completed jobs
+	LogStore logstore.Interface
+	// OpenTofuBinaryPath is the name of the binary to use to run the commands for the opentofu engine
+	OpenTofuBinaryPath string
+	// OpenTofuImage is the image to use for all opentofu jobs
+	OpenTofuImage string
+	// OpenTofuVersions is a collection of opentofu versions made available by the administrator,
+	// used to resolve any version constraints on the configurations
+	OpenTofuVersions []string
 	// PolicyImage is the image to use for all policy / checkov jobs
 	PolicyImage string
+	// ProviderMirrorCASecret is the name of the secret containing the certificate authority
+	// used to trust the provider mirror
+	ProviderMirrorCASecret string
+	// ProviderMirrorURL is the location of the provider network mirror used by the jobs
+	ProviderMirrorURL string
+	// SourceAllowedHosts is a collection of hosts module sources are permitted to be retrieved from
+	SourceAllowedHosts []string
+	// SourceAllowedSchemes is a collection of schemes module sources are permitted to use
+	SourceAllowedSchemes []string
+	// SourceSigningKeys is the name of a secret in the controller namespace containing the public
+	// keys used to verify the signature of git module sources
+	SourceSigningKeys string
 	// TerraformImage is the image to use for all terraform jobs
 	TerraformImage string
+	// TerraformVersions is a collection of terraform versions made available by the administrator,
+	// used to resolve any version constraints on the configurations
+	TerraformVersions []string
 }
 
 // HasBackendTemplate returns true if the configuration has a backend template
@@ -168,10 +205,13 @@ func (c *Controller) Add(mgr manager.Manager) error {
 	log.WithFields(log.Fields{
 		"additional_secrets": len(c.ExecutorSecrets),
 		"backend":            c.BackendTemplate,
+		"default_engine":     c.DefaultEngine,
 		"enable_costs":       c.EnableInfracosts,
+		"enable_namespaced":  c.EnableNamespacedJobs,
 		"enable_watchers":    c.EnableWatchers,
 		"filters":            strings.Join(c.NamespaceFilters, ","),
 		"namespace":          c.ControllerNamespace,
+		"opentofu_image":     c.OpenTofuImage,
 		"policy_image":       c.PolicyImage,
 		"terraform_image":    c.TerraformImage,
 	}).Info("adding the configuration controller")
@@ -191,11 +231,22 @@ func (c *Controller) Add(mgr manager.Manager) error {
 		}
 	}
 
+	// @step: ensure the available versions are valid
+	for _, list := range [][]string{c.OpenTofuVersions, c.TerraformVersions} {
+		if _, err := utils.SortSemverVersions(list); err != nil {
+			return fmt.Errorf("invalid available version, error: %w", err)
+		}
+	}
+
 	switch {
 	case c.ControllerNamespace == "":
 		return errors.New("job namespace is required")
+	case !terraformv1alpha1.IsValidEngine(c.DefaultEngine):
+		return fmt.Errorf("default engine must be %s or %s", terraformv1alpha1.EngineOpenTofu, terraformv1alpha1.EngineTerraform)
 	case c.TerraformImage == "":
 		return errors.New("terraform image is required")
+	case c.OpenTofuImage == "":
+		return errors.New("opentofu image is required")
 	case c.PolicyImage == "":
 		return errors.New("policy image is required")
 	case c.EnableInfracosts && c.InfracostsImage == "":
@@ -229,7 +280,7 @@ func (c *Controller) Add(mgr manager.Manager) error {
 	if c.EnableWebhooks {
 		mgr.GetWebhookServer().Register(
 			fmt.Sprintf("/validate/%s/configurations", terraformv1alpha1.GroupName),
-			admission.WithCustomValidator(mgr.GetScheme(), &terraformv1alpha1.Configuration{}, configurations.NewValidator(c.cc, c.EnableTerraformVersions)),
+			admission.WithCustomValidator(mgr.GetScheme(), &terraformv1alpha1.Configuration{}, configurations.NewValidator(c.cc, c.EnableTerraformVersions, c.EnableConfigurationHooks)),
 		)
 		mgr.GetWebhookServer().Register(
 			fmt.Sprintf("/mutate/%s/configurations", terraformv1alpha1.GroupName),
@@ -257,17 +308,17 @@ func (c *Controller) Add(mgr manager.Manager) error {
 					}},
 				}
 			}),
-			// we only care about jobs in our namespace
+			// we only care about jobs in the job namespaces
 			builder.WithPredicates(predicate.And(
 				predicate.Funcs{
 					GenericFunc: func(e event.GenericEvent) bool {
-						return e.Object.GetNamespace() == c.ControllerNamespace
+						return c.isJobNamespace(e.Object)
 					},
 					CreateFunc: func(e event.CreateEvent) bool {
-						return e.Object.GetNamespace() == c.ControllerNamespace
+						return c.isJobNamespace(e.Object)
 					},
 					UpdateFunc: func(e event.UpdateEvent) bool {
-						return e.ObjectNew.GetNamespace() == c.ControllerNamespace
+						return c.isJobNamespace(e.ObjectNew)
 					},
 					DeleteFunc: func(e event.DeleteEvent) bool {
 						return false
@@ -63,7 +63,7 @@ func (c *Controller) ensureTerraformDestroy(configuration *terraformv1alpha1.Con
 
 		// @step: check we have a terraform state - else we can just continue
 		secret := &v1.Secret{}
-		secret.Namespace = c.ControllerNamespace
+		secret.Namespace = c.jobNamespace(configuration)
 		secret.Name = configuration.GetTerraformStateSecretName()
 
 		found, err := kubernetes.GetIfExists(ctx, c.cc, secret)
@@ -98,16 +98,25 @@ func (c *Controller) ensureTerraformDestroy(configuration *terraformv1alpha1.Con
 				map[string]string{
 					terraformv1alpha1.RetryAnnotation: configuration.GetAnnotations()[terraformv1alpha1.RetryAnnotation],
 				}),
-			BackoffLimit:     c.BackoffLimit,
-			BinaryPath:       c.BinaryPath,
-			EnableInfraCosts: c.EnableInfracosts,
-			ExecutorImage:    c.ExecutorImage,
-			ExecutorSecrets:  c.ExecutorSecrets,
-			InfracostsImage:  c.InfracostsImage,
-			InfracostsSecret: c.InfracostsSecretName,
-			Namespace:        c.ControllerNamespace,
-			Template:         state.jobTemplate,
-			Image:            GetTerraformImage(configuration, c.TerraformImage),
+			BackoffLimit:           c.BackoffLimit,
+			BinaryPath:             c.engineBinaryPath(state.engine),
+			CacheVolume:            c.jobCacheVolume(),
+			EnableInfraCosts:       c.EnableInfracosts,
+			Engine:                 state.engine,
+			ExecutorImage:          c.ExecutorImage,
+			ExecutorSecrets:        c.ExecutorSecrets,
+			Hooks:                  state.hooks,
+			InfracostsImage:        c.InfracostsImage,
+			InfracostsSecret:       c.InfracostsSecretName,
+			Namespace:              c.jobNamespace(configuration),
+			PodTemplateOverrides:   state.podTemplateOverrides,
+			ProviderMirrorCASecret: c.ProviderMirrorCASecret,
+			ProviderMirrorURL:      c.ProviderMirrorURL,
+			SourceAllowedHosts:     c.SourceAllowedHosts,
+			SourceAllowedSchemes:   c.SourceAllowedSchemes,
+			SourceSigningKeys:      c.SourceSigningKeys,
+			Template:               state.jobTemplate,
+			Image:                  c.engineImage(state.engine, state.version),
 		})
 		if err != nil {
 			cond.Failed(err, "Failed to create the terraform destroy job")
@@ -151,11 +160,21 @@ func (c *Controller) ensureTerraformDestroy(configuration *terraformv1alpha1.Con
 
 		switch {
 		case jobs.IsComplete(job):
-			cond.Success("Terraform destroy is complete")
+			// we deliberately do not retain the logs of a successful destroy, the build logs are
+			// removed along with the configuration further down the deletion chain
+			c.captureStepResults(ctx, configuration, job, terraformv1alpha1.StageTerraformDestroy)
+			cond.Success("Terraform destroy is complete%s", describeIgnoredHooks(configuration))
 			return reconcile.Result{}, nil
 
 		case jobs.IsFailed(job):
-			cond.Failed(nil, "Terraform destroy has failed")
+			// a failed destroy halts the deletion, so the logs are retained for troubleshooting
+			c.captureBuildLogs(ctx, configuration, job, terraformv1alpha1.StageTerraformDestroy)
+			c.captureStepResults(ctx, configuration, job, terraformv1alpha1.StageTerraformDestroy)
+			if hook, found := findFailedHook(configuration); found {
+				cond.Failed(nil, "Terraform destroy has failed, the hook %q failed", hook)
+			} else {
+				cond.Failed(nil, "Terraform destroy has failed")
+			}
 			configuration.Status.ResourceStatus = terraformv1alpha1.DestroyingResourcesFailed
 
 			return reconcile.Result{}, controller.ErrIgnore
@@ -180,11 +199,15 @@ func (c *Controller) ensureConfigurationSecretsDeleted(configuration *terraformv
 			configuration.GetTerraformStateSecretName(),
 			configuration.GetTerraformPlanOutSecretName(),
 			configuration.GetTerraformPlanJSONSecretName(),
+			configuration.GetTerraformStepResultsSecretName(),
+			configuration.GetTerraformPreviewPlanOutSecretName(),
+			configuration.GetTerraformPreviewPlanJSONSecretName(),
+			configuration.GetTerraformPreviewStepResultsSecretName(),
 		}
 
 		for _, name := range names {
 			secret := &v1.Secret{}
-			secret.Namespace = c.ControllerNamespace
+			secret.Namespace = c.jobNamespace(configuration)
 			secret.Name = name
 
 			if err := kubernetes.DeleteIfExists(ctx, c.cc, secret); err != nil {
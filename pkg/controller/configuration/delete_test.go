@@ -44,6 +44,7 @@ import (
 	"github.com/appvia/terranetes-controller/pkg/schema"
 	"github.com/appvia/terranetes-controller/pkg/utils"
 	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+	"github.com/appvia/terranetes-controller/pkg/utils/logstore"
 	controllertests "github.com/appvia/terranetes-controller/test"
 	"github.com/appvia/terranetes-controller/test/fixtures"
 )
@@ -569,6 +570,7 @@ var _ = Describe("Configuration Controller with Contexts", func() {
 						terraformv1alpha1.ConfigurationNamespaceLabel:  configuration.Namespace,
 						terraformv1alpha1.ConfigurationStageLabel:      terraformv1alpha1.StageTerraformDestroy,
 						terraformv1alpha1.ConfigurationUIDLabel:        string(configuration.UID),
+						terraformv1alpha1.JobWatcherLabel:              "true",
 					}))
 					Expect(list.Items[0].Spec.Parallelism).ToNot(BeNil())
 					Expect(*list.Items[0].Spec.Parallelism).To(Equal(int32(1)))
@@ -701,5 +703,74 @@ var _ = Describe("Configuration Controller with Contexts", func() {
 				})
 			})
 		})
+
+		When("a log store is configured", func() {
+			var store logstore.Interface
+			var job *batchv1.Job
+
+			key := func() logstore.Key {
+				return logstore.Key{
+					Name:      configuration.Name,
+					Namespace: configuration.Namespace,
+					Stage:     terraformv1alpha1.StageTerraformDestroy,
+					UID:       string(configuration.GetUID()),
+				}
+			}
+
+			BeforeEach(func() {
+				watcher := fixtures.NewConfigurationPodWatcher(configuration, terraformv1alpha1.StageTerraformDestroy)
+				Expect(cc.Create(context.Background(), watcher)).ToNot(HaveOccurred())
+
+				store, _ = logstore.New("kubernetes", cc, ctrl.ControllerNamespace)
+				ctrl.LogStore = store
+			})
+
+			setup := func() {
+				job.Namespace = ctrl.ControllerNamespace
+				Expect(cc.Create(context.Background(), job)).ToNot(HaveOccurred())
+
+				ctrl.kc = kfake.NewSimpleClientset(&v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      job.Name + "-abcde",
+						Namespace: ctrl.ControllerNamespace,
+						Labels:    map[string]string{"job-name": job.Name},
+					},
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{{Name: "terraform"}},
+					},
+				})
+
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 0)
+			}
+
+			Context("and the terraform destroy has failed", func() {
+				BeforeEach(func() {
+					job = fixtures.NewFailedTerraformJob(configuration, terraformv1alpha1.StageTerraformDestroy)
+					setup()
+				})
+
+				It("should have retained the destroy logs", func() {
+					logs, err := store.Get(context.TODO(), key())
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(logs)).To(Equal("fake logs"))
+				})
+			})
+
+			Context("and the terraform destroy has succeeded", func() {
+				BeforeEach(func() {
+					job = fixtures.NewCompletedTerraformJob(configuration, terraformv1alpha1.StageTerraformDestroy)
+					setup()
+				})
+
+				It("should have deleted the configuration", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).To(HaveOccurred())
+				})
+
+				It("should not have retained the destroy logs", func() {
+					_, err := store.Get(context.TODO(), key())
+					Expect(err).To(Equal(logstore.ErrNotFound))
+				})
+			})
+		})
 	})
 })
@@ -20,23 +20,65 @@ package configuration
 import (
 	"context"
 	"io"
-	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"github.com/appvia/terranetes-controller/pkg/agent"
 	corev1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/core/v1alpha1"
 	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
 	"github.com/appvia/terranetes-controller/pkg/controller"
+	"github.com/appvia/terranetes-controller/pkg/utils/integrity"
 	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
 	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
 	"github.com/appvia/terranetes-controller/pkg/utils/terraform"
 )
 
+// findErrorDetectors returns the detectors defined within configmaps in the controller namespace;
+// these are retrieved on every call so changes are picked up without a restart
+func (c *Controller) findErrorDetectors(ctx context.Context) []terraform.ErrorDetection {
+	list := &v1.ConfigMapList{}
+	if err := c.cc.List(ctx, list,
+		client.InNamespace(c.ControllerNamespace),
+		client.MatchingLabels{terraformv1alpha1.ErrorDetectorsLabel: "true"},
+	); err != nil {
+		log.WithError(err).Error("failed to list the error detectors")
+
+		return nil
+	}
+
+	var detectors []terraform.ErrorDetection
+	for _, configmap := range list.Items {
+		keys := make([]string, 0, len(configmap.Data))
+		for key := range configmap.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			found, err := terraform.ParseDetectors([]byte(configmap.Data[key]))
+			if err != nil {
+				log.WithFields(log.Fields{
+					"key":  key,
+					"name": configmap.Name,
+				}).WithError(err).Error("invalid error detectors in configmap, skipping")
+
+				continue
+			}
+			detectors = append(detectors, found...)
+		}
+	}
+
+	return detectors
+}
+
 // ensureErrorDetection is helper used to try and detect by the configuration failed and
 // report is back to the users via status
 func (c *Controller) ensureErrorDetection(configuration *terraformv1alpha1.Configuration, job *batchv1.Job, state *state) controller.EnsureFunc {
@@ -45,12 +87,23 @@ func (c *Controller) ensureErrorDetection(configuration *terraformv1alpha1.Confi
 		"name":      configuration.Name,
 		"namespace": configuration.Namespace,
 	})
-	provider := string(state.provider.Spec.Provider)
 	cond := controller.ConditionMgr(configuration, corev1alpha1.ConditionReady, c.recorder)
 
 	return func(ctx context.Context) (reconcile.Result, error) {
+		// @step: jobs run by a remote agent have no pods, the logs are those reported by the agent
+		if agent.IsAgentJob(job) {
+			logs, err := c.agentJobLogs(ctx, job)
+			if err != nil {
+				logger.WithError(err).Error("failed to retrieve the logs reported by the agent")
+
+				return reconcile.Result{}, controller.ErrIgnore
+			}
+
+			return c.detectErrors(ctx, configuration, job, state, logs)
+		}
+
 		// @step: we check if the logs for the configuration are available
-		pods, err := c.kc.CoreV1().Pods(c.ControllerNamespace).List(ctx, metav1.ListOptions{
+		pods, err := c.kc.CoreV1().Pods(c.jobNamespace(configuration)).List(ctx, metav1.ListOptions{
 			LabelSelector: "job-name=" + job.Name,
 		})
 		if err != nil {
@@ -79,8 +132,15 @@ func (c *Controller) ensureErrorDetection(configuration *terraformv1alpha1.Confi
 			return reconcile.Result{}, controller.ErrIgnore
 		}
 
+		// @step: check if the module source failed verification, in which case terraform never ran
+		if message, found := findSourceVerificationFailure(pod); found {
+			cond.ActionRequired("Module source verification failed: %s", message)
+
+			return reconcile.Result{}, controller.ErrIgnore
+		}
+
 		// @step: find the terraform container and retrieve the logs
-		stream, err := c.kc.CoreV1().Pods(c.ControllerNamespace).GetLogs(pod.Name, &v1.PodLogOptions{
+		stream, err := c.kc.CoreV1().Pods(c.jobNamespace(configuration)).GetLogs(pod.Name, &v1.PodLogOptions{
 			Container: jobs.TerraformContainerName,
 			Follow:    false,
 		}).Stream(ctx)
@@ -100,28 +160,103 @@ func (c *Controller) ensureErrorDetection(configuration *terraformv1alpha1.Confi
 			return reconcile.Result{}, controller.ErrIgnore
 		}
 
-		// @step: retrieve all the detection regexes for this configuration
-		detectors := append(terraform.Detectors["*"], terraform.Detectors[provider]...)
-		if len(detectors) == 0 {
-			return reconcile.Result{}, controller.ErrIgnore
-		}
+		return c.detectErrors(ctx, configuration, job, state, logs)
+	}
+}
 
-		for i := 0; i < len(detectors); i++ {
-			m, err := regexp.Compile(detectors[i].Regex)
-			if err != nil {
-				logger.WithError(err).Error("failed to compile regex")
+// detectErrors searches the logs of the failed job for known errors, reporting them on the
+// status of the configuration and scheduling any retries
+func (c *Controller) detectErrors(
+	ctx context.Context,
+	configuration *terraformv1alpha1.Configuration,
+	job *batchv1.Job,
+	state *state,
+	logs []byte) (reconcile.Result, error) {
 
-				return reconcile.Result{}, controller.ErrIgnore
-			}
-			if m.MatchString(string(logs)) {
-				message := detectors[i].Message
+	logger := log.WithFields(log.Fields{
+		"job":       job.Name,
+		"name":      configuration.Name,
+		"namespace": configuration.Namespace,
+	})
+	provider := string(state.provider.Spec.Provider)
+	cond := controller.ConditionMgr(configuration, corev1alpha1.ConditionReady, c.recorder)
 
-				//nolint:govet
-				cond.ActionRequired(message)
-			}
+	// @step: record any state lock which prevented the run from completing
+	if lock, found := terraform.FindStateLock(string(logs)); found {
+		configuration.Status.StateLock = &terraformv1alpha1.StateLockStatus{
+			ID:        lock.ID,
+			Operation: lock.Operation,
+			Path:      lock.Path,
+			Who:       lock.Who,
 		}
+		if !lock.Created.IsZero() {
+			configuration.Status.StateLock.Created = &metav1.Time{Time: lock.Created}
+		}
+	}
+
+	// @step: retrieve all the detectors for this configuration, including those
+	// defined by the platform administrators
+	detectors := terraform.FindDetectors(provider, c.findErrorDetectors(ctx))
+
+	matches, err := terraform.DetectErrors(string(logs), detectors)
+	if err != nil {
+		logger.WithError(err).Error("failed to compile regex")
 
-		// if we've entered this method we cannot move forward in the reconciliation process
 		return reconcile.Result{}, controller.ErrIgnore
 	}
+
+	// @step: warnings are raised as events regardless of the outcome
+	for _, detection := range matches {
+		if detection.IsWarning() {
+			c.recorder.Event(configuration, v1.EventTypeWarning, "ErrorDetected", detection.Description())
+		}
+	}
+
+	// @step: check if the failure is transient and permitted to be retried automatically
+	delay, err := c.ensureRetryScheduled(ctx, configuration, job, state, matches, cond)
+	if err != nil {
+		logger.WithError(err).Error("failed to schedule a retry of the configuration")
+	}
+	if delay > 0 {
+		return reconcile.Result{RequeueAfter: delay}, nil
+	}
+
+	// @step: the first error is reported on the condition
+	for _, detection := range matches {
+		if !detection.IsWarning() {
+			cond.ActionRequired("%s", detection.Description())
+
+			break
+		}
+	}
+
+	// if we've entered this method we cannot move forward in the reconciliation process
+	return reconcile.Result{}, controller.ErrIgnore
+}
+
+// findSourceVerificationFailure checks if the setup container of the pod terminated due to the
+// module source failing the integrity or allow-list checks, returning the reason
+func findSourceVerificationFailure(pod *v1.Pod) (string, bool) {
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.Name != jobs.SetupContainerName {
+			continue
+		}
+		terminated := status.State.Terminated
+		if terminated == nil || terminated.ExitCode == 0 {
+			return "", false
+		}
+
+		lines := strings.Split(terminated.Message, "\n")
+		for i := len(lines) - 1; i >= 0; i-- {
+			index := strings.Index(lines[i], integrity.ErrVerification.Error())
+			if index < 0 {
+				continue
+			}
+			message := strings.TrimSpace(lines[i][index+len(integrity.ErrVerification.Error()):])
+
+			return strings.TrimSpace(strings.TrimPrefix(message, ":")), true
+		}
+	}
+
+	return "", false
 }
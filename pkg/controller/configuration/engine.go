@@ -0,0 +1,95 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package configuration
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/core/v1alpha1"
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/controller"
+	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+	"github.com/appvia/terranetes-controller/pkg/utils/terraform"
+)
+
+// ensureEngine is responsible for resolving the engine and version used to execute the
+// configuration. Any change must be able to read the state written by the engine which last
+// applied the configuration, else we refuse to run any jobs
+func (c *Controller) ensureEngine(configuration *terraformv1alpha1.Configuration, state *state) controller.EnsureFunc {
+	cond := controller.ConditionMgr(configuration, corev1alpha1.ConditionReady, c.recorder)
+
+	return func(ctx context.Context) (reconcile.Result, error) {
+		state.engine = GetEngine(configuration, state.provider, c.DefaultEngine)
+
+		// @step: resolve the version, which may be a constraint against the available versions
+		resolved, err := terraform.ResolveVersion(configuration.Spec.TerraformVersion, c.engineVersions(state.engine))
+		if err != nil {
+			cond.ActionRequired("Unable to resolve the %s version %q, %s",
+				terraform.EngineName(state.engine), configuration.Spec.TerraformVersion, err)
+
+			return reconcile.Result{}, controller.ErrIgnore
+		}
+		state.version = resolved
+
+		version := GetEngineVersion(state.version, c.engineImage(state.engine, ""))
+
+		switch {
+		case configuration.Status.Engine == "", configuration.Status.Engine == state.engine:
+			if err := terraform.IsVersionCompatible(state.engine, configuration.Status.TerraformVersion, version); err != nil {
+				cond.ActionRequired("Unable to downgrade the %s version, %s", terraform.EngineName(state.engine), err)
+
+				return reconcile.Result{}, controller.ErrIgnore
+			}
+
+			return reconcile.Result{}, nil
+		}
+
+		secret := &v1.Secret{}
+		secret.Name = configuration.GetTerraformStateSecretName()
+		secret.Namespace = c.jobNamespace(configuration)
+
+		found, err := kubernetes.GetIfExists(ctx, c.cc, secret)
+		if err != nil {
+			cond.Failed(err, "Failed to get terraform state secret (%s/%s)", secret.Namespace, secret.Name)
+
+			return reconcile.Result{}, err
+		}
+		if !found {
+			return reconcile.Result{}, nil
+		}
+
+		tfstate, err := terraform.DecodeState(secret.Data[terraformv1alpha1.TerraformStateSecretKey])
+		if err != nil {
+			cond.Failed(err, "Failed to decode the terraform state")
+
+			return reconcile.Result{}, err
+		}
+
+		if err := terraform.IsEngineCompatible(configuration.Status.Engine, state.engine, tfstate, version); err != nil {
+			cond.ActionRequired("Unable to switch the engine from %s to %s, %s",
+				configuration.Status.Engine, state.engine, err)
+
+			return reconcile.Result{}, controller.ErrIgnore
+		}
+
+		return reconcile.Result{}, nil
+	}
+}
@@ -33,6 +33,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -131,8 +132,8 @@ func (c *Controller) ensureCapturedState(configuration *terraformv1alpha1.Config
 
 		// @step: retrieve a list of jobs
 		jobs := &batchv1.JobList{}
-		if err := c.cc.List(ctx, jobs, client.InNamespace(c.ControllerNamespace)); err != nil {
-			cond.Failed(err, "Failed to list the jobs in controller namespace")
+		if err := c.cc.List(ctx, jobs, c.jobListOptions()...); err != nil {
+			cond.Failed(err, "Failed to list the jobs in the job namespace")
 
 			return reconcile.Result{}, err
 		}
@@ -308,7 +309,7 @@ func (c *Controller) ensureCostSecret(configuration *terraformv1alpha1.Configura
 		}
 
 		secret := &v1.Secret{}
-		secret.Namespace = c.ControllerNamespace
+		secret.Namespace = c.jobNamespace(configuration)
 		secret.Name = c.InfracostsSecretName
 
 		found, err := kubernetes.GetIfExists(ctx, c.cc, secret)
@@ -382,34 +383,76 @@ func (c *Controller) ensureValueFromSecret(configuration *terraformv1alpha1.Conf
 				}
 
 			case x.Context != nil:
-				config := &terraformv1alpha1.Context{}
-				config.Name = *x.Context
+				// @step: first we check for a namespace context, which takes precedence over the cluster context
+				local := &terraformv1alpha1.NamespaceContext{}
+				local.Namespace = configuration.Namespace
+				local.Name = *x.Context
 
-				// @step: first we check for the terranetes context resource
-				found, err := kubernetes.GetIfExists(ctx, c.cc, config)
+				exists, err := kubernetes.GetIfExists(ctx, c.cc, local)
 				if err != nil {
-					cond.Failed(err, "Failed to retrieve the context spec.valueFrom[%d].context: %s", i, config.Name)
+					cond.Failed(err, "Failed to retrieve the namespace context spec.valueFrom[%d].context: %s", i, local.Name)
 
 					return reconcile.Result{}, err
 				}
+				var raw runtime.RawExtension
+				var found bool
+				if exists {
+					raw, found = local.Spec.GetVariableValue(x.Key)
+				}
+
 				if !found {
-					if !x.Optional {
-						cond.ActionRequired("spec.valueFrom[%d].context (%s) does not exist", i, config.Name)
+					config := &terraformv1alpha1.Context{}
+					config.Name = *x.Context
 
-						return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+					// @step: next we check for the terranetes context resource
+					found, err := kubernetes.GetIfExists(ctx, c.cc, config)
+					if err != nil {
+						cond.Failed(err, "Failed to retrieve the context spec.valueFrom[%d].context: %s", i, config.Name)
+
+						return reconcile.Result{}, err
+					}
+					if !found {
+						if !x.Optional {
+							if exists {
+								cond.ActionRequired("spec.valueFrom[%d] does not contain key: %s", i, x.Key)
+							} else {
+								cond.ActionRequired("spec.valueFrom[%d].context (%s) does not exist", i, config.Name)
+							}
+
+							return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+						}
+						continue
 					}
-					continue
-				}
 
-				// @step: we need to check terranetes context has a value
-				raw, found := config.Spec.GetVariableValue(x.Key)
-				if !found {
-					if !x.Optional {
-						cond.ActionRequired("spec.valueFrom[%d] does not contain key: %s", i, x.Key)
+					// @step: check if the key is a sensitive variable, these are only resolved
+					// when building the job configuration secret
+					if sensitive, found := config.Spec.GetSecret(x.Key); found {
+						permitted, err := c.isPermittedContextSecret(ctx, configuration, sensitive)
+						if err != nil {
+							cond.Failed(err, "Failed to check the configuration is permitted to use spec.valueFrom[%d].context: %s", i, config.Name)
 
-						return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+							return reconcile.Result{}, err
+						}
+						if !permitted {
+							cond.ActionRequired("spec.valueFrom[%d] is not permitted to use the sensitive key: %s", i, x.Key)
+
+							return reconcile.Result{}, controller.ErrIgnore
+						}
+						state.valueFromSecrets[x.GetName()] = sensitive.SecretRef
+
+						continue
+					}
+
+					// @step: we need to check terranetes context has a value
+					raw, found = config.Spec.GetVariableValue(x.Key)
+					if !found {
+						if !x.Optional {
+							cond.ActionRequired("spec.valueFrom[%d] does not contain key: %s", i, x.Key)
+
+							return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+						}
+						continue
 					}
-					continue
 				}
 				if len(raw.Raw) == 0 {
 					if !x.Optional {
@@ -422,7 +465,7 @@ func (c *Controller) ensureValueFromSecret(configuration *terraformv1alpha1.Conf
 
 				av := make(map[string]interface{})
 				if err := json.NewDecoder(bytes.NewBuffer(raw.Raw)).Decode(&av); err != nil {
-					cond.Failed(err, "Failed to decode the context spec.valueFrom[%d].context: %s", i, config.Name)
+					cond.Failed(err, "Failed to decode the context spec.valueFrom[%d].context: %s", i, *x.Context)
 
 					return reconcile.Result{}, err
 				}
@@ -675,10 +718,11 @@ func (c *Controller) ensurePolicyDefaultsExist(configuration *terraformv1alpha1.
 			}
 		}
 
-		// @step: we iterate the referenced secrets and check they exist in the controller namespace
+		// @step: we iterate the referenced secrets and check they exist in the namespace the jobs
+		// are run in, the controller never copies these into the configuration namespace
 		for i := 0; i < len(list); i++ {
 			secret := &v1.Secret{}
-			secret.Namespace = c.ControllerNamespace
+			secret.Namespace = c.jobNamespace(configuration)
 			secret.Name = list[i]
 
 			found, err := kubernetes.GetIfExists(ctx, c.cc, secret)
@@ -711,7 +755,7 @@ func (c *Controller) ensureJobConfigurationSecret(configuration *terraformv1alph
 
 	return func(ctx context.Context) (reconcile.Result, error) {
 		secret := &v1.Secret{}
-		secret.Namespace = c.ControllerNamespace
+		secret.Namespace = c.jobNamespace(configuration)
 		secret.Name = name
 
 		if _, err := kubernetes.GetIfExists(ctx, c.cc, secret); err != nil {
@@ -729,7 +773,7 @@ func (c *Controller) ensureJobConfigurationSecret(configuration *terraformv1alph
 		// backend pointing at a secret
 		cfg, err := terraform.NewKubernetesBackend(terraform.BackendOptions{
 			Configuration: configuration,
-			Namespace:     c.ControllerNamespace,
+			Namespace:     c.jobNamespace(configuration),
 			Suffix:        suffix,
 			Template:      state.backendTemplate,
 		})
@@ -760,6 +804,22 @@ func (c *Controller) ensureJobConfigurationSecret(configuration *terraformv1alph
 			variables[key] = value
 		}
 
+		// @step: resolve any sensitive context variables from the controller namespace
+		for key, ref := range state.valueFromSecrets {
+			value, found, err := kubernetes.GetSecretIfExists(ctx, c.cc, c.ControllerNamespace, ref.Name)
+			if err != nil {
+				cond.Failed(err, "Failed to retrieve the sensitive context variable: %s", key)
+
+				return reconcile.Result{}, err
+			}
+			if !found || len(value.Data[ref.Key]) == 0 {
+				cond.ActionRequired("Sensitive context variable: %s references a missing secret or key (%s/%s)", key, ref.Name, ref.Key)
+
+				return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+			}
+			variables[key] = string(value.Data[ref.Key])
+		}
+
 		// @step: should we inject the context?
 		if c.EnableContextInjection {
 			variables["terranetes"] = map[string]interface{}{
@@ -845,6 +905,12 @@ func (c *Controller) ensureTerraformPlan(configuration *terraformv1alpha1.Config
 
 			return reconcile.Result{}, controller.ErrIgnore
 
+		// @note: an automatic retry has been scheduled for this generation and is not yet due
+		case configuration.GetRetryDelay(time.Now()) > 0 &&
+			cond.GetCondition().ObservedGeneration == configuration.GetGeneration():
+
+			return reconcile.Result{RequeueAfter: configuration.GetRetryDelay(time.Now())}, nil
+
 		// @note: if the configuration is marked for retry and the last reconcile was before us - we can retry
 		// the configuration
 		case configuration.HasRetryableAnnotation() && configuration.IsRetryable():
@@ -889,21 +955,30 @@ func (c *Controller) ensureTerraformPlan(configuration *terraformv1alpha1.Config
 					terraformv1alpha1.JobTemplateHashLabel: state.jobTemplateHash,
 				}),
 			BackoffLimit:                 c.BackoffLimit,
-			BinaryPath:                   c.BinaryPath,
+			BinaryPath:                   c.engineBinaryPath(state.engine),
+			CacheVolume:                  c.jobCacheVolume(),
 			DefaultExecutorCPULimit:      c.DefaultExecutorCPULimit,
 			DefaultExecutorCPURequest:    c.DefaultExecutorCPURequest,
 			DefaultExecutorMemoryLimit:   c.DefaultExecutorMemoryLimit,
 			DefaultExecutorMemoryRequest: c.DefaultExecutorMemoryRequest,
 			EnableInfraCosts:             c.EnableInfracosts,
+			Engine:                       state.engine,
 			ExecutorImage:                c.ExecutorImage,
 			ExecutorSecrets:              c.ExecutorSecrets,
-			Image:                        GetTerraformImage(configuration, c.TerraformImage),
+			Hooks:                        state.hooks,
+			Image:                        c.engineImage(state.engine, state.version),
 			InfracostsImage:              c.InfracostsImage,
 			InfracostsSecret:             c.InfracostsSecretName,
-			Namespace:                    c.ControllerNamespace,
+			Namespace:                    c.jobNamespace(configuration),
+			PodTemplateOverrides:         state.podTemplateOverrides,
 			PolicyConstraint:             state.checkovConstraint,
 			PolicyImage:                  c.PolicyImage,
 			SaveTerraformState:           saveState,
+			ProviderMirrorCASecret:       c.ProviderMirrorCASecret,
+			ProviderMirrorURL:            c.ProviderMirrorURL,
+			SourceAllowedHosts:           c.SourceAllowedHosts,
+			SourceAllowedSchemes:         c.SourceAllowedSchemes,
+			SourceSigningKeys:            c.SourceSigningKeys,
 			Template:                     state.jobTemplate,
 		}
 
@@ -925,6 +1000,7 @@ func (c *Controller) ensureTerraformPlan(configuration *terraformv1alpha1.Config
 			WithNamespace(configuration.GetNamespace()).
 			WithStage(terraformv1alpha1.StageTerraformPlan).
 			WithUID(string(configuration.GetUID())).
+			WithoutLabel(terraformv1alpha1.JobPreviewVersionLabel).
 			Latest()
 
 		if !found {
@@ -996,12 +1072,21 @@ func (c *Controller) ensureTerraformPlan(configuration *terraformv1alpha1.Config
 		// @step: we only shift out of this state of the job is complete
 		switch {
 		case jobs.IsComplete(job):
-			cond.Success("Terraform plan is complete")
+			c.captureBuildLogs(ctx, configuration, job, terraformv1alpha1.StageTerraformPlan)
+			c.captureStepResults(ctx, configuration, job, terraformv1alpha1.StageTerraformPlan)
+			configuration.Status.StateLock = nil
+			cond.Success("Terraform plan is complete%s", describeIgnoredHooks(configuration))
 
 			return reconcile.Result{}, nil
 
 		case jobs.IsFailed(job):
-			cond.Failed(nil, "Terraform plan is failed")
+			c.captureBuildLogs(ctx, configuration, job, terraformv1alpha1.StageTerraformPlan)
+			c.captureStepResults(ctx, configuration, job, terraformv1alpha1.StageTerraformPlan)
+			if hook, found := findFailedHook(configuration); found {
+				cond.Failed(nil, "Terraform plan has failed, the hook %q failed", hook)
+			} else {
+				cond.Failed(nil, "Terraform plan is failed")
+			}
 
 			return c.ensureErrorDetection(configuration, job, state)(ctx)
 
@@ -1021,17 +1106,17 @@ func (c *Controller) ensureTerraformPlanSecret(configuration *terraformv1alpha1.
 	return func(ctx context.Context) (reconcile.Result, error) {
 		secret := &v1.Secret{}
 		secret.Name = configuration.GetTerraformPlanJSONSecretName()
-		secret.Namespace = c.ControllerNamespace
+		secret.Namespace = c.jobNamespace(configuration)
 
 		// @step: check the secret exists
 		found, err := kubernetes.GetIfExists(ctx, c.cc, secret)
 		if err != nil {
-			cond.Failed(err, "Failed to get terraform plan secret (%s/%s)", c.ControllerNamespace, secret.Name)
+			cond.Failed(err, "Failed to get terraform plan secret (%s/%s)", secret.Namespace, secret.Name)
 
 			return reconcile.Result{}, err
 		}
 		if !found {
-			cond.Failed(nil, "Terraform plan secret (%s/%s) not found", c.ControllerNamespace, secret.Name)
+			cond.Failed(nil, "Terraform plan secret (%s/%s) not found", secret.Namespace, secret.Name)
 
 			return reconcile.Result{}, controller.ErrIgnore
 		}
@@ -1057,7 +1142,7 @@ func (c *Controller) ensureCostStatus(configuration *terraformv1alpha1.Configura
 		}
 
 		secret := &v1.Secret{}
-		secret.Namespace = c.ControllerNamespace
+		secret.Namespace = c.jobNamespace(configuration)
 		secret.Name = configuration.GetTerraformCostSecretName()
 
 		found, err := kubernetes.GetIfExists(ctx, c.cc, secret)
@@ -1151,7 +1236,7 @@ func (c *Controller) ensurePolicyStatus(configuration *terraformv1alpha1.Configu
 
 		// @step: retrieve the uploaded scan
 		secret := &v1.Secret{}
-		secret.Namespace = c.ControllerNamespace
+		secret.Namespace = c.jobNamespace(configuration)
 		secret.Name = configuration.GetTerraformPolicySecretName()
 
 		found, err := kubernetes.GetIfExists(ctx, c.cc, secret)
@@ -1161,7 +1246,7 @@ func (c *Controller) ensurePolicyStatus(configuration *terraformv1alpha1.Configu
 			return reconcile.Result{}, err
 		}
 		if !found {
-			cond.Warning("Failed to find the secret: (%s/%s) containing checkov scan", c.ControllerNamespace, configuration.GetTerraformPolicySecretName())
+			cond.Warning("Failed to find the secret: (%s/%s) containing checkov scan", secret.Namespace, configuration.GetTerraformPolicySecretName())
 
 			return reconcile.Result{RequeueAfter: 10 * time.Minute}, nil
 		}
@@ -1271,7 +1356,7 @@ func (c *Controller) ensureTerraformApply(configuration *terraformv1alpha1.Confi
 
 	return func(ctx context.Context) (reconcile.Result, error) {
 		// @step: retrieve the terraform state secret, if it exists
-		_, found, err := kubernetes.GetSecretIfExists(ctx, c.cc, c.ControllerNamespace, configuration.GetTerraformStateSecretName())
+		_, found, err := kubernetes.GetSecretIfExists(ctx, c.cc, c.jobNamespace(configuration), configuration.GetTerraformStateSecretName())
 		if err != nil {
 			cond.Failed(err, "Failed to get terraform state secret")
 
@@ -1329,20 +1414,29 @@ func (c *Controller) ensureTerraformApply(configuration *terraformv1alpha1.Confi
 				},
 			),
 			BackoffLimit:                 c.BackoffLimit,
-			BinaryPath:                   c.BinaryPath,
+			BinaryPath:                   c.engineBinaryPath(state.engine),
+			CacheVolume:                  c.jobCacheVolume(),
 			DefaultExecutorCPULimit:      c.DefaultExecutorCPULimit,
 			DefaultExecutorCPURequest:    c.DefaultExecutorCPURequest,
 			DefaultExecutorMemoryLimit:   c.DefaultExecutorMemoryLimit,
 			DefaultExecutorMemoryRequest: c.DefaultExecutorMemoryRequest,
 			EnableInfraCosts:             c.EnableInfracosts,
+			Engine:                       state.engine,
 			ExecutorImage:                c.ExecutorImage,
 			ExecutorSecrets:              c.ExecutorSecrets,
+			Hooks:                        state.hooks,
 			InfracostsImage:              c.InfracostsImage,
 			InfracostsSecret:             c.InfracostsSecretName,
-			Namespace:                    c.ControllerNamespace,
+			Namespace:                    c.jobNamespace(configuration),
+			PodTemplateOverrides:         state.podTemplateOverrides,
 			SaveTerraformState:           saveState,
+			ProviderMirrorCASecret:       c.ProviderMirrorCASecret,
+			ProviderMirrorURL:            c.ProviderMirrorURL,
+			SourceAllowedHosts:           c.SourceAllowedHosts,
+			SourceAllowedSchemes:         c.SourceAllowedSchemes,
+			SourceSigningKeys:            c.SourceSigningKeys,
 			Template:                     state.jobTemplate,
-			Image:                        GetTerraformImage(configuration, c.TerraformImage),
+			Image:                        c.engineImage(state.engine, state.version),
 		})
 		if err != nil {
 			cond.Failed(err, "Failed to create the terraform apply job")
@@ -1387,13 +1481,25 @@ func (c *Controller) ensureTerraformApply(configuration *terraformv1alpha1.Confi
 		// @step: we only shift out of this state of the job is complete
 		switch {
 		case jobs.IsComplete(job):
+			c.captureBuildLogs(ctx, configuration, job, terraformv1alpha1.StageTerraformApply)
+			c.captureStepResults(ctx, configuration, job, terraformv1alpha1.StageTerraformApply)
 			configuration.Status.ResourceStatus = terraformv1alpha1.ResourcesInSync
+			configuration.Status.StateLock = nil
+			if engine, found := job.GetLabels()[terraformv1alpha1.ConfigurationEngineLabel]; found {
+				configuration.Status.Engine = engine
+			}
 
-			cond.Success("Terraform apply is complete")
+			cond.Success("Terraform apply is complete%s", describeIgnoredHooks(configuration))
 			return reconcile.Result{}, nil
 
 		case jobs.IsFailed(job):
-			cond.Failed(nil, "Terraform apply has failed")
+			c.captureBuildLogs(ctx, configuration, job, terraformv1alpha1.StageTerraformApply)
+			c.captureStepResults(ctx, configuration, job, terraformv1alpha1.StageTerraformApply)
+			if hook, found := findFailedHook(configuration); found {
+				cond.Failed(nil, "Terraform apply has failed, the hook %q failed", hook)
+			} else {
+				cond.Failed(nil, "Terraform apply has failed")
+			}
 
 			return c.ensureErrorDetection(configuration, job, state)(ctx)
 
@@ -1412,16 +1518,16 @@ func (c *Controller) ensureConnectionSecret(configuration *terraformv1alpha1.Con
 	return func(ctx context.Context) (reconcile.Result, error) {
 		secret := &v1.Secret{}
 		secret.Name = configuration.GetTerraformStateSecretName()
-		secret.Namespace = c.ControllerNamespace
+		secret.Namespace = c.jobNamespace(configuration)
 
 		found, err := kubernetes.GetIfExists(ctx, c.cc, secret)
 		if err != nil {
-			cond.Failed(err, "Failed to get terraform state secret (%s/%s)", c.ControllerNamespace, secret.Name)
+			cond.Failed(err, "Failed to get terraform state secret (%s/%s)", secret.Namespace, secret.Name)
 
 			return reconcile.Result{}, err
 		}
 		if !found {
-			cond.Failed(nil, "Terraform state secret (%s/%s) not found", c.ControllerNamespace, secret.Name)
+			cond.Failed(nil, "Terraform state secret (%s/%s) not found", secret.Namespace, secret.Name)
 
 			return reconcile.Result{}, controller.ErrIgnore
 		}
@@ -24,6 +24,10 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
 	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
@@ -33,12 +37,119 @@ import (
 // GetTerraformImage is called to return the terraform image to use, or the image plus version
 // override
 func GetTerraformImage(configuration *terraformv1alpha1.Configuration, image string) string {
-	if configuration.Spec.TerraformVersion == "" {
+	return GetEngineImage(image, configuration.Spec.TerraformVersion)
+}
+
+// GetEngineImage returns the image with the tag replaced by the version, or the image as is
+// when no version is provided
+func GetEngineImage(image, version string) string {
+	if version == "" {
 		return image
 	}
 	e := strings.Split(image, ":")
 
-	return fmt.Sprintf("%s:%s", e[0], configuration.Spec.TerraformVersion)
+	return fmt.Sprintf("%s:%s", e[0], version)
+}
+
+// GetEngine returns the engine used to execute the configuration, which is taken from the
+// configuration, the provider or else the default engine
+func GetEngine(configuration *terraformv1alpha1.Configuration, provider *terraformv1alpha1.Provider, engine string) string {
+	switch {
+	case configuration.Spec.Engine != "":
+		return configuration.Spec.Engine
+	case provider != nil && provider.Spec.Engine != "":
+		return provider.Spec.Engine
+	}
+
+	return engine
+}
+
+// GetEngineVersion returns the version of the engine used to execute the configuration, which
+// is either the resolved version or the tag of the image
+func GetEngineVersion(version, image string) string {
+	if version != "" {
+		return version
+	}
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return ""
+	}
+
+	return image[i+1:]
+}
+
+// engineBinaryPath returns the path of the binary for the engine
+func (c *Controller) engineBinaryPath(engine string) string {
+	if engine == terraformv1alpha1.EngineOpenTofu {
+		return c.OpenTofuBinaryPath
+	}
+
+	return c.BinaryPath
+}
+
+// engineImage returns the image for the engine, including any version override
+func (c *Controller) engineImage(engine, version string) string {
+	if engine == terraformv1alpha1.EngineOpenTofu {
+		return GetEngineImage(c.OpenTofuImage, version)
+	}
+
+	return GetEngineImage(c.TerraformImage, version)
+}
+
+// engineVersions returns the versions of the engine made available by the administrator
+func (c *Controller) engineVersions(engine string) []string {
+	if engine == terraformv1alpha1.EngineOpenTofu {
+		return c.OpenTofuVersions
+	}
+
+	return c.TerraformVersions
+}
+
+// jobNamespace returns the namespace the jobs, configuration secrets and state of the configuration
+// are placed in, which is either the controller namespace or the namespace of the configuration
+func (c *Controller) jobNamespace(configuration *terraformv1alpha1.Configuration) string {
+	if c.EnableNamespacedJobs {
+		return configuration.Namespace
+	}
+
+	return c.ControllerNamespace
+}
+
+// jobCacheVolume returns the name of the cache volume mounted by the jobs. The claim resides in the
+// controller namespace, hence the cache is not available to jobs run in the configuration namespace
+func (c *Controller) jobCacheVolume() string {
+	if c.EnableNamespacedJobs {
+		return ""
+	}
+
+	return c.CacheVolume
+}
+
+// isJobNamespace checks if the object resides in a namespace the jobs are run in
+func (c *Controller) isJobNamespace(object client.Object) bool {
+	if object.GetNamespace() == c.ControllerNamespace {
+		return true
+	}
+	if !c.EnableNamespacedJobs {
+		return false
+	}
+	_, found := object.GetLabels()[terraformv1alpha1.ConfigurationUIDLabel]
+
+	return found
+}
+
+// jobListOptions returns the options used to list the jobs of all the configurations. When the jobs
+// are run in the namespace of the configuration, we select on the labels across all namespaces,
+// excluding the watchers which share the labels of the build
+func (c *Controller) jobListOptions() []client.ListOption {
+	if !c.EnableNamespacedJobs {
+		return []client.ListOption{client.InNamespace(c.ControllerNamespace)}
+	}
+
+	owned, _ := labels.NewRequirement(terraformv1alpha1.ConfigurationUIDLabel, selection.Exists, nil)
+	watcher, _ := labels.NewRequirement(terraformv1alpha1.JobWatcherLabel, selection.DoesNotExist, nil)
+
+	return []client.ListOption{client.MatchingLabelsSelector{Selector: labels.NewSelector().Add(*owned, *watcher)}}
 }
 
 // getNamespaceFromCache is responsible for retrieving the namespace from the cache, or deferring
@@ -67,6 +178,20 @@ func (c *Controller) getNamespaceFromCache(ctx context.Context, name string) (*v
 	return namespace, nil
 }
 
+// isPermittedContextSecret checks if the configuration is permitted to consume the sensitive context variable
+func (c *Controller) isPermittedContextSecret(ctx context.Context, configuration *terraformv1alpha1.Configuration, secret terraformv1alpha1.ContextSecret) (bool, error) {
+	if secret.Selector == nil {
+		return true, nil
+	}
+
+	namespace, err := c.getNamespaceFromCache(ctx, configuration.Namespace)
+	if err != nil {
+		return false, err
+	}
+
+	return kubernetes.IsSelectorMatch(*secret.Selector, configuration.GetLabels(), namespace.GetLabels())
+}
+
 // CreateWatcher is responsible for ensuring the logger is running in the application namespace
 func (c Controller) CreateWatcher(ctx context.Context, configuration *terraformv1alpha1.Configuration, stage string) error {
 	watcher := jobs.New(configuration, nil).NewJobWatch(c.ControllerNamespace, stage, c.ExecutorImage)
@@ -80,5 +205,20 @@ func (c Controller) CreateWatcher(ctx context.Context, configuration *terraformv
 		return nil
 	}
 
+	// @step: ensure the service account used by the watcher exists
+	account := &v1.ServiceAccount{}
+	account.Namespace = configuration.Namespace
+	account.Name = jobs.WatcherServiceAccount
+
+	found, err = kubernetes.GetIfExists(ctx, c.cc, account)
+	if err != nil {
+		return err
+	}
+	if !found {
+		if err := c.cc.Create(ctx, account); err != nil && !kerrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+
 	return c.cc.Create(ctx, watcher)
 }
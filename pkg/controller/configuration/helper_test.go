@@ -49,3 +49,63 @@ func TestGetTerraformImage(t *testing.T) {
 		assert.Equal(t, c.Expected, GetTerraformImage(config, c.Default))
 	}
 }
+
+func TestGetEngine(t *testing.T) {
+	cases := []struct {
+		Configuration string
+		Provider      string
+		Expected      string
+	}{
+		{
+			Expected: terraformv1alpha1.EngineOpenTofu,
+		},
+		{
+			Provider: terraformv1alpha1.EngineTerraform,
+			Expected: terraformv1alpha1.EngineTerraform,
+		},
+		{
+			Configuration: terraformv1alpha1.EngineOpenTofu,
+			Provider:      terraformv1alpha1.EngineTerraform,
+			Expected:      terraformv1alpha1.EngineOpenTofu,
+		},
+	}
+
+	for _, c := range cases {
+		config := &terraformv1alpha1.Configuration{}
+		config.Spec.Engine = c.Configuration
+		provider := &terraformv1alpha1.Provider{}
+		provider.Spec.Engine = c.Provider
+
+		assert.Equal(t, c.Expected, GetEngine(config, provider, terraformv1alpha1.EngineOpenTofu))
+	}
+}
+
+func TestGetEngineVersion(t *testing.T) {
+	cases := []struct {
+		Image    string
+		Override string
+		Expected string
+	}{
+		{
+			Image:    "ghcr.io/opentofu/opentofu:1.8.5",
+			Expected: "1.8.5",
+		},
+		{
+			Image:    "ghcr.io/opentofu/opentofu:1.8.5",
+			Override: "1.6.0",
+			Expected: "1.6.0",
+		},
+		{
+			Image:    "registry:5000/opentofu",
+			Expected: "",
+		},
+		{
+			Image:    "registry:5000/opentofu:latest",
+			Expected: "latest",
+		},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.Expected, GetEngineVersion(c.Override, c.Image))
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package configuration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/core/v1alpha1"
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/controller"
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
+)
+
+// ensureHooks is responsible for collecting the hooks to execute within the jobs, these are the
+// hooks defined on the configuration followed by those injected by any matching policies. As the
+// hook names form the container names and policy hooks must always run, a configuration hook
+// sharing the name of a policy hook is rejected
+func (c *Controller) ensureHooks(configuration *terraformv1alpha1.Configuration, state *state) controller.EnsureFunc {
+	cond := controller.ConditionMgr(configuration, corev1alpha1.ConditionReady, c.recorder)
+
+	return func(ctx context.Context) (reconcile.Result, error) {
+		if len(configuration.Spec.Hooks) > 0 && !c.EnableConfigurationHooks {
+			cond.ActionRequired("Configuration hooks have been disabled, contact a platform administrator")
+
+			return reconcile.Result{}, controller.ErrIgnore
+		}
+
+		hooks := append([]terraformv1alpha1.Hook{}, configuration.Spec.Hooks...)
+		names := make(map[string]bool)
+		for _, x := range hooks {
+			names[x.Name] = true
+		}
+		injected := make(map[string]bool)
+
+		if state.policies != nil && len(state.policies.Items) > 0 {
+			namespace, err := c.getNamespaceFromCache(ctx, configuration.Namespace)
+			if err != nil {
+				cond.Failed(err, "Failed to retrieve the namespace")
+
+				return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
+			}
+
+			for i := 0; i < len(state.policies.Items); i++ {
+				for _, x := range state.policies.Items[i].Spec.Hooks {
+					match := len(x.Selector.Modules) == 0 && x.Selector.Namespace == nil
+
+					if !match && len(x.Selector.Modules) > 0 {
+						if match, err = x.Selector.IsModulesMatch(configuration); err != nil {
+							cond.Failed(err, "Failed to check against the policy: %q", state.policies.Items[i].Name)
+
+							return reconcile.Result{}, err
+						}
+					}
+					if !match && x.Selector.Namespace != nil {
+						if namespace == nil {
+							cond.Failed(errors.New("namespace missing from cache"), "Failed to retrieve the namespace from the cache")
+
+							return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
+						}
+						if match, err = x.Selector.IsLabelsMatch(namespace); err != nil {
+							cond.Failed(err, "Failed to check against the policy: %q", state.policies.Items[i].Name)
+
+							return reconcile.Result{}, err
+						}
+					}
+					if !match {
+						continue
+					}
+					for _, hook := range x.Hooks {
+						switch {
+						case injected[hook.Name]:
+							continue
+						case names[hook.Name]:
+							cond.ActionRequired("Hook %q conflicts with a hook defined by the policy: %q", hook.Name, state.policies.Items[i].Name)
+
+							return reconcile.Result{}, controller.ErrIgnore
+						}
+						hooks = append(hooks, hook)
+						injected[hook.Name] = true
+					}
+				}
+			}
+		}
+		state.hooks = hooks
+
+		return reconcile.Result{}, nil
+	}
+}
+
+// describeIgnoredHooks returns a description of the hooks which failed in the last job but were
+// permitted to continue, or an empty string when there are none
+func describeIgnoredHooks(configuration *terraformv1alpha1.Configuration) string {
+	if configuration.Status.Steps == nil {
+		return ""
+	}
+
+	var list []string
+	for _, x := range configuration.Status.Steps.Steps {
+		if x.Ignored && strings.HasPrefix(x.Name, jobs.HookContainerPrefix) {
+			list = append(list, strings.TrimPrefix(x.Name, jobs.HookContainerPrefix))
+		}
+	}
+	if len(list) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(", ignoring the failed hooks: %s", strings.Join(list, ", "))
+}
+
+// findFailedHook returns the name of the hook which failed the last job, if any
+func findFailedHook(configuration *terraformv1alpha1.Configuration) (string, bool) {
+	if configuration.Status.Steps == nil {
+		return "", false
+	}
+	if !strings.HasPrefix(configuration.Status.Steps.FailedStep, jobs.HookContainerPrefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(configuration.Status.Steps.FailedStep, jobs.HookContainerPrefix), true
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package configuration
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/appvia/terranetes-controller/pkg/agent"
+	corev1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/core/v1alpha1"
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/controller"
+	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+	"github.com/appvia/terranetes-controller/pkg/utils/logstore"
+)
+
+// captureBuildLogs is responsible for retaining the logs of a finished job within the log store,
+// once captured the job is annotated to ensure we do not capture them again. Failures are logged
+// but do not block the reconciliation
+func (c *Controller) captureBuildLogs(ctx context.Context, configuration *terraformv1alpha1.Configuration, job *batchv1.Job, stage string) {
+	if c.LogStore == nil || job == nil || job.GetAnnotations()[terraformv1alpha1.BuildLogsAnnotation] == "true" {
+		return
+	}
+
+	logger := log.WithFields(log.Fields{
+		"job":       job.Name,
+		"name":      configuration.Name,
+		"namespace": configuration.Namespace,
+		"stage":     stage,
+	})
+
+	logs, err := c.retrieveBuildLogs(ctx, configuration, job)
+	if err != nil {
+		logger.WithError(err).Error("failed to retrieve the build logs")
+
+		return
+	}
+
+	key := logstore.Key{
+		Generation: configuration.GetGeneration(),
+		Name:       configuration.Name,
+		Namespace:  configuration.Namespace,
+		Stage:      stage,
+		UID:        string(configuration.GetUID()),
+	}
+	if err := c.LogStore.Save(ctx, key, logs); err != nil {
+		logger.WithError(err).Error("failed to save the build logs")
+
+		return
+	}
+
+	original := job.DeepCopy()
+	if job.Annotations == nil {
+		job.Annotations = map[string]string{}
+	}
+	job.Annotations[terraformv1alpha1.BuildLogsAnnotation] = "true"
+
+	if err := c.cc.Patch(ctx, job, client.MergeFrom(original)); err != nil {
+		logger.WithError(err).Error("failed to annotate the job with the build logs")
+	}
+}
+
+// retrieveBuildLogs returns the logs of all the containers of the latest pod for the job, or the
+// logs reported by the agent when the job was run by a remote agent
+func (c *Controller) retrieveBuildLogs(ctx context.Context, configuration *terraformv1alpha1.Configuration, job *batchv1.Job) ([]byte, error) {
+	if agent.IsAgentJob(job) {
+		return c.agentJobLogs(ctx, job)
+	}
+
+	pods, err := c.kc.CoreV1().Pods(c.jobNamespace(configuration)).List(ctx, metav1.ListOptions{
+		LabelSelector: "job-name=" + job.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods, %w", err)
+	}
+	pod := kubernetes.FindLatestPod(pods)
+	if pod == nil {
+		return nil, errors.New("no pod found for the job")
+	}
+
+	logs := &bytes.Buffer{}
+	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		stream, err := c.kc.CoreV1().Pods(c.jobNamespace(configuration)).GetLogs(pod.Name, &v1.PodLogOptions{
+			Container: container.Name,
+		}).Stream(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve the logs of container %q, %w", container.Name, err)
+		}
+		_, err = io.Copy(logs, stream)
+		stream.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the logs of container %q, %w", container.Name, err)
+		}
+	}
+
+	return logs.Bytes(), nil
+}
+
+// agentJobLogs returns the logs reported by the agent for a job run by a remote agent
+func (c *Controller) agentJobLogs(ctx context.Context, job *batchv1.Job) ([]byte, error) {
+	secret, found, err := kubernetes.GetSecretIfExists(ctx, c.cc, job.Namespace, agent.LogsSecretName(job.Name))
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return secret.Data[agent.LogsSecretKey], nil
+}
+
+// ensureBuildLogsDeleted is responsible for removing any retained build logs for the configuration
+func (c *Controller) ensureBuildLogsDeleted(configuration *terraformv1alpha1.Configuration) controller.EnsureFunc {
+	cond := controller.ConditionMgr(configuration, corev1alpha1.ConditionReady, c.recorder)
+
+	return func(ctx context.Context) (reconcile.Result, error) {
+		if c.LogStore == nil {
+			return reconcile.Result{}, nil
+		}
+
+		if err := c.LogStore.Delete(ctx, logstore.Key{
+			Name:      configuration.Name,
+			Namespace: configuration.Namespace,
+			UID:       string(configuration.GetUID()),
+		}); err != nil {
+			cond.Failed(err, "Failed to delete the build logs")
+
+			return reconcile.Result{}, err
+		}
+
+		return reconcile.Result{}, nil
+	}
+}
@@ -0,0 +1,265 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package configuration
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/core/v1alpha1"
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/controller"
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
+	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+)
+
+// executorRules are the permissions required by the service account running the jobs, i.e. to
+// upload the reports and maintain the state via the kubernetes backend
+var executorRules = []rbacv1.PolicyRule{
+	{
+		APIGroups: []string{"coordination.k8s.io"},
+		Resources: []string{"leases"},
+		Verbs:     []string{"create", "delete", "get", "list", "update", "watch"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"configmaps"},
+		Verbs:     []string{"get", "list", "watch"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"secrets"},
+		Verbs:     []string{"create", "delete", "get", "list", "patch", "update", "watch"},
+	},
+}
+
+// ensureJobNamespace is responsible for preparing the namespace of the configuration to run the jobs
+// when namespaced jobs are enabled. This ensures the service account and permissions used by the jobs,
+// and the credentials consumed by the jobs exist within the namespace. Credentials are never copied
+// out of the controller namespace, only the certificate authority and public signing keys are
+func (c *Controller) ensureJobNamespace(configuration *terraformv1alpha1.Configuration, state *state) controller.EnsureFunc {
+	cond := controller.ConditionMgr(configuration, corev1alpha1.ConditionReady, c.recorder)
+
+	return func(ctx context.Context) (reconcile.Result, error) {
+		if !c.EnableNamespacedJobs {
+			return reconcile.Result{}, nil
+		}
+		namespace := c.jobNamespace(configuration)
+
+		// @step: ensure the service account used to run the jobs
+		account := jobs.DefaultServiceAccount
+		if name := ptr.Deref(state.provider.Spec.ServiceAccount, ""); name != "" {
+			account = name
+
+			sa := &v1.ServiceAccount{}
+			sa.Namespace = namespace
+			sa.Name = name
+
+			found, err := kubernetes.GetIfExists(ctx, c.cc, sa)
+			if err != nil {
+				cond.Failed(err, "Failed to retrieve the provider service account (%s/%s)", namespace, name)
+
+				return reconcile.Result{}, err
+			}
+			if !found {
+				cond.ActionRequired("Provider service account (%s/%s) does not exist, contact platform administrator", namespace, name)
+
+				return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+			}
+		} else {
+			sa := &v1.ServiceAccount{}
+			sa.Namespace = namespace
+			sa.Name = jobs.DefaultServiceAccount
+
+			found, err := kubernetes.GetIfExists(ctx, c.cc, sa)
+			if err != nil {
+				cond.Failed(err, "Failed to retrieve the executor service account")
+
+				return reconcile.Result{}, err
+			}
+			if !found {
+				if err := c.cc.Create(ctx, sa); err != nil {
+					cond.Failed(err, "Failed to create the executor service account")
+
+					return reconcile.Result{}, err
+				}
+			}
+		}
+
+		// @step: ensure the permissions for the service account
+		role := &rbacv1.Role{}
+		role.Namespace = namespace
+		role.Name = jobs.DefaultServiceAccount
+		role.Rules = executorRules
+
+		if err := kubernetes.CreateOrForceUpdate(ctx, c.cc, role); err != nil {
+			cond.Failed(err, "Failed to provision the executor role")
+
+			return reconcile.Result{}, err
+		}
+
+		binding := &rbacv1.RoleBinding{}
+		binding.Namespace = namespace
+		binding.Name = jobs.DefaultServiceAccount
+		if account != jobs.DefaultServiceAccount {
+			binding.Name = jobs.DefaultServiceAccount + "-" + account
+		}
+		binding.RoleRef = rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     role.Name,
+		}
+		binding.Subjects = []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: account, Namespace: namespace}}
+
+		if err := kubernetes.CreateOrForceUpdate(ctx, c.cc, binding); err != nil {
+			cond.Failed(err, "Failed to provision the executor role binding")
+
+			return reconcile.Result{}, err
+		}
+
+		// @step: the provider credentials must be owned by the namespace, else the provider must use
+		// an injected identity i.e. workload identity via the service account
+		if state.provider.Spec.Source == terraformv1alpha1.SourceSecret && state.provider.Spec.SecretRef != nil {
+			name := state.provider.Spec.SecretRef.Name
+
+			found, err := c.hasJobSecret(ctx, namespace, name)
+			if err != nil {
+				cond.Failed(err, "Failed to retrieve the provider secret (%s/%s)", namespace, name)
+
+				return reconcile.Result{}, err
+			}
+			if !found {
+				cond.ActionRequired("Provider secret (%s/%s) does not exist, jobs in the configuration namespace require "+
+					"the provider credentials within the namespace, or a provider using an injected identity", namespace, name)
+
+				return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+			}
+		}
+
+		// @step: the checkov sources must also be owned by the namespace, while the cost analytics and
+		// policy default secrets are checked in the namespace the jobs are run in beforehand
+		var required []string
+		if policy := state.checkovConstraint; policy != nil {
+			if policy.Source != nil && policy.Source.SecretRef != nil && policy.Source.SecretRef.Name != "" {
+				required = append(required, policy.Source.SecretRef.Name)
+			}
+			for _, x := range policy.External {
+				if x.SecretRef != nil && x.SecretRef.Name != "" {
+					required = append(required, x.SecretRef.Name)
+				}
+			}
+		}
+		for _, name := range required {
+			found, err := c.hasJobSecret(ctx, namespace, name)
+			if err != nil {
+				cond.Failed(err, "Failed to retrieve the secret (%s/%s)", namespace, name)
+
+				return reconcile.Result{}, err
+			}
+			if !found {
+				cond.ActionRequired("Secret (%s/%s) required by the jobs does not exist, jobs in the configuration "+
+					"namespace require the credentials within the namespace, contact platform administrator", namespace, name)
+
+				return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+			}
+		}
+
+		// @step: copy the certificate authority and signing keys, which only hold public material
+		for _, name := range []string{c.ProviderMirrorCASecret, c.SourceSigningKeys} {
+			if name == "" {
+				continue
+			}
+			if result, err := c.copyJobSecret(ctx, configuration, name); err != nil || !result.IsZero() {
+				return result, err
+			}
+		}
+
+		return reconcile.Result{}, nil
+	}
+}
+
+// hasJobSecret checks the secret exists within the namespace the jobs are run in, and is owned by the
+// namespace rather than copied from the controller namespace
+func (c *Controller) hasJobSecret(ctx context.Context, namespace, name string) (bool, error) {
+	secret := &v1.Secret{}
+	secret.Namespace = namespace
+	secret.Name = name
+
+	found, err := kubernetes.GetIfExists(ctx, c.cc, secret)
+	if err != nil || !found {
+		return false, err
+	}
+
+	return secret.GetLabels()[terraformv1alpha1.JobSecretCopyLabel] == "", nil
+}
+
+// copyJobSecret is responsible for copying a secret holding public material, such as a certificate
+// authority, from the controller namespace into the namespace the jobs are run in. Secrets in the job
+// namespace which were not copied by the controller are never overwritten
+func (c *Controller) copyJobSecret(ctx context.Context, configuration *terraformv1alpha1.Configuration, name string) (reconcile.Result, error) {
+	cond := controller.ConditionMgr(configuration, corev1alpha1.ConditionReady, c.recorder)
+
+	source := &v1.Secret{}
+	source.Namespace = c.ControllerNamespace
+	source.Name = name
+
+	found, err := kubernetes.GetIfExists(ctx, c.cc, source)
+	if err != nil {
+		cond.Failed(err, "Failed to retrieve the secret (%s/%s)", source.Namespace, source.Name)
+
+		return reconcile.Result{}, err
+	}
+	if !found {
+		cond.ActionRequired("Secret (%s/%s) required by the jobs does not exist, contact platform administrator", source.Namespace, source.Name)
+
+		return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+	}
+
+	secret := &v1.Secret{}
+	secret.Namespace = c.jobNamespace(configuration)
+	secret.Name = name
+
+	found, err = kubernetes.GetIfExists(ctx, c.cc, secret)
+	if err != nil {
+		cond.Failed(err, "Failed to retrieve the secret (%s/%s)", secret.Namespace, secret.Name)
+
+		return reconcile.Result{}, err
+	}
+	if found && secret.GetLabels()[terraformv1alpha1.JobSecretCopyLabel] == "" {
+		cond.ActionRequired("Secret (%s/%s) already exists and is not managed by the controller", secret.Namespace, secret.Name)
+
+		return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+	}
+
+	secret.Labels = map[string]string{terraformv1alpha1.JobSecretCopyLabel: c.ControllerNamespace}
+	secret.Data = source.Data
+	secret.Type = source.Type
+
+	if err := kubernetes.CreateOrForceUpdate(ctx, c.cc, secret); err != nil {
+		cond.Failed(err, "Failed to copy the secret (%s/%s) into the job namespace", source.Namespace, source.Name)
+
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
+}
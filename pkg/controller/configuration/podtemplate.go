@@ -0,0 +1,88 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package configuration
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/core/v1alpha1"
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/controller"
+)
+
+// ensurePodTemplateOverrides is responsible for collecting the partial pod templates which are
+// merged onto the pod template of the jobs. These are the overrides from any matching policies,
+// followed by the override on the provider, which takes precedence as it is merged last
+func (c *Controller) ensurePodTemplateOverrides(configuration *terraformv1alpha1.Configuration, state *state) controller.EnsureFunc {
+	cond := controller.ConditionMgr(configuration, corev1alpha1.ConditionReady, c.recorder)
+
+	return func(ctx context.Context) (reconcile.Result, error) {
+		var overrides [][]byte
+
+		if state.policies != nil && len(state.policies.Items) > 0 {
+			namespace, err := c.getNamespaceFromCache(ctx, configuration.Namespace)
+			if err != nil {
+				cond.Failed(err, "Failed to retrieve the namespace")
+
+				return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
+			}
+
+			for i := 0; i < len(state.policies.Items); i++ {
+				for _, x := range state.policies.Items[i].Spec.PodTemplateOverrides {
+					match := len(x.Selector.Modules) == 0 && x.Selector.Namespace == nil
+
+					if !match && len(x.Selector.Modules) > 0 {
+						if match, err = x.Selector.IsModulesMatch(configuration); err != nil {
+							cond.Failed(err, "Failed to check against the policy: %q", state.policies.Items[i].Name)
+
+							return reconcile.Result{}, err
+						}
+					}
+					if !match && x.Selector.Namespace != nil {
+						if namespace == nil {
+							cond.Failed(errors.New("namespace missing from cache"), "Failed to retrieve the namespace from the cache")
+
+							return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
+						}
+						if match, err = x.Selector.IsLabelsMatch(namespace); err != nil {
+							cond.Failed(err, "Failed to check against the policy: %q", state.policies.Items[i].Name)
+
+							return reconcile.Result{}, err
+						}
+					}
+					if match && len(x.Template.Raw) > 0 {
+						overrides = append(overrides, x.Template.Raw)
+					}
+				}
+			}
+		}
+
+		if state.provider != nil && state.provider.Spec.PodTemplateOverrides != nil {
+			if len(state.provider.Spec.PodTemplateOverrides.Raw) > 0 {
+				overrides = append(overrides, state.provider.Spec.PodTemplateOverrides.Raw)
+			}
+		}
+		state.podTemplateOverrides = overrides
+
+		return reconcile.Result{}, nil
+	}
+}
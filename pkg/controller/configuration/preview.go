@@ -0,0 +1,184 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package configuration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/controller"
+	"github.com/appvia/terranetes-controller/pkg/utils"
+	"github.com/appvia/terranetes-controller/pkg/utils/filters"
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
+	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+	"github.com/appvia/terranetes-controller/pkg/utils/terraform"
+)
+
+// ensureVersionPreview is responsible for planning the configuration under another version of the
+// engine when requested via the preview annotation. The plan is never applied, the outcome is
+// recorded on the status so we can check which configurations would change before upgrading
+func (c *Controller) ensureVersionPreview(configuration *terraformv1alpha1.Configuration, state *state) controller.EnsureFunc {
+	generation := fmt.Sprintf("%d", configuration.GetGeneration())
+
+	return func(ctx context.Context) (reconcile.Result, error) {
+		requested := configuration.GetAnnotations()[terraformv1alpha1.PreviewVersionAnnotation]
+		preview := configuration.Status.Preview
+
+		switch {
+		case requested == "":
+			configuration.Status.Preview = nil
+
+			return reconcile.Result{}, nil
+
+		case preview != nil && preview.Version == requested &&
+			preview.Generation == configuration.GetGeneration() &&
+			preview.Status != terraformv1alpha1.PreviewInProgress:
+
+			return reconcile.Result{}, nil
+		}
+
+		status := &terraformv1alpha1.PreviewStatus{
+			Generation: configuration.GetGeneration(),
+			Status:     terraformv1alpha1.PreviewInProgress,
+			Version:    requested,
+		}
+		configuration.Status.Preview = status
+
+		// @step: resolve the version and ensure we are not attempting a downgrade
+		version, err := terraform.ResolveVersion(requested, c.engineVersions(state.engine))
+		if err != nil {
+			status.Status = terraformv1alpha1.PreviewFailed
+			status.Message = fmt.Sprintf("Unable to resolve the %s version %q, %s", terraform.EngineName(state.engine), requested, err)
+
+			return reconcile.Result{}, nil
+		}
+		if err := terraform.IsVersionCompatible(state.engine, configuration.Status.TerraformVersion, version); err != nil {
+			status.Status = terraformv1alpha1.PreviewFailed
+			status.Message = fmt.Sprintf("Unable to downgrade the %s version, %s", terraform.EngineName(state.engine), err)
+
+			return reconcile.Result{}, nil
+		}
+
+		status.Message = fmt.Sprintf("Terraform plan under version %s in progress", version)
+
+		// @step: search for any current preview jobs
+		job, found := filters.Jobs(state.jobs).
+			WithGeneration(generation).
+			WithLabel(terraformv1alpha1.JobPreviewVersionLabel, version).
+			WithName(configuration.GetName()).
+			WithNamespace(configuration.GetNamespace()).
+			WithStage(terraformv1alpha1.StageTerraformPlan).
+			WithUID(string(configuration.GetUID())).
+			Latest()
+
+		if !found {
+			// @note: we wait for any running jobs to finish, these will requeue the configuration
+			if filters.Jobs(state.jobs).WithUID(string(configuration.GetUID())).IsRunning() > 0 {
+				return reconcile.Result{}, nil
+			}
+
+			options := jobs.Options{
+				AdditionalJobAnnotations: state.provider.JobAnnotations(),
+				AdditionalJobSecrets:     state.additionalJobSecrets,
+				AdditionalJobLabels: utils.MergeStringMaps(
+					c.ControllerJobLabels,
+					state.provider.JobLabels(),
+					configuration.GetLabels(),
+					map[string]string{
+						terraformv1alpha1.JobPreviewVersionLabel: version,
+						terraformv1alpha1.JobTemplateHashLabel:   state.jobTemplateHash,
+					}),
+				BackoffLimit:                 c.BackoffLimit,
+				BinaryPath:                   c.engineBinaryPath(state.engine),
+				CacheVolume:                  c.jobCacheVolume(),
+				DefaultExecutorCPULimit:      c.DefaultExecutorCPULimit,
+				DefaultExecutorCPURequest:    c.DefaultExecutorCPURequest,
+				DefaultExecutorMemoryLimit:   c.DefaultExecutorMemoryLimit,
+				DefaultExecutorMemoryRequest: c.DefaultExecutorMemoryRequest,
+				Engine:                       state.engine,
+				ExecutorImage:                c.ExecutorImage,
+				ExecutorSecrets:              c.ExecutorSecrets,
+				Image:                        c.engineImage(state.engine, version),
+				Namespace:                    c.jobNamespace(configuration),
+				PodTemplateOverrides:         state.podTemplateOverrides,
+				Preview:                      true,
+				ProviderMirrorCASecret:       c.ProviderMirrorCASecret,
+				ProviderMirrorURL:            c.ProviderMirrorURL,
+				SourceAllowedHosts:           c.SourceAllowedHosts,
+				SourceAllowedSchemes:         c.SourceAllowedSchemes,
+				SourceSigningKeys:            c.SourceSigningKeys,
+				Template:                     state.jobTemplate,
+			}
+
+			runner, err := jobs.New(configuration, state.provider).NewTerraformPlan(options)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			if err := c.cc.Create(ctx, runner); err != nil {
+				return reconcile.Result{}, err
+			}
+
+			return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+
+		switch {
+		case jobs.IsComplete(job):
+			secret := &v1.Secret{}
+			secret.Name = configuration.GetTerraformPreviewPlanJSONSecretName()
+			secret.Namespace = c.jobNamespace(configuration)
+
+			if found, err := kubernetes.GetIfExists(ctx, c.cc, secret); err != nil {
+				return reconcile.Result{}, err
+			} else if !found {
+				status.Status = terraformv1alpha1.PreviewFailed
+				status.Message = fmt.Sprintf("Terraform plan secret (%s/%s) not found", secret.Namespace, secret.Name)
+
+				return reconcile.Result{}, nil
+			}
+
+			tfplan, err := terraform.DecodePlan(secret.Data[terraformv1alpha1.TerraformPlanJSONSecretKey])
+			if err != nil {
+				status.Status = terraformv1alpha1.PreviewFailed
+				status.Message = fmt.Sprintf("Failed to decode the terraform plan, %s", err)
+
+				return reconcile.Result{}, nil
+			}
+			status.Changes = tfplan.NeedsApply()
+			status.Status = terraformv1alpha1.PreviewComplete
+			status.Message = fmt.Sprintf("Terraform plan under version %s has no changes", version)
+			if status.Changes {
+				status.Message = fmt.Sprintf("Terraform plan under version %s has changes", version)
+			}
+
+			return reconcile.Result{}, nil
+
+		case jobs.IsFailed(job):
+			status.Status = terraformv1alpha1.PreviewFailed
+			status.Message = fmt.Sprintf("Terraform plan under version %s has failed", version)
+
+			return reconcile.Result{}, nil
+		}
+
+		return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+}
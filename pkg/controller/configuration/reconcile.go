@@ -40,8 +40,17 @@ type state struct {
 	checkovConstraint *terraformv1alpha1.PolicyConstraint
 	// revision is the Revision we are based from
 	revision *terraformv1alpha1.Revision
+	// engine is the engine used to execute the configuration, i.e. terraform or opentofu
+	engine string
+	// version is the resolved version of the engine, empty when using the default image
+	version string
 	// hasDrift is a flag to indicate if the configuration has drift
 	hasDrift bool
+	// hooks is the collection of hooks from the configuration and any matching policies
+	hooks []terraformv1alpha1.Hook
+	// podTemplateOverrides is the collection of partial pod templates from any matching policies
+	// and the provider, merged onto the pod template of the jobs in order
+	podTemplateOverrides [][]byte
 	// backendTemplate is the template to use for the terraform state backend.
 	// We always default this to the kubernetes backend
 	backendTemplate string
@@ -56,10 +65,13 @@ type state struct {
 	// jobTemplateHash is an hex encoded SHA hash for the job template
 	jobTemplateHash string
 	// additionalJobSecrets is a collection of additional secrets to job - these secrets
-	// must reside in the same namespace as the controller
+	// must reside in the namespace the jobs are run in
 	additionalJobSecrets []string
 	// valueFrom is a map of keys to values
 	valueFrom map[string]interface{}
+	// valueFromSecrets is a map of keys to sensitive context references, these are
+	// only resolved when building the job configuration secret
+	valueFromSecrets map[string]terraformv1alpha1.ContextSecretKeyReference
 	// tfstate is the secret containing the terraform state
 	tfstate *v1.Secret
 	// tfplan is the secret containing the terraform plan
@@ -91,7 +103,11 @@ func (c *Controller) Reconcile(ctx context.Context, request reconcile.Request) (
 		).Set(status)
 	}()
 
-	state := &state{valueFrom: make(map[string]interface{}), backendTemplate: terraform.KubernetesBackendTemplate}
+	state := &state{
+		backendTemplate:  terraform.KubernetesBackendTemplate,
+		valueFrom:        make(map[string]interface{}),
+		valueFromSecrets: make(map[string]terraformv1alpha1.ContextSecretKeyReference),
+	}
 
 	finalizer := controller.NewFinalizer(c.cc, controllerName)
 	if finalizer.IsDeletionCandidate(configuration) {
@@ -101,12 +117,17 @@ func (c *Controller) Reconcile(ctx context.Context, request reconcile.Request) (
 				c.ensureProviderReady(configuration, state),
 				c.ensureCustomBackendTemplate(configuration, state),
 				c.ensurePolicyDefaultsExist(configuration, state),
+				c.ensureHooks(configuration, state),
+				c.ensurePodTemplateOverrides(configuration, state),
+				c.ensureEngine(configuration, state),
 				c.ensureValueFromSecret(configuration, state),
 				c.ensureAuthenticationSecret(configuration, state),
 				c.ensureCustomJobTemplate(configuration, state),
 				c.ensureJobConfigurationSecret(configuration, state),
+				c.ensureJobNamespace(configuration, state),
 				c.ensureTerraformDestroy(configuration, state),
 				c.ensureConfigurationSecretsDeleted(configuration),
+				c.ensureBuildLogsDeleted(configuration),
 				c.ensureConfigurationJobsDeleted(configuration),
 				finalizer.EnsureRemoved(configuration),
 			})
@@ -136,7 +157,13 @@ func (c *Controller) Reconcile(ctx context.Context, request reconcile.Request) (
 			c.ensureProviderReady(configuration, state),
 			c.ensureCustomBackendTemplate(configuration, state),
 			c.ensurePolicyDefaultsExist(configuration, state),
+			c.ensureHooks(configuration, state),
+			c.ensurePodTemplateOverrides(configuration, state),
+			c.ensureEngine(configuration, state),
 			c.ensureJobConfigurationSecret(configuration, state),
+			c.ensureJobNamespace(configuration, state),
+			c.ensureStateUnlock(configuration, state),
+			c.ensureVersionPreview(configuration, state),
 			c.ensureTerraformPlan(configuration, state),
 			c.ensureTerraformPlanSecret(configuration, state),
 			c.ensureCostStatus(configuration),
@@ -30,6 +30,7 @@ import (
 	"github.com/sirupsen/logrus"
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -39,11 +40,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"github.com/appvia/terranetes-controller/pkg/agent"
 	corev1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/core/v1alpha1"
 	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
 	"github.com/appvia/terranetes-controller/pkg/controller"
 	"github.com/appvia/terranetes-controller/pkg/schema"
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
 	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+	"github.com/appvia/terranetes-controller/pkg/utils/logstore"
 	controllertests "github.com/appvia/terranetes-controller/test"
 	"github.com/appvia/terranetes-controller/test/fixtures"
 )
@@ -61,18 +65,22 @@ func makeFakeController(cc client.Client) *Controller {
 		cache:                        cache.New(5*time.Minute, 10*time.Minute),
 		recorder:                     recorder,
 		BackoffLimit:                 2,
-		BinaryPath:                   "/usr/local/bin/tofu",
+		BinaryPath:                   "/bin/terraform",
 		ControllerNamespace:          "terraform-system",
+		DefaultEngine:                terraformv1alpha1.EngineOpenTofu,
 		DefaultExecutorCPULimit:      "1",
 		DefaultExecutorCPURequest:    "5m",
 		DefaultExecutorMemoryLimit:   "1Gi",
 		DefaultExecutorMemoryRequest: "32Mi",
+		EnableConfigurationHooks:     true,
 		EnableInfracosts:             false,
 		EnableWatchers:               true,
 		ExecutorImage:                "ghcr.io/appvia/terranetes-executor",
 		InfracostsImage:              "infracosts/infracost:latest",
+		OpenTofuBinaryPath:           "/usr/local/bin/tofu",
+		OpenTofuImage:                "ghcr.io/opentofu/opentofu:latest",
 		PolicyImage:                  "bridgecrew/checkov:2.0.1140",
-		TerraformImage:               "ghcr.io/opentofu/opentofu:latest",
+		TerraformImage:               "hashicorp/terraform:latest",
 	}
 
 	return ctrl
@@ -697,6 +705,28 @@ var _ = Describe("Configuration Controller with Contexts", func() {
 				})
 			})
 
+			Context("and a namespace context exists without the key", func() {
+				BeforeEach(func() {
+					local := terraformv1alpha1.NewNamespaceContext(namespace, "default")
+					local.Spec.Variables = map[string]runtime.RawExtension{
+						"other": {Raw: []byte(`{"description": "other", "value": "other"}`)},
+					}
+					Expect(cc.Create(context.Background(), local)).To(Succeed())
+					Expect(cc.Create(context.Background(), configuration)).To(Succeed())
+
+					result, _, rerr = controllertests.Roll(context.Background(), ctrl, configuration, 0)
+				})
+
+				It("should indicate the key is missing", func() {
+					Expect(cc.Get(context.Background(), configuration.GetNamespacedName(), configuration)).To(Succeed())
+
+					cond := configuration.GetCommonStatus().GetCondition(corev1alpha1.ConditionReady)
+					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+					Expect(cond.Message).To(Equal("spec.valueFrom[0] does not contain key: foo"))
+				})
+			})
+
 			Context("and the context is required", func() {
 				BeforeEach(func() {
 					configuration.Spec.ValueFrom[0].Optional = false
@@ -797,6 +827,117 @@ var _ = Describe("Configuration Controller with Contexts", func() {
 					Expect(string(secret.Data[terraformv1alpha1.TerraformVariablesConfigMapKey])).To(Equal(expected))
 				})
 			})
+
+			Context("and a namespace context defines the value", func() {
+				BeforeEach(func() {
+					local := terraformv1alpha1.NewNamespaceContext(namespace, "default")
+					local.Spec.Variables = map[string]runtime.RawExtension{
+						"foo": {Raw: []byte(`{"description": "foo", "value": "should_be_namespace"}`)},
+					}
+					Expect(cc.Create(context.Background(), local)).To(Succeed())
+					Expect(cc.Create(context.Background(), configuration)).To(Succeed())
+
+					result, _, rerr = controllertests.Roll(context.Background(), ctrl, configuration, 0)
+				})
+
+				It("should not error", func() {
+					Expect(rerr).ToNot(HaveOccurred())
+					Expect(result.Requeue).To(BeFalse())
+				})
+
+				It("should take precedence over the cluster context", func() {
+					secret := &v1.Secret{}
+					secret.Namespace = ctrl.ControllerNamespace
+					secret.Name = configuration.GetTerraformConfigSecretName()
+
+					expected := "{\"complex\":[\"subnet0\",\"subnet1\",\"subnet2\"],\"hello\":\"world\",\"test\":\"should_be_namespace\"}\n"
+
+					found, err := kubernetes.GetIfExists(context.Background(), cc, secret)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeTrue())
+					Expect(string(secret.Data[terraformv1alpha1.TerraformVariablesConfigMapKey])).To(Equal(expected))
+				})
+			})
+
+			Context("and the value is a sensitive variable", func() {
+				BeforeEach(func() {
+					txt := fixtures.NewTerranettesContext("default")
+					Expect(cc.Get(context.Background(), txt.GetNamespacedName(), txt)).To(Succeed())
+					delete(txt.Spec.Variables, "foo")
+					txt.Spec.Secrets = map[string]terraformv1alpha1.ContextSecret{
+						"foo": {
+							Description: "foo",
+							SecretRef:   terraformv1alpha1.ContextSecretKeyReference{Name: "sensitive", Key: "password"},
+						},
+					}
+					Expect(cc.Update(context.Background(), txt)).To(Succeed())
+
+					sensitive := &v1.Secret{}
+					sensitive.Namespace = ctrl.ControllerNamespace
+					sensitive.Name = "sensitive"
+					sensitive.Data = map[string][]byte{"password": []byte("should_be_secret")}
+					Expect(cc.Create(context.Background(), sensitive)).To(Succeed())
+				})
+
+				Context("and the configuration is permitted", func() {
+					BeforeEach(func() {
+						Expect(cc.Create(context.Background(), configuration)).To(Succeed())
+
+						result, _, rerr = controllertests.Roll(context.Background(), ctrl, configuration, 0)
+					})
+
+					It("should not error", func() {
+						Expect(rerr).ToNot(HaveOccurred())
+						Expect(result.Requeue).To(BeFalse())
+					})
+
+					It("should have the sensitive variable in the job configuration secret", func() {
+						secret := &v1.Secret{}
+						secret.Namespace = ctrl.ControllerNamespace
+						secret.Name = configuration.GetTerraformConfigSecretName()
+
+						expected := "{\"complex\":[\"subnet0\",\"subnet1\",\"subnet2\"],\"hello\":\"world\",\"test\":\"should_be_secret\"}\n"
+
+						found, err := kubernetes.GetIfExists(context.Background(), cc, secret)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(found).To(BeTrue())
+						Expect(string(secret.Data[terraformv1alpha1.TerraformVariablesConfigMapKey])).To(Equal(expected))
+					})
+				})
+
+				Context("and the configuration is not permitted", func() {
+					BeforeEach(func() {
+						txt := fixtures.NewTerranettesContext("default")
+						Expect(cc.Get(context.Background(), txt.GetNamespacedName(), txt)).To(Succeed())
+						sensitive := txt.Spec.Secrets["foo"]
+						sensitive.Selector = &terraformv1alpha1.Selector{
+							Namespace: &metav1.LabelSelector{MatchLabels: map[string]string{"name": "other"}},
+						}
+						txt.Spec.Secrets["foo"] = sensitive
+						Expect(cc.Update(context.Background(), txt)).To(Succeed())
+
+						Expect(cc.Create(context.Background(), configuration)).To(Succeed())
+
+						result, _, rerr = controllertests.Roll(context.Background(), ctrl, configuration, 0)
+					})
+
+					It("should not create any jobs", func() {
+						list := &batchv1.JobList{}
+
+						Expect(cc.List(context.Background(), list, client.InNamespace(ctrl.ControllerNamespace))).To(Succeed())
+						Expect(list.Items).To(BeEmpty())
+					})
+
+					It("should have appropriate conditions", func() {
+						Expect(cc.Get(context.Background(), configuration.GetNamespacedName(), configuration)).To(Succeed())
+
+						cond := configuration.GetCommonStatus().GetCondition(corev1alpha1.ConditionReady)
+						Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+						Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+						Expect(cond.Message).To(Equal("spec.valueFrom[0] is not permitted to use the sensitive key: foo"))
+					})
+				})
+			})
 		})
 	})
 })
@@ -909,10 +1050,13 @@ var _ = Describe("Configuration Controller", func() {
 
 	verifyPolicyArguments := []string{
 		"--comment=Evaluating Against Security Policy",
+		"--name=policy",
 		"--command=/usr/local/bin/checkov --config /run/checkov/checkov.yaml --framework terraform_plan -f /run/tfplan.json --soft-fail -o json -o cli --output-file-path /run --repo-root-for-plan-enrichment /data --download-external-modules true >/dev/null",
 		"--command=/bin/cat /run/results_cli.txt",
 		"--namespace=$(KUBE_NAMESPACE)",
 		"--upload=$(POLICY_REPORT_NAME)=/run/results_json.json",
+		"--results=/run/steps/results.json",
+		"--results-secret=$(STEP_RESULTS_NAME)",
 		"--is-failure=/run/steps/terraform.failed",
 		"--wait-on=/run/steps/terraform.complete",
 	}
@@ -937,18 +1081,22 @@ var _ = Describe("Configuration Controller", func() {
 			kc:                           kfake.NewSimpleClientset(),
 			cache:                        cache.New(5*time.Minute, 10*time.Minute),
 			recorder:                     recorder,
-			BinaryPath:                   "/usr/local/bin/tofu",
+			BinaryPath:                   "/bin/terraform",
+			DefaultEngine:                terraformv1alpha1.EngineOpenTofu,
 			DefaultExecutorCPULimit:      "1",
 			DefaultExecutorCPURequest:    "5m",
 			DefaultExecutorMemoryLimit:   "1Gi",
 			DefaultExecutorMemoryRequest: "32Mi",
+			EnableConfigurationHooks:     true,
 			EnableInfracosts:             false,
 			EnableWatchers:               true,
 			ExecutorImage:                "ghcr.io/appvia/terranetes-executor",
 			InfracostsImage:              "infracosts/infracost:latest",
 			ControllerNamespace:          "default",
+			OpenTofuBinaryPath:           "/usr/local/bin/tofu",
+			OpenTofuImage:                "ghcr.io/opentofu/opentofu:latest",
 			PolicyImage:                  "bridgecrew/checkov:2.0.1140",
-			TerraformImage:               "ghcr.io/opentofu/opentofu:latest",
+			TerraformImage:               "hashicorp/terraform:latest",
 		}
 		ctrl.cache.SetDefault(cfgNamespace, fixtures.NewNamespace(cfgNamespace))
 	}
@@ -1449,610 +1597,1068 @@ var _ = Describe("Configuration Controller", func() {
 		})
 	})
 
-	// COSTS
-	When("predicted costs is enabled", func() {
-		When("the costs token is missing", func() {
+	// ENGINE
+	When("configuration has an engine", func() {
+		When("the engine is defined on the configuration", func() {
 			BeforeEach(func() {
 				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				configuration.Spec.Engine = terraformv1alpha1.EngineTerraform
 				Setup(configuration)
-				ctrl.EnableInfracosts = true
-				ctrl.InfracostsSecretName = "not_there"
-
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 2)
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 			})
 
-			It("should have the conditions", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
 			})
 
-			It("should indicate the costs analytics token is invalid", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+			It("should have created the job using the engine", func() {
+				list := &batchv1.JobList{}
 
-				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
-				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
-				Expect(cond.Message).To(Equal("Cost analytics secret (default/not_there) does not exist, contact platform administrator"))
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+				Expect(list.Items[0].Labels).To(HaveKeyWithValue(terraformv1alpha1.ConfigurationEngineLabel, terraformv1alpha1.EngineTerraform))
+				Expect(list.Items[0].Spec.Template.Spec.Containers[0].Image).To(Equal("hashicorp/terraform:latest"))
+				Expect(list.Items[0].Spec.Template.Spec.Containers[0].Args).To(ContainElement(
+					"--command=/bin/terraform plan --var-file variables.tfvars.json -out=/run/plan.out -lock=false -no-color -input=false",
+				))
 			})
+		})
 
-			It("should have raised a event", func() {
-				Expect(recorder.Events).ToNot(BeEmpty())
-				Expect(recorder.Events[0]).To(ContainSubstring("Cost analytics secret (default/not_there) does not exist, contact platform administrator"))
+		When("the engine is defined on the provider", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				Setup(configuration)
+
+				provider := &terraformv1alpha1.Provider{}
+				provider.Name = configuration.Spec.ProviderRef.Name
+				Expect(cc.Get(context.TODO(), provider.GetNamespacedName(), provider)).To(Succeed())
+				provider.Spec.Engine = terraformv1alpha1.EngineTerraform
+				Expect(cc.Update(context.TODO(), provider)).To(Succeed())
+
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 			})
 
-			It("should not create any jobs", func() {
+			It("should have created the job using the provider engine", func() {
 				list := &batchv1.JobList{}
 
 				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(0))
+				Expect(len(list.Items)).To(Equal(1))
+				Expect(list.Items[0].Labels).To(HaveKeyWithValue(terraformv1alpha1.ConfigurationEngineLabel, terraformv1alpha1.EngineTerraform))
+				Expect(list.Items[0].Spec.Template.Spec.Containers[0].Image).To(Equal("hashicorp/terraform:latest"))
 			})
 		})
 
-		When("cost token is invalid", func() {
+		When("no engine is defined", func() {
 			BeforeEach(func() {
 				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-				secret := &v1.Secret{}
-				secret.Name = "token"
-				secret.Namespace = ctrl.ControllerNamespace
+				Setup(configuration)
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			})
 
-				Setup(configuration, secret)
-				ctrl.EnableInfracosts = true
-				ctrl.InfracostsSecretName = "token"
+			It("should have created the job using the default engine", func() {
+				list := &batchv1.JobList{}
 
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 2)
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+				Expect(list.Items[0].Labels).To(HaveKeyWithValue(terraformv1alpha1.ConfigurationEngineLabel, terraformv1alpha1.EngineOpenTofu))
+				Expect(list.Items[0].Spec.Template.Spec.Containers[0].Image).To(Equal("ghcr.io/opentofu/opentofu:latest"))
 			})
+		})
 
-			It("should have the conditions", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+		When("switching the engine and the state cannot be read by the new engine", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				configuration.Spec.Engine = terraformv1alpha1.EngineOpenTofu
+				configuration.Spec.TerraformVersion = "1.0.0"
+				configuration.Status.Engine = terraformv1alpha1.EngineTerraform
+				tfstate := fixtures.NewTerraformState(configuration)
+				tfstate.Namespace = ctrl.ControllerNamespace
+
+				Setup(configuration, tfstate)
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 			})
 
-			It("should indicate the costs analytics token is invalid", func() {
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
+			})
+
+			It("should indicate the engine cannot be switched", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
 				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
 				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
 				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
-				Expect(cond.Message).To(Equal("Cost analytics secret (default/token) does not contain a token, contact platform administrator"))
-			})
-
-			It("should have raised a event", func() {
-				Expect(recorder.Events).ToNot(BeEmpty())
-				Expect(recorder.Events[0]).To(ContainSubstring("Cost analytics secret (default/token) does not contain a token, contact platform administrator"))
+				Expect(cond.Message).To(Equal("Unable to switch the engine from terraform to opentofu, state was written by Terraform v1.1.9, which is newer than OpenTofu v1.0.0"))
 			})
 
-			It("should not create any jobs", func() {
+			It("should not have created a job", func() {
 				list := &batchv1.JobList{}
 
 				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(0))
+				Expect(list.Items).To(BeEmpty())
 			})
 		})
 
-		When("configuration has successful run a plan but no cost report", func() {
+		When("switching the engine and the state can be read by the new engine", func() {
 			BeforeEach(func() {
 				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				configuration.Spec.Engine = terraformv1alpha1.EngineOpenTofu
+				configuration.Spec.TerraformVersion = "1.8.5"
+				configuration.Status.Engine = terraformv1alpha1.EngineTerraform
+				tfstate := fixtures.NewTerraformState(configuration)
+				tfstate.Namespace = ctrl.ControllerNamespace
 
-				// create two successful plan
-				plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
-				plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
-				plan.Status.Succeeded = 1
-				// create fake terraform state
-				state := fixtures.NewTerraformState(configuration)
-				state.Namespace = ctrl.ControllerNamespace
-				// create fake costs secret
-				token := fixtures.NewCostsSecret(ctrl.ControllerNamespace, "infracost")
-				token.Namespace = ctrl.ControllerNamespace
-				// create fake plan secret
-				tfplan := fixtures.NewTerraformPlanWithDiff(configuration, ctrl.ControllerNamespace)
-
-				Setup(configuration, plan, state, token, tfplan)
-				ctrl.EnableInfracosts = true
-				ctrl.InfracostsSecretName = "infracost"
-
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 10)
+				Setup(configuration, tfstate)
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 			})
 
-			It("the cost status should be empty", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+			It("should have created the job using the new engine", func() {
+				list := &batchv1.JobList{}
 
-				Expect(configuration.Status.Costs).ToNot(BeNil())
-				Expect(configuration.Status.Costs.Enabled).To(BeFalse())
-				Expect(configuration.Status.Costs.Monthly).To(BeEmpty())
-				Expect(configuration.Status.Costs.Hourly).To(BeEmpty())
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+				Expect(list.Items[0].Spec.Template.Spec.Containers[0].Image).To(Equal("ghcr.io/opentofu/opentofu:1.8.5"))
 			})
 		})
 
-		When("configuration has successful run a plan", func() {
+		When("the terraform apply has completed", func() {
 			BeforeEach(func() {
 				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-
-				// create two successful plan
 				plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
 				plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
 				plan.Status.Succeeded = 1
-				// create fake terraform state
-				state := fixtures.NewTerraformState(configuration)
-				state.Namespace = ctrl.ControllerNamespace
-				// create fake costs report
-				report := fixtures.NewCostsReport(configuration)
-				report.Namespace = ctrl.ControllerNamespace
-				// create fake costs secret
-				token := fixtures.NewCostsSecret(ctrl.ControllerNamespace, "infracost")
-				token.Namespace = ctrl.ControllerNamespace
-				// create fake plan secret
 				tfplan := fixtures.NewTerraformPlanWithDiff(configuration, ctrl.ControllerNamespace)
 
-				Setup(configuration, plan, state, report, token, tfplan)
-				ctrl.EnableInfracosts = true
-				ctrl.InfracostsSecretName = "infracost"
-
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 10)
-			})
+				apply := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformApply)
+				apply.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+				apply.Status.Succeeded = 1
+				apply.Labels[terraformv1alpha1.JobPlanIDLabel] = fixtures.TFPlanID
+				apply.Labels[terraformv1alpha1.ConfigurationEngineLabel] = terraformv1alpha1.EngineTerraform
 
-			It("should indicate the costs is enabled and costs available on the status", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				tfstate := fixtures.NewTerraformState(configuration)
+				tfstate.Namespace = ctrl.ControllerNamespace
 
-				Expect(configuration.Status.Costs).ToNot(BeNil())
-				Expect(configuration.Status.Costs.Enabled).To(BeTrue())
-				Expect(configuration.Status.Costs.Monthly).To(Equal("$100"))
-				Expect(configuration.Status.Costs.Hourly).To(Equal("$0.01"))
+				Setup(configuration, plan, apply, tfstate, tfplan)
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 			})
 
-			It("should have copied the secret into the configuration namespace", func() {
-				expected := "\n{\n\t\"totalHourlyCost\": \"0.01\",\n  \"totalMonthlyCost\": \"100.00\"\n}\n"
-				secret := &v1.Secret{}
-				secret.Namespace = configuration.Namespace
-				secret.Name = configuration.GetTerraformCostSecretName()
-				found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
+			})
 
-				Expect(err).ToNot(HaveOccurred())
-				Expect(found).To(BeTrue())
-				Expect(secret.Data).To(HaveKey("costs.json"))
-				Expect(string(secret.Data["costs.json"])).To(Equal(expected))
+			It("should record the engine which wrote the state", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.Engine).To(Equal(terraformv1alpha1.EngineTerraform))
 			})
 		})
 	})
 
-	// VALUEFROM FIELDS
-	When("the configuration has valueFrom definitions", func() {
-		When("using values from the valueFrom field", func() {
+	// AGENTS
+	When("configuration is using a provider with an agent", func() {
+		var remote *agent.Fake
+
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			Setup(configuration)
+
+			provider := &terraformv1alpha1.Provider{}
+			provider.Name = configuration.Spec.ProviderRef.Name
+			Expect(cc.Get(context.TODO(), provider.GetNamespacedName(), provider)).To(Succeed())
+			provider.Spec.Agent = "remote"
+			Expect(cc.Update(context.TODO(), provider)).To(Succeed())
+
+			remote = &agent.Fake{
+				Broker:    agent.NewBroker(cc, ctrl.ControllerNamespace),
+				Name:      "remote",
+				Providers: []string{provider.Name},
+			}
+		})
+
+		When("the plan has been scheduled", func() {
 			BeforeEach(func() {
-				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-				Setup()
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 			})
 
-			When("secret is missing and not optional", func() {
-				BeforeEach(func() {
-					configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{
-						{Secret: ptr.To("missing"), Key: "key"},
-					}
-					Expect(ctrl.cc.Create(context.TODO(), configuration)).ToNot(HaveOccurred())
-					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
-				})
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
+			})
 
-				It("should have the conditions", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-				})
+			It("should have created a suspended job for the agent", func() {
+				list := &batchv1.JobList{}
 
-				It("should indicate the failure on the conditions", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+				Expect(list.Items[0].Labels).To(HaveKeyWithValue(terraformv1alpha1.JobAgentLabel, "remote"))
+				Expect(list.Items[0].Labels).To(HaveKeyWithValue(terraformv1alpha1.JobProviderLabel, "aws"))
+				Expect(list.Items[0].Spec.Suspend).To(Equal(ptr.To(true)))
+				Expect(list.Items[0].Spec.ManagedBy).To(Equal(ptr.To(terraformv1alpha1.JobAgentManager)))
+			})
 
-					cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
-					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
-					Expect(cond.Message).To(Equal("spec.valueFrom[0].secret (apps/missing) does not exist"))
-					Expect(cond.Detail).To(Equal(""))
-				})
+			It("should indicate the plan is running", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				It("should not create any jobs", func() {
-					list := &batchv1.JobList{}
+				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
+				Expect(cond.Message).To(Equal("Terraform plan is running"))
+			})
+		})
 
-					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-					Expect(len(list.Items)).To(Equal(0))
-				})
+		When("the agent has completed the plan", func() {
+			BeforeEach(func() {
+				_, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+				Expect(rerr).ToNot(HaveOccurred())
+				Expect(remote.Sync(context.TODO())).To(Succeed())
+
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 			})
 
-			When("secret is missing but optional", func() {
-				BeforeEach(func() {
-					configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{
-						{Secret: ptr.To("missing"), Key: "key", Optional: true},
-					}
-					Expect(ctrl.cc.Create(context.TODO(), configuration)).ToNot(HaveOccurred())
-					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
-				})
-
-				It("should have created a job", func() {
-					list := &batchv1.JobList{}
-					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-
-					Expect(len(list.Items)).To(Equal(1))
-				})
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
 			})
 
-			When("key is missing and not optional", func() {
-				BeforeEach(func() {
-					secret := &v1.Secret{}
-					secret.Namespace = configuration.Namespace
-					secret.Name = "exists"
+			It("should have sent the job to the agent", func() {
+				Expect(remote.Assignments).To(HaveLen(1))
+			})
 
-					configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{{Secret: ptr.To("exists"), Key: "missing"}}
-					Expect(ctrl.cc.Create(context.TODO(), configuration)).ToNot(HaveOccurred())
-					Expect(ctrl.cc.Create(context.TODO(), secret)).ToNot(HaveOccurred())
+			It("should indicate the plan is complete", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
-				})
+				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
+				Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonReady))
+				Expect(cond.Message).To(Equal("Terraform plan is complete"))
+			})
+		})
 
-				It("should have the conditions", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-				})
+		When("the agent has failed the plan", func() {
+			BeforeEach(func() {
+				_, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+				Expect(rerr).ToNot(HaveOccurred())
 
-				It("should indicate the failure on the conditions", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				remote.Phase = agent.PhaseFailed
+				remote.Logs = []byte("Error: failed to plan\n")
+				Expect(remote.Sync(context.TODO())).To(Succeed())
 
-					cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
-					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
-					Expect(cond.Message).To(Equal(`spec.valueFrom[0] (apps/exists) does not contain key: "missing"`))
-				})
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			})
 
-				It("should not create any jobs", func() {
-					list := &batchv1.JobList{}
+			It("should indicate the plan has failed", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-					Expect(len(list.Items)).To(Equal(0))
-				})
+				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonError))
+				Expect(cond.Message).To(Equal("Terraform plan is failed"))
 			})
 
-			When("key is missing but optional", func() {
-				BeforeEach(func() {
-					configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{
-						{Secret: ptr.To("missing"), Key: "key", Optional: true},
-					}
-					Expect(ctrl.cc.Create(context.TODO(), configuration)).ToNot(HaveOccurred())
-					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
-				})
+			It("should have recorded the logs reported by the agent", func() {
+				secret := &v1.Secret{}
+				secret.Namespace = ctrl.ControllerNamespace
 
-				It("should have the conditions", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-				})
+				list := &batchv1.JobList{}
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(list.Items).To(HaveLen(1))
+				secret.Name = agent.LogsSecretName(list.Items[0].Name)
 
-				It("should have created a job", func() {
-					list := &batchv1.JobList{}
+				Expect(cc.Get(context.TODO(), client.ObjectKeyFromObject(secret), secret)).To(Succeed())
+				Expect(string(secret.Data[agent.LogsSecretKey])).To(Equal("Error: failed to plan\n"))
+			})
+		})
+	})
 
-					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-					Expect(len(list.Items)).To(Equal(1))
-				})
+	// VERSIONS
+	When("configuration has a version", func() {
+		When("the version is a constraint", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				configuration.Spec.TerraformVersion = "~> 1.7"
+				Setup(configuration)
+				ctrl.OpenTofuVersions = []string{"1.6.2", "1.7.3", "1.8.5"}
 
-				It("should have the conditions", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-				})
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			})
 
-				It("should have created a job", func() {
-					list := &batchv1.JobList{}
-					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-					Expect(len(list.Items)).To(Equal(1))
-				})
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
 			})
 
-			When("secret and key exist", func() {
-				BeforeEach(func() {
-					secret := &v1.Secret{}
-					secret.Namespace = configuration.Namespace
-					secret.Name = "exists"
-					secret.Data = map[string][]byte{"my": []byte("value")}
+			It("should have created the job using the latest matching version", func() {
+				list := &batchv1.JobList{}
 
-					configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{{Secret: ptr.To("exists"), Key: "my"}}
-					Expect(ctrl.cc.Create(context.TODO(), configuration)).ToNot(HaveOccurred())
-					Expect(ctrl.cc.Create(context.TODO(), secret)).ToNot(HaveOccurred())
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+				Expect(list.Items[0].Spec.Template.Spec.Containers[0].Image).To(Equal("ghcr.io/opentofu/opentofu:1.8.5"))
+			})
+		})
 
-					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 5)
-				})
+		When("no available version satisfies the constraint", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				configuration.Spec.TerraformVersion = "~> 2.0"
+				Setup(configuration)
+				ctrl.OpenTofuVersions = []string{"1.6.2", "1.7.3", "1.8.5"}
 
-				It("should have the conditions", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-				})
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			})
 
-				It("should indicate the plan is in progress", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
+			})
 
-					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
-					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
-				})
+			It("should indicate the version cannot be resolved", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				It("should have created a job", func() {
-					list := &batchv1.JobList{}
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(cond.Message).To(Equal("Unable to resolve the OpenTofu version \"~> 2.0\", no available version satisfies the constraint \"~> 2.0\""))
+			})
 
-					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-					Expect(len(list.Items)).To(Equal(1))
-				})
+			It("should not have created a job", func() {
+				list := &batchv1.JobList{}
 
-				It("should have added the value to the configuration config", func() {
-					expected := "{\"my\":\"value\",\"name\":\"test\"}\n"
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(list.Items).To(BeEmpty())
+			})
+		})
 
-					secret := &v1.Secret{}
-					secret.Namespace = ctrl.ControllerNamespace
-					secret.Name = configuration.GetTerraformConfigSecretName()
+		When("the version is older than the version which wrote the state", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				configuration.Spec.TerraformVersion = "1.6.0"
+				configuration.Status.TerraformVersion = "1.8.5"
+				Setup(configuration)
 
-					found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
-					Expect(err).ToNot(HaveOccurred())
-					Expect(found).To(BeTrue())
-					Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformVariablesConfigMapKey))
-					Expect(string(secret.Data[terraformv1alpha1.TerraformVariablesConfigMapKey])).To(Equal(expected))
-				})
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 			})
 
-			When("secret and key exist and we are remapping", func() {
-				BeforeEach(func() {
-					secret := &v1.Secret{}
-					secret.Namespace = configuration.Namespace
-					secret.Name = "exists"
-					secret.Data = map[string][]byte{"DB_HOST": []byte("value")}
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
+			})
 
-					configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{{Secret: ptr.To("exists"), Key: "DB_HOST", Name: "database_host"}}
-					Expect(ctrl.cc.Create(context.TODO(), configuration)).ToNot(HaveOccurred())
-					Expect(ctrl.cc.Create(context.TODO(), secret)).ToNot(HaveOccurred())
+			It("should indicate the version cannot be downgraded", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 5)
-				})
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(cond.Message).To(Equal("Unable to downgrade the OpenTofu version, state was written by v1.8.5, which is newer than OpenTofu v1.6.0"))
+			})
 
-				It("should have the conditions", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-				})
+			It("should not have created a job", func() {
+				list := &batchv1.JobList{}
 
-				It("should indicate the plan is in progress", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(list.Items).To(BeEmpty())
+			})
+		})
 
-					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
-					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
-				})
+		When("a preview of another version has been requested", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				configuration.Annotations = map[string]string{terraformv1alpha1.PreviewVersionAnnotation: "~> 1.8"}
+				Setup(configuration)
+				ctrl.OpenTofuVersions = []string{"1.7.3", "1.8.5"}
 
-				It("should have created a job", func() {
-					list := &batchv1.JobList{}
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			})
 
-					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-					Expect(len(list.Items)).To(Equal(1))
-				})
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
+			})
 
-				It("should have added the value to the configuration config", func() {
-					expected := "{\"database_host\":\"value\",\"name\":\"test\"}\n"
+			It("should have created a preview plan job", func() {
+				list := &batchv1.JobList{}
 
-					secret := &v1.Secret{}
-					secret.Namespace = ctrl.ControllerNamespace
-					secret.Name = configuration.GetTerraformConfigSecretName()
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+				Expect(list.Items[0].Labels).To(HaveKeyWithValue(terraformv1alpha1.JobPreviewVersionLabel, "1.8.5"))
+				Expect(list.Items[0].Labels).To(HaveKeyWithValue(terraformv1alpha1.ConfigurationStageLabel, terraformv1alpha1.StageTerraformPlan))
+				Expect(list.Items[0].Spec.Template.Spec.Containers[0].Image).To(Equal("ghcr.io/opentofu/opentofu:1.8.5"))
+			})
 
-					found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
-					Expect(err).ToNot(HaveOccurred())
-					Expect(found).To(BeTrue())
-					Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformVariablesConfigMapKey))
-					Expect(string(secret.Data[terraformv1alpha1.TerraformVariablesConfigMapKey])).To(Equal(expected))
-				})
+			It("should indicate the preview is in progress", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.Preview).ToNot(BeNil())
+				Expect(configuration.Status.Preview.Status).To(Equal(terraformv1alpha1.PreviewInProgress))
+				Expect(configuration.Status.Preview.Version).To(Equal("~> 1.8"))
+				Expect(configuration.Status.Preview.Message).To(Equal("Terraform plan under version 1.8.5 in progress"))
 			})
 		})
-	})
 
-	// ADDITIONAL SECRETS
-	When("the controller has been configured with additional secrets", func() {
-		BeforeEach(func() {
-			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-			Setup(configuration)
+		When("the preview of another version has completed", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				configuration.Annotations = map[string]string{terraformv1alpha1.PreviewVersionAnnotation: "1.8.5"}
 
-			ctrl.ExecutorSecrets = []string{"secret1", "secret2"}
-			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 4)
-		})
+				preview := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
+				preview.Labels[terraformv1alpha1.JobPreviewVersionLabel] = "1.8.5"
+				preview.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+				preview.Status.Succeeded = 1
 
-		It("should have the conditions", func() {
-			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-			Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-		})
+				tfplan := fixtures.NewTerraformPlanWithDiff(configuration, ctrl.ControllerNamespace)
+				tfplan.Name = configuration.GetTerraformPreviewPlanJSONSecretName()
 
-		It("should indicate the failure on the conditions", func() {
-			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Setup(configuration, preview, tfplan)
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			})
 
-			cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionProviderReady)
-			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
-			Expect(cond.Reason).To(Equal(corev1alpha1.ReasonReady))
-			Expect(cond.Message).To(Equal("Provider ready"))
-		})
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
+			})
 
-		It("should have create a plan", func() {
-			list := &batchv1.JobList{}
+			It("should record the outcome of the preview", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.Preview).ToNot(BeNil())
+				Expect(configuration.Status.Preview.Status).To(Equal(terraformv1alpha1.PreviewComplete))
+				Expect(configuration.Status.Preview.Changes).To(BeTrue())
+				Expect(configuration.Status.Preview.Message).To(Equal("Terraform plan under version 1.8.5 has changes"))
+			})
 
-			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-			Expect(len(list.Items)).To(Equal(1))
+			It("should continue with the terraform plan", func() {
+				list := &batchv1.JobList{}
+
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(2))
+			})
 		})
 
-		It("should have the additional secrets added", func() {
-			list := &batchv1.JobList{}
-			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-			Expect(len(list.Items)).To(Equal(1))
+		When("the preview version is older than the version which wrote the state", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				configuration.Annotations = map[string]string{terraformv1alpha1.PreviewVersionAnnotation: "1.0.0"}
+				configuration.Status.TerraformVersion = "1.1.9"
+				Setup(configuration)
 
-			job := list.Items[0]
-			Expect(job.Spec.Template.Spec.InitContainers[0].Name).To(Equal("setup"))
-			Expect(job.Spec.Template.Spec.InitContainers[0].EnvFrom).To(HaveLen(3))
-			Expect(job.Spec.Template.Spec.InitContainers[0].EnvFrom[1].SecretRef.Name).To(Equal("secret1"))
-			Expect(job.Spec.Template.Spec.InitContainers[0].EnvFrom[2].SecretRef.Name).To(Equal("secret2"))
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			})
 
-			Expect(job.Spec.Template.Spec.Containers).To(HaveLen(1))
-			Expect(job.Spec.Template.Spec.Containers[0].EnvFrom).To(HaveLen(3))
-			Expect(job.Spec.Template.Spec.Containers[0].EnvFrom[0].SecretRef.Name).To(Equal("aws"))
+			It("should indicate the preview has failed", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.Preview).ToNot(BeNil())
+				Expect(configuration.Status.Preview.Status).To(Equal(terraformv1alpha1.PreviewFailed))
+				Expect(configuration.Status.Preview.Message).To(Equal("Unable to downgrade the OpenTofu version, state was written by v1.1.9, which is newer than OpenTofu v1.0.0"))
+			})
 
-			Expect(job.Spec.Template.Spec.Containers[0].EnvFrom[1].SecretRef.Name).To(Equal("secret1"))
-			Expect(job.Spec.Template.Spec.Containers[0].EnvFrom[1].SecretRef.Optional).ToNot(BeNil())
-			Expect(*job.Spec.Template.Spec.Containers[0].EnvFrom[1].SecretRef.Optional).To(BeTrue())
+			It("should not have created a preview job", func() {
+				list := &batchv1.JobList{}
 
-			Expect(job.Spec.Template.Spec.Containers[0].EnvFrom[2].SecretRef.Name).To(Equal("secret2"))
-			Expect(job.Spec.Template.Spec.Containers[0].EnvFrom[2].SecretRef.Optional).ToNot(BeNil())
-			Expect(*job.Spec.Template.Spec.Containers[0].EnvFrom[2].SecretRef.Optional).To(BeTrue())
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				for _, x := range list.Items {
+					Expect(x.Labels).ToNot(HaveKey(terraformv1alpha1.JobPreviewVersionLabel))
+				}
+			})
 		})
 	})
 
-	When("configuration has not yet run the terraform plan", func() {
-		BeforeEach(func() {
-			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-			Setup(configuration)
-			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
-		})
-
-		It("should have the conditions", func() {
-			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-			Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-		})
-
-		It("should indicate the provider is ready", func() {
-			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+	// COSTS
+	When("predicted costs is enabled", func() {
+		When("the costs token is missing", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				Setup(configuration)
+				ctrl.EnableInfracosts = true
+				ctrl.InfracostsSecretName = "not_there"
 
-			cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionProviderReady)
-			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
-			Expect(cond.Reason).To(Equal(corev1alpha1.ReasonReady))
-			Expect(cond.Message).To(Equal("Provider ready"))
-		})
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 2)
+			})
 
-		It("should have created the generated configuration secret", func() {
-			secret := &v1.Secret{}
-			secret.Namespace = ctrl.ControllerNamespace
-			secret.Name = configuration.GetTerraformConfigSecretName()
+			It("should have the conditions", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+			})
 
-			found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
-			Expect(err).ToNot(HaveOccurred())
-			Expect(found).To(BeTrue())
+			It("should indicate the costs analytics token is invalid", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-			Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformVariablesConfigMapKey))
-			Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformBackendSecretKey))
-		})
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(cond.Message).To(Equal("Cost analytics secret (default/not_there) does not exist, contact platform administrator"))
+			})
 
-		It("should have create a terraform backend configuration", func() {
-			expected := `
-terraform {
-	backend "kubernetes" {
-		in_cluster_config = true
-		namespace         = "default"
-		labels            = {
-			"terraform.appvia.io/configuration" = "bucket"
-			"terraform.appvia.io/configuration-uid" = "1234-122-1234-1234"
-			"terraform.appvia.io/generation" = "0"
-			"terraform.appvia.io/namespace" = "apps"
-		}
-		secret_suffix     = "1234-122-1234-1234"
-	}
-}
-`
-			secret := &v1.Secret{}
-			secret.Namespace = ctrl.ControllerNamespace
-			secret.Name = configuration.GetTerraformConfigSecretName()
+			It("should have raised a event", func() {
+				Expect(recorder.Events).ToNot(BeEmpty())
+				Expect(recorder.Events[0]).To(ContainSubstring("Cost analytics secret (default/not_there) does not exist, contact platform administrator"))
+			})
 
-			found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
-			Expect(err).ToNot(HaveOccurred())
-			Expect(found).To(BeTrue())
+			It("should not create any jobs", func() {
+				list := &batchv1.JobList{}
 
-			backend := string(secret.Data[terraformv1alpha1.TerraformBackendSecretKey])
-			Expect(backend).ToNot(BeZero())
-			Expect(backend).To(Equal(expected))
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(0))
+			})
 		})
 
-		It("should have a provider.tf.json", func() {
-			expected := "{\n  \"provider\": {\n    \"aws\": {}\n  }\n}\n"
-			secret := &v1.Secret{}
-			secret.Namespace = ctrl.ControllerNamespace
-			secret.Name = configuration.GetTerraformConfigSecretName()
-
-			found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
-			Expect(err).ToNot(HaveOccurred())
-			Expect(found).To(BeTrue())
-
-			backend := string(secret.Data[terraformv1alpha1.TerraformProviderConfigMapKey])
-			Expect(backend).ToNot(BeZero())
-			Expect(backend).To(Equal(expected))
-		})
+		When("cost token is invalid", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				secret := &v1.Secret{}
+				secret.Name = "token"
+				secret.Namespace = ctrl.ControllerNamespace
 
-		It("should have the following variables in the config", func() {
-			expected := "{\"name\":\"test\"}\n"
+				Setup(configuration, secret)
+				ctrl.EnableInfracosts = true
+				ctrl.InfracostsSecretName = "token"
 
-			secret := &v1.Secret{}
-			secret.Namespace = ctrl.ControllerNamespace
-			secret.Name = configuration.GetTerraformConfigSecretName()
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 2)
+			})
 
-			found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
-			Expect(err).ToNot(HaveOccurred())
-			Expect(found).To(BeTrue())
+			It("should have the conditions", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+			})
 
-			backend := string(secret.Data[terraformv1alpha1.TerraformVariablesConfigMapKey])
-			Expect(backend).ToNot(BeZero())
-			Expect(backend).To(Equal(expected))
-		})
+			It("should indicate the costs analytics token is invalid", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-		It("should indicate the terraform plan is running", func() {
-			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(cond.Message).To(Equal("Cost analytics secret (default/token) does not contain a token, contact platform administrator"))
+			})
 
-			cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
-			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-			Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
-			Expect(cond.Message).To(Equal("Terraform plan is running"))
-		})
+			It("should have raised a event", func() {
+				Expect(recorder.Events).ToNot(BeEmpty())
+				Expect(recorder.Events[0]).To(ContainSubstring("Cost analytics secret (default/token) does not contain a token, contact platform administrator"))
+			})
 
-		It("should have created job for the terraform plan", func() {
-			list := &batchv1.JobList{}
+			It("should not create any jobs", func() {
+				list := &batchv1.JobList{}
 
-			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-			Expect(len(list.Items)).To(Equal(1))
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(0))
+			})
 		})
 
-		It("should have a terraform container", func() {
-			list := &batchv1.JobList{}
-			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-			Expect(len(list.Items)).To(Equal(1))
+		When("configuration has successful run a plan but no cost report", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
 
-			expected := []string{
-				"--comment=Executing Terraform",
-				"--command=/usr/local/bin/tofu plan --var-file variables.tfvars.json -out=/run/plan.out -lock=false -no-color -input=false",
-				"--command=/usr/local/bin/tofu show -json /run/plan.out > /run/tfplan.json",
-				"--command=/bin/cp /run/tfplan.json /run/plan.json",
-				"--command=/bin/gzip /run/plan.json",
-				"--command=/bin/mv /run/plan.json.gz /run/plan.json",
-				"--namespace=$(KUBE_NAMESPACE)",
-				"--upload=$(TERRAFORM_PLAN_JSON_NAME)=/run/plan.json",
-				"--upload=$(TERRAFORM_PLAN_OUT_NAME)=/run/plan.out",
-				"--on-error=/run/steps/terraform.failed",
-				"--on-success=/run/steps/terraform.complete",
-			}
-			job := list.Items[0]
-			container := job.Spec.Template.Spec.Containers[0]
+				// create two successful plan
+				plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
+				plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+				plan.Status.Succeeded = 1
+				// create fake terraform state
+				state := fixtures.NewTerraformState(configuration)
+				state.Namespace = ctrl.ControllerNamespace
+				// create fake costs secret
+				token := fixtures.NewCostsSecret(ctrl.ControllerNamespace, "infracost")
+				token.Namespace = ctrl.ControllerNamespace
+				// create fake plan secret
+				tfplan := fixtures.NewTerraformPlanWithDiff(configuration, ctrl.ControllerNamespace)
 
-			Expect(container.Name).To(Equal("terraform"))
-			Expect(container.Command).To(Equal([]string{"/run/bin/step"}))
-			Expect(container.Args).To(Equal(expected))
+				Setup(configuration, plan, state, token, tfplan)
+				ctrl.EnableInfracosts = true
+				ctrl.InfracostsSecretName = "infracost"
 
-			Expect(len(container.EnvFrom)).To(Equal(1))
-			Expect(container.EnvFrom[0].SecretRef).ToNot(BeNil())
-			Expect(container.EnvFrom[0].SecretRef.Name).To(Equal("aws"))
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 10)
+			})
 
-			Expect(len(container.Env)).To(Equal(8))
-			Expect(container.Env[5].Name).To(Equal("TERRAFORM_STATE_NAME"))
-			Expect(container.Env[5].Value).To(Equal(configuration.GetTerraformStateSecretName()))
-			Expect(container.Env[6].Name).To(Equal("TERRAFORM_PLAN_OUT_NAME"))
-			Expect(container.Env[6].Value).To(Equal(configuration.GetTerraformPlanOutSecretName()))
-			Expect(container.Env[7].Name).To(Equal("TERRAFORM_PLAN_JSON_NAME"))
-			Expect(container.Env[7].Value).To(Equal(configuration.GetTerraformPlanJSONSecretName()))
+			It("the cost status should be empty", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-			Expect(container.VolumeMounts[0].Name).To(Equal("run"))
-			Expect(container.VolumeMounts[1].Name).To(Equal("source"))
+				Expect(configuration.Status.Costs).ToNot(BeNil())
+				Expect(configuration.Status.Costs.Enabled).To(BeFalse())
+				Expect(configuration.Status.Costs.Monthly).To(BeEmpty())
+				Expect(configuration.Status.Costs.Hourly).To(BeEmpty())
+			})
 		})
 
-		It("it should have the configuration labels", func() {
-			list := &batchv1.JobList{}
-			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-			Expect(len(list.Items)).To(Equal(1))
+		When("configuration has successful run a plan", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
 
-			labels := list.Items[0].GetLabels()
-			Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationNameLabel))
+				// create two successful plan
+				plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
+				plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+				plan.Status.Succeeded = 1
+				// create fake terraform state
+				state := fixtures.NewTerraformState(configuration)
+				state.Namespace = ctrl.ControllerNamespace
+				// create fake costs report
+				report := fixtures.NewCostsReport(configuration)
+				report.Namespace = ctrl.ControllerNamespace
+				// create fake costs secret
+				token := fixtures.NewCostsSecret(ctrl.ControllerNamespace, "infracost")
+				token.Namespace = ctrl.ControllerNamespace
+				// create fake plan secret
+				tfplan := fixtures.NewTerraformPlanWithDiff(configuration, ctrl.ControllerNamespace)
+
+				Setup(configuration, plan, state, report, token, tfplan)
+				ctrl.EnableInfracosts = true
+				ctrl.InfracostsSecretName = "infracost"
+
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 10)
+			})
+
+			It("should indicate the costs is enabled and costs available on the status", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+				Expect(configuration.Status.Costs).ToNot(BeNil())
+				Expect(configuration.Status.Costs.Enabled).To(BeTrue())
+				Expect(configuration.Status.Costs.Monthly).To(Equal("$100"))
+				Expect(configuration.Status.Costs.Hourly).To(Equal("$0.01"))
+			})
+
+			It("should have copied the secret into the configuration namespace", func() {
+				expected := "\n{\n\t\"totalHourlyCost\": \"0.01\",\n  \"totalMonthlyCost\": \"100.00\"\n}\n"
+				secret := &v1.Secret{}
+				secret.Namespace = configuration.Namespace
+				secret.Name = configuration.GetTerraformCostSecretName()
+				found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(secret.Data).To(HaveKey("costs.json"))
+				Expect(string(secret.Data["costs.json"])).To(Equal(expected))
+			})
+		})
+	})
+
+	// VALUEFROM FIELDS
+	When("the configuration has valueFrom definitions", func() {
+		When("using values from the valueFrom field", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				Setup()
+			})
+
+			When("secret is missing and not optional", func() {
+				BeforeEach(func() {
+					configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{
+						{Secret: ptr.To("missing"), Key: "key"},
+					}
+					Expect(ctrl.cc.Create(context.TODO(), configuration)).ToNot(HaveOccurred())
+					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+				})
+
+				It("should have the conditions", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+				})
+
+				It("should indicate the failure on the conditions", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+					cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+					Expect(cond.Message).To(Equal("spec.valueFrom[0].secret (apps/missing) does not exist"))
+					Expect(cond.Detail).To(Equal(""))
+				})
+
+				It("should not create any jobs", func() {
+					list := &batchv1.JobList{}
+
+					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+					Expect(len(list.Items)).To(Equal(0))
+				})
+			})
+
+			When("secret is missing but optional", func() {
+				BeforeEach(func() {
+					configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{
+						{Secret: ptr.To("missing"), Key: "key", Optional: true},
+					}
+					Expect(ctrl.cc.Create(context.TODO(), configuration)).ToNot(HaveOccurred())
+					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+				})
+
+				It("should have created a job", func() {
+					list := &batchv1.JobList{}
+					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+
+					Expect(len(list.Items)).To(Equal(1))
+				})
+			})
+
+			When("key is missing and not optional", func() {
+				BeforeEach(func() {
+					secret := &v1.Secret{}
+					secret.Namespace = configuration.Namespace
+					secret.Name = "exists"
+
+					configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{{Secret: ptr.To("exists"), Key: "missing"}}
+					Expect(ctrl.cc.Create(context.TODO(), configuration)).ToNot(HaveOccurred())
+					Expect(ctrl.cc.Create(context.TODO(), secret)).ToNot(HaveOccurred())
+
+					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+				})
+
+				It("should have the conditions", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+				})
+
+				It("should indicate the failure on the conditions", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+					cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+					Expect(cond.Message).To(Equal(`spec.valueFrom[0] (apps/exists) does not contain key: "missing"`))
+				})
+
+				It("should not create any jobs", func() {
+					list := &batchv1.JobList{}
+
+					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+					Expect(len(list.Items)).To(Equal(0))
+				})
+			})
+
+			When("key is missing but optional", func() {
+				BeforeEach(func() {
+					configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{
+						{Secret: ptr.To("missing"), Key: "key", Optional: true},
+					}
+					Expect(ctrl.cc.Create(context.TODO(), configuration)).ToNot(HaveOccurred())
+					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+				})
+
+				It("should have the conditions", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+				})
+
+				It("should have created a job", func() {
+					list := &batchv1.JobList{}
+
+					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+					Expect(len(list.Items)).To(Equal(1))
+				})
+
+				It("should have the conditions", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+				})
+
+				It("should have created a job", func() {
+					list := &batchv1.JobList{}
+					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+					Expect(len(list.Items)).To(Equal(1))
+				})
+			})
+
+			When("secret and key exist", func() {
+				BeforeEach(func() {
+					secret := &v1.Secret{}
+					secret.Namespace = configuration.Namespace
+					secret.Name = "exists"
+					secret.Data = map[string][]byte{"my": []byte("value")}
+
+					configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{{Secret: ptr.To("exists"), Key: "my"}}
+					Expect(ctrl.cc.Create(context.TODO(), configuration)).ToNot(HaveOccurred())
+					Expect(ctrl.cc.Create(context.TODO(), secret)).ToNot(HaveOccurred())
+
+					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 5)
+				})
+
+				It("should have the conditions", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+				})
+
+				It("should indicate the plan is in progress", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
+					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
+				})
+
+				It("should have created a job", func() {
+					list := &batchv1.JobList{}
+
+					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+					Expect(len(list.Items)).To(Equal(1))
+				})
+
+				It("should have added the value to the configuration config", func() {
+					expected := "{\"my\":\"value\",\"name\":\"test\"}\n"
+
+					secret := &v1.Secret{}
+					secret.Namespace = ctrl.ControllerNamespace
+					secret.Name = configuration.GetTerraformConfigSecretName()
+
+					found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeTrue())
+					Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformVariablesConfigMapKey))
+					Expect(string(secret.Data[terraformv1alpha1.TerraformVariablesConfigMapKey])).To(Equal(expected))
+				})
+			})
+
+			When("secret and key exist and we are remapping", func() {
+				BeforeEach(func() {
+					secret := &v1.Secret{}
+					secret.Namespace = configuration.Namespace
+					secret.Name = "exists"
+					secret.Data = map[string][]byte{"DB_HOST": []byte("value")}
+
+					configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{{Secret: ptr.To("exists"), Key: "DB_HOST", Name: "database_host"}}
+					Expect(ctrl.cc.Create(context.TODO(), configuration)).ToNot(HaveOccurred())
+					Expect(ctrl.cc.Create(context.TODO(), secret)).ToNot(HaveOccurred())
+
+					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 5)
+				})
+
+				It("should have the conditions", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+				})
+
+				It("should indicate the plan is in progress", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
+					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
+				})
+
+				It("should have created a job", func() {
+					list := &batchv1.JobList{}
+
+					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+					Expect(len(list.Items)).To(Equal(1))
+				})
+
+				It("should have added the value to the configuration config", func() {
+					expected := "{\"database_host\":\"value\",\"name\":\"test\"}\n"
+
+					secret := &v1.Secret{}
+					secret.Namespace = ctrl.ControllerNamespace
+					secret.Name = configuration.GetTerraformConfigSecretName()
+
+					found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeTrue())
+					Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformVariablesConfigMapKey))
+					Expect(string(secret.Data[terraformv1alpha1.TerraformVariablesConfigMapKey])).To(Equal(expected))
+				})
+			})
+		})
+	})
+
+	// ADDITIONAL SECRETS
+	When("the controller has been configured with additional secrets", func() {
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			Setup(configuration)
+
+			ctrl.ExecutorSecrets = []string{"secret1", "secret2"}
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 4)
+		})
+
+		It("should have the conditions", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+			Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+		})
+
+		It("should indicate the failure on the conditions", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+			cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionProviderReady)
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Reason).To(Equal(corev1alpha1.ReasonReady))
+			Expect(cond.Message).To(Equal("Provider ready"))
+		})
+
+		It("should have create a plan", func() {
+			list := &batchv1.JobList{}
+
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(len(list.Items)).To(Equal(1))
+		})
+
+		It("should have the additional secrets added", func() {
+			list := &batchv1.JobList{}
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(len(list.Items)).To(Equal(1))
+
+			job := list.Items[0]
+			Expect(job.Spec.Template.Spec.InitContainers[0].Name).To(Equal("setup"))
+			Expect(job.Spec.Template.Spec.InitContainers[0].EnvFrom).To(HaveLen(3))
+			Expect(job.Spec.Template.Spec.InitContainers[0].EnvFrom[1].SecretRef.Name).To(Equal("secret1"))
+			Expect(job.Spec.Template.Spec.InitContainers[0].EnvFrom[2].SecretRef.Name).To(Equal("secret2"))
+
+			Expect(job.Spec.Template.Spec.Containers).To(HaveLen(1))
+			Expect(job.Spec.Template.Spec.Containers[0].EnvFrom).To(HaveLen(3))
+			Expect(job.Spec.Template.Spec.Containers[0].EnvFrom[0].SecretRef.Name).To(Equal("aws"))
+
+			Expect(job.Spec.Template.Spec.Containers[0].EnvFrom[1].SecretRef.Name).To(Equal("secret1"))
+			Expect(job.Spec.Template.Spec.Containers[0].EnvFrom[1].SecretRef.Optional).ToNot(BeNil())
+			Expect(*job.Spec.Template.Spec.Containers[0].EnvFrom[1].SecretRef.Optional).To(BeTrue())
+
+			Expect(job.Spec.Template.Spec.Containers[0].EnvFrom[2].SecretRef.Name).To(Equal("secret2"))
+			Expect(job.Spec.Template.Spec.Containers[0].EnvFrom[2].SecretRef.Optional).ToNot(BeNil())
+			Expect(*job.Spec.Template.Spec.Containers[0].EnvFrom[2].SecretRef.Optional).To(BeTrue())
+		})
+	})
+
+	When("configuration has not yet run the terraform plan", func() {
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			Setup(configuration)
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+		})
+
+		It("should have the conditions", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+			Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+		})
+
+		It("should indicate the provider is ready", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+			cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionProviderReady)
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Reason).To(Equal(corev1alpha1.ReasonReady))
+			Expect(cond.Message).To(Equal("Provider ready"))
+		})
+
+		It("should have created the generated configuration secret", func() {
+			secret := &v1.Secret{}
+			secret.Namespace = ctrl.ControllerNamespace
+			secret.Name = configuration.GetTerraformConfigSecretName()
+
+			found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformVariablesConfigMapKey))
+			Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformBackendSecretKey))
+		})
+
+		It("should have create a terraform backend configuration", func() {
+			expected := `
+terraform {
+	backend "kubernetes" {
+		in_cluster_config = true
+		namespace         = "default"
+		labels            = {
+			"terraform.appvia.io/configuration" = "bucket"
+			"terraform.appvia.io/configuration-uid" = "1234-122-1234-1234"
+			"terraform.appvia.io/generation" = "0"
+			"terraform.appvia.io/namespace" = "apps"
+		}
+		secret_suffix     = "1234-122-1234-1234"
+	}
+}
+`
+			secret := &v1.Secret{}
+			secret.Namespace = ctrl.ControllerNamespace
+			secret.Name = configuration.GetTerraformConfigSecretName()
+
+			found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			backend := string(secret.Data[terraformv1alpha1.TerraformBackendSecretKey])
+			Expect(backend).ToNot(BeZero())
+			Expect(backend).To(Equal(expected))
+		})
+
+		It("should have a provider.tf.json", func() {
+			expected := "{\n  \"provider\": {\n    \"aws\": {}\n  }\n}\n"
+			secret := &v1.Secret{}
+			secret.Namespace = ctrl.ControllerNamespace
+			secret.Name = configuration.GetTerraformConfigSecretName()
+
+			found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			backend := string(secret.Data[terraformv1alpha1.TerraformProviderConfigMapKey])
+			Expect(backend).ToNot(BeZero())
+			Expect(backend).To(Equal(expected))
+		})
+
+		It("should have the following variables in the config", func() {
+			expected := "{\"name\":\"test\"}\n"
+
+			secret := &v1.Secret{}
+			secret.Namespace = ctrl.ControllerNamespace
+			secret.Name = configuration.GetTerraformConfigSecretName()
+
+			found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			backend := string(secret.Data[terraformv1alpha1.TerraformVariablesConfigMapKey])
+			Expect(backend).ToNot(BeZero())
+			Expect(backend).To(Equal(expected))
+		})
+
+		It("should indicate the terraform plan is running", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+			cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
+			Expect(cond.Message).To(Equal("Terraform plan is running"))
+		})
+
+		It("should have created job for the terraform plan", func() {
+			list := &batchv1.JobList{}
+
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(len(list.Items)).To(Equal(1))
+		})
+
+		It("should have a terraform container", func() {
+			list := &batchv1.JobList{}
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(len(list.Items)).To(Equal(1))
+
+			expected := []string{
+				"--comment=Executing OpenTofu",
+				"--command=/usr/local/bin/tofu plan --var-file variables.tfvars.json -out=/run/plan.out -lock=false -no-color -input=false",
+				"--command=/usr/local/bin/tofu show -json /run/plan.out > /run/tfplan.json",
+				"--command=/bin/cp /run/tfplan.json /run/plan.json",
+				"--command=/bin/gzip /run/plan.json",
+				"--command=/bin/mv /run/plan.json.gz /run/plan.json",
+				"--upload=$(TERRAFORM_PLAN_JSON_NAME)=/run/plan.json",
+				"--upload=$(TERRAFORM_PLAN_OUT_NAME)=/run/plan.out",
+				"--name=terraform",
+				"--namespace=$(KUBE_NAMESPACE)",
+				"--on-error=/run/steps/terraform.failed",
+				"--on-success=/run/steps/terraform.complete",
+				"--results=/run/steps/results.json",
+				"--results-secret=$(STEP_RESULTS_NAME)",
+			}
+			job := list.Items[0]
+			container := job.Spec.Template.Spec.Containers[0]
+
+			Expect(container.Name).To(Equal("terraform"))
+			Expect(container.Command).To(Equal([]string{"/run/bin/step"}))
+			Expect(container.Args).To(Equal(expected))
+
+			Expect(len(container.EnvFrom)).To(Equal(1))
+			Expect(container.EnvFrom[0].SecretRef).ToNot(BeNil())
+			Expect(container.EnvFrom[0].SecretRef.Name).To(Equal("aws"))
+
+			Expect(len(container.Env)).To(Equal(10))
+			Expect(container.Env[5].Name).To(Equal("TERRAFORM_STATE_NAME"))
+			Expect(container.Env[5].Value).To(Equal(configuration.GetTerraformStateSecretName()))
+			Expect(container.Env[6].Name).To(Equal("TERRAFORM_PLAN_OUT_NAME"))
+			Expect(container.Env[6].Value).To(Equal(configuration.GetTerraformPlanOutSecretName()))
+			Expect(container.Env[7].Name).To(Equal("TERRAFORM_PLAN_JSON_NAME"))
+			Expect(container.Env[7].Value).To(Equal(configuration.GetTerraformPlanJSONSecretName()))
+
+			Expect(container.VolumeMounts[0].Name).To(Equal("run"))
+			Expect(container.VolumeMounts[1].Name).To(Equal("source"))
+		})
+
+		It("it should have the configuration labels", func() {
+			list := &batchv1.JobList{}
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(len(list.Items)).To(Equal(1))
+
+			labels := list.Items[0].GetLabels()
+			Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationNameLabel))
 			Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationNamespaceLabel))
 			Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationGenerationLabel))
 			Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationStageLabel))
@@ -2061,359 +2667,1171 @@ terraform {
 			Expect(labels[terraformv1alpha1.ConfigurationNamespaceLabel]).To(Equal(configuration.Namespace))
 		})
 
-		It("should have created a watch job in the configuration namespace", func() {
-			list := &batchv1.JobList{}
-			Expect(cc.List(context.TODO(), list, client.InNamespace(configuration.Namespace))).ToNot(HaveOccurred())
-			Expect(len(list.Items)).To(Equal(1))
-			Expect(len(list.Items[0].Spec.Template.Spec.Containers)).To(Equal(1))
+		It("should have created a watch job in the configuration namespace", func() {
+			list := &batchv1.JobList{}
+			Expect(cc.List(context.TODO(), list, client.InNamespace(configuration.Namespace))).ToNot(HaveOccurred())
+			Expect(len(list.Items)).To(Equal(1))
+			Expect(len(list.Items[0].Spec.Template.Spec.Containers)).To(Equal(1))
+
+			container := list.Items[0].Spec.Template.Spec.Containers[0]
+			Expect(container.Name).To(Equal("watch"))
+			Expect(container.Command).To(Equal([]string{"/watch_logs.sh"}))
+			Expect(container.Args).To(Equal([]string{"-e", "http://controller.default.svc.cluster.local/v1/builds/apps/bucket/logs?generation=0&name=bucket&namespace=apps&stage=plan&uid=1234-122-1234-1234"}))
+			Expect(list.Items[0].Spec.Template.Spec.ServiceAccountName).To(Equal(jobs.WatcherServiceAccount))
+		})
+
+		It("should have created the watcher service account in the configuration namespace", func() {
+			account := &v1.ServiceAccount{}
+			Expect(cc.Get(context.TODO(), client.ObjectKey{Namespace: configuration.Namespace, Name: jobs.WatcherServiceAccount}, account)).To(Succeed())
+		})
+
+		It("should have added a approval annotation to the configuration", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+			Expect(configuration.Annotations).To(HaveKey(terraformv1alpha1.ApplyAnnotation))
+		})
+
+		It("should have a out of sync status", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+			Expect(configuration.Status.ResourceStatus).To(Equal(terraformv1alpha1.ResourcesOutOfSync))
+		})
+
+		It("should not have a terraform version yet", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+			Expect(configuration.Status.TerraformVersion).To(BeEmpty())
+		})
+
+		It("should ask us to requeue", func() {
+			Expect(result).To(Equal(reconcile.Result{RequeueAfter: 10 * time.Second}))
+			Expect(rerr).To(BeNil())
+		})
+	})
+
+	// CONTEXT INJECTION
+	When("using a context injection", func() {
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			Setup(configuration)
+
+			ctrl.EnableContextInjection = true
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 4)
+		})
+
+		It("should have the conditions", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+			Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+		})
+
+		It("should have create a plan", func() {
+			list := &batchv1.JobList{}
+
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(len(list.Items)).To(Equal(1))
+		})
+
+		It("should have create job configuration secret", func() {
+			secret := &v1.Secret{}
+			secret.Namespace = ctrl.ControllerNamespace
+			secret.Name = configuration.GetTerraformConfigSecretName()
+
+			found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+		})
+
+		It("should have included the additional context variables", func() {
+			secret := &v1.Secret{}
+			secret.Namespace = ctrl.ControllerNamespace
+			secret.Name = configuration.GetTerraformConfigSecretName()
+
+			found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformVariablesConfigMapKey))
+			Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformBackendSecretKey))
+
+			expected := "{\"name\":\"test\",\"terranetes\":{\"name\":\"bucket\",\"namespace\":\"apps\"}}\n"
+
+			Expect(string(secret.Data[terraformv1alpha1.TerraformVariablesConfigMapKey])).To(Equal(expected))
+		})
+	})
+
+	// CUSTOM BACKEND TEMPLATE
+	When("using a provider specific backend template", func() {
+		var template *v1.Secret
+
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			Setup(configuration)
+
+			provider := &terraformv1alpha1.Provider{}
+			provider.Name = configuration.Spec.ProviderRef.Name
+			Expect(cc.Get(context.Background(), provider.GetNamespacedName(), provider)).ToNot(HaveOccurred())
+
+			template = fixtures.NewBackendTemplateSecret(ctrl.ControllerNamespace, "provider-template")
+			provider.Spec.BackendTemplate = &v1.SecretReference{
+				Namespace: template.Namespace,
+				Name:      template.Name,
+			}
+			Expect(cc.Create(context.Background(), template)).ToNot(HaveOccurred())
+			Expect(cc.Update(context.Background(), provider)).ToNot(HaveOccurred())
+		})
+
+		Context("and the secret is not present", func() {
+			BeforeEach(func() {
+				Expect(cc.Delete(context.Background(), template)).ToNot(HaveOccurred())
+
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 0)
+			})
+
+			It("should indicate the error", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(cond.Message).To(Equal(`Backend template secret "default/provider-template" not found, contact administrator`))
+			})
+		})
+
+		Context("and the template is not present", func() {
+			BeforeEach(func() {
+				template = fixtures.NewBackendTemplateSecret(ctrl.ControllerNamespace, "provider-template")
+				Expect(cc.Delete(context.Background(), template)).ToNot(HaveOccurred())
+
+				template = fixtures.NewBackendTemplateSecret(ctrl.ControllerNamespace, "provider-template")
+				template.Data = map[string][]byte{}
+				Expect(cc.Create(context.Background(), template)).ToNot(HaveOccurred())
+
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 0)
+			})
+
+			It("should indicate the error", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(cond.Message).To(Equal(`Backend template secret "default/provider-template" does not contain the backend.tf key`))
+			})
+		})
+
+		Context("and the template is present", func() {
+			BeforeEach(func() {
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 0)
+			})
+
+			It("should have used the custom backend template from the provider", func() {
+				secret := &v1.Secret{}
+				secret.Namespace = ctrl.ControllerNamespace
+				secret.Name = configuration.GetTerraformConfigSecretName()
+
+				found, err := kubernetes.GetIfExists(context.Background(), cc, secret)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+
+				Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformBackendSecretKey))
+				Expect(string(secret.Data[terraformv1alpha1.TerraformBackendSecretKey])).To(ContainSubstring(`backend "s3"`))
+				Expect(string(secret.Data[terraformv1alpha1.TerraformBackendSecretKey])).To(ContainSubstring(`terranetes-controller-state"`))
+				Expect(string(secret.Data[terraformv1alpha1.TerraformBackendSecretKey])).To(ContainSubstring(`AWS_SECRET_ACCESS_KEY"`))
+			})
+		})
+	})
+
+	When("using a custom backend template", func() {
+		When("the template is not present", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				Setup(configuration)
+				ctrl.BackendTemplate = "not_there"
+
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 10)
+			})
+
+			It("should have the conditions", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+			})
+
+			It("should indicate the configuration failed", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(cond.Message).To(Equal("Backend template secret \"default/not_there\" not found, contact administrator"))
+			})
+
+			It("should not create any jobs", func() {
+				list := &batchv1.JobList{}
+
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(0))
+			})
+		})
+
+		When("the template is present but missing required keys", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				backend := fixtures.NewBackendTemplateSecret(ctrl.ControllerNamespace, "missing_key")
+				delete(backend.Data, "backend.tf")
+				Setup(configuration, backend)
+				ctrl.BackendTemplate = "missing_key"
+
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 10)
+			})
+
+			It("should have the conditions", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+			})
+
+			It("should indicate the configuration failed", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(cond.Message).To(Equal("Backend template secret \"default/missing_key\" does not contain the backend.tf key"))
+			})
+
+			It("should not create any jobs", func() {
+				list := &batchv1.JobList{}
+
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(0))
+			})
+		})
+
+		When("the template is present but missing required key is empty", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				backend := fixtures.NewBackendTemplateSecret(ctrl.ControllerNamespace, "missing_key")
+				backend.Data["backend.tf"] = []byte("")
+				Setup(configuration, backend)
+				ctrl.BackendTemplate = "missing_key"
+
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 10)
+			})
+
+			It("should have the conditions", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+			})
+
+			It("should indicate the configuration failed", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(cond.Message).To(Equal("Backend template secret \"default/missing_key\" does not contain the backend.tf key"))
+			})
+
+			It("should not create any jobs", func() {
+				list := &batchv1.JobList{}
+
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(0))
+			})
+		})
+
+		When("the template is present, valid and plan has not been run", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				backend := fixtures.NewBackendTemplateSecret(ctrl.ControllerNamespace, "backend-s3")
+				Setup(configuration, backend)
+				ctrl.BackendTemplate = "backend-s3"
+
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 10)
+			})
+
+			It("should have the conditions", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+			})
+
+			It("should indicate the provider is ready", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionProviderReady)
+				Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonReady))
+				Expect(cond.Message).To(Equal("Provider ready"))
+			})
+
+			It("should have created the generated configuration secret", func() {
+				secret := &v1.Secret{}
+				secret.Namespace = ctrl.ControllerNamespace
+				secret.Name = configuration.GetTerraformConfigSecretName()
+
+				found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+
+				Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformVariablesConfigMapKey))
+				Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformBackendSecretKey))
+			})
+
+			It("should have create a terraform backend configuration", func() {
+				expected := `
+terraform {
+  backend "s3" {
+    bucket     = "terranetes-controller-state"
+    key        = "cluster_one/apps/bucket"
+    region     = "eu-west-2"
+    access_key = "AWS_ACCESS_KEY_ID"
+    secret_key = "AWS_SECRET_ACCESS_KEY"
+  }
+}
+`
+				secret := &v1.Secret{}
+				secret.Namespace = ctrl.ControllerNamespace
+				secret.Name = configuration.GetTerraformConfigSecretName()
+
+				found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+
+				backend := string(secret.Data[terraformv1alpha1.TerraformBackendSecretKey])
+				Expect(backend).ToNot(BeZero())
+				Expect(backend).To(Equal(expected))
+			})
+
+			It("should indicate the terraform plan is running", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
+				Expect(cond.Message).To(Equal("Terraform plan is running"))
+			})
+
+			It("should have created job for the terraform plan", func() {
+				list := &batchv1.JobList{}
+
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+			})
+		})
+	})
+
+	// DRIFT
+	When("configuration has drift options", func() {
+		When("drift annotation is tagged but configuration has not opted in for detection", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				configuration.Annotations = map[string]string{terraformv1alpha1.DriftAnnotation: "true"}
+
+				Setup(configuration)
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			})
+
+			It("should have the conditions", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+			})
+
+			It("should indicate the terraform plan is running", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
+				Expect(cond.Message).To(Equal("Terraform plan is running"))
+			})
+
+			It("should have created job for the terraform plan", func() {
+				list := &batchv1.JobList{}
+
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+			})
+
+			It("it should have the configuration labels", func() {
+				list := &batchv1.JobList{}
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+
+				labels := list.Items[0].GetLabels()
+				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationNameLabel))
+				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationNamespaceLabel))
+				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationGenerationLabel))
+				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationStageLabel))
+				Expect(labels).To(HaveKey(terraformv1alpha1.DriftAnnotation))
+				Expect(labels[terraformv1alpha1.ConfigurationStageLabel]).To(Equal(terraformv1alpha1.StageTerraformPlan))
+				Expect(labels[terraformv1alpha1.ConfigurationNameLabel]).To(Equal(configuration.Name))
+				Expect(labels[terraformv1alpha1.ConfigurationNamespaceLabel]).To(Equal(configuration.Namespace))
+				Expect(labels[terraformv1alpha1.DriftAnnotation]).To(Equal("true"))
+			})
+
+			It("should have created a watch job in the configuration namespace", func() {
+				list := &batchv1.JobList{}
+				Expect(cc.List(context.TODO(), list, client.InNamespace(configuration.Namespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+				Expect(len(list.Items[0].Spec.Template.Spec.Containers)).To(Equal(1))
+
+				container := list.Items[0].Spec.Template.Spec.Containers[0]
+				Expect(container.Name).To(Equal("watch"))
+				Expect(container.Command).To(Equal([]string{"/watch_logs.sh"}))
+				Expect(container.Args).To(Equal([]string{"-e", "http://controller.default.svc.cluster.local/v1/builds/apps/bucket/logs?generation=0&name=bucket&namespace=apps&stage=plan&uid=1234-122-1234-1234"}))
+			})
+		})
+
+		When("drift check has already been run", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				configuration.Spec.EnableDriftDetection = true
+				configuration.Annotations = map[string]string{
+					terraformv1alpha1.DriftAnnotation: "true",
+					terraformv1alpha1.ApplyAnnotation: "false",
+				}
+
+				plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
+				plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+				plan.Status.Succeeded = 1
+				tfplan := fixtures.NewTerraformPlanWithDiff(configuration, ctrl.ControllerNamespace)
+
+				Setup(configuration, plan, tfplan)
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 5)
+			})
+
+			It("should not create another job", func() {
+				list := &batchv1.JobList{}
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+
+				Expect(len(list.Items)).To(Equal(1))
+			})
+
+			It("should have the conditions", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+			})
+
+			It("should indicate the terraform plan is running", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformApply)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(cond.Message).To(Equal("Waiting for terraform apply annotation to be set to true"))
+			})
+		})
+
+		When("drift annotation changes", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				configuration.Spec.EnableAutoApproval = true
+				configuration.Annotations = map[string]string{terraformv1alpha1.DriftAnnotation: "changed"}
+
+				job := &batchv1.Job{}
+				job.Name = "test"
+				job.Namespace = ctrl.ControllerNamespace
+				job.Labels = map[string]string{
+					terraformv1alpha1.ConfigurationGenerationLabel: fmt.Sprintf("%d", configuration.GetGeneration()),
+					terraformv1alpha1.ConfigurationNameLabel:       configuration.Name,
+					terraformv1alpha1.ConfigurationNamespaceLabel:  configuration.Namespace,
+					terraformv1alpha1.ConfigurationStageLabel:      terraformv1alpha1.StageTerraformPlan,
+					terraformv1alpha1.ConfigurationUIDLabel:        string(configuration.GetUID()),
+					terraformv1alpha1.DriftAnnotation:              "different_before",
+				}
+				Setup(configuration, job)
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			})
+
+			It("should indicate the terraform plan is running", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
+				Expect(cond.Message).To(Equal("Terraform plan is running"))
+			})
+
+			It("should have an out of sync status", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.ResourceStatus).To(Equal(terraformv1alpha1.ResourcesOutOfSync))
+			})
+
+			It("should create another job", func() {
+				list := &batchv1.JobList{}
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(2))
+			})
+		})
+	})
+
+	// CHECKOV
+	When("checkov with an external source", func() {
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			Setup(configuration)
+
+			constraint := fixtures.NewMatchAllPolicyConstraint("all")
+			constraint.Spec.Constraints.Checkov.Source = &terraformv1alpha1.ExternalSource{
+				URL:           "https://github.com/appvia/terranetes-policy?ref=main",
+				Configuration: "config.yaml",
+			}
+
+			Expect(ctrl.cc.Create(context.TODO(), constraint)).ToNot(HaveOccurred())
+
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+		})
+
+		It("should have conditions", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+			Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+		})
+
+		It("should created a terraform plan job", func() {
+			list := &batchv1.JobList{}
+
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(len(list.Items)).To(Equal(1))
+		})
+
+		It("should add a source init container to the job", func() {
+			list := &batchv1.JobList{}
+
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(len(list.Items)).To(Equal(1))
+			job := list.Items[0]
+
+			expectedArgs := []string{
+				"--comment=Retrieve policy source",
+				"--command=/bin/source --dest=/run/checkov --source=https://github.com/appvia/terranetes-policy?ref=main",
+			}
+
+			Expect(len(job.Spec.Template.Spec.Containers)).To(Equal(2))
+			Expect(job.Spec.Template.Spec.InitContainers[2].Name).To(Equal("policy-source"))
+			Expect(job.Spec.Template.Spec.InitContainers[2].Command).To(Equal([]string{"/run/bin/step"}))
+			Expect(job.Spec.Template.Spec.InitContainers[2].Args).To(Equal(expectedArgs))
+		})
+
+		It("should not have checkov configuration in job secret", func() {
+			secret := &v1.Secret{}
+			secret.Namespace = ctrl.ControllerNamespace
+			secret.Name = configuration.GetTerraformConfigSecretName()
+
+			found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(secret.Data).ToNot(HaveKey(terraformv1alpha1.CheckovJobTemplateConfigMapKey))
+		})
+
+		It("should have updated the command line for checkov scan", func() {
+			list := &batchv1.JobList{}
+
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(len(list.Items)).To(Equal(1))
+			job := list.Items[0]
+
+			expected := []string{
+				"--comment=Evaluating Against Security Policy",
+				"--name=policy",
+				"--command=/usr/local/bin/checkov --config /run/checkov/config.yaml --framework terraform_plan -f /run/tfplan.json --soft-fail -o json -o cli --output-file-path /run --repo-root-for-plan-enrichment /data --download-external-modules true >/dev/null",
+				"--command=/bin/cat /run/results_cli.txt",
+				"--namespace=$(KUBE_NAMESPACE)",
+				"--upload=$(POLICY_REPORT_NAME)=/run/results_json.json",
+				"--results=/run/steps/results.json",
+				"--results-secret=$(STEP_RESULTS_NAME)",
+				"--is-failure=/run/steps/terraform.failed",
+				"--wait-on=/run/steps/terraform.complete",
+			}
+
+			Expect(len(job.Spec.Template.Spec.Containers)).To(Equal(2))
+			Expect(job.Spec.Template.Spec.Containers[1].Name).To(Equal("verify-policy"))
+			Expect(job.Spec.Template.Spec.Containers[1].Command).To(Equal([]string{"/run/bin/step"}))
+			Expect(job.Spec.Template.Spec.Containers[1].Args).To(Equal(expected))
+		})
+	})
+
+	When("checkov is configured", func() {
+		When("configuration matches a policy", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				Setup(configuration)
+				constraint := fixtures.NewMatchAllPolicyConstraint("all")
+				constraint.Spec.Constraints.Checkov.Checks = []string{"check0", "check1"}
+				constraint.Spec.Constraints.Checkov.SkipChecks = nil
+
+				Expect(ctrl.cc.Create(context.TODO(), constraint)).ToNot(HaveOccurred())
+
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			})
+
+			It("should have conditions", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+			})
+
+			It("should created a terraform plan job", func() {
+				list := &batchv1.JobList{}
+
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+			})
+
+			It("should add a verify-policy container to the job", func() {
+				list := &batchv1.JobList{}
+
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+				job := list.Items[0]
+
+				Expect(len(job.Spec.Template.Spec.Containers)).To(Equal(2))
+				Expect(job.Spec.Template.Spec.Containers[1].Name).To(Equal("verify-policy"))
+				Expect(job.Spec.Template.Spec.Containers[1].Command).To(Equal([]string{"/run/bin/step"}))
+				Expect(job.Spec.Template.Spec.Containers[1].Args).To(Equal(verifyPolicyArguments))
+				Expect(job.Spec.Template.Spec.Containers[1].VolumeMounts).To(HaveLen(3))
+				Expect(job.Spec.Template.Spec.Containers[1].VolumeMounts[0].Name).To(Equal("checkov"))
+				Expect(job.Spec.Template.Spec.Containers[1].VolumeMounts[0].MountPath).To(Equal("/run/checkov"))
+			})
+
+			It("should have a checkov configuration secret", func() {
+				secret := &v1.Secret{}
+				secret.Namespace = ctrl.ControllerNamespace
+				secret.Name = configuration.GetTerraformConfigSecretName()
+
+				found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(secret.Data).To(HaveKey(terraformv1alpha1.CheckovJobTemplateConfigMapKey))
+				Expect(string(secret.Data[terraformv1alpha1.CheckovJobTemplateConfigMapKey])).To(Equal("framework:\n  - terraform_plan\nsoft-fail: true\ncompact: true\ncheck:\n  - check0\n  - check1"))
+			})
+		})
+
+		When("configuration matches multiple policies, highest priority wins", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				Setup(configuration)
+
+				// @notes: we add two policies here, the later one with the namespace should take priority
+				// given the namespace selector.
+
+				all := fixtures.NewMatchAllPolicyConstraint("all")
+				all.Spec.Constraints.Checkov.Checks = []string{"check0"}
 
-			container := list.Items[0].Spec.Template.Spec.Containers[0]
-			Expect(container.Name).To(Equal("watch"))
-			Expect(container.Command).To(Equal([]string{"/watch_logs.sh"}))
-			Expect(container.Args).To(Equal([]string{"-e", "http://controller.default.svc.cluster.local/v1/builds/apps/bucket/logs?generation=0&name=bucket&namespace=apps&stage=plan&uid=1234-122-1234-1234"}))
-		})
+				priority := fixtures.NewMatchAllPolicyConstraint("priority")
+				priority.Spec.Constraints.Checkov.Checks = []string{"priority"}
+				priority.Spec.Constraints.Checkov.Selector = &terraformv1alpha1.Selector{}
+				priority.Spec.Constraints.Checkov.Selector.Namespace = &metav1.LabelSelector{
+					MatchLabels: map[string]string{"name": cfgNamespace},
+				}
 
-		It("should have added a approval annotation to the configuration", func() {
-			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-			Expect(configuration.Annotations).To(HaveKey(terraformv1alpha1.ApplyAnnotation))
-		})
+				Expect(ctrl.cc.Create(context.TODO(), all)).ToNot(HaveOccurred())
+				Expect(ctrl.cc.Create(context.TODO(), priority)).ToNot(HaveOccurred())
 
-		It("should have a out of sync status", func() {
-			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			})
 
-			Expect(configuration.Status.ResourceStatus).To(Equal(terraformv1alpha1.ResourcesOutOfSync))
-		})
+			It("should have conditions", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+			})
 
-		It("should not have a terraform version yet", func() {
-			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+			It("should created a terraform plan job", func() {
+				list := &batchv1.JobList{}
 
-			Expect(configuration.Status.TerraformVersion).To(BeEmpty())
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+			})
+
+			It("should have selected priority policy", func() {
+				list := &batchv1.JobList{}
+
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+				job := list.Items[0]
+
+				Expect(len(job.Spec.Template.Spec.Containers)).To(Equal(2))
+				Expect(job.Spec.Template.Spec.Containers[1].Name).To(Equal("verify-policy"))
+				Expect(job.Spec.Template.Spec.Containers[1].Command).To(Equal([]string{"/run/bin/step"}))
+				Expect(job.Spec.Template.Spec.Containers[1].Args).To(Equal(verifyPolicyArguments))
+			})
+
+			It("should have a checkov configuration secret", func() {
+				secret := &v1.Secret{}
+				secret.Namespace = ctrl.ControllerNamespace
+				secret.Name = configuration.GetTerraformConfigSecretName()
+
+				found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(secret.Data).To(HaveKey(terraformv1alpha1.CheckovJobTemplateConfigMapKey))
+				Expect(string(secret.Data[terraformv1alpha1.CheckovJobTemplateConfigMapKey])).To(Equal("framework:\n  - terraform_plan\nsoft-fail: true\ncompact: true\ncheck:\n  - priority"))
+			})
 		})
 
-		It("should ask us to requeue", func() {
-			Expect(result).To(Equal(reconcile.Result{RequeueAfter: 10 * time.Second}))
-			Expect(rerr).To(BeNil())
+		When("we have external checks defined on the security policy", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				Setup(configuration)
+
+				// @notes: we add a policy with and external check
+				external := fixtures.NewMatchAllPolicyConstraint("external")
+				external.Spec.Constraints.Checkov.Checks = []string{"check0"}
+				external.Spec.Constraints.Checkov.External = []terraformv1alpha1.ExternalCheck{
+					{
+						Name: "test",
+						URL:  "https://example.com//dir",
+						SecretRef: &v1.SecretReference{
+							Name: "test-secret",
+						},
+					},
+				}
+
+				Expect(ctrl.cc.Create(context.TODO(), external)).ToNot(HaveOccurred())
+
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			})
+
+			It("should have conditions", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+			})
+
+			It("should create a terraform plan job", func() {
+				list := &batchv1.JobList{}
+
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+			})
+
+			It("should have an init container retrieving the source", func() {
+				list := &batchv1.JobList{}
+
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+				Expect(len(list.Items[0].Spec.Template.Spec.InitContainers)).To(Equal(3))
+
+				source := list.Items[0].Spec.Template.Spec.InitContainers[2]
+				Expect(source.Name).To(Equal("policy-external-test"))
+				Expect(source.Command).To(Equal([]string{"/run/bin/step"}))
+				Expect(source.Args).To(Equal([]string{
+					"--comment=Retrieve external source for test",
+					"--command=/bin/mkdir -p /run/policy",
+					"--command=/bin/source --dest=/run/policy/test --source=https://example.com//dir",
+				}))
+				Expect(source.EnvFrom).To(Equal([]v1.EnvFromSource{
+					{
+						SecretRef: &v1.SecretEnvSource{
+							LocalObjectReference: v1.LocalObjectReference{Name: "test-secret"},
+							Optional:             nil,
+						},
+					},
+				}))
+				Expect(len(source.VolumeMounts)).To(Equal(1))
+				Expect(source.VolumeMounts[0].Name).To(Equal("run"))
+			})
+
+			It("should have updated the command line for checkov scan", func() {
+				list := &batchv1.JobList{}
+
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+				job := list.Items[0]
+
+				Expect(len(job.Spec.Template.Spec.Containers)).To(Equal(2))
+				Expect(job.Spec.Template.Spec.Containers[1].Name).To(Equal("verify-policy"))
+				Expect(job.Spec.Template.Spec.Containers[1].Command).To(Equal([]string{"/run/bin/step"}))
+				Expect(job.Spec.Template.Spec.Containers[1].Args).To(Equal([]string{
+					"--comment=Evaluating Against Security Policy",
+					"--name=policy",
+					"--command=/usr/local/bin/checkov --config /run/checkov/checkov.yaml --framework terraform_plan -f /run/tfplan.json --soft-fail -o json -o cli --output-file-path /run --repo-root-for-plan-enrichment /data --download-external-modules true >/dev/null",
+					"--command=/bin/cat /run/results_cli.txt",
+					"--namespace=$(KUBE_NAMESPACE)",
+					"--upload=$(POLICY_REPORT_NAME)=/run/results_json.json",
+					"--results=/run/steps/results.json",
+					"--results-secret=$(STEP_RESULTS_NAME)",
+					"--is-failure=/run/steps/terraform.failed",
+					"--wait-on=/run/steps/terraform.complete",
+				}))
+			})
+
+			It("should have a checkov configuration secret", func() {
+				secret := &v1.Secret{}
+				secret.Namespace = ctrl.ControllerNamespace
+				secret.Name = configuration.GetTerraformConfigSecretName()
+
+				found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(secret.Data).To(HaveKey(terraformv1alpha1.CheckovJobTemplateConfigMapKey))
+				Expect(string(secret.Data[terraformv1alpha1.CheckovJobTemplateConfigMapKey])).To(Equal("framework:\n  - terraform_plan\nsoft-fail: true\ncompact: true\ncheck:\n  - check0\nexternal-checks-dir:\n  - /run/policy/test"))
+			})
 		})
-	})
 
-	// CONTEXT INJECTION
-	When("using a context injection", func() {
-		BeforeEach(func() {
-			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-			Setup(configuration)
+		When("configuration has matched a policy", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+
+				// @note: we create a policy, we set the terraform plan job to complete and we create
+				// a fake security report
+				policy := fixtures.NewMatchAllPolicyConstraint("all")
+				policy.Spec.Constraints.Checkov.Checks = []string{"check0"}
+				plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
+				plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+				plan.Status.Succeeded = 1
+				tfplan := fixtures.NewTerraformPlanWithDiff(configuration, ctrl.ControllerNamespace)
+
+				report := &v1.Secret{}
+				report.Namespace = ctrl.ControllerNamespace
+				report.Name = configuration.GetTerraformPolicySecretName()
+				report.Data = map[string][]byte{"results_json.json": []byte(`{"summary":{"failed": 1}}`)}
+
+				Setup(configuration, policy, plan, report, tfplan)
+			})
+
+			When("policy report is missing due to interval error", func() {
+				BeforeEach(func() {
+					report := &v1.Secret{}
+					report.Namespace = ctrl.ControllerNamespace
+					report.Name = configuration.GetTerraformPolicySecretName()
+					ctrl.cc.Delete(context.TODO(), report)
+
+					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+				})
+
+				It("should have the conditions", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+				})
+
+				It("should indicate the we failed", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPolicy)
+					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonWarning))
+					Expect(cond.Message).To(Equal("Failed to find the secret: (default/policy-1234-122-1234-1234) containing checkov scan"))
+				})
+			})
+
+			When("policy report contains failed checks", func() {
+				BeforeEach(func() {
+					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+				})
+
+				It("should have the conditions", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+				})
+
+				It("should indicate the we failed", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPolicy)
+					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+					Expect(cond.Message).To(Equal("Configuration has failed security policy, refusing to continue"))
+				})
+
+				It("should have raised a event", func() {
+					Expect(recorder.Events).To(HaveLen(1))
+					Expect(recorder.Events[0]).To(ContainSubstring("Configuration has failed security policy, refusing to continue"))
+				})
+
+				It("should have not create an apply job", func() {
+					list := &batchv1.JobList{}
+
+					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+					Expect(len(list.Items)).To(Equal(1))
+				})
+
+				It("should copied the report into the configuration namespace", func() {
+					secret := &v1.Secret{}
+					secret.Namespace = configuration.Namespace
+					secret.Name = configuration.GetTerraformPolicySecretName()
+					found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeTrue())
+					Expect(secret.Data).To(HaveKey("results_json.json"))
+					Expect(string(secret.Data["results_json.json"])).To(ContainSubstring("summary"))
+				})
+			})
+
+			When("policy report contains no failed checks", func() {
+				BeforeEach(func() {
+					report := &v1.Secret{}
+					report.Namespace = ctrl.ControllerNamespace
+					report.Name = configuration.GetTerraformPolicySecretName()
+
+					// @note: delete the old secret adding a passed one
+					Expect(ctrl.cc.Delete(context.TODO(), report)).ToNot(HaveOccurred())
+					report.Data = map[string][]byte{"results_json.json": []byte(`{"summary":{"failed": 0}}`)}
+					Expect(ctrl.cc.Create(context.TODO(), report)).ToNot(HaveOccurred())
 
-			ctrl.EnableContextInjection = true
-			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 4)
-		})
+					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+				})
 
-		It("should have the conditions", func() {
-			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-			Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-		})
+				It("should have the conditions", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+				})
 
-		It("should have create a plan", func() {
-			list := &batchv1.JobList{}
+				It("should indicate the we failed", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-			Expect(len(list.Items)).To(Equal(1))
-		})
+					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPolicy)
+					Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonReady))
+					Expect(cond.Message).To(Equal("Passed security checks"))
+				})
 
-		It("should have create job configuration secret", func() {
-			secret := &v1.Secret{}
-			secret.Namespace = ctrl.ControllerNamespace
-			secret.Name = configuration.GetTerraformConfigSecretName()
+				It("should have not create an apply job", func() {
+					list := &batchv1.JobList{}
 
-			found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
-			Expect(err).ToNot(HaveOccurred())
-			Expect(found).To(BeTrue())
-		})
+					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+					Expect(len(list.Items)).To(Equal(2))
+				})
 
-		It("should have included the additional context variables", func() {
-			secret := &v1.Secret{}
-			secret.Namespace = ctrl.ControllerNamespace
-			secret.Name = configuration.GetTerraformConfigSecretName()
+				It("should copied the report into the configuration namespace", func() {
+					secret := &v1.Secret{}
+					secret.Namespace = configuration.Namespace
+					secret.Name = configuration.GetTerraformPolicySecretName()
+					found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeTrue())
+					Expect(secret.Data).To(HaveKey("results_json.json"))
+					Expect(string(secret.Data["results_json.json"])).To(ContainSubstring("summary"))
+				})
+			})
 
-			found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
-			Expect(err).ToNot(HaveOccurred())
-			Expect(found).To(BeTrue())
-			Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformVariablesConfigMapKey))
-			Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformBackendSecretKey))
+			When("policy contains no passes or failures", func() {
+				BeforeEach(func() {
+					report := &v1.Secret{}
+					report.Namespace = ctrl.ControllerNamespace
+					report.Name = configuration.GetTerraformPolicySecretName()
 
-			expected := "{\"name\":\"test\",\"terranetes\":{\"name\":\"bucket\",\"namespace\":\"apps\"}}\n"
+					// @note: delete the old secret adding a passed one
+					Expect(ctrl.cc.Delete(context.TODO(), report)).ToNot(HaveOccurred())
+					report.Data = map[string][]byte{"results_json.json": []byte(`{"failed": 0, "passed": 0}}`)}
+					Expect(ctrl.cc.Create(context.TODO(), report)).ToNot(HaveOccurred())
 
-			Expect(string(secret.Data[terraformv1alpha1.TerraformVariablesConfigMapKey])).To(Equal(expected))
-		})
-	})
+					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+				})
 
-	// CUSTOM BACKEND TEMPLATE
-	When("using a provider specific backend template", func() {
-		var template *v1.Secret
+				It("should have the conditions", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+				})
 
-		BeforeEach(func() {
-			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-			Setup(configuration)
+				It("should indicate the we failed", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-			provider := &terraformv1alpha1.Provider{}
-			provider.Name = configuration.Spec.ProviderRef.Name
-			Expect(cc.Get(context.Background(), provider.GetNamespacedName(), provider)).ToNot(HaveOccurred())
+					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPolicy)
+					Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonReady))
+					Expect(cond.Message).To(Equal("Passed security checks"))
+				})
 
-			template = fixtures.NewBackendTemplateSecret(ctrl.ControllerNamespace, "provider-template")
-			provider.Spec.BackendTemplate = &v1.SecretReference{
-				Namespace: template.Namespace,
-				Name:      template.Name,
-			}
-			Expect(cc.Create(context.Background(), template)).ToNot(HaveOccurred())
-			Expect(cc.Update(context.Background(), provider)).ToNot(HaveOccurred())
-		})
+				It("should have not create an apply job", func() {
+					list := &batchv1.JobList{}
 
-		Context("and the secret is not present", func() {
-			BeforeEach(func() {
-				Expect(cc.Delete(context.Background(), template)).ToNot(HaveOccurred())
+					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+					Expect(len(list.Items)).To(Equal(2))
+				})
 
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 0)
+				It("should copied the report into the configuration namespace", func() {
+					secret := &v1.Secret{}
+					secret.Namespace = configuration.Namespace
+					secret.Name = configuration.GetTerraformPolicySecretName()
+					found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeTrue())
+					Expect(secret.Data).To(HaveKey("results_json.json"))
+				})
 			})
 
-			It("should indicate the error", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+			When("policy report zero, but missing passed field", func() {
+				BeforeEach(func() {
+					report := &v1.Secret{}
+					report.Namespace = ctrl.ControllerNamespace
+					report.Name = configuration.GetTerraformPolicySecretName()
 
-				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
-				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
-				Expect(cond.Message).To(Equal(`Backend template secret "default/provider-template" not found, contact administrator`))
-			})
-		})
+					// @note: delete the old secret adding a passed one
+					Expect(ctrl.cc.Delete(context.TODO(), report)).ToNot(HaveOccurred())
+					report.Data = map[string][]byte{"results_json.json": []byte(`{"failed": 0}}`)}
+					Expect(ctrl.cc.Create(context.TODO(), report)).ToNot(HaveOccurred())
 
-		Context("and the template is not present", func() {
-			BeforeEach(func() {
-				template = fixtures.NewBackendTemplateSecret(ctrl.ControllerNamespace, "provider-template")
-				Expect(cc.Delete(context.Background(), template)).ToNot(HaveOccurred())
+					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+				})
 
-				template = fixtures.NewBackendTemplateSecret(ctrl.ControllerNamespace, "provider-template")
-				template.Data = map[string][]byte{}
-				Expect(cc.Create(context.Background(), template)).ToNot(HaveOccurred())
+				It("should have the conditions", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+				})
 
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 0)
-			})
+				It("should indicate the we failed", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-			It("should indicate the error", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPolicy)
+					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonError))
+					Expect(cond.Message).To(Equal("Security report is missing passed field"))
+				})
 
-				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
-				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
-				Expect(cond.Message).To(Equal(`Backend template secret "default/provider-template" does not contain the backend.tf key`))
-			})
-		})
+				It("should have not create an apply job", func() {
+					list := &batchv1.JobList{}
 
-		Context("and the template is present", func() {
-			BeforeEach(func() {
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 0)
+					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+					Expect(len(list.Items)).To(Equal(1))
+				})
 			})
 
-			It("should have used the custom backend template from the provider", func() {
-				secret := &v1.Secret{}
-				secret.Namespace = ctrl.ControllerNamespace
-				secret.Name = configuration.GetTerraformConfigSecretName()
+			When("policy report zero, but missing failed field", func() {
+				BeforeEach(func() {
+					report := &v1.Secret{}
+					report.Namespace = ctrl.ControllerNamespace
+					report.Name = configuration.GetTerraformPolicySecretName()
 
-				found, err := kubernetes.GetIfExists(context.Background(), cc, secret)
-				Expect(err).ToNot(HaveOccurred())
-				Expect(found).To(BeTrue())
+					// @note: delete the old secret adding a passed one
+					Expect(ctrl.cc.Delete(context.TODO(), report)).ToNot(HaveOccurred())
+					report.Data = map[string][]byte{"results_json.json": []byte(`{"passed": 0}}`)}
+					Expect(ctrl.cc.Create(context.TODO(), report)).ToNot(HaveOccurred())
 
-				Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformBackendSecretKey))
-				Expect(string(secret.Data[terraformv1alpha1.TerraformBackendSecretKey])).To(ContainSubstring(`backend "s3"`))
-				Expect(string(secret.Data[terraformv1alpha1.TerraformBackendSecretKey])).To(ContainSubstring(`terranetes-controller-state"`))
-				Expect(string(secret.Data[terraformv1alpha1.TerraformBackendSecretKey])).To(ContainSubstring(`AWS_SECRET_ACCESS_KEY"`))
-			})
-		})
-	})
+					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+				})
 
-	When("using a custom backend template", func() {
-		When("the template is not present", func() {
-			BeforeEach(func() {
-				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-				Setup(configuration)
-				ctrl.BackendTemplate = "not_there"
+				It("should have the conditions", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+				})
 
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 10)
-			})
+				It("should indicate the we failed", func() {
+					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-			It("should have the conditions", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-			})
+					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPolicy)
+					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonError))
+					Expect(cond.Message).To(Equal("Security report is missing failed field"))
+				})
 
-			It("should indicate the configuration failed", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				It("should have not create an apply job", func() {
+					list := &batchv1.JobList{}
 
-				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
-				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
-				Expect(cond.Message).To(Equal("Backend template secret \"default/not_there\" not found, contact administrator"))
+					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+					Expect(len(list.Items)).To(Equal(1))
+				})
 			})
+		})
+	})
 
-			It("should not create any jobs", func() {
-				list := &batchv1.JobList{}
+	When("using a custom job template", func() {
+		templateName := "template"
+
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			Setup(configuration)
 
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(0))
-			})
+			cm := fixtures.NewJobTemplateConfigmap(ctrl.ControllerNamespace, templateName)
+			ctrl.JobTemplate = cm.Name
+
+			Expect(ctrl.cc.Create(context.TODO(), cm)).ToNot(HaveOccurred())
 		})
 
-		When("the template is present but missing required keys", func() {
+		When("the template is missing", func() {
 			BeforeEach(func() {
-				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-				backend := fixtures.NewBackendTemplateSecret(ctrl.ControllerNamespace, "missing_key")
-				delete(backend.Data, "backend.tf")
-				Setup(configuration, backend)
-				ctrl.BackendTemplate = "missing_key"
-
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 10)
+				ctrl.cc.Delete(context.TODO(), fixtures.NewJobTemplateConfigmap(ctrl.ControllerNamespace, templateName))
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 			})
 
-			It("should have the conditions", func() {
+			It("should have conditions", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
 			})
 
-			It("should indicate the configuration failed", func() {
+			It("should indicate action is required in the conditions", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
 				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
 				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
 				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
-				Expect(cond.Message).To(Equal("Backend template secret \"default/missing_key\" does not contain the backend.tf key"))
+				Expect(cond.Message).To(Equal("Custom job template (default/template) does not exists"))
 			})
 
-			It("should not create any jobs", func() {
-				list := &batchv1.JobList{}
-
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(0))
+			It("should have raised a event", func() {
+				Expect(recorder.Events).To(HaveLen(1))
+				Expect(recorder.Events[0]).To(ContainSubstring("Custom job template (default/template) does not exists"))
 			})
 		})
 
-		When("the template is present but missing required key is empty", func() {
+		When("the template does not have the correct key", func() {
 			BeforeEach(func() {
-				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-				backend := fixtures.NewBackendTemplateSecret(ctrl.ControllerNamespace, "missing_key")
-				backend.Data["backend.tf"] = []byte("")
-				Setup(configuration, backend)
-				ctrl.BackendTemplate = "missing_key"
+				// @step: delete the old one and create a new one with missing keys
+				cm := fixtures.NewJobTemplateConfigmap(ctrl.ControllerNamespace, templateName)
+				invalid := fixtures.NewJobTemplateConfigmap(ctrl.ControllerNamespace, templateName)
+				invalid.Data = map[string]string{}
 
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 10)
+				ctrl.cc.Delete(context.TODO(), cm)
+				ctrl.cc.Create(context.TODO(), invalid)
+
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 			})
 
-			It("should have the conditions", func() {
+			It("should have the template", func() {
+				cm := fixtures.NewJobTemplateConfigmap(ctrl.ControllerNamespace, templateName)
+				req := types.NamespacedName{Namespace: cm.Namespace, Name: cm.Name}
+
+				Expect(ctrl.cc.Get(context.TODO(), req, &v1.ConfigMap{})).ToNot(HaveOccurred())
+			})
+
+			It("should have conditions", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
 			})
 
-			It("should indicate the configuration failed", func() {
+			It("should indicate action is required due to missing keys", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
 				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
 				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
 				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
-				Expect(cond.Message).To(Equal("Backend template secret \"default/missing_key\" does not contain the backend.tf key"))
+				Expect(cond.Message).To(Equal("Custom job template (default/template) does not contain the \"job.yaml\" key"))
 			})
 
-			It("should not create any jobs", func() {
-				list := &batchv1.JobList{}
-
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(0))
+			It("should have raised a event", func() {
+				Expect(recorder.Events).To(HaveLen(1))
+				Expect(recorder.Events[0]).To(ContainSubstring("Custom job template (default/template) does not contain the \"job.yaml\" key"))
 			})
 		})
 
-		When("the template is present, valid and plan has not been run", func() {
+		When("we have a valid template", func() {
 			BeforeEach(func() {
-				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-				backend := fixtures.NewBackendTemplateSecret(ctrl.ControllerNamespace, "backend-s3")
-				Setup(configuration, backend)
-				ctrl.BackendTemplate = "backend-s3"
-
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 10)
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 			})
 
-			It("should have the conditions", func() {
+			It("should have conditions", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
 			})
 
-			It("should indicate the provider is ready", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-
-				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionProviderReady)
-				Expect(cond.Status).To(Equal(metav1.ConditionTrue))
-				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonReady))
-				Expect(cond.Message).To(Equal("Provider ready"))
-			})
-
-			It("should have created the generated configuration secret", func() {
-				secret := &v1.Secret{}
-				secret.Namespace = ctrl.ControllerNamespace
-				secret.Name = configuration.GetTerraformConfigSecretName()
-
-				found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
-				Expect(err).ToNot(HaveOccurred())
-				Expect(found).To(BeTrue())
-
-				Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformVariablesConfigMapKey))
-				Expect(secret.Data).To(HaveKey(terraformv1alpha1.TerraformBackendSecretKey))
-			})
-
-			It("should have create a terraform backend configuration", func() {
-				expected := `
-terraform {
-  backend "s3" {
-    bucket     = "terranetes-controller-state"
-    key        = "cluster_one/apps/bucket"
-    region     = "eu-west-2"
-    access_key = "AWS_ACCESS_KEY_ID"
-    secret_key = "AWS_SECRET_ACCESS_KEY"
-  }
-}
-`
-				secret := &v1.Secret{}
-				secret.Namespace = ctrl.ControllerNamespace
-				secret.Name = configuration.GetTerraformConfigSecretName()
-
-				found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
-				Expect(err).ToNot(HaveOccurred())
-				Expect(found).To(BeTrue())
-
-				backend := string(secret.Data[terraformv1alpha1.TerraformBackendSecretKey])
-				Expect(backend).ToNot(BeZero())
-				Expect(backend).To(Equal(expected))
-			})
-
 			It("should indicate the terraform plan is running", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
 				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
 				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
 				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
-				Expect(cond.Message).To(Equal("Terraform plan is running"))
-			})
-
-			It("should have created job for the terraform plan", func() {
-				list := &batchv1.JobList{}
-
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(1))
+				Expect(cond.Message).To(Equal("Terraform plan in progress"))
 			})
 		})
 	})
 
-	// DRIFT
-	When("configuration has drift options", func() {
-		When("drift annotation is tagged but configuration has not opted in for detection", func() {
+	// BEFORE TERRAFORM APPLY
+	When("terraform apply has not been provisoned", func() {
+		When("the configuration needs approval", func() {
 			BeforeEach(func() {
 				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-				configuration.Annotations = map[string]string{terraformv1alpha1.DriftAnnotation: "true"}
+				configuration.Annotations = map[string]string{terraformv1alpha1.ApplyAnnotation: "false"}
+				plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
+				plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+				plan.Status.Succeeded = 1
+				tfplan := fixtures.NewTerraformPlanWithDiff(configuration, ctrl.ControllerNamespace)
 
-				Setup(configuration)
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+				Setup(configuration, plan, tfplan)
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 5)
 			})
 
 			It("should have the conditions", func() {
@@ -2421,939 +3839,1251 @@ terraform {
 				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
 			})
 
-			It("should indicate the terraform plan is running", func() {
+			It("should indicate configuration is waiting approval", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
+				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformApply)
 				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
-				Expect(cond.Message).To(Equal("Terraform plan is running"))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(cond.Message).To(Equal("Waiting for terraform apply annotation to be set to true"))
 			})
 
-			It("should have created job for the terraform plan", func() {
-				list := &batchv1.JobList{}
+			It("should indicate the ready condition is false", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(1))
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
+				Expect(cond.Message).To(Equal("Waiting for changes to be approved"))
 			})
 
-			It("it should have the configuration labels", func() {
-				list := &batchv1.JobList{}
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(1))
+			It("should indicate the resource out of sync", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(configuration.Status.ResourceStatus).To(Equal(terraformv1alpha1.ResourcesOutOfSync))
+			})
 
-				labels := list.Items[0].GetLabels()
-				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationNameLabel))
-				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationNamespaceLabel))
-				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationGenerationLabel))
-				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationStageLabel))
-				Expect(labels).To(HaveKey(terraformv1alpha1.DriftAnnotation))
-				Expect(labels[terraformv1alpha1.ConfigurationStageLabel]).To(Equal(terraformv1alpha1.StageTerraformPlan))
-				Expect(labels[terraformv1alpha1.ConfigurationNameLabel]).To(Equal(configuration.Name))
-				Expect(labels[terraformv1alpha1.ConfigurationNamespaceLabel]).To(Equal(configuration.Namespace))
-				Expect(labels[terraformv1alpha1.DriftAnnotation]).To(Equal("true"))
+			It("should have raised a kubernetes event", func() {
+				Expect(recorder.Events).To(HaveLen(1))
+				Expect(recorder.Events[0]).To(Equal("(apps/bucket) Warning Action Required: Waiting for terraform apply annotation to be set to true"))
 			})
 
-			It("should have created a watch job in the configuration namespace", func() {
+			It("should not have created a job", func() {
 				list := &batchv1.JobList{}
-				Expect(cc.List(context.TODO(), list, client.InNamespace(configuration.Namespace))).ToNot(HaveOccurred())
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
 				Expect(len(list.Items)).To(Equal(1))
-				Expect(len(list.Items[0].Spec.Template.Spec.Containers)).To(Equal(1))
-
-				container := list.Items[0].Spec.Template.Spec.Containers[0]
-				Expect(container.Name).To(Equal("watch"))
-				Expect(container.Command).To(Equal([]string{"/watch_logs.sh"}))
-				Expect(container.Args).To(Equal([]string{"-e", "http://controller.default.svc.cluster.local/v1/builds/apps/bucket/logs?generation=0&name=bucket&namespace=apps&stage=plan&uid=1234-122-1234-1234"}))
 			})
 		})
 
-		When("drift check has already been run", func() {
+		When("the configuration is approved", func() {
 			BeforeEach(func() {
 				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-				configuration.Spec.EnableDriftDetection = true
-				configuration.Annotations = map[string]string{
-					terraformv1alpha1.DriftAnnotation: "true",
-					terraformv1alpha1.ApplyAnnotation: "false",
-				}
-
 				plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
 				plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
 				plan.Status.Succeeded = 1
 				tfplan := fixtures.NewTerraformPlanWithDiff(configuration, ctrl.ControllerNamespace)
-
 				Setup(configuration, plan, tfplan)
 				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 5)
 			})
 
-			It("should not create another job", func() {
-				list := &batchv1.JobList{}
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-
-				Expect(len(list.Items)).To(Equal(1))
-			})
-
 			It("should have the conditions", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
 			})
 
-			It("should indicate the terraform plan is running", func() {
+			It("should indicate the terraform apply is running", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformApply)
-				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
-				Expect(cond.Message).To(Equal("Waiting for terraform apply annotation to be set to true"))
+				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
+				Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonReady))
+				Expect(cond.Message).To(Equal("Terraform plan is complete"))
 			})
-		})
 
-		When("drift annotation changes", func() {
-			BeforeEach(func() {
-				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-				configuration.Spec.EnableAutoApproval = true
-				configuration.Annotations = map[string]string{terraformv1alpha1.DriftAnnotation: "changed"}
+			It("should have created job for the terraform apply", func() {
+				list := &batchv1.JobList{}
 
-				job := &batchv1.Job{}
-				job.Name = "test"
-				job.Namespace = ctrl.ControllerNamespace
-				job.Labels = map[string]string{
-					terraformv1alpha1.ConfigurationGenerationLabel: fmt.Sprintf("%d", configuration.GetGeneration()),
-					terraformv1alpha1.ConfigurationNameLabel:       configuration.Name,
-					terraformv1alpha1.ConfigurationNamespaceLabel:  configuration.Namespace,
-					terraformv1alpha1.ConfigurationStageLabel:      terraformv1alpha1.StageTerraformPlan,
-					terraformv1alpha1.ConfigurationUIDLabel:        string(configuration.GetUID()),
-					terraformv1alpha1.DriftAnnotation:              "different_before",
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(2))
+			})
+
+			It("should have a terraform container", func() {
+				list := &batchv1.JobList{}
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(2))
+
+				expected := []string{
+					"--comment=Executing OpenTofu",
+					"--command=/usr/local/bin/tofu apply --var-file variables.tfvars.json -lock=false -no-color -input=false -auto-approve",
+					"--name=terraform",
+					"--namespace=$(KUBE_NAMESPACE)",
+					"--on-error=/run/steps/terraform.failed",
+					"--on-success=/run/steps/terraform.complete",
+					"--results=/run/steps/results.json",
+					"--results-secret=$(STEP_RESULTS_NAME)",
 				}
-				Setup(configuration, job)
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
-			})
+				job := list.Items[0]
+				container := job.Spec.Template.Spec.Containers[0]
 
-			It("should indicate the terraform plan is running", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				Expect(container.Name).To(Equal("terraform"))
+				Expect(container.Command).To(Equal([]string{"/run/bin/step"}))
+				Expect(container.Args).To(Equal(expected))
 
-				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
-				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
-				Expect(cond.Message).To(Equal("Terraform plan is running"))
-			})
+				Expect(len(container.EnvFrom)).To(Equal(1))
+				Expect(container.EnvFrom[0].SecretRef).ToNot(BeNil())
+				Expect(container.EnvFrom[0].SecretRef.Name).To(Equal("aws"))
 
-			It("should have an out of sync status", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-				Expect(configuration.Status.ResourceStatus).To(Equal(terraformv1alpha1.ResourcesOutOfSync))
+				Expect(len(container.Env)).To(Equal(10))
+				Expect(container.Env[5].Name).To(Equal("TERRAFORM_STATE_NAME"))
+				Expect(container.Env[5].Value).To(Equal(configuration.GetTerraformStateSecretName()))
+				Expect(container.Env[6].Name).To(Equal("TERRAFORM_PLAN_OUT_NAME"))
+				Expect(container.Env[6].Value).To(Equal(configuration.GetTerraformPlanOutSecretName()))
+				Expect(container.Env[7].Name).To(Equal("TERRAFORM_PLAN_JSON_NAME"))
+				Expect(container.Env[7].Value).To(Equal(configuration.GetTerraformPlanJSONSecretName()))
+
+				Expect(container.VolumeMounts).To(HaveLen(3))
+				Expect(container.VolumeMounts[0].Name).To(Equal("planout"))
+				Expect(container.VolumeMounts[1].Name).To(Equal("run"))
+				Expect(container.VolumeMounts[2].Name).To(Equal("source"))
 			})
 
-			It("should create another job", func() {
+			It("it should have the configuration labels", func() {
 				list := &batchv1.JobList{}
 				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(2))
+
+				labels := list.Items[0].GetLabels()
+				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationNameLabel))
+				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationNamespaceLabel))
+				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationGenerationLabel))
+				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationStageLabel))
+				Expect(labels[terraformv1alpha1.ConfigurationStageLabel]).To(Equal(terraformv1alpha1.StageTerraformApply))
+				Expect(labels[terraformv1alpha1.ConfigurationNameLabel]).To(Equal(configuration.Name))
+				Expect(labels[terraformv1alpha1.ConfigurationNamespaceLabel]).To(Equal(configuration.Namespace))
+			})
+
+			It("should have created a watch job in the configuration namespace", func() {
+				list := &batchv1.JobList{}
+				Expect(cc.List(context.TODO(), list, client.InNamespace(configuration.Namespace))).ToNot(HaveOccurred())
+				Expect(len(list.Items)).To(Equal(1))
+				Expect(len(list.Items[0].Spec.Template.Spec.Containers)).To(Equal(1))
+
+				container := list.Items[0].Spec.Template.Spec.Containers[0]
+				Expect(container.Name).To(Equal("watch"))
+				Expect(container.Command).To(Equal([]string{"/watch_logs.sh"}))
+				Expect(container.Args).To(Equal([]string{"-e", "http://controller.default.svc.cluster.local/v1/builds/apps/bucket/logs?generation=0&name=bucket&namespace=apps&stage=apply&uid=1234-122-1234-1234"}))
+			})
+
+			It("should ask us to requeue", func() {
+				Expect(result).To(Equal(reconcile.Result{RequeueAfter: 5 * time.Second}))
+				Expect(rerr).To(BeNil())
 			})
 		})
 	})
 
-	// CHECKOV
-	When("checkov with an external source", func() {
+	// ERROR DETECTION
+	When("terraform plan has failed and error detectors are configured", func() {
 		BeforeEach(func() {
 			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-			Setup(configuration)
-
-			constraint := fixtures.NewMatchAllPolicyConstraint("all")
-			constraint.Spec.Constraints.Checkov.Source = &terraformv1alpha1.ExternalSource{
-				URL:           "https://github.com/appvia/terranetes-policy?ref=main",
-				Configuration: "config.yaml",
+			plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
+			plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue}}
+			plan.Status.Failed = 1
+
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      plan.Name + "-abcde",
+					Namespace: ctrl.ControllerNamespace,
+					Labels:    map[string]string{"job-name": plan.Name},
+				},
+				Status: v1.PodStatus{Phase: v1.PodFailed},
 			}
 
-			Expect(ctrl.cc.Create(context.TODO(), constraint)).ToNot(HaveOccurred())
+			detectors := &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "detectors",
+					Namespace: ctrl.ControllerNamespace,
+					Labels:    map[string]string{terraformv1alpha1.ErrorDetectorsLabel: "true"},
+				},
+				Data: map[string]string{
+					"custom.yaml": `
+- regex: "fake"
+  message: "This is only a warning"
+  severity: warning
+- regex: "fake logs"
+  provider: aws
+  message: "Detected a custom error"
+  remediationURL: https://example.com/runbook
+- regex: "fake logs"
+  provider: google
+  message: "Should not match a different provider"
+`,
+					"invalid.yaml": `- regex: "("`,
+				},
+			}
 
+			Setup(configuration, plan, detectors)
+			ctrl.kc = kfake.NewSimpleClientset(pod)
 			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 		})
 
-		It("should have conditions", func() {
-			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-			Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+		It("should not error", func() {
+			Expect(rerr).ToNot(HaveOccurred())
 		})
 
-		It("should created a terraform plan job", func() {
-			list := &batchv1.JobList{}
+		It("should indicate the custom error on the ready condition", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-			Expect(len(list.Items)).To(Equal(1))
+			cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+			Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+			Expect(cond.Message).To(Equal("Detected a custom error (see https://example.com/runbook)"))
 		})
 
-		It("should add a source init container to the job", func() {
-			list := &batchv1.JobList{}
+		It("should raise an event for the warning", func() {
+			Expect(recorder.Events).To(ContainElement(ContainSubstring("This is only a warning")))
+		})
 
-			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-			Expect(len(list.Items)).To(Equal(1))
-			job := list.Items[0]
+		It("should not requeue", func() {
+			Expect(result).To(Equal(reconcile.Result{}))
+		})
+	})
 
-			expectedArgs := []string{
-				"--comment=Retrieve policy source",
-				"--command=/bin/source --dest=/run/checkov --source=https://github.com/appvia/terranetes-policy?ref=main",
+	// SOURCE VERIFICATION
+	When("the module source has failed verification", func() {
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			configuration.Spec.ModuleChecksum = "h1:expected"
+			plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
+			plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue}}
+			plan.Status.Failed = 1
+
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      plan.Name + "-abcde",
+					Namespace: ctrl.ControllerNamespace,
+					Labels:    map[string]string{"job-name": plan.Name},
+				},
+				Status: v1.PodStatus{
+					Phase: v1.PodFailed,
+					InitContainerStatuses: []v1.ContainerStatus{
+						{
+							Name: jobs.SetupContainerName,
+							State: v1.ContainerState{
+								Terminated: &v1.ContainerStateTerminated{
+									ExitCode: 1,
+									Message:  "[build] retrieving the module source\n[error] failed to run: source verification failed: checksum mismatch, expected: h1:expected, got: h1:actual",
+								},
+							},
+						},
+					},
+				},
 			}
 
-			Expect(len(job.Spec.Template.Spec.Containers)).To(Equal(2))
-			Expect(job.Spec.Template.Spec.InitContainers[2].Name).To(Equal("policy-source"))
-			Expect(job.Spec.Template.Spec.InitContainers[2].Command).To(Equal([]string{"/run/bin/step"}))
-			Expect(job.Spec.Template.Spec.InitContainers[2].Args).To(Equal(expectedArgs))
+			Setup(configuration, plan)
+			ctrl.kc = kfake.NewSimpleClientset(pod)
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 		})
 
-		It("should not have checkov configuration in job secret", func() {
-			secret := &v1.Secret{}
-			secret.Namespace = ctrl.ControllerNamespace
-			secret.Name = configuration.GetTerraformConfigSecretName()
+		It("should not error", func() {
+			Expect(rerr).ToNot(HaveOccurred())
+		})
 
-			found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
-			Expect(err).ToNot(HaveOccurred())
-			Expect(found).To(BeTrue())
-			Expect(secret.Data).ToNot(HaveKey(terraformv1alpha1.CheckovJobTemplateConfigMapKey))
+		It("should indicate the verification failure on the ready condition", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+			cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+			Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+			Expect(cond.Message).To(Equal("Module source verification failed: checksum mismatch, expected: h1:expected, got: h1:actual"))
 		})
 
-		It("should have updated the command line for checkov scan", func() {
-			list := &batchv1.JobList{}
+		It("should not requeue", func() {
+			Expect(result).To(Equal(reconcile.Result{}))
+		})
+	})
 
-			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-			Expect(len(list.Items)).To(Equal(1))
-			job := list.Items[0]
+	// STEP RESULTS
+	When("the terraform plan has failed and the steps have recorded their results", func() {
+		var jobName string
 
-			expected := []string{
-				"--comment=Evaluating Against Security Policy",
-				"--command=/usr/local/bin/checkov --config /run/checkov/config.yaml --framework terraform_plan -f /run/tfplan.json --soft-fail -o json -o cli --output-file-path /run --repo-root-for-plan-enrichment /data --download-external-modules true >/dev/null",
-				"--command=/bin/cat /run/results_cli.txt",
-				"--namespace=$(KUBE_NAMESPACE)",
-				"--upload=$(POLICY_REPORT_NAME)=/run/results_json.json",
-				"--is-failure=/run/steps/terraform.failed",
-				"--wait-on=/run/steps/terraform.complete",
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
+			plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue}}
+			plan.Status.Failed = 1
+			jobName = plan.Name
+		})
+
+		JustBeforeEach(func() {
+			secret := &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      configuration.GetTerraformStepResultsSecretName(),
+					Namespace: ctrl.ControllerNamespace,
+				},
+				Data: map[string][]byte{
+					"results.json": []byte(`{"job":"` + jobName + `","steps":[` +
+						`{"name":"setup","duration":2000000000,"exitCode":0},` +
+						`{"name":"terraform","duration":60000000000,"exitCode":1,"retries":2,"stderr":"Error: boom"}]}`),
+				},
 			}
+			plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
+			plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue}}
+			plan.Status.Failed = 1
 
-			Expect(len(job.Spec.Template.Spec.Containers)).To(Equal(2))
-			Expect(job.Spec.Template.Spec.Containers[1].Name).To(Equal("verify-policy"))
-			Expect(job.Spec.Template.Spec.Containers[1].Command).To(Equal([]string{"/run/bin/step"}))
-			Expect(job.Spec.Template.Spec.Containers[1].Args).To(Equal(expected))
+			Setup(configuration, plan, secret)
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 		})
-	})
 
-	When("checkov is configured", func() {
-		When("configuration matches a policy", func() {
-			BeforeEach(func() {
-				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-				Setup(configuration)
-				constraint := fixtures.NewMatchAllPolicyConstraint("all")
-				constraint.Spec.Constraints.Checkov.Checks = []string{"check0", "check1"}
-				constraint.Spec.Constraints.Checkov.SkipChecks = nil
+		It("should not error", func() {
+			Expect(rerr).ToNot(HaveOccurred())
+		})
 
-				Expect(ctrl.cc.Create(context.TODO(), constraint)).ToNot(HaveOccurred())
+		It("should surface the step results on the status", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			Expect(configuration.Status.Steps).To(Equal(&terraformv1alpha1.StepsStatus{
+				FailedStep: "terraform",
+				Stage:      terraformv1alpha1.StageTerraformPlan,
+				Steps: []terraformv1alpha1.StepStatus{
+					{Name: "setup", Duration: metav1.Duration{Duration: 2 * time.Second}},
+					{Name: "terraform", Duration: metav1.Duration{Duration: time.Minute}, ExitCode: 1, Retries: 2, Message: "Error: boom"},
+				},
+			}))
+		})
+
+		Context("and the results belong to a previous job", func() {
+			BeforeEach(func() {
+				jobName = "previous"
 			})
 
-			It("should have conditions", func() {
+			It("should not surface the step results", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+				Expect(configuration.Status.Steps).To(BeNil())
 			})
+		})
+	})
 
-			It("should created a terraform plan job", func() {
-				list := &batchv1.JobList{}
+	// HOOKS
+	When("a policy injects hooks into the configuration", func() {
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			configuration.Spec.Hooks = []terraformv1alpha1.Hook{
+				{Name: "format", When: terraformv1alpha1.HookPrePlan, Image: "alpine:3", Commands: []string{"true"}},
+			}
+			policy := fixtures.NewPolicy("hooks")
+			policy.Spec.Hooks = []terraformv1alpha1.HookDefaults{
+				{
+					Hooks: []terraformv1alpha1.Hook{
+						{Name: "lint", When: terraformv1alpha1.HookPostPlan, Image: "alpine:3", Commands: []string{"false"}},
+						{Name: "notify", When: terraformv1alpha1.HookPostPlan, Image: "alpine:3", Commands: []string{"true"}},
+						{Name: "cleanup", When: terraformv1alpha1.HookPostApply, Image: "alpine:3", Commands: []string{"true"}},
+					},
+				},
+			}
 
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(1))
-			})
+			Setup(configuration, policy)
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 0)
+		})
 
-			It("should add a verify-policy container to the job", func() {
-				list := &batchv1.JobList{}
+		It("should not error", func() {
+			Expect(rerr).ToNot(HaveOccurred())
+		})
 
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(1))
-				job := list.Items[0]
+		It("should render the hooks for the stage into the plan job", func() {
+			list := &batchv1.JobList{}
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(list.Items).To(HaveLen(1))
 
-				Expect(len(job.Spec.Template.Spec.Containers)).To(Equal(2))
-				Expect(job.Spec.Template.Spec.Containers[1].Name).To(Equal("verify-policy"))
-				Expect(job.Spec.Template.Spec.Containers[1].Command).To(Equal([]string{"/run/bin/step"}))
-				Expect(job.Spec.Template.Spec.Containers[1].Args).To(Equal(verifyPolicyArguments))
-				Expect(job.Spec.Template.Spec.Containers[1].VolumeMounts).To(HaveLen(3))
-				Expect(job.Spec.Template.Spec.Containers[1].VolumeMounts[0].Name).To(Equal("checkov"))
-				Expect(job.Spec.Template.Spec.Containers[1].VolumeMounts[0].MountPath).To(Equal("/run/checkov"))
-			})
+			var names []string
+			for _, x := range append(list.Items[0].Spec.Template.Spec.InitContainers, list.Items[0].Spec.Template.Spec.Containers...) {
+				names = append(names, x.Name)
+			}
+			Expect(names).To(ContainElements("hook-format", "hook-lint", "hook-notify"))
+			Expect(names).ToNot(ContainElement("hook-cleanup"))
+		})
+	})
 
-			It("should have a checkov configuration secret", func() {
-				secret := &v1.Secret{}
-				secret.Namespace = ctrl.ControllerNamespace
-				secret.Name = configuration.GetTerraformConfigSecretName()
+	When("the configuration defines hooks which have been disabled", func() {
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			configuration.Spec.Hooks = []terraformv1alpha1.Hook{
+				{Name: "lint", When: terraformv1alpha1.HookPrePlan, Image: "alpine:3", Commands: []string{"true"}},
+			}
 
-				found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
-				Expect(err).ToNot(HaveOccurred())
-				Expect(found).To(BeTrue())
-				Expect(secret.Data).To(HaveKey(terraformv1alpha1.CheckovJobTemplateConfigMapKey))
-				Expect(string(secret.Data[terraformv1alpha1.CheckovJobTemplateConfigMapKey])).To(Equal("framework:\n  - terraform_plan\nsoft-fail: true\ncompact: true\ncheck:\n  - check0\n  - check1"))
-			})
+			Setup(configuration)
+			ctrl.EnableConfigurationHooks = false
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 0)
 		})
 
-		When("configuration matches multiple policies, highest priority wins", func() {
-			BeforeEach(func() {
-				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-				Setup(configuration)
-
-				// @notes: we add two policies here, the later one with the namespace should take priority
-				// given the namespace selector.
+		It("should not error", func() {
+			Expect(rerr).ToNot(HaveOccurred())
+		})
 
-				all := fixtures.NewMatchAllPolicyConstraint("all")
-				all.Spec.Constraints.Checkov.Checks = []string{"check0"}
+		It("should indicate the hooks are disabled on the ready condition", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				priority := fixtures.NewMatchAllPolicyConstraint("priority")
-				priority.Spec.Constraints.Checkov.Checks = []string{"priority"}
-				priority.Spec.Constraints.Checkov.Selector = &terraformv1alpha1.Selector{}
-				priority.Spec.Constraints.Checkov.Selector.Namespace = &metav1.LabelSelector{
-					MatchLabels: map[string]string{"name": cfgNamespace},
-				}
+			cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+			Expect(cond.Message).To(Equal("Configuration hooks have been disabled, contact a platform administrator"))
+		})
 
-				Expect(ctrl.cc.Create(context.TODO(), all)).ToNot(HaveOccurred())
-				Expect(ctrl.cc.Create(context.TODO(), priority)).ToNot(HaveOccurred())
+		It("should not create any jobs", func() {
+			list := &batchv1.JobList{}
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(list.Items).To(BeEmpty())
+		})
+	})
 
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
-			})
+	When("a policy hook selects the namespace which is missing from the cache", func() {
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			policy := fixtures.NewPolicy("hooks")
+			policy.Spec.Hooks = []terraformv1alpha1.HookDefaults{
+				{
+					Selector: terraformv1alpha1.DefaultVariablesSelector{
+						Namespace: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+					},
+					Hooks: []terraformv1alpha1.Hook{
+						{Name: "lint", When: terraformv1alpha1.HookPrePlan, Image: "alpine:3", Commands: []string{"true"}},
+					},
+				},
+			}
+			Setup(configuration, policy)
+			ctrl.cache.SetDefault(cfgNamespace, (*v1.Namespace)(nil))
 
-			It("should have conditions", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-			})
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 0)
+		})
 
-			It("should created a terraform plan job", func() {
-				list := &batchv1.JobList{}
+		It("should not error", func() {
+			Expect(rerr).ToNot(HaveOccurred())
+		})
 
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(1))
-			})
+		It("should indicate the namespace could not be retrieved and requeue", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-			It("should have selected priority policy", func() {
-				list := &batchv1.JobList{}
+			cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Message).To(Equal("Failed to retrieve the namespace from the cache"))
+			Expect(result.RequeueAfter).To(Equal(30 * time.Second))
+		})
 
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(1))
-				job := list.Items[0]
+		It("should not create the plan job", func() {
+			list := &batchv1.JobList{}
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(list.Items).To(BeEmpty())
+		})
+	})
 
-				Expect(len(job.Spec.Template.Spec.Containers)).To(Equal(2))
-				Expect(job.Spec.Template.Spec.Containers[1].Name).To(Equal("verify-policy"))
-				Expect(job.Spec.Template.Spec.Containers[1].Command).To(Equal([]string{"/run/bin/step"}))
-				Expect(job.Spec.Template.Spec.Containers[1].Args).To(Equal(verifyPolicyArguments))
-			})
+	When("a configuration hook conflicts with a policy hook", func() {
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			configuration.Spec.Hooks = []terraformv1alpha1.Hook{
+				{Name: "lint", When: terraformv1alpha1.HookPrePlan, Image: "alpine:3", Commands: []string{"true"}},
+			}
+			policy := fixtures.NewPolicy("hooks")
+			policy.Spec.Hooks = []terraformv1alpha1.HookDefaults{
+				{
+					Hooks: []terraformv1alpha1.Hook{
+						{Name: "lint", When: terraformv1alpha1.HookPostPlan, Image: "alpine:3", Commands: []string{"false"}},
+					},
+				},
+			}
 
-			It("should have a checkov configuration secret", func() {
-				secret := &v1.Secret{}
-				secret.Namespace = ctrl.ControllerNamespace
-				secret.Name = configuration.GetTerraformConfigSecretName()
+			Setup(configuration, policy)
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 0)
+		})
 
-				found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
-				Expect(err).ToNot(HaveOccurred())
-				Expect(found).To(BeTrue())
-				Expect(secret.Data).To(HaveKey(terraformv1alpha1.CheckovJobTemplateConfigMapKey))
-				Expect(string(secret.Data[terraformv1alpha1.CheckovJobTemplateConfigMapKey])).To(Equal("framework:\n  - terraform_plan\nsoft-fail: true\ncompact: true\ncheck:\n  - priority"))
-			})
+		It("should not error", func() {
+			Expect(rerr).ToNot(HaveOccurred())
 		})
 
-		When("we have external checks defined on the security policy", func() {
-			BeforeEach(func() {
-				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-				Setup(configuration)
+		It("should indicate the conflict on the ready condition", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				// @notes: we add a policy with and external check
-				external := fixtures.NewMatchAllPolicyConstraint("external")
-				external.Spec.Constraints.Checkov.Checks = []string{"check0"}
-				external.Spec.Constraints.Checkov.External = []terraformv1alpha1.ExternalCheck{
-					{
-						Name: "test",
-						URL:  "https://example.com//dir",
-						SecretRef: &v1.SecretReference{
-							Name: "test-secret",
-						},
-					},
-				}
+			cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+			Expect(cond.Message).To(Equal("Hook \"lint\" conflicts with a hook defined by the policy: \"hooks\""))
+		})
 
-				Expect(ctrl.cc.Create(context.TODO(), external)).ToNot(HaveOccurred())
+		It("should not create any jobs", func() {
+			list := &batchv1.JobList{}
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(list.Items).To(BeEmpty())
+		})
+	})
 
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
-			})
+	When("the terraform plan has failed due to a hook", func() {
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
+			plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue}}
+			plan.Status.Failed = 1
 
-			It("should have conditions", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-			})
+			secret := &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      configuration.GetTerraformStepResultsSecretName(),
+					Namespace: ctrl.ControllerNamespace,
+				},
+				Data: map[string][]byte{
+					"results.json": []byte(`{"job":"` + plan.Name + `","steps":[` +
+						`{"name":"setup","duration":2000000000,"exitCode":0},` +
+						`{"name":"hook-lint","duration":1000000000,"exitCode":1,"stderr":"lint failed"}]}`),
+				},
+			}
 
-			It("should create a terraform plan job", func() {
-				list := &batchv1.JobList{}
+			Setup(configuration, plan, secret)
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+		})
 
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(1))
-			})
+		It("should not error", func() {
+			Expect(rerr).ToNot(HaveOccurred())
+		})
 
-			It("should have an init container retrieving the source", func() {
-				list := &batchv1.JobList{}
+		It("should indicate the hook failed on the plan condition", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(1))
-				Expect(len(list.Items[0].Spec.Template.Spec.InitContainers)).To(Equal(3))
+			cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(corev1alpha1.ReasonError))
+			Expect(cond.Message).To(Equal("Terraform plan has failed, the hook \"lint\" failed"))
+		})
+	})
 
-				source := list.Items[0].Spec.Template.Spec.InitContainers[2]
-				Expect(source.Name).To(Equal("policy-external-test"))
-				Expect(source.Command).To(Equal([]string{"/run/bin/step"}))
-				Expect(source.Args).To(Equal([]string{
-					"--comment=Retrieve external source for test",
-					"--command=/bin/mkdir -p /run/policy",
-					"--command=/bin/source --dest=/run/policy/test --source=https://example.com//dir",
-				}))
-				Expect(source.EnvFrom).To(Equal([]v1.EnvFromSource{
-					{
-						SecretRef: &v1.SecretEnvSource{
-							LocalObjectReference: v1.LocalObjectReference{Name: "test-secret"},
-							Optional:             nil,
-						},
-					},
-				}))
-				Expect(len(source.VolumeMounts)).To(Equal(1))
-				Expect(source.VolumeMounts[0].Name).To(Equal("run"))
-			})
+	When("the terraform plan has completed with ignored hook failures", func() {
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
+			plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+			plan.Status.Succeeded = 1
 
-			It("should have updated the command line for checkov scan", func() {
-				list := &batchv1.JobList{}
+			secret := &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      configuration.GetTerraformStepResultsSecretName(),
+					Namespace: ctrl.ControllerNamespace,
+				},
+				Data: map[string][]byte{
+					"results.json": []byte(`{"job":"` + plan.Name + `","steps":[` +
+						`{"name":"hook-lint","duration":1000000000,"exitCode":1,"ignored":true},` +
+						`{"name":"terraform","duration":60000000000,"exitCode":0}]}`),
+				},
+			}
 
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(1))
-				job := list.Items[0]
+			Setup(configuration, plan, secret)
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+		})
 
-				Expect(len(job.Spec.Template.Spec.Containers)).To(Equal(2))
-				Expect(job.Spec.Template.Spec.Containers[1].Name).To(Equal("verify-policy"))
-				Expect(job.Spec.Template.Spec.Containers[1].Command).To(Equal([]string{"/run/bin/step"}))
-				Expect(job.Spec.Template.Spec.Containers[1].Args).To(Equal([]string{
-					"--comment=Evaluating Against Security Policy",
-					"--command=/usr/local/bin/checkov --config /run/checkov/checkov.yaml --framework terraform_plan -f /run/tfplan.json --soft-fail -o json -o cli --output-file-path /run --repo-root-for-plan-enrichment /data --download-external-modules true >/dev/null",
-					"--command=/bin/cat /run/results_cli.txt",
-					"--namespace=$(KUBE_NAMESPACE)",
-					"--upload=$(POLICY_REPORT_NAME)=/run/results_json.json",
-					"--is-failure=/run/steps/terraform.failed",
-					"--wait-on=/run/steps/terraform.complete",
-				}))
-			})
+		It("should not error", func() {
+			Expect(rerr).ToNot(HaveOccurred())
+		})
 
-			It("should have a checkov configuration secret", func() {
-				secret := &v1.Secret{}
-				secret.Namespace = ctrl.ControllerNamespace
-				secret.Name = configuration.GetTerraformConfigSecretName()
+		It("should mention the ignored hooks on the plan condition", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
-				Expect(err).ToNot(HaveOccurred())
-				Expect(found).To(BeTrue())
-				Expect(secret.Data).To(HaveKey(terraformv1alpha1.CheckovJobTemplateConfigMapKey))
-				Expect(string(secret.Data[terraformv1alpha1.CheckovJobTemplateConfigMapKey])).To(Equal("framework:\n  - terraform_plan\nsoft-fail: true\ncompact: true\ncheck:\n  - check0\nexternal-checks-dir:\n  - /run/policy/test"))
-			})
+			cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Message).To(Equal("Terraform plan is complete, ignoring the failed hooks: lint"))
 		})
+	})
 
-		When("configuration has matched a policy", func() {
-			BeforeEach(func() {
-				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+	// POD TEMPLATE OVERRIDES
+	When("a policy and the provider define pod template overrides", func() {
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			policy := fixtures.NewPolicy("overrides")
+			policy.Spec.PodTemplateOverrides = []terraformv1alpha1.PodTemplateDefaults{
+				{
+					Template: runtime.RawExtension{
+						Raw: []byte(`{"spec":{"nodeSelector":{"pool":"terraform"},"priorityClassName":"low"}}`),
+					},
+				},
+				{
+					Selector: terraformv1alpha1.DefaultVariablesSelector{Modules: []string{"does-not-match"}},
+					Template: runtime.RawExtension{
+						Raw: []byte(`{"spec":{"serviceAccountName":"ignored"}}`),
+					},
+				},
+			}
+			Setup(configuration, policy)
 
-				// @note: we create a policy, we set the terraform plan job to complete and we create
-				// a fake security report
-				policy := fixtures.NewMatchAllPolicyConstraint("all")
-				policy.Spec.Constraints.Checkov.Checks = []string{"check0"}
-				plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
-				plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
-				plan.Status.Succeeded = 1
-				tfplan := fixtures.NewTerraformPlanWithDiff(configuration, ctrl.ControllerNamespace)
+			provider := &terraformv1alpha1.Provider{}
+			provider.Name = configuration.Spec.ProviderRef.Name
+			Expect(cc.Get(context.TODO(), provider.GetNamespacedName(), provider)).To(Succeed())
+			provider.Spec.PodTemplateOverrides = &runtime.RawExtension{
+				Raw: []byte(`{"metadata":{"labels":{"team":"platform"}},"spec":{"priorityClassName":"high"}}`),
+			}
+			Expect(cc.Update(context.TODO(), provider)).To(Succeed())
 
-				report := &v1.Secret{}
-				report.Namespace = ctrl.ControllerNamespace
-				report.Name = configuration.GetTerraformPolicySecretName()
-				report.Data = map[string][]byte{"results_json.json": []byte(`{"summary":{"failed": 1}}`)}
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 0)
+		})
 
-				Setup(configuration, policy, plan, report, tfplan)
-			})
+		It("should not error", func() {
+			Expect(rerr).ToNot(HaveOccurred())
+		})
 
-			When("policy report is missing due to interval error", func() {
-				BeforeEach(func() {
-					report := &v1.Secret{}
-					report.Namespace = ctrl.ControllerNamespace
-					report.Name = configuration.GetTerraformPolicySecretName()
-					ctrl.cc.Delete(context.TODO(), report)
+		It("should merge the matching overrides onto the plan job", func() {
+			list := &batchv1.JobList{}
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(list.Items).To(HaveLen(1))
 
-					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
-				})
+			template := list.Items[0].Spec.Template
+			Expect(template.Labels).To(HaveKeyWithValue("team", "platform"))
+			Expect(template.Spec.NodeSelector).To(HaveKeyWithValue("pool", "terraform"))
+			Expect(template.Spec.ServiceAccountName).ToNot(Equal("ignored"))
+		})
 
-				It("should have the conditions", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-				})
+		It("should give precedence to the provider overrides", func() {
+			list := &batchv1.JobList{}
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(list.Items).To(HaveLen(1))
+			Expect(list.Items[0].Spec.Template.Spec.PriorityClassName).To(Equal("high"))
+		})
+	})
 
-				It("should indicate the we failed", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+	When("a pod template override selects the namespace which is missing from the cache", func() {
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			policy := fixtures.NewPolicy("overrides")
+			policy.Spec.PodTemplateOverrides = []terraformv1alpha1.PodTemplateDefaults{
+				{
+					Selector: terraformv1alpha1.DefaultVariablesSelector{
+						Namespace: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "platform"}},
+					},
+					Template: runtime.RawExtension{
+						Raw: []byte(`{"spec":{"nodeSelector":{"pool":"terraform"}}}`),
+					},
+				},
+			}
+			Setup(configuration, policy)
+			ctrl.cache.SetDefault(cfgNamespace, (*v1.Namespace)(nil))
 
-					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPolicy)
-					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonWarning))
-					Expect(cond.Message).To(Equal("Failed to find the secret: (default/policy-1234-122-1234-1234) containing checkov scan"))
-				})
-			})
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 0)
+		})
 
-			When("policy report contains failed checks", func() {
-				BeforeEach(func() {
-					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
-				})
+		It("should not error", func() {
+			Expect(rerr).ToNot(HaveOccurred())
+		})
 
-				It("should have the conditions", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-				})
+		It("should indicate the namespace could not be retrieved", func() {
+			Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				It("should indicate the we failed", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+			cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(corev1alpha1.ReasonError))
+			Expect(cond.Message).To(Equal("Failed to retrieve the namespace from the cache"))
+		})
 
-					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPolicy)
-					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
-					Expect(cond.Message).To(Equal("Configuration has failed security policy, refusing to continue"))
-				})
+		It("should requeue the configuration", func() {
+			Expect(result.RequeueAfter).To(Equal(30 * time.Second))
+		})
 
-				It("should have raised a event", func() {
-					Expect(recorder.Events).To(HaveLen(1))
-					Expect(recorder.Events[0]).To(ContainSubstring("Configuration has failed security policy, refusing to continue"))
-				})
+		It("should not create the plan job", func() {
+			list := &batchv1.JobList{}
+			Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			Expect(list.Items).To(BeEmpty())
+		})
+	})
 
-				It("should have not create an apply job", func() {
-					list := &batchv1.JobList{}
+	// NAMESPACED JOBS
+	When("the jobs are run in the namespace of the configuration", func() {
+		When("the configuration is planned", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				secret := fixtures.NewValidAWSProviderSecret(cfgNamespace, "aws")
+				secret.Data = map[string][]byte{"AWS_ACCESS_KEY_ID": []byte("tenant")}
 
-					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-					Expect(len(list.Items)).To(Equal(1))
-				})
+				Setup(configuration, secret)
+				ctrl.CacheVolume = "terranetes-cache"
+				ctrl.EnableNamespacedJobs = true
 
-				It("should copied the report into the configuration namespace", func() {
-					secret := &v1.Secret{}
-					secret.Namespace = configuration.Namespace
-					secret.Name = configuration.GetTerraformPolicySecretName()
-					found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
-					Expect(err).ToNot(HaveOccurred())
-					Expect(found).To(BeTrue())
-					Expect(secret.Data).To(HaveKey("results_json.json"))
-					Expect(string(secret.Data["results_json.json"])).To(ContainSubstring("summary"))
-				})
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 			})
 
-			When("policy report contains no failed checks", func() {
-				BeforeEach(func() {
-					report := &v1.Secret{}
-					report.Namespace = ctrl.ControllerNamespace
-					report.Name = configuration.GetTerraformPolicySecretName()
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
+			})
 
-					// @note: delete the old secret adding a passed one
-					Expect(ctrl.cc.Delete(context.TODO(), report)).ToNot(HaveOccurred())
-					report.Data = map[string][]byte{"results_json.json": []byte(`{"summary":{"failed": 0}}`)}
-					Expect(ctrl.cc.Create(context.TODO(), report)).ToNot(HaveOccurred())
+			It("should not mount the cache volume from the controller namespace", func() {
+				list := &batchv1.JobList{}
+				Expect(cc.List(context.TODO(), list, client.InNamespace(cfgNamespace))).ToNot(HaveOccurred())
+				Expect(list.Items).ToNot(BeEmpty())
 
-					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
-				})
+				for _, job := range list.Items {
+					for _, volume := range job.Spec.Template.Spec.Volumes {
+						Expect(volume.Name).ToNot(Equal("cache"))
+					}
+				}
+			})
 
-				It("should have the conditions", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-				})
+			It("should have created the plan job in the configuration namespace", func() {
+				list := &batchv1.JobList{}
+				Expect(cc.List(context.TODO(), list, client.InNamespace(cfgNamespace))).ToNot(HaveOccurred())
 
-				It("should indicate the we failed", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				var plans []batchv1.Job
+				for _, x := range list.Items {
+					if x.Labels[terraformv1alpha1.JobWatcherLabel] == "" {
+						plans = append(plans, x)
+					}
+				}
+				Expect(plans).To(HaveLen(1))
+				Expect(plans[0].Labels).To(HaveKeyWithValue(terraformv1alpha1.ConfigurationStageLabel, terraformv1alpha1.StageTerraformPlan))
+				Expect(plans[0].Spec.Template.Spec.ServiceAccountName).To(Equal(jobs.DefaultServiceAccount))
+			})
 
-					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPolicy)
-					Expect(cond.Status).To(Equal(metav1.ConditionTrue))
-					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonReady))
-					Expect(cond.Message).To(Equal("Passed security checks"))
-				})
+			It("should not have created any jobs in the controller namespace", func() {
+				list := &batchv1.JobList{}
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(list.Items).To(BeEmpty())
+			})
 
-				It("should have not create an apply job", func() {
-					list := &batchv1.JobList{}
+			It("should have created the configuration secret in the configuration namespace", func() {
+				secret := &v1.Secret{}
+				secret.Namespace = cfgNamespace
+				secret.Name = configuration.GetTerraformConfigSecretName()
 
-					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-					Expect(len(list.Items)).To(Equal(2))
-				})
+				found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(string(secret.Data[terraformv1alpha1.TerraformBackendSecretKey])).To(ContainSubstring(`namespace         = "apps"`))
+			})
 
-				It("should copied the report into the configuration namespace", func() {
-					secret := &v1.Secret{}
-					secret.Namespace = configuration.Namespace
-					secret.Name = configuration.GetTerraformPolicySecretName()
-					found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
-					Expect(err).ToNot(HaveOccurred())
-					Expect(found).To(BeTrue())
-					Expect(secret.Data).To(HaveKey("results_json.json"))
-					Expect(string(secret.Data["results_json.json"])).To(ContainSubstring("summary"))
-				})
+			It("should have provisioned the executor service account and permissions", func() {
+				sa := &v1.ServiceAccount{}
+				Expect(cc.Get(context.TODO(), types.NamespacedName{Namespace: cfgNamespace, Name: jobs.DefaultServiceAccount}, sa)).To(Succeed())
+
+				role := &rbacv1.Role{}
+				Expect(cc.Get(context.TODO(), types.NamespacedName{Namespace: cfgNamespace, Name: jobs.DefaultServiceAccount}, role)).To(Succeed())
+				Expect(role.Rules).To(Equal(executorRules))
+
+				binding := &rbacv1.RoleBinding{}
+				Expect(cc.Get(context.TODO(), types.NamespacedName{Namespace: cfgNamespace, Name: jobs.DefaultServiceAccount}, binding)).To(Succeed())
+				Expect(binding.RoleRef.Name).To(Equal(role.Name))
+				Expect(binding.Subjects).To(HaveLen(1))
+				Expect(binding.Subjects[0].Name).To(Equal(jobs.DefaultServiceAccount))
+				Expect(binding.Subjects[0].Namespace).To(Equal(cfgNamespace))
 			})
 
-			When("policy contains no passes or failures", func() {
-				BeforeEach(func() {
-					report := &v1.Secret{}
-					report.Namespace = ctrl.ControllerNamespace
-					report.Name = configuration.GetTerraformPolicySecretName()
+			It("should not have overwritten the provider secret in the configuration namespace", func() {
+				secret := &v1.Secret{}
+				secret.Namespace = cfgNamespace
+				secret.Name = "aws"
 
-					// @note: delete the old secret adding a passed one
-					Expect(ctrl.cc.Delete(context.TODO(), report)).ToNot(HaveOccurred())
-					report.Data = map[string][]byte{"results_json.json": []byte(`{"failed": 0, "passed": 0}}`)}
-					Expect(ctrl.cc.Create(context.TODO(), report)).ToNot(HaveOccurred())
+				found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(secret.Labels).ToNot(HaveKey(terraformv1alpha1.JobSecretCopyLabel))
+				Expect(string(secret.Data["AWS_ACCESS_KEY_ID"])).To(Equal("tenant"))
+			})
+		})
 
-					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
-				})
+		When("the provider secret does not exist in the configuration namespace", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				Setup(configuration)
+				ctrl.EnableNamespacedJobs = true
 
-				It("should have the conditions", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-				})
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			})
 
-				It("should indicate the we failed", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
+			})
 
-					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPolicy)
-					Expect(cond.Status).To(Equal(metav1.ConditionTrue))
-					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonReady))
-					Expect(cond.Message).To(Equal("Passed security checks"))
-				})
+			It("should indicate the provider credentials are missing", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				It("should have not create an apply job", func() {
-					list := &batchv1.JobList{}
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(cond.Message).To(Equal("Provider secret (apps/aws) does not exist, jobs in the configuration namespace " +
+					"require the provider credentials within the namespace, or a provider using an injected identity"))
+			})
 
-					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-					Expect(len(list.Items)).To(Equal(2))
-				})
+			It("should not have copied the provider secret from the controller namespace", func() {
+				secret := &v1.Secret{}
+				secret.Namespace = cfgNamespace
+				secret.Name = "aws"
 
-				It("should copied the report into the configuration namespace", func() {
-					secret := &v1.Secret{}
-					secret.Namespace = configuration.Namespace
-					secret.Name = configuration.GetTerraformPolicySecretName()
-					found, err := kubernetes.GetIfExists(context.TODO(), ctrl.cc, secret)
-					Expect(err).ToNot(HaveOccurred())
-					Expect(found).To(BeTrue())
-					Expect(secret.Data).To(HaveKey("results_json.json"))
-				})
+				found, err := kubernetes.GetIfExists(context.TODO(), cc, secret)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
 			})
 
-			When("policy report zero, but missing passed field", func() {
-				BeforeEach(func() {
-					report := &v1.Secret{}
-					report.Namespace = ctrl.ControllerNamespace
-					report.Name = configuration.GetTerraformPolicySecretName()
+			It("should not have created a plan job", func() {
+				list := &batchv1.JobList{}
+				Expect(cc.List(context.TODO(), list, client.InNamespace(cfgNamespace))).ToNot(HaveOccurred())
+				for _, x := range list.Items {
+					Expect(x.Labels).To(HaveKey(terraformv1alpha1.JobWatcherLabel))
+				}
+			})
+		})
 
-					// @note: delete the old secret adding a passed one
-					Expect(ctrl.cc.Delete(context.TODO(), report)).ToNot(HaveOccurred())
-					report.Data = map[string][]byte{"results_json.json": []byte(`{"failed": 0}}`)}
-					Expect(ctrl.cc.Create(context.TODO(), report)).ToNot(HaveOccurred())
+		When("the infracost secret does not exist in the configuration namespace", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				Setup(configuration, fixtures.NewValidAWSProviderSecret(cfgNamespace, "aws"))
+				ctrl.EnableNamespacedJobs = true
+				ctrl.EnableInfracosts = true
+				ctrl.InfracostsSecretName = "infracost-api"
 
-					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
-				})
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			})
 
-				It("should have the conditions", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-				})
+			It("should indicate the credentials are missing", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				It("should indicate the we failed", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(cond.Message).To(Equal("Cost analytics secret (apps/infracost-api) does not exist, contact platform administrator"))
+			})
+		})
 
-					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPolicy)
-					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonError))
-					Expect(cond.Message).To(Equal("Security report is missing passed field"))
-				})
+		When("a secret of the same name as the certificate authority is not managed by the controller", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				ca := &v1.Secret{}
+				ca.Namespace = ctrl.ControllerNamespace
+				ca.Name = "ca"
+				ca.Data = map[string][]byte{"ca.pem": []byte("ca")}
+				secret := &v1.Secret{}
+				secret.Namespace = cfgNamespace
+				secret.Name = "ca"
 
-				It("should have not create an apply job", func() {
-					list := &batchv1.JobList{}
+				Setup(configuration, ca, secret, fixtures.NewValidAWSProviderSecret(cfgNamespace, "aws"))
+				ctrl.EnableNamespacedJobs = true
+				ctrl.ProviderMirrorCASecret = "ca"
 
-					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-					Expect(len(list.Items)).To(Equal(1))
-				})
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
 			})
 
-			When("policy report zero, but missing failed field", func() {
-				BeforeEach(func() {
-					report := &v1.Secret{}
-					report.Namespace = ctrl.ControllerNamespace
-					report.Name = configuration.GetTerraformPolicySecretName()
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
+			})
 
-					// @note: delete the old secret adding a passed one
-					Expect(ctrl.cc.Delete(context.TODO(), report)).ToNot(HaveOccurred())
-					report.Data = map[string][]byte{"results_json.json": []byte(`{"passed": 0}}`)}
-					Expect(ctrl.cc.Create(context.TODO(), report)).ToNot(HaveOccurred())
+			It("should indicate the secret is not managed by the controller", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-					result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
-				})
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(cond.Message).To(Equal("Secret (apps/ca) already exists and is not managed by the controller"))
+			})
 
-				It("should have the conditions", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-					Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
-				})
+			It("should not have created a plan job", func() {
+				list := &batchv1.JobList{}
+				Expect(cc.List(context.TODO(), list, client.InNamespace(cfgNamespace))).ToNot(HaveOccurred())
+				for _, x := range list.Items {
+					Expect(x.Labels).To(HaveKey(terraformv1alpha1.JobWatcherLabel))
+				}
+			})
+		})
 
-				It("should indicate the we failed", func() {
-					Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+		When("the provider service account does not exist in the configuration namespace", func() {
+			BeforeEach(func() {
+				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+				Setup(configuration)
+				ctrl.EnableNamespacedJobs = true
 
-					cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPolicy)
-					Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-					Expect(cond.Reason).To(Equal(corev1alpha1.ReasonError))
-					Expect(cond.Message).To(Equal("Security report is missing failed field"))
-				})
+				provider := &terraformv1alpha1.Provider{}
+				provider.Name = configuration.Spec.ProviderRef.Name
+				Expect(cc.Get(context.TODO(), provider.GetNamespacedName(), provider)).To(Succeed())
+				provider.Spec.Source = terraformv1alpha1.SourceInjected
+				provider.Spec.SecretRef = nil
+				provider.Spec.ServiceAccount = ptr.To("aws-identity")
+				Expect(cc.Update(context.TODO(), provider)).To(Succeed())
 
-				It("should have not create an apply job", func() {
-					list := &batchv1.JobList{}
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+			})
 
-					Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-					Expect(len(list.Items)).To(Equal(1))
-				})
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
+			})
+
+			It("should indicate the service account is missing", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(cond.Message).To(Equal("Provider service account (apps/aws-identity) does not exist, contact platform administrator"))
 			})
 		})
 	})
 
-	When("using a custom job template", func() {
-		templateName := "template"
+	// AUTOMATIC RETRIES
+	When("terraform plan has failed with a transient error", func() {
+		var plan *batchv1.Job
+		var policy *terraformv1alpha1.Policy
+
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			plan = fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
+			plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue}}
+			plan.Status.Failed = 1
+			policy = nil
+		})
+
+		JustBeforeEach(func() {
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      plan.Name + "-abcde",
+					Namespace: ctrl.ControllerNamespace,
+					Labels:    map[string]string{"job-name": plan.Name},
+				},
+				Status: v1.PodStatus{Phase: v1.PodFailed},
+			}
+
+			detectors := &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "detectors",
+					Namespace: ctrl.ControllerNamespace,
+					Labels:    map[string]string{terraformv1alpha1.ErrorDetectorsLabel: "true"},
+				},
+				Data: map[string]string{
+					"custom.yaml": `
+- regex: "fake logs"
+  class: throttling
+  message: "Provider api is throttling requests"
+  retryable: true
+`,
+				},
+			}
+
+			objects := []runtime.Object{configuration, plan, detectors}
+			if policy != nil {
+				objects = append(objects, policy)
+			}
+			Setup(objects...)
+			ctrl.kc = kfake.NewSimpleClientset(pod)
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+		})
+
+		When("no retry policy is defined", func() {
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
+			})
 
-		BeforeEach(func() {
-			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-			Setup(configuration)
+			It("should require action on the ready condition", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-			cm := fixtures.NewJobTemplateConfigmap(ctrl.ControllerNamespace, templateName)
-			ctrl.JobTemplate = cm.Name
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(configuration.Status.Retry).To(BeNil())
+			})
 
-			Expect(ctrl.cc.Create(context.TODO(), cm)).ToNot(HaveOccurred())
+			It("should not requeue", func() {
+				Expect(result).To(Equal(reconcile.Result{}))
+			})
 		})
 
-		When("the template is missing", func() {
+		When("the configuration has a retry policy", func() {
 			BeforeEach(func() {
-				ctrl.cc.Delete(context.TODO(), fixtures.NewJobTemplateConfigmap(ctrl.ControllerNamespace, templateName))
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+				configuration.Spec.Retry = &terraformv1alpha1.RetryPolicy{MaxAttempts: 2}
 			})
 
-			It("should have conditions", func() {
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
+			})
+
+			It("should have scheduled a retry via the retry annotation", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+
+				Expect(configuration.GetAnnotations()).To(HaveKey(terraformv1alpha1.RetryAnnotation))
+				Expect(configuration.GetRetryDelay(time.Now())).To(BeNumerically(">", 20*time.Second))
 			})
 
-			It("should indicate action is required in the conditions", func() {
+			It("should track the attempt in the status", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
-				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
-				Expect(cond.Message).To(Equal("Custom job template (default/template) does not exists"))
+				Expect(configuration.Status.Retry).ToNot(BeNil())
+				Expect(configuration.Status.Retry.Attempts).To(Equal(1))
+				Expect(configuration.Status.Retry.Class).To(Equal("throttling"))
+				Expect(configuration.Status.Retry.Generation).To(Equal(configuration.GetGeneration()))
+				Expect(configuration.Status.Retry.Job).To(Equal(plan.Name))
+				Expect(configuration.Status.Retry.NextAttempt).ToNot(BeNil())
 			})
 
-			It("should have raised a event", func() {
-				Expect(recorder.Events).To(HaveLen(1))
-				Expect(recorder.Events[0]).To(ContainSubstring("Custom job template (default/template) does not exists"))
+			It("should indicate the retry on the ready condition", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonWarning))
+				Expect(cond.Message).To(Equal("Provider api is throttling requests, retrying in 30s (attempt 1 of 2)"))
 			})
-		})
 
-		When("the template does not have the correct key", func() {
-			BeforeEach(func() {
-				// @step: delete the old one and create a new one with missing keys
-				cm := fixtures.NewJobTemplateConfigmap(ctrl.ControllerNamespace, templateName)
-				invalid := fixtures.NewJobTemplateConfigmap(ctrl.ControllerNamespace, templateName)
-				invalid.Data = map[string]string{}
+			It("should raise an event", func() {
+				Expect(recorder.Events).To(ContainElement(ContainSubstring("RetryScheduled")))
+			})
 
-				ctrl.cc.Delete(context.TODO(), cm)
-				ctrl.cc.Create(context.TODO(), invalid)
+			It("should not create a new plan until the retry is due", func() {
+				list := &batchv1.JobList{}
 
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+				Expect(list.Items).To(HaveLen(1))
 			})
 
-			It("should have the template", func() {
-				cm := fixtures.NewJobTemplateConfigmap(ctrl.ControllerNamespace, templateName)
-				req := types.NamespacedName{Namespace: cm.Namespace, Name: cm.Name}
+			It("should requeue for the retry", func() {
+				Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+			})
+		})
 
-				Expect(ctrl.cc.Get(context.TODO(), req, &v1.ConfigMap{})).ToNot(HaveOccurred())
+		When("the retry policy does not permit the error class", func() {
+			BeforeEach(func() {
+				configuration.Spec.Retry = &terraformv1alpha1.RetryPolicy{MaxAttempts: 2, Classes: []string{"state-lock"}}
 			})
 
-			It("should have conditions", func() {
+			It("should require action on the ready condition", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
+				Expect(configuration.GetAnnotations()).ToNot(HaveKey(terraformv1alpha1.RetryAnnotation))
 			})
+		})
 
-			It("should indicate action is required due to missing keys", func() {
+		When("the retry attempts have been exhausted", func() {
+			BeforeEach(func() {
+				configuration.Spec.Retry = &terraformv1alpha1.RetryPolicy{MaxAttempts: 2}
+				configuration.Status.Retry = &terraformv1alpha1.RetryStatus{
+					Attempts:   2,
+					Generation: configuration.GetGeneration(),
+					Job:        "bucket-plan-previous",
+				}
+			})
+
+			It("should require action on the ready condition", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
 				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
-				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
 				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
-				Expect(cond.Message).To(Equal("Custom job template (default/template) does not contain the \"job.yaml\" key"))
+				Expect(configuration.Status.Retry.Attempts).To(Equal(2))
 			})
 
-			It("should have raised a event", func() {
-				Expect(recorder.Events).To(HaveLen(1))
-				Expect(recorder.Events[0]).To(ContainSubstring("Custom job template (default/template) does not contain the \"job.yaml\" key"))
+			It("should raise an event", func() {
+				Expect(recorder.Events).To(ContainElement(ContainSubstring("RetriesExhausted")))
 			})
 		})
 
-		When("we have a valid template", func() {
+		When("a policy defines a retry policy for the module", func() {
 			BeforeEach(func() {
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
-			})
-
-			It("should have conditions", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+				policy = fixtures.NewPolicy("retries")
+				policy.Spec.Retries = []terraformv1alpha1.RetryDefaults{
+					{
+						Selector: terraformv1alpha1.DefaultVariablesSelector{Modules: []string{"does_not_match"}},
+						Policy:   terraformv1alpha1.RetryPolicy{MaxAttempts: 1},
+					},
+					{
+						Selector: terraformv1alpha1.DefaultVariablesSelector{Modules: []string{".*"}},
+						Policy: terraformv1alpha1.RetryPolicy{
+							MaxAttempts: 5,
+							Backoff:     &metav1.Duration{Duration: time.Minute},
+						},
+					},
+				}
 			})
 
-			It("should indicate the terraform plan is running", func() {
+			It("should have scheduled a retry using the policy", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
-				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
-				Expect(cond.Message).To(Equal("Terraform plan in progress"))
+				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
+				Expect(cond.Message).To(Equal("Provider api is throttling requests, retrying in 1m0s (attempt 1 of 5)"))
+				Expect(configuration.Status.Retry.Attempts).To(Equal(1))
 			})
 		})
 	})
 
-	// BEFORE TERRAFORM APPLY
-	When("terraform apply has not been provisoned", func() {
-		When("the configuration needs approval", func() {
-			BeforeEach(func() {
-				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-				configuration.Annotations = map[string]string{terraformv1alpha1.ApplyAnnotation: "false"}
-				plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
-				plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
-				plan.Status.Succeeded = 1
-				tfplan := fixtures.NewTerraformPlanWithDiff(configuration, ctrl.ControllerNamespace)
+	// STATE UNLOCK
+	When("the configuration has been marked to release a state lock", func() {
+		lockID := "3c2d1f6e-0e4a-5b7c-8d9e-1f2a3b4c5d6e"
+		var objects []runtime.Object
 
-				Setup(configuration, plan, tfplan)
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 5)
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			configuration.Annotations = map[string]string{terraformv1alpha1.ForceUnlockAnnotation: lockID}
+			configuration.Status.StateLock = &terraformv1alpha1.StateLockStatus{ID: lockID}
+
+			plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
+			plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue}}
+			plan.Status.Failed = 1
+
+			objects = []runtime.Object{configuration, plan}
+		})
+
+		When("no unlock job exists", func() {
+			BeforeEach(func() {
+				Setup(objects...)
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 1)
 			})
 
-			It("should have the conditions", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
 			})
 
-			It("should indicate configuration is waiting approval", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
+			It("should have created the unlock job", func() {
+				list := &batchv1.JobList{}
 
-				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformApply)
-				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
-				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonActionRequired))
-				Expect(cond.Message).To(Equal("Waiting for terraform apply annotation to be set to true"))
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace), client.MatchingLabels{
+					terraformv1alpha1.ConfigurationStageLabel: terraformv1alpha1.StageTerraformUnlock,
+					terraformv1alpha1.ForceUnlockAnnotation:   lockID,
+				})).ToNot(HaveOccurred())
+				Expect(list.Items).To(HaveLen(1))
+
+				container := list.Items[0].Spec.Template.Spec.Containers[0]
+				Expect(container.Args).To(ContainElement("--command=/usr/local/bin/tofu force-unlock -force " + lockID))
 			})
 
-			It("should indicate the ready condition is false", func() {
+			It("should indicate the lock is being released", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
 				cond := configuration.Status.GetCondition(corev1alpha1.ConditionReady)
-				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
 				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonInProgress))
-				Expect(cond.Message).To(Equal("Waiting for changes to be approved"))
+				Expect(cond.Message).To(Equal("Releasing the terraform state lock"))
 			})
 
-			It("should indicate the resource out of sync", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-				Expect(configuration.Status.ResourceStatus).To(Equal(terraformv1alpha1.ResourcesOutOfSync))
+			It("should raise an event", func() {
+				Expect(recorder.Events).To(ContainElement(ContainSubstring("StateUnlock")))
 			})
 
-			It("should have raised a kubernetes event", func() {
-				Expect(recorder.Events).To(HaveLen(1))
-				Expect(recorder.Events[0]).To(Equal("(apps/bucket) Warning Action Required: Waiting for terraform apply annotation to be set to true"))
+			It("should requeue", func() {
+				Expect(result).To(Equal(reconcile.Result{RequeueAfter: 5 * time.Second}))
 			})
+		})
 
-			It("should not have created a job", func() {
+		When("another job for the configuration is active", func() {
+			BeforeEach(func() {
+				apply := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformApply)
+				apply.Status.Active = 1
+
+				Setup(append(objects, apply)...)
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 1)
+			})
+
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
+			})
+
+			It("should not have created the unlock job", func() {
 				list := &batchv1.JobList{}
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(1))
+
+				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace), client.MatchingLabels{
+					terraformv1alpha1.ConfigurationStageLabel: terraformv1alpha1.StageTerraformUnlock,
+				})).ToNot(HaveOccurred())
+				Expect(list.Items).To(BeEmpty())
+			})
+
+			It("should requeue", func() {
+				Expect(result).To(Equal(reconcile.Result{RequeueAfter: 10 * time.Second}))
 			})
 		})
 
-		When("the configuration is approved", func() {
+		When("the unlock job has completed", func() {
 			BeforeEach(func() {
-				configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
-				plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
-				plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
-				plan.Status.Succeeded = 1
-				tfplan := fixtures.NewTerraformPlanWithDiff(configuration, ctrl.ControllerNamespace)
-				Setup(configuration, plan, tfplan)
-				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 5)
+				unlock := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformUnlock)
+				unlock.Labels[terraformv1alpha1.ForceUnlockAnnotation] = lockID
+				unlock.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+				unlock.Status.Succeeded = 1
+
+				Setup(append(objects, unlock)...)
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 1)
 			})
 
-			It("should have the conditions", func() {
-				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
-				Expect(configuration.Status.Conditions).To(HaveLen(defaultConditions))
+			It("should not error", func() {
+				Expect(rerr).ToNot(HaveOccurred())
 			})
 
-			It("should indicate the terraform apply is running", func() {
+			It("should have removed the annotation and the state lock", func() {
 				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				cond := configuration.Status.GetCondition(terraformv1alpha1.ConditionTerraformPlan)
-				Expect(cond.Status).To(Equal(metav1.ConditionTrue))
-				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonReady))
-				Expect(cond.Message).To(Equal("Terraform plan is complete"))
+				Expect(configuration.GetAnnotations()).ToNot(HaveKey(terraformv1alpha1.ForceUnlockAnnotation))
+				Expect(configuration.Status.StateLock).To(BeNil())
 			})
 
-			It("should have created job for the terraform apply", func() {
-				list := &batchv1.JobList{}
+			It("should raise an event", func() {
+				Expect(recorder.Events).To(ContainElement(ContainSubstring("StateUnlocked")))
+			})
+		})
 
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(2))
+		When("the unlock job has failed", func() {
+			BeforeEach(func() {
+				unlock := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformUnlock)
+				unlock.Labels[terraformv1alpha1.ForceUnlockAnnotation] = lockID
+				unlock.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: v1.ConditionTrue}}
+				unlock.Status.Failed = 1
+
+				Setup(append(objects, unlock)...)
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 1)
 			})
 
-			It("should have a terraform container", func() {
-				list := &batchv1.JobList{}
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(2))
+			It("should have removed the annotation but retained the state lock", func() {
+				Expect(cc.Get(context.TODO(), configuration.GetNamespacedName(), configuration)).ToNot(HaveOccurred())
 
-				expected := []string{
-					"--comment=Executing Terraform",
-					"--command=/usr/local/bin/tofu apply --var-file variables.tfvars.json -lock=false -no-color -input=false -auto-approve",
-					"--on-error=/run/steps/terraform.failed",
-					"--on-success=/run/steps/terraform.complete",
-				}
-				job := list.Items[0]
-				container := job.Spec.Template.Spec.Containers[0]
+				Expect(configuration.GetAnnotations()).ToNot(HaveKey(terraformv1alpha1.ForceUnlockAnnotation))
+				Expect(configuration.Status.StateLock).ToNot(BeNil())
+			})
 
-				Expect(container.Name).To(Equal("terraform"))
-				Expect(container.Command).To(Equal([]string{"/run/bin/step"}))
-				Expect(container.Args).To(Equal(expected))
+			It("should raise a warning event", func() {
+				Expect(recorder.Events).To(ContainElement(ContainSubstring("StateUnlockFailed")))
+			})
+		})
+	})
 
-				Expect(len(container.EnvFrom)).To(Equal(1))
-				Expect(container.EnvFrom[0].SecretRef).ToNot(BeNil())
-				Expect(container.EnvFrom[0].SecretRef.Name).To(Equal("aws"))
+	// BUILD LOGS
+	When("terraform apply has completed and a log store is configured", func() {
+		var store logstore.Interface
 
-				Expect(len(container.Env)).To(Equal(8))
-				Expect(container.Env[5].Name).To(Equal("TERRAFORM_STATE_NAME"))
-				Expect(container.Env[5].Value).To(Equal(configuration.GetTerraformStateSecretName()))
-				Expect(container.Env[6].Name).To(Equal("TERRAFORM_PLAN_OUT_NAME"))
-				Expect(container.Env[6].Value).To(Equal(configuration.GetTerraformPlanOutSecretName()))
-				Expect(container.Env[7].Name).To(Equal("TERRAFORM_PLAN_JSON_NAME"))
-				Expect(container.Env[7].Value).To(Equal(configuration.GetTerraformPlanJSONSecretName()))
+		BeforeEach(func() {
+			configuration = fixtures.NewValidBucketConfiguration(cfgNamespace, "bucket")
+			plan := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformPlan)
+			plan.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+			plan.Status.Succeeded = 1
+			tfplan := fixtures.NewTerraformPlanWithDiff(configuration, ctrl.ControllerNamespace)
 
-				Expect(container.VolumeMounts).To(HaveLen(3))
-				Expect(container.VolumeMounts[0].Name).To(Equal("planout"))
-				Expect(container.VolumeMounts[1].Name).To(Equal("run"))
-				Expect(container.VolumeMounts[2].Name).To(Equal("source"))
-			})
+			apply := fixtures.NewTerraformJob(configuration, ctrl.ControllerNamespace, terraformv1alpha1.StageTerraformApply)
+			apply.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+			apply.Status.Succeeded = 1
+			apply.Labels[terraformv1alpha1.JobPlanIDLabel] = fixtures.TFPlanID
 
-			It("it should have the configuration labels", func() {
-				list := &batchv1.JobList{}
-				Expect(cc.List(context.TODO(), list, client.InNamespace(ctrl.ControllerNamespace))).ToNot(HaveOccurred())
+			state := fixtures.NewTerraformState(configuration)
+			state.Namespace = "default"
 
-				labels := list.Items[0].GetLabels()
-				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationNameLabel))
-				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationNamespaceLabel))
-				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationGenerationLabel))
-				Expect(labels).To(HaveKey(terraformv1alpha1.ConfigurationStageLabel))
-				Expect(labels[terraformv1alpha1.ConfigurationStageLabel]).To(Equal(terraformv1alpha1.StageTerraformApply))
-				Expect(labels[terraformv1alpha1.ConfigurationNameLabel]).To(Equal(configuration.Name))
-				Expect(labels[terraformv1alpha1.ConfigurationNamespaceLabel]).To(Equal(configuration.Namespace))
-			})
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      apply.Name + "-abcde",
+					Namespace: ctrl.ControllerNamespace,
+					Labels:    map[string]string{"job-name": apply.Name},
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Name: "terraform"}},
+				},
+			}
 
-			It("should have created a watch job in the configuration namespace", func() {
-				list := &batchv1.JobList{}
-				Expect(cc.List(context.TODO(), list, client.InNamespace(configuration.Namespace))).ToNot(HaveOccurred())
-				Expect(len(list.Items)).To(Equal(1))
-				Expect(len(list.Items[0].Spec.Template.Spec.Containers)).To(Equal(1))
+			Setup(configuration, plan, apply, state, tfplan)
+			store, _ = logstore.New("kubernetes", cc, ctrl.ControllerNamespace)
+			ctrl.kc = kfake.NewSimpleClientset(pod)
+			ctrl.LogStore = store
 
-				container := list.Items[0].Spec.Template.Spec.Containers[0]
-				Expect(container.Name).To(Equal("watch"))
-				Expect(container.Command).To(Equal([]string{"/watch_logs.sh"}))
-				Expect(container.Args).To(Equal([]string{"-e", "http://controller.default.svc.cluster.local/v1/builds/apps/bucket/logs?generation=0&name=bucket&namespace=apps&stage=apply&uid=1234-122-1234-1234"}))
-			})
+			result, _, rerr = controllertests.Roll(context.TODO(), ctrl, configuration, 3)
+		})
 
-			It("should ask us to requeue", func() {
-				Expect(result).To(Equal(reconcile.Result{RequeueAfter: 5 * time.Second}))
-				Expect(rerr).To(BeNil())
+		It("should not error", func() {
+			Expect(rerr).ToNot(HaveOccurred())
+		})
+
+		It("should have retained the apply logs", func() {
+			logs, err := store.Get(context.TODO(), logstore.Key{
+				Name:      configuration.Name,
+				Namespace: configuration.Namespace,
+				Stage:     terraformv1alpha1.StageTerraformApply,
+				UID:       string(configuration.GetUID()),
 			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(logs)).To(Equal("fake logs"))
+		})
+
+		It("should have annotated the job as captured", func() {
+			job := &batchv1.Job{}
+			key := types.NamespacedName{Namespace: ctrl.ControllerNamespace, Name: "bucket-apply-1234"}
+
+			Expect(cc.Get(context.TODO(), key, job)).ToNot(HaveOccurred())
+			Expect(job.GetAnnotations()).To(HaveKeyWithValue(terraformv1alpha1.BuildLogsAnnotation, "true"))
 		})
 	})
 
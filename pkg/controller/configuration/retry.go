@@ -0,0 +1,169 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package configuration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/controller"
+	"github.com/appvia/terranetes-controller/pkg/utils/terraform"
+)
+
+// findRetryPolicy returns the retry policy for the configuration, favouring the one defined on the
+// configuration itself before falling back to the first matching policy
+func (c *Controller) findRetryPolicy(ctx context.Context, configuration *terraformv1alpha1.Configuration, state *state) (*terraformv1alpha1.RetryPolicy, error) {
+	if configuration.Spec.Retry != nil {
+		return configuration.Spec.Retry, nil
+	}
+	if state.policies == nil || len(state.policies.Items) == 0 {
+		return nil, nil
+	}
+
+	namespace, err := c.getNamespaceFromCache(ctx, configuration.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(state.policies.Items); i++ {
+		for _, x := range state.policies.Items[i].Spec.Retries {
+			if len(x.Selector.Modules) == 0 && x.Selector.Namespace == nil {
+				return x.Policy.DeepCopy(), nil
+			}
+
+			if len(x.Selector.Modules) > 0 {
+				match, err := x.Selector.IsModulesMatch(configuration)
+				if err != nil {
+					return nil, fmt.Errorf("failed to check against the policy: %q, %w", state.policies.Items[i].Name, err)
+				}
+				if match {
+					return x.Policy.DeepCopy(), nil
+				}
+			}
+
+			if x.Selector.Namespace != nil {
+				match, err := x.Selector.IsLabelsMatch(namespace)
+				if err != nil {
+					return nil, fmt.Errorf("failed to check against the policy: %q, %w", state.policies.Items[i].Name, err)
+				}
+				if match {
+					return x.Policy.DeepCopy(), nil
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// ensureRetryScheduled checks if the errors detected in a failed job are transient and permitted
+// by the retry policy, and if so schedules a retry by moving the retry annotation into the future.
+// It returns the delay until the retry, or zero when the failure should not be retried.
+func (c *Controller) ensureRetryScheduled(
+	ctx context.Context,
+	configuration *terraformv1alpha1.Configuration,
+	job *batchv1.Job,
+	state *state,
+	matches []terraform.ErrorDetection,
+	cond *controller.ConditionManager) (time.Duration, error) {
+
+	policy, err := c.findRetryPolicy(ctx, configuration, state)
+	if err != nil || policy == nil {
+		return 0, err
+	}
+
+	// @step: we only retry when every error found is retryable under the policy
+	var detection *terraform.ErrorDetection
+	for i := 0; i < len(matches); i++ {
+		if matches[i].IsWarning() {
+			continue
+		}
+		if !policy.IsRetryable(matches[i].Class, matches[i].Retryable) {
+			return 0, nil
+		}
+		if detection == nil {
+			detection = &matches[i]
+		}
+	}
+	if detection == nil {
+		return 0, nil
+	}
+
+	// @step: the attempts are reset whenever the configuration changes
+	status := configuration.Status.Retry
+	if status == nil || status.Generation != configuration.GetGeneration() {
+		status = &terraformv1alpha1.RetryStatus{Generation: configuration.GetGeneration()}
+	}
+	// @step: we have already scheduled a retry for this job
+	if status.Job == job.Name {
+		return configuration.GetRetryDelay(time.Now()), nil
+	}
+
+	if status.Attempts >= policy.MaxAttempts {
+		c.recorder.Eventf(configuration, v1.EventTypeWarning, "RetriesExhausted",
+			"Configuration has failed after %d automatic retries", status.Attempts)
+
+		return 0, nil
+	}
+
+	delay := policy.GetBackoff(status.Attempts)
+	next := time.Now().Add(delay).Truncate(time.Second)
+
+	// @step: patch the retry annotation on a copy, so the pending status changes are not
+	// overwritten by the response
+	patched := configuration.DeepCopy()
+	if patched.Annotations == nil {
+		patched.Annotations = map[string]string{}
+	}
+	patched.Annotations[terraformv1alpha1.RetryAnnotation] = fmt.Sprintf("%d", next.Unix())
+
+	if err := c.cc.Patch(ctx, patched, client.MergeFrom(configuration)); err != nil {
+		return 0, err
+	}
+	configuration.Annotations = patched.Annotations
+
+	status.Attempts++
+	status.Class = detection.Class
+	status.Job = job.Name
+	status.NextAttempt = &metav1.Time{Time: next}
+	configuration.Status.Retry = status
+
+	log.WithFields(log.Fields{
+		"attempt":   status.Attempts,
+		"class":     detection.Class,
+		"delay":     delay.String(),
+		"name":      configuration.Name,
+		"namespace": configuration.Namespace,
+	}).Info("scheduling a retry of the configuration after a transient failure")
+
+	c.recorder.Eventf(configuration, v1.EventTypeNormal, "RetryScheduled",
+		"Retrying in %s after a transient failure (%s), attempt %d of %d",
+		delay, detection.Class, status.Attempts, policy.MaxAttempts)
+
+	cond.Warning("%s, retrying in %s (attempt %d of %d)",
+		detection.Description(), delay, status.Attempts, policy.MaxAttempts)
+
+	return delay, nil
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package configuration
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+	"github.com/appvia/terranetes-controller/pkg/utils/steps"
+)
+
+// captureStepResults is responsible for surfacing the results of the steps within a finished job
+// on the configuration status. The results are uploaded by the steps to a secret, which is only
+// used when it belongs to the job. Failures are logged, as the results are informational
+func (c *Controller) captureStepResults(ctx context.Context, configuration *terraformv1alpha1.Configuration, job *batchv1.Job, stage string) {
+	logger := log.WithFields(log.Fields{
+		"job":       job.Name,
+		"name":      configuration.Name,
+		"namespace": configuration.Namespace,
+		"stage":     stage,
+	})
+
+	secret := &v1.Secret{}
+	secret.Namespace = c.jobNamespace(configuration)
+	secret.Name = configuration.GetTerraformStepResultsSecretName()
+
+	found, err := kubernetes.GetIfExists(ctx, c.cc, secret)
+	if err != nil {
+		logger.WithError(err).Error("failed to retrieve the step results")
+
+		return
+	}
+	if !found {
+		return
+	}
+
+	results, err := steps.Decode(secret.Data[steps.ResultsKey])
+	if err != nil {
+		logger.WithError(err).Error("failed to decode the step results")
+
+		return
+	}
+	if results.Job != job.Name {
+		return
+	}
+
+	status := &terraformv1alpha1.StepsStatus{Stage: stage}
+	for _, x := range results.Steps {
+		step := terraformv1alpha1.StepStatus{
+			Name:     x.Name,
+			Duration: metav1.Duration{Duration: x.Duration},
+			ExitCode: x.ExitCode,
+			Ignored:  x.Ignored,
+			Retries:  x.Retries,
+		}
+		if x.ExitCode != 0 {
+			step.Message = x.Stderr
+		}
+		status.Steps = append(status.Steps, step)
+	}
+	if failed, found := results.FailedStep(); found {
+		status.FailedStep = failed.Name
+	}
+	configuration.Status.Steps = status
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package configuration
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/core/v1alpha1"
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/controller"
+	"github.com/appvia/terranetes-controller/pkg/utils"
+	"github.com/appvia/terranetes-controller/pkg/utils/filters"
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
+)
+
+// ensureStateUnlock is responsible for releasing a terraform state lock when requested via the
+// force-unlock annotation. The unlock job is only created when no other stage is running for the
+// configuration.
+func (c *Controller) ensureStateUnlock(configuration *terraformv1alpha1.Configuration, state *state) controller.EnsureFunc {
+	cond := controller.ConditionMgr(configuration, corev1alpha1.ConditionReady, c.recorder)
+
+	return func(ctx context.Context) (reconcile.Result, error) {
+		lockID := configuration.GetAnnotations()[terraformv1alpha1.ForceUnlockAnnotation]
+		if lockID == "" {
+			return reconcile.Result{}, nil
+		}
+		logger := log.WithFields(log.Fields{
+			"lock":      lockID,
+			"name":      configuration.Name,
+			"namespace": configuration.Namespace,
+		})
+
+		// @step: the lock id is used as a label on the job, so must be valid as such
+		if errs := validation.IsValidLabelValue(lockID); len(errs) > 0 {
+			c.recorder.Eventf(configuration, v1.EventTypeWarning, "StateUnlockFailed", "Invalid state lock id: %q", lockID)
+
+			return reconcile.Result{}, c.removeForceUnlockAnnotation(ctx, configuration)
+		}
+
+		job, found := filters.Jobs(state.jobs).
+			WithLabel(terraformv1alpha1.ForceUnlockAnnotation, lockID).
+			WithName(configuration.GetName()).
+			WithNamespace(configuration.GetNamespace()).
+			WithStage(terraformv1alpha1.StageTerraformUnlock).
+			WithUID(string(configuration.GetUID())).
+			Latest()
+
+		if !found {
+			// @step: we must never release the lock from underneath a running stage
+			list, _ := filters.Jobs(state.jobs).
+				WithName(configuration.GetName()).
+				WithNamespace(configuration.GetNamespace()).
+				WithUID(string(configuration.GetUID())).
+				List()
+			if list != nil {
+				for i := 0; i < len(list.Items); i++ {
+					if jobs.IsComplete(&list.Items[i]) || jobs.IsFailed(&list.Items[i]) {
+						continue
+					}
+					logger.WithField("job", list.Items[i].Name).Info("waiting for the running job to finish before releasing the state lock")
+
+					return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+				}
+			}
+
+			runner, err := jobs.New(configuration, state.provider).NewTerraformUnlock(jobs.Options{
+				AdditionalJobAnnotations: state.provider.JobAnnotations(),
+				AdditionalJobSecrets:     state.additionalJobSecrets,
+				AdditionalJobLabels: utils.MergeStringMaps(
+					c.ControllerJobLabels,
+					state.provider.JobLabels(),
+					configuration.GetLabels(),
+					map[string]string{
+						terraformv1alpha1.ForceUnlockAnnotation: lockID,
+					}),
+				BackoffLimit:           c.BackoffLimit,
+				BinaryPath:             c.engineBinaryPath(state.engine),
+				CacheVolume:            c.jobCacheVolume(),
+				Engine:                 state.engine,
+				ExecutorImage:          c.ExecutorImage,
+				ExecutorSecrets:        c.ExecutorSecrets,
+				Image:                  c.engineImage(state.engine, state.version),
+				Namespace:              c.jobNamespace(configuration),
+				PodTemplateOverrides:   state.podTemplateOverrides,
+				ProviderMirrorCASecret: c.ProviderMirrorCASecret,
+				ProviderMirrorURL:      c.ProviderMirrorURL,
+				SourceAllowedHosts:     c.SourceAllowedHosts,
+				SourceAllowedSchemes:   c.SourceAllowedSchemes,
+				SourceSigningKeys:      c.SourceSigningKeys,
+				StateLockID:            lockID,
+				Template:               state.jobTemplate,
+			})
+			if err != nil {
+				cond.Failed(err, "Failed to create the terraform unlock job")
+
+				return reconcile.Result{}, err
+			}
+
+			if err := c.cc.Create(ctx, runner); err != nil {
+				cond.Failed(err, "Failed to create the terraform unlock job")
+
+				return reconcile.Result{}, err
+			}
+			c.recorder.Eventf(configuration, v1.EventTypeNormal, "StateUnlock", "Releasing the terraform state lock: %q", lockID)
+			cond.InProgress("Releasing the terraform state lock")
+
+			return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+
+		switch {
+		case jobs.IsComplete(job):
+			logger.Info("released the terraform state lock")
+			c.recorder.Eventf(configuration, v1.EventTypeNormal, "StateUnlocked", "Released the terraform state lock: %q", lockID)
+			configuration.Status.StateLock = nil
+
+		case jobs.IsFailed(job):
+			c.recorder.Eventf(configuration, v1.EventTypeWarning, "StateUnlockFailed",
+				"Failed to release the terraform state lock: %q, check the logs of job %s/%s", lockID, job.Namespace, job.Name)
+
+		default:
+			cond.InProgress("Releasing the terraform state lock")
+
+			return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+
+		return reconcile.Result{}, c.removeForceUnlockAnnotation(ctx, configuration)
+	}
+}
+
+// removeForceUnlockAnnotation removes the force-unlock annotation from the configuration; the patch
+// is made on a copy so the pending status changes are not overwritten by the response
+func (c *Controller) removeForceUnlockAnnotation(ctx context.Context, configuration *terraformv1alpha1.Configuration) error {
+	patched := configuration.DeepCopy()
+	delete(patched.Annotations, terraformv1alpha1.ForceUnlockAnnotation)
+
+	if err := c.cc.Patch(ctx, patched, client.MergeFrom(configuration)); err != nil {
+		return err
+	}
+	configuration.Annotations = patched.Annotations
+
+	return nil
+}
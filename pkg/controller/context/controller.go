@@ -18,32 +18,96 @@
 package context
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
 	"github.com/appvia/terranetes-controller/pkg/handlers/contexts"
 )
 
+const controllerName = "context.terraform.appvia.io"
+
+// DefaultSourcesInterval is the default interval to resync the context sources
+const DefaultSourcesInterval = 5 * time.Minute
+
 // Controller handles the reconciliation of the resource
 type Controller struct {
+	// cc is the kubernetes client to the cluster
+	cc client.Client
+	// recorder is the kubernetes event recorder
+	recorder record.EventRecorder
+	// ControllerNamespace is the namespace the controller is running in
+	ControllerNamespace string
+	// EnableNamespacedJobs indicates the configuration state is held in the namespace of the configuration
+	EnableNamespacedJobs bool
 	// EnableWebhooks indicates if the webhooks should be enabled
 	EnableWebhooks bool
+	// SourcesInterval is the interval to resync the dynamic sources of a context
+	SourcesInterval time.Duration
 }
 
 // Add is called to setup the manager for the controller
 func (c *Controller) Add(mgr manager.Manager) error {
 	log.Info("adding the contexts controller")
 
+	if c.SourcesInterval <= 0 {
+		c.SourcesInterval = DefaultSourcesInterval
+	}
+
+	c.cc = mgr.GetClient()
+	c.recorder = mgr.GetEventRecorderFor(controllerName)
+
 	if c.EnableWebhooks {
 		mgr.GetWebhookServer().Register(
 			fmt.Sprintf("/validate/%s/contexts", terraformv1alpha1.GroupName),
 			admission.WithCustomValidator(mgr.GetScheme(), &terraformv1alpha1.Context{}, contexts.NewValidator(mgr.GetClient())),
 		)
+		mgr.GetWebhookServer().Register(
+			fmt.Sprintf("/validate/%s/namespacecontexts", terraformv1alpha1.GroupName),
+			admission.WithCustomValidator(mgr.GetScheme(), &terraformv1alpha1.NamespaceContext{}, contexts.NewNamespaceValidator(mgr.GetClient())),
+		)
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&terraformv1alpha1.Context{}, builder.WithPredicates(&predicate.GenerationChangedPredicate{})).
+		Named(controllerName).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 5}).
+		Watches(
+			&terraformv1alpha1.Configuration{},
+			// allows us to requeue any contexts sourcing outputs from the configuration
+			handler.EnqueueRequestsFromMapFunc(c.findContextsForConfiguration),
+		).
+		Complete(c)
+}
+
+// findContextsForConfiguration returns the contexts which have a source referencing the configuration
+func (c *Controller) findContextsForConfiguration(ctx context.Context, o client.Object) []reconcile.Request {
+	list := &terraformv1alpha1.ContextList{}
+	if err := c.cc.List(ctx, list); err != nil {
+		log.WithError(err).Error("failed to list the contexts")
+
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, x := range list.Items {
+		if x.Spec.HasConfigurationSource(o.GetNamespace(), o.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKey{Name: x.Name}})
+		}
 	}
 
-	return nil
+	return requests
 }
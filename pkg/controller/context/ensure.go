@@ -0,0 +1,336 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package context
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/controller"
+	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+	"github.com/appvia/terranetes-controller/pkg/utils/terraform"
+)
+
+// errSourceUnresolved is used to indicate a source could not be resolved
+type errSourceUnresolved struct {
+	message string
+}
+
+// Error returns the error message
+func (e *errSourceUnresolved) Error() string {
+	return e.message
+}
+
+// newSourceUnresolved returns an error indicating the source could not be resolved
+func newSourceUnresolved(message string, args ...interface{}) error {
+	return &errSourceUnresolved{message: fmt.Sprintf(message, args...)}
+}
+
+// ensureSourcesEnabled is responsible for checking if the context has any dynamic sources
+func (c *Controller) ensureSourcesEnabled(txt *terraformv1alpha1.Context) controller.EnsureFunc {
+	cond := controller.ConditionMgr(txt, terraformv1alpha1.ConditionContextSources, c.recorder)
+
+	return func(ctx context.Context) (reconcile.Result, error) {
+		if txt.Spec.HasSources() || len(txt.Status.Sources) > 0 {
+			return reconcile.Result{}, nil
+		}
+		cond.Disabled("Context has no dynamic sources")
+
+		return reconcile.Result{}, nil
+	}
+}
+
+// ensureSources is responsible for resolving the dynamic sources and updating the context variables
+func (c *Controller) ensureSources(txt *terraformv1alpha1.Context) controller.EnsureFunc {
+	cond := controller.ConditionMgr(txt, terraformv1alpha1.ConditionContextSources, c.recorder)
+
+	return func(ctx context.Context) (reconcile.Result, error) {
+		if !txt.Spec.HasSources() && len(txt.Status.Sources) == 0 {
+			return reconcile.Result{}, nil
+		}
+
+		var statuses []terraformv1alpha1.ContextSourceStatus
+		var unresolved []string
+		var updated bool
+
+		variables := make(map[string]runtime.RawExtension)
+		for k, v := range txt.Spec.Variables {
+			variables[k] = v
+		}
+
+		for _, source := range txt.Spec.Sources {
+			status := terraformv1alpha1.ContextSourceStatus{Name: source.Name}
+			if previous, found := txt.GetSourceStatus(source.Name); found {
+				status.LastResolved = previous.LastResolved
+			}
+
+			value, err := c.resolveSource(ctx, source)
+			if err != nil {
+				if _, ok := err.(*errSourceUnresolved); !ok {
+					cond.Failed(err, "Failed to resolve the context source %q", source.Name)
+
+					return reconcile.Result{}, err
+				}
+				status.Message = err.Error()
+				statuses = append(statuses, status)
+				unresolved = append(unresolved, source.Name)
+
+				continue
+			}
+
+			encoded, err := json.Marshal(map[string]interface{}{
+				terraformv1alpha1.ContextDescription: source.Description,
+				terraformv1alpha1.ContextValue:       value,
+			})
+			if err != nil {
+				cond.Failed(err, "Failed to encode the value of context source %q", source.Name)
+
+				return reconcile.Result{}, err
+			}
+
+			status.Resolved = true
+			status.LastResolved = ptr.To(metav1.Now())
+			statuses = append(statuses, status)
+
+			if isSameVariable(variables[source.Name], encoded) {
+				continue
+			}
+			variables[source.Name] = runtime.RawExtension{Raw: encoded}
+			updated = true
+		}
+
+		// @step: remove any variables from sources which are no longer defined
+		for _, x := range txt.Status.Sources {
+			if hasSource(txt.Spec.Sources, x.Name) {
+				continue
+			}
+			if _, found := variables[x.Name]; found {
+				delete(variables, x.Name)
+				updated = true
+			}
+		}
+
+		// @step: update the variables on a copy, so we don't lose the status changes
+		if updated {
+			resource := txt.DeepCopy()
+			resource.Spec.Variables = variables
+			if err := c.cc.Update(ctx, resource); err != nil {
+				cond.Failed(err, "Failed to update the context variables from the sources")
+
+				return reconcile.Result{}, err
+			}
+			txt.Spec = resource.Spec
+			txt.ResourceVersion = resource.ResourceVersion
+		}
+		txt.Status.Sources = statuses
+
+		switch {
+		case !txt.Spec.HasSources():
+			cond.Disabled("Context has no dynamic sources")
+		case len(unresolved) > 0:
+			cond.Warning("Unable to resolve context sources: %s", strings.Join(unresolved, ", "))
+		default:
+			cond.Success("All context sources resolved")
+		}
+
+		return reconcile.Result{}, nil
+	}
+}
+
+// resolveSource is responsible for retrieving the value of a source
+func (c *Controller) resolveSource(ctx context.Context, source terraformv1alpha1.ContextSource) (interface{}, error) {
+	switch {
+	case source.ConfigMap != nil:
+		return c.resolveConfigMap(ctx, source.ConfigMap)
+	case source.Secret != nil:
+		return c.resolveSecret(ctx, source.Secret)
+	case source.Configuration != nil:
+		return c.resolveConfiguration(ctx, source.Configuration)
+	case source.Cluster != "":
+		return c.resolveClusterFact(ctx, source.Cluster)
+	}
+
+	return nil, newSourceUnresolved("source has no type defined")
+}
+
+// resolveConfigMap retrieves the value from a key within a configmap
+func (c *Controller) resolveConfigMap(ctx context.Context, ref *terraformv1alpha1.ContextSourceKeyReference) (interface{}, error) {
+	cm := &v1.ConfigMap{}
+	cm.Namespace = ref.Namespace
+	cm.Name = ref.Name
+
+	found, err := kubernetes.GetIfExists(ctx, c.cc, cm)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, newSourceUnresolved("configmap (%s/%s) not found", ref.Namespace, ref.Name)
+	}
+	value, found := cm.Data[ref.Key]
+	if !found {
+		return nil, newSourceUnresolved("configmap (%s/%s) does not have key %q", ref.Namespace, ref.Name, ref.Key)
+	}
+
+	return value, nil
+}
+
+// resolveSecret retrieves the value from a key within a non-sensitive secret
+func (c *Controller) resolveSecret(ctx context.Context, ref *terraformv1alpha1.ContextSourceKeyReference) (interface{}, error) {
+	secret := &v1.Secret{}
+	secret.Namespace = ref.Namespace
+	secret.Name = ref.Name
+
+	found, err := kubernetes.GetIfExists(ctx, c.cc, secret)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, newSourceUnresolved("secret (%s/%s) not found", ref.Namespace, ref.Name)
+	}
+	if secret.GetAnnotations()[terraformv1alpha1.ContextSourceAnnotation] != "true" {
+		return nil, newSourceUnresolved("secret (%s/%s) is not annotated with %s=true",
+			ref.Namespace, ref.Name, terraformv1alpha1.ContextSourceAnnotation)
+	}
+	value, found := secret.Data[ref.Key]
+	if !found {
+		return nil, newSourceUnresolved("secret (%s/%s) does not have key %q", ref.Namespace, ref.Name, ref.Key)
+	}
+
+	return string(value), nil
+}
+
+// resolveConfiguration retrieves the value of an output from a configuration terraform state
+func (c *Controller) resolveConfiguration(ctx context.Context, ref *terraformv1alpha1.ContextSourceConfigurationReference) (interface{}, error) {
+	configuration := &terraformv1alpha1.Configuration{}
+	configuration.Namespace = ref.Namespace
+	configuration.Name = ref.Name
+
+	found, err := kubernetes.GetIfExists(ctx, c.cc, configuration)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, newSourceUnresolved("configuration (%s/%s) not found", ref.Namespace, ref.Name)
+	}
+
+	secret := &v1.Secret{}
+	secret.Namespace = c.ControllerNamespace
+	secret.Name = configuration.GetTerraformStateSecretName()
+	if c.EnableNamespacedJobs {
+		secret.Namespace = configuration.Namespace
+	}
+
+	found, err = kubernetes.GetIfExists(ctx, c.cc, secret)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, newSourceUnresolved("configuration (%s/%s) has no terraform state", ref.Namespace, ref.Name)
+	}
+
+	state, err := terraform.DecodeState(secret.Data[terraformv1alpha1.TerraformStateSecretKey])
+	if err != nil {
+		return nil, err
+	}
+	output, found := state.Outputs[ref.Output]
+	if !found {
+		return nil, newSourceUnresolved("configuration (%s/%s) does not have output %q", ref.Namespace, ref.Name, ref.Output)
+	}
+	// @step: the context is readable by all, so sensitive outputs must never be copied into it
+	if output.Sensitive {
+		return nil, newSourceUnresolved("configuration (%s/%s) output %q is sensitive", ref.Namespace, ref.Name, ref.Output)
+	}
+
+	return output.Value, nil
+}
+
+// resolveClusterFact retrieves a fact about the cluster
+func (c *Controller) resolveClusterFact(ctx context.Context, fact string) (interface{}, error) {
+	var label string
+
+	switch fact {
+	case terraformv1alpha1.ContextSourceClusterNodeRegions:
+		label = v1.LabelTopologyRegion
+	case terraformv1alpha1.ContextSourceClusterNodeZones:
+		label = v1.LabelTopologyZone
+	default:
+		return nil, newSourceUnresolved("unknown cluster fact %q", fact)
+	}
+
+	list := &v1.NodeList{}
+	if err := c.cc.List(ctx, list); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]bool)
+	for _, node := range list.Items {
+		if value, found := node.GetLabels()[label]; found && value != "" {
+			values[value] = true
+		}
+	}
+	if len(values) == 0 {
+		return nil, newSourceUnresolved("no nodes found with the label %s", label)
+	}
+
+	var facts []string
+	for k := range values {
+		facts = append(facts, k)
+	}
+	sort.Strings(facts)
+
+	return facts, nil
+}
+
+// isSameVariable checks if the existing variable is the same as the encoded value
+func isSameVariable(existing runtime.RawExtension, encoded []byte) bool {
+	if len(existing.Raw) == 0 {
+		return false
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(existing.Raw, &decoded); err != nil {
+		return false
+	}
+	normalized, err := json.Marshal(decoded)
+	if err != nil {
+		return false
+	}
+
+	return string(normalized) == string(encoded)
+}
+
+// hasSource checks if the source name exists in the list
+func hasSource(sources []terraformv1alpha1.ContextSource, name string) bool {
+	for _, x := range sources {
+		if x.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,262 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package context
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/core/v1alpha1"
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/schema"
+	controllertests "github.com/appvia/terranetes-controller/test"
+	"github.com/appvia/terranetes-controller/test/fixtures"
+)
+
+func TestReconcile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Running Test Suite")
+}
+
+var _ = Describe("Context Controller", func() {
+	logrus.SetOutput(io.Discard)
+
+	ctx := context.Background()
+
+	var cc client.Client
+	var result reconcile.Result
+	var rerr error
+	var controller *Controller
+	var txt *terraformv1alpha1.Context
+
+	BeforeEach(func() {
+		cc = fake.NewClientBuilder().
+			WithScheme(schema.GetScheme()).
+			WithStatusSubresource(&terraformv1alpha1.Context{}).
+			Build()
+
+		controller = &Controller{
+			cc:                  cc,
+			recorder:            &controllertests.FakeRecorder{},
+			ControllerNamespace: "terraform-system",
+			SourcesInterval:     DefaultSourcesInterval,
+		}
+		txt = fixtures.NewTerranettesContext("default")
+	})
+
+	When("the context has no sources", func() {
+		BeforeEach(func() {
+			Expect(cc.Create(ctx, txt)).To(Succeed())
+
+			result, rerr = controller.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKey{Name: txt.Name}})
+		})
+
+		It("should not error or requeue", func() {
+			Expect(rerr).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeZero())
+		})
+
+		It("should indicate the sources are disabled", func() {
+			Expect(cc.Get(ctx, txt.GetNamespacedName(), txt)).To(Succeed())
+
+			cond := txt.Status.GetCondition(terraformv1alpha1.ConditionContextSources)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Reason).To(Equal(corev1alpha1.ReasonDisabled))
+			Expect(cond.Message).To(Equal("Context has no dynamic sources"))
+		})
+
+		It("should not change the variables", func() {
+			Expect(cc.Get(ctx, txt.GetNamespacedName(), txt)).To(Succeed())
+			Expect(txt.Spec.Variables).To(HaveLen(2))
+			Expect(txt.Status.Sources).To(BeEmpty())
+		})
+	})
+
+	When("the context references a sensitive configuration output", func() {
+		BeforeEach(func() {
+			configuration := fixtures.NewValidBucketConfiguration("apps", "bucket")
+			state := fixtures.NewTerraformStateFromJSON(configuration, `{
+				"outputs": {
+					"password": {"value": "s3cr3t", "type": "string", "sensitive": true}
+				}
+			}`)
+			state.Namespace = controller.ControllerNamespace
+
+			for _, x := range []client.Object{configuration, state} {
+				Expect(cc.Create(ctx, x)).To(Succeed())
+			}
+
+			txt.Spec.Sources = []terraformv1alpha1.ContextSource{
+				{
+					Name:          "password",
+					Description:   "A sensitive output",
+					Configuration: &terraformv1alpha1.ContextSourceConfigurationReference{Namespace: "apps", Name: "bucket", Output: "password"},
+				},
+			}
+			Expect(cc.Create(ctx, txt)).To(Succeed())
+
+			result, rerr = controller.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKey{Name: txt.Name}})
+		})
+
+		It("should not error", func() {
+			Expect(rerr).ToNot(HaveOccurred())
+		})
+
+		It("should not copy the output into the context", func() {
+			Expect(cc.Get(ctx, txt.GetNamespacedName(), txt)).To(Succeed())
+			Expect(txt.Spec.HasVariable("password")).To(BeFalse())
+
+			status, found := txt.GetSourceStatus("password")
+			Expect(found).To(BeTrue())
+			Expect(status.Resolved).To(BeFalse())
+			Expect(status.Message).To(Equal("configuration (apps/bucket) output \"password\" is sensitive"))
+		})
+	})
+
+	When("the context has sources", func() {
+		BeforeEach(func() {
+			configuration := fixtures.NewValidBucketConfiguration("apps", "bucket")
+			state := fixtures.NewTerraformState(configuration)
+			state.Namespace = controller.ControllerNamespace
+
+			cm := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "network"}}
+			cm.Data = map[string]string{"vpc": "vpc-654321"}
+
+			sensitive := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "apps", Name: "sensitive"}}
+			sensitive.Data = map[string][]byte{"password": []byte("secret")}
+
+			nodes := []*v1.Node{
+				{ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: map[string]string{v1.LabelTopologyZone: "eu-west-2b"}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "b", Labels: map[string]string{v1.LabelTopologyZone: "eu-west-2a"}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "c", Labels: map[string]string{v1.LabelTopologyZone: "eu-west-2a"}}},
+			}
+			for _, x := range []client.Object{configuration, state, cm, sensitive, nodes[0], nodes[1], nodes[2]} {
+				Expect(cc.Create(ctx, x)).To(Succeed())
+			}
+
+			txt.Spec.Sources = []terraformv1alpha1.ContextSource{
+				{
+					Name:        "vpc_id",
+					Description: "The VPC id",
+					ConfigMap:   &terraformv1alpha1.ContextSourceKeyReference{Namespace: "apps", Name: "network", Key: "vpc"},
+				},
+				{
+					Name:          "bucket",
+					Description:   "The bucket output",
+					Configuration: &terraformv1alpha1.ContextSourceConfigurationReference{Namespace: "apps", Name: "bucket", Output: "test_output"},
+				},
+				{
+					Name:        "zones",
+					Description: "The node zones",
+					Cluster:     terraformv1alpha1.ContextSourceClusterNodeZones,
+				},
+				{
+					Name:        "password",
+					Description: "A sensitive value",
+					Secret:      &terraformv1alpha1.ContextSourceKeyReference{Namespace: "apps", Name: "sensitive", Key: "password"},
+				},
+			}
+			Expect(cc.Create(ctx, txt)).To(Succeed())
+
+			result, rerr = controller.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKey{Name: txt.Name}})
+		})
+
+		It("should requeue to resync the sources", func() {
+			Expect(rerr).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(DefaultSourcesInterval))
+		})
+
+		It("should update the variables from the resolved sources", func() {
+			Expect(cc.Get(ctx, txt.GetNamespacedName(), txt)).To(Succeed())
+
+			value, found, err := txt.Spec.GetVariable("vpc_id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("vpc-654321"))
+
+			value, found, err = txt.Spec.GetVariable("bucket")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal("test"))
+
+			value, found, err = txt.Spec.GetVariable("zones")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(value).To(Equal([]interface{}{"eu-west-2a", "eu-west-2b"}))
+		})
+
+		It("should not use secrets which are not marked as a context source", func() {
+			Expect(cc.Get(ctx, txt.GetNamespacedName(), txt)).To(Succeed())
+			Expect(txt.Spec.HasVariable("password")).To(BeFalse())
+
+			status, found := txt.GetSourceStatus("password")
+			Expect(found).To(BeTrue())
+			Expect(status.Resolved).To(BeFalse())
+			Expect(status.Message).To(Equal("secret (apps/sensitive) is not annotated with terraform.appvia.io/context-source=true"))
+		})
+
+		It("should record the resolution state of each source", func() {
+			Expect(cc.Get(ctx, txt.GetNamespacedName(), txt)).To(Succeed())
+			Expect(txt.Status.Sources).To(HaveLen(4))
+
+			for _, name := range []string{"vpc_id", "bucket", "zones"} {
+				status, found := txt.GetSourceStatus(name)
+				Expect(found).To(BeTrue())
+				Expect(status.Resolved).To(BeTrue())
+				Expect(status.LastResolved).ToNot(BeNil())
+			}
+		})
+
+		It("should have a warning on the sources condition", func() {
+			Expect(cc.Get(ctx, txt.GetNamespacedName(), txt)).To(Succeed())
+
+			cond := txt.Status.GetCondition(terraformv1alpha1.ConditionContextSources)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Reason).To(Equal(corev1alpha1.ReasonWarning))
+			Expect(cond.Message).To(Equal("Unable to resolve context sources: password"))
+		})
+
+		Context("and a source is removed", func() {
+			BeforeEach(func() {
+				Expect(cc.Get(ctx, txt.GetNamespacedName(), txt)).To(Succeed())
+				txt.Spec.Sources = txt.Spec.Sources[1:]
+				Expect(cc.Update(ctx, txt)).To(Succeed())
+
+				result, rerr = controller.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKey{Name: txt.Name}})
+			})
+
+			It("should remove the variable", func() {
+				Expect(rerr).ToNot(HaveOccurred())
+				Expect(cc.Get(ctx, txt.GetNamespacedName(), txt)).To(Succeed())
+				Expect(txt.Spec.HasVariable("vpc_id")).To(BeFalse())
+				Expect(txt.Spec.HasVariable("public_subnets")).To(BeTrue())
+				Expect(txt.Status.Sources).To(HaveLen(3))
+			})
+		})
+	})
+})
@@ -22,6 +22,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -40,6 +41,7 @@ import (
 	"github.com/appvia/terranetes-controller/pkg/utils/filters"
 	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
 	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+	"github.com/appvia/terranetes-controller/pkg/utils/preload"
 	"github.com/appvia/terranetes-controller/pkg/utils/template"
 )
 
@@ -54,8 +56,8 @@ func (c *Controller) ensurePreloadEnabled(provider *terraformv1alpha1.Provider)
 
 			return reconcile.Result{}, controller.ErrIgnore
 
-		case provider.Spec.Provider != terraformv1alpha1.AWSProviderType:
-			cond.Warning("Loading contextual is supported on AWS only")
+		case !preload.IsSupported(provider.Spec.Provider.String()):
+			cond.Warning("Loading contextual data is supported on AWS, Azure and Google only")
 
 			return reconcile.Result{}, controller.ErrIgnore
 		}
@@ -162,12 +164,130 @@ func (c *Controller) ensurePreloadStatus(provider *terraformv1alpha1.Provider, s
 
 			totalSuccess.Inc()
 			cond.Success("Contextual data successfully loaded")
+
+			state.context = txt
 		}
 
 		return reconcile.Result{}, nil
 	}
 }
 
+// ensureContextChanges is responsible for comparing the data loaded into the context against
+// the previous run, recording a summary of the changes on the context and raising events. When
+// enabled, any configurations referencing a changed variable are retried.
+func (c *Controller) ensureContextChanges(provider *terraformv1alpha1.Provider, state *state) controller.EnsureFunc {
+	cc := c.cc
+	cond := controller.ConditionMgr(provider, terraformv1alpha1.ConditionProviderPreload, c.recorder)
+
+	return func(ctx context.Context) (reconcile.Result, error) {
+		// @step: we only have a context once a preload job has completed successfully
+		if state.context == nil {
+			return reconcile.Result{}, nil
+		}
+		txt := state.context
+
+		data, err := preload.NewDataFromContext(txt)
+		if err != nil {
+			cond.Failed(err, "Failed to decode the contextual data resource: %s", txt.Name)
+
+			return reconcile.Result{}, err
+		}
+		checksums, err := data.Checksums()
+		if err != nil {
+			cond.Failed(err, "Failed to compute the checksums for the contextual data resource: %s", txt.Name)
+
+			return reconcile.Result{}, err
+		}
+
+		changes := preload.Diff(txt.GetPreloadChecksums(), checksums)
+		if changes.IsEmpty() {
+			return reconcile.Result{}, nil
+		}
+
+		// @step: record the changes on the context status
+		original := txt.DeepCopy()
+		txt.Status.Preload = &terraformv1alpha1.ContextPreloadStatus{
+			Added:          changes.Added,
+			Changed:        changes.Changed,
+			Checksums:      checksums,
+			LastChangeTime: &metav1.Time{Time: time.Now()},
+			Removed:        changes.Removed,
+		}
+		if err := cc.Status().Patch(ctx, txt, client.MergeFrom(original)); err != nil {
+			cond.Failed(err, "Failed to update the status of the contextual data resource: %s", txt.Name)
+
+			return reconcile.Result{}, err
+		}
+		totalChanges.Add(float64(len(changes.Keys())))
+
+		log.WithFields(log.Fields{
+			"added":    len(changes.Added),
+			"changed":  len(changes.Changed),
+			"context":  txt.Name,
+			"provider": provider.Name,
+			"removed":  len(changes.Removed),
+		}).Info("contextual data has changed since the last preload")
+
+		// @step: raise events on both the context and provider
+		for _, x := range []client.Object{txt, provider} {
+			c.recorder.Eventf(x, v1.EventTypeNormal, "ContextChanged", "Contextual data changed: %s", changes.String())
+		}
+		for _, x := range []struct {
+			Keys   []string
+			Reason string
+		}{
+			{Keys: changes.Added, Reason: "ContextAdded"},
+			{Keys: changes.Changed, Reason: "ContextChanged"},
+			{Keys: changes.Removed, Reason: "ContextRemoved"},
+		} {
+			if len(x.Keys) > 0 {
+				c.recorder.Eventf(txt, v1.EventTypeNormal, x.Reason, "Variables: %s", strings.Join(x.Keys, ", "))
+			}
+		}
+
+		// @step: the initial load has nothing to compare against, so there is nothing to replan
+		if !provider.Spec.Preload.IsReplanOnChangeEnabled() || original.Status.Preload == nil {
+			return reconcile.Result{}, nil
+		}
+
+		return reconcile.Result{}, c.retryConfigurations(ctx, provider, txt, changes)
+	}
+}
+
+// retryConfigurations is responsible for retrying any configurations which reference a variable
+// in the context which has been changed
+func (c *Controller) retryConfigurations(ctx context.Context, provider *terraformv1alpha1.Provider, txt *terraformv1alpha1.Context, changes preload.Changes) error {
+	list := &terraformv1alpha1.ConfigurationList{}
+	if err := c.cc.List(ctx, list); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(list.Items); i++ {
+		configuration := &list.Items[i]
+
+		if !configuration.Spec.ValueFrom.HasContextReference(txt.Name, changes.Keys()) {
+			continue
+		}
+		if configuration.GetDeletionTimestamp() != nil {
+			continue
+		}
+
+		original := configuration.DeepCopy()
+		if configuration.Annotations == nil {
+			configuration.Annotations = map[string]string{}
+		}
+		configuration.Annotations[terraformv1alpha1.RetryAnnotation] = fmt.Sprintf("%d", time.Now().Unix())
+
+		if err := c.cc.Patch(ctx, configuration, client.MergeFrom(original)); err != nil {
+			return fmt.Errorf("failed to retry configuration: %s/%s, error: %w", configuration.Namespace, configuration.Name, err)
+		}
+		c.recorder.Eventf(provider, v1.EventTypeNormal, "ContextReplan",
+			"Retrying configuration %s/%s as the contextual data has changed", configuration.Namespace, configuration.Name)
+	}
+
+	return nil
+}
+
 // ensurePreload is responsible for checking when the last preloading job ran and if greater than
 // the interval, we run a new job
 func (c *Controller) ensurePreload(provider *terraformv1alpha1.Provider) controller.EnsureFunc {
@@ -24,12 +24,20 @@ import (
 
 func init() {
 	metrics.Registry.MustRegister(
+		totalChanges,
 		totalSuccess,
 		totalFailure,
 	)
 }
 
 var (
+	totalChanges = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "preload_changes_total",
+			Help: "Total number of context variables added, changed or removed by preloads",
+		},
+	)
+
 	totalFailure = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Name: "preload_failure_total",
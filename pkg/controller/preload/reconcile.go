@@ -30,6 +30,8 @@ import (
 )
 
 type state struct {
+	// context is the context populated by the preload job
+	context *terraformv1alpha1.Context
 	// is the jobs currently found
 	jobs *batchv1.JobList
 }
@@ -55,6 +57,7 @@ func (c *Controller) Reconcile(ctx context.Context, request reconcile.Request) (
 			c.ensureReady(provider),
 			c.ensurePreloadNotRunning(provider, state),
 			c.ensurePreloadStatus(provider, state),
+			c.ensureContextChanges(provider, state),
 			c.ensurePreload(provider),
 		})
 	if err != nil {
@@ -32,6 +32,7 @@ import (
 	//	batchv1 "k8s.io/api/batch/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -102,7 +103,7 @@ var _ = Describe("Preload Controller", func() {
 				Expect(cond.Type).To(Equal(terraformv1alpha1.ConditionProviderPreload))
 				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
 				Expect(cond.Reason).To(Equal(corev1alpha1.ReasonWarning))
-				Expect(cond.Message).To(Equal("Loading contextual is supported on AWS only"))
+				Expect(cond.Message).To(Equal("Loading contextual data is supported on AWS, Azure and Google only"))
 			})
 		})
 
@@ -425,6 +426,8 @@ var _ = Describe("Preload Controller", func() {
 				Expect(cc.Create(context.Background(), job)).To(Succeed())
 
 				txt := fixtures.NewTerranettesContext(provider.Spec.Preload.Context)
+				txt.Annotations = map[string]string{terraformv1alpha1.PreloadKeysAnnotation: "public_subnets,vpc_id"}
+				txt.Spec.Variables["manual"] = runtime.RawExtension{Raw: []byte(`{"description": "added by hand", "value": "manual"}`)}
 				Expect(cc.Create(context.Background(), txt)).To(Succeed())
 
 				provider.Status.LastPreloadTime = &metav1.Time{Time: time.Now().Add(-10 * time.Minute)}
@@ -456,6 +459,136 @@ var _ = Describe("Preload Controller", func() {
 			})
 		})
 
+		Context("and the completed job is the first to populate the context", func() {
+			var recorder *controllertests.FakeRecorder
+
+			BeforeEach(func() {
+				recorder = &controllertests.FakeRecorder{}
+				ctrl.recorder = recorder
+
+				job := fixtures.NewCompletedPreloadJob("default", provider.Name)
+				Expect(cc.Create(context.Background(), job)).To(Succeed())
+
+				txt := fixtures.NewTerranettesContext(provider.Spec.Preload.Context)
+				txt.Annotations = map[string]string{terraformv1alpha1.PreloadKeysAnnotation: "public_subnets,vpc_id"}
+				txt.Spec.Variables["manual"] = runtime.RawExtension{Raw: []byte(`{"description": "added by hand", "value": "manual"}`)}
+				Expect(cc.Create(context.Background(), txt)).To(Succeed())
+
+				provider.Status.LastPreloadTime = &metav1.Time{Time: time.Now()}
+				Expect(cc.Status().Update(context.Background(), provider)).To(Succeed())
+
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, provider, 0)
+			})
+
+			It("should not error", func() {
+				Expect(rerr).NotTo(HaveOccurred())
+			})
+
+			It("should record the changes on the context", func() {
+				txt := &terraformv1alpha1.Context{}
+				Expect(cc.Get(context.Background(), client.ObjectKey{Name: provider.Spec.Preload.Context}, txt)).To(Succeed())
+
+				Expect(txt.Status.Preload).ToNot(BeNil())
+				Expect(txt.Status.Preload.Added).To(Equal([]string{"public_subnets", "vpc_id"}))
+				Expect(txt.Status.Preload.Changed).To(BeEmpty())
+				Expect(txt.Status.Preload.Removed).To(BeEmpty())
+				Expect(txt.Status.Preload.Checksums).To(HaveLen(2))
+				Expect(txt.Status.Preload.Checksums).ToNot(HaveKey("manual"))
+				Expect(txt.Status.Preload.LastChangeTime).ToNot(BeNil())
+			})
+
+			It("should raise an event on the provider", func() {
+				Expect(recorder.Events).To(ContainElement(
+					"(/aws) Normal ContextChanged: Contextual data changed: 2 added, 0 changed, 0 removed",
+				))
+				Expect(recorder.Events).To(ContainElement(
+					"(/test-context) Normal ContextAdded: Variables: public_subnets, vpc_id",
+				))
+			})
+
+			It("should not raise events when reconciled again", func() {
+				recorder.Events = nil
+
+				Expect(cc.Get(context.Background(), provider.GetNamespacedName(), provider)).To(Succeed())
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, provider, 0)
+				Expect(rerr).NotTo(HaveOccurred())
+				Expect(recorder.Events).ToNot(ContainElement(ContainSubstring("ContextChanged")))
+			})
+		})
+
+		Context("and the completed job has changed the context", func() {
+			var recorder *controllertests.FakeRecorder
+			var configuration *terraformv1alpha1.Configuration
+			var unrelated *terraformv1alpha1.Configuration
+
+			BeforeEach(func() {
+				recorder = &controllertests.FakeRecorder{}
+				ctrl.recorder = recorder
+
+				provider.Spec.Preload.ReplanOnChange = ptr.To(true)
+				Expect(cc.Update(context.Background(), provider)).To(Succeed())
+
+				job := fixtures.NewCompletedPreloadJob("default", provider.Name)
+				Expect(cc.Create(context.Background(), job)).To(Succeed())
+
+				txt := fixtures.NewTerranettesContext(provider.Spec.Preload.Context)
+				txt.Annotations = map[string]string{terraformv1alpha1.PreloadKeysAnnotation: "public_subnets,vpc_id"}
+				txt.Spec.Variables["manual"] = runtime.RawExtension{Raw: []byte(`{"description": "added by hand", "value": "manual"}`)}
+				Expect(cc.Create(context.Background(), txt)).To(Succeed())
+				txt.Status.Preload = &terraformv1alpha1.ContextPreloadStatus{
+					Checksums: map[string]string{
+						"vpc_id":      "changed",
+						"old_subnets": "removed",
+					},
+				}
+				Expect(cc.Status().Update(context.Background(), txt)).To(Succeed())
+
+				configuration = fixtures.NewValidBucketConfiguration("default", "referenced")
+				configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{
+					{Context: ptr.To(txt.Name), Key: "vpc_id"},
+				}
+				Expect(cc.Create(context.Background(), configuration)).To(Succeed())
+
+				unrelated = fixtures.NewValidBucketConfiguration("default", "unrelated")
+				unrelated.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{
+					{Context: ptr.To("other"), Key: "vpc_id"},
+				}
+				Expect(cc.Create(context.Background(), unrelated)).To(Succeed())
+
+				provider.Status.LastPreloadTime = &metav1.Time{Time: time.Now()}
+				Expect(cc.Status().Update(context.Background(), provider)).To(Succeed())
+
+				result, _, rerr = controllertests.Roll(context.TODO(), ctrl, provider, 0)
+			})
+
+			It("should not error", func() {
+				Expect(rerr).NotTo(HaveOccurred())
+			})
+
+			It("should record the changes on the context", func() {
+				txt := &terraformv1alpha1.Context{}
+				Expect(cc.Get(context.Background(), client.ObjectKey{Name: provider.Spec.Preload.Context}, txt)).To(Succeed())
+
+				Expect(txt.Status.Preload).ToNot(BeNil())
+				Expect(txt.Status.Preload.Added).To(Equal([]string{"public_subnets"}))
+				Expect(txt.Status.Preload.Changed).To(Equal([]string{"vpc_id"}))
+				Expect(txt.Status.Preload.Removed).To(Equal([]string{"old_subnets"}))
+			})
+
+			It("should retry the configurations referencing the changed keys", func() {
+				Expect(cc.Get(context.Background(), configuration.GetNamespacedName(), configuration)).To(Succeed())
+				Expect(configuration.Annotations).To(HaveKey(terraformv1alpha1.RetryAnnotation))
+				Expect(recorder.Events).To(ContainElement(
+					"(/aws) Normal ContextReplan: Retrying configuration default/referenced as the contextual data has changed",
+				))
+			})
+
+			It("should not retry unrelated configurations", func() {
+				Expect(cc.Get(context.Background(), unrelated.GetNamespacedName(), unrelated)).To(Succeed())
+				Expect(unrelated.Annotations).ToNot(HaveKey(terraformv1alpha1.RetryAnnotation))
+			})
+		})
+
 		Context("no active jobs, but had a recent job", func() {
 			BeforeEach(func() {
 				Expect(cc.Delete(context.Background(), provider)).To(Succeed())
@@ -28,19 +28,23 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/utils/integrity"
 	"github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
 	"github.com/appvia/terranetes-controller/pkg/utils/policies"
+	"github.com/appvia/terranetes-controller/pkg/utils/terraform"
 )
 
 type validator struct {
 	cc client.Client
+	// enableHooks indicates the configurations are permitted to define hooks
+	enableHooks bool
 	// enableVersions indicates the terraform version can be changed
 	enableVersions bool
 }
 
 // NewValidator is validation handler
-func NewValidator(cc client.Client, versioning bool) admission.CustomValidator {
-	return &validator{cc: cc, enableVersions: versioning}
+func NewValidator(cc client.Client, versioning, hooks bool) admission.CustomValidator {
+	return &validator{cc: cc, enableHooks: hooks, enableVersions: versioning}
 }
 
 // ValidateCreate is called when a new resource is created
@@ -84,6 +88,11 @@ func (v *validator) validate(ctx context.Context, before, configuration *terrafo
 		return errors.New("spec.module is required")
 	}
 
+	if configuration.Spec.ModuleChecksum != "" {
+		if err := integrity.ValidateChecksum(configuration.Spec.ModuleChecksum); err != nil {
+			return fmt.Errorf("spec.moduleChecksum is invalid, %w", err)
+		}
+	}
 	if configuration.Spec.Plan != nil {
 		if err := configuration.Spec.Plan.IsValid(); err != nil {
 			return err
@@ -97,6 +106,14 @@ func (v *validator) validate(ctx context.Context, before, configuration *terrafo
 	if err := configuration.Spec.ProviderRef.IsValid(); err != nil {
 		return err
 	}
+	if configuration.Spec.Engine != "" && !terraformv1alpha1.IsValidEngine(configuration.Spec.Engine) {
+		return fmt.Errorf("spec.engine must be %s or %s", terraformv1alpha1.EngineOpenTofu, terraformv1alpha1.EngineTerraform)
+	}
+	if terraform.IsVersionConstraint(configuration.Spec.TerraformVersion) {
+		if _, err := terraform.ParseVersionConstraint(configuration.Spec.TerraformVersion); err != nil {
+			return errors.New("spec.terraformVersion is not a valid version constraint")
+		}
+	}
 
 	// @step: perform some checks which are dependent on if the resource is being created or updated
 	switch creating {
@@ -128,6 +145,13 @@ func (v *validator) validate(ctx context.Context, before, configuration *terrafo
 	if err := configuration.Spec.ValueFrom.IsValid(); err != nil {
 		return err
 	}
+	// @step: check the hooks are permitted and valid
+	if len(configuration.Spec.Hooks) > 0 && !v.enableHooks {
+		return errors.New("spec.hooks have been disabled, contact a platform administrator")
+	}
+	if err := terraformv1alpha1.HookList(configuration.Spec.Hooks).IsValid("spec.hooks"); err != nil {
+		return err
+	}
 
 	// @step: grab the namespace of the configuration
 	namespace := &v1.Namespace{}
@@ -145,6 +169,11 @@ func (v *validator) validate(ctx context.Context, before, configuration *terrafo
 		return err
 	}
 
+	// @step: check the configuration is permitted to use any sensitive context variables
+	if err := validateContextSecrets(ctx, v.cc, configuration, namespace); err != nil {
+		return err
+	}
+
 	list := &terraformv1alpha1.PolicyList{}
 	if err := v.cc.List(ctx, list); err != nil {
 		return err
@@ -154,6 +183,27 @@ func (v *validator) validate(ctx context.Context, before, configuration *terrafo
 		if err := validateModuleConstriants(configuration, list, namespace); err != nil {
 			return err
 		}
+		if err := validateHookNames(configuration, list); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateHookNames ensures the configuration hooks do not share a name with any hook injected by
+// the policies, as the names form the container names and policy hooks must always run
+func validateHookNames(configuration *terraformv1alpha1.Configuration, list *terraformv1alpha1.PolicyList) error {
+	for i, hook := range configuration.Spec.Hooks {
+		for _, policy := range list.Items {
+			for _, x := range policy.Spec.Hooks {
+				for _, injected := range x.Hooks {
+					if injected.Name == hook.Name {
+						return fmt.Errorf("spec.hooks[%d].name %q is reserved by the policy %q", i, hook.Name, policy.Name)
+					}
+				}
+			}
+		}
 	}
 
 	return nil
@@ -183,6 +233,55 @@ func validateProvider(ctx context.Context, cc client.Client, configuration *terr
 	return nil
 }
 
+// validateContextSecrets is called to ensure the configuration is permitted to consume any sensitive
+// context variables it references. A namespace context takes precedence over the cluster context,
+// following the same order the values are resolved by the controller
+func validateContextSecrets(ctx context.Context, cc client.Client, configuration *terraformv1alpha1.Configuration, namespace *v1.Namespace) error {
+	for i, x := range configuration.Spec.ValueFrom {
+		if x.Context == nil {
+			continue
+		}
+
+		local := &terraformv1alpha1.NamespaceContext{}
+		local.Namespace = configuration.Namespace
+		local.Name = *x.Context
+
+		found, err := kubernetes.GetIfExists(ctx, cc, local)
+		if err != nil {
+			return err
+		}
+		if found && local.Spec.HasVariable(x.Key) {
+			continue
+		}
+
+		txt := &terraformv1alpha1.Context{}
+		txt.Name = *x.Context
+
+		found, err = kubernetes.GetIfExists(ctx, cc, txt)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+
+		secret, found := txt.Spec.GetSecret(x.Key)
+		if !found || secret.Selector == nil {
+			continue
+		}
+
+		matched, err := kubernetes.IsSelectorMatch(*secret.Selector, configuration.GetLabels(), namespace.GetLabels())
+		if err != nil {
+			return err
+		}
+		if !matched {
+			return fmt.Errorf("spec.valueFrom[%d] is not permitted to use the sensitive context variable %q", i, x.Key)
+		}
+	}
+
+	return nil
+}
+
 // validateModuleConstriants evaluates the module constraints and ensure the configuration passes all policies
 func validateModuleConstriants(
 	configuration *terraformv1alpha1.Configuration,
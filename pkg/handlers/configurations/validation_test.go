@@ -24,6 +24,7 @@ import (
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -47,13 +48,13 @@ var _ = Describe("Checking Configuration Validation", func() {
 
 	BeforeEach(func() {
 		cc = fake.NewClientBuilder().WithScheme(schema.GetScheme()).WithRuntimeObjects(fixtures.NewNamespace("default")).Build()
-		v = &validator{cc: cc, enableVersions: true}
+		v = &validator{cc: cc, enableHooks: true, enableVersions: true}
 		configuration = fixtures.NewValidBucketConfiguration(namespace, name)
 	})
 
 	When("creating a validator", func() {
 		It("should not be nil", func() {
-			v := NewValidator(cc, true)
+			v := NewValidator(cc, true, true)
 			Expect(v).ToNot(BeNil())
 		})
 	})
@@ -272,6 +273,119 @@ var _ = Describe("Checking Configuration Validation", func() {
 			Expect(warnings).To(BeEmpty())
 		})
 
+		It("should fail when the module checksum is invalid", func() {
+			configuration.Spec.ModuleChecksum = "sha256:invalid"
+
+			warnings, err := v.ValidateCreate(ctx, configuration)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.moduleChecksum is invalid"))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("should fail when the engine is unknown", func() {
+			configuration.Spec.Engine = "unknown"
+
+			warnings, err := v.ValidateCreate(ctx, configuration)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("spec.engine must be opentofu or terraform"))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("should fail when the version constraint is invalid", func() {
+			configuration.Spec.TerraformVersion = "~> bad"
+
+			warnings, err := v.ValidateCreate(ctx, configuration)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("spec.terraformVersion is not a valid version constraint"))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("should not fail when the version constraint is valid", func() {
+			configuration.Spec.TerraformVersion = "~> 1.6, != 1.6.1"
+
+			warnings, err := v.ValidateCreate(ctx, configuration)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		Context("specifying hooks", func() {
+			It("should fail when the configuration hooks are disabled", func() {
+				v.enableHooks = false
+				configuration.Spec.Hooks = []terraformv1alpha1.Hook{
+					{Name: "lint", When: terraformv1alpha1.HookPrePlan, Image: "alpine:3", Commands: []string{"true"}},
+				}
+
+				warnings, err := v.ValidateCreate(ctx, configuration)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("spec.hooks have been disabled, contact a platform administrator"))
+				Expect(warnings).To(BeEmpty())
+			})
+
+			It("should fail when the hook has no commands", func() {
+				configuration.Spec.Hooks = []terraformv1alpha1.Hook{
+					{Name: "lint", When: terraformv1alpha1.HookPrePlan, Image: "alpine:3"},
+				}
+
+				warnings, err := v.ValidateCreate(ctx, configuration)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("spec.hooks[0].commands is required"))
+				Expect(warnings).To(BeEmpty())
+			})
+
+			It("should fail when the hook stage is invalid", func() {
+				configuration.Spec.Hooks = []terraformv1alpha1.Hook{
+					{Name: "lint", When: "never", Image: "alpine:3", Commands: []string{"true"}},
+				}
+
+				warnings, err := v.ValidateCreate(ctx, configuration)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("spec.hooks[0].when must be one of"))
+				Expect(warnings).To(BeEmpty())
+			})
+
+			It("should fail when the hook names are not unique", func() {
+				configuration.Spec.Hooks = []terraformv1alpha1.Hook{
+					{Name: "lint", When: terraformv1alpha1.HookPrePlan, Image: "alpine:3", Commands: []string{"true"}},
+					{Name: "lint", When: terraformv1alpha1.HookPostPlan, Image: "alpine:3", Commands: []string{"true"}},
+				}
+
+				warnings, err := v.ValidateCreate(ctx, configuration)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("spec.hooks[1].name \"lint\" is not unique"))
+				Expect(warnings).To(BeEmpty())
+			})
+
+			It("should not fail when the hooks are valid", func() {
+				configuration.Spec.Hooks = []terraformv1alpha1.Hook{
+					{Name: "lint", When: terraformv1alpha1.HookPrePlan, Image: "alpine:3", Commands: []string{"true"}},
+				}
+
+				_, err := v.ValidateCreate(ctx, configuration)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should fail when the hook name is reserved by a policy", func() {
+				policy := fixtures.NewPolicy("hooks")
+				policy.Spec.Hooks = []terraformv1alpha1.HookDefaults{
+					{
+						Hooks: []terraformv1alpha1.Hook{
+							{Name: "lint", When: terraformv1alpha1.HookPostPlan, Image: "alpine:3", Commands: []string{"true"}},
+						},
+					},
+				}
+				Expect(cc.Create(ctx, policy)).To(Succeed())
+
+				configuration.Spec.Hooks = []terraformv1alpha1.Hook{
+					{Name: "lint", When: terraformv1alpha1.HookPrePlan, Image: "alpine:3", Commands: []string{"true"}},
+				}
+
+				warnings, err := v.ValidateCreate(ctx, configuration)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("spec.hooks[0].name \"lint\" is reserved by the policy \"hooks\""))
+				Expect(warnings).To(BeEmpty())
+			})
+		})
+
 		Context("specifying value from inputs", func() {
 			It("should fail when no inputs are found", func() {
 				configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{{}}
@@ -410,6 +524,63 @@ var _ = Describe("Checking Configuration Validation", func() {
 			})
 		})
 
+		Context("sensitive context variable selectors do not match", func() {
+			BeforeEach(func() {
+				txt := fixtures.NewTerranettesContext("sensitive")
+				txt.Spec.Secrets = map[string]terraformv1alpha1.ContextSecret{
+					"password": {
+						Description: "password",
+						SecretRef:   terraformv1alpha1.ContextSecretKeyReference{Name: "sensitive", Key: "password"},
+						Selector: &terraformv1alpha1.Selector{
+							Namespace: &metav1.LabelSelector{
+								MatchLabels: map[string]string{"does_not_match": "true"},
+							},
+						},
+					},
+				}
+				Expect(cc.Create(ctx, txt)).To(Succeed())
+			})
+
+			It("should deny the creation of the configuration", func() {
+				configuration := fixtures.NewValidBucketConfiguration(namespace, "test")
+				configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{
+					{Context: pointer.String("sensitive"), Key: "password", Name: "password"},
+				}
+				warnings, err := v.ValidateCreate(ctx, configuration)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal(`spec.valueFrom[0] is not permitted to use the sensitive context variable "password"`))
+				Expect(warnings).To(BeEmpty())
+			})
+
+			It("should allow the variable when provided by a namespace context", func() {
+				local := &terraformv1alpha1.NamespaceContext{}
+				local.Namespace = namespace
+				local.Name = "sensitive"
+				local.Spec.Variables = map[string]runtime.RawExtension{
+					"password": {Raw: []byte(`{"description": "password", "value": "local"}`)},
+				}
+				Expect(cc.Create(ctx, local)).To(Succeed())
+
+				configuration := fixtures.NewValidBucketConfiguration(namespace, "test")
+				configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{
+					{Context: pointer.String("sensitive"), Key: "password", Name: "password"},
+				}
+				warnings, err := v.ValidateCreate(ctx, configuration)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(warnings).To(BeEmpty())
+			})
+
+			It("should allow non-sensitive variables from the same context", func() {
+				configuration := fixtures.NewValidBucketConfiguration(namespace, "test")
+				configuration.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{
+					{Context: pointer.String("sensitive"), Key: "vpc_id", Name: "vpc_id"},
+				}
+				warnings, err := v.ValidateCreate(ctx, configuration)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(warnings).To(BeEmpty())
+			})
+		})
+
 		Context("provider namespace selectors do match", func() {
 			BeforeEach(func() {
 				provider := fixtures.NewValidAWSProvider(name, fixtures.NewValidAWSProviderSecret(namespace, name))
@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package contexts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+)
+
+type namespaceValidator struct {
+	cc client.Client
+}
+
+// NewNamespaceValidator is validation handler for namespace contexts
+func NewNamespaceValidator(cc client.Client) admission.CustomValidator {
+	return &namespaceValidator{cc: cc}
+}
+
+// ValidateCreate is called when a new resource is created
+func (v *namespaceValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return admission.Warnings{}, validateVariables(obj.(*terraformv1alpha1.NamespaceContext).Spec.Variables)
+}
+
+// ValidateUpdate is called when a resource is being updated
+func (v *namespaceValidator) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	return admission.Warnings{}, validateVariables(newObj.(*terraformv1alpha1.NamespaceContext).Spec.Variables)
+}
+
+// ValidateDelete is called when a resource is being deleted
+func (v *namespaceValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	var warnings admission.Warnings
+	current := obj.(*terraformv1alpha1.NamespaceContext)
+
+	if current.GetAnnotations()[terraformv1alpha1.OrphanAnnotation] == "true" {
+		return warnings, nil
+	}
+
+	// @choice: a namespace context can only be consumed by configurations within the same
+	// namespace, and should not be deleted while being referenced
+	list := &terraformv1alpha1.ConfigurationList{}
+	if err := v.cc.List(ctx, list, client.InNamespace(current.Namespace)); err != nil {
+		return warnings, err
+	}
+
+	var inuse []string
+
+	for i := 0; i < len(list.Items); i++ {
+		for _, x := range list.Items[i].Spec.ValueFrom {
+			if ptr.Deref(x.Context, "") == current.Name {
+				inuse = append(inuse, list.Items[i].GetNamespacedName().String())
+			}
+		}
+	}
+
+	if len(inuse) > 0 {
+		return warnings, fmt.Errorf("resource in use by configuration(s): %v", strings.Join(inuse, ", "))
+	}
+
+	return warnings, nil
+}
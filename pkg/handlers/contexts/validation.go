@@ -62,11 +62,19 @@ func (v *validator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.O
 
 // validate is called to ensure the configuration is valid and incline with current policies
 func (v *validator) validate(_ context.Context, _, current *terraformv1alpha1.Context) error {
-	if len(current.Spec.Variables) == 0 {
-		return nil
+	if err := validateSources(current); err != nil {
+		return err
 	}
+	if err := validateSecrets(current); err != nil {
+		return err
+	}
+
+	return validateVariables(current.Spec.Variables)
+}
 
-	for name, variable := range current.Spec.Variables {
+// validateVariables is called to ensure the variables have both a description and value
+func validateVariables(variables map[string]runtime.RawExtension) error {
+	for name, variable := range variables {
 		if len(variable.Raw) == 0 {
 			return fmt.Errorf(`spec.variable["%s"] must have a value`, name)
 		}
@@ -88,6 +96,88 @@ func (v *validator) validate(_ context.Context, _, current *terraformv1alpha1.Co
 	return nil
 }
 
+// validateSources is called to ensure the dynamic sources are valid
+func validateSources(current *terraformv1alpha1.Context) error {
+	names := make(map[string]bool)
+
+	for i, source := range current.Spec.Sources {
+		switch {
+		case source.Name == "":
+			return fmt.Errorf("spec.sources[%d].name is required", i)
+		case names[source.Name]:
+			return fmt.Errorf("spec.sources[%d].name %q is duplicated", i, source.Name)
+		case source.Description == "":
+			return fmt.Errorf("spec.sources[%d].description is required", i)
+		case source.CountSourceTypes() == 0:
+			return fmt.Errorf("spec.sources[%d] must have one of configMap, secret, configuration or cluster", i)
+		case source.CountSourceTypes() > 1:
+			return fmt.Errorf("spec.sources[%d] can only have one of configMap, secret, configuration or cluster", i)
+		}
+		names[source.Name] = true
+
+		switch {
+		case source.ConfigMap != nil:
+			if err := validateKeyReference(fmt.Sprintf("spec.sources[%d].configMap", i), source.ConfigMap); err != nil {
+				return err
+			}
+		case source.Secret != nil:
+			if err := validateKeyReference(fmt.Sprintf("spec.sources[%d].secret", i), source.Secret); err != nil {
+				return err
+			}
+		case source.Configuration != nil:
+			switch {
+			case source.Configuration.Namespace == "":
+				return fmt.Errorf("spec.sources[%d].configuration.namespace is required", i)
+			case source.Configuration.Name == "":
+				return fmt.Errorf("spec.sources[%d].configuration.name is required", i)
+			case source.Configuration.Output == "":
+				return fmt.Errorf("spec.sources[%d].configuration.output is required", i)
+			}
+		case source.Cluster != "":
+			switch source.Cluster {
+			case terraformv1alpha1.ContextSourceClusterNodeRegions, terraformv1alpha1.ContextSourceClusterNodeZones:
+			default:
+				return fmt.Errorf("spec.sources[%d].cluster must be one of %s or %s", i,
+					terraformv1alpha1.ContextSourceClusterNodeRegions, terraformv1alpha1.ContextSourceClusterNodeZones)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateSecrets is called to ensure the sensitive variables are valid
+func validateSecrets(current *terraformv1alpha1.Context) error {
+	for name, secret := range current.Spec.Secrets {
+		switch {
+		case secret.Description == "":
+			return fmt.Errorf(`spec.secrets["%s"].description is required`, name)
+		case secret.SecretRef.Name == "":
+			return fmt.Errorf(`spec.secrets["%s"].secretRef.name is required`, name)
+		case secret.SecretRef.Key == "":
+			return fmt.Errorf(`spec.secrets["%s"].secretRef.key is required`, name)
+		case current.Spec.HasVariable(name):
+			return fmt.Errorf(`spec.secrets["%s"] cannot also be defined in spec.variables`, name)
+		}
+	}
+
+	return nil
+}
+
+// validateKeyReference is called to ensure the key reference is valid
+func validateKeyReference(path string, ref *terraformv1alpha1.ContextSourceKeyReference) error {
+	switch {
+	case ref.Namespace == "":
+		return fmt.Errorf("%s.namespace is required", path)
+	case ref.Name == "":
+		return fmt.Errorf("%s.name is required", path)
+	case ref.Key == "":
+		return fmt.Errorf("%s.key is required", path)
+	}
+
+	return nil
+}
+
 // ValidateDelete is called when a resource is being deleted
 func (v *validator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
 	var warnings admission.Warnings
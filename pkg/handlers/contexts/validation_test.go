@@ -164,6 +164,156 @@ var _ = Describe("Context Validation", func() {
 		})
 	})
 
+	When("creating a context with sources", func() {
+		Context("and the source has no type", func() {
+			BeforeEach(func() {
+				c.Spec.Sources = []terraformv1alpha1.ContextSource{
+					{Name: "zones", Description: "zones"},
+				}
+				warnings, err = v.ValidateCreate(context.Background(), c)
+			})
+
+			It("should return an error", func() {
+				Expect(err).ToNot(BeNil())
+				Expect(err.Error()).To(Equal("spec.sources[0] must have one of configMap, secret, configuration or cluster"))
+				Expect(warnings).To(BeEmpty())
+			})
+		})
+
+		Context("and the source has multiple types", func() {
+			BeforeEach(func() {
+				c.Spec.Sources = []terraformv1alpha1.ContextSource{
+					{
+						Name:        "zones",
+						Description: "zones",
+						Cluster:     terraformv1alpha1.ContextSourceClusterNodeZones,
+						ConfigMap:   &terraformv1alpha1.ContextSourceKeyReference{Namespace: "default", Name: "test", Key: "zones"},
+					},
+				}
+				warnings, err = v.ValidateCreate(context.Background(), c)
+			})
+
+			It("should return an error", func() {
+				Expect(err).ToNot(BeNil())
+				Expect(err.Error()).To(Equal("spec.sources[0] can only have one of configMap, secret, configuration or cluster"))
+			})
+		})
+
+		Context("and the source names are duplicated", func() {
+			BeforeEach(func() {
+				c.Spec.Sources = []terraformv1alpha1.ContextSource{
+					{Name: "zones", Description: "zones", Cluster: terraformv1alpha1.ContextSourceClusterNodeZones},
+					{Name: "zones", Description: "zones", Cluster: terraformv1alpha1.ContextSourceClusterNodeRegions},
+				}
+				warnings, err = v.ValidateCreate(context.Background(), c)
+			})
+
+			It("should return an error", func() {
+				Expect(err).ToNot(BeNil())
+				Expect(err.Error()).To(Equal(`spec.sources[1].name "zones" is duplicated`))
+			})
+		})
+
+		Context("and the secret reference is missing a key", func() {
+			BeforeEach(func() {
+				c.Spec.Sources = []terraformv1alpha1.ContextSource{
+					{
+						Name:        "vpc",
+						Description: "vpc",
+						Secret:      &terraformv1alpha1.ContextSourceKeyReference{Namespace: "default", Name: "test"},
+					},
+				}
+				warnings, err = v.ValidateCreate(context.Background(), c)
+			})
+
+			It("should return an error", func() {
+				Expect(err).ToNot(BeNil())
+				Expect(err.Error()).To(Equal("spec.sources[0].secret.key is required"))
+			})
+		})
+
+		Context("and the configuration reference is missing an output", func() {
+			BeforeEach(func() {
+				c.Spec.Sources = []terraformv1alpha1.ContextSource{
+					{
+						Name:          "vpc",
+						Description:   "vpc",
+						Configuration: &terraformv1alpha1.ContextSourceConfigurationReference{Namespace: "default", Name: "test"},
+					},
+				}
+				warnings, err = v.ValidateCreate(context.Background(), c)
+			})
+
+			It("should return an error", func() {
+				Expect(err).ToNot(BeNil())
+				Expect(err.Error()).To(Equal("spec.sources[0].configuration.output is required"))
+			})
+		})
+
+		Context("and the sources are valid", func() {
+			BeforeEach(func() {
+				c.Spec.Sources = []terraformv1alpha1.ContextSource{
+					{Name: "zones", Description: "zones", Cluster: terraformv1alpha1.ContextSourceClusterNodeZones},
+					{
+						Name:        "vpc",
+						Description: "vpc",
+						ConfigMap:   &terraformv1alpha1.ContextSourceKeyReference{Namespace: "default", Name: "test", Key: "vpc"},
+					},
+				}
+				warnings, err = v.ValidateCreate(context.Background(), c)
+			})
+
+			It("should not return an error", func() {
+				Expect(err).To(BeNil())
+				Expect(warnings).To(BeEmpty())
+			})
+		})
+	})
+
+	When("creating a context with sensitive variables", func() {
+		Context("and the secret reference is missing a key", func() {
+			BeforeEach(func() {
+				c.Spec.Secrets = map[string]terraformv1alpha1.ContextSecret{
+					"password": {Description: "password", SecretRef: terraformv1alpha1.ContextSecretKeyReference{Name: "test"}},
+				}
+				warnings, err = v.ValidateCreate(context.Background(), c)
+			})
+
+			It("should return an error", func() {
+				Expect(err).ToNot(BeNil())
+				Expect(err.Error()).To(Equal(`spec.secrets["password"].secretRef.key is required`))
+			})
+		})
+
+		Context("and the variable is also defined in the variables", func() {
+			BeforeEach(func() {
+				c.Spec.Secrets = map[string]terraformv1alpha1.ContextSecret{
+					"vpc_id": {Description: "vpc", SecretRef: terraformv1alpha1.ContextSecretKeyReference{Name: "test", Key: "vpc"}},
+				}
+				warnings, err = v.ValidateCreate(context.Background(), c)
+			})
+
+			It("should return an error", func() {
+				Expect(err).ToNot(BeNil())
+				Expect(err.Error()).To(Equal(`spec.secrets["vpc_id"] cannot also be defined in spec.variables`))
+			})
+		})
+
+		Context("and the sensitive variables are valid", func() {
+			BeforeEach(func() {
+				c.Spec.Secrets = map[string]terraformv1alpha1.ContextSecret{
+					"password": {Description: "password", SecretRef: terraformv1alpha1.ContextSecretKeyReference{Name: "test", Key: "password"}},
+				}
+				warnings, err = v.ValidateCreate(context.Background(), c)
+			})
+
+			It("should not return an error", func() {
+				Expect(err).To(BeNil())
+				Expect(warnings).To(BeEmpty())
+			})
+		})
+	})
+
 	When("deleting a context", func() {
 		BeforeEach(func() {
 			for i := 0; i < 2; i++ {
@@ -248,3 +398,67 @@ var _ = Describe("Context Validation", func() {
 		})
 	})
 })
+
+var _ = Describe("Namespace Context Validation", func() {
+	var c *terraformv1alpha1.NamespaceContext
+	var cc client.Client
+	var v admission.CustomValidator
+	var err error
+
+	BeforeEach(func() {
+		cc = fake.NewClientBuilder().WithScheme(schema.GetScheme()).WithRuntimeObjects(fixtures.NewNamespace("apps")).Build()
+		v = NewNamespaceValidator(cc)
+		c = terraformv1alpha1.NewNamespaceContext("apps", "default")
+		c.Spec.Variables = map[string]runtime.RawExtension{
+			"foo": {Raw: []byte(`{"description": "bar", "value": "baz"}`)},
+		}
+	})
+
+	When("creating a namespace context", func() {
+		Context("and the variables are valid", func() {
+			It("should not return an error", func() {
+				_, err = v.ValidateCreate(context.Background(), c)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
+		Context("and a variable is missing a value", func() {
+			It("should return an error", func() {
+				c.Spec.Variables["foo"] = runtime.RawExtension{Raw: []byte(`{"description": "bar"}`)}
+
+				_, err = v.ValidateCreate(context.Background(), c)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("spec.variables[\"foo\"].value is required"))
+			})
+		})
+	})
+
+	When("deleting a namespace context", func() {
+		BeforeEach(func() {
+			for _, namespace := range []string{"apps", "other"} {
+				cr := fixtures.NewValidBucketConfiguration(namespace, "test")
+				cr.Spec.ValueFrom = []terraformv1alpha1.ValueFromSource{
+					{Context: pointer.String(c.Name), Key: "foo", Name: "foo"},
+				}
+				Expect(cc.Create(context.Background(), cr)).To(Succeed())
+			}
+		})
+
+		Context("and it is referenced by a configuration in the namespace", func() {
+			It("should return an error", func() {
+				_, err = v.ValidateDelete(context.Background(), c)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("resource in use by configuration(s): apps/test"))
+			})
+		})
+
+		Context("and the orphan annotation is set", func() {
+			It("should not return an error", func() {
+				c.Annotations = map[string]string{terraformv1alpha1.OrphanAnnotation: "true"}
+
+				_, err = v.ValidateDelete(context.Background(), c)
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+	})
+})
@@ -31,6 +31,7 @@ import (
 
 	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
 	"github.com/appvia/terranetes-controller/pkg/utils"
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
 )
 
 type validator struct {
@@ -97,6 +98,12 @@ func (v *validator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.O
 	if err := validateModuleConstraint(o); err != nil {
 		return warnings, err
 	}
+	if err := validateHooks(o); err != nil {
+		return warnings, err
+	}
+	if err := validatePodTemplateOverrides(o); err != nil {
+		return warnings, err
+	}
 
 	return warnings, nil
 }
@@ -119,6 +126,51 @@ func validateDefaultVariables(policy *terraformv1alpha1.Policy) error {
 	return nil
 }
 
+// validateHooks ensures the hooks injected by the policy are valid
+func validateHooks(policy *terraformv1alpha1.Policy) error {
+	for i, x := range policy.Spec.Hooks {
+		if err := x.IsValid(fmt.Sprintf("spec.hooks[%d]", i)); err != nil {
+			return err
+		}
+		if x.Selector.Namespace != nil {
+			if _, err := metav1.LabelSelectorAsSelector(x.Selector.Namespace); err != nil {
+				return fmt.Errorf("spec.hooks[%d].selector.namespace is invalid, %w", i, err)
+			}
+		}
+		for j, expression := range x.Selector.Modules {
+			if _, err := regexp.Compile(expression); err != nil {
+				return fmt.Errorf("spec.hooks[%d].selector.modules[%d] is not a valid regex, %w", i, j, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validatePodTemplateOverrides ensures the pod template overrides are valid
+func validatePodTemplateOverrides(policy *terraformv1alpha1.Policy) error {
+	for i, x := range policy.Spec.PodTemplateOverrides {
+		if len(x.Template.Raw) == 0 {
+			return fmt.Errorf("spec.podTemplateOverrides[%d].template cannot be empty", i)
+		}
+		if err := jobs.ValidatePodTemplateOverrides(x.Template.Raw); err != nil {
+			return fmt.Errorf("spec.podTemplateOverrides[%d].template is invalid, %w", i, err)
+		}
+		if x.Selector.Namespace != nil {
+			if _, err := metav1.LabelSelectorAsSelector(x.Selector.Namespace); err != nil {
+				return fmt.Errorf("spec.podTemplateOverrides[%d].selector.namespace is invalid, %w", i, err)
+			}
+		}
+		for j, expression := range x.Selector.Modules {
+			if _, err := regexp.Compile(expression); err != nil {
+				return fmt.Errorf("spec.podTemplateOverrides[%d].selector.modules[%d] is not a valid regex, %w", i, j, err)
+			}
+		}
+	}
+
+	return nil
+}
+
 // validateModuleConstraint ensures the constraints are valid
 func validateModuleConstraint(policy *terraformv1alpha1.Policy) error {
 	switch {
@@ -26,6 +26,7 @@ import (
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -115,6 +116,124 @@ var _ = Describe("Module Constraints", func() {
 	})
 })
 
+var _ = Describe("Policy Hooks", func() {
+	var err error
+	var v *validator
+	var policy *terraformv1alpha1.Policy
+	var warnings admission.Warnings
+
+	BeforeEach(func() {
+		v = &validator{cc: fake.NewClientBuilder().WithScheme(schema.GetScheme()).Build()}
+		policy = fixtures.NewPolicy("hooks")
+	})
+
+	When("creating a policy with hooks", func() {
+		cases := []struct {
+			Hooks  []terraformv1alpha1.HookDefaults
+			Expect string
+		}{
+			{
+				Hooks:  []terraformv1alpha1.HookDefaults{{}},
+				Expect: "spec.hooks[0].hooks is required",
+			},
+			{
+				Hooks: []terraformv1alpha1.HookDefaults{
+					{Hooks: []terraformv1alpha1.Hook{{Name: "Bad_Name", When: terraformv1alpha1.HookPrePlan, Image: "alpine:3", Commands: []string{"true"}}}},
+				},
+				Expect: "spec.hooks[0].hooks[0].name must be a valid dns label",
+			},
+			{
+				Hooks: []terraformv1alpha1.HookDefaults{
+					{
+						Selector: terraformv1alpha1.DefaultVariablesSelector{Modules: []string{"^^.[]$$"}},
+						Hooks:    []terraformv1alpha1.Hook{{Name: "lint", When: terraformv1alpha1.HookPrePlan, Image: "alpine:3", Commands: []string{"true"}}},
+					},
+				},
+				Expect: "spec.hooks[0].selector.modules[0] is not a valid regex",
+			},
+		}
+
+		It("should error on invalid hooks", func() {
+			for _, c := range cases {
+				policy.Spec.Hooks = c.Hooks
+
+				warnings, err = v.ValidateCreate(context.TODO(), policy)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(c.Expect))
+				Expect(warnings).To(BeEmpty())
+			}
+		})
+
+		It("should not error on valid hooks", func() {
+			policy.Spec.Hooks = []terraformv1alpha1.HookDefaults{
+				{Hooks: []terraformv1alpha1.Hook{{Name: "lint", When: terraformv1alpha1.HookPrePlan, Image: "alpine:3", Commands: []string{"true"}}}},
+			}
+
+			_, err = v.ValidateCreate(context.TODO(), policy)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Policy Pod Template Overrides", func() {
+	var err error
+	var v *validator
+	var policy *terraformv1alpha1.Policy
+	var warnings admission.Warnings
+
+	BeforeEach(func() {
+		v = &validator{cc: fake.NewClientBuilder().WithScheme(schema.GetScheme()).Build()}
+		policy = fixtures.NewPolicy("overrides")
+	})
+
+	When("creating a policy with pod template overrides", func() {
+		cases := []struct {
+			Overrides []terraformv1alpha1.PodTemplateDefaults
+			Expect    string
+		}{
+			{
+				Overrides: []terraformv1alpha1.PodTemplateDefaults{{}},
+				Expect:    "spec.podTemplateOverrides[0].template cannot be empty",
+			},
+			{
+				Overrides: []terraformv1alpha1.PodTemplateDefaults{
+					{Template: runtime.RawExtension{Raw: []byte(`{"spec":{"nodeSelectors":{"pool":"terraform"}}}`)}},
+				},
+				Expect: "spec.podTemplateOverrides[0].template is invalid",
+			},
+			{
+				Overrides: []terraformv1alpha1.PodTemplateDefaults{
+					{
+						Selector: terraformv1alpha1.DefaultVariablesSelector{Modules: []string{"^^.[]$$"}},
+						Template: runtime.RawExtension{Raw: []byte(`{"spec":{"nodeSelector":{"pool":"terraform"}}}`)},
+					},
+				},
+				Expect: "spec.podTemplateOverrides[0].selector.modules[0] is not a valid regex",
+			},
+		}
+
+		It("should error on invalid overrides", func() {
+			for _, c := range cases {
+				policy.Spec.PodTemplateOverrides = c.Overrides
+
+				warnings, err = v.ValidateCreate(context.TODO(), policy)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(c.Expect))
+				Expect(warnings).To(BeEmpty())
+			}
+		})
+
+		It("should not error on valid overrides", func() {
+			policy.Spec.PodTemplateOverrides = []terraformv1alpha1.PodTemplateDefaults{
+				{Template: runtime.RawExtension{Raw: []byte(`{"spec":{"nodeSelector":{"pool":"terraform"}}}`)}},
+			}
+
+			_, err = v.ValidateCreate(context.TODO(), policy)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+})
+
 var _ = Describe("Policy Validation", func() {
 	var err error
 	var v *validator
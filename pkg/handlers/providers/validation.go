@@ -21,12 +21,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
 )
 
 type validator struct {
@@ -81,6 +84,19 @@ func (v *validator) validate(ctx context.Context, provider *terraformv1alpha1.Pr
 	default:
 		return fmt.Errorf("spec.source: %s is not supported", provider.Spec.Source)
 	}
+	if provider.Spec.Engine != "" && !terraformv1alpha1.IsValidEngine(provider.Spec.Engine) {
+		return fmt.Errorf("spec.engine: %s is not supported", provider.Spec.Engine)
+	}
+	if provider.HasAgent() {
+		if errs := validation.IsDNS1123Label(provider.Spec.Agent); len(errs) > 0 {
+			return fmt.Errorf("spec.agent: %s is not a valid agent name, %s", provider.Spec.Agent, strings.Join(errs, ", "))
+		}
+	}
+	if provider.Spec.PodTemplateOverrides != nil && len(provider.Spec.PodTemplateOverrides.Raw) > 0 {
+		if err := jobs.ValidatePodTemplateOverrides(provider.Spec.PodTemplateOverrides.Raw); err != nil {
+			return fmt.Errorf("spec.podTemplateOverrides is invalid, %w", err)
+		}
+	}
 
 	// @step: are we trying to set provider as a default provider
 	annotations := provider.GetAnnotations()
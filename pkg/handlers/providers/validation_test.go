@@ -23,6 +23,7 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -79,6 +80,69 @@ var _ = Describe("Provider Validation", func() {
 		})
 	})
 
+	When("creating a provider with an engine", func() {
+		It("should throw error when the engine is unknown", func() {
+			provider := fixtures.NewValidAWSProvider(name, fixtures.NewValidAWSProviderSecret(namespace, name))
+			provider.Spec.Engine = "unknown"
+
+			warnings, err := v.ValidateCreate(ctx, provider)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("spec.engine: unknown is not supported"))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("should not error when the engine is supported", func() {
+			provider := fixtures.NewValidAWSProvider(name, fixtures.NewValidAWSProviderSecret(namespace, name))
+			provider.Spec.Engine = terraformv1alpha1.EngineOpenTofu
+
+			warnings, err := v.ValidateCreate(ctx, provider)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+	})
+
+	When("creating a provider with an agent", func() {
+		It("should throw error when the agent name is invalid", func() {
+			provider := fixtures.NewValidAWSProvider(name, fixtures.NewValidAWSProviderSecret(namespace, name))
+			provider.Spec.Agent = "Not_Valid"
+
+			warnings, err := v.ValidateCreate(ctx, provider)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.agent: Not_Valid is not a valid agent name"))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("should not error when the agent name is valid", func() {
+			provider := fixtures.NewValidAWSProvider(name, fixtures.NewValidAWSProviderSecret(namespace, name))
+			provider.Spec.Agent = "eu-west-1"
+
+			warnings, err := v.ValidateCreate(ctx, provider)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+	})
+
+	When("creating a provider with pod template overrides", func() {
+		It("should throw error when the overrides contain unknown fields", func() {
+			provider := fixtures.NewValidAWSProvider(name, fixtures.NewValidAWSProviderSecret(namespace, name))
+			provider.Spec.PodTemplateOverrides = &runtime.RawExtension{Raw: []byte(`{"spec":{"nodeSelectors":{"pool":"terraform"}}}`)}
+
+			warnings, err := v.ValidateCreate(ctx, provider)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("spec.podTemplateOverrides is invalid"))
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("should not error when the overrides are valid", func() {
+			provider := fixtures.NewValidAWSProvider(name, fixtures.NewValidAWSProviderSecret(namespace, name))
+			provider.Spec.PodTemplateOverrides = &runtime.RawExtension{Raw: []byte(`{"spec":{"nodeSelector":{"pool":"terraform"}}}`)}
+
+			warnings, err := v.ValidateCreate(ctx, provider)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+	})
+
 	When("creating a provider with a secret", func() {
 		It("should throw error when no secret reference", func() {
 			policy := fixtures.NewValidAWSProvider(name, fixtures.NewValidAWSProviderSecret(namespace, name))
@@ -94,6 +94,11 @@ func (v *validator) validate(ctx context.Context, before, revision *terraformv1a
 		return warnings, fmt.Errorf("spec.plan.version is not a valid semver")
 	}
 
+	// @step: check the hooks are valid
+	if err := terraformv1alpha1.HookList(revision.Spec.Configuration.Hooks).IsValid("spec.configuration.hooks"); err != nil {
+		return warnings, err
+	}
+
 	// @step: check the dependencies
 	for i, x := range revision.Spec.Dependencies {
 		switch {
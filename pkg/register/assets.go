@@ -4,6 +4,7 @@
 // charts/terranetes-controller/crds/terraform.appvia.io_cloudresources.yaml
 // charts/terranetes-controller/crds/terraform.appvia.io_configurations.yaml
 // charts/terranetes-controller/crds/terraform.appvia.io_contexts.yaml
+// charts/terranetes-controller/crds/terraform.appvia.io_namespacecontexts.yaml
 // charts/terranetes-controller/crds/terraform.appvia.io_plans.yaml
 // charts/terranetes-controller/crds/terraform.appvia.io_policies.yaml
 // charts/terranetes-controller/crds/terraform.appvia.io_providers.yaml
@@ -155,6 +156,14 @@ spec:
                     for any drift between the expected and current state. If any drift is detected the
                     status is changed and a kubernetes event raised.
                   type: boolean
+                engine:
+                  description: |-
+                    Engine is the engine used to execute the configuration, i.e. terraform or opentofu. When
+                    not defined the engine of the revision is used
+                  enum:
+                    - terraform
+                    - opentofu
+                  type: string
                 plan:
                   description: |-
                     Plan is the reference to the plan which this cloud resource is associated with. This
@@ -423,6 +432,9 @@ spec:
                     driftTimestamp:
                       description: DriftTimestamp is the timestamp of the last drift detection
                       type: string
+                    engine:
+                      description: Engine is the engine which last applied the configuration and wrote the terraform state
+                      type: string
                     lastReconcile:
                       description: LastReconcile describes the generation and time of the last reconciliation
                       properties:
@@ -449,6 +461,26 @@ spec:
                           format: date-time
                           type: string
                       type: object
+                    preview:
+                      description: Preview is the outcome of the last plan of the configuration under another version of the engine
+                      properties:
+                        changes:
+                          description: Changes indicates the plan under the version would change the managed resources
+                          type: boolean
+                        generation:
+                          description: Generation is the generation of the configuration which was planned
+                          format: int64
+                          type: integer
+                        message:
+                          description: Message provides a human readable description of the outcome
+                          type: string
+                        status:
+                          description: Status is the status of the preview, i.e. InProgress, Complete or Failed
+                          type: string
+                        version:
+                          description: Version is the version of the engine the configuration was planned under
+                          type: string
+                      type: object
                     resourceStatus:
                       description: |-
                         ResourceStatus indicates the status of the resources and if the resources are insync with the
@@ -459,6 +491,86 @@ spec:
                         Resources is the number of managed cloud resources which are currently under management.
                         This field is taken from the terraform state itself.
                       type: integer
+                    retry:
+                      description: Retry tracks the automatic retries of the configuration for the current generation
+                      properties:
+                        attempts:
+                          description: Attempts is the number of automatic retries made for this generation
+                          type: integer
+                        class:
+                          description: Class is the error class which caused the last retry
+                          type: string
+                        generation:
+                          description: Generation is the generation of the configuration the attempts relate to
+                          format: int64
+                          type: integer
+                        job:
+                          description: Job is the name of the last failed job which was retried
+                          type: string
+                        nextAttempt:
+                          description: NextAttempt is the time the next retry is scheduled for
+                          format: date-time
+                          type: string
+                      type: object
+                    stateLock:
+                      description: StateLock is the terraform state lock which prevented the last run from completing
+                      properties:
+                        created:
+                          description: Created is the time the lock was acquired
+                          format: date-time
+                          type: string
+                        id:
+                          description: ID is the unique identifier of the lock
+                          type: string
+                        operation:
+                          description: Operation is the terraform operation which acquired the lock
+                          type: string
+                        path:
+                          description: Path is the location of the state which is locked
+                          type: string
+                        who:
+                          description: Who is the holder of the lock
+                          type: string
+                      type: object
+                    steps:
+                      description: Steps is the outcome of the steps within the last job run for this configuration
+                      properties:
+                        failedStep:
+                          description: FailedStep is the name of the step which failed the job, if any
+                          type: string
+                        stage:
+                          description: Stage is the stage of the job i.e. plan, apply or destroy
+                          type: string
+                        steps:
+                          description: Steps is the outcome of the steps, in the order they finished
+                          items:
+                            description: StepStatus provides the outcome of a step within a job
+                            properties:
+                              duration:
+                                description: Duration is the time taken to run the step
+                                type: string
+                              exitCode:
+                                description: ExitCode is the exit code of the step
+                                type: integer
+                              ignored:
+                                description: |-
+                                  Ignored indicates the step failed but was permitted to continue, i.e. a hook with
+                                  continueOnError enabled
+                                type: boolean
+                              message:
+                                description: Message is the tail of the error output when the step failed
+                                type: string
+                              name:
+                                description: Name is the name of the step
+                                type: string
+                              retries:
+                                description: Retries is the number of times the step was retried
+                                type: integer
+                            required:
+                              - name
+                            type: object
+                          type: array
+                      type: object
                     terraformVersion:
                       description: |-
                         TerraformVersion is the version of terraform which was last used to run this
@@ -634,12 +746,82 @@ spec:
                     for any drift between the expected and current state. If any drift is detected the
                     status is changed and a kubernetes event raised.
                   type: boolean
+                engine:
+                  description: |-
+                    Engine is the engine used to execute the configuration, i.e. terraform or opentofu. When not
+                    defined the engine of the provider is used, else the default engine of the controller. Note,
+                    changing the engine of an existing configuration is only permitted when the state written
+                    by the previous engine can be read by the new one
+                  enum:
+                    - terraform
+                    - opentofu
+                  type: string
+                hooks:
+                  description: |-
+                    Hooks is a collection of custom steps executed within the terraform jobs, before or
+                    after the plan, apply and destroy stages
+                  items:
+                    description: |-
+                      Hook defines a custom step executed within the terraform job of a stage, such as linting the
+                      module before a plan or running a smoke test after an apply
+                    properties:
+                      commands:
+                        description: |-
+                          Commands is a collection of shell commands executed in order by the hook. The module
+                          source is available in the working directory
+                        items:
+                          type: string
+                        minItems: 1
+                        type: array
+                      continueOnError:
+                        description: ContinueOnError indicates a failure of the hook should not fail the stage
+                        type: boolean
+                      image:
+                        description: Image is the container image the hook is executed in, the image must provide a shell
+                        type: string
+                      name:
+                        description: Name is the name of the hook, which must be unique within the configuration
+                        maxLength: 40
+                        type: string
+                      retries:
+                        description: Retries is the number of times the commands are retried on failure
+                        minimum: 0
+                        type: integer
+                      timeout:
+                        description: Timeout is the maximum duration of each command, after which the command is failed
+                        type: string
+                      when:
+                        description: |-
+                          When is the point the hook is executed i.e. pre-plan, post-plan, pre-apply, post-apply,
+                          pre-destroy or post-destroy. Pre hooks are executed in order, while post hooks are executed
+                          concurrently and only when the stage was successful
+                        enum:
+                          - pre-plan
+                          - post-plan
+                          - pre-apply
+                          - post-apply
+                          - pre-destroy
+                          - post-destroy
+                        type: string
+                    required:
+                      - commands
+                      - image
+                      - name
+                      - when
+                    type: object
+                  type: array
                 module:
                   description: |-
                     Module is the URL to the source of the terraform module. The format of the URL is
                     a direct implementation of terraform's module reference. Please see the following
                     repository for more details https://github.com/hashicorp/go-getter
                   type: string
+                moduleChecksum:
+                  description: |-
+                    ModuleChecksum is the expected checksum of the module source, used to pin the module to
+                    known contents. This is either a checksum of the module contents (h1:<hash>), as logged
+                    by the controller when retrieving the source, or of a downloaded archive (i.e. sha256:<hex>)
+                  type: string
                 plan:
                   description: |-
                     Plan is an optional reference to a plan this configuration is associated with. If
@@ -671,85 +853,638 @@ spec:
                   required:
                     - name
                   type: object
+                retry:
+                  description: |-
+                    Retry defines a policy for automatically retrying the configuration when a terraform
+                    job fails due to a transient error, such as api throttling or state lock contention
+                  properties:
+                    backoff:
+                      description: |-
+                        Backoff is the initial delay before retrying, doubled on every subsequent attempt. Defaults
+                        to 30 seconds
+                      type: string
+                    classes:
+                      description: |-
+                        Classes is a collection of error classes (e.g. throttling, state-lock) which are permitted
+                        to be retried. When empty, any error classified by a detector as retryable is retried.
+                      items:
+                        type: string
+                      type: array
+                    maxAttempts:
+                      description: |-
+                        MaxAttempts is the maximum number of automatic retries for a given generation of the
+                        configuration
+                      minimum: 1
+                      type: integer
+                    maxBackoff:
+                      description: MaxBackoff is the upper limit on the delay between retries. Defaults to 10 minutes
+                      type: string
+                  required:
+                    - maxAttempts
+                  type: object
                 terraformVersion:
                   description: |-
                     TerraformVersion provides the ability to override the default terraform version. Before
                     changing this field its best to consult with platform administrator. As the
                     value of this field is used to change the tag of the terraform container image.
+                    Note the version is the version of the engine in use, i.e. the OpenTofu version when
+                    the engine is opentofu. The version can also be a constraint (i.e. ~> 1.6, >= 1.5, < 1.7)
+                    which is resolved to the latest matching version from those made available by the
+                    platform administrator
                   type: string
                 tfVars:
                   description: |-
-                    TFVars provides an initial set of variables to pass to the Terraform module. If both this and
-                    Variables are set, values in Variables will override values with the same key in TFVars.
-                  type: string
-                valueFrom:
+                    TFVars provides an initial set of variables to pass to the Terraform module. If both this and
+                    Variables are set, values in Variables will override values with the same key in TFVars.
+                  type: string
+                valueFrom:
+                  description: |-
+                    ValueFromSource is a collection of value from sources, where the source of the value
+                    is taken from a secret
+                  items:
+                    description: ValueFromSource defines a value which is taken from a secret
+                    properties:
+                      context:
+                        description: |-
+                          Context is the context is the name of the terraform context where the
+                          value should be retrieved from
+                        type: string
+                      key:
+                        description: Key is the key in the secret which we should used for the value
+                        type: string
+                      name:
+                        description: |-
+                          Name is the name which we use when injecting the value into the terraform code
+                          i.e. the secret may contain data.DB_HOST but you call this database_hostname. Note,
+                          for backwards compatiability if no name is provided, we using the key at the name
+                        type: string
+                      optional:
+                        description: |-
+                          Optional indicates the secret can be optional, i.e if the secret does not exist, or the key is
+                          not contained in the secret, we ignore the error
+                        type: boolean
+                      secret:
+                        description: Secret is the name of the secret in the configuration namespace
+                        type: string
+                    required:
+                      - key
+                    type: object
+                  type: array
+                variables:
+                  description: |-
+                    Variables provides the inputs for the terraform module itself. These are passed to the
+                    terraform executor and used to execute the plan, apply and destroy phases.
+                  type: object
+                  x-kubernetes-preserve-unknown-fields: true
+                writeConnectionSecretToRef:
+                  description: |-
+                    WriteConnectionSecretToRef is the name for a secret. On execution of the terraform module
+                    any module outputs are written to this secret. The outputs are automatically uppercased
+                    and ready to be consumed as environment variables.
+                    WriteConnectionSecretRef is the secret where the terraform outputs will be written.
+                  properties:
+                    keys:
+                      description: |-
+                        Keys is a collection of name used to filter the terraform output. By default all keys from the
+                        output of the terraform state are written to the connection secret. Here we can define exactly
+                        which keys we want from that output.
+                      items:
+                        type: string
+                      type: array
+                    name:
+                      description: |-
+                        Name is the of the secret where you want to the terraform output to be written. The terraform outputs
+                        will be written to the secret as a key value pair. All are uppercased can read to be consumed by the
+                        workload.
+                      type: string
+                  required:
+                    - name
+                  type: object
+              required:
+                - module
+              type: object
+            status:
+              description: ConfigurationStatus defines the observed state of a terraform
+              properties:
+                conditions:
+                  description: Conditions represents the observations of the resource's current state.
+                  items:
+                    description: Condition is the current observed condition of some aspect of a resource
+                    properties:
+                      detail:
+                        description: |-
+                          Detail is any additional human-readable detail to understand this condition, for example,
+                          the full underlying error which caused an issue
+                        type: string
+                      lastTransitionTime:
+                        description: |-
+                          LastTransitionTime is the last time the condition transitioned from one status to another.
+                          This should be when the underlying condition changed.  If that is not known, then using the time when the API field changed is acceptable.
+                        format: date-time
+                        type: string
+                      message:
+                        description: |-
+                          Message is a human readable message indicating details about the transition.
+                          This may be an empty string.
+                        maxLength: 32768
+                        type: string
+                      name:
+                        description: Name is a human-readable name for this condition.
+                        minLength: 1
+                        type: string
+                      observedGeneration:
+                        description: |-
+                          ObservedGeneration represents the .metadata.generation that the condition was set based upon.
+                          For instance, if .metadata.generation is currently 12, but the .status.conditions[x].observedGeneration is 9, the condition is out of date
+                          with respect to the current state of the instance.
+                        format: int64
+                        minimum: 0
+                        type: integer
+                      reason:
+                        description: |-
+                          Reason contains a programmatic identifier indicating the reason for the condition's last transition.
+                          Producers of specific condition types may define expected values and meanings for this field,
+                          and whether the values are considered a guaranteed API.
+                          The value should be a CamelCase string.
+                          This field may not be empty.
+                        maxLength: 1024
+                        minLength: 1
+                        pattern: ^[A-Za-z]([A-Za-z0-9_,:]*[A-Za-z0-9_])?$
+                        type: string
+                      status:
+                        description: Status of the condition, one of True, False, Unknown.
+                        enum:
+                          - "True"
+                          - "False"
+                          - Unknown
+                        type: string
+                      type:
+                        description: Type of condition in CamelCase or in foo.example.com/CamelCase.
+                        maxLength: 316
+                        pattern: ^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*/)?(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])$
+                        type: string
+                    required:
+                      - lastTransitionTime
+                      - name
+                      - reason
+                      - status
+                      - type
+                    type: object
+                  type: array
+                  x-kubernetes-list-map-keys:
+                    - type
+                  x-kubernetes-list-type: map
+                costs:
+                  description: |-
+                    Costs is the predicted costs of this configuration. Note this field is only populated
+                    when the integration has been configured by the administrator.
+                  properties:
+                    enabled:
+                      description: |-
+                        Enabled indicates if the cost integration was enabled when this configuration was last
+                        executed.
+                      type: boolean
+                    hourly:
+                      description: Hourly is the hourly estimated cost of the configuration
+                      type: string
+                    monthly:
+                      description: Monthly is the monthly estimated cost of the configuration
+                      type: string
+                  type: object
+                driftTimestamp:
+                  description: DriftTimestamp is the timestamp of the last drift detection
+                  type: string
+                engine:
+                  description: Engine is the engine which last applied the configuration and wrote the terraform state
+                  type: string
+                lastReconcile:
+                  description: LastReconcile describes the generation and time of the last reconciliation
+                  properties:
+                    generation:
+                      description: Generation is the generation reconciled on the last reconciliation
+                      format: int64
+                      type: integer
+                    time:
+                      description: Time is the last time the resource was reconciled
+                      format: date-time
+                      type: string
+                  type: object
+                lastSuccess:
+                  description: |-
+                    LastSuccess descibes the generation and time of the last reconciliation which resulted in
+                    a Success status
+                  properties:
+                    generation:
+                      description: Generation is the generation reconciled on the last reconciliation
+                      format: int64
+                      type: integer
+                    time:
+                      description: Time is the last time the resource was reconciled
+                      format: date-time
+                      type: string
+                  type: object
+                preview:
+                  description: Preview is the outcome of the last plan of the configuration under another version of the engine
+                  properties:
+                    changes:
+                      description: Changes indicates the plan under the version would change the managed resources
+                      type: boolean
+                    generation:
+                      description: Generation is the generation of the configuration which was planned
+                      format: int64
+                      type: integer
+                    message:
+                      description: Message provides a human readable description of the outcome
+                      type: string
+                    status:
+                      description: Status is the status of the preview, i.e. InProgress, Complete or Failed
+                      type: string
+                    version:
+                      description: Version is the version of the engine the configuration was planned under
+                      type: string
+                  type: object
+                resourceStatus:
+                  description: |-
+                    ResourceStatus indicates the status of the resources and if the resources are insync with the
+                    configuration
+                  type: string
+                resources:
+                  description: |-
+                    Resources is the number of managed cloud resources which are currently under management.
+                    This field is taken from the terraform state itself.
+                  type: integer
+                retry:
+                  description: Retry tracks the automatic retries of the configuration for the current generation
+                  properties:
+                    attempts:
+                      description: Attempts is the number of automatic retries made for this generation
+                      type: integer
+                    class:
+                      description: Class is the error class which caused the last retry
+                      type: string
+                    generation:
+                      description: Generation is the generation of the configuration the attempts relate to
+                      format: int64
+                      type: integer
+                    job:
+                      description: Job is the name of the last failed job which was retried
+                      type: string
+                    nextAttempt:
+                      description: NextAttempt is the time the next retry is scheduled for
+                      format: date-time
+                      type: string
+                  type: object
+                stateLock:
+                  description: StateLock is the terraform state lock which prevented the last run from completing
+                  properties:
+                    created:
+                      description: Created is the time the lock was acquired
+                      format: date-time
+                      type: string
+                    id:
+                      description: ID is the unique identifier of the lock
+                      type: string
+                    operation:
+                      description: Operation is the terraform operation which acquired the lock
+                      type: string
+                    path:
+                      description: Path is the location of the state which is locked
+                      type: string
+                    who:
+                      description: Who is the holder of the lock
+                      type: string
+                  type: object
+                steps:
+                  description: Steps is the outcome of the steps within the last job run for this configuration
+                  properties:
+                    failedStep:
+                      description: FailedStep is the name of the step which failed the job, if any
+                      type: string
+                    stage:
+                      description: Stage is the stage of the job i.e. plan, apply or destroy
+                      type: string
+                    steps:
+                      description: Steps is the outcome of the steps, in the order they finished
+                      items:
+                        description: StepStatus provides the outcome of a step within a job
+                        properties:
+                          duration:
+                            description: Duration is the time taken to run the step
+                            type: string
+                          exitCode:
+                            description: ExitCode is the exit code of the step
+                            type: integer
+                          ignored:
+                            description: |-
+                              Ignored indicates the step failed but was permitted to continue, i.e. a hook with
+                              continueOnError enabled
+                            type: boolean
+                          message:
+                            description: Message is the tail of the error output when the step failed
+                            type: string
+                          name:
+                            description: Name is the name of the step
+                            type: string
+                          retries:
+                            description: Retries is the number of times the step was retried
+                            type: integer
+                        required:
+                          - name
+                        type: object
+                      type: array
+                  type: object
+                terraformVersion:
+                  description: |-
+                    TerraformVersion is the version of terraform which was last used to run this
+                    configuration
+                  type: string
+              type: object
+          type: object
+      served: true
+      storage: true
+      subresources:
+        status: {}
+  preserveUnknownFields: false
+`)
+
+func chartsTerranetesControllerCrdsTerraformAppviaIo_configurationsYamlBytes() ([]byte, error) {
+	return _chartsTerranetesControllerCrdsTerraformAppviaIo_configurationsYaml, nil
+}
+
+func chartsTerranetesControllerCrdsTerraformAppviaIo_configurationsYaml() (*asset, error) {
+	bytes, err := chartsTerranetesControllerCrdsTerraformAppviaIo_configurationsYamlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "charts/terranetes-controller/crds/terraform.appvia.io_configurations.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _chartsTerranetesControllerCrdsTerraformAppviaIo_contextsYaml = []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  annotations:
+    controller-gen.kubebuilder.io/version: v0.17.3
+  name: contexts.terraform.appvia.io
+spec:
+  group: terraform.appvia.io
+  names:
+    categories:
+      - terraform
+    kind: Context
+    listKind: ContextList
+    plural: contexts
+    singular: context
+  scope: Cluster
+  versions:
+    - additionalPrinterColumns:
+        - jsonPath: .metadata.creationTimestamp
+          name: Age
+          type: date
+      name: v1alpha1
+      schema:
+        openAPIV3Schema:
+          description: Context is the schema for the context type
+          properties:
+            apiVersion:
+              description: |-
+                APIVersion defines the versioned schema of this representation of an object.
+                Servers should convert recognized schemas to the latest internal value, and
+                may reject unrecognized values.
+                More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources
+              type: string
+            kind:
+              description: |-
+                Kind is a string value representing the REST resource this object represents.
+                Servers may infer this from the endpoint the client submits requests to.
+                Cannot be updated.
+                In CamelCase.
+                More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds
+              type: string
+            metadata:
+              type: object
+            spec:
+              description: ContextSpec defines the desired state for a context
+              properties:
+                secrets:
+                  additionalProperties:
+                    description: ContextSecret defines a sensitive variable whose value is held in a secret
+                    properties:
+                      description:
+                        description: Description is a description for the variable
+                        type: string
+                      secretRef:
+                        description: SecretRef is a reference to the key within a secret in the controller namespace
+                        properties:
+                          key:
+                            description: Key is the key within the secret
+                            type: string
+                          name:
+                            description: Name is the name of the secret
+                            type: string
+                        required:
+                          - key
+                          - name
+                        type: object
+                      selector:
+                        description: |-
+                          Selector provides the ability to filter who can consume the variable. If empty, all
+                          configurations in the cluster are permitted to use it. Otherwise you can specify a
+                          selector which can use namespace and resource labels
+                        properties:
+                          namespace:
+                            description: |-
+                              Namespace is used to filter a configuration based on the namespace labels of
+                              where it exists
+                            properties:
+                              matchExpressions:
+                                description: matchExpressions is a list of label selector requirements. The requirements are ANDed.
+                                items:
+                                  description: |-
+                                    A label selector requirement is a selector that contains values, a key, and an operator that
+                                    relates the key and values.
+                                  properties:
+                                    key:
+                                      description: key is the label key that the selector applies to.
+                                      type: string
+                                    operator:
+                                      description: |-
+                                        operator represents a key's relationship to a set of values.
+                                        Valid operators are In, NotIn, Exists and DoesNotExist.
+                                      type: string
+                                    values:
+                                      description: |-
+                                        values is an array of string values. If the operator is In or NotIn,
+                                        the values array must be non-empty. If the operator is Exists or DoesNotExist,
+                                        the values array must be empty. This array is replaced during a strategic
+                                        merge patch.
+                                      items:
+                                        type: string
+                                      type: array
+                                      x-kubernetes-list-type: atomic
+                                  required:
+                                    - key
+                                    - operator
+                                  type: object
+                                type: array
+                                x-kubernetes-list-type: atomic
+                              matchLabels:
+                                additionalProperties:
+                                  type: string
+                                description: |-
+                                  matchLabels is a map of {key,value} pairs. A single {key,value} in the matchLabels
+                                  map is equivalent to an element of matchExpressions, whose key field is "key", the
+                                  operator is "In", and the values array contains only "value". The requirements are ANDed.
+                                type: object
+                            type: object
+                            x-kubernetes-map-type: atomic
+                          resource:
+                            description: Resource provides the ability to filter a configuration based on it's labels
+                            properties:
+                              matchExpressions:
+                                description: matchExpressions is a list of label selector requirements. The requirements are ANDed.
+                                items:
+                                  description: |-
+                                    A label selector requirement is a selector that contains values, a key, and an operator that
+                                    relates the key and values.
+                                  properties:
+                                    key:
+                                      description: key is the label key that the selector applies to.
+                                      type: string
+                                    operator:
+                                      description: |-
+                                        operator represents a key's relationship to a set of values.
+                                        Valid operators are In, NotIn, Exists and DoesNotExist.
+                                      type: string
+                                    values:
+                                      description: |-
+                                        values is an array of string values. If the operator is In or NotIn,
+                                        the values array must be non-empty. If the operator is Exists or DoesNotExist,
+                                        the values array must be empty. This array is replaced during a strategic
+                                        merge patch.
+                                      items:
+                                        type: string
+                                      type: array
+                                      x-kubernetes-list-type: atomic
+                                  required:
+                                    - key
+                                    - operator
+                                  type: object
+                                type: array
+                                x-kubernetes-list-type: atomic
+                              matchLabels:
+                                additionalProperties:
+                                  type: string
+                                description: |-
+                                  matchLabels is a map of {key,value} pairs. A single {key,value} in the matchLabels
+                                  map is equivalent to an element of matchExpressions, whose key field is "key", the
+                                  operator is "In", and the values array contains only "value". The requirements are ANDed.
+                                type: object
+                            type: object
+                            x-kubernetes-map-type: atomic
+                        type: object
+                    required:
+                      - description
+                      - secretRef
+                    type: object
+                  description: |-
+                    Secrets is a collection of sensitive variables. Rather than holding the value, each entry
+                    references a secret in the controller namespace, which is only resolved when building the
+                    configuration for the terraform job
+                  type: object
+                sources:
                   description: |-
-                    ValueFromSource is a collection of value from sources, where the source of the value
-                    is taken from a secret
+                    Sources is a collection of dynamic sources for variables within the context. The values
+                    are resolved by the controller and kept in sync within the variables
                   items:
-                    description: ValueFromSource defines a value which is taken from a secret
+                    description: ContextSource defines a variable whose value is retrieved from another resource
                     properties:
-                      context:
-                        description: |-
-                          Context is the context is the name of the terraform context where the
-                          value should be retrieved from
+                      cluster:
+                        description: Cluster is a fact retrieved from the cluster, i.e. NodeZones or NodeRegions
+                        enum:
+                          - NodeRegions
+                          - NodeZones
                         type: string
-                      key:
-                        description: Key is the key in the secret which we should used for the value
+                      configMap:
+                        description: ConfigMap is a reference to a key within a configmap
+                        properties:
+                          key:
+                            description: Key is the key within the resource
+                            type: string
+                          name:
+                            description: Name is the name of the resource
+                            type: string
+                          namespace:
+                            description: Namespace is the namespace of the resource
+                            type: string
+                        required:
+                          - key
+                          - name
+                          - namespace
+                        type: object
+                      configuration:
+                        description: Configuration is a reference to an output of another configuration
+                        properties:
+                          name:
+                            description: Name is the name of the configuration
+                            type: string
+                          namespace:
+                            description: Namespace is the namespace of the configuration
+                            type: string
+                          output:
+                            description: Output is the name of the terraform output
+                            type: string
+                        required:
+                          - name
+                          - namespace
+                          - output
+                        type: object
+                      description:
+                        description: Description is a description for the variable
                         type: string
                       name:
-                        description: |-
-                          Name is the name which we use when injecting the value into the terraform code
-                          i.e. the secret may contain data.DB_HOST but you call this database_hostname. Note,
-                          for backwards compatiability if no name is provided, we using the key at the name
+                        description: Name is the name of the variable within the context
                         type: string
-                      optional:
-                        description: |-
-                          Optional indicates the secret can be optional, i.e if the secret does not exist, or the key is
-                          not contained in the secret, we ignore the error
-                        type: boolean
                       secret:
-                        description: Secret is the name of the secret in the configuration namespace
-                        type: string
+                        description: |-
+                          Secret is a reference to a key within a secret. The secret must be annotated with
+                          terraform.appvia.io/context-source=true to indicate it is non-sensitive.
+                        properties:
+                          key:
+                            description: Key is the key within the resource
+                            type: string
+                          name:
+                            description: Name is the name of the resource
+                            type: string
+                          namespace:
+                            description: Namespace is the namespace of the resource
+                            type: string
+                        required:
+                          - key
+                          - name
+                          - namespace
+                        type: object
                     required:
-                      - key
+                      - description
+                      - name
                     type: object
                   type: array
                 variables:
+                  additionalProperties:
+                    type: object
+                    x-kubernetes-preserve-unknown-fields: true
                   description: |-
-                    Variables provides the inputs for the terraform module itself. These are passed to the
-                    terraform executor and used to execute the plan, apply and destroy phases.
-                  type: object
-                  x-kubernetes-preserve-unknown-fields: true
-                writeConnectionSecretToRef:
-                  description: |-
-                    WriteConnectionSecretToRef is the name for a secret. On execution of the terraform module
-                    any module outputs are written to this secret. The outputs are automatically uppercased
-                    and ready to be consumed as environment variables.
-                    WriteConnectionSecretRef is the secret where the terraform outputs will be written.
-                  properties:
-                    keys:
-                      description: |-
-                        Keys is a collection of name used to filter the terraform output. By default all keys from the
-                        output of the terraform state are written to the connection secret. Here we can define exactly
-                        which keys we want from that output.
-                      items:
-                        type: string
-                      type: array
-                    name:
-                      description: |-
-                        Name is the of the secret where you want to the terraform output to be written. The terraform outputs
-                        will be written to the secret as a key value pair. All are uppercased can read to be consumed by the
-                        workload.
-                      type: string
-                  required:
-                    - name
+                    Variables is a list of variables which can be used globally by Context resources.
+                    The structure of the variables is a map of key/value pairs, which MUST have both
+                    a description and a value.
                   type: object
               required:
-                - module
+                - variables
               type: object
             status:
-              description: ConfigurationStatus defines the observed state of a terraform
+              description: ContextStatus defines the observed state of a terraform
               properties:
                 conditions:
                   description: Conditions represents the observations of the resource's current state.
@@ -819,26 +1554,6 @@ spec:
                   x-kubernetes-list-map-keys:
                     - type
                   x-kubernetes-list-type: map
-                costs:
-                  description: |-
-                    Costs is the predicted costs of this configuration. Note this field is only populated
-                    when the integration has been configured by the administrator.
-                  properties:
-                    enabled:
-                      description: |-
-                        Enabled indicates if the cost integration was enabled when this configuration was last
-                        executed.
-                      type: boolean
-                    hourly:
-                      description: Hourly is the hourly estimated cost of the configuration
-                      type: string
-                    monthly:
-                      description: Monthly is the monthly estimated cost of the configuration
-                      type: string
-                  type: object
-                driftTimestamp:
-                  description: DriftTimestamp is the timestamp of the last drift detection
-                  type: string
                 lastReconcile:
                   description: LastReconcile describes the generation and time of the last reconciliation
                   properties:
@@ -865,21 +1580,59 @@ spec:
                       format: date-time
                       type: string
                   type: object
-                resourceStatus:
-                  description: |-
-                    ResourceStatus indicates the status of the resources and if the resources are insync with the
-                    configuration
-                  type: string
-                resources:
-                  description: |-
-                    Resources is the number of managed cloud resources which are currently under management.
-                    This field is taken from the terraform state itself.
-                  type: integer
-                terraformVersion:
-                  description: |-
-                    TerraformVersion is the version of terraform which was last used to run this
-                    configuration
-                  type: string
+                preload:
+                  description: Preload holds the details of the changes made to the context by the last preload
+                  properties:
+                    added:
+                      description: Added is a list of variables which were added by the last change
+                      items:
+                        type: string
+                      type: array
+                    changed:
+                      description: Changed is a list of variables whose value was changed by the last change
+                      items:
+                        type: string
+                      type: array
+                    checksums:
+                      additionalProperties:
+                        type: string
+                      description: |-
+                        Checksums is a map of variable name to a checksum of the value loaded by the
+                        preloader, and used to detect changes between runs
+                      type: object
+                    lastChangeTime:
+                      description: LastChangeTime is the last time the preloader changed the context
+                      format: date-time
+                      type: string
+                    removed:
+                      description: Removed is a list of variables which were removed by the last change
+                      items:
+                        type: string
+                      type: array
+                  type: object
+                sources:
+                  description: Sources is the resolution state of the dynamic sources
+                  items:
+                    description: ContextSourceStatus is the resolution state of a dynamic source
+                    properties:
+                      lastResolved:
+                        description: LastResolved is the last time the source was successfully resolved
+                        format: date-time
+                        type: string
+                      message:
+                        description: Message provides a reason when the source could not be resolved
+                        type: string
+                      name:
+                        description: Name is the name of the variable
+                        type: string
+                      resolved:
+                        description: Resolved indicates the value of the source was resolved
+                        type: boolean
+                    required:
+                      - name
+                      - resolved
+                    type: object
+                  type: array
               type: object
           type: object
       served: true
@@ -889,37 +1642,37 @@ spec:
   preserveUnknownFields: false
 `)
 
-func chartsTerranetesControllerCrdsTerraformAppviaIo_configurationsYamlBytes() ([]byte, error) {
-	return _chartsTerranetesControllerCrdsTerraformAppviaIo_configurationsYaml, nil
+func chartsTerranetesControllerCrdsTerraformAppviaIo_contextsYamlBytes() ([]byte, error) {
+	return _chartsTerranetesControllerCrdsTerraformAppviaIo_contextsYaml, nil
 }
 
-func chartsTerranetesControllerCrdsTerraformAppviaIo_configurationsYaml() (*asset, error) {
-	bytes, err := chartsTerranetesControllerCrdsTerraformAppviaIo_configurationsYamlBytes()
+func chartsTerranetesControllerCrdsTerraformAppviaIo_contextsYaml() (*asset, error) {
+	bytes, err := chartsTerranetesControllerCrdsTerraformAppviaIo_contextsYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "charts/terranetes-controller/crds/terraform.appvia.io_configurations.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "charts/terranetes-controller/crds/terraform.appvia.io_contexts.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _chartsTerranetesControllerCrdsTerraformAppviaIo_contextsYaml = []byte(`apiVersion: apiextensions.k8s.io/v1
+var _chartsTerranetesControllerCrdsTerraformAppviaIo_namespacecontextsYaml = []byte(`apiVersion: apiextensions.k8s.io/v1
 kind: CustomResourceDefinition
 metadata:
   annotations:
     controller-gen.kubebuilder.io/version: v0.17.3
-  name: contexts.terraform.appvia.io
+  name: namespacecontexts.terraform.appvia.io
 spec:
   group: terraform.appvia.io
   names:
     categories:
       - terraform
-    kind: Context
-    listKind: ContextList
-    plural: contexts
-    singular: context
-  scope: Cluster
+    kind: NamespaceContext
+    listKind: NamespaceContextList
+    plural: namespacecontexts
+    singular: namespacecontext
+  scope: Namespaced
   versions:
     - additionalPrinterColumns:
         - jsonPath: .metadata.creationTimestamp
@@ -928,7 +1681,9 @@ spec:
       name: v1alpha1
       schema:
         openAPIV3Schema:
-          description: Context is the schema for the context type
+          description: |-
+            NamespaceContext is the schema for a context scoped to a namespace. Values defined here
+            take precedence over a cluster Context of the same name
           properties:
             apiVersion:
               description: |-
@@ -948,22 +1703,22 @@ spec:
             metadata:
               type: object
             spec:
-              description: ContextSpec defines the desired state for a context
+              description: NamespaceContextSpec defines the desired state for a namespace context
               properties:
                 variables:
                   additionalProperties:
                     type: object
                     x-kubernetes-preserve-unknown-fields: true
                   description: |-
-                    Variables is a list of variables which can be used globally by Context resources.
-                    The structure of the variables is a map of key/value pairs, which MUST have both
-                    a description and a value.
+                    Variables is a list of variables which can be used by the configurations within the
+                    namespace. The structure of the variables is a map of key/value pairs, which MUST have
+                    both a description and a value.
                   type: object
               required:
                 - variables
               type: object
             status:
-              description: ContextStatus defines the observed state of a terraform
+              description: NamespaceContextStatus defines the observed state of a namespace context
               properties:
                 conditions:
                   description: Conditions represents the observations of the resource's current state.
@@ -1068,17 +1823,17 @@ spec:
   preserveUnknownFields: false
 `)
 
-func chartsTerranetesControllerCrdsTerraformAppviaIo_contextsYamlBytes() ([]byte, error) {
-	return _chartsTerranetesControllerCrdsTerraformAppviaIo_contextsYaml, nil
+func chartsTerranetesControllerCrdsTerraformAppviaIo_namespacecontextsYamlBytes() ([]byte, error) {
+	return _chartsTerranetesControllerCrdsTerraformAppviaIo_namespacecontextsYaml, nil
 }
 
-func chartsTerranetesControllerCrdsTerraformAppviaIo_contextsYaml() (*asset, error) {
-	bytes, err := chartsTerranetesControllerCrdsTerraformAppviaIo_contextsYamlBytes()
+func chartsTerranetesControllerCrdsTerraformAppviaIo_namespacecontextsYaml() (*asset, error) {
+	bytes, err := chartsTerranetesControllerCrdsTerraformAppviaIo_namespacecontextsYamlBytes()
 	if err != nil {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "charts/terranetes-controller/crds/terraform.appvia.io_contexts.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	info := bindataFileInfo{name: "charts/terranetes-controller/crds/terraform.appvia.io_namespacecontexts.yaml", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -1626,27 +2381,154 @@ spec:
                                     map is equivalent to an element of matchExpressions, whose key field is "key", the
                                     operator is "In", and the values array contains only "value". The requirements are ANDed.
                                   type: object
-                              type: object
-                              x-kubernetes-map-type: atomic
-                          type: object
-                      type: object
-                  type: object
-                defaults:
+                              type: object
+                              x-kubernetes-map-type: atomic
+                          type: object
+                      type: object
+                  type: object
+                defaults:
+                  description: |-
+                    Defaults provides the ability to target specific terraform module based on namespace or
+                    resource labels and automatically inject variables into the configurations.
+                  items:
+                    description: |-
+                      DefaultVariables provides platform administrators the ability to inject
+                      default variables into a configuration
+                    properties:
+                      secrets:
+                        description: Secrets is a collection of secrets which are used to inject variables into the configuration
+                        items:
+                          type: string
+                        type: array
+                      selector:
+                        description: Selector is used to determine which configurations the variables should be injected into
+                        properties:
+                          modules:
+                            description: |-
+                              Modules provides a collection of regexes which are used to match against the
+                              configuration module
+                            items:
+                              type: string
+                            type: array
+                          namespace:
+                            description: |-
+                              Namespace selectors all configurations under one or more namespaces, determined by the
+                              labeling on the namespace.
+                            properties:
+                              matchExpressions:
+                                description: matchExpressions is a list of label selector requirements. The requirements are ANDed.
+                                items:
+                                  description: |-
+                                    A label selector requirement is a selector that contains values, a key, and an operator that
+                                    relates the key and values.
+                                  properties:
+                                    key:
+                                      description: key is the label key that the selector applies to.
+                                      type: string
+                                    operator:
+                                      description: |-
+                                        operator represents a key's relationship to a set of values.
+                                        Valid operators are In, NotIn, Exists and DoesNotExist.
+                                      type: string
+                                    values:
+                                      description: |-
+                                        values is an array of string values. If the operator is In or NotIn,
+                                        the values array must be non-empty. If the operator is Exists or DoesNotExist,
+                                        the values array must be empty. This array is replaced during a strategic
+                                        merge patch.
+                                      items:
+                                        type: string
+                                      type: array
+                                      x-kubernetes-list-type: atomic
+                                  required:
+                                    - key
+                                    - operator
+                                  type: object
+                                type: array
+                                x-kubernetes-list-type: atomic
+                              matchLabels:
+                                additionalProperties:
+                                  type: string
+                                description: |-
+                                  matchLabels is a map of {key,value} pairs. A single {key,value} in the matchLabels
+                                  map is equivalent to an element of matchExpressions, whose key field is "key", the
+                                  operator is "In", and the values array contains only "value". The requirements are ANDed.
+                                type: object
+                            type: object
+                            x-kubernetes-map-type: atomic
+                        type: object
+                      variables:
+                        description: Variables is a collection of variables to inject into the configuration
+                        type: object
+                        x-kubernetes-preserve-unknown-fields: true
+                    required:
+                      - selector
+                    type: object
+                  type: array
+                hooks:
                   description: |-
-                    Defaults provides the ability to target specific terraform module based on namespace or
-                    resource labels and automatically inject variables into the configurations.
+                    Hooks provides the ability to target specific terraform modules based on namespace or
+                    module and inject hooks into the jobs, in addition to those defined by the configuration
                   items:
                     description: |-
-                      DefaultVariables provides platform administrators the ability to inject
-                      default variables into a configuration
+                      HookDefaults provides platform administrators the ability to inject hooks into the
+                      jobs of the matching configurations
                     properties:
-                      secrets:
-                        description: Secrets is a collection of secrets which are used to inject variables into the configuration
+                      hooks:
+                        description: Hooks is a collection of hooks to inject into the matching configurations
                         items:
-                          type: string
+                          description: |-
+                            Hook defines a custom step executed within the terraform job of a stage, such as linting the
+                            module before a plan or running a smoke test after an apply
+                          properties:
+                            commands:
+                              description: |-
+                                Commands is a collection of shell commands executed in order by the hook. The module
+                                source is available in the working directory
+                              items:
+                                type: string
+                              minItems: 1
+                              type: array
+                            continueOnError:
+                              description: ContinueOnError indicates a failure of the hook should not fail the stage
+                              type: boolean
+                            image:
+                              description: Image is the container image the hook is executed in, the image must provide a shell
+                              type: string
+                            name:
+                              description: Name is the name of the hook, which must be unique within the configuration
+                              maxLength: 40
+                              type: string
+                            retries:
+                              description: Retries is the number of times the commands are retried on failure
+                              minimum: 0
+                              type: integer
+                            timeout:
+                              description: Timeout is the maximum duration of each command, after which the command is failed
+                              type: string
+                            when:
+                              description: |-
+                                When is the point the hook is executed i.e. pre-plan, post-plan, pre-apply, post-apply,
+                                pre-destroy or post-destroy. Pre hooks are executed in order, while post hooks are executed
+                                concurrently and only when the stage was successful
+                              enum:
+                                - pre-plan
+                                - post-plan
+                                - pre-apply
+                                - post-apply
+                                - pre-destroy
+                                - post-destroy
+                              type: string
+                          required:
+                            - commands
+                            - image
+                            - name
+                            - when
+                          type: object
+                        minItems: 1
                         type: array
                       selector:
-                        description: Selector is used to determine which configurations the variables should be injected into
+                        description: Selector is used to determine which configurations the hooks are injected into
                         properties:
                           modules:
                             description: |-
@@ -1702,12 +2584,186 @@ spec:
                             type: object
                             x-kubernetes-map-type: atomic
                         type: object
-                      variables:
-                        description: Variables is a collection of variables to inject into the configuration
+                    required:
+                      - hooks
+                      - selector
+                    type: object
+                  type: array
+                podTemplateOverrides:
+                  description: |-
+                    PodTemplateOverrides provides the ability to target specific terraform modules based on
+                    namespace or module and customise the pods of the jobs, without replacing the job template
+                  items:
+                    description: |-
+                      PodTemplateDefaults provides platform administrators the ability to customise the pods of the
+                      jobs for the matching configurations
+                    properties:
+                      selector:
+                        description: Selector is used to determine which configurations the overrides are applied to
+                        properties:
+                          modules:
+                            description: |-
+                              Modules provides a collection of regexes which are used to match against the
+                              configuration module
+                            items:
+                              type: string
+                            type: array
+                          namespace:
+                            description: |-
+                              Namespace selectors all configurations under one or more namespaces, determined by the
+                              labeling on the namespace.
+                            properties:
+                              matchExpressions:
+                                description: matchExpressions is a list of label selector requirements. The requirements are ANDed.
+                                items:
+                                  description: |-
+                                    A label selector requirement is a selector that contains values, a key, and an operator that
+                                    relates the key and values.
+                                  properties:
+                                    key:
+                                      description: key is the label key that the selector applies to.
+                                      type: string
+                                    operator:
+                                      description: |-
+                                        operator represents a key's relationship to a set of values.
+                                        Valid operators are In, NotIn, Exists and DoesNotExist.
+                                      type: string
+                                    values:
+                                      description: |-
+                                        values is an array of string values. If the operator is In or NotIn,
+                                        the values array must be non-empty. If the operator is Exists or DoesNotExist,
+                                        the values array must be empty. This array is replaced during a strategic
+                                        merge patch.
+                                      items:
+                                        type: string
+                                      type: array
+                                      x-kubernetes-list-type: atomic
+                                  required:
+                                    - key
+                                    - operator
+                                  type: object
+                                type: array
+                                x-kubernetes-list-type: atomic
+                              matchLabels:
+                                additionalProperties:
+                                  type: string
+                                description: |-
+                                  matchLabels is a map of {key,value} pairs. A single {key,value} in the matchLabels
+                                  map is equivalent to an element of matchExpressions, whose key field is "key", the
+                                  operator is "In", and the values array contains only "value". The requirements are ANDed.
+                                type: object
+                            type: object
+                            x-kubernetes-map-type: atomic
+                        type: object
+                      template:
+                        description: |-
+                          Template is a partial pod template which is strategically merged onto the pod template
+                          of the jobs, i.e. node selectors, tolerations, affinity, priority class, additional volumes,
+                          sidecars or the resources of the terraform container
                         type: object
                         x-kubernetes-preserve-unknown-fields: true
                     required:
                       - selector
+                      - template
+                    type: object
+                  type: array
+                retries:
+                  description: |-
+                    Retries provides the ability to target specific terraform modules based on namespace or
+                    module and apply an automatic retry policy for transient failures. Configurations which
+                    define their own retry policy take precedence.
+                  items:
+                    description: |-
+                      RetryDefaults provides platform administrators the ability to apply a retry policy to
+                      configurations which do not define their own
+                    properties:
+                      policy:
+                        description: Policy is the retry policy to apply to the matching configurations
+                        properties:
+                          backoff:
+                            description: |-
+                              Backoff is the initial delay before retrying, doubled on every subsequent attempt. Defaults
+                              to 30 seconds
+                            type: string
+                          classes:
+                            description: |-
+                              Classes is a collection of error classes (e.g. throttling, state-lock) which are permitted
+                              to be retried. When empty, any error classified by a detector as retryable is retried.
+                            items:
+                              type: string
+                            type: array
+                          maxAttempts:
+                            description: |-
+                              MaxAttempts is the maximum number of automatic retries for a given generation of the
+                              configuration
+                            minimum: 1
+                            type: integer
+                          maxBackoff:
+                            description: MaxBackoff is the upper limit on the delay between retries. Defaults to 10 minutes
+                            type: string
+                        required:
+                          - maxAttempts
+                        type: object
+                      selector:
+                        description: Selector is used to determine which configurations the retry policy applies to
+                        properties:
+                          modules:
+                            description: |-
+                              Modules provides a collection of regexes which are used to match against the
+                              configuration module
+                            items:
+                              type: string
+                            type: array
+                          namespace:
+                            description: |-
+                              Namespace selectors all configurations under one or more namespaces, determined by the
+                              labeling on the namespace.
+                            properties:
+                              matchExpressions:
+                                description: matchExpressions is a list of label selector requirements. The requirements are ANDed.
+                                items:
+                                  description: |-
+                                    A label selector requirement is a selector that contains values, a key, and an operator that
+                                    relates the key and values.
+                                  properties:
+                                    key:
+                                      description: key is the label key that the selector applies to.
+                                      type: string
+                                    operator:
+                                      description: |-
+                                        operator represents a key's relationship to a set of values.
+                                        Valid operators are In, NotIn, Exists and DoesNotExist.
+                                      type: string
+                                    values:
+                                      description: |-
+                                        values is an array of string values. If the operator is In or NotIn,
+                                        the values array must be non-empty. If the operator is Exists or DoesNotExist,
+                                        the values array must be empty. This array is replaced during a strategic
+                                        merge patch.
+                                      items:
+                                        type: string
+                                      type: array
+                                      x-kubernetes-list-type: atomic
+                                  required:
+                                    - key
+                                    - operator
+                                  type: object
+                                type: array
+                                x-kubernetes-list-type: atomic
+                              matchLabels:
+                                additionalProperties:
+                                  type: string
+                                description: |-
+                                  matchLabels is a map of {key,value} pairs. A single {key,value} in the matchLabels
+                                  map is equivalent to an element of matchExpressions, whose key field is "key", the
+                                  operator is "In", and the values array contains only "value". The requirements are ANDed.
+                                type: object
+                            type: object
+                            x-kubernetes-map-type: atomic
+                        type: object
+                    required:
+                      - policy
+                      - selector
                     type: object
                   type: array
                 summary:
@@ -1889,6 +2945,12 @@ spec:
             spec:
               description: ProviderSpec defines the desired state of a provider
               properties:
+                agent:
+                  description: |-
+                    Agent is the name of a remote execution agent which runs the jobs for the configurations
+                    using this provider, rather than the cluster the controller is running in. The agent must
+                    be registered with the controller and permitted to serve the provider.
+                  type: string
                 backendTemplate:
                   description: |-
                     BackendTemplate is the reference to a backend template used for the terraform
@@ -1908,6 +2970,14 @@ spec:
                   description: Configuration is optional configuration to the provider. This is terraform provider specific.
                   type: object
                   x-kubernetes-preserve-unknown-fields: true
+                engine:
+                  description: |-
+                    Engine is the default engine used to execute the configurations which use this provider,
+                    i.e. terraform or opentofu. Configurations are able to override this via their own engine
+                  enum:
+                    - terraform
+                    - opentofu
+                  type: string
                 job:
                   description: |-
                     Job defined a custom collection of labels and annotations to be applied to all jobs
@@ -1924,6 +2994,14 @@ spec:
                       description: Labels is a collection of labels which are automatically added to all jobs.
                       type: object
                   type: object
+                podTemplateOverrides:
+                  description: |-
+                    PodTemplateOverrides is a partial pod template which is strategically merged onto the pod
+                    template of all jobs which use this provider, i.e. node selectors, tolerations, affinity,
+                    priority class, additional volumes, sidecars or the resources of the terraform container.
+                    These are applied after any overrides from the policies, hence take precedence.
+                  type: object
+                  x-kubernetes-preserve-unknown-fields: true
                 preload:
                   description: Preload defines the configuration for the preloading of contextual data from the cloud vendor.
                   properties:
@@ -1944,6 +3022,11 @@ spec:
                     region:
                       description: Region is the cloud region the cluster is location in
                       type: string
+                    replanOnChange:
+                      description: |-
+                        ReplanOnChange indicates any configurations which reference a context variable
+                        changed by the preloader should be automatically retried
+                      type: boolean
                   type: object
                 provider:
                   description: |-
@@ -2294,12 +3377,82 @@ spec:
                         for any drift between the expected and current state. If any drift is detected the
                         status is changed and a kubernetes event raised.
                       type: boolean
+                    engine:
+                      description: |-
+                        Engine is the engine used to execute the configuration, i.e. terraform or opentofu. When not
+                        defined the engine of the provider is used, else the default engine of the controller. Note,
+                        changing the engine of an existing configuration is only permitted when the state written
+                        by the previous engine can be read by the new one
+                      enum:
+                        - terraform
+                        - opentofu
+                      type: string
+                    hooks:
+                      description: |-
+                        Hooks is a collection of custom steps executed within the terraform jobs, before or
+                        after the plan, apply and destroy stages
+                      items:
+                        description: |-
+                          Hook defines a custom step executed within the terraform job of a stage, such as linting the
+                          module before a plan or running a smoke test after an apply
+                        properties:
+                          commands:
+                            description: |-
+                              Commands is a collection of shell commands executed in order by the hook. The module
+                              source is available in the working directory
+                            items:
+                              type: string
+                            minItems: 1
+                            type: array
+                          continueOnError:
+                            description: ContinueOnError indicates a failure of the hook should not fail the stage
+                            type: boolean
+                          image:
+                            description: Image is the container image the hook is executed in, the image must provide a shell
+                            type: string
+                          name:
+                            description: Name is the name of the hook, which must be unique within the configuration
+                            maxLength: 40
+                            type: string
+                          retries:
+                            description: Retries is the number of times the commands are retried on failure
+                            minimum: 0
+                            type: integer
+                          timeout:
+                            description: Timeout is the maximum duration of each command, after which the command is failed
+                            type: string
+                          when:
+                            description: |-
+                              When is the point the hook is executed i.e. pre-plan, post-plan, pre-apply, post-apply,
+                              pre-destroy or post-destroy. Pre hooks are executed in order, while post hooks are executed
+                              concurrently and only when the stage was successful
+                            enum:
+                              - pre-plan
+                              - post-plan
+                              - pre-apply
+                              - post-apply
+                              - pre-destroy
+                              - post-destroy
+                            type: string
+                        required:
+                          - commands
+                          - image
+                          - name
+                          - when
+                        type: object
+                      type: array
                     module:
                       description: |-
                         Module is the URL to the source of the terraform module. The format of the URL is
                         a direct implementation of terraform's module reference. Please see the following
                         repository for more details https://github.com/hashicorp/go-getter
                       type: string
+                    moduleChecksum:
+                      description: |-
+                        ModuleChecksum is the expected checksum of the module source, used to pin the module to
+                        known contents. This is either a checksum of the module contents (h1:<hash>), as logged
+                        by the controller when retrieving the source, or of a downloaded archive (i.e. sha256:<hex>)
+                      type: string
                     plan:
                       description: |-
                         Plan is an optional reference to a plan this configuration is associated with. If
@@ -2331,11 +3484,44 @@ spec:
                       required:
                         - name
                       type: object
+                    retry:
+                      description: |-
+                        Retry defines a policy for automatically retrying the configuration when a terraform
+                        job fails due to a transient error, such as api throttling or state lock contention
+                      properties:
+                        backoff:
+                          description: |-
+                            Backoff is the initial delay before retrying, doubled on every subsequent attempt. Defaults
+                            to 30 seconds
+                          type: string
+                        classes:
+                          description: |-
+                            Classes is a collection of error classes (e.g. throttling, state-lock) which are permitted
+                            to be retried. When empty, any error classified by a detector as retryable is retried.
+                          items:
+                            type: string
+                          type: array
+                        maxAttempts:
+                          description: |-
+                            MaxAttempts is the maximum number of automatic retries for a given generation of the
+                            configuration
+                          minimum: 1
+                          type: integer
+                        maxBackoff:
+                          description: MaxBackoff is the upper limit on the delay between retries. Defaults to 10 minutes
+                          type: string
+                      required:
+                        - maxAttempts
+                      type: object
                     terraformVersion:
                       description: |-
                         TerraformVersion provides the ability to override the default terraform version. Before
                         changing this field its best to consult with platform administrator. As the
                         value of this field is used to change the tag of the terraform container image.
+                        Note the version is the version of the engine in use, i.e. the OpenTofu version when
+                        the engine is opentofu. The version can also be a constraint (i.e. ~> 1.6, >= 1.5, < 1.7)
+                        which is resolved to the latest matching version from those made available by the
+                        platform administrator
                       type: string
                     tfVars:
                       description: |-
@@ -2783,6 +3969,27 @@ webhooks:
     resources:
     - contexts
   sideEffects: None
+- admissionReviewVersions:
+  - v1
+  clientConfig:
+    service:
+      name: webhook-service
+      namespace: system
+      path: /validate/terraform.appvia.io/namespacecontexts
+  failurePolicy: Fail
+  name: namespacecontexts.terraform.appvia.io
+  rules:
+  - apiGroups:
+    - terraform.appvia.io
+    apiVersions:
+    - v1alpha1
+    operations:
+    - CREATE
+    - DELETE
+    - UPDATE
+    resources:
+    - namespacecontexts
+  sideEffects: None
 - admissionReviewVersions:
   - v1
   clientConfig:
@@ -2914,13 +4121,14 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"charts/terranetes-controller/crds/terraform.appvia.io_cloudresources.yaml": chartsTerranetesControllerCrdsTerraformAppviaIo_cloudresourcesYaml,
-	"charts/terranetes-controller/crds/terraform.appvia.io_configurations.yaml": chartsTerranetesControllerCrdsTerraformAppviaIo_configurationsYaml,
-	"charts/terranetes-controller/crds/terraform.appvia.io_contexts.yaml":       chartsTerranetesControllerCrdsTerraformAppviaIo_contextsYaml,
-	"charts/terranetes-controller/crds/terraform.appvia.io_plans.yaml":          chartsTerranetesControllerCrdsTerraformAppviaIo_plansYaml,
-	"charts/terranetes-controller/crds/terraform.appvia.io_policies.yaml":       chartsTerranetesControllerCrdsTerraformAppviaIo_policiesYaml,
-	"charts/terranetes-controller/crds/terraform.appvia.io_providers.yaml":      chartsTerranetesControllerCrdsTerraformAppviaIo_providersYaml,
-	"charts/terranetes-controller/crds/terraform.appvia.io_revisions.yaml":      chartsTerranetesControllerCrdsTerraformAppviaIo_revisionsYaml,
+	"charts/terranetes-controller/crds/terraform.appvia.io_cloudresources.yaml":    chartsTerranetesControllerCrdsTerraformAppviaIo_cloudresourcesYaml,
+	"charts/terranetes-controller/crds/terraform.appvia.io_configurations.yaml":    chartsTerranetesControllerCrdsTerraformAppviaIo_configurationsYaml,
+	"charts/terranetes-controller/crds/terraform.appvia.io_contexts.yaml":          chartsTerranetesControllerCrdsTerraformAppviaIo_contextsYaml,
+	"charts/terranetes-controller/crds/terraform.appvia.io_namespacecontexts.yaml": chartsTerranetesControllerCrdsTerraformAppviaIo_namespacecontextsYaml,
+	"charts/terranetes-controller/crds/terraform.appvia.io_plans.yaml":             chartsTerranetesControllerCrdsTerraformAppviaIo_plansYaml,
+	"charts/terranetes-controller/crds/terraform.appvia.io_policies.yaml":          chartsTerranetesControllerCrdsTerraformAppviaIo_policiesYaml,
+	"charts/terranetes-controller/crds/terraform.appvia.io_providers.yaml":         chartsTerranetesControllerCrdsTerraformAppviaIo_providersYaml,
+	"charts/terranetes-controller/crds/terraform.appvia.io_revisions.yaml":         chartsTerranetesControllerCrdsTerraformAppviaIo_revisionsYaml,
 	"webhooks/manifests.yaml": webhooksManifestsYaml,
 }
 
@@ -2970,13 +4178,14 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"charts": &bintree{nil, map[string]*bintree{
 		"terranetes-controller": &bintree{nil, map[string]*bintree{
 			"crds": &bintree{nil, map[string]*bintree{
-				"terraform.appvia.io_cloudresources.yaml": &bintree{chartsTerranetesControllerCrdsTerraformAppviaIo_cloudresourcesYaml, map[string]*bintree{}},
-				"terraform.appvia.io_configurations.yaml": &bintree{chartsTerranetesControllerCrdsTerraformAppviaIo_configurationsYaml, map[string]*bintree{}},
-				"terraform.appvia.io_contexts.yaml":       &bintree{chartsTerranetesControllerCrdsTerraformAppviaIo_contextsYaml, map[string]*bintree{}},
-				"terraform.appvia.io_plans.yaml":          &bintree{chartsTerranetesControllerCrdsTerraformAppviaIo_plansYaml, map[string]*bintree{}},
-				"terraform.appvia.io_policies.yaml":       &bintree{chartsTerranetesControllerCrdsTerraformAppviaIo_policiesYaml, map[string]*bintree{}},
-				"terraform.appvia.io_providers.yaml":      &bintree{chartsTerranetesControllerCrdsTerraformAppviaIo_providersYaml, map[string]*bintree{}},
-				"terraform.appvia.io_revisions.yaml":      &bintree{chartsTerranetesControllerCrdsTerraformAppviaIo_revisionsYaml, map[string]*bintree{}},
+				"terraform.appvia.io_cloudresources.yaml":    &bintree{chartsTerranetesControllerCrdsTerraformAppviaIo_cloudresourcesYaml, map[string]*bintree{}},
+				"terraform.appvia.io_configurations.yaml":    &bintree{chartsTerranetesControllerCrdsTerraformAppviaIo_configurationsYaml, map[string]*bintree{}},
+				"terraform.appvia.io_contexts.yaml":          &bintree{chartsTerranetesControllerCrdsTerraformAppviaIo_contextsYaml, map[string]*bintree{}},
+				"terraform.appvia.io_namespacecontexts.yaml": &bintree{chartsTerranetesControllerCrdsTerraformAppviaIo_namespacecontextsYaml, map[string]*bintree{}},
+				"terraform.appvia.io_plans.yaml":             &bintree{chartsTerranetesControllerCrdsTerraformAppviaIo_plansYaml, map[string]*bintree{}},
+				"terraform.appvia.io_policies.yaml":          &bintree{chartsTerranetesControllerCrdsTerraformAppviaIo_policiesYaml, map[string]*bintree{}},
+				"terraform.appvia.io_providers.yaml":         &bintree{chartsTerranetesControllerCrdsTerraformAppviaIo_providersYaml, map[string]*bintree{}},
+				"terraform.appvia.io_revisions.yaml":         &bintree{chartsTerranetesControllerCrdsTerraformAppviaIo_revisionsYaml, map[string]*bintree{}},
 			}},
 		}},
 	}},
@@ -19,23 +19,29 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	"github.com/appvia/terranetes-controller/pkg/agent"
 	"github.com/appvia/terranetes-controller/pkg/apiserver"
+	"github.com/appvia/terranetes-controller/pkg/apiserver/stream"
 	"github.com/appvia/terranetes-controller/pkg/controller/cloudresource"
 	"github.com/appvia/terranetes-controller/pkg/controller/configuration"
 	ctrlcontext "github.com/appvia/terranetes-controller/pkg/controller/context"
@@ -51,6 +57,8 @@ import (
 	"github.com/appvia/terranetes-controller/pkg/schema"
 	"github.com/appvia/terranetes-controller/pkg/utils"
 	k8sutils "github.com/appvia/terranetes-controller/pkg/utils/kubernetes"
+	"github.com/appvia/terranetes-controller/pkg/utils/logstore"
+	"github.com/appvia/terranetes-controller/pkg/utils/mirror"
 	"github.com/appvia/terranetes-controller/pkg/version"
 )
 
@@ -61,6 +69,12 @@ type Server struct {
 	mgr      manager.Manager
 	hs       *http.Server
 	listener net.Listener
+	// ms and mirrorListener serve the provider mirror over tls when enabled
+	ms             *http.Server
+	mirrorListener net.Listener
+	// as and agentsListener serve the remote execution agents over mutual tls when enabled
+	as             *http.Server
+	agentsListener net.Listener
 }
 
 // New returns and starts a new server
@@ -111,18 +125,13 @@ func New(cfg *rest.Config, config Config) (*Server, error) {
 		ns = "terraform-system"
 	}
 
-	hs := &http.Server{
-		Addr:              listener.Addr().String(),
-		IdleTimeout:       30 * time.Second,
-		ReadHeaderTimeout: 5 * time.Second,
-		Handler: (&apiserver.Server{
-			Client:    cc,
-			Namespace: config.Namespace,
-		}).Serve(),
-	}
-
 	options := manager.Options{
-		Cache:                         cache.Options{SyncPeriod: &config.ResyncPeriod},
+		Cache: cache.Options{SyncPeriod: &config.ResyncPeriod},
+		// the agent registrations are read directly, as the controller is only permitted to
+		// access the leases within its own namespace
+		Client: client.Options{
+			Cache: &client.CacheOptions{DisableFor: []client.Object{&coordinationv1.Lease{}}},
+		},
 		LeaderElection:                true,
 		LeaderElectionID:              "controller.terraform.appvia.io",
 		LeaderElectionNamespace:       ns,
@@ -149,13 +158,114 @@ func New(cfg *rest.Config, config Config) (*Server, error) {
 		return nil, fmt.Errorf("failed to create the controller manager: %w", err)
 	}
 
+	store, err := logstore.New(config.BuildLogsStore, mgr.GetClient(), config.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the build logs store: %w", err)
+	}
+
+	broker := stream.NewBroker()
+	if err := broker.Watch(context.Background(), mgr.GetCache(), config.Namespace); err != nil {
+		return nil, fmt.Errorf("failed to watch the resources for lifecycle events: %w", err)
+	}
+
+	// @step: create the provider mirror if enabled, this is served on a dedicated listener over tls,
+	// as terraform only permits https network mirrors and pushes carry the bearer token of the caller
+	var providers *mirror.Store
+	var ms *http.Server
+	var mirrorListener net.Listener
+	if config.ProviderMirrorDir != "" {
+		if config.TLSDir == "" {
+			return nil, errors.New("provider mirror requires the tls certificates (--tls-dir)")
+		}
+		if providers, err = mirror.New(config.ProviderMirrorDir); err != nil {
+			return nil, err
+		}
+		if config.ProviderMirrorSigningKeys != "" {
+			keys, err := os.Open(config.ProviderMirrorSigningKeys)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open the provider signing keys, error: %w", err)
+			}
+			providers.Keyring, err = mirror.ReadKeyring(keys)
+			keys.Close()
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			log.Warn("no provider signing keys (--provider-mirror-signing-keys), pushes to the mirror will be refused")
+		}
+		if config.ProviderMirrorURL == "" {
+			config.ProviderMirrorURL = fmt.Sprintf("https://controller.%s.svc.cluster.local:%d/v1/providers/",
+				config.Namespace, config.ProviderMirrorPort)
+		}
+		if mirrorListener, err = net.Listen("tcp", fmt.Sprintf(":%d", config.ProviderMirrorPort)); err != nil {
+			return nil, err
+		}
+	}
+
+	api := &apiserver.Server{
+		CC:                   mgr.GetClient(),
+		Client:               cc,
+		EnableAuthentication: config.EnableAPIServerAuthentication,
+		EnableNamespacedJobs: config.EnableNamespacedJobs,
+		Events:               broker,
+		LogStore:             store,
+		Mirror:               providers,
+		Namespace:            config.Namespace,
+	}
+
+	hs := &http.Server{
+		Addr:              listener.Addr().String(),
+		IdleTimeout:       30 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		Handler:           api.Serve(),
+	}
+	if mirrorListener != nil {
+		ms = &http.Server{
+			Addr:              mirrorListener.Addr().String(),
+			IdleTimeout:       30 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			Handler:           api.ServeMirror(),
+		}
+	}
+
+	// @step: create the server for the remote execution agents if enabled, the agents are
+	// authenticated by their client certificates
+	var as *http.Server
+	var agentsListener net.Listener
+	if config.EnableAgents {
+		if config.TLSDir == "" || config.TLSAuthority == "" {
+			return nil, errors.New("agents require the tls certificates and authority (--tls-dir, --tls-ca)")
+		}
+		tlsConfig, err := agent.NewServerTLSConfig(
+			filepath.Join(config.TLSDir, config.TLSCert),
+			filepath.Join(config.TLSDir, config.TLSKey),
+			config.TLSAuthority,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the tls configuration for the agents, error: %w", err)
+		}
+		if agentsListener, err = net.Listen("tcp", fmt.Sprintf(":%d", config.AgentsPort)); err != nil {
+			return nil, err
+		}
+
+		as = &http.Server{
+			Addr:              agentsListener.Addr().String(),
+			IdleTimeout:       30 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			Handler:           agent.NewHandler(agent.NewBroker(mgr.GetClient(), config.Namespace)),
+			TLSConfig:         tlsConfig,
+		}
+	}
+
 	if config.InfracostsSecretName != "" && config.InfracostsImage != "" {
 		log.Info("enabling the infracost integration")
 	}
 
 	// @step: ensure the contexts controller is enabled
 	if err := (&ctrlcontext.Controller{
-		EnableWebhooks: config.EnableWebhooks,
+		ControllerNamespace:  config.Namespace,
+		EnableNamespacedJobs: config.EnableNamespacedJobs,
+		EnableWebhooks:       config.EnableWebhooks,
 	}).Add(mgr); err != nil {
 		return nil, fmt.Errorf("failed to add the contexts controller: %w", err)
 	}
@@ -174,13 +284,17 @@ func New(cfg *rest.Config, config Config) (*Server, error) {
 		BackendTemplate:              config.BackendTemplate,
 		BackoffLimit:                 config.BackoffLimit,
 		BinaryPath:                   config.BinaryPath,
+		CacheVolume:                  config.CacheVolume,
 		ControllerJobLabels:          jobLabels,
 		ControllerNamespace:          config.Namespace,
+		DefaultEngine:                config.DefaultEngine,
 		DefaultExecutorCPULimit:      config.ExecutorCPULimit,
 		DefaultExecutorCPURequest:    config.ExecutorCPURequest,
 		DefaultExecutorMemoryLimit:   config.ExecutorMemoryLimit,
 		DefaultExecutorMemoryRequest: config.ExecutorMemoryRequest,
+		EnableConfigurationHooks:     config.EnableConfigurationHooks,
 		EnableInfracosts:             (config.InfracostsSecretName != ""),
+		EnableNamespacedJobs:         config.EnableNamespacedJobs,
 		EnableTerraformVersions:      config.EnableTerraformVersions,
 		EnableWatchers:               config.EnableWatchers,
 		EnableWebhooks:               config.EnableWebhooks,
@@ -189,8 +303,18 @@ func New(cfg *rest.Config, config Config) (*Server, error) {
 		InfracostsImage:              config.InfracostsImage,
 		InfracostsSecretName:         config.InfracostsSecretName,
 		JobTemplate:                  config.JobTemplate,
+		LogStore:                     store,
+		OpenTofuBinaryPath:           config.OpenTofuBinaryPath,
+		OpenTofuImage:                config.OpenTofuImage,
+		OpenTofuVersions:             config.OpenTofuVersions,
 		PolicyImage:                  config.PolicyImage,
+		ProviderMirrorCASecret:       config.ProviderMirrorCASecret,
+		ProviderMirrorURL:            config.ProviderMirrorURL,
+		SourceAllowedHosts:           config.SourceAllowedHosts,
+		SourceAllowedSchemes:         config.SourceAllowedSchemes,
+		SourceSigningKeys:            config.SourceSigningKeys,
 		TerraformImage:               config.TerraformImage,
+		TerraformVersions:            config.TerraformVersions,
 	}).Add(mgr); err != nil {
 		return nil, fmt.Errorf("failed to create the configuration controller, error: %w", err)
 	}
@@ -265,11 +389,15 @@ func New(cfg *rest.Config, config Config) (*Server, error) {
 	}
 
 	return &Server{
-		cfg:      cfg,
-		config:   config,
-		hs:       hs,
-		listener: listener,
-		mgr:      mgr,
+		agentsListener: agentsListener,
+		as:             as,
+		cfg:            cfg,
+		config:         config,
+		hs:             hs,
+		listener:       listener,
+		mgr:            mgr,
+		mirrorListener: mirrorListener,
+		ms:             ms,
 	}, nil
 }
 
@@ -290,5 +418,30 @@ func (s *Server) Start(ctx context.Context) error {
 		}
 	}()
 
+	if s.ms != nil {
+		go func() {
+			log.WithField("url", s.config.ProviderMirrorURL).Info("starting the provider mirror")
+
+			err := s.ms.ServeTLS(s.mirrorListener,
+				filepath.Join(s.config.TLSDir, s.config.TLSCert),
+				filepath.Join(s.config.TLSDir, s.config.TLSKey),
+			)
+			if err != nil {
+				log.WithError(err).Fatal("trying to start the provider mirror")
+			}
+		}()
+	}
+
+	if s.as != nil {
+		go func() {
+			log.WithField("port", s.config.AgentsPort).Info("starting the agents server")
+
+			// @note: the certificates are provided by the tls configuration
+			if err := s.as.ServeTLS(s.agentsListener, "", ""); err != nil {
+				log.WithError(err).Fatal("trying to start the agents server")
+			}
+		}()
+	}
+
 	return s.mgr.Start(ctrl.SetupSignalHandler())
 }
@@ -21,19 +21,32 @@ import "time"
 
 // Config is the configuration for the controller
 type Config struct {
+	// AgentsPort is the port the remote execution agents connect to using mutual TLS
+	AgentsPort int
 	// APIServerPort is the port to listen on
 	APIServerPort int
+	// EnableAPIServerAuthentication indicates the api server requires a bearer token, which
+	// is validated via a TokenReview and authorized with a SubjectAccessReview
+	EnableAPIServerAuthentication bool
 	// BackendTemplate is the name of a secret in the controller namespace which
 	// contains an optional template to use for the backend state - unless this
 	// is set we use the default backend state i.e. kubernetes state
 	BackendTemplate string
 	// BackoffLimit is the number of times we are willing to allow a job to fail
 	BackoffLimit int
-	// BinaryPath is the name of the binary to use to run the terraform commands
+	// BuildLogsStore is the location of the store used to retain the logs of completed
+	// jobs i.e. kubernetes, file:///path or s3://bucket/prefix - empty disables retention
+	BuildLogsStore string
+	// BinaryPath is the name of the binary to use to run the commands for the terraform engine
 	BinaryPath string
+	// CacheVolume is the name of a persistent volume claim in the controller namespace used to
+	// cache module sources and provider plugins across jobs
+	CacheVolume string
 	// ConfigurationThreshold is the max number of configurations we are willing
 	// to run at the same time
 	ConfigurationThreshold float64
+	// DefaultEngine is the engine used when neither the configuration or provider defines one
+	DefaultEngine string
 	// DriftControllerInterval is the interval for the controller to check for drift
 	DriftControllerInterval time.Duration
 	// DriftInterval is the minimum interval between drift checks
@@ -41,6 +54,12 @@ type Config struct {
 	// DriftThreshold is the max number of drifts we are running to run - this prevents the
 	// controller from running many configurations at the same time
 	DriftThreshold float64
+	// EnableAgents enables the server used by remote execution agents to retrieve and report on
+	// the jobs for their providers
+	EnableAgents bool
+	// EnableConfigurationHooks indicates configurations are permitted to define their own hooks,
+	// as these run tenant supplied images within the jobs
+	EnableConfigurationHooks bool
 	// EnableContextInjection indicates the controller should always inject the context
 	// into the terraform variables - i.e. namespace and name under a terraform variable
 	// called 'terranetes'
@@ -51,6 +70,9 @@ type Config struct {
 	// EnableNamespaceProtection indicates the controller should protect the namespace
 	// from being deleted if there are any terranetes resources in the namespace
 	EnableNamespaceProtection bool
+	// EnableNamespacedJobs indicates the jobs, configuration secrets and state are placed in the
+	// namespace of the configuration rather than the controller namespace
+	EnableNamespacedJobs bool
 	// EnableWebhooks enables the webhooks registration
 	EnableWebhooks bool
 	// EnableWebhooksRegistration indicates the controller should register the webhooks
@@ -88,18 +110,45 @@ type Config struct {
 	Namespace string
 	// NamespaceFilters is the namespace/s to filter on
 	NamespaceFilters []string
+	// OpenTofuBinaryPath is the name of the binary to use to run the commands for the opentofu engine
+	OpenTofuBinaryPath string
+	// OpenTofuImage is the image to use for opentofu
+	OpenTofuImage string
+	// OpenTofuVersions is a collection of opentofu versions version constraints are resolved against
+	OpenTofuVersions []string
 	// PolicyImage is the image to use for policy
 	PolicyImage string
 	// PreloadImage is the image to use for the preload job
 	PreloadImage string
+	// ProviderMirrorCASecret is the name of a secret in the controller namespace containing the
+	// certificate authority (ca.pem) the jobs use to trust the provider mirror
+	ProviderMirrorCASecret string
+	// ProviderMirrorDir is the directory holding the provider mirror, enabling the mirror
+	ProviderMirrorDir string
+	// ProviderMirrorPort is the port the provider mirror is served on using TLS
+	ProviderMirrorPort int
+	// ProviderMirrorSigningKeys is the path to the ascii armored keys used by the providers to
+	// sign their checksums, archives pushed into the mirror are verified against these keys
+	ProviderMirrorSigningKeys string
+	// ProviderMirrorURL overrides the location of the provider mirror used by the jobs
+	ProviderMirrorURL string
 	// RegisterCRDs indicated we register our crds
 	RegisterCRDs bool
 	// ResyncPeriod is the period to resync the controller manager
 	ResyncPeriod time.Duration
 	// RevisionExpiration is the duration before a revision is expired
 	RevisionExpiration time.Duration
+	// SourceAllowedHosts is a collection of hosts module sources are permitted to be retrieved from
+	SourceAllowedHosts []string
+	// SourceAllowedSchemes is a collection of schemes module sources are permitted to use
+	SourceAllowedSchemes []string
+	// SourceSigningKeys is the name of the secret containing the public keys used to verify
+	// the signature of git module sources
+	SourceSigningKeys string
 	// TerraformImage is the image to use for terraform
 	TerraformImage string
+	// TerraformVersions is a collection of terraform versions version constraints are resolved against
+	TerraformVersions []string
 	// TLSDir is the directory where the TLS certificates are stored
 	TLSDir string
 	// TLSAuthority is the path to the ca certificate
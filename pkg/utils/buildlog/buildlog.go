@@ -0,0 +1,204 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package buildlog
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// RecordLog is a line of output from a container
+	RecordLog = "log"
+	// RecordContainer is a marker indicating the output of a container is starting
+	RecordContainer = "container"
+	// RecordStep is a marker indicating a step within the container is starting
+	RecordStep = "step"
+	// RecordStatus is a status message from the controller i.e. waiting on the job
+	RecordStatus = "status"
+)
+
+const (
+	// LevelDebug is the debug severity
+	LevelDebug = "debug"
+	// LevelInfo is the info severity
+	LevelInfo = "info"
+	// LevelWarn is the warning severity
+	LevelWarn = "warn"
+	// LevelError is the error severity
+	LevelError = "error"
+)
+
+// Record is a structured entry within the build logs
+type Record struct {
+	// Type is the type of record i.e. log, container, step or status
+	Type string `json:"type"`
+	// Stage is the stage of the build i.e. plan, apply or destroy
+	Stage string `json:"stage,omitempty"`
+	// Container is the name of the container which produced the output
+	Container string `json:"container,omitempty"`
+	// Step is the step within the container which produced the output
+	Step string `json:"step,omitempty"`
+	// Level is the severity of the record
+	Level string `json:"level"`
+	// Message is the content of the record
+	Message string `json:"message"`
+	// Time is the time the record was produced
+	Time time.Time `json:"time"`
+}
+
+// terraformRecord is a line of terraform machine-readable (-json) output
+type terraformRecord struct {
+	// Level is the severity of the message
+	Level string `json:"@level"`
+	// Message is the human readable message
+	Message string `json:"@message"`
+	// Timestamp is the time the message was produced
+	Timestamp string `json:"@timestamp"`
+}
+
+var (
+	// bannerRegex matches the lines surrounding a step comment
+	bannerRegex = regexp.MustCompile(`^={10,}$`)
+	// errorRegex matches lines which indicate an error
+	errorRegex = regexp.MustCompile(`(?i)^(│\s*)?(\[error\]|error:)|level=(error|fatal|panic)\b`)
+	// warnRegex matches lines which indicate a warning
+	warnRegex = regexp.MustCompile(`(?i)^(│\s*)?(\[warn(ing)?\]|warning:)|level=warn(ing)?\b`)
+)
+
+// Parser converts the output of a container into records, tracking the current step
+type Parser struct {
+	// container is the name of the container
+	container string
+	// stage is the stage of the build
+	stage string
+	// step is the current step within the container
+	step string
+	// timestamps indicates the lines are prefixed with a RFC3339 timestamp
+	timestamps bool
+	// banner tracks our position within a step banner
+	banner int
+}
+
+// NewParser returns a parser for the output of a container. When timestamps is true
+// the lines are expected to be prefixed by the timestamp added by the kubelet
+func NewParser(stage, container string, timestamps bool) *Parser {
+	return &Parser{container: container, stage: stage, timestamps: timestamps}
+}
+
+// Container returns the marker for the start of the container output
+func (p *Parser) Container() Record {
+	return p.record(RecordContainer, LevelInfo, p.container, time.Now())
+}
+
+// Parse converts a line of output into a record; false is returned when the line is
+// consumed by the parser i.e. the lines surrounding a step banner
+func (p *Parser) Parse(line string) (Record, bool) {
+	now := time.Now()
+	if p.timestamps {
+		if i := strings.IndexByte(line, ' '); i > 0 {
+			if ts, err := time.Parse(time.RFC3339Nano, line[:i]); err == nil {
+				now, line = ts, line[i+1:]
+			}
+		}
+	}
+	line = strings.TrimRight(line, "\r\n")
+
+	// @step: the step comments are surrounded by a banner
+	isBanner := bannerRegex.MatchString(strings.TrimSpace(line))
+	switch {
+	case p.banner == 0 && isBanner:
+		p.banner = 1
+
+		return Record{}, false
+
+	case p.banner == 1 && isBanner:
+		p.banner = 0
+
+		return Record{}, false
+
+	case p.banner == 1:
+		p.banner = 2
+		p.step = strings.TrimSpace(line)
+
+		return p.record(RecordStep, LevelInfo, p.step, now), true
+
+	case p.banner == 2 && isBanner:
+		p.banner = 0
+
+		return Record{}, false
+
+	case p.banner == 2:
+		p.banner = 0
+	}
+
+	// @step: use terraform machine-readable output where available
+	if strings.HasPrefix(line, "{") {
+		tr := &terraformRecord{}
+		if err := json.Unmarshal([]byte(line), tr); err == nil && tr.Message != "" {
+			if ts, err := time.Parse(time.RFC3339Nano, tr.Timestamp); err == nil {
+				now = ts
+			}
+
+			return p.record(RecordLog, normalizeLevel(tr.Level), tr.Message, now), true
+		}
+	}
+
+	return p.record(RecordLog, DetectLevel(line), line, now), true
+}
+
+// record returns a record for the current position
+func (p *Parser) record(kind, level, message string, now time.Time) Record {
+	return Record{
+		Type:      kind,
+		Stage:     p.stage,
+		Container: p.container,
+		Step:      p.step,
+		Level:     level,
+		Message:   message,
+		Time:      now.UTC(),
+	}
+}
+
+// DetectLevel returns the severity of a plain text line of output
+func DetectLevel(line string) string {
+	switch {
+	case errorRegex.MatchString(line):
+		return LevelError
+	case warnRegex.MatchString(line):
+		return LevelWarn
+	}
+
+	return LevelInfo
+}
+
+// normalizeLevel converts the terraform severity into our own
+func normalizeLevel(level string) string {
+	switch strings.ToLower(level) {
+	case "error":
+		return LevelError
+	case "warn", "warning":
+		return LevelWarn
+	case "debug", "trace":
+		return LevelDebug
+	}
+
+	return LevelInfo
+}
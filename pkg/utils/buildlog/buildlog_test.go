@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package buildlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseAll returns the records produced by the lines
+func parseAll(p *Parser, lines ...string) []Record {
+	var list []Record
+	for _, line := range lines {
+		if record, ok := p.Parse(line); ok {
+			list = append(list, record)
+		}
+	}
+
+	return list
+}
+
+func TestParserContainer(t *testing.T) {
+	record := NewParser("plan", "setup", false).Container()
+	assert.Equal(t, RecordContainer, record.Type)
+	assert.Equal(t, "plan", record.Stage)
+	assert.Equal(t, "setup", record.Container)
+	assert.Equal(t, "setup", record.Message)
+}
+
+func TestParserSteps(t *testing.T) {
+	list := parseAll(NewParser("plan", "terraform", false),
+		"",
+		"=======================================================",
+		"EXECUTING TERRAFORM",
+		"=======================================================",
+		"Plan: 1 to add, 0 to change, 0 to destroy.",
+	)
+	require.Len(t, list, 3)
+
+	assert.Equal(t, RecordLog, list[0].Type)
+	assert.Equal(t, "", list[0].Step)
+	assert.Equal(t, RecordStep, list[1].Type)
+	assert.Equal(t, "EXECUTING TERRAFORM", list[1].Message)
+	assert.Equal(t, "EXECUTING TERRAFORM", list[1].Step)
+	assert.Equal(t, RecordLog, list[2].Type)
+	assert.Equal(t, "EXECUTING TERRAFORM", list[2].Step)
+	assert.Equal(t, "Plan: 1 to add, 0 to change, 0 to destroy.", list[2].Message)
+}
+
+func TestParserTimestamps(t *testing.T) {
+	record, ok := NewParser("plan", "terraform", true).Parse("2023-01-02T03:04:05.123456789Z hello world")
+	require.True(t, ok)
+	assert.Equal(t, "hello world", record.Message)
+	assert.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 123456789, time.UTC), record.Time)
+
+	record, ok = NewParser("plan", "terraform", true).Parse("not a timestamp")
+	require.True(t, ok)
+	assert.Equal(t, "not a timestamp", record.Message)
+}
+
+func TestParserTerraformJSON(t *testing.T) {
+	line := `{"@level":"error","@message":"Error: Invalid reference","@module":"terraform.ui","@timestamp":"2023-01-02T03:04:05.000000Z","type":"diagnostic"}`
+
+	record, ok := NewParser("plan", "terraform", false).Parse(line)
+	require.True(t, ok)
+	assert.Equal(t, LevelError, record.Level)
+	assert.Equal(t, "Error: Invalid reference", record.Message)
+	assert.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), record.Time)
+
+	record, ok = NewParser("plan", "terraform", false).Parse(`{"not":"terraform"}`)
+	require.True(t, ok)
+	assert.Equal(t, `{"not":"terraform"}`, record.Message)
+	assert.Equal(t, LevelInfo, record.Level)
+}
+
+func TestDetectLevel(t *testing.T) {
+	cases := map[string]string{
+		"Plan: 1 to add":                          LevelInfo,
+		"Error: Invalid reference":                LevelError,
+		"│ Error: Unsupported argument":           LevelError,
+		"[Error] command failed":                  LevelError,
+		`time="now" level=error msg="failed"`:     LevelError,
+		"Warning: Argument is deprecated":         LevelWarn,
+		"│ Warning: Deprecated":                   LevelWarn,
+		`time="now" level=warning msg="retrying"`: LevelWarn,
+		`time="now" level=info msg="waiting"`:     LevelInfo,
+	}
+	for line, expected := range cases {
+		assert.Equal(t, expected, DetectLevel(line), "line: %s", line)
+	}
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/appvia/terranetes-controller/pkg/utils"
+)
+
+// ErrNotCacheable indicates the source does not reference an immutable version and cannot be cached
+var ErrNotCacheable = errors.New("source is not cacheable")
+
+// commitRegex matches a full git commit sha (sha1 or sha256)
+var commitRegex = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// Key returns the content address for a resolved source (i.e. git::https://github.com/org/repo?ref=<sha>).
+// Git sources are only cacheable when pinned to a full commit sha, as branches and tags can be
+// moved, while other sources must carry a checksum; any credentials embedded in the query are
+// excluded from the key.
+func Key(source string) (string, error) {
+	if source == "" {
+		return "", errors.New("source is empty")
+	}
+
+	resolved := source
+	var pinned bool
+	if index := strings.Index(source, "?"); index >= 0 {
+		query, err := url.ParseQuery(source[index+1:])
+		if err != nil {
+			return "", fmt.Errorf("failed to parse the source query: %w", err)
+		}
+		query.Del("sshkey")
+		switch strings.HasPrefix(source, "git::") {
+		case true:
+			pinned = commitRegex.MatchString(query.Get("ref"))
+		default:
+			pinned = query.Get("checksum") != ""
+		}
+
+		resolved = source[:index]
+		if len(query) > 0 {
+			// note: Encode sorts the parameters so the ordering is irrelevant
+			resolved = resolved + "?" + query.Encode()
+		}
+	}
+
+	if !pinned {
+		return "", ErrNotCacheable
+	}
+	sum := sha256.Sum256([]byte(resolved))
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Cache is a content addressed store of module sources, held on a volume shared by the jobs
+type Cache struct {
+	// Directory is the root of the cache
+	Directory string
+}
+
+// New returns a cache rooted at the directory
+func New(directory string) *Cache {
+	return &Cache{Directory: directory}
+}
+
+// Lookup returns the location of the cached source if present
+func (c *Cache) Lookup(key string) (string, bool) {
+	location := filepath.Join(c.Directory, key)
+
+	found, err := utils.DirExists(location)
+	if err != nil || !found {
+		return "", false
+	}
+
+	return location, true
+}
+
+// Store copies the source into the cache. The copy is written to a temporary directory within
+// the cache and renamed into place, so concurrent jobs never observe a partial entry.
+func (c *Cache) Store(key, source string) error {
+	if _, found := c.Lookup(key); found {
+		return nil
+	}
+	if err := os.MkdirAll(c.Directory, 0755); err != nil {
+		return fmt.Errorf("failed to create the cache directory: %w", err)
+	}
+
+	temporary, err := os.MkdirTemp(c.Directory, ".tmp-")
+	if err != nil {
+		return fmt.Errorf("failed to create a temporary directory in the cache: %w", err)
+	}
+	defer os.RemoveAll(temporary)
+
+	if err := CopyDir(source, temporary); err != nil {
+		return fmt.Errorf("failed to copy the source into the cache: %w", err)
+	}
+	if err := os.Chmod(temporary, 0755); err != nil {
+		return err
+	}
+
+	if err := os.Rename(temporary, filepath.Join(c.Directory, key)); err != nil {
+		// another job may have stored the same source in the meantime
+		if _, found := c.Lookup(key); found {
+			return nil
+		}
+
+		return fmt.Errorf("failed to move the source into the cache: %w", err)
+	}
+
+	return nil
+}
+
+// CopyDir recursively copies the contents of the source directory into the destination
+func CopyDir(source, destination string) error {
+	return filepath.WalkDir(source, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(source, name)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destination, relative)
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+
+		case entry.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(name)
+			if err != nil {
+				return err
+			}
+
+			return os.Symlink(link, target)
+
+		case entry.Type().IsRegular():
+			return copyFile(name, target, info.Mode().Perm())
+		}
+
+		return nil
+	})
+}
+
+// copyFile copies a regular file preserving the permissions
+func copyFile(source, destination string, mode fs.FileMode) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(destination, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+
+		return err
+	}
+
+	return out.Close()
+}
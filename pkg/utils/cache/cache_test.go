@@ -0,0 +1,128 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	cases := []struct {
+		Source   string
+		Expected string
+		Error    error
+	}{
+		{
+			Source: "git::https://github.com/appvia/terraform-aws-vpc",
+			Error:  ErrNotCacheable,
+		},
+		{
+			Source: "git::https://github.com/appvia/terraform-aws-vpc?depth=1",
+			Error:  ErrNotCacheable,
+		},
+		{
+			Source: "git::https://github.com/appvia/terraform-aws-vpc?ref=v1.0.0",
+			Error:  ErrNotCacheable,
+		},
+		{
+			Source: "git::https://github.com/appvia/terraform-aws-vpc?ref=main",
+			Error:  ErrNotCacheable,
+		},
+		{
+			Source: "git::https://github.com/appvia/terraform-aws-vpc?ref=0a1b2c3",
+			Error:  ErrNotCacheable,
+		},
+		{
+			Source:   "git::https://github.com/appvia/terraform-aws-vpc?ref=0a1b2c3d4e5f60718293a4b5c6d7e8f901234567",
+			Expected: "git::https://github.com/appvia/terraform-aws-vpc?ref=0a1b2c3d4e5f60718293a4b5c6d7e8f901234567",
+		},
+		{
+			Source:   "git::https://github.com/appvia/terraform-aws-vpc?sshkey=c2VjcmV0&ref=0a1b2c3d4e5f60718293a4b5c6d7e8f901234567",
+			Expected: "git::https://github.com/appvia/terraform-aws-vpc?ref=0a1b2c3d4e5f60718293a4b5c6d7e8f901234567",
+		},
+		{
+			Source:   "git::https://github.com/appvia/terraform-aws-vpc?ref=0a1b2c3d4e5f60718293a4b5c6d7e8f901234567&depth=1",
+			Expected: "git::https://github.com/appvia/terraform-aws-vpc?depth=1&ref=0a1b2c3d4e5f60718293a4b5c6d7e8f901234567",
+		},
+		{
+			Source: "https://example.com/module.zip",
+			Error:  ErrNotCacheable,
+		},
+		{
+			Source: "s3::https://s3.amazonaws.com/bucket/module.zip?version=2",
+			Error:  ErrNotCacheable,
+		},
+		{
+			Source:   "https://example.com/module.zip?checksum=sha256:abcd",
+			Expected: "https://example.com/module.zip?checksum=sha256:abcd",
+		},
+	}
+	for _, c := range cases {
+		key, err := Key(c.Source)
+		if c.Error != nil {
+			assert.ErrorIs(t, err, c.Error, c.Source)
+
+			continue
+		}
+		require.NoError(t, err, c.Source)
+
+		expected, err := Key(c.Expected)
+		require.NoError(t, err)
+		assert.Equal(t, expected, key, c.Source)
+		assert.Len(t, key, 64)
+	}
+
+	_, err := Key("")
+	assert.Error(t, err)
+}
+
+func TestCache(t *testing.T) {
+	source := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(source, "modules", "network"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "main.tf"), []byte("# main"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "modules", "network", "main.tf"), []byte("# network"), 0600))
+	require.NoError(t, os.Symlink("main.tf", filepath.Join(source, "link.tf")))
+
+	store := New(filepath.Join(t.TempDir(), "modules"))
+
+	_, found := store.Lookup("key")
+	assert.False(t, found)
+
+	require.NoError(t, store.Store("key", source))
+	location, found := store.Lookup("key")
+	require.True(t, found)
+
+	content, err := os.ReadFile(filepath.Join(location, "modules", "network", "main.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, "# network", string(content))
+
+	link, err := os.Readlink(filepath.Join(location, "link.tf"))
+	require.NoError(t, err)
+	assert.Equal(t, "main.tf", link)
+
+	// storing again is a no-op
+	require.NoError(t, store.Store("key", source))
+
+	entries, err := os.ReadDir(store.Directory)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
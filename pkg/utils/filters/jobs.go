@@ -34,6 +34,7 @@ type Filter struct {
 	list       *batchv1.JobList
 	labels     map[string]string
 	stage      string
+	without    []string
 }
 
 // Jobs providers a filter for jobs
@@ -78,6 +79,13 @@ func (j *Filter) WithLabel(name, value string) *Filter {
 	return j
 }
 
+// WithoutLabel filters out any jobs carrying the label
+func (j *Filter) WithoutLabel(name string) *Filter {
+	j.without = append(j.without, name)
+
+	return j
+}
+
 // WithNamespace filters on the configuration namespace
 func (j *Filter) WithNamespace(namespace string) *Filter {
 	j.namespace = namespace
@@ -145,6 +153,9 @@ func (j *Filter) String() string {
 	for k, v := range j.labels {
 		list = append(list, "label="+k+":"+v)
 	}
+	for _, k := range j.without {
+		list = append(list, "without="+k)
+	}
 
 	return strings.Join(list, ",")
 }
@@ -170,6 +181,8 @@ func (j *Filter) List() (*batchv1.JobList, bool) {
 			continue
 		case j.uid != "" && labels[terraformv1alpha1.ConfigurationUIDLabel] != j.uid:
 			continue
+		case hasAnyLabel(labels, j.without):
+			continue
 		case len(j.labels) > 0:
 			missing := func() bool {
 				for k, v := range j.labels {
@@ -189,3 +202,14 @@ func (j *Filter) List() (*batchv1.JobList, bool) {
 
 	return list, len(list.Items) > 0
 }
+
+// hasAnyLabel returns true if any of the labels are present
+func hasAnyLabel(labels map[string]string, names []string) bool {
+	for _, name := range names {
+		if _, found := labels[name]; found {
+			return true
+		}
+	}
+
+	return false
+}
@@ -90,6 +90,15 @@ func TestFilterList(t *testing.T) {
 					},
 				},
 			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						terraformv1alpha1.ConfigurationGenerationLabel: "2",
+						terraformv1alpha1.ConfigurationStageLabel:      "plan",
+						terraformv1alpha1.JobPreviewVersionLabel:       "1.6.0",
+					},
+				},
+			},
 		},
 	}
 
@@ -101,8 +110,20 @@ func TestFilterList(t *testing.T) {
 	}{
 		{
 			Func:     Jobs(&list).WithStage("plan").List,
+			Expected: 3,
+		},
+		{
+			Func:     Jobs(&list).WithStage("plan").WithoutLabel(terraformv1alpha1.JobPreviewVersionLabel).List,
 			Expected: 2,
 		},
+		{
+			Func:     Jobs(&list).WithStage("plan").WithGeneration("2").WithoutLabel(terraformv1alpha1.JobPreviewVersionLabel).List,
+			Expected: 1,
+		},
+		{
+			Func:     Jobs(&list).WithLabel(terraformv1alpha1.JobPreviewVersionLabel, "1.6.0").List,
+			Expected: 1,
+		},
 		{
 			Func:     Jobs(&list).WithStage("plan").WithGeneration(one).List,
 			Expected: 1,
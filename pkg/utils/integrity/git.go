@@ -0,0 +1,117 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package integrity
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// AllowedSignersFile is the name of the file within the keys directory containing the ssh
+// public keys permitted to sign, in the format expected by ssh-keygen
+const AllowedSignersFile = "allowed_signers"
+
+// VerifyGitSignature verifies the tag, when the reference is an annotated tag, or else the
+// commit checked out in the repository was signed by one of the keys in the keys directory.
+// The directory can contain an allowed_signers file for ssh signatures and any number of
+// armored gpg public keys (*.asc, *.gpg).
+func VerifyGitSignature(ctx context.Context, repository, reference, keys string) error {
+	var options []string
+	var environment []string
+	var found bool
+
+	// @step: configure the ssh allowed signers if present
+	signers := filepath.Join(keys, AllowedSignersFile)
+	if _, err := os.Stat(signers); err == nil {
+		options = append(options, "-c", "gpg.ssh.allowedSignersFile="+signers)
+		found = true
+	}
+
+	// @step: import any gpg keys into a dedicated keyring
+	gpgKeys, err := findGPGKeys(keys)
+	if err != nil {
+		return err
+	}
+	if len(gpgKeys) > 0 {
+		home, err := os.MkdirTemp("", "gnupg")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(home)
+
+		environment = append(environment, "GNUPGHOME="+home)
+		args := append([]string{"--batch", "--import"}, gpgKeys...)
+		if out, err := run(ctx, "", environment, "gpg", args...); err != nil {
+			return fmt.Errorf("failed to import the gpg keys: %s", out)
+		}
+		found = true
+	}
+
+	if !found {
+		return fmt.Errorf("%w: no trusted keys found in %s", ErrVerification, keys)
+	}
+
+	// @step: determine if we are verifying an annotated tag or the commit
+	args := append(options, "verify-commit", "HEAD")
+	if reference != "" {
+		if kind, err := run(ctx, repository, nil, "git", "cat-file", "-t", "refs/tags/"+reference); err == nil && kind == "tag" {
+			args = append(options, "verify-tag", reference)
+		}
+	}
+
+	if out, err := run(ctx, repository, environment, "git", args...); err != nil {
+		return fmt.Errorf("%w: signature of %s could not be verified: %s", ErrVerification, args[len(args)-1], out)
+	}
+
+	return nil
+}
+
+// findGPGKeys returns the gpg public keys in the directory
+func findGPGKeys(dir string) ([]string, error) {
+	var list []string
+
+	for _, pattern := range []string{"*.asc", "*.gpg"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, matches...)
+	}
+
+	return list, nil
+}
+
+// run executes the command and returns the combined output
+func run(ctx context.Context, dir string, environment []string, name string, args ...string) (string, error) {
+	buffer := &bytes.Buffer{}
+
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), environment...)
+	cmd.Stdout = buffer
+	cmd.Stderr = buffer
+
+	err := cmd.Run()
+
+	return strings.TrimSpace(buffer.String()), err
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package integrity
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSignedRepository creates a repository with a commit and an annotated tag signed by
+// a freshly generated ssh key, returning the repository and the public key
+func newSignedRepository(t *testing.T) (string, string) {
+	for _, binary := range []string{"git", "ssh-keygen"} {
+		if _, err := exec.LookPath(binary); err != nil {
+			t.Skipf("%s is not available", binary)
+		}
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	key := filepath.Join(t.TempDir(), "id_ed25519")
+
+	_, err := run(ctx, "", nil, "ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key)
+	require.NoError(t, err)
+	public, err := os.ReadFile(key + ".pub")
+	require.NoError(t, err)
+
+	config := []string{
+		"-c", "user.name=test", "-c", "user.email=test@example.com",
+		"-c", "gpg.format=ssh", "-c", "user.signingkey=" + key,
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte("resource {}"), 0600))
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "main.tf"},
+		append(config, "commit", "-q", "-S", "-m", "signed"),
+		append(config, "tag", "-s", "-m", "signed", "v1.0.0"),
+		append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, "tag", "-a", "-m", "unsigned", "v2.0.0"),
+	} {
+		out, err := run(ctx, dir, nil, "git", args...)
+		require.NoError(t, err, out)
+	}
+
+	return dir, string(public)
+}
+
+func TestVerifyGitSignature(t *testing.T) {
+	repository, public := newSignedRepository(t)
+	ctx := context.Background()
+
+	trusted := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(trusted, AllowedSignersFile), []byte("test@example.com "+public), 0600))
+
+	assert.NoError(t, VerifyGitSignature(ctx, repository, "", trusted))
+	assert.NoError(t, VerifyGitSignature(ctx, repository, "v1.0.0", trusted))
+
+	err := VerifyGitSignature(ctx, repository, "v2.0.0", trusted)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrVerification))
+}
+
+func TestVerifyGitSignatureUntrusted(t *testing.T) {
+	repository, _ := newSignedRepository(t)
+	_, other := newSignedRepository(t)
+
+	untrusted := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(untrusted, AllowedSignersFile), []byte("test@example.com "+other), 0600))
+
+	err := VerifyGitSignature(context.Background(), repository, "", untrusted)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrVerification))
+}
+
+func TestVerifyGitSignatureNoKeys(t *testing.T) {
+	err := VerifyGitSignature(context.Background(), t.TempDir(), "", t.TempDir())
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrVerification))
+}
@@ -0,0 +1,212 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package integrity
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// ErrVerification indicates the module source failed an integrity check
+var ErrVerification = errors.New("source verification failed")
+
+// TreeChecksumPrefix is the prefix for a checksum computed over the module contents
+const TreeChecksumPrefix = "h1:"
+
+// forcedRegexp is the same expression go-getter uses to find a forced getter (i.e. git::)
+var forcedRegexp = regexp.MustCompile(`^([A-Za-z0-9]+)::(.+)$`)
+
+// checksumRegexp validates the file checksums supported by go-getter
+var checksumRegexp = regexp.MustCompile(`^(md5|sha1|sha256|sha512):[a-fA-F0-9]+$`)
+
+// IsTreeChecksum returns true if the checksum is computed over the module contents rather
+// than the downloaded file
+func IsTreeChecksum(checksum string) bool {
+	return strings.HasPrefix(checksum, TreeChecksumPrefix)
+}
+
+// ValidateChecksum checks the checksum is in a supported format
+func ValidateChecksum(checksum string) error {
+	switch {
+	case checksum == "":
+		return errors.New("checksum is empty")
+	case IsTreeChecksum(checksum):
+		if len(checksum) == len(TreeChecksumPrefix) {
+			return errors.New("checksum is missing the hash")
+		}
+
+		return nil
+	case checksumRegexp.MatchString(checksum):
+		return nil
+	}
+
+	return fmt.Errorf("checksum %q must be h1:<hash> or <md5|sha1|sha256|sha512>:<hex>", checksum)
+}
+
+// ExtractTreeChecksum removes a tree checksum from the query of the source and returns it. File
+// checksums are left in place to be verified by go-getter.
+func ExtractTreeChecksum(source string) (string, string, error) {
+	index := strings.Index(source, "?")
+	if index < 0 {
+		return source, "", nil
+	}
+
+	query, err := url.ParseQuery(source[index+1:])
+	if err != nil {
+		return "", "", err
+	}
+	checksum := query.Get("checksum")
+	if !IsTreeChecksum(checksum) {
+		return source, "", nil
+	}
+	query.Del("checksum")
+
+	if len(query) == 0 {
+		return source[:index], checksum, nil
+	}
+
+	return source[:index] + "?" + query.Encode(), checksum, nil
+}
+
+// Checksum computes a checksum over the contents of the directory, ignoring any .git directories.
+// The format is the same as used by go modules, so is stable across platforms.
+func Checksum(dir string) (string, error) {
+	var files []string
+
+	err := filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir() && entry.Name() == ".git":
+			return filepath.SkipDir
+		case !entry.Type().IsRegular():
+			return nil
+		}
+
+		relative, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(relative))
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	return dirhash.Hash1(files, func(name string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+	})
+}
+
+// VerifyChecksum computes the checksum of the directory and compares it to the expected value
+func VerifyChecksum(dir, expected string) error {
+	checksum, err := Checksum(dir)
+	if err != nil {
+		return err
+	}
+	if checksum != expected {
+		return fmt.Errorf("%w: checksum mismatch, expected: %s, got: %s", ErrVerification, expected, checksum)
+	}
+
+	return nil
+}
+
+// AllowList restricts the locations a module can be retrieved from
+type AllowList struct {
+	// Hosts is a collection of hostnames, which may be prefixed with a wildcard (i.e. *.example.com)
+	Hosts []string
+	// Schemes is a collection of getters (i.e. git, https, s3); the forced getter takes
+	// precedence over the url scheme, so git::https://... is matched as git
+	Schemes []string
+}
+
+// IsEmpty returns true if no restrictions are defined
+func (a AllowList) IsEmpty() bool {
+	return len(a.Hosts) == 0 && len(a.Schemes) == 0
+}
+
+// Verify checks the detected source (i.e. as returned from getter.Detect) is permitted
+func (a AllowList) Verify(source string) error {
+	if a.IsEmpty() {
+		return nil
+	}
+
+	forced, location := "", source
+	if matches := forcedRegexp.FindStringSubmatch(source); matches != nil {
+		forced, location = matches[1], matches[2]
+	}
+
+	uri, err := url.Parse(location)
+	if err != nil {
+		return fmt.Errorf("%w: unable to parse the source: %s", ErrVerification, err)
+	}
+	scheme := uri.Scheme
+	if forced != "" {
+		scheme = forced
+	}
+
+	if len(a.Schemes) > 0 && !a.isSchemeAllowed(scheme) {
+		return fmt.Errorf("%w: scheme %q is not in the allowed list: %s", ErrVerification, scheme, strings.Join(a.Schemes, ", "))
+	}
+	if len(a.Hosts) > 0 && !a.isHostAllowed(uri.Hostname()) {
+		return fmt.Errorf("%w: host %q is not in the allowed list: %s", ErrVerification, uri.Hostname(), strings.Join(a.Hosts, ", "))
+	}
+
+	return nil
+}
+
+// isSchemeAllowed checks the forced getter, else the url scheme, against the allowed list
+func (a AllowList) isSchemeAllowed(scheme string) bool {
+	for _, allowed := range a.Schemes {
+		if scheme != "" && strings.EqualFold(allowed, scheme) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isHostAllowed checks the hostname against the allowed list, permitting wildcards
+func (a AllowList) isHostAllowed(hostname string) bool {
+	if hostname == "" {
+		return false
+	}
+	hostname = strings.ToLower(hostname)
+
+	for _, allowed := range a.Hosts {
+		if matched, _ := path.Match(strings.ToLower(allowed), hostname); matched {
+			return true
+		}
+	}
+
+	return false
+}
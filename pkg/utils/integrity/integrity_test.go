@@ -0,0 +1,138 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package integrity
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateChecksum(t *testing.T) {
+	cases := []struct {
+		Checksum string
+		Valid    bool
+	}{
+		{Checksum: ""},
+		{Checksum: "h1:"},
+		{Checksum: "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=", Valid: true},
+		{Checksum: "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", Valid: true},
+		{Checksum: "md5:d41d8cd98f00b204e9800998ecf8427e", Valid: true},
+		{Checksum: "sha256:not-hex"},
+		{Checksum: "crc32:1234"},
+	}
+	for _, c := range cases {
+		err := ValidateChecksum(c.Checksum)
+		if c.Valid {
+			assert.NoError(t, err, "checksum: %q", c.Checksum)
+		} else {
+			assert.Error(t, err, "checksum: %q", c.Checksum)
+		}
+	}
+}
+
+func TestExtractTreeChecksum(t *testing.T) {
+	cases := []struct {
+		Source   string
+		Expected string
+		Checksum string
+	}{
+		{
+			Source:   "https://github.com/appvia/terranetes-controller.git",
+			Expected: "https://github.com/appvia/terranetes-controller.git",
+		},
+		{
+			Source:   "https://github.com/appvia/terranetes-controller.git?ref=v0.1.0&checksum=h1:abc",
+			Expected: "https://github.com/appvia/terranetes-controller.git?ref=v0.1.0",
+			Checksum: "h1:abc",
+		},
+		{
+			Source:   "git::https://github.com/appvia/terranetes-controller.git//module?checksum=h1:abc",
+			Expected: "git::https://github.com/appvia/terranetes-controller.git//module",
+			Checksum: "h1:abc",
+		},
+		{
+			Source:   "https://example.com/module.zip?checksum=sha256:abcdef",
+			Expected: "https://example.com/module.zip?checksum=sha256:abcdef",
+		},
+	}
+	for _, c := range cases {
+		source, checksum, err := ExtractTreeChecksum(c.Source)
+		assert.NoError(t, err)
+		assert.Equal(t, c.Expected, source)
+		assert.Equal(t, c.Checksum, checksum)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "modules", "bucket"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte("resource {}"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "modules", "bucket", "main.tf"), []byte("bucket"), 0600))
+
+	checksum, err := Checksum(dir)
+	require.NoError(t, err)
+	assert.True(t, IsTreeChecksum(checksum))
+	assert.NoError(t, VerifyChecksum(dir, checksum))
+
+	// @note: the git metadata should not change the checksum
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref: refs/heads/main"), 0600))
+	assert.NoError(t, VerifyChecksum(dir, checksum))
+
+	// @note: any change to the contents should
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte("changed"), 0600))
+	err = VerifyChecksum(dir, checksum)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrVerification))
+}
+
+func TestAllowListVerify(t *testing.T) {
+	list := AllowList{
+		Hosts:   []string{"github.com", "*.example.com"},
+		Schemes: []string{"git", "s3"},
+	}
+
+	cases := []struct {
+		Source  string
+		Allowed bool
+	}{
+		{Source: "git::https://github.com/appvia/terranetes-controller.git", Allowed: true},
+		{Source: "git::ssh://git@github.com/appvia/terranetes-controller.git", Allowed: true},
+		{Source: "git::https://git.example.com/team/module.git//sub?ref=v1", Allowed: true},
+		{Source: "s3::https://modules.example.com/bucket/module.zip", Allowed: true},
+		{Source: "https://github.com/appvia/terranetes-controller/archive/main.zip"},
+		{Source: "git::https://gitlab.com/team/module.git"},
+		{Source: "git::https://example.com/team/module.git"},
+		{Source: "file:///tmp/module"},
+	}
+	for _, c := range cases {
+		err := list.Verify(c.Source)
+		if c.Allowed {
+			assert.NoError(t, err, "source: %s", c.Source)
+		} else {
+			assert.Error(t, err, "source: %s", c.Source)
+			assert.True(t, errors.Is(err, ErrVerification))
+		}
+	}
+
+	assert.NoError(t, AllowList{}.Verify("file:///tmp/module"))
+}
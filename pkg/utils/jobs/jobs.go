@@ -39,9 +39,19 @@ import (
 // DefaultServiceAccount is the default service account to use for the job if no override is given
 const DefaultServiceAccount = "terranetes-executor"
 
+// WatcherServiceAccount is the service account used by the watcher jobs in the configuration
+// namespace, the api server only permits this service account to retrieve the build logs
+const WatcherServiceAccount = "terranetes-watcher"
+
 // TerraformContainerName is the default name for the main terraform container
 const TerraformContainerName = "terraform"
 
+// SetupContainerName is the name of the init container which retrieves the module source
+const SetupContainerName = "setup"
+
+// HookContainerPrefix is the prefix of the container name for the hooks
+const HookContainerPrefix = "hook-"
+
 // Options is the configuration for the render
 type Options struct {
 	// AdditionalJobAnnotations are additional annotations added to the job
@@ -55,6 +65,9 @@ type Options struct {
 	BackoffLimit int
 	// BinaryPath is the name of the binary to use to run the terraform commands
 	BinaryPath string
+	// CacheVolume is the name of a persistent volume claim used to cache module sources
+	// and provider plugins across jobs
+	CacheVolume string
 	// DefaultExecutorMemoryRequest is the default memory request for the executor
 	DefaultExecutorMemoryRequest string
 	// DefaultExecutorMemoryLimit is the default memory limit for the executor
@@ -63,24 +76,49 @@ type Options struct {
 	DefaultExecutorCPURequest string
 	// DefaultExecutorCPULimit is the default CPU limit for the executor
 	DefaultExecutorCPULimit string
+	// Engine is the engine executing the terraform commands, i.e. terraform or opentofu
+	Engine string
 	// EnableInfraCosts is the flag to enable cost analysis
 	EnableInfraCosts bool
 	// ExecutorImage is the image to use for the terraform jobs
 	ExecutorImage string
 	// ExecutorSecrets is a list of additional secrets to add to the job
 	ExecutorSecrets []string
+	// Hooks is a collection of hooks, those belonging to the stage are executed within the job
+	Hooks []terraformv1alpha1.Hook
 	// InfracostsImage is the image to use for infracosts
 	InfracostsImage string
 	// InfracostsSecret is the name of the secret contain the infracost token and url
 	InfracostsSecret string
 	// Namespace is the location of the jobs
 	Namespace string
+	// PodTemplateOverrides is a collection of partial pod templates strategically merged onto the
+	// pod template of the job, in order
+	PodTemplateOverrides [][]byte
 	// PolicyConstraint is a matching constraint for this policy
 	PolicyConstraint *terraformv1alpha1.PolicyConstraint
 	// PolicyImage is image to use for checkov
 	PolicyImage string
+	// Preview indicates the plan is previewing the configuration under another version of the
+	// engine, the plan and step results are written to the preview secrets
+	Preview bool
+	// ProviderMirrorCASecret is the name of a secret containing the certificate authority (ca.pem)
+	// used to trust the provider mirror
+	ProviderMirrorCASecret string
+	// ProviderMirrorURL is the location of a provider network mirror terraform should install
+	// the providers from
+	ProviderMirrorURL string
 	// SaveTerraformState indicates we should save the terraform state in a secret
 	SaveTerraformState bool
+	// SourceAllowedHosts is a collection of hosts the module source can be retrieved from
+	SourceAllowedHosts []string
+	// SourceAllowedSchemes is a collection of schemes the module source can be retrieved with
+	SourceAllowedSchemes []string
+	// SourceSigningKeys is the name of a secret containing the public keys used to verify the
+	// signature of git module sources
+	SourceSigningKeys string
+	// StateLockID is the id of the terraform state lock to release
+	StateLockID string
 	// Template is the source for the job template if overridden by the controller
 	Template []byte
 	// Image is the image to use for the terraform jobs
@@ -126,6 +164,7 @@ func (r *Render) NewJobWatch(namespace, stage string, executorImage string) *bat
 				terraformv1alpha1.ConfigurationNamespaceLabel:  r.configuration.Namespace,
 				terraformv1alpha1.ConfigurationStageLabel:      stage,
 				terraformv1alpha1.ConfigurationUIDLabel:        string(r.configuration.GetUID()),
+				terraformv1alpha1.JobWatcherLabel:              "true",
 			}),
 			OwnerReferences: []metav1.OwnerReference{
 				*metav1.NewControllerRef(r.configuration, r.configuration.GroupVersionKind()),
@@ -146,7 +185,8 @@ func (r *Render) NewJobWatch(namespace, stage string, executorImage string) *bat
 					}),
 				},
 				Spec: v1.PodSpec{
-					RestartPolicy: v1.RestartPolicyNever,
+					RestartPolicy:      v1.RestartPolicyNever,
+					ServiceAccountName: WatcherServiceAccount,
 					SecurityContext: &v1.PodSecurityContext{
 						RunAsGroup:   ptr.To(int64(65534)),
 						RunAsNonRoot: ptr.To(true),
@@ -194,6 +234,15 @@ func (r *Render) NewTerraformDestroy(options Options) (*batchv1.Job, error) {
 	return r.createTerraformFromTemplate(options, terraformv1alpha1.StageTerraformDestroy)
 }
 
+// NewTerraformUnlock is responsible for creating a batch job to release a terraform state lock
+func (r *Render) NewTerraformUnlock(options Options) (*batchv1.Job, error) {
+	if options.StateLockID == "" {
+		return nil, fmt.Errorf("state lock id is required")
+	}
+
+	return r.createTerraformFromTemplate(options, terraformv1alpha1.StageTerraformUnlock)
+}
+
 // createTerraformFromTemplate is used to render the terraform job from the parameters and the template
 func (r *Render) createTerraformFromTemplate(options Options, stage string) (*batchv1.Job, error) {
 	var arguments string
@@ -215,7 +264,7 @@ func (r *Render) createTerraformFromTemplate(options Options, stage string) (*ba
 			terraformv1alpha1.ConfigurationNamespaceLabel:  r.configuration.GetNamespace(),
 			terraformv1alpha1.ConfigurationStageLabel:      stage,
 			terraformv1alpha1.ConfigurationUIDLabel:        string(r.configuration.GetUID()),
-		}),
+		}, engineLabels(options.Engine)),
 		"BinaryPath":                   options.BinaryPath,
 		"DefaultExecutorMemoryRequest": options.DefaultExecutorMemoryRequest,
 		"DefaultExecutorMemoryLimit":   options.DefaultExecutorMemoryLimit,
@@ -229,6 +278,7 @@ func (r *Render) createTerraformFromTemplate(options Options, stage string) (*ba
 			"Source":         string(r.provider.Spec.Source),
 		},
 		"EnableInfraCosts":       options.EnableInfraCosts,
+		"EngineName":             terraform.EngineName(options.Engine),
 		"EnableVariables":        r.configuration.Spec.HasVariables(),
 		"EnableTFVars":           r.configuration.Spec.TFVars != "",
 		"ExecutorSecrets":        options.ExecutorSecrets,
@@ -237,15 +287,31 @@ func (r *Render) createTerraformFromTemplate(options Options, stage string) (*ba
 		"SaveTerraformState":     options.SaveTerraformState,
 		"ServiceAccount":         DefaultServiceAccount,
 		"Stage":                  stage,
+		"StateLockID":            options.StateLockID,
 		"TerraformArguments":     arguments,
+		"SetupContainerName":     SetupContainerName,
 		"TerraformContainerName": TerraformContainerName,
 		"Configuration": map[string]interface{}{
-			"Generation": fmt.Sprintf("%d", r.configuration.GetGeneration()),
-			"Module":     r.configuration.Spec.Module,
-			"Name":       r.configuration.Name,
-			"Namespace":  r.configuration.Namespace,
-			"UUID":       string(r.configuration.GetUID()),
-			"Variables":  r.configuration.Spec.Variables,
+			"Generation":     fmt.Sprintf("%d", r.configuration.GetGeneration()),
+			"Module":         r.configuration.Spec.Module,
+			"ModuleChecksum": r.configuration.Spec.ModuleChecksum,
+			"Name":           r.configuration.Name,
+			"Namespace":      r.configuration.Namespace,
+			"UUID":           string(r.configuration.GetUID()),
+			"Variables":      r.configuration.Spec.Variables,
+		},
+		"Cache": map[string]interface{}{
+			"Volume": options.CacheVolume,
+		},
+		"Hooks": hookParameters(options.Hooks, stage),
+		"Mirror": map[string]interface{}{
+			"CASecret": options.ProviderMirrorCASecret,
+			"URL":      options.ProviderMirrorURL,
+		},
+		"Source": map[string]interface{}{
+			"AllowedHosts":   options.SourceAllowedHosts,
+			"AllowedSchemes": options.SourceAllowedSchemes,
+			"SigningKeys":    options.SourceSigningKeys,
 		},
 		"Images": map[string]interface{}{
 			"Executor":   options.ExecutorImage,
@@ -259,12 +325,21 @@ func (r *Render) createTerraformFromTemplate(options Options, stage string) (*ba
 			"Infracosts":        options.InfracostsSecret,
 			"InfracostsReport":  r.configuration.GetTerraformCostSecretName(),
 			"PolicyReport":      r.configuration.GetTerraformPolicySecretName(),
+			"StepResults":       r.configuration.GetTerraformStepResultsSecretName(),
 			"TerraformPlanJSON": r.configuration.GetTerraformPlanJSONSecretName(),
 			"TerraformPlanOut":  r.configuration.GetTerraformPlanOutSecretName(),
 			"TerraformState":    r.configuration.GetTerraformStateSecretName(),
 		},
 	}
 
+	// @step: ensure a preview does not overwrite the plan awaiting approval
+	if options.Preview {
+		secrets := params["Secrets"].(map[string]interface{})
+		secrets["StepResults"] = r.configuration.GetTerraformPreviewStepResultsSecretName()
+		secrets["TerraformPlanJSON"] = r.configuration.GetTerraformPreviewPlanJSONSecretName()
+		secrets["TerraformPlanOut"] = r.configuration.GetTerraformPreviewPlanOutSecretName()
+	}
+
 	// @step: create the template and render
 	render, err := terraform.Template(string(options.Template), params)
 	if err != nil {
@@ -282,9 +357,76 @@ func (r *Render) createTerraformFromTemplate(options Options, stage string) (*ba
 		return nil, err
 	}
 
+	// @step: merge any overrides onto the pod template
+	if err := ApplyPodTemplateOverrides(&job.Spec.Template, options.PodTemplateOverrides...); err != nil {
+		return nil, err
+	}
+
+	// @step: jobs using a provider served by a remote agent are never run within this cluster,
+	// the agent pulls the job and reports the outcome back
+	if r.provider.HasAgent() {
+		job.Labels = utils.MergeStringMaps(job.Labels, map[string]string{
+			terraformv1alpha1.JobAgentLabel:    r.provider.Spec.Agent,
+			terraformv1alpha1.JobProviderLabel: r.provider.Name,
+		})
+		job.Spec.ManagedBy = ptr.To(terraformv1alpha1.JobAgentManager)
+		job.Spec.Suspend = ptr.To(true)
+	}
+
 	return job, nil
 }
 
+// engineLabels returns the labels used to record the engine which executed the job
+func engineLabels(engine string) map[string]string {
+	if engine == "" {
+		return nil
+	}
+
+	return map[string]string{terraformv1alpha1.ConfigurationEngineLabel: engine}
+}
+
+// hookParameters returns the template parameters for the hooks executed before and after the stage
+func hookParameters(hooks []terraformv1alpha1.Hook, stage string) map[string]interface{} {
+	pre := []map[string]interface{}{}
+	post := []map[string]interface{}{}
+
+	for _, hook := range hooks {
+		if hook.GetStage() != stage {
+			continue
+		}
+
+		// @note: the commands are quoted as the step binary parses them as csv
+		commands := make([]string, len(hook.Commands))
+		for i, command := range hook.Commands {
+			commands[i] = `"` + strings.ReplaceAll(command, `"`, `""`) + `"`
+		}
+
+		var timeout string
+		if hook.Timeout != nil {
+			timeout = hook.Timeout.Duration.String()
+		}
+
+		values := map[string]interface{}{
+			"Commands":        commands,
+			"ContinueOnError": hook.ContinueOnError,
+			"Image":           hook.Image,
+			"Name":            HookContainerPrefix + hook.Name,
+			"Retries":         hook.Retries,
+			"Timeout":         timeout,
+		}
+		if hook.IsPre() {
+			pre = append(pre, values)
+		} else {
+			post = append(post, values)
+		}
+	}
+
+	return map[string]interface{}{
+		"Pre":  pre,
+		"Post": post,
+	}
+}
+
 func TemplateHash(data []byte) (string, error) {
 	hash := sha256.New()
 	_, err := hash.Write(data)
@@ -1,9 +1,14 @@
 package jobs_test
 
 import (
+	"encoding/csv"
+	"strings"
 	"testing"
+	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -13,6 +18,18 @@ import (
 	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
 )
 
+// assertCommandsParse checks the commands of the step container can be parsed, as the step
+// binary parses the commands as csv
+func assertCommandsParse(t *testing.T, container v1.Container) {
+	for _, arg := range container.Args {
+		if !strings.HasPrefix(arg, "--command=") {
+			continue
+		}
+		_, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(arg, "--command="))).Read()
+		assert.NoError(t, err, arg)
+	}
+}
+
 func TestNewTerraformPlan(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -54,6 +71,225 @@ func TestNewTerraformPlan(t *testing.T) {
 				assert.NotContains(t, job.Spec.Template.Spec.Containers[0].Args[1], "--var-file variables.tfvars")
 			},
 		},
+		{
+			name: "When source verification is configured, the setup container is passed the checks",
+			conf: &v1alpha1.Configuration{
+				Spec: v1alpha1.ConfigurationSpec{
+					Module:         "git::https://github.com/appvia/terraform-aws-vpc?ref=v1.0.0",
+					ModuleChecksum: "h1:HmuZwJtuBsjXJ5IsZQsDh3PNwxVhz8WHgwJwHyZ0rVU=",
+				},
+			},
+			provider: &v1alpha1.Provider{},
+			opts: jobs.Options{
+				SourceAllowedHosts:   []string{"github.com", "*.example.com"},
+				SourceAllowedSchemes: []string{"git"},
+				SourceSigningKeys:    "signing-keys",
+				Template:             assets.MustAsset("job.yaml.tpl"),
+			},
+			checkJob: func(t *testing.T, job *batchv1.Job) {
+				setup := job.Spec.Template.Spec.InitContainers[0]
+				require.Equal(t, jobs.SetupContainerName, setup.Name)
+
+				var command string
+				for _, arg := range setup.Args {
+					if strings.HasPrefix(arg, "--command=/bin/source") {
+						command = arg
+					}
+				}
+				assert.Contains(t, command, "--checksum=h1:HmuZwJtuBsjXJ5IsZQsDh3PNwxVhz8WHgwJwHyZ0rVU=")
+				assert.Contains(t, command, "--allowed-host='github.com' --allowed-host='*.example.com'")
+				assert.Contains(t, command, "--allowed-scheme=git")
+				assert.Contains(t, command, "--signing-keys=/run/keys")
+
+				var found bool
+				for _, volume := range job.Spec.Template.Spec.Volumes {
+					if volume.Secret != nil && volume.Secret.SecretName == "signing-keys" {
+						found = true
+					}
+				}
+				assert.True(t, found, "Expected the signing keys volume")
+			},
+		},
+		{
+			name: "When a cache volume is configured, the jobs use the shared cache",
+			conf: &v1alpha1.Configuration{
+				Spec: v1alpha1.ConfigurationSpec{
+					Module: "git::https://github.com/appvia/terraform-aws-vpc?ref=v1.0.0",
+				},
+			},
+			provider: &v1alpha1.Provider{},
+			opts: jobs.Options{
+				CacheVolume: "terranetes-cache",
+				Template:    assets.MustAsset("job.yaml.tpl"),
+			},
+			checkJob: func(t *testing.T, job *batchv1.Job) {
+				var found bool
+				for _, volume := range job.Spec.Template.Spec.Volumes {
+					if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == "terranetes-cache" {
+						found = true
+					}
+				}
+				assert.True(t, found, "Expected the cache volume")
+
+				setup := job.Spec.Template.Spec.InitContainers[0]
+				assert.Contains(t, strings.Join(setup.Args, " "), "--cache=/cache/modules")
+
+				cache := job.Spec.Template.Spec.InitContainers[1]
+				assert.Equal(t, "cache", cache.Name)
+				assert.Contains(t, cache.Env, v1.EnvVar{Name: "TF_PLUGIN_CACHE_DIR", Value: "/cache/providers"})
+				assert.Contains(t, cache.VolumeMounts, v1.VolumeMount{Name: "cache", MountPath: "/cache"})
+
+				for _, container := range []v1.Container{job.Spec.Template.Spec.InitContainers[2], job.Spec.Template.Spec.Containers[0]} {
+					for _, env := range container.Env {
+						assert.NotContains(t, env.Name, "TF_PLUGIN_CACHE", container.Name)
+					}
+					assert.Contains(t, container.VolumeMounts, v1.VolumeMount{Name: "cache", MountPath: "/cache", ReadOnly: true}, container.Name)
+				}
+			},
+		},
+		{
+			name:     "When a provider mirror is configured, terraform is configured to use it",
+			conf:     &v1alpha1.Configuration{},
+			provider: &v1alpha1.Provider{},
+			opts: jobs.Options{
+				ProviderMirrorCASecret: "ca",
+				ProviderMirrorURL:      "https://controller.terraform-system.svc.cluster.local:10443/v1/providers/",
+				Template:               assets.MustAsset("job.yaml.tpl"),
+			},
+			checkJob: func(t *testing.T, job *batchv1.Job) {
+				setup := job.Spec.Template.Spec.InitContainers[0]
+				assert.Contains(t, setup.Args, `--command=printf 'provider_installation {\n  network_mirror {\n    url = \042%s\042\n  }\n}\n' https://controller.terraform-system.svc.cluster.local:10443/v1/providers/ > /data/.terraformrc`)
+				assertCommandsParse(t, setup)
+
+				var found bool
+				for _, volume := range job.Spec.Template.Spec.Volumes {
+					if volume.Secret != nil && volume.Secret.SecretName == "ca" {
+						found = true
+						assert.Equal(t, []v1.KeyToPath{{Key: "ca.pem", Path: "ca.pem"}}, volume.Secret.Items)
+					}
+				}
+				assert.True(t, found, "Expected the mirror certificate authority volume")
+
+				for _, container := range []v1.Container{job.Spec.Template.Spec.InitContainers[1], job.Spec.Template.Spec.Containers[0]} {
+					assert.Contains(t, container.Env, v1.EnvVar{Name: "TF_CLI_CONFIG_FILE", Value: "/data/.terraformrc"}, container.Name)
+					assert.Contains(t, container.Env, v1.EnvVar{Name: "SSL_CERT_DIR", Value: "/run/mirror"}, container.Name)
+					assert.Contains(t, container.VolumeMounts, v1.VolumeMount{Name: "mirror-ca", MountPath: "/run/mirror", ReadOnly: true}, container.Name)
+				}
+			},
+		},
+		{
+			name: "The steps record their results to the step results secret",
+			conf: &v1alpha1.Configuration{
+				ObjectMeta: metav1.ObjectMeta{UID: "1234"},
+			},
+			provider: &v1alpha1.Provider{},
+			opts: jobs.Options{
+				Template: assets.MustAsset("job.yaml.tpl"),
+			},
+			checkJob: func(t *testing.T, job *batchv1.Job) {
+				setup := job.Spec.Template.Spec.InitContainers[0]
+				terraform := job.Spec.Template.Spec.Containers[0]
+				assert.Contains(t, setup.Args, "--name=setup")
+				assert.Contains(t, terraform.Args, "--name=terraform")
+
+				for _, container := range []v1.Container{setup, terraform} {
+					assert.Contains(t, container.Args, "--results=/run/steps/results.json", container.Name)
+					assertCommandsParse(t, container)
+					assert.Contains(t, container.Args, "--results-secret=$(STEP_RESULTS_NAME)", container.Name)
+					assert.Contains(t, container.Env, v1.EnvVar{Name: "STEP_RESULTS_NAME", Value: "steps-1234"}, container.Name)
+					assert.Contains(t, container.Env, v1.EnvVar{
+						Name: "JOB_NAME",
+						ValueFrom: &v1.EnvVarSource{
+							FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.labels['job-name']"},
+						},
+					}, container.Name)
+				}
+			},
+		},
+		{
+			name:     "When hooks are defined, the hooks for the stage are added to the job",
+			conf:     &v1alpha1.Configuration{},
+			provider: &v1alpha1.Provider{},
+			opts: jobs.Options{
+				Hooks: []v1alpha1.Hook{
+					{
+						Name:     "lint",
+						When:     v1alpha1.HookPrePlan,
+						Image:    "ghcr.io/terraform-linters/tflint",
+						Commands: []string{"tflint --init", `tflint --format=compact --enable-rule="a,b": true`},
+						Retries:  2,
+						Timeout:  &metav1.Duration{Duration: time.Minute},
+					},
+					{
+						Name:            "notify",
+						When:            v1alpha1.HookPostPlan,
+						Image:           "curlimages/curl",
+						Commands:        []string{"curl -X POST https://example.com"},
+						ContinueOnError: true,
+					},
+					{
+						Name:     "smoke",
+						When:     v1alpha1.HookPostApply,
+						Image:    "curlimages/curl",
+						Commands: []string{"curl https://example.com"},
+					},
+				},
+				ExecutorSecrets: []string{"executor"},
+				Template:        assets.MustAsset("job.yaml.tpl"),
+			},
+			checkJob: func(t *testing.T, job *batchv1.Job) {
+				initContainers := job.Spec.Template.Spec.InitContainers
+				lint := initContainers[len(initContainers)-1]
+				assert.Equal(t, "hook-lint", lint.Name)
+				assert.Equal(t, "ghcr.io/terraform-linters/tflint", lint.Image)
+				assert.Equal(t, []string{"/run/bin/step"}, lint.Command)
+				assert.Equal(t, []string{
+					"--comment=Executing the hook-lint",
+					`--command="tflint --init"`,
+					`--command="tflint --format=compact --enable-rule=""a,b"": true"`,
+					"--command-timeout=1m0s",
+					"--retry-attempts=2",
+					"--retry-min-backoff=5s",
+					"--name=hook-lint",
+					"--namespace=$(KUBE_NAMESPACE)",
+					"--results=/run/steps/results.json",
+					"--results-secret=$(STEP_RESULTS_NAME)",
+				}, lint.Args)
+				assert.Empty(t, lint.EnvFrom)
+				assertCommandsParse(t, lint)
+
+				parsed, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(lint.Args[2], "--command="))).Read()
+				require.NoError(t, err)
+				assert.Equal(t, []string{`tflint --format=compact --enable-rule="a,b": true`}, parsed)
+
+				containers := job.Spec.Template.Spec.Containers
+				require.Len(t, containers, 2)
+				notify := containers[1]
+				assert.Equal(t, "hook-notify", notify.Name)
+				assert.Contains(t, notify.Args, "--continue-on-error")
+				assert.Contains(t, notify.Args, "--wait-on=/run/steps/terraform.complete")
+				assert.Contains(t, notify.Args, "--is-failure=/run/steps/terraform.failed")
+				assert.Empty(t, notify.EnvFrom)
+				assertCommandsParse(t, notify)
+			},
+		},
+		{
+			name:     "When no cache volume is configured, the cache is not used",
+			conf:     &v1alpha1.Configuration{},
+			provider: &v1alpha1.Provider{},
+			opts: jobs.Options{
+				Template: assets.MustAsset("job.yaml.tpl"),
+			},
+			checkJob: func(t *testing.T, job *batchv1.Job) {
+				for _, volume := range job.Spec.Template.Spec.Volumes {
+					assert.NotEqual(t, "cache", volume.Name)
+				}
+				assert.NotContains(t, strings.Join(job.Spec.Template.Spec.InitContainers[0].Args, " "), "--cache")
+				for _, container := range job.Spec.Template.Spec.InitContainers {
+					assert.NotEqual(t, "cache", container.Name)
+				}
+			},
+		},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -68,3 +304,188 @@ func TestNewTerraformPlan(t *testing.T) {
 		})
 	}
 }
+
+func TestNewTerraformUnlock(t *testing.T) {
+	render := jobs.New(&v1alpha1.Configuration{}, &v1alpha1.Provider{})
+
+	_, err := render.NewTerraformUnlock(jobs.Options{Template: assets.MustAsset("job.yaml.tpl")})
+	assert.Error(t, err)
+
+	job, err := render.NewTerraformUnlock(jobs.Options{
+		BinaryPath:  "terraform",
+		StateLockID: "3c2d1f6e-0e4a-5b7c-8d9e-1f2a3b4c5d6e",
+		Template:    assets.MustAsset("job.yaml.tpl"),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	assert.Equal(t, v1alpha1.StageTerraformUnlock, job.Labels[v1alpha1.ConfigurationStageLabel])
+	assert.Contains(t, job.Spec.Template.Spec.Containers[0].Args, "--command=terraform force-unlock -force 3c2d1f6e-0e4a-5b7c-8d9e-1f2a3b4c5d6e")
+}
+
+func TestNewTerraformPlanWithEngine(t *testing.T) {
+	configuration := &v1alpha1.Configuration{}
+	configuration.Name = "test"
+	configuration.Namespace = "default"
+	render := jobs.New(configuration, &v1alpha1.Provider{})
+
+	job, err := render.NewTerraformPlan(jobs.Options{
+		BinaryPath: "/usr/local/bin/tofu",
+		Engine:     v1alpha1.EngineOpenTofu,
+		Image:      "ghcr.io/opentofu/opentofu:1.8.5",
+		Namespace:  "terraform-system",
+		Template:   assets.MustAsset("job.yaml.tpl"),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	assert.Equal(t, v1alpha1.EngineOpenTofu, job.Labels[v1alpha1.ConfigurationEngineLabel])
+	assert.Contains(t, job.Spec.Template.Spec.Containers[0].Args, "--comment=Executing OpenTofu")
+
+	job, err = render.NewTerraformPlan(jobs.Options{
+		BinaryPath: "terraform",
+		Namespace:  "terraform-system",
+		Template:   assets.MustAsset("job.yaml.tpl"),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	assert.NotContains(t, job.Labels, v1alpha1.ConfigurationEngineLabel)
+	assert.Contains(t, job.Spec.Template.Spec.Containers[0].Args, "--comment=Executing Terraform")
+}
+
+func TestNewTerraformPlanWithPreview(t *testing.T) {
+	configuration := &v1alpha1.Configuration{}
+	configuration.Name = "test"
+	configuration.Namespace = "default"
+	configuration.UID = "1234-122-1234-1234"
+	render := jobs.New(configuration, &v1alpha1.Provider{})
+
+	job, err := render.NewTerraformPlan(jobs.Options{
+		BinaryPath: "terraform",
+		Namespace:  "terraform-system",
+		Preview:    true,
+		Template:   assets.MustAsset("job.yaml.tpl"),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	env := map[string]string{}
+	for _, x := range job.Spec.Template.Spec.Containers[0].Env {
+		env[x.Name] = x.Value
+	}
+	assert.Equal(t, "tfplan-json-preview-1234-122-1234-1234", env["TERRAFORM_PLAN_JSON_NAME"])
+	assert.Equal(t, "tfplan-out-preview-1234-122-1234-1234", env["TERRAFORM_PLAN_OUT_NAME"])
+	assert.Equal(t, "steps-preview-1234-122-1234-1234", env["STEP_RESULTS_NAME"])
+	assert.Equal(t, "tfstate-default-1234-122-1234-1234", env["TERRAFORM_STATE_NAME"])
+}
+
+func TestNewTerraformPlanWithPodTemplateOverrides(t *testing.T) {
+	configuration := &v1alpha1.Configuration{}
+	configuration.Name = "test"
+	configuration.Namespace = "default"
+	render := jobs.New(configuration, &v1alpha1.Provider{})
+
+	policy := []byte(`{
+		"spec": {
+			"nodeSelector": {"pool": "terraform"},
+			"priorityClassName": "low",
+			"tolerations": [{"key": "dedicated", "operator": "Equal", "value": "terraform", "effect": "NoSchedule"}]
+		}
+	}`)
+	provider := []byte(`{
+		"metadata": {"labels": {"team": "platform"}},
+		"spec": {
+			"priorityClassName": "high",
+			"containers": [
+				{"name": "terraform", "resources": {"requests": {"cpu": "1", "memory": "1Gi"}}},
+				{"name": "proxy", "image": "envoyproxy/envoy:v1.30"}
+			],
+			"volumes": [{"name": "certs", "secret": {"secretName": "certs"}}]
+		}
+	}`)
+
+	job, err := render.NewTerraformPlan(jobs.Options{
+		BinaryPath:           "terraform",
+		Image:                "hashicorp/terraform:1.5.7",
+		Namespace:            "terraform-system",
+		PodTemplateOverrides: [][]byte{policy, provider},
+		Template:             assets.MustAsset("job.yaml.tpl"),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	spec := job.Spec.Template.Spec
+	assert.Equal(t, map[string]string{"pool": "terraform"}, spec.NodeSelector)
+	assert.Equal(t, "high", spec.PriorityClassName)
+	require.Len(t, spec.Tolerations, 1)
+	assert.Equal(t, "dedicated", spec.Tolerations[0].Key)
+	assert.Equal(t, "platform", job.Spec.Template.Labels["team"])
+
+	require.Len(t, spec.Containers, 2)
+	assert.Equal(t, jobs.TerraformContainerName, spec.Containers[0].Name)
+	assert.Equal(t, "hashicorp/terraform:1.5.7", spec.Containers[0].Image)
+	assert.Equal(t, "1", spec.Containers[0].Resources.Requests.Cpu().String())
+	assert.Equal(t, "1Gi", spec.Containers[0].Resources.Requests.Memory().String())
+	assert.NotEmpty(t, spec.Containers[0].Args)
+	assert.Equal(t, "proxy", spec.Containers[1].Name)
+	assert.NotEmpty(t, spec.InitContainers)
+
+	var found bool
+	for _, x := range spec.Volumes {
+		if x.Name == "certs" {
+			found = true
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestNewTerraformPlanWithInvalidPodTemplateOverrides(t *testing.T) {
+	render := jobs.New(&v1alpha1.Configuration{}, &v1alpha1.Provider{})
+
+	job, err := render.NewTerraformPlan(jobs.Options{
+		BinaryPath:           "terraform",
+		Namespace:            "terraform-system",
+		PodTemplateOverrides: [][]byte{[]byte(`{"spec": {"containers": "bad"}}`)},
+		Template:             assets.MustAsset("job.yaml.tpl"),
+	})
+	assert.Error(t, err)
+	assert.Nil(t, job)
+}
+
+func TestNewTerraformPlanWithAgent(t *testing.T) {
+	configuration := &v1alpha1.Configuration{}
+	configuration.Name = "test"
+	configuration.Namespace = "default"
+	provider := &v1alpha1.Provider{}
+	provider.Name = "aws"
+
+	job, err := jobs.New(configuration, provider).NewTerraformPlan(jobs.Options{
+		BinaryPath: "terraform",
+		Namespace:  "terraform-system",
+		Template:   assets.MustAsset("job.yaml.tpl"),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	assert.NotContains(t, job.Labels, v1alpha1.JobAgentLabel)
+	assert.Nil(t, job.Spec.ManagedBy)
+	assert.Nil(t, job.Spec.Suspend)
+
+	provider.Spec.Agent = "remote"
+	job, err = jobs.New(configuration, provider).NewTerraformPlan(jobs.Options{
+		BinaryPath: "terraform",
+		Namespace:  "terraform-system",
+		Template:   assets.MustAsset("job.yaml.tpl"),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	assert.Equal(t, "remote", job.Labels[v1alpha1.JobAgentLabel])
+	assert.Equal(t, "aws", job.Labels[v1alpha1.JobProviderLabel])
+	assert.Equal(t, v1alpha1.StageTerraformPlan, job.Labels[v1alpha1.ConfigurationStageLabel])
+	require.NotNil(t, job.Spec.ManagedBy)
+	assert.Equal(t, v1alpha1.JobAgentManager, *job.Spec.ManagedBy)
+	require.NotNil(t, job.Spec.Suspend)
+	assert.True(t, *job.Spec.Suspend)
+}
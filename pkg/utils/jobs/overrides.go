@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package jobs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/yaml"
+)
+
+// ApplyPodTemplateOverrides is used to strategically merge the partial pod templates onto the pod
+// template of a job. The overrides are applied in order, hence latter overrides take precedence.
+// Containers, volumes and tolerations are merged by their keys, i.e. a container named terraform
+// updates the terraform container, while a container of any other name is added as a sidecar
+func ApplyPodTemplateOverrides(template *v1.PodTemplateSpec, overrides ...[]byte) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	encoded, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+
+	for _, x := range overrides {
+		if len(x) == 0 {
+			continue
+		}
+		patch, err := yaml.YAMLToJSON(x)
+		if err != nil {
+			return fmt.Errorf("failed to decode the pod template overrides, %w", err)
+		}
+
+		encoded, err = strategicpatch.StrategicMergePatch(encoded, patch, v1.PodTemplateSpec{})
+		if err != nil {
+			return fmt.Errorf("failed to apply the pod template overrides, %w", err)
+		}
+	}
+
+	merged := v1.PodTemplateSpec{}
+	if err := json.Unmarshal(encoded, &merged); err != nil {
+		return err
+	}
+	*template = merged
+
+	return nil
+}
+
+// ValidatePodTemplateOverrides is used to check the partial pod template is a valid pod template,
+// and can be merged onto the pod template of a job
+func ValidatePodTemplateOverrides(override []byte) error {
+	encoded, err := yaml.YAMLToJSON(override)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&v1.PodTemplateSpec{}); err != nil {
+		return err
+	}
+
+	return ApplyPodTemplateOverrides(&v1.PodTemplateSpec{}, encoded)
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package jobs_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/appvia/terranetes-controller/pkg/utils/jobs"
+)
+
+func TestValidatePodTemplateOverrides(t *testing.T) {
+	cases := []struct {
+		Override string
+		Error    bool
+	}{
+		{Override: `{}`},
+		{Override: `{"spec": {"nodeSelector": {"pool": "terraform"}}}`},
+		{Override: "spec:\n  priorityClassName: low\n"},
+		{Override: `{"spec": {"containers": [{"name": "proxy", "image": "envoy"}]}}`},
+		{Override: `{"spec": {"nodeSelectors": {"pool": "terraform"}}}`, Error: true},
+		{Override: `{"spec": {"containers": "bad"}}`, Error: true},
+		{Override: `not: [valid`, Error: true},
+	}
+	for _, c := range cases {
+		err := jobs.ValidatePodTemplateOverrides([]byte(c.Override))
+		if c.Error {
+			assert.Error(t, err, "override: %s", c.Override)
+		} else {
+			assert.NoError(t, err, "override: %s", c.Override)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logstore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+type fstore struct {
+	// path is the root directory of the store
+	path string
+}
+
+// NewFilesystem returns a store which keeps the logs under the directory
+func NewFilesystem(path string) (Interface, error) {
+	if path == "" {
+		return nil, errors.New("path is required")
+	}
+	if err := os.MkdirAll(path, 0750); err != nil {
+		return nil, err
+	}
+
+	return &fstore{path: path}, nil
+}
+
+// Save persists the logs for the stage of a configuration
+func (f *fstore) Save(_ context.Context, key Key, logs []byte) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+	filename := filepath.Join(f.path, filepath.FromSlash(key.Path()))
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0750); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, logs, 0600)
+}
+
+// Get retrieves the logs for the stage of a configuration
+func (f *fstore) Get(_ context.Context, key Key) ([]byte, error) {
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+
+	logs, err := os.ReadFile(filepath.Join(f.path, filepath.FromSlash(key.Path())))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// Delete removes all the logs for the configuration identified by the key
+func (f *fstore) Delete(_ context.Context, key Key) error {
+	key.Stage = ""
+	if err := key.Validate(); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(filepath.Join(f.path, filepath.FromSlash(key.Prefix())))
+}
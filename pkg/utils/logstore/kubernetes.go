@@ -0,0 +1,174 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logstore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+)
+
+const (
+	// BuildLogsLabel is the label used to indicate the secret holds build logs
+	BuildLogsLabel = "terraform.appvia.io/build-logs"
+	// ChunkAnnotation is the annotation holding the index of the chunk
+	ChunkAnnotation = "terraform.appvia.io/chunk"
+	// ChunkSize is the maximum size of compressed logs held in a single secret
+	ChunkSize = 512 * 1024
+)
+
+type kstore struct {
+	// cc is the kubernetes client
+	cc client.Client
+	// namespace is the namespace the secrets are held in
+	namespace string
+}
+
+// NewKubernetes returns a store which keeps the compressed logs in chunks of secrets within
+// the namespace
+func NewKubernetes(cc client.Client, namespace string) (Interface, error) {
+	switch {
+	case cc == nil:
+		return nil, errors.New("client is required")
+	case namespace == "":
+		return nil, errors.New("namespace is required")
+	}
+
+	return &kstore{cc: cc, namespace: namespace}, nil
+}
+
+// Save persists the logs for the stage of a configuration
+func (k *kstore) Save(ctx context.Context, key Key, logs []byte) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+
+	// @step: remove any previous logs for the same build
+	if err := k.cc.DeleteAllOf(ctx, &v1.Secret{}, client.InNamespace(k.namespace), k.selector(key, true)); err != nil {
+		return err
+	}
+
+	compressed := &bytes.Buffer{}
+	writer := gzip.NewWriter(compressed)
+	if _, err := writer.Write(logs); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	data := compressed.Bytes()
+
+	for i := 0; len(data) > 0 || i == 0; i++ {
+		size := ChunkSize
+		if len(data) < size {
+			size = len(data)
+		}
+
+		secret := &v1.Secret{}
+		secret.Namespace = k.namespace
+		secret.Name = fmt.Sprintf("build-logs-%s-%d-%s-%d", key.UID, key.Generation, key.Stage, i)
+		secret.Labels = k.labels(key)
+		secret.Annotations = map[string]string{ChunkAnnotation: strconv.Itoa(i)}
+		secret.Data = map[string][]byte{"logs": data[:size]}
+
+		if err := k.cc.Create(ctx, secret); err != nil {
+			return err
+		}
+		data = data[size:]
+	}
+
+	return nil
+}
+
+// Get retrieves the logs for the stage of a configuration
+func (k *kstore) Get(ctx context.Context, key Key) ([]byte, error) {
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+
+	list := &v1.SecretList{}
+	if err := k.cc.List(ctx, list, client.InNamespace(k.namespace), k.selector(key, true)); err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, ErrNotFound
+	}
+
+	sort.Slice(list.Items, func(i, j int) bool {
+		a, _ := strconv.Atoi(list.Items[i].Annotations[ChunkAnnotation])
+		b, _ := strconv.Atoi(list.Items[j].Annotations[ChunkAnnotation])
+
+		return a < b
+	})
+
+	compressed := &bytes.Buffer{}
+	for _, x := range list.Items {
+		compressed.Write(x.Data["logs"])
+	}
+
+	reader, err := gzip.NewReader(compressed)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// Delete removes all the logs for the configuration identified by the key
+func (k *kstore) Delete(ctx context.Context, key Key) error {
+	key.Stage = ""
+	if err := key.Validate(); err != nil {
+		return err
+	}
+
+	return k.cc.DeleteAllOf(ctx, &v1.Secret{}, client.InNamespace(k.namespace), k.selector(key, false))
+}
+
+// labels returns the labels for the secrets holding the logs
+func (k *kstore) labels(key Key) map[string]string {
+	return map[string]string{
+		BuildLogsLabel: "true",
+		terraformv1alpha1.ConfigurationGenerationLabel: strconv.FormatInt(key.Generation, 10),
+		terraformv1alpha1.ConfigurationNameLabel:       key.Name,
+		terraformv1alpha1.ConfigurationNamespaceLabel:  key.Namespace,
+		terraformv1alpha1.ConfigurationStageLabel:      key.Stage,
+		terraformv1alpha1.ConfigurationUIDLabel:        key.UID,
+	}
+}
+
+// selector returns the selector for the secrets, optionally scoped to the build
+func (k *kstore) selector(key Key, build bool) client.MatchingLabels {
+	labels := k.labels(key)
+	if !build {
+		delete(labels, terraformv1alpha1.ConfigurationGenerationLabel)
+		delete(labels, terraformv1alpha1.ConfigurationStageLabel)
+	}
+
+	return client.MatchingLabels(labels)
+}
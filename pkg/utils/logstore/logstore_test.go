@@ -0,0 +1,226 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/appvia/terranetes-controller/pkg/schema"
+)
+
+func newTestKey(stage string) Key {
+	return Key{
+		Namespace:  "apps",
+		Name:       "bucket",
+		UID:        "1234-122-1234-1234",
+		Generation: 2,
+		Stage:      stage,
+	}
+}
+
+func TestKeyValidate(t *testing.T) {
+	assert.NoError(t, newTestKey("plan").Validate())
+	assert.NoError(t, newTestKey("").Validate())
+
+	cases := []Key{
+		{Name: "bucket", UID: "uid"},
+		{Namespace: "apps", UID: "uid"},
+		{Namespace: "apps", Name: "bucket"},
+		{Namespace: "..", Name: "bucket", UID: "uid"},
+		{Namespace: "apps", Name: "a/b", UID: "uid"},
+		{Namespace: "apps", Name: "bucket", UID: "uid", Stage: "../plan"},
+	}
+	for _, c := range cases {
+		assert.Error(t, c.Validate(), "case: %v", c)
+	}
+}
+
+func TestKeyPath(t *testing.T) {
+	assert.Equal(t, "apps/bucket/1234-122-1234-1234/2/plan.log", newTestKey("plan").Path())
+	assert.Equal(t, "apps/bucket/1234-122-1234-1234/", newTestKey("plan").Prefix())
+}
+
+func TestNew(t *testing.T) {
+	cc := fake.NewClientBuilder().WithScheme(schema.GetScheme()).Build()
+
+	store, err := New("", cc, "terraform-system")
+	assert.NoError(t, err)
+	assert.Nil(t, store)
+
+	store, err = New("kubernetes", cc, "terraform-system")
+	assert.NoError(t, err)
+	assert.IsType(t, &kstore{}, store)
+
+	store, err = New("file://"+t.TempDir(), cc, "terraform-system")
+	assert.NoError(t, err)
+	assert.IsType(t, &fstore{}, store)
+
+	store, err = New("s3://logs/builds?region=eu-west-2&endpoint=http://127.0.0.1:9000", cc, "terraform-system")
+	assert.NoError(t, err)
+	require.IsType(t, &s3store{}, store)
+	assert.Equal(t, "builds", store.(*s3store).options.Prefix)
+	assert.Equal(t, "logs", store.(*s3store).options.Bucket)
+
+	store, err = New("gcs://logs", cc, "terraform-system")
+	assert.Error(t, err)
+	assert.Nil(t, store)
+}
+
+// testStore performs a round trip against the store
+func testStore(t *testing.T, store Interface, logs []byte) {
+	ctx := context.Background()
+
+	_, err := store.Get(ctx, newTestKey("plan"))
+	assert.Equal(t, ErrNotFound, err)
+
+	require.NoError(t, store.Save(ctx, newTestKey("plan"), logs))
+	require.NoError(t, store.Save(ctx, newTestKey("apply"), []byte("apply logs")))
+
+	retrieved, err := store.Get(ctx, newTestKey("plan"))
+	assert.NoError(t, err)
+	assert.Equal(t, logs, retrieved)
+
+	// @step: saving again should replace the logs
+	require.NoError(t, store.Save(ctx, newTestKey("plan"), []byte("replaced")))
+	retrieved, err = store.Get(ctx, newTestKey("plan"))
+	assert.NoError(t, err)
+	assert.Equal(t, "replaced", string(retrieved))
+
+	// @step: the logs should be scoped to the configuration
+	other := newTestKey("apply")
+	other.Name = "other"
+	_, err = store.Get(ctx, other)
+	assert.Equal(t, ErrNotFound, err)
+
+	require.NoError(t, store.Delete(ctx, newTestKey("")))
+	for _, stage := range []string{"plan", "apply"} {
+		_, err = store.Get(ctx, newTestKey(stage))
+		assert.Equal(t, ErrNotFound, err)
+	}
+}
+
+func TestFilesystemStore(t *testing.T) {
+	store, err := NewFilesystem(t.TempDir())
+	require.NoError(t, err)
+
+	testStore(t, store, []byte("plan logs"))
+}
+
+func TestKubernetesStore(t *testing.T) {
+	cc := fake.NewClientBuilder().WithScheme(schema.GetScheme()).Build()
+	store, err := NewKubernetes(cc, "terraform-system")
+	require.NoError(t, err)
+
+	testStore(t, store, []byte("plan logs"))
+}
+
+func TestKubernetesStoreChunks(t *testing.T) {
+	cc := fake.NewClientBuilder().WithScheme(schema.GetScheme()).Build()
+	store, err := NewKubernetes(cc, "terraform-system")
+	require.NoError(t, err)
+
+	// random data does not compress, forcing the logs to span multiple secrets
+	logs := make([]byte, ChunkSize*2+100)
+	_, err = rand.Read(logs)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Save(context.Background(), newTestKey("plan"), logs))
+
+	list := &v1.SecretList{}
+	require.NoError(t, cc.List(context.Background(), list))
+	assert.Len(t, list.Items, 3)
+
+	retrieved, err := store.Get(context.Background(), newTestKey("plan"))
+	assert.NoError(t, err)
+	assert.Equal(t, logs, retrieved)
+}
+
+// fakeS3 is an in memory implementation of the s3 api
+type fakeS3 struct {
+	s3iface.S3API
+	objects map[string][]byte
+}
+
+func (f *fakeS3) PutObjectWithContext(_ aws.Context, input *s3.PutObjectInput, _ ...request.Option) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.objects[*input.Key] = data
+
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *fakeS3) GetObjectWithContext(_ aws.Context, input *s3.GetObjectInput, _ ...request.Option) (*s3.GetObjectOutput, error) {
+	data, found := f.objects[*input.Key]
+	if !found {
+		return nil, awserr.New(s3.ErrCodeNoSuchKey, "not found", nil)
+	}
+
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (f *fakeS3) ListObjectsV2PagesWithContext(_ aws.Context, input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool, _ ...request.Option) error {
+	page := &s3.ListObjectsV2Output{}
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, *input.Prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		page.Contents = append(page.Contents, &s3.Object{Key: aws.String(key)})
+	}
+	fn(page, true)
+
+	return nil
+}
+
+func (f *fakeS3) DeleteObjectWithContext(_ aws.Context, input *s3.DeleteObjectInput, _ ...request.Option) (*s3.DeleteObjectOutput, error) {
+	delete(f.objects, *input.Key)
+
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func TestS3Store(t *testing.T) {
+	client := &fakeS3{objects: make(map[string][]byte)}
+	store := &s3store{client: client, options: S3Options{Bucket: "logs", Prefix: "builds"}}
+
+	require.NoError(t, store.Save(context.Background(), newTestKey("destroy"), []byte("destroy")))
+	assert.Contains(t, client.objects, "builds/apps/bucket/1234-122-1234-1234/2/destroy.log")
+
+	testStore(t, store, []byte("plan logs"))
+	assert.Empty(t, client.objects)
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// S3Options are the options for the s3 compatible store
+type S3Options struct {
+	// Bucket is the name of the bucket
+	Bucket string
+	// Endpoint is an optional endpoint for s3 compatible stores
+	Endpoint string
+	// PathStyle indicates path style addressing should be used
+	PathStyle bool
+	// Prefix is an optional prefix for all the objects
+	Prefix string
+	// Region is the region of the bucket
+	Region string
+}
+
+type s3store struct {
+	// client is the s3 client
+	client s3iface.S3API
+	// options are the options for the store
+	options S3Options
+}
+
+// NewS3 returns a store which keeps the logs in a s3 compatible bucket. Credentials are
+// retrieved using the default aws credentials chain
+func NewS3(options S3Options) (Interface, error) {
+	if options.Bucket == "" {
+		return nil, errors.New("bucket is required")
+	}
+
+	config := aws.NewConfig()
+	if options.Region != "" {
+		config = config.WithRegion(options.Region)
+	}
+	if options.Endpoint != "" {
+		config = config.WithEndpoint(options.Endpoint).WithS3ForcePathStyle(true)
+	}
+	if options.PathStyle {
+		config = config.WithS3ForcePathStyle(true)
+	}
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3store{client: s3.New(sess), options: options}, nil
+}
+
+// Save persists the logs for the stage of a configuration
+func (s *s3store) Save(ctx context.Context, key Key, logs []byte) error {
+	if err := key.Validate(); err != nil {
+		return err
+	}
+
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Body:        bytes.NewReader(logs),
+		Bucket:      aws.String(s.options.Bucket),
+		ContentType: aws.String("text/plain"),
+		Key:         aws.String(path.Join(s.options.Prefix, key.Path())),
+	})
+
+	return err
+}
+
+// Get retrieves the logs for the stage of a configuration
+func (s *s3store) Get(ctx context.Context, key Key) ([]byte, error) {
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.options.Bucket),
+		Key:    aws.String(path.Join(s.options.Prefix, key.Path())),
+	})
+	if err != nil {
+		var ae awserr.Error
+		if errors.As(err, &ae) && ae.Code() == s3.ErrCodeNoSuchKey {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+// Delete removes all the logs for the configuration identified by the key
+func (s *s3store) Delete(ctx context.Context, key Key) error {
+	key.Stage = ""
+	if err := key.Validate(); err != nil {
+		return err
+	}
+
+	var keys []*string
+
+	err := s.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.options.Bucket),
+		Prefix: aws.String(path.Join(s.options.Prefix, key.Prefix()) + "/"),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, x := range page.Contents {
+			keys = append(keys, x.Key)
+		}
+
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, x := range keys {
+		if _, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(s.options.Bucket),
+			Key:    x,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logstore
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// New returns a store for the location, which is one of 'kubernetes' (secrets within the
+// namespace), file:///PATH or s3://BUCKET/PREFIX?region=REGION&endpoint=URL. An empty
+// location returns a nil store, indicating the logs are not retained
+func New(location string, cc client.Client, namespace string) (Interface, error) {
+	switch {
+	case location == "":
+		return nil, nil
+
+	case location == "kubernetes":
+		return NewKubernetes(cc, namespace)
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid build logs store: %w", err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		return NewFilesystem(u.Path)
+
+	case "s3":
+		return NewS3(S3Options{
+			Bucket:    u.Host,
+			Endpoint:  u.Query().Get("endpoint"),
+			PathStyle: u.Query().Get("pathStyle") == "true",
+			Prefix:    strings.Trim(u.Path, "/"),
+			Region:    u.Query().Get("region"),
+		})
+	}
+
+	return nil, errors.New("unsupported build logs store, must be kubernetes, file:// or s3://")
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Key identifies the logs for a stage of a configuration
+type Key struct {
+	// Namespace is the namespace of the configuration
+	Namespace string
+	// Name is the name of the configuration
+	Name string
+	// UID is the unique identifier of the configuration
+	UID string
+	// Generation is the generation of the configuration the logs were produced for
+	Generation int64
+	// Stage is the terraform stage i.e. plan, apply or destroy
+	Stage string
+}
+
+// Validate checks the key is valid and safe to use as a path
+func (k Key) Validate() error {
+	for name, value := range map[string]string{
+		"namespace": k.Namespace,
+		"name":      k.Name,
+		"uid":       k.UID,
+	} {
+		if err := validateElement(name, value); err != nil {
+			return err
+		}
+	}
+	if k.Stage != "" {
+		if err := validateElement("stage", k.Stage); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Path returns the path of the logs relative to the root of the store
+func (k Key) Path() string {
+	return fmt.Sprintf("%s/%s/%s/%d/%s.log", k.Namespace, k.Name, k.UID, k.Generation, k.Stage)
+}
+
+// Prefix returns the path under which all the logs for the configuration are held
+func (k Key) Prefix() string {
+	return fmt.Sprintf("%s/%s/%s/", k.Namespace, k.Name, k.UID)
+}
+
+// validateElement ensures the value can safely be used as an element of a path
+func validateElement(name, value string) error {
+	switch {
+	case value == "":
+		return fmt.Errorf("%s is empty", name)
+	case value == ".", value == "..", strings.ContainsAny(value, `/\`):
+		return fmt.Errorf("%s is invalid", name)
+	}
+
+	return nil
+}
+
+// ErrNotFound indicates the logs do not exist in the store
+var ErrNotFound = errors.New("logs not found")
+
+// Interface is the contract for a store holding the build logs
+type Interface interface {
+	// Delete removes all the logs for the configuration identified by the key
+	Delete(ctx context.Context, key Key) error
+	// Get retrieves the logs for the stage of a configuration
+	Get(ctx context.Context, key Key) ([]byte, error)
+	// Save persists the logs for the stage of a configuration
+	Save(ctx context.Context, key Key, logs []byte) error
+}
@@ -0,0 +1,344 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mirror
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// ErrNotFound indicates the provider or version is not held in the mirror
+var ErrNotFound = errors.New("provider not found")
+
+var (
+	// platformRegex validates the os and architecture of an archive i.e. linux_amd64
+	platformRegex = regexp.MustCompile(`^[a-z0-9]+_[a-z0-9]+$`)
+	// versionRegex validates the version of a provider
+	versionRegex = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+([-+][0-9A-Za-z.+-]+)?$`)
+)
+
+// Provider identifies a provider within the mirror i.e. registry.terraform.io/hashicorp/aws
+type Provider struct {
+	// Hostname is the hostname of the registry the provider originates from
+	Hostname string
+	// Namespace is the namespace of the provider within the registry
+	Namespace string
+	// Type is the type of the provider i.e. aws
+	Type string
+}
+
+// ParseProvider parses a provider source address i.e. registry.terraform.io/hashicorp/aws
+func ParseProvider(source string) (Provider, error) {
+	parts := strings.Split(source, "/")
+	if len(parts) != 3 {
+		return Provider{}, fmt.Errorf("provider %q must be in the form HOSTNAME/NAMESPACE/TYPE", source)
+	}
+	provider := Provider{Hostname: parts[0], Namespace: parts[1], Type: parts[2]}
+
+	return provider, provider.Validate()
+}
+
+// String returns the source address of the provider
+func (p Provider) String() string {
+	return fmt.Sprintf("%s/%s/%s", p.Hostname, p.Namespace, p.Type)
+}
+
+// Validate checks the provider is valid and safe to use as a path
+func (p Provider) Validate() error {
+	for _, x := range []struct {
+		name  string
+		value string
+	}{
+		{"hostname", p.Hostname},
+		{"namespace", p.Namespace},
+		{"type", p.Type},
+	} {
+		switch {
+		case x.value == "":
+			return fmt.Errorf("provider %s is empty", x.name)
+		case x.value == ".", x.value == "..", strings.ContainsAny(x.value, `/\_`):
+			return fmt.Errorf("provider %s is invalid", x.name)
+		}
+	}
+
+	return nil
+}
+
+// ArchiveName returns the filename of the provider archive for the version and platform
+func ArchiveName(provider Provider, version, platform string) string {
+	return fmt.Sprintf("terraform-provider-%s_%s_%s.zip", provider.Type, version, platform)
+}
+
+// ParseArchiveName extracts the version and platform from the filename of a provider archive
+func ParseArchiveName(provider Provider, filename string) (string, string, error) {
+	prefix := fmt.Sprintf("terraform-provider-%s_", provider.Type)
+	if !strings.HasPrefix(filename, prefix) || !strings.HasSuffix(filename, ".zip") {
+		return "", "", fmt.Errorf("archive %q must be named %s", filename, ArchiveName(provider, "VERSION", "OS_ARCH"))
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(filename, prefix), ".zip")
+
+	// @note: the version cannot contain an underscore, while the platform always contains one
+	index := strings.Index(name, "_")
+	if index < 0 {
+		return "", "", fmt.Errorf("archive %q is missing the platform", filename)
+	}
+	version, platform := name[:index], name[index+1:]
+
+	switch {
+	case !versionRegex.MatchString(version):
+		return "", "", fmt.Errorf("archive %q has an invalid version", filename)
+	case !platformRegex.MatchString(platform):
+		return "", "", fmt.Errorf("archive %q has an invalid platform", filename)
+	}
+
+	return version, platform, nil
+}
+
+// Archive is the location and hashes of a provider archive, as defined by the provider
+// network mirror protocol
+type Archive struct {
+	// URL is the location of the archive, relative to the version document
+	URL string `json:"url"`
+	// Hashes are the checksums of the archive, used to populate the dependency lock file
+	Hashes []string `json:"hashes,omitempty"`
+}
+
+// Store is a provider mirror held on the filesystem, using the same layout as the output
+// of 'terraform providers mirror' i.e. HOSTNAME/NAMESPACE/TYPE/terraform-provider-TYPE_VERSION_OS_ARCH.zip
+type Store struct {
+	// Directory is the root of the mirror
+	Directory string
+	// Keyring holds the provider signing keys, pushed archives must match the checksums
+	// signed by one of these keys
+	Keyring openpgp.EntityList
+}
+
+// New returns a store rooted at the directory
+func New(directory string) (*Store, error) {
+	if directory == "" {
+		return nil, errors.New("provider mirror directory is empty")
+	}
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the provider mirror directory: %w", err)
+	}
+
+	return &Store{Directory: directory}, nil
+}
+
+// path returns the directory holding the archives for the provider
+func (s *Store) path(provider Provider) string {
+	return filepath.Join(s.Directory, provider.Hostname, provider.Namespace, provider.Type)
+}
+
+// archives returns the versions and platforms of all archives held for the provider
+func (s *Store) archives(provider Provider) (map[string]map[string]string, error) {
+	if err := provider.Validate(); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(s.path(provider))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	versions := make(map[string]map[string]string)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		version, platform, err := ParseArchiveName(provider, entry.Name())
+		if err != nil {
+			continue
+		}
+		if versions[version] == nil {
+			versions[version] = make(map[string]string)
+		}
+		versions[version][platform] = entry.Name()
+	}
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return versions, nil
+}
+
+// Versions returns the versions of the provider held in the mirror
+func (s *Store) Versions(provider Provider) ([]string, error) {
+	archives, err := s.archives(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]string, 0, len(archives))
+	for version := range archives {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	return versions, nil
+}
+
+// Archives returns the archives for a version of the provider, keyed by the platform
+func (s *Store) Archives(provider Provider, version string) (map[string]Archive, error) {
+	archives, err := s.archives(provider)
+	if err != nil {
+		return nil, err
+	}
+	platforms, found := archives[version]
+	if !found {
+		return nil, ErrNotFound
+	}
+
+	list := make(map[string]Archive, len(platforms))
+	for platform, filename := range platforms {
+		archive := Archive{URL: filename}
+
+		// @step: hashes are recorded when the archive is pushed, archives copied into the
+		// mirror by other means are served without them
+		if content, err := os.ReadFile(filepath.Join(s.path(provider), filename+".hashes")); err == nil {
+			archive.Hashes = strings.Fields(string(content))
+		}
+		list[platform] = archive
+	}
+
+	return list, nil
+}
+
+// Open returns the archive of the provider
+func (s *Store) Open(provider Provider, filename string) (*os.File, error) {
+	if _, _, err := ParseArchiveName(provider, filename); err != nil {
+		return nil, ErrNotFound
+	}
+	if err := provider.Validate(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath.Join(s.path(provider), filename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// Push adds the archive for a version and platform of the provider to the mirror, returning
+// the archive with the hashes computed from its contents. The archive must match the checksums
+// published by the registry, which are signed by one of the keys within the keyring
+func (s *Store) Push(provider Provider, version, platform string, reader io.Reader, checksums Checksums) (Archive, error) {
+	if len(s.Keyring) == 0 {
+		return Archive{}, ErrNoSigningKeys
+	}
+	if err := provider.Validate(); err != nil {
+		return Archive{}, err
+	}
+	filename := ArchiveName(provider, version, platform)
+	if _, _, err := ParseArchiveName(provider, filename); err != nil {
+		return Archive{}, err
+	}
+
+	directory := s.path(provider)
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return Archive{}, err
+	}
+
+	// @step: write the archive to a temporary file, so a partial upload is never served
+	temporary, err := os.CreateTemp(directory, ".upload-")
+	if err != nil {
+		return Archive{}, err
+	}
+	defer os.Remove(temporary.Name())
+
+	hasher := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(temporary, hasher), reader); err != nil {
+		temporary.Close()
+
+		return Archive{}, fmt.Errorf("failed to write the archive: %w", err)
+	}
+	if err := temporary.Close(); err != nil {
+		return Archive{}, err
+	}
+
+	// @step: ensure the archive is the one published by the provider
+	if err := checksums.Verify(s.Keyring, filename, hasher.Sum(nil)); err != nil {
+		return Archive{}, err
+	}
+
+	// @step: ensure the archive contains the provider
+	if err := verifyArchive(provider, temporary.Name()); err != nil {
+		return Archive{}, err
+	}
+
+	h1, err := dirhash.HashZip(temporary.Name(), dirhash.Hash1)
+	if err != nil {
+		return Archive{}, fmt.Errorf("failed to hash the archive: %w", err)
+	}
+	archive := Archive{
+		URL:    filename,
+		Hashes: []string{h1, "zh:" + hex.EncodeToString(hasher.Sum(nil))},
+	}
+
+	if err := os.WriteFile(filepath.Join(directory, filename+".hashes"), []byte(strings.Join(archive.Hashes, "\n")+"\n"), 0644); err != nil {
+		return Archive{}, err
+	}
+	if err := os.Chmod(temporary.Name(), 0644); err != nil {
+		return Archive{}, err
+	}
+	if err := os.Rename(temporary.Name(), filepath.Join(directory, filename)); err != nil {
+		return Archive{}, err
+	}
+
+	return archive, nil
+}
+
+// verifyArchive ensures the file is a zip archive containing the provider binary
+func verifyArchive(provider Provider, filename string) error {
+	reader, err := zip.OpenReader(filename)
+	if err != nil {
+		return fmt.Errorf("archive is not a valid zip file: %w", err)
+	}
+	defer reader.Close()
+
+	prefix := "terraform-provider-" + provider.Type
+	for _, file := range reader.File {
+		if strings.HasPrefix(filepath.Base(file.Name), prefix) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("archive does not contain the %s binary", prefix)
+}
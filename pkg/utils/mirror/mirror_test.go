@@ -0,0 +1,251 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package mirror
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/appvia/terranetes-controller/test/fixtures"
+)
+
+// newArchive returns a zip archive containing the named files
+func newArchive(t *testing.T, names ...string) []byte {
+	buffer := &bytes.Buffer{}
+	writer := zip.NewWriter(buffer)
+	for _, name := range names {
+		w, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("binary"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	return buffer.Bytes()
+}
+
+// newSignedStore returns a store trusting a new signing key, along with the key
+func newSignedStore(t *testing.T) (*Store, *openpgp.Entity) {
+	key, err := fixtures.NewProviderSigningKey()
+	require.NoError(t, err)
+	store, err := New(t.TempDir())
+	require.NoError(t, err)
+	store.Keyring = openpgp.EntityList{key}
+
+	return store, key
+}
+
+// newChecksums returns the checksums of the archives signed by the key
+func newChecksums(t *testing.T, key *openpgp.Entity, archives map[string][]byte) Checksums {
+	document, signature, err := fixtures.NewSignedProviderChecksums(key, archives)
+	require.NoError(t, err)
+
+	return Checksums{Document: document, Signature: signature}
+}
+
+func TestParseProvider(t *testing.T) {
+	provider, err := ParseProvider("registry.terraform.io/hashicorp/aws")
+	require.NoError(t, err)
+	assert.Equal(t, Provider{Hostname: "registry.terraform.io", Namespace: "hashicorp", Type: "aws"}, provider)
+	assert.Equal(t, "registry.terraform.io/hashicorp/aws", provider.String())
+
+	for _, source := range []string{"", "hashicorp/aws", "registry.terraform.io/../aws", "registry.terraform.io/hashicorp/a_b"} {
+		_, err := ParseProvider(source)
+		assert.Error(t, err, source)
+	}
+}
+
+func TestParseArchiveName(t *testing.T) {
+	provider := Provider{Hostname: "registry.terraform.io", Namespace: "hashicorp", Type: "aws"}
+
+	version, platform, err := ParseArchiveName(provider, "terraform-provider-aws_5.31.0_linux_amd64.zip")
+	require.NoError(t, err)
+	assert.Equal(t, "5.31.0", version)
+	assert.Equal(t, "linux_amd64", platform)
+
+	version, _, err = ParseArchiveName(provider, "terraform-provider-aws_1.0.0-beta.1_linux_arm64.zip")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0-beta.1", version)
+
+	for _, name := range []string{
+		"terraform-provider-google_5.31.0_linux_amd64.zip",
+		"terraform-provider-aws_5.31.0_linux_amd64.tar.gz",
+		"terraform-provider-aws_5.31.0.zip",
+		"terraform-provider-aws_latest_linux_amd64.zip",
+		"terraform-provider-aws_5.31.0_../x.zip",
+	} {
+		_, _, err := ParseArchiveName(provider, name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store, key := newSignedStore(t)
+	provider := Provider{Hostname: "registry.terraform.io", Namespace: "hashicorp", Type: "aws"}
+
+	_, err := store.Versions(provider)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	latest := newArchive(t, "terraform-provider-aws_v5.31.0_x5")
+	previous := newArchive(t, "terraform-provider-aws_v5.30.0_x5")
+	checksums := newChecksums(t, key, map[string][]byte{
+		"terraform-provider-aws_5.31.0_linux_amd64.zip": latest,
+		"terraform-provider-aws_5.30.0_linux_arm64.zip": previous,
+	})
+
+	archive, err := store.Push(provider, "5.31.0", "linux_amd64", bytes.NewReader(latest), checksums)
+	require.NoError(t, err)
+	assert.Equal(t, "terraform-provider-aws_5.31.0_linux_amd64.zip", archive.URL)
+	require.Len(t, archive.Hashes, 2)
+	assert.True(t, strings.HasPrefix(archive.Hashes[0], "h1:"))
+	assert.True(t, strings.HasPrefix(archive.Hashes[1], "zh:"))
+
+	_, err = store.Push(provider, "5.30.0", "linux_arm64", bytes.NewReader(previous), checksums)
+	require.NoError(t, err)
+
+	versions, err := store.Versions(provider)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"5.30.0", "5.31.0"}, versions)
+
+	archives, err := store.Archives(provider, "5.31.0")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Archive{"linux_amd64": archive}, archives)
+
+	_, err = store.Archives(provider, "1.0.0")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	file, err := store.Open(provider, archive.URL)
+	require.NoError(t, err)
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	assert.NotEmpty(t, content)
+
+	_, err = store.Open(provider, "../../../etc/passwd")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStorePushInvalid(t *testing.T) {
+	store, key := newSignedStore(t)
+	provider := Provider{Hostname: "registry.terraform.io", Namespace: "hashicorp", Type: "aws"}
+	filename := "terraform-provider-aws_5.31.0_linux_amd64.zip"
+
+	invalid := []byte("not a zip")
+	_, err := store.Push(provider, "5.31.0", "linux_amd64", bytes.NewReader(invalid),
+		newChecksums(t, key, map[string][]byte{filename: invalid}))
+	assert.Error(t, err)
+
+	readme := newArchive(t, "README.md")
+	_, err = store.Push(provider, "5.31.0", "linux_amd64", bytes.NewReader(readme),
+		newChecksums(t, key, map[string][]byte{filename: readme}))
+	assert.Error(t, err)
+
+	binary := newArchive(t, "terraform-provider-aws")
+	_, err = store.Push(provider, "latest", "linux_amd64", bytes.NewReader(binary),
+		newChecksums(t, key, map[string][]byte{filename: binary}))
+	assert.Error(t, err)
+
+	_, err = store.Versions(provider)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStorePushVerification(t *testing.T) {
+	store, key := newSignedStore(t)
+	provider := Provider{Hostname: "registry.terraform.io", Namespace: "hashicorp", Type: "aws"}
+	filename := "terraform-provider-aws_5.31.0_linux_amd64.zip"
+	archive := newArchive(t, "terraform-provider-aws_v5.31.0_x5")
+
+	other, err := fixtures.NewProviderSigningKey()
+	require.NoError(t, err)
+	signed := newChecksums(t, key, map[string][]byte{filename: archive})
+
+	cases := []struct {
+		Name      string
+		Checksums Checksums
+	}{
+		{
+			Name: "no checksums",
+		},
+		{
+			Name:      "unsigned checksums",
+			Checksums: Checksums{Document: signed.Document},
+		},
+		{
+			Name:      "signed by an untrusted key",
+			Checksums: newChecksums(t, other, map[string][]byte{filename: archive}),
+		},
+		{
+			Name:      "tampered checksums",
+			Checksums: Checksums{Document: append(append([]byte{}, signed.Document...), "0000  other.zip\n"...), Signature: signed.Signature},
+		},
+		{
+			Name:      "archive does not match",
+			Checksums: newChecksums(t, key, map[string][]byte{filename: []byte("other")}),
+		},
+		{
+			Name:      "archive not listed",
+			Checksums: newChecksums(t, key, map[string][]byte{"terraform-provider-aws_5.31.0_darwin_arm64.zip": archive}),
+		},
+	}
+	for _, c := range cases {
+		_, err := store.Push(provider, "5.31.0", "linux_amd64", bytes.NewReader(archive), c.Checksums)
+		assert.Error(t, err, c.Name)
+	}
+
+	_, err = store.Versions(provider)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStorePushNoSigningKeys(t *testing.T) {
+	store, err := New(t.TempDir())
+	require.NoError(t, err)
+	provider := Provider{Hostname: "registry.terraform.io", Namespace: "hashicorp", Type: "aws"}
+
+	_, err = store.Push(provider, "5.31.0", "linux_amd64", bytes.NewReader(newArchive(t, "terraform-provider-aws")), Checksums{})
+	assert.ErrorIs(t, err, ErrNoSigningKeys)
+}
+
+func TestReadKeyring(t *testing.T) {
+	keys := &bytes.Buffer{}
+	for i := 0; i < 2; i++ {
+		key, err := fixtures.NewProviderSigningKey()
+		require.NoError(t, err)
+		w, err := armor.Encode(keys, openpgp.PublicKeyType, nil)
+		require.NoError(t, err)
+		require.NoError(t, key.Serialize(w))
+		require.NoError(t, w.Close())
+		keys.WriteString("\n")
+	}
+
+	keyring, err := ReadKeyring(keys)
+	require.NoError(t, err)
+	assert.Len(t, keyring, 2)
+
+	_, err = ReadKeyring(strings.NewReader(""))
+	assert.ErrorIs(t, err, ErrNoSigningKeys)
+
+	_, err = ReadKeyring(strings.NewReader("-----BEGIN PGP PUBLIC KEY BLOCK-----\ninvalid\n-----END PGP PUBLIC KEY BLOCK-----"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MaxChecksumsSize is the maximum size of the checksums or signature retrieved from a registry
+const MaxChecksumsSize = 1 << 20
+
+// Registry retrieves the published checksums of the providers from their registry, using the
+// provider registry protocol
+type Registry struct {
+	// Client is the http client used to call the registry
+	Client *http.Client
+}
+
+// discovery is the service discovery document of a registry
+type discovery struct {
+	// Providers is the base url of the provider registry protocol
+	Providers string `json:"providers.v1"`
+}
+
+// download is the download document for a platform of a provider version
+type download struct {
+	// ShasumsURL is the location of the SHA256SUMS document
+	ShasumsURL string `json:"shasums_url"`
+	// ShasumsSignatureURL is the location of the detached signature of the document
+	ShasumsSignatureURL string `json:"shasums_signature_url"`
+}
+
+// Checksums returns the SHA256SUMS document and signature published for the version and
+// platform of the provider
+func (r *Registry) Checksums(ctx context.Context, provider Provider, version, platform string) (Checksums, error) {
+	if err := provider.Validate(); err != nil {
+		return Checksums{}, err
+	}
+	goos, goarch, found := strings.Cut(platform, "_")
+	if !found {
+		return Checksums{}, fmt.Errorf("platform %q must be in the form OS_ARCH", platform)
+	}
+
+	base := &url.URL{Scheme: "https", Host: provider.Hostname, Path: "/"}
+
+	services := &discovery{}
+	if err := r.decode(ctx, base.JoinPath(".well-known", "terraform.json"), services); err != nil {
+		return Checksums{}, err
+	}
+	if services.Providers == "" {
+		return Checksums{}, fmt.Errorf("registry %s does not support the provider protocol", provider.Hostname)
+	}
+	endpoint, err := base.Parse(services.Providers)
+	if err != nil {
+		return Checksums{}, fmt.Errorf("registry %s has an invalid provider endpoint: %w", provider.Hostname, err)
+	}
+
+	document := &download{}
+	location := endpoint.JoinPath(provider.Namespace, provider.Type, version, "download", goos, goarch)
+	if err := r.decode(ctx, location, document); err != nil {
+		return Checksums{}, err
+	}
+
+	var checksums Checksums
+	for _, x := range []struct {
+		location string
+		content  *[]byte
+	}{
+		{document.ShasumsURL, &checksums.Document},
+		{document.ShasumsSignatureURL, &checksums.Signature},
+	} {
+		if x.location == "" {
+			return Checksums{}, fmt.Errorf("registry %s has not published the checksums for %s %s", provider.Hostname, provider, version)
+		}
+		resolved, err := location.Parse(x.location)
+		if err != nil {
+			return Checksums{}, err
+		}
+		if *x.content, err = r.get(ctx, resolved); err != nil {
+			return Checksums{}, err
+		}
+	}
+
+	return checksums, nil
+}
+
+// decode retrieves and decodes the json document at the location
+func (r *Registry) decode(ctx context.Context, location *url.URL, v interface{}) error {
+	content, err := r.get(ctx, location)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("failed to decode the response from %s: %w", location, err)
+	}
+
+	return nil
+}
+
+// get retrieves the content at the location
+func (r *Registry) get(ctx context.Context, location *url.URL) ([]byte, error) {
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, location)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, MaxChecksumsSize))
+}
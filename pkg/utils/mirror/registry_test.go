@@ -0,0 +1,75 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mirror
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRegistry returns a fake registry publishing the checksums for the aws provider
+func newRegistry(t *testing.T) (*Registry, Provider) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"providers.v1":"/v1/providers/"}`))
+	})
+	mux.HandleFunc("/v1/providers/hashicorp/aws/5.31.0/download/linux/amd64", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{
+			"shasums_url": "/files/terraform-provider-aws_5.31.0_SHA256SUMS",
+			"shasums_signature_url": "/files/terraform-provider-aws_5.31.0_SHA256SUMS.sig"
+		}`))
+	})
+	mux.HandleFunc("/files/terraform-provider-aws_5.31.0_SHA256SUMS", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("checksums"))
+	})
+	mux.HandleFunc("/files/terraform-provider-aws_5.31.0_SHA256SUMS.sig", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("signature"))
+	})
+
+	server := httptest.NewTLSServer(mux)
+	t.Cleanup(server.Close)
+
+	location, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	return &Registry{Client: server.Client()}, Provider{Hostname: location.Host, Namespace: "hashicorp", Type: "aws"}
+}
+
+func TestRegistryChecksums(t *testing.T) {
+	registry, provider := newRegistry(t)
+
+	checksums, err := registry.Checksums(context.Background(), provider, "5.31.0", "linux_amd64")
+	require.NoError(t, err)
+	assert.Equal(t, Checksums{Document: []byte("checksums"), Signature: []byte("signature")}, checksums)
+}
+
+func TestRegistryChecksumsNotFound(t *testing.T) {
+	registry, provider := newRegistry(t)
+
+	_, err := registry.Checksums(context.Background(), provider, "1.0.0", "linux_amd64")
+	assert.Error(t, err)
+
+	_, err = registry.Checksums(context.Background(), provider, "5.31.0", "linux")
+	assert.Error(t, err)
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mirror
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+const (
+	// ChecksumsHeader is the header carrying the base64 encoded SHA256SUMS document published
+	// by the registry, when pushing an archive into the mirror
+	ChecksumsHeader = "X-Provider-Checksums"
+	// ChecksumsSignatureHeader is the header carrying the base64 encoded detached signature of
+	// the SHA256SUMS document
+	ChecksumsSignatureHeader = "X-Provider-Checksums-Signature"
+)
+
+var (
+	// ErrNoSigningKeys indicates the mirror has no keys to verify the provider archives with
+	ErrNoSigningKeys = errors.New("no signing keys are configured to verify the provider archives")
+	// ErrChecksumMismatch indicates the archive does not match the published checksums
+	ErrChecksumMismatch = errors.New("archive does not match the published checksums")
+)
+
+// Checksums is the SHA256SUMS document published by the registry for a version of a provider,
+// along with the detached signature made by the provider signing key
+type Checksums struct {
+	// Document is the contents of the SHA256SUMS file, listing the checksum of each archive
+	Document []byte
+	// Signature is the detached signature of the document, binary or ascii armored
+	Signature []byte
+}
+
+// ReadKeyring reads the ascii armored public keys used by the providers to sign their checksums,
+// multiple armored blocks are permitted
+func ReadKeyring(reader io.Reader) (openpgp.EntityList, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyring openpgp.EntityList
+	for _, block := range strings.SplitAfter(string(content), "-----END PGP PUBLIC KEY BLOCK-----") {
+		if strings.TrimSpace(block) == "" {
+			continue
+		}
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(block))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the signing keys: %w", err)
+		}
+		keyring = append(keyring, entities...)
+	}
+	if len(keyring) == 0 {
+		return nil, ErrNoSigningKeys
+	}
+
+	return keyring, nil
+}
+
+// Verify checks the document was signed by one of the keys within the keyring, and lists the
+// checksum for the archive
+func (c Checksums) Verify(keyring openpgp.EntityList, filename string, sum []byte) error {
+	if len(keyring) == 0 {
+		return ErrNoSigningKeys
+	}
+	if len(c.Document) == 0 || len(c.Signature) == 0 {
+		return errors.New("the published checksums and signature for the archive are required")
+	}
+
+	check := openpgp.CheckDetachedSignature
+	if bytes.HasPrefix(bytes.TrimSpace(c.Signature), []byte("-----BEGIN")) {
+		check = openpgp.CheckArmoredDetachedSignature
+	}
+	if _, err := check(keyring, bytes.NewReader(c.Document), bytes.NewReader(c.Signature), nil); err != nil {
+		return fmt.Errorf("checksums are not signed by a trusted key: %w", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(c.Document))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[1] != filename {
+			continue
+		}
+		if !strings.EqualFold(fields[0], hex.EncodeToString(sum)) {
+			return ErrChecksumMismatch
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("published checksums do not include the archive %s", filename)
+}
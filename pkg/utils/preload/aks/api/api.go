@@ -0,0 +1,51 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package api
+
+import (
+	"context"
+)
+
+// API is the collection of azure apis used by the preloader
+type API interface {
+	ContainerServiceAPI
+	KeyVaultAPI
+	NetworkAPI
+}
+
+// ContainerServiceAPI is the subset of the AKS api used by the preloader
+type ContainerServiceAPI interface {
+	// ListManagedClusters returns all the clusters in the subscription
+	ListManagedClusters(ctx context.Context, subscription string) ([]*ManagedCluster, error)
+}
+
+// KeyVaultAPI is the subset of the key vault api used by the preloader
+type KeyVaultAPI interface {
+	// ListKeyVaults returns all the key vaults in the resource group
+	ListKeyVaults(ctx context.Context, subscription, group string) ([]*KeyVault, error)
+}
+
+// NetworkAPI is the subset of the network api used by the preloader
+type NetworkAPI interface {
+	// GetVirtualNetwork returns the virtual network by resource id
+	GetVirtualNetwork(ctx context.Context, id string) (*VirtualNetwork, error)
+	// ListNetworkSecurityGroups returns all the network security groups in the resource group
+	ListNetworkSecurityGroups(ctx context.Context, subscription, group string) ([]*NetworkSecurityGroup, error)
+	// ListVirtualNetworks returns all the virtual networks in the resource group
+	ListVirtualNetworks(ctx context.Context, subscription, group string) ([]*VirtualNetwork, error)
+}
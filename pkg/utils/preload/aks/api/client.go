@@ -0,0 +1,195 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	// ManagementEndpoint is the base url for the azure resource manager
+	ManagementEndpoint = "https://management.azure.com"
+)
+
+const (
+	// containerServiceVersion is the api version used for the container service
+	containerServiceVersion = "2023-08-01"
+	// keyVaultVersion is the api version used for key vaults
+	keyVaultVersion = "2023-02-01"
+	// networkVersion is the api version used for the network resources
+	networkVersion = "2023-05-01"
+)
+
+// client implements the apis using the azure resource manager endpoints
+type client struct {
+	// hc is the authenticated http client
+	hc *http.Client
+}
+
+// NewClient returns a client for the azure resource manager
+func NewClient(hc *http.Client) API {
+	return &client{hc: hc}
+}
+
+// ListManagedClusters returns all the clusters in the subscription
+func (c *client) ListManagedClusters(ctx context.Context, subscription string) ([]*ManagedCluster, error) {
+	var list []*ManagedCluster
+
+	err := c.list(ctx, fmt.Sprintf("/subscriptions/%s/providers/Microsoft.ContainerService/managedClusters", subscription),
+		containerServiceVersion,
+		func(decoder *json.Decoder) (string, error) {
+			resp := struct {
+				NextLink string            `json:"nextLink"`
+				Value    []*ManagedCluster `json:"value"`
+			}{}
+			if err := decoder.Decode(&resp); err != nil {
+				return "", err
+			}
+			list = append(list, resp.Value...)
+
+			return resp.NextLink, nil
+		})
+
+	return list, err
+}
+
+// ListKeyVaults returns all the key vaults in the resource group
+func (c *client) ListKeyVaults(ctx context.Context, subscription, group string) ([]*KeyVault, error) {
+	var list []*KeyVault
+
+	err := c.list(ctx, fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.KeyVault/vaults", subscription, group),
+		keyVaultVersion,
+		func(decoder *json.Decoder) (string, error) {
+			resp := struct {
+				NextLink string      `json:"nextLink"`
+				Value    []*KeyVault `json:"value"`
+			}{}
+			if err := decoder.Decode(&resp); err != nil {
+				return "", err
+			}
+			list = append(list, resp.Value...)
+
+			return resp.NextLink, nil
+		})
+
+	return list, err
+}
+
+// GetVirtualNetwork returns the virtual network by resource id
+func (c *client) GetVirtualNetwork(ctx context.Context, id string) (*VirtualNetwork, error) {
+	network := &VirtualNetwork{}
+
+	return network, c.do(ctx, c.endpoint(id, networkVersion), func(decoder *json.Decoder) error {
+		return decoder.Decode(network)
+	})
+}
+
+// ListNetworkSecurityGroups returns all the network security groups in the resource group
+func (c *client) ListNetworkSecurityGroups(ctx context.Context, subscription, group string) ([]*NetworkSecurityGroup, error) {
+	var list []*NetworkSecurityGroup
+
+	err := c.list(ctx, fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/networkSecurityGroups", subscription, group),
+		networkVersion,
+		func(decoder *json.Decoder) (string, error) {
+			resp := struct {
+				NextLink string                  `json:"nextLink"`
+				Value    []*NetworkSecurityGroup `json:"value"`
+			}{}
+			if err := decoder.Decode(&resp); err != nil {
+				return "", err
+			}
+			list = append(list, resp.Value...)
+
+			return resp.NextLink, nil
+		})
+
+	return list, err
+}
+
+// ListVirtualNetworks returns all the virtual networks in the resource group
+func (c *client) ListVirtualNetworks(ctx context.Context, subscription, group string) ([]*VirtualNetwork, error) {
+	var list []*VirtualNetwork
+
+	err := c.list(ctx, fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/virtualNetworks", subscription, group),
+		networkVersion,
+		func(decoder *json.Decoder) (string, error) {
+			resp := struct {
+				NextLink string            `json:"nextLink"`
+				Value    []*VirtualNetwork `json:"value"`
+			}{}
+			if err := decoder.Decode(&resp); err != nil {
+				return "", err
+			}
+			list = append(list, resp.Value...)
+
+			return resp.NextLink, nil
+		})
+
+	return list, err
+}
+
+// endpoint returns the full url for the resource path
+func (c *client) endpoint(path, version string) string {
+	values := url.Values{}
+	values.Set("api-version", version)
+
+	return fmt.Sprintf("%s/%s?%s", strings.TrimSuffix(ManagementEndpoint, "/"), strings.TrimPrefix(path, "/"), values.Encode())
+}
+
+// list is used to iterate the pages of a list response, the handler returns the next link
+func (c *client) list(ctx context.Context, path, version string, fn func(*json.Decoder) (string, error)) error {
+	next := c.endpoint(path, version)
+
+	for next != "" {
+		if err := c.do(ctx, next, func(decoder *json.Decoder) error {
+			var err error
+			next, err = fn(decoder)
+
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// do performs the request and hands the decoder to the handler
+func (c *client) do(ctx context.Context, endpoint string, handler func(*json.Decoder) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{StatusCode: resp.StatusCode, URL: endpoint}
+	}
+
+	return handler(json.NewDecoder(resp.Body))
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+var (
+	// AuthorityEndpoint is the base url for the azure active directory
+	AuthorityEndpoint = "https://login.microsoftonline.com"
+)
+
+// Credentials are the credentials used to authenticate to azure
+type Credentials struct {
+	// ClientID is the application id
+	ClientID string
+	// ClientSecret is the application secret, used when not using a federated token
+	ClientSecret string
+	// FederatedTokenFile is the path to a federated token used for workload identity
+	FederatedTokenFile string
+	// TenantID is the azure active directory tenant
+	TenantID string
+}
+
+// NewCredentialsFromEnv returns the credentials from the environment, these are the same
+// environment variables consumed by the azurerm terraform provider, falling back to those
+// injected by azure workload identity
+func NewCredentialsFromEnv() Credentials {
+	lookup := func(keys ...string) string {
+		for _, key := range keys {
+			if value := os.Getenv(key); value != "" {
+				return value
+			}
+		}
+
+		return ""
+	}
+
+	return Credentials{
+		ClientID:           lookup("ARM_CLIENT_ID", "AZURE_CLIENT_ID"),
+		ClientSecret:       lookup("ARM_CLIENT_SECRET", "AZURE_CLIENT_SECRET"),
+		FederatedTokenFile: lookup("ARM_OIDC_TOKEN_FILE_PATH", "AZURE_FEDERATED_TOKEN_FILE"),
+		TenantID:           lookup("ARM_TENANT_ID", "AZURE_TENANT_ID"),
+	}
+}
+
+// NewHTTPClient returns a http client authenticated to the azure resource manager
+func NewHTTPClient(ctx context.Context, credentials Credentials) (*http.Client, error) {
+	switch {
+	case credentials.ClientID == "":
+		return nil, errors.New("client id is required")
+	case credentials.TenantID == "":
+		return nil, errors.New("tenant id is required")
+	case credentials.ClientSecret == "" && credentials.FederatedTokenFile == "":
+		return nil, errors.New("client secret or federated token file is required")
+	}
+
+	return oauth2.NewClient(ctx, oauth2.ReuseTokenSource(nil, &tokenSource{
+		ctx:         ctx,
+		credentials: credentials,
+	})), nil
+}
+
+// tokenSource retrieves access tokens using the client credentials flow
+type tokenSource struct {
+	// ctx is the context used for the token requests
+	ctx context.Context
+	// credentials are the credentials used to request the token
+	credentials Credentials
+}
+
+// Token implements the oauth2.TokenSource interface
+func (t *tokenSource) Token() (*oauth2.Token, error) {
+	values := url.Values{}
+	values.Set("client_id", t.credentials.ClientID)
+	values.Set("grant_type", "client_credentials")
+	values.Set("scope", strings.TrimSuffix(ManagementEndpoint, "/")+"/.default")
+
+	if t.credentials.FederatedTokenFile != "" {
+		assertion, err := os.ReadFile(t.credentials.FederatedTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the federated token file, error: %w", err)
+		}
+		values.Set("client_assertion", strings.TrimSpace(string(assertion)))
+		values.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	} else {
+		values.Set("client_secret", t.credentials.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodPost,
+		fmt.Sprintf("%s/%s/oauth2/v2.0/token", AuthorityEndpoint, t.credentials.TenantID),
+		strings.NewReader(values.Encode()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to retrieve access token, status code: %d", resp.StatusCode)
+	}
+
+	token := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+		TokenType   string `json:"token_type"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Token{
+		AccessToken: token.AccessToken,
+		Expiry:      time.Now().Add(time.Duration(token.ExpiresIn) * time.Second),
+		TokenType:   token.TokenType,
+	}, nil
+}
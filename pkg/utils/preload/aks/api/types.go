@@ -0,0 +1,158 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package api
+
+// ManagedCluster is the subset of the AKS cluster definition used by the preloader
+type ManagedCluster struct {
+	// ID is the resource id of the cluster
+	ID string `json:"id,omitempty"`
+	// Location is the region the cluster resides in
+	Location string `json:"location,omitempty"`
+	// Name is the name of the cluster
+	Name string `json:"name,omitempty"`
+	// Properties are the properties of the cluster
+	Properties ManagedClusterProperties `json:"properties"`
+	// Tags are the resource tags applied to the cluster
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// ManagedClusterProperties are the properties of the AKS cluster
+type ManagedClusterProperties struct {
+	// AgentPoolProfiles is a list of node pools in the cluster
+	AgentPoolProfiles []AgentPoolProfile `json:"agentPoolProfiles,omitempty"`
+	// APIServerAccessProfile holds the control plane access configuration
+	APIServerAccessProfile *APIServerAccessProfile `json:"apiServerAccessProfile,omitempty"`
+	// FQDN is the fully qualified domain name of the control plane
+	FQDN string `json:"fqdn,omitempty"`
+	// KubernetesVersion is the version of the cluster
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+	// NetworkProfile holds the networking configuration of the cluster
+	NetworkProfile *NetworkProfile `json:"networkProfile,omitempty"`
+	// NodeResourceGroup is the resource group holding the cluster infrastructure
+	NodeResourceGroup string `json:"nodeResourceGroup,omitempty"`
+	// OIDCIssuerProfile holds the oidc issuer configuration
+	OIDCIssuerProfile *OIDCIssuerProfile `json:"oidcIssuerProfile,omitempty"`
+	// PrivateFQDN is the private domain name of the control plane
+	PrivateFQDN string `json:"privateFQDN,omitempty"`
+	// ProvisioningState is the current state of the cluster
+	ProvisioningState string `json:"provisioningState,omitempty"`
+}
+
+// AgentPoolProfile is a node pool in the cluster
+type AgentPoolProfile struct {
+	// Name is the name of the node pool
+	Name string `json:"name,omitempty"`
+	// VnetSubnetID is the subnet the node pool is connected to
+	VnetSubnetID string `json:"vnetSubnetID,omitempty"`
+}
+
+// APIServerAccessProfile holds the control plane access configuration
+type APIServerAccessProfile struct {
+	// AuthorizedIPRanges are the ranges permitted to access the control plane
+	AuthorizedIPRanges []string `json:"authorizedIPRanges,omitempty"`
+	// EnablePrivateCluster indicates the control plane is private
+	EnablePrivateCluster bool `json:"enablePrivateCluster,omitempty"`
+}
+
+// NetworkProfile holds the networking configuration of the cluster
+type NetworkProfile struct {
+	// NetworkPlugin is the network plugin used by the cluster
+	NetworkPlugin string `json:"networkPlugin,omitempty"`
+	// PodCidr is the range used for pod addresses
+	PodCidr string `json:"podCidr,omitempty"`
+	// ServiceCidr is the range used for service addresses
+	ServiceCidr string `json:"serviceCidr,omitempty"`
+}
+
+// OIDCIssuerProfile holds the oidc issuer configuration
+type OIDCIssuerProfile struct {
+	// Enabled indicates the oidc issuer is enabled
+	Enabled bool `json:"enabled,omitempty"`
+	// IssuerURL is the url of the oidc issuer
+	IssuerURL string `json:"issuerURL,omitempty"`
+}
+
+// VirtualNetwork is the subset of a virtual network used by the preloader
+type VirtualNetwork struct {
+	// ID is the resource id of the virtual network
+	ID string `json:"id,omitempty"`
+	// Name is the name of the virtual network
+	Name string `json:"name,omitempty"`
+	// Properties are the properties of the virtual network
+	Properties VirtualNetworkProperties `json:"properties"`
+	// Tags are the resource tags applied to the virtual network
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// VirtualNetworkProperties are the properties of the virtual network
+type VirtualNetworkProperties struct {
+	// AddressSpace is the address space of the virtual network
+	AddressSpace struct {
+		// AddressPrefixes is a list of address ranges
+		AddressPrefixes []string `json:"addressPrefixes,omitempty"`
+	} `json:"addressSpace"`
+	// Subnets is a list of subnets within the virtual network
+	Subnets []Subnet `json:"subnets,omitempty"`
+}
+
+// Subnet is a subnet within a virtual network
+type Subnet struct {
+	// ID is the resource id of the subnet
+	ID string `json:"id,omitempty"`
+	// Name is the name of the subnet
+	Name string `json:"name,omitempty"`
+	// Properties are the properties of the subnet
+	Properties SubnetProperties `json:"properties"`
+}
+
+// SubnetProperties are the properties of the subnet
+type SubnetProperties struct {
+	// AddressPrefix is the address range of the subnet
+	AddressPrefix string `json:"addressPrefix,omitempty"`
+	// NetworkSecurityGroup is a reference to the associated network security group
+	NetworkSecurityGroup *Reference `json:"networkSecurityGroup,omitempty"`
+	// RouteTable is a reference to the associated route table
+	RouteTable *Reference `json:"routeTable,omitempty"`
+}
+
+// Reference is a reference to another resource
+type Reference struct {
+	// ID is the resource id
+	ID string `json:"id,omitempty"`
+}
+
+// NetworkSecurityGroup is the subset of a network security group used by the preloader
+type NetworkSecurityGroup struct {
+	// ID is the resource id of the network security group
+	ID string `json:"id,omitempty"`
+	// Name is the name of the network security group
+	Name string `json:"name,omitempty"`
+}
+
+// KeyVault is the subset of a key vault used by the preloader
+type KeyVault struct {
+	// ID is the resource id of the key vault
+	ID string `json:"id,omitempty"`
+	// Name is the name of the key vault
+	Name string `json:"name,omitempty"`
+	// Properties are the properties of the key vault
+	Properties struct {
+		// VaultURI is the uri of the key vault
+		VaultURI string `json:"vaultUri,omitempty"`
+	} `json:"properties"`
+}
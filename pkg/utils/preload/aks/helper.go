@@ -0,0 +1,71 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aks
+
+import (
+	"sort"
+	"strings"
+)
+
+// ResourceGroup returns the resource group from the resource id
+func ResourceGroup(id string) string {
+	return resourceSegment(id, "resourceGroups")
+}
+
+// VirtualNetworkID returns the virtual network resource id from a subnet resource id
+func VirtualNetworkID(subnet string) string {
+	index := strings.Index(strings.ToLower(subnet), "/subnets/")
+	if index < 0 {
+		return ""
+	}
+
+	return subnet[:index]
+}
+
+// ToMapTags converts the resource tags to a list of key and values
+func ToMapTags(tags map[string]string) []map[string]interface{} {
+	var list []map[string]interface{}
+	var keys []string
+
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		list = append(list, map[string]interface{}{
+			"key":    key,
+			"values": []string{tags[key]},
+		})
+	}
+
+	return list
+}
+
+// resourceSegment returns the value following the named segment in the resource id
+func resourceSegment(id, name string) string {
+	items := strings.Split(strings.Trim(id, "/"), "/")
+
+	for i := 0; i < len(items)-1; i++ {
+		if strings.EqualFold(items[i], name) {
+			return items[i+1]
+		}
+	}
+
+	return ""
+}
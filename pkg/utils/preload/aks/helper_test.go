@@ -0,0 +1,46 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceGroup(t *testing.T) {
+	assert.Equal(t, "", ResourceGroup(""))
+	assert.Equal(t, "test", ResourceGroup("/subscriptions/sub/resourceGroups/test/providers/Microsoft.Network/virtualNetworks/main"))
+	assert.Equal(t, "test", ResourceGroup("/subscriptions/sub/resourcegroups/test"))
+}
+
+func TestVirtualNetworkID(t *testing.T) {
+	assert.Equal(t, "", VirtualNetworkID(""))
+	assert.Equal(t,
+		"/subscriptions/sub/resourceGroups/test/providers/Microsoft.Network/virtualNetworks/main",
+		VirtualNetworkID("/subscriptions/sub/resourceGroups/test/providers/Microsoft.Network/virtualNetworks/main/subnets/nodes"),
+	)
+}
+
+func TestToMapTags(t *testing.T) {
+	assert.Nil(t, ToMapTags(nil))
+	assert.Equal(t, []map[string]interface{}{
+		{"key": "a", "values": []string{"1"}},
+		{"key": "b", "values": []string{"2"}},
+	}, ToMapTags(map[string]string{"b": "2", "a": "1"}))
+}
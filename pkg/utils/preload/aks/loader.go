@@ -0,0 +1,363 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appvia/terranetes-controller/pkg/utils"
+	"github.com/appvia/terranetes-controller/pkg/utils/preload"
+	"github.com/appvia/terranetes-controller/pkg/utils/preload/aks/api"
+)
+
+// aksPreloader is a preloader for AKS clusters
+type aksPreloader struct {
+	// clusterName is the name of the AKS cluster to preload
+	clusterName string
+	// containercc is a client to the container service API
+	containercc api.ContainerServiceAPI
+	// keyvaultcc is a client to the key vault API
+	keyvaultcc api.KeyVaultAPI
+	// networkcc is a client to the network API
+	networkcc api.NetworkAPI
+	// region is the location of the cluster
+	region string
+	// subscription is the subscription the cluster resides in
+	subscription string
+}
+
+// New creates and returns a preloader for AKS clusters
+func New(config Config) (preload.Interface, error) {
+	switch {
+	case config.Client == nil:
+		return nil, errors.New("client is required")
+	case config.ClusterName == "":
+		return nil, errors.New("cluster name is required")
+	case config.SubscriptionID == "":
+		return nil, errors.New("subscription id is required")
+	}
+	cc := api.NewClient(config.Client)
+
+	return &aksPreloader{
+		clusterName:  config.ClusterName,
+		containercc:  cc,
+		keyvaultcc:   cc,
+		networkcc:    cc,
+		region:       config.Region,
+		subscription: config.SubscriptionID,
+	}, nil
+}
+
+// Load implements the preload.Interface and used to retrieve details on an AKS cluster
+func (a *aksPreloader) Load(ctx context.Context) (preload.Data, error) {
+	data := make(preload.Data)
+
+	// @step: first we check the cluster exists and extract the cluster details
+	cluster, err := a.findManagedCluster(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger := log.WithFields(log.Fields{
+		"cluster": cluster.Name,
+		"status":  strings.ToLower(cluster.Properties.ProvisioningState),
+	})
+	logger.Debug("retrieved details on the aks cluster")
+
+	// @step: ensure the cluster is condition we can query it
+	switch cluster.Properties.ProvisioningState {
+	case "Creating", "Deleting", "Failed":
+		return nil, preload.ErrNotReady
+	case "Succeeded", "Updating", "Upgrading", "Scaling", "Starting", "Stopping", "Canceled":
+		break
+	default:
+		return nil, fmt.Errorf("unknown cluster status: %s", cluster.Properties.ProvisioningState)
+	}
+
+	data.Add("region", preload.Entry{
+		Description: "Azure region the cluster is running in",
+		Value:       cluster.Location,
+	})
+	data.Add("resource_group", preload.Entry{
+		Description: "The resource group the AKS cluster resides in",
+		Value:       ResourceGroup(cluster.ID),
+	})
+	data.Add("subscription_id", preload.Entry{
+		Description: "The Azure subscription the cluster resides in",
+		Value:       a.subscription,
+	})
+
+	// @step: extract the cluster details
+	a.findCluster(cluster, &data)
+
+	// @step: lets discover the virtual network and subnets
+	network, err := a.findVirtualNetwork(ctx, cluster, &data)
+	if err != nil {
+		return nil, err
+	}
+	// @step: lets discover the network security groups
+	if err := a.findNetworkSecurityGroups(ctx, cluster, network, &data); err != nil {
+		return nil, err
+	}
+	// @step: find any key vaults in the cluster resource group
+	if err := a.findKeyVaults(ctx, cluster, &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// findManagedCluster is responsible for finding the cluster within the subscription
+func (a *aksPreloader) findManagedCluster(ctx context.Context) (*api.ManagedCluster, error) {
+	list, err := a.containercc.ListManagedClusters(ctx, a.subscription)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the aks cluster details, error: %w", err)
+	}
+
+	for _, cluster := range list {
+		if cluster.Name != a.clusterName {
+			continue
+		}
+		if a.region != "" && !strings.EqualFold(cluster.Location, a.region) {
+			continue
+		}
+
+		return cluster, nil
+	}
+
+	return nil, fmt.Errorf("failed to find aks cluster: %s", a.clusterName)
+}
+
+// findCluster is responsible for extracting the cluster details into the data structure
+func (a *aksPreloader) findCluster(cluster *api.ManagedCluster, data *preload.Data) {
+	var subnets []string
+	for _, pool := range cluster.Properties.AgentPoolProfiles {
+		if pool.VnetSubnetID != "" {
+			subnets = append(subnets, pool.VnetSubnetID)
+		}
+	}
+
+	data.Add("aks", preload.Entry{
+		Description: "The resource id of the Kubernetes cluster",
+		Value:       cluster.ID,
+	})
+	data.Add("aks_fqdn", preload.Entry{
+		Description: "The fully qualified domain name of the AKS cluster control plane",
+		Value:       cluster.Properties.FQDN,
+	})
+	data.Add("aks_name", preload.Entry{
+		Description: "The name of the AKS cluster",
+		Value:       cluster.Name,
+	})
+	data.Add("aks_node_resource_group", preload.Entry{
+		Description: "The resource group holding the infrastructure of the AKS cluster",
+		Value:       cluster.Properties.NodeResourceGroup,
+	})
+	data.Add("aks_private_fqdn", preload.Entry{
+		Description: "The private domain name of the AKS cluster control plane",
+		Value:       cluster.Properties.PrivateFQDN,
+	})
+	data.Add("aks_subnet_ids", preload.Entry{
+		Description: "The subnets associated to the AKS node pools",
+		Value:       utils.Unique(utils.Sorted(subnets)),
+	})
+	data.Add("aks_tags", preload.Entry{
+		Description: "The resource tags associated to the AKS cluster",
+		Value:       ToMapTags(cluster.Tags),
+	})
+	data.Add("aks_version", preload.Entry{
+		Description: "The current Kubernetes version of the AKS cluster",
+		Value:       cluster.Properties.KubernetesVersion,
+	})
+
+	if profile := cluster.Properties.APIServerAccessProfile; profile != nil {
+		data.Add("aks_authorized_ip_ranges", preload.Entry{
+			Description: "The CIDR blocks that are allowed access to the AKS cluster control plane",
+			Value:       profile.AuthorizedIPRanges,
+		})
+		data.Add("aks_private_cluster", preload.Entry{
+			Description: "Indicates whether or not the AKS cluster control plane is private",
+			Value:       profile.EnablePrivateCluster,
+		})
+	}
+	if profile := cluster.Properties.NetworkProfile; profile != nil {
+		data.Add("aks_network_plugin", preload.Entry{
+			Description: "The network plugin used by the AKS cluster",
+			Value:       profile.NetworkPlugin,
+		})
+		data.Add("aks_pod_cidr", preload.Entry{
+			Description: "The CIDR block used by the AKS cluster for pod addresses",
+			Value:       profile.PodCidr,
+		})
+		data.Add("aks_service_cidr", preload.Entry{
+			Description: "The CIDR block used by the AKS cluster for Kubernetes service addresses",
+			Value:       profile.ServiceCidr,
+		})
+	}
+	if profile := cluster.Properties.OIDCIssuerProfile; profile != nil && profile.Enabled {
+		data.Add("aks_oidc_issuer", preload.Entry{
+			Description: "The OIDC issuer URL of the AKS cluster",
+			Value:       profile.IssuerURL,
+		})
+	}
+}
+
+// findVirtualNetwork is responsible for finding the virtual network the cluster is connected to,
+// either via the node pool subnets or the network managed within the node resource group
+func (a *aksPreloader) findVirtualNetwork(ctx context.Context, cluster *api.ManagedCluster, data *preload.Data) (*api.VirtualNetwork, error) {
+	var network *api.VirtualNetwork
+
+	if subnets := data.Get("aks_subnet_ids").Value.([]string); len(subnets) > 0 {
+		resp, err := a.networkcc.GetVirtualNetwork(ctx, VirtualNetworkID(subnets[0]))
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve virtual network for cluster: %s, error: %w", a.clusterName, err)
+		}
+		network = resp
+	} else {
+		list, err := a.networkcc.ListVirtualNetworks(ctx, a.subscription, cluster.Properties.NodeResourceGroup)
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve virtual networks for cluster: %s, error: %w", a.clusterName, err)
+		}
+		if len(list) == 0 {
+			log.WithField("cluster", a.clusterName).Warn("no virtual network found for the aks cluster")
+
+			return nil, nil
+		}
+		network = list[0]
+	}
+
+	data.Add("vnet_address_space", preload.Entry{
+		Description: "The address ranges of the virtual network the AKS cluster is connected to",
+		Value:       network.Properties.AddressSpace.AddressPrefixes,
+	})
+	data.Add("vnet_id", preload.Entry{
+		Description: "The resource id of the virtual network the AKS cluster is connected to",
+		Value:       network.ID,
+	})
+	data.Add("vnet_name", preload.Entry{
+		Description: "The name of the virtual network the AKS cluster is connected to",
+		Value:       network.Name,
+	})
+	data.Add("vnet_resource_group", preload.Entry{
+		Description: "The resource group of the virtual network the AKS cluster is connected to",
+		Value:       ResourceGroup(network.ID),
+	})
+
+	var subnets, routes []string
+
+	for _, subnet := range network.Properties.Subnets {
+		name := preload.SanitizeName(subnet.Name)
+		subnets = append(subnets, subnet.ID)
+
+		data.Add(fmt.Sprintf("subnet_%s_cidr", name), preload.Entry{
+			Description: fmt.Sprintf("The address range of the subnet named %s", subnet.Name),
+			Value:       subnet.Properties.AddressPrefix,
+		})
+		data.Add(fmt.Sprintf("subnet_%s_id", name), preload.Entry{
+			Description: fmt.Sprintf("The resource id of the subnet named %s", subnet.Name),
+			Value:       subnet.ID,
+		})
+		if subnet.Properties.RouteTable != nil {
+			routes = append(routes, subnet.Properties.RouteTable.ID)
+
+			data.Add(fmt.Sprintf("subnet_%s_route_table_id", name), preload.Entry{
+				Description: fmt.Sprintf("The route table associated to the subnet named %s", subnet.Name),
+				Value:       subnet.Properties.RouteTable.ID,
+			})
+		}
+		if subnet.Properties.NetworkSecurityGroup != nil {
+			data.Add(fmt.Sprintf("subnet_%s_network_security_group_id", name), preload.Entry{
+				Description: fmt.Sprintf("The network security group associated to the subnet named %s", subnet.Name),
+				Value:       subnet.Properties.NetworkSecurityGroup.ID,
+			})
+		}
+	}
+
+	data.Add("route_tables_ids", preload.Entry{
+		Description: "A list of all route tables ids associated to the virtual network the AKS cluster is connected to",
+		Value:       utils.Unique(utils.Sorted(routes)),
+	})
+	data.Add("subnet_ids", preload.Entry{
+		Description: "A list of all subnets associated to the virtual network the AKS cluster is connected to",
+		Value:       utils.Unique(utils.Sorted(subnets)),
+	})
+
+	return network, nil
+}
+
+// findNetworkSecurityGroups is responsible for finding the network security groups in the
+// node resource group and the resource group of the virtual network
+func (a *aksPreloader) findNetworkSecurityGroups(ctx context.Context, cluster *api.ManagedCluster, network *api.VirtualNetwork, data *preload.Data) error {
+	groups := []string{cluster.Properties.NodeResourceGroup}
+	if network != nil {
+		groups = append(groups, ResourceGroup(network.ID))
+	}
+
+	for _, group := range utils.Unique(utils.Sorted(groups)) {
+		if group == "" {
+			continue
+		}
+
+		list, err := a.networkcc.ListNetworkSecurityGroups(ctx, a.subscription, group)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve network security groups in resource group: %s, error: %w", group, err)
+		}
+		for _, nsg := range list {
+			data.Add(fmt.Sprintf("network_security_group_%s_id", preload.SanitizeName(nsg.Name)), preload.Entry{
+				Description: fmt.Sprintf("The resource id of the network security group named %s", nsg.Name),
+				Value:       nsg.ID,
+			})
+		}
+	}
+
+	return nil
+}
+
+// findKeyVaults is responsible for finding any key vaults in the cluster resource group
+func (a *aksPreloader) findKeyVaults(ctx context.Context, cluster *api.ManagedCluster, data *preload.Data) error {
+	list, err := a.keyvaultcc.ListKeyVaults(ctx, a.subscription, ResourceGroup(cluster.ID))
+	if err != nil {
+		return fmt.Errorf("unable to retrieve key vaults for cluster: %s, error: %w", a.clusterName, err)
+	}
+
+	var ids []string
+
+	for _, vault := range list {
+		ids = append(ids, vault.ID)
+
+		data.Add(fmt.Sprintf("key_vault_%s_id", preload.SanitizeName(vault.Name)), preload.Entry{
+			Description: fmt.Sprintf("The resource id of the key vault named %s", vault.Name),
+			Value:       vault.ID,
+		})
+		data.Add(fmt.Sprintf("key_vault_%s_uri", preload.SanitizeName(vault.Name)), preload.Entry{
+			Description: fmt.Sprintf("The uri of the key vault named %s", vault.Name),
+			Value:       vault.Properties.VaultURI,
+		})
+	}
+
+	data.Add("key_vault_ids", preload.Entry{
+		Description: "A list of all the key vaults in the resource group of the AKS cluster",
+		Value:       utils.Unique(utils.Sorted(ids)),
+	})
+
+	return nil
+}
@@ -0,0 +1,240 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/appvia/terranetes-controller/pkg/utils/preload"
+	"github.com/appvia/terranetes-controller/pkg/utils/preload/aks/api"
+	"github.com/appvia/terranetes-controller/pkg/utils/preload/aks/mocks"
+)
+
+//go:generate go run ../../../../vendor/github.com/golang/mock/mockgen -package mocks -destination=mocks/api_zz.go github.com/appvia/terranetes-controller/pkg/utils/preload/aks/api ContainerServiceAPI,KeyVaultAPI,NetworkAPI
+
+func TestReconcile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Running Test Suite")
+}
+
+var _ = Describe("AKS Preload", func() {
+	logrus.SetOutput(io.Discard)
+
+	var err error
+	var mc *gomock.Controller
+	var containercc *mocks.MockContainerServiceAPI
+	var keyvaultcc *mocks.MockKeyVaultAPI
+	var networkcc *mocks.MockNetworkAPI
+	var loader *aksPreloader
+	var data preload.Data
+
+	subnetID := "/subscriptions/sub/resourceGroups/network/providers/Microsoft.Network/virtualNetworks/main/subnets/nodes"
+
+	expectedCluster := &api.ManagedCluster{
+		ID:       "/subscriptions/sub/resourceGroups/clusters/providers/Microsoft.ContainerService/managedClusters/test",
+		Location: "uksouth",
+		Name:     "test",
+		Properties: api.ManagedClusterProperties{
+			AgentPoolProfiles: []api.AgentPoolProfile{
+				{Name: "system", VnetSubnetID: subnetID},
+			},
+			FQDN:              "test.hcp.uksouth.azmk8s.io",
+			KubernetesVersion: "1.27.3",
+			NetworkProfile: &api.NetworkProfile{
+				NetworkPlugin: "azure",
+				ServiceCidr:   "10.0.0.0/16",
+			},
+			NodeResourceGroup: "MC_clusters_test_uksouth",
+			OIDCIssuerProfile: &api.OIDCIssuerProfile{
+				Enabled:   true,
+				IssuerURL: "https://uksouth.oic.prod-aks.azure.com/tenant/id/",
+			},
+			ProvisioningState: "Succeeded",
+		},
+		Tags: map[string]string{"team": "platform"},
+	}
+
+	BeforeEach(func() {
+		mc = gomock.NewController(GinkgoT())
+		containercc = mocks.NewMockContainerServiceAPI(mc)
+		keyvaultcc = mocks.NewMockKeyVaultAPI(mc)
+		networkcc = mocks.NewMockNetworkAPI(mc)
+
+		loader = &aksPreloader{
+			clusterName:  "test",
+			containercc:  containercc,
+			keyvaultcc:   keyvaultcc,
+			networkcc:    networkcc,
+			region:       "uksouth",
+			subscription: "sub",
+		}
+	})
+
+	AfterEach(func() {
+		mc.Finish()
+	})
+
+	When("creating a new preloader", func() {
+		It("should fail when missing the client", func() {
+			_, err := New(Config{ClusterName: "test", SubscriptionID: "sub"})
+			Expect(err).To(MatchError("client is required"))
+		})
+
+		It("should fail when missing the subscription", func() {
+			_, err := New(Config{Client: http.DefaultClient, ClusterName: "test"})
+			Expect(err).To(MatchError("subscription id is required"))
+		})
+
+		It("should return a preloader", func() {
+			loader, err := New(Config{Client: http.DefaultClient, ClusterName: "test", SubscriptionID: "sub"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loader).ToNot(BeNil())
+		})
+	})
+
+	When("loading the preload data for the cluster", func() {
+		Context("when listing the clusters errors", func() {
+			BeforeEach(func() {
+				containercc.EXPECT().ListManagedClusters(gomock.Any(), "sub").Return(nil, errors.New("bad"))
+
+				data, err = loader.Load(context.Background())
+			})
+
+			It("should return an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(MatchError("failed to retrieve the aks cluster details, error: bad"))
+			})
+
+			It("should not return any data", func() {
+				Expect(data).To(BeNil())
+			})
+		})
+
+		Context("when the cluster is not found", func() {
+			BeforeEach(func() {
+				containercc.EXPECT().ListManagedClusters(gomock.Any(), "sub").Return([]*api.ManagedCluster{
+					{Name: "test", Location: "westeurope"},
+					{Name: "other", Location: "uksouth"},
+				}, nil)
+
+				data, err = loader.Load(context.Background())
+			})
+
+			It("should return an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(MatchError("failed to find aks cluster: test"))
+			})
+		})
+
+		Context("when the cluster is being created", func() {
+			BeforeEach(func() {
+				containercc.EXPECT().ListManagedClusters(gomock.Any(), "sub").Return([]*api.ManagedCluster{
+					{Name: "test", Location: "uksouth", Properties: api.ManagedClusterProperties{ProvisioningState: "Creating"}},
+				}, nil)
+
+				data, err = loader.Load(context.Background())
+			})
+
+			It("should indicate the cluster is not ready", func() {
+				Expect(err).To(Equal(preload.ErrNotReady))
+			})
+		})
+
+		Context("when the cluster is found", func() {
+			BeforeEach(func() {
+				containercc.EXPECT().ListManagedClusters(gomock.Any(), "sub").Return([]*api.ManagedCluster{expectedCluster}, nil)
+
+				network := &api.VirtualNetwork{
+					ID:   "/subscriptions/sub/resourceGroups/network/providers/Microsoft.Network/virtualNetworks/main",
+					Name: "main",
+					Properties: api.VirtualNetworkProperties{
+						Subnets: []api.Subnet{
+							{
+								ID:   subnetID,
+								Name: "nodes",
+								Properties: api.SubnetProperties{
+									AddressPrefix:        "10.1.0.0/24",
+									NetworkSecurityGroup: &api.Reference{ID: "nsg-nodes"},
+									RouteTable:           &api.Reference{ID: "rt-nodes"},
+								},
+							},
+						},
+					},
+				}
+				network.Properties.AddressSpace.AddressPrefixes = []string{"10.1.0.0/16"}
+
+				networkcc.EXPECT().GetVirtualNetwork(gomock.Any(),
+					"/subscriptions/sub/resourceGroups/network/providers/Microsoft.Network/virtualNetworks/main",
+				).Return(network, nil)
+				networkcc.EXPECT().ListNetworkSecurityGroups(gomock.Any(), "sub", "MC_clusters_test_uksouth").Return([]*api.NetworkSecurityGroup{
+					{ID: "nsg-agentpool", Name: "aks-agentpool"},
+				}, nil)
+				networkcc.EXPECT().ListNetworkSecurityGroups(gomock.Any(), "sub", "network").Return([]*api.NetworkSecurityGroup{
+					{ID: "nsg-nodes", Name: "nodes"},
+				}, nil)
+				keyvaultcc.EXPECT().ListKeyVaults(gomock.Any(), "sub", "clusters").Return([]*api.KeyVault{
+					{ID: "kv-platform", Name: "platform"},
+				}, nil)
+
+				data, err = loader.Load(context.Background())
+			})
+
+			It("should not error", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).ToNot(BeNil())
+			})
+
+			It("should have the cluster details", func() {
+				Expect(data.Get("subscription_id").Value).To(Equal("sub"))
+				Expect(data.Get("region").Value).To(Equal("uksouth"))
+				Expect(data.Get("resource_group").Value).To(Equal("clusters"))
+				Expect(data.Get("aks_name").Value).To(Equal("test"))
+				Expect(data.Get("aks_oidc_issuer").Value).To(Equal("https://uksouth.oic.prod-aks.azure.com/tenant/id/"))
+				Expect(data.Get("aks_subnet_ids").Value).To(Equal([]string{subnetID}))
+			})
+
+			It("should have the network details", func() {
+				Expect(data.Get("vnet_name").Value).To(Equal("main"))
+				Expect(data.Get("vnet_resource_group").Value).To(Equal("network"))
+				Expect(data.Get("subnet_ids").Value).To(Equal([]string{subnetID}))
+				Expect(data.Get("subnet_nodes_cidr").Value).To(Equal("10.1.0.0/24"))
+				Expect(data.Get("subnet_nodes_route_table_id").Value).To(Equal("rt-nodes"))
+				Expect(data.Get("subnet_nodes_network_security_group_id").Value).To(Equal("nsg-nodes"))
+			})
+
+			It("should have the network security groups", func() {
+				Expect(data.Get("network_security_group_aks_agentpool_id").Value).To(Equal("nsg-agentpool"))
+				Expect(data.Get("network_security_group_nodes_id").Value).To(Equal("nsg-nodes"))
+			})
+
+			It("should have the key vaults", func() {
+				Expect(data.Get("key_vault_platform_id").Value).To(Equal("kv-platform"))
+				Expect(data.Get("key_vault_ids").Value).To(Equal([]string{"kv-platform"}))
+			})
+		})
+	})
+})
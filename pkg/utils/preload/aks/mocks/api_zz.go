@@ -0,0 +1,158 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/appvia/terranetes-controller/pkg/utils/preload/aks/api (interfaces: ContainerServiceAPI,KeyVaultAPI,NetworkAPI)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+
+	api "github.com/appvia/terranetes-controller/pkg/utils/preload/aks/api"
+)
+
+// MockContainerServiceAPI is a mock of ContainerServiceAPI interface.
+type MockContainerServiceAPI struct {
+	ctrl     *gomock.Controller
+	recorder *MockContainerServiceAPIMockRecorder
+}
+
+// MockContainerServiceAPIMockRecorder is the mock recorder for MockContainerServiceAPI.
+type MockContainerServiceAPIMockRecorder struct {
+	mock *MockContainerServiceAPI
+}
+
+// NewMockContainerServiceAPI creates a new mock instance.
+func NewMockContainerServiceAPI(ctrl *gomock.Controller) *MockContainerServiceAPI {
+	mock := &MockContainerServiceAPI{ctrl: ctrl}
+	mock.recorder = &MockContainerServiceAPIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockContainerServiceAPI) EXPECT() *MockContainerServiceAPIMockRecorder {
+	return m.recorder
+}
+
+// ListManagedClusters mocks base method.
+func (m *MockContainerServiceAPI) ListManagedClusters(arg0 context.Context, arg1 string) ([]*api.ManagedCluster, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListManagedClusters", arg0, arg1)
+	ret0, _ := ret[0].([]*api.ManagedCluster)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListManagedClusters indicates an expected call of ListManagedClusters.
+func (mr *MockContainerServiceAPIMockRecorder) ListManagedClusters(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListManagedClusters", reflect.TypeOf((*MockContainerServiceAPI)(nil).ListManagedClusters), arg0, arg1)
+}
+
+// MockKeyVaultAPI is a mock of KeyVaultAPI interface.
+type MockKeyVaultAPI struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeyVaultAPIMockRecorder
+}
+
+// MockKeyVaultAPIMockRecorder is the mock recorder for MockKeyVaultAPI.
+type MockKeyVaultAPIMockRecorder struct {
+	mock *MockKeyVaultAPI
+}
+
+// NewMockKeyVaultAPI creates a new mock instance.
+func NewMockKeyVaultAPI(ctrl *gomock.Controller) *MockKeyVaultAPI {
+	mock := &MockKeyVaultAPI{ctrl: ctrl}
+	mock.recorder = &MockKeyVaultAPIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKeyVaultAPI) EXPECT() *MockKeyVaultAPIMockRecorder {
+	return m.recorder
+}
+
+// ListKeyVaults mocks base method.
+func (m *MockKeyVaultAPI) ListKeyVaults(arg0 context.Context, arg1, arg2 string) ([]*api.KeyVault, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListKeyVaults", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*api.KeyVault)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListKeyVaults indicates an expected call of ListKeyVaults.
+func (mr *MockKeyVaultAPIMockRecorder) ListKeyVaults(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKeyVaults", reflect.TypeOf((*MockKeyVaultAPI)(nil).ListKeyVaults), arg0, arg1, arg2)
+}
+
+// MockNetworkAPI is a mock of NetworkAPI interface.
+type MockNetworkAPI struct {
+	ctrl     *gomock.Controller
+	recorder *MockNetworkAPIMockRecorder
+}
+
+// MockNetworkAPIMockRecorder is the mock recorder for MockNetworkAPI.
+type MockNetworkAPIMockRecorder struct {
+	mock *MockNetworkAPI
+}
+
+// NewMockNetworkAPI creates a new mock instance.
+func NewMockNetworkAPI(ctrl *gomock.Controller) *MockNetworkAPI {
+	mock := &MockNetworkAPI{ctrl: ctrl}
+	mock.recorder = &MockNetworkAPIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNetworkAPI) EXPECT() *MockNetworkAPIMockRecorder {
+	return m.recorder
+}
+
+// GetVirtualNetwork mocks base method.
+func (m *MockNetworkAPI) GetVirtualNetwork(arg0 context.Context, arg1 string) (*api.VirtualNetwork, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVirtualNetwork", arg0, arg1)
+	ret0, _ := ret[0].(*api.VirtualNetwork)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVirtualNetwork indicates an expected call of GetVirtualNetwork.
+func (mr *MockNetworkAPIMockRecorder) GetVirtualNetwork(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVirtualNetwork", reflect.TypeOf((*MockNetworkAPI)(nil).GetVirtualNetwork), arg0, arg1)
+}
+
+// ListNetworkSecurityGroups mocks base method.
+func (m *MockNetworkAPI) ListNetworkSecurityGroups(arg0 context.Context, arg1, arg2 string) ([]*api.NetworkSecurityGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNetworkSecurityGroups", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*api.NetworkSecurityGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNetworkSecurityGroups indicates an expected call of ListNetworkSecurityGroups.
+func (mr *MockNetworkAPIMockRecorder) ListNetworkSecurityGroups(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNetworkSecurityGroups", reflect.TypeOf((*MockNetworkAPI)(nil).ListNetworkSecurityGroups), arg0, arg1, arg2)
+}
+
+// ListVirtualNetworks mocks base method.
+func (m *MockNetworkAPI) ListVirtualNetworks(arg0 context.Context, arg1, arg2 string) ([]*api.VirtualNetwork, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListVirtualNetworks", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*api.VirtualNetwork)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListVirtualNetworks indicates an expected call of ListVirtualNetworks.
+func (mr *MockNetworkAPIMockRecorder) ListVirtualNetworks(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListVirtualNetworks", reflect.TypeOf((*MockNetworkAPI)(nil).ListVirtualNetworks), arg0, arg1, arg2)
+}
@@ -0,0 +1,34 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package aks
+
+import (
+	"net/http"
+)
+
+// Config is the configuration for the AKS preloader
+type Config struct {
+	// ClusterName is the name of the AKS cluster.
+	ClusterName string
+	// Client is an authenticated http client used to communicate with the Azure Resource Manager.
+	Client *http.Client
+	// Region is the location of the AKS cluster.
+	Region string
+	// SubscriptionID is the subscription the cluster resides in.
+	SubscriptionID string
+}
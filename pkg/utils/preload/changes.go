@@ -0,0 +1,163 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package preload
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+)
+
+// Changes is a summary of the differences between two runs of the preloader
+type Changes struct {
+	// Added is a list of keys which did not previously exist
+	Added []string
+	// Changed is a list of keys whose value has changed
+	Changed []string
+	// Removed is a list of keys which no longer exist
+	Removed []string
+}
+
+// IsEmpty returns true if there are no changes
+func (c *Changes) IsEmpty() bool {
+	return len(c.Added) == 0 && len(c.Changed) == 0 && len(c.Removed) == 0
+}
+
+// Keys returns a sorted list of all the keys which were added, changed or removed
+func (c *Changes) Keys() []string {
+	var list []string
+
+	list = append(list, c.Added...)
+	list = append(list, c.Changed...)
+	list = append(list, c.Removed...)
+	sort.Strings(list)
+
+	return list
+}
+
+// String returns a human readable summary of the changes
+func (c *Changes) String() string {
+	return fmt.Sprintf("%d added, %d changed, %d removed", len(c.Added), len(c.Changed), len(c.Removed))
+}
+
+// Diff compares the checksums of the previous and current data, returning the keys
+// which have been added, changed or removed
+func Diff(previous, current map[string]string) Changes {
+	changes := Changes{}
+
+	for key, checksum := range current {
+		existing, found := previous[key]
+		switch {
+		case !found:
+			changes.Added = append(changes.Added, key)
+		case existing != checksum:
+			changes.Changed = append(changes.Changed, key)
+		}
+	}
+	for key := range previous {
+		if _, found := current[key]; !found {
+			changes.Removed = append(changes.Removed, key)
+		}
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Changed)
+	sort.Strings(changes.Removed)
+
+	return changes
+}
+
+// Checksums returns a map of key to a checksum of the entry value
+func (d *Data) Checksums() (map[string]string, error) {
+	checksums := make(map[string]string)
+
+	for key, entry := range *d {
+		if entry == nil {
+			continue
+		}
+		// @note: encoding via json ensures map keys are sorted and the checksum stable
+		encoded, err := json.Marshal(entry.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode the value of %q, error: %w", key, err)
+		}
+		checksums[key] = fmt.Sprintf("%x", sha256.Sum256(encoded))
+	}
+
+	return checksums, nil
+}
+
+// NewDataFromContext decodes the variables within the context which were written by the
+// preloader into preload data, any variables added by hand are ignored
+func NewDataFromContext(txt *terraformv1alpha1.Context) (Data, error) {
+	data := NewData()
+
+	for _, key := range txt.GetPreloadKeys() {
+		value, found := txt.Spec.Variables[key]
+		if !found || len(value.Raw) == 0 {
+			continue
+		}
+		entry := Entry{}
+		if err := json.NewDecoder(bytes.NewReader(value.Raw)).Decode(&entry); err != nil {
+			return nil, fmt.Errorf("failed to decode the context variable %q, error: %w", key, err)
+		}
+		data.Add(key, entry)
+	}
+
+	return data, nil
+}
+
+// ApplyTo writes the data into the variables of the context, removing any variables previously
+// written by the preloader which no longer exist. Variables added by hand are left untouched. The
+// keys which were removed are returned.
+func (d *Data) ApplyTo(txt *terraformv1alpha1.Context) ([]string, error) {
+	if txt.Spec.Variables == nil {
+		txt.Spec.Variables = make(map[string]runtime.RawExtension)
+	}
+
+	keys := d.Keys()
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		encoded, err := d.Get(key).Marshal()
+		if err != nil {
+			return nil, err
+		}
+		txt.Spec.Variables[key] = runtime.RawExtension{Raw: encoded}
+	}
+
+	var removed []string
+	for _, key := range txt.GetPreloadKeys() {
+		if d.Get(key) == nil {
+			removed = append(removed, key)
+			delete(txt.Spec.Variables, key)
+		}
+	}
+
+	if txt.Annotations == nil {
+		txt.Annotations = make(map[string]string)
+	}
+	txt.Annotations[terraformv1alpha1.PreloadKeysAnnotation] = strings.Join(keys, ",")
+
+	return removed, nil
+}
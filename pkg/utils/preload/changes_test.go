@@ -0,0 +1,137 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package preload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+)
+
+func TestDiff(t *testing.T) {
+	cases := []struct {
+		Previous map[string]string
+		Current  map[string]string
+		Expected Changes
+	}{
+		{},
+		{
+			Current:  map[string]string{"a": "1", "b": "2"},
+			Expected: Changes{Added: []string{"a", "b"}},
+		},
+		{
+			Previous: map[string]string{"a": "1", "b": "2"},
+			Expected: Changes{Removed: []string{"a", "b"}},
+		},
+		{
+			Previous: map[string]string{"a": "1", "b": "2", "c": "3"},
+			Current:  map[string]string{"a": "1", "b": "3", "d": "4"},
+			Expected: Changes{Added: []string{"d"}, Changed: []string{"b"}, Removed: []string{"c"}},
+		},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.Expected, Diff(c.Previous, c.Current))
+	}
+}
+
+func TestChangesKeys(t *testing.T) {
+	changes := Changes{Added: []string{"d"}, Changed: []string{"b"}, Removed: []string{"a"}}
+	assert.Equal(t, []string{"a", "b", "d"}, changes.Keys())
+	assert.False(t, changes.IsEmpty())
+	assert.True(t, (&Changes{}).IsEmpty())
+	assert.Equal(t, "1 added, 1 changed, 1 removed", changes.String())
+}
+
+func TestChecksums(t *testing.T) {
+	d := NewData()
+	d.Add("a", Entry{Value: map[string]interface{}{"b": 1, "a": 2}})
+	d.Add("b", Entry{Description: "test", Value: "value"})
+
+	checksums, err := d.Checksums()
+	require.NoError(t, err)
+	assert.Len(t, checksums, 2)
+
+	// the description should not change the checksum
+	d.Add("b", Entry{Description: "changed", Value: "value"})
+	updated, err := d.Checksums()
+	require.NoError(t, err)
+	assert.Equal(t, checksums, updated)
+
+	d.Add("b", Entry{Value: "changed"})
+	updated, err = d.Checksums()
+	require.NoError(t, err)
+	assert.NotEqual(t, checksums["b"], updated["b"])
+	assert.Equal(t, checksums["a"], updated["a"])
+}
+
+func TestNewDataFromContext(t *testing.T) {
+	txt := terraformv1alpha1.NewContext("test")
+	txt.Annotations = map[string]string{terraformv1alpha1.PreloadKeysAnnotation: "vpc_id,empty,missing"}
+	txt.Spec.Variables = map[string]runtime.RawExtension{
+		"vpc_id": {Raw: []byte(`{"description": "vpc", "value": "vpc-123"}`)},
+		"empty":  {},
+		"manual": {Raw: []byte(`{"description": "added by hand", "value": "manual"}`)},
+	}
+
+	data, err := NewDataFromContext(txt)
+	require.NoError(t, err)
+	assert.Len(t, data, 1)
+	assert.Equal(t, "vpc-123", data.Get("vpc_id").Value)
+	assert.Nil(t, data.Get("manual"))
+
+	txt.Spec.Variables["bad"] = runtime.RawExtension{Raw: []byte(`not json`)}
+	txt.Annotations[terraformv1alpha1.PreloadKeysAnnotation] = "vpc_id,bad"
+	_, err = NewDataFromContext(txt)
+	assert.Error(t, err)
+}
+
+func TestApplyTo(t *testing.T) {
+	txt := terraformv1alpha1.NewContext("test")
+
+	d := NewData()
+	d.Add("vpc_id", Entry{Description: "vpc", Value: "vpc-123"})
+	d.Add("old_subnets", Entry{Description: "subnets", Value: []string{"subnet-1"}})
+	removed, err := d.ApplyTo(txt)
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+	assert.Equal(t, []string{"old_subnets", "vpc_id"}, txt.GetPreloadKeys())
+
+	// a variable added by hand must survive the next preload
+	txt.Spec.Variables["manual"] = runtime.RawExtension{Raw: []byte(`{"description": "added by hand", "value": "manual"}`)}
+
+	d = NewData()
+	d.Add("vpc_id", Entry{Description: "vpc", Value: "vpc-456"})
+	removed, err = d.ApplyTo(txt)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old_subnets"}, removed)
+	assert.Equal(t, []string{"vpc_id"}, txt.GetPreloadKeys())
+	assert.Contains(t, txt.Spec.Variables, "manual")
+	assert.NotContains(t, txt.Spec.Variables, "old_subnets")
+	assert.JSONEq(t, `{"description": "vpc", "value": "vpc-456"}`, string(txt.Spec.Variables["vpc_id"].Raw))
+
+	data, err := NewDataFromContext(txt)
+	require.NoError(t, err)
+	checksums, err := data.Checksums()
+	require.NoError(t, err)
+	assert.Len(t, checksums, 1)
+	assert.Contains(t, checksums, "vpc_id")
+}
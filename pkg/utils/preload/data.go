@@ -20,6 +20,7 @@ package preload
 import (
 	"encoding/json"
 	"io"
+	"strings"
 )
 
 // NewData returns a new Data instance
@@ -59,3 +60,8 @@ func (d *Data) MarshalTo(w io.Writer) error {
 func (e *Entry) Marshal() ([]byte, error) {
 	return json.Marshal(e)
 }
+
+// SanitizeName converts the cloud resource name into a form suitable for a context key
+func SanitizeName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "-", "_")
+}
@@ -53,3 +53,18 @@ func TestGet(t *testing.T) {
 	assert.Equal(t, "test", v.Description)
 	assert.Equal(t, "value", v.Value)
 }
+
+func TestSanitizeName(t *testing.T) {
+	assert.Equal(t, "foo", SanitizeName("foo"))
+	assert.Equal(t, "fooname", SanitizeName("fooName"))
+	assert.Equal(t, "foo_name", SanitizeName("foo-name"))
+	assert.Equal(t, "foo_name", SanitizeName("foo_name"))
+}
+
+func TestIsSupported(t *testing.T) {
+	assert.True(t, IsSupported("aws"))
+	assert.True(t, IsSupported("azurerm"))
+	assert.True(t, IsSupported("google"))
+	assert.False(t, IsSupported("vsphere"))
+	assert.False(t, IsSupported(""))
+}
@@ -20,17 +20,18 @@ package eks
 import (
 	"fmt"
 	"regexp"
-	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/eks"
+
+	"github.com/appvia/terranetes-controller/pkg/utils/preload"
 )
 
 // SanitizeName sanitizes the given name
 func SanitizeName(name string) string {
-	return strings.ReplaceAll(strings.ToLower(name), "-", "_")
+	return preload.SanitizeName(name)
 }
 
 // IsAWSErrorType returns true if the given error is an AWS error
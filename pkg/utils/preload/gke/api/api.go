@@ -0,0 +1,51 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package api
+
+import (
+	"context"
+)
+
+// API is the collection of google apis used by the preloader
+type API interface {
+	ComputeAPI
+	ContainerAPI
+	KMSAPI
+}
+
+// ContainerAPI is the subset of the GKE API used by the preloader
+type ContainerAPI interface {
+	// GetCluster returns the cluster definition
+	GetCluster(ctx context.Context, project, location, name string) (*Cluster, error)
+}
+
+// ComputeAPI is the subset of the Compute API used by the preloader
+type ComputeAPI interface {
+	// ListFirewalls returns all the firewall rules in the project
+	ListFirewalls(ctx context.Context, project string) ([]*Firewall, error)
+	// ListSubnetworks returns all the subnetworks in the project region
+	ListSubnetworks(ctx context.Context, project, region string) ([]*Subnetwork, error)
+}
+
+// KMSAPI is the subset of the Cloud KMS API used by the preloader
+type KMSAPI interface {
+	// ListCryptoKeys returns all the crypto keys within the key ring
+	ListCryptoKeys(ctx context.Context, keyring string) ([]*CryptoKey, error)
+	// ListKeyRings returns all the key rings in the project location
+	ListKeyRings(ctx context.Context, project, location string) ([]*KeyRing, error)
+}
@@ -0,0 +1,193 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+var (
+	// ComputeEndpoint is the base url for the compute api
+	ComputeEndpoint = "https://compute.googleapis.com/compute/v1"
+	// ContainerEndpoint is the base url for the container api
+	ContainerEndpoint = "https://container.googleapis.com/v1"
+	// KMSEndpoint is the base url for the cloud kms api
+	KMSEndpoint = "https://cloudkms.googleapis.com/v1"
+)
+
+// client implements the apis using the google rest endpoints
+type client struct {
+	// hc is the authenticated http client
+	hc *http.Client
+}
+
+// NewClient returns a client for the google apis
+func NewClient(hc *http.Client) API {
+	return &client{hc: hc}
+}
+
+// GetCluster returns the cluster definition
+func (c *client) GetCluster(ctx context.Context, project, location, name string) (*Cluster, error) {
+	cluster := &Cluster{}
+
+	return cluster, c.get(ctx, fmt.Sprintf("%s/projects/%s/locations/%s/clusters/%s",
+		ContainerEndpoint, project, location, name), cluster)
+}
+
+// ListFirewalls returns all the firewall rules in the project
+func (c *client) ListFirewalls(ctx context.Context, project string) ([]*Firewall, error) {
+	var list []*Firewall
+
+	err := c.list(ctx, fmt.Sprintf("%s/projects/%s/global/firewalls", ComputeEndpoint, project),
+		func(decoder *json.Decoder) (string, error) {
+			resp := struct {
+				Items         []*Firewall `json:"items"`
+				NextPageToken string      `json:"nextPageToken"`
+			}{}
+			if err := decoder.Decode(&resp); err != nil {
+				return "", err
+			}
+			list = append(list, resp.Items...)
+
+			return resp.NextPageToken, nil
+		})
+
+	return list, err
+}
+
+// ListSubnetworks returns all the subnetworks in the project region
+func (c *client) ListSubnetworks(ctx context.Context, project, region string) ([]*Subnetwork, error) {
+	var list []*Subnetwork
+
+	err := c.list(ctx, fmt.Sprintf("%s/projects/%s/regions/%s/subnetworks", ComputeEndpoint, project, region),
+		func(decoder *json.Decoder) (string, error) {
+			resp := struct {
+				Items         []*Subnetwork `json:"items"`
+				NextPageToken string        `json:"nextPageToken"`
+			}{}
+			if err := decoder.Decode(&resp); err != nil {
+				return "", err
+			}
+			list = append(list, resp.Items...)
+
+			return resp.NextPageToken, nil
+		})
+
+	return list, err
+}
+
+// ListKeyRings returns all the key rings in the project location
+func (c *client) ListKeyRings(ctx context.Context, project, location string) ([]*KeyRing, error) {
+	var list []*KeyRing
+
+	err := c.list(ctx, fmt.Sprintf("%s/projects/%s/locations/%s/keyRings", KMSEndpoint, project, location),
+		func(decoder *json.Decoder) (string, error) {
+			resp := struct {
+				KeyRings      []*KeyRing `json:"keyRings"`
+				NextPageToken string     `json:"nextPageToken"`
+			}{}
+			if err := decoder.Decode(&resp); err != nil {
+				return "", err
+			}
+			list = append(list, resp.KeyRings...)
+
+			return resp.NextPageToken, nil
+		})
+
+	return list, err
+}
+
+// ListCryptoKeys returns all the crypto keys within the key ring
+func (c *client) ListCryptoKeys(ctx context.Context, keyring string) ([]*CryptoKey, error) {
+	var list []*CryptoKey
+
+	err := c.list(ctx, fmt.Sprintf("%s/%s/cryptoKeys", KMSEndpoint, keyring),
+		func(decoder *json.Decoder) (string, error) {
+			resp := struct {
+				CryptoKeys    []*CryptoKey `json:"cryptoKeys"`
+				NextPageToken string       `json:"nextPageToken"`
+			}{}
+			if err := decoder.Decode(&resp); err != nil {
+				return "", err
+			}
+			list = append(list, resp.CryptoKeys...)
+
+			return resp.NextPageToken, nil
+		})
+
+	return list, err
+}
+
+// list is used to iterate the pages of a list response
+func (c *client) list(ctx context.Context, endpoint string, fn func(*json.Decoder) (string, error)) error {
+	var token string
+
+	for {
+		location, err := url.Parse(endpoint)
+		if err != nil {
+			return err
+		}
+		if token != "" {
+			values := location.Query()
+			values.Set("pageToken", token)
+			location.RawQuery = values.Encode()
+		}
+
+		if err := c.do(ctx, location.String(), func(decoder *json.Decoder) error {
+			token, err = fn(decoder)
+
+			return err
+		}); err != nil {
+			return err
+		}
+		if token == "" {
+			return nil
+		}
+	}
+}
+
+// get is used to retrieve a single resource
+func (c *client) get(ctx context.Context, endpoint string, out interface{}) error {
+	return c.do(ctx, endpoint, func(decoder *json.Decoder) error {
+		return decoder.Decode(out)
+	})
+}
+
+// do performs the request and hands the decoder to the handler
+func (c *client) do(ctx context.Context, endpoint string, handler func(*json.Decoder) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{StatusCode: resp.StatusCode, URL: endpoint}
+	}
+
+	return handler(json.NewDecoder(resp.Body))
+}
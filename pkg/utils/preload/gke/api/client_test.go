@@ -0,0 +1,76 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetClusterNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	ContainerEndpoint = server.URL
+
+	cluster, err := NewClient(server.Client()).GetCluster(context.Background(), "project", "region", "name")
+	assert.Error(t, err)
+	assert.True(t, IsNotFound(err))
+	assert.Equal(t, &Cluster{}, cluster)
+}
+
+func TestGetCluster(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/projects/project/locations/region/clusters/name", r.URL.Path)
+		_, _ = w.Write([]byte(`{"name": "name", "status": "RUNNING"}`))
+	}))
+	defer server.Close()
+	ContainerEndpoint = server.URL
+
+	cluster, err := NewClient(server.Client()).GetCluster(context.Background(), "project", "region", "name")
+	require.NoError(t, err)
+	assert.Equal(t, "name", cluster.Name)
+	assert.Equal(t, "RUNNING", cluster.Status)
+}
+
+func TestListSubnetworksPaginated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/projects/project/regions/region/subnetworks", r.URL.Path)
+
+		switch r.URL.Query().Get("pageToken") {
+		case "":
+			_, _ = w.Write([]byte(`{"items": [{"name": "a"}], "nextPageToken": "next"}`))
+		case "next":
+			_, _ = w.Write([]byte(`{"items": [{"name": "b"}]}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	ComputeEndpoint = server.URL
+
+	list, err := NewClient(server.Client()).ListSubnetworks(context.Background(), "project", "region")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "a", list[0].Name)
+	assert.Equal(t, "b", list[1].Name)
+}
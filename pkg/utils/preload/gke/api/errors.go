@@ -0,0 +1,44 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// APIError is returned when the google api responds with an unexpected status
+type APIError struct {
+	// StatusCode is the http status code returned
+	StatusCode int
+	// URL is the endpoint which was requested
+	URL string
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d from: %s", e.StatusCode, e.URL)
+}
+
+// IsNotFound returns true if the error indicates the resource does not exist
+func IsNotFound(err error) bool {
+	var apierr *APIError
+
+	return errors.As(err, &apierr) && apierr.StatusCode == http.StatusNotFound
+}
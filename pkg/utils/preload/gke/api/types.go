@@ -0,0 +1,140 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package api
+
+// Cluster is the subset of the GKE cluster definition used by the preloader
+type Cluster struct {
+	// ClusterIpv4Cidr is the IP range of the pods in the cluster
+	ClusterIpv4Cidr string `json:"clusterIpv4Cidr,omitempty"`
+	// CurrentMasterVersion is the current version of the control plane
+	CurrentMasterVersion string `json:"currentMasterVersion,omitempty"`
+	// Endpoint is the IP address of the cluster control plane
+	Endpoint string `json:"endpoint,omitempty"`
+	// ID is the unique identifier of the cluster
+	ID string `json:"id,omitempty"`
+	// Location is the zone or region the cluster resides in
+	Location string `json:"location,omitempty"`
+	// MasterAuth holds the authentication details of the control plane
+	MasterAuth *MasterAuth `json:"masterAuth,omitempty"`
+	// Name is the name of the cluster
+	Name string `json:"name,omitempty"`
+	// Network is the name of the VPC network the cluster is connected to
+	Network string `json:"network,omitempty"`
+	// NetworkConfig holds the fully qualified network references
+	NetworkConfig *NetworkConfig `json:"networkConfig,omitempty"`
+	// PrivateClusterConfig holds the private cluster configuration
+	PrivateClusterConfig *PrivateClusterConfig `json:"privateClusterConfig,omitempty"`
+	// ResourceLabels are the labels applied to the cluster
+	ResourceLabels map[string]string `json:"resourceLabels,omitempty"`
+	// SelfLink is the server defined URL for the cluster
+	SelfLink string `json:"selfLink,omitempty"`
+	// ServicesIpv4Cidr is the IP range of the services in the cluster
+	ServicesIpv4Cidr string `json:"servicesIpv4Cidr,omitempty"`
+	// Status is the current status of the cluster
+	Status string `json:"status,omitempty"`
+	// Subnetwork is the name of the subnetwork the cluster is connected to
+	Subnetwork string `json:"subnetwork,omitempty"`
+	// WorkloadIdentityConfig holds the workload identity configuration
+	WorkloadIdentityConfig *WorkloadIdentityConfig `json:"workloadIdentityConfig,omitempty"`
+}
+
+// MasterAuth holds the authentication details of the control plane
+type MasterAuth struct {
+	// ClusterCaCertificate is the base64 encoded certificate authority
+	ClusterCaCertificate string `json:"clusterCaCertificate,omitempty"`
+}
+
+// NetworkConfig holds the fully qualified network references
+type NetworkConfig struct {
+	// Network is the relative name of the VPC network
+	Network string `json:"network,omitempty"`
+	// Subnetwork is the relative name of the subnetwork
+	Subnetwork string `json:"subnetwork,omitempty"`
+}
+
+// PrivateClusterConfig holds the private cluster configuration
+type PrivateClusterConfig struct {
+	// EnablePrivateEndpoint indicates the control plane has no public endpoint
+	EnablePrivateEndpoint bool `json:"enablePrivateEndpoint,omitempty"`
+	// EnablePrivateNodes indicates the nodes have only private addresses
+	EnablePrivateNodes bool `json:"enablePrivateNodes,omitempty"`
+}
+
+// WorkloadIdentityConfig holds the workload identity configuration
+type WorkloadIdentityConfig struct {
+	// WorkloadPool is the workload identity pool for the cluster
+	WorkloadPool string `json:"workloadPool,omitempty"`
+}
+
+// Subnetwork is the subset of a compute subnetwork used by the preloader
+type Subnetwork struct {
+	// ID is the unique identifier of the subnetwork
+	ID string `json:"id,omitempty"`
+	// IPCidrRange is the primary range of the subnetwork
+	IPCidrRange string `json:"ipCidrRange,omitempty"`
+	// Name is the name of the subnetwork
+	Name string `json:"name,omitempty"`
+	// Network is the URL of the network the subnetwork belongs to
+	Network string `json:"network,omitempty"`
+	// PrivateIPGoogleAccess indicates access to Google APIs without a public address
+	PrivateIPGoogleAccess bool `json:"privateIpGoogleAccess,omitempty"`
+	// Purpose is the purpose of the subnetwork
+	Purpose string `json:"purpose,omitempty"`
+	// SecondaryIPRanges is a list of secondary ranges in the subnetwork
+	SecondaryIPRanges []SecondaryIPRange `json:"secondaryIpRanges,omitempty"`
+	// SelfLink is the server defined URL for the subnetwork
+	SelfLink string `json:"selfLink,omitempty"`
+}
+
+// SecondaryIPRange is a secondary range within a subnetwork
+type SecondaryIPRange struct {
+	// IPCidrRange is the range of the secondary range
+	IPCidrRange string `json:"ipCidrRange,omitempty"`
+	// RangeName is the name of the secondary range
+	RangeName string `json:"rangeName,omitempty"`
+}
+
+// Firewall is the subset of a compute firewall rule used by the preloader
+type Firewall struct {
+	// Direction is the direction of the traffic, INGRESS or EGRESS
+	Direction string `json:"direction,omitempty"`
+	// ID is the unique identifier of the firewall rule
+	ID string `json:"id,omitempty"`
+	// Name is the name of the firewall rule
+	Name string `json:"name,omitempty"`
+	// Network is the URL of the network the rule applies to
+	Network string `json:"network,omitempty"`
+	// SelfLink is the server defined URL for the firewall rule
+	SelfLink string `json:"selfLink,omitempty"`
+	// TargetTags is the list of instance tags the rule applies to
+	TargetTags []string `json:"targetTags,omitempty"`
+}
+
+// KeyRing is the subset of a KMS key ring used by the preloader
+type KeyRing struct {
+	// Name is the resource name of the key ring
+	Name string `json:"name,omitempty"`
+}
+
+// CryptoKey is the subset of a KMS crypto key used by the preloader
+type CryptoKey struct {
+	// Name is the resource name of the crypto key
+	Name string `json:"name,omitempty"`
+	// Purpose is the purpose of the key i.e. ENCRYPT_DECRYPT
+	Purpose string `json:"purpose,omitempty"`
+}
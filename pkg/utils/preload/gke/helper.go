@@ -0,0 +1,63 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gke
+
+import (
+	"sort"
+	"strings"
+)
+
+// ResourceName returns the last segment of a self link or relative resource name
+func ResourceName(link string) string {
+	if link == "" {
+		return ""
+	}
+	items := strings.Split(strings.TrimSuffix(link, "/"), "/")
+
+	return items[len(items)-1]
+}
+
+// IsSameNetwork returns true if the two network references refer to the same network, the
+// references can be either self links or relative resource names
+func IsSameNetwork(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+
+	return ResourceName(a) == ResourceName(b)
+}
+
+// ToMapLabels converts the resource labels to a list of key and values
+func ToMapLabels(labels map[string]string) []map[string]interface{} {
+	var list []map[string]interface{}
+	var keys []string
+
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		list = append(list, map[string]interface{}{
+			"key":    key,
+			"values": []string{labels[key]},
+		})
+	}
+
+	return list
+}
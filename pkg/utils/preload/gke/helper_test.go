@@ -0,0 +1,50 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gke
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceName(t *testing.T) {
+	assert.Equal(t, "", ResourceName(""))
+	assert.Equal(t, "default", ResourceName("default"))
+	assert.Equal(t, "default", ResourceName("projects/test/global/networks/default"))
+	assert.Equal(t, "default", ResourceName("https://www.googleapis.com/compute/v1/projects/test/global/networks/default/"))
+}
+
+func TestIsSameNetwork(t *testing.T) {
+	assert.False(t, IsSameNetwork("", "default"))
+	assert.False(t, IsSameNetwork("default", ""))
+	assert.False(t, IsSameNetwork("projects/test/global/networks/default", "other"))
+	assert.True(t, IsSameNetwork("projects/test/global/networks/default", "default"))
+	assert.True(t, IsSameNetwork(
+		"https://www.googleapis.com/compute/v1/projects/test/global/networks/default",
+		"projects/test/global/networks/default",
+	))
+}
+
+func TestToMapLabels(t *testing.T) {
+	assert.Nil(t, ToMapLabels(nil))
+	assert.Equal(t, []map[string]interface{}{
+		{"key": "a", "values": []string{"1"}},
+		{"key": "b", "values": []string{"2"}},
+	}, ToMapLabels(map[string]string{"b": "2", "a": "1"}))
+}
@@ -0,0 +1,335 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gke
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appvia/terranetes-controller/pkg/utils"
+	"github.com/appvia/terranetes-controller/pkg/utils/preload"
+	"github.com/appvia/terranetes-controller/pkg/utils/preload/gke/api"
+)
+
+// gkePreloader is a preloader for GKE clusters
+type gkePreloader struct {
+	// clusterName is the name of the GKE cluster to preload
+	clusterName string
+	// computecc is a client to the Compute API
+	computecc api.ComputeAPI
+	// containercc is a client to the GKE API
+	containercc api.ContainerAPI
+	// kmscc is a client to the Cloud KMS API
+	kmscc api.KMSAPI
+	// project is the google project the cluster resides in
+	project string
+	// region is the location of the cluster
+	region string
+}
+
+// New creates and returns a preloader for GKE clusters
+func New(config Config) (preload.Interface, error) {
+	switch {
+	case config.Client == nil:
+		return nil, errors.New("client is required")
+	case config.ClusterName == "":
+		return nil, errors.New("cluster name is required")
+	case config.Project == "":
+		return nil, errors.New("project is required")
+	case config.Region == "":
+		return nil, errors.New("region is required")
+	}
+	cc := api.NewClient(config.Client)
+
+	return &gkePreloader{
+		clusterName: config.ClusterName,
+		computecc:   cc,
+		containercc: cc,
+		kmscc:       cc,
+		project:     config.Project,
+		region:      config.Region,
+	}, nil
+}
+
+// Load implements the preload.Interface and used to retrieve details on a GKE cluster
+func (g *gkePreloader) Load(ctx context.Context) (preload.Data, error) {
+	data := make(preload.Data)
+
+	// @step: first we check the cluster exists and extract the cluster details
+	cluster, err := g.containercc.GetCluster(ctx, g.project, g.region, g.clusterName)
+	if err != nil {
+		if api.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to find gke cluster: %s", g.clusterName)
+		}
+
+		return nil, fmt.Errorf("failed to retrieve the gke cluster details, error: %w", err)
+	}
+	logger := log.WithFields(log.Fields{
+		"cluster": cluster.Name,
+		"status":  strings.ToLower(cluster.Status),
+	})
+	logger.Debug("retrieved details on the gke cluster")
+
+	// @step: ensure the cluster is condition we can query it
+	switch cluster.Status {
+	case "PROVISIONING", "STOPPING", "ERROR", "STATUS_UNSPECIFIED":
+		return nil, preload.ErrNotReady
+	case "RUNNING", "RECONCILING", "DEGRADED":
+		break
+	default:
+		return nil, fmt.Errorf("unknown cluster status: %s", cluster.Status)
+	}
+
+	data.Add("project_id", preload.Entry{
+		Description: "The Google project the cluster resides in",
+		Value:       g.project,
+	})
+	data.Add("project_ids", preload.Entry{
+		Description: "The Google project the cluster resides in a list",
+		Value:       []string{g.project},
+	})
+	data.Add("region", preload.Entry{
+		Description: "Google region the cluster is running in",
+		Value:       g.region,
+	})
+
+	// @step: extract the cluster details
+	g.findCluster(cluster, &data)
+
+	// @step: lets discover the subnetworks
+	if err := g.findSubnetworks(ctx, &data); err != nil {
+		return nil, err
+	}
+	// @step: lets discover the firewall rules
+	if err := g.findFirewalls(ctx, &data); err != nil {
+		return nil, err
+	}
+	// @step: find any kms keys in the project and region
+	if err := g.findKMSKeys(ctx, &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// findCluster is responsible for extracting the cluster details into the data structure
+func (g *gkePreloader) findCluster(cluster *api.Cluster, data *preload.Data) {
+	network := cluster.Network
+	subnetwork := cluster.Subnetwork
+	if cluster.NetworkConfig != nil {
+		if cluster.NetworkConfig.Network != "" {
+			network = cluster.NetworkConfig.Network
+		}
+		if cluster.NetworkConfig.Subnetwork != "" {
+			subnetwork = cluster.NetworkConfig.Subnetwork
+		}
+	}
+
+	data.Add("gke", preload.Entry{
+		Description: "The self link for the Kubernetes cluster",
+		Value:       cluster.SelfLink,
+	})
+	data.Add("gke_id", preload.Entry{
+		Description: "The ID of the GKE cluster",
+		Value:       cluster.ID,
+	})
+	data.Add("gke_endpoint", preload.Entry{
+		Description: "The endpoint for the GKE cluster",
+		Value:       cluster.Endpoint,
+	})
+	data.Add("gke_labels", preload.Entry{
+		Description: "The resource labels associated to the GKE cluster",
+		Value:       ToMapLabels(cluster.ResourceLabels),
+	})
+	data.Add("gke_location", preload.Entry{
+		Description: "The zone or region the GKE cluster is located in",
+		Value:       cluster.Location,
+	})
+	data.Add("gke_name", preload.Entry{
+		Description: "The name of the GKE cluster",
+		Value:       cluster.Name,
+	})
+	data.Add("gke_network", preload.Entry{
+		Description: "The name of the VPC network the GKE cluster is connected to",
+		Value:       ResourceName(network),
+	})
+	data.Add("gke_pods_cidr_ipv4", preload.Entry{
+		Description: "The CIDR block used by the GKE cluster for pod IPv4 addresses",
+		Value:       cluster.ClusterIpv4Cidr,
+	})
+	data.Add("gke_service_cidr_ipv4", preload.Entry{
+		Description: "The CIDR block used by the GKE cluster for Kubernetes service IPv4 addresses",
+		Value:       cluster.ServicesIpv4Cidr,
+	})
+	data.Add("gke_subnetwork", preload.Entry{
+		Description: "The name of the subnetwork the GKE cluster is connected to",
+		Value:       ResourceName(subnetwork),
+	})
+	data.Add("gke_version", preload.Entry{
+		Description: "The current Kubernetes version of the GKE cluster",
+		Value:       cluster.CurrentMasterVersion,
+	})
+	data.Add("network", preload.Entry{
+		Description: "The name of the VPC network the GKE cluster is connected to",
+		Value:       ResourceName(network),
+	})
+	data.Add("network_id", preload.Entry{
+		Description: "The relative resource name of the VPC network the GKE cluster is connected to",
+		Value:       fmt.Sprintf("projects/%s/global/networks/%s", g.project, ResourceName(network)),
+	})
+
+	if cluster.MasterAuth != nil {
+		data.Add("gke_certificate_authority", preload.Entry{
+			Description: "The certificate authority data for the GKE cluster",
+			Value:       cluster.MasterAuth.ClusterCaCertificate,
+		})
+	}
+	if cluster.PrivateClusterConfig != nil {
+		data.Add("gke_private_endpoint", preload.Entry{
+			Description: "Indicates whether or not the GKE cluster control plane only has a private endpoint",
+			Value:       cluster.PrivateClusterConfig.EnablePrivateEndpoint,
+		})
+		data.Add("gke_private_nodes", preload.Entry{
+			Description: "Indicates whether or not the GKE cluster nodes only have private addresses",
+			Value:       cluster.PrivateClusterConfig.EnablePrivateNodes,
+		})
+	}
+	if cluster.WorkloadIdentityConfig != nil {
+		data.Add("gke_workload_identity_pool", preload.Entry{
+			Description: "The workload identity pool associated to the GKE cluster",
+			Value:       cluster.WorkloadIdentityConfig.WorkloadPool,
+		})
+	}
+}
+
+// findSubnetworks is responsible for listing all the subnetworks in the cluster network and region
+func (g *gkePreloader) findSubnetworks(ctx context.Context, data *preload.Data) error {
+	list, err := g.computecc.ListSubnetworks(ctx, g.project, g.region)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve subnetworks for cluster: %s, error: %w", g.clusterName, err)
+	}
+
+	var names, links []string
+
+	for _, subnet := range list {
+		if !IsSameNetwork(subnet.Network, data.Get("network").Value.(string)) {
+			continue
+		}
+		name := preload.SanitizeName(subnet.Name)
+
+		names = append(names, subnet.Name)
+		links = append(links, subnet.SelfLink)
+
+		data.Add(fmt.Sprintf("subnetwork_%s_cidr", name), preload.Entry{
+			Description: fmt.Sprintf("The primary CIDR range of the subnetwork named %s", subnet.Name),
+			Value:       subnet.IPCidrRange,
+		})
+		data.Add(fmt.Sprintf("subnetwork_%s_id", name), preload.Entry{
+			Description: fmt.Sprintf("The self link of the subnetwork named %s", subnet.Name),
+			Value:       subnet.SelfLink,
+		})
+		for _, secondary := range subnet.SecondaryIPRanges {
+			data.Add(fmt.Sprintf("subnetwork_%s_%s_cidr", name, preload.SanitizeName(secondary.RangeName)), preload.Entry{
+				Description: fmt.Sprintf("The secondary range %s of the subnetwork named %s", secondary.RangeName, subnet.Name),
+				Value:       secondary.IPCidrRange,
+			})
+		}
+	}
+
+	data.Add("subnetwork_names", preload.Entry{
+		Description: "A list of all subnetwork names in the network the GKE cluster is connected to",
+		Value:       utils.Unique(utils.Sorted(names)),
+	})
+	data.Add("subnet_ids", preload.Entry{
+		Description: "A list of all subnetwork self links in the network the GKE cluster is connected to",
+		Value:       utils.Unique(utils.Sorted(links)),
+	})
+
+	return nil
+}
+
+// findFirewalls is responsible for finding the firewall rules attached to the cluster network
+func (g *gkePreloader) findFirewalls(ctx context.Context, data *preload.Data) error {
+	list, err := g.computecc.ListFirewalls(ctx, g.project)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve firewall rules for cluster: %s, error: %w", g.clusterName, err)
+	}
+
+	for _, rule := range list {
+		if !IsSameNetwork(rule.Network, data.Get("network").Value.(string)) {
+			continue
+		}
+
+		data.Add(fmt.Sprintf("firewall_%s_id", preload.SanitizeName(rule.Name)), preload.Entry{
+			Description: fmt.Sprintf("The self link of the firewall rule named %s", rule.Name),
+			Value:       rule.SelfLink,
+		})
+		if len(rule.TargetTags) > 0 {
+			data.Add(fmt.Sprintf("firewall_%s_target_tags", preload.SanitizeName(rule.Name)), preload.Entry{
+				Description: fmt.Sprintf("The network tags the firewall rule named %s applies to", rule.Name),
+				Value:       rule.TargetTags,
+			})
+		}
+	}
+
+	return nil
+}
+
+// findKMSKeys is responsible for finding any kms keys in the project and region
+func (g *gkePreloader) findKMSKeys(ctx context.Context, data *preload.Data) error {
+	rings, err := g.kmscc.ListKeyRings(ctx, g.project, g.region)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve kms key rings for cluster: %s, error: %w", g.clusterName, err)
+	}
+
+	var keys []string
+
+	for _, ring := range rings {
+		data.Add(fmt.Sprintf("kms_keyring_%s_id", preload.SanitizeName(ResourceName(ring.Name))), preload.Entry{
+			Description: fmt.Sprintf("The resource name of the kms key ring %s", ResourceName(ring.Name)),
+			Value:       ring.Name,
+		})
+
+		list, err := g.kmscc.ListCryptoKeys(ctx, ring.Name)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve kms keys in key ring: %s, error: %w", ring.Name, err)
+		}
+		for _, key := range list {
+			keys = append(keys, key.Name)
+
+			data.Add(fmt.Sprintf("kms_key_%s_%s_id",
+				preload.SanitizeName(ResourceName(ring.Name)),
+				preload.SanitizeName(ResourceName(key.Name)),
+			), preload.Entry{
+				Description: fmt.Sprintf("The resource name of the kms key %s in key ring %s", ResourceName(key.Name), ResourceName(ring.Name)),
+				Value:       key.Name,
+			})
+		}
+	}
+
+	data.Add("kms_key_ids", preload.Entry{
+		Description: "A list of all the kms keys in the project and region of the GKE cluster",
+		Value:       utils.Unique(utils.Sorted(keys)),
+	})
+
+	return nil
+}
@@ -0,0 +1,245 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gke
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/appvia/terranetes-controller/pkg/utils/preload"
+	"github.com/appvia/terranetes-controller/pkg/utils/preload/gke/api"
+	"github.com/appvia/terranetes-controller/pkg/utils/preload/gke/mocks"
+)
+
+//go:generate go run ../../../../vendor/github.com/golang/mock/mockgen -package mocks -destination=mocks/api_zz.go github.com/appvia/terranetes-controller/pkg/utils/preload/gke/api ComputeAPI,ContainerAPI,KMSAPI
+
+func TestReconcile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Running Test Suite")
+}
+
+var _ = Describe("GKE Preload", func() {
+	logrus.SetOutput(io.Discard)
+
+	var err error
+	var mc *gomock.Controller
+	var computecc *mocks.MockComputeAPI
+	var containercc *mocks.MockContainerAPI
+	var kmscc *mocks.MockKMSAPI
+	var loader *gkePreloader
+	var data preload.Data
+
+	expectedCluster := &api.Cluster{
+		ClusterIpv4Cidr:      "10.100.0.0/14",
+		CurrentMasterVersion: "1.27.3-gke.100",
+		Endpoint:             "10.0.0.2",
+		ID:                   "abcdef",
+		Location:             "europe-west2",
+		MasterAuth:           &api.MasterAuth{ClusterCaCertificate: "Q0E="},
+		Name:                 "test",
+		Network:              "default",
+		NetworkConfig: &api.NetworkConfig{
+			Network:    "projects/test-project/global/networks/default",
+			Subnetwork: "projects/test-project/regions/europe-west2/subnetworks/nodes",
+		},
+		PrivateClusterConfig: &api.PrivateClusterConfig{EnablePrivateNodes: true},
+		ResourceLabels:       map[string]string{"team": "platform"},
+		SelfLink:             "https://container.googleapis.com/v1/projects/test-project/locations/europe-west2/clusters/test",
+		ServicesIpv4Cidr:     "10.104.0.0/20",
+		Status:               "RUNNING",
+		Subnetwork:           "nodes",
+	}
+
+	BeforeEach(func() {
+		mc = gomock.NewController(GinkgoT())
+		computecc = mocks.NewMockComputeAPI(mc)
+		containercc = mocks.NewMockContainerAPI(mc)
+		kmscc = mocks.NewMockKMSAPI(mc)
+
+		loader = &gkePreloader{
+			clusterName: "test",
+			computecc:   computecc,
+			containercc: containercc,
+			kmscc:       kmscc,
+			project:     "test-project",
+			region:      "europe-west2",
+		}
+	})
+
+	AfterEach(func() {
+		mc.Finish()
+	})
+
+	When("creating a new preloader", func() {
+		It("should fail when missing the client", func() {
+			_, err := New(Config{ClusterName: "test", Project: "test", Region: "test"})
+			Expect(err).To(MatchError("client is required"))
+		})
+
+		It("should fail when missing the cluster name", func() {
+			_, err := New(Config{Client: http.DefaultClient, Project: "test", Region: "test"})
+			Expect(err).To(MatchError("cluster name is required"))
+		})
+
+		It("should fail when missing the project", func() {
+			_, err := New(Config{Client: http.DefaultClient, ClusterName: "test", Region: "test"})
+			Expect(err).To(MatchError("project is required"))
+		})
+
+		It("should return a preloader", func() {
+			loader, err := New(Config{Client: http.DefaultClient, ClusterName: "test", Project: "test", Region: "test"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(loader).ToNot(BeNil())
+		})
+	})
+
+	When("loading the preload data for the cluster", func() {
+		Context("when describing the cluster errors", func() {
+			BeforeEach(func() {
+				containercc.EXPECT().GetCluster(gomock.Any(), "test-project", "europe-west2", "test").Return(nil, errors.New("bad"))
+
+				data, err = loader.Load(context.Background())
+			})
+
+			It("should return an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(MatchError("failed to retrieve the gke cluster details, error: bad"))
+			})
+
+			It("should not return any data", func() {
+				Expect(data).To(BeNil())
+			})
+		})
+
+		Context("when the cluster is not found", func() {
+			BeforeEach(func() {
+				containercc.EXPECT().GetCluster(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+					nil, &api.APIError{StatusCode: http.StatusNotFound},
+				)
+
+				data, err = loader.Load(context.Background())
+			})
+
+			It("should return an error", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(MatchError("failed to find gke cluster: test"))
+			})
+		})
+
+		Context("when the cluster is provisioning", func() {
+			BeforeEach(func() {
+				containercc.EXPECT().GetCluster(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(
+					&api.Cluster{Name: "test", Status: "PROVISIONING"}, nil,
+				)
+
+				data, err = loader.Load(context.Background())
+			})
+
+			It("should indicate the cluster is not ready", func() {
+				Expect(err).To(Equal(preload.ErrNotReady))
+			})
+		})
+
+		Context("when the cluster is found", func() {
+			BeforeEach(func() {
+				containercc.EXPECT().GetCluster(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(expectedCluster, nil)
+				computecc.EXPECT().ListSubnetworks(gomock.Any(), "test-project", "europe-west2").Return([]*api.Subnetwork{
+					{
+						IPCidrRange: "10.0.0.0/24",
+						Name:        "nodes",
+						Network:     "https://www.googleapis.com/compute/v1/projects/test-project/global/networks/default",
+						SecondaryIPRanges: []api.SecondaryIPRange{
+							{RangeName: "pods-range", IPCidrRange: "10.100.0.0/14"},
+						},
+						SelfLink: "https://www.googleapis.com/compute/v1/projects/test-project/regions/europe-west2/subnetworks/nodes",
+					},
+					{
+						IPCidrRange: "10.1.0.0/24",
+						Name:        "other",
+						Network:     "https://www.googleapis.com/compute/v1/projects/test-project/global/networks/other",
+						SelfLink:    "https://www.googleapis.com/compute/v1/projects/test-project/regions/europe-west2/subnetworks/other",
+					},
+				}, nil)
+				computecc.EXPECT().ListFirewalls(gomock.Any(), "test-project").Return([]*api.Firewall{
+					{
+						Name:       "allow-ssh",
+						Network:    "https://www.googleapis.com/compute/v1/projects/test-project/global/networks/default",
+						SelfLink:   "https://www.googleapis.com/compute/v1/projects/test-project/global/firewalls/allow-ssh",
+						TargetTags: []string{"ssh"},
+					},
+					{
+						Name:     "other",
+						Network:  "https://www.googleapis.com/compute/v1/projects/test-project/global/networks/other",
+						SelfLink: "https://www.googleapis.com/compute/v1/projects/test-project/global/firewalls/other",
+					},
+				}, nil)
+				kmscc.EXPECT().ListKeyRings(gomock.Any(), "test-project", "europe-west2").Return([]*api.KeyRing{
+					{Name: "projects/test-project/locations/europe-west2/keyRings/platform"},
+				}, nil)
+				kmscc.EXPECT().ListCryptoKeys(gomock.Any(), "projects/test-project/locations/europe-west2/keyRings/platform").Return([]*api.CryptoKey{
+					{Name: "projects/test-project/locations/europe-west2/keyRings/platform/cryptoKeys/disk"},
+				}, nil)
+
+				data, err = loader.Load(context.Background())
+			})
+
+			It("should not error", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(data).ToNot(BeNil())
+			})
+
+			It("should have the cluster details", func() {
+				Expect(data.Get("project_id").Value).To(Equal("test-project"))
+				Expect(data.Get("region").Value).To(Equal("europe-west2"))
+				Expect(data.Get("gke_name").Value).To(Equal("test"))
+				Expect(data.Get("gke_endpoint").Value).To(Equal("10.0.0.2"))
+				Expect(data.Get("gke_network").Value).To(Equal("default"))
+				Expect(data.Get("gke_subnetwork").Value).To(Equal("nodes"))
+				Expect(data.Get("gke_private_nodes").Value).To(BeTrue())
+				Expect(data.Get("network_id").Value).To(Equal("projects/test-project/global/networks/default"))
+			})
+
+			It("should have the subnetworks in the cluster network", func() {
+				Expect(data.Get("subnetwork_names").Value).To(Equal([]string{"nodes"}))
+				Expect(data.Get("subnetwork_nodes_cidr").Value).To(Equal("10.0.0.0/24"))
+				Expect(data.Get("subnetwork_nodes_pods_range_cidr").Value).To(Equal("10.100.0.0/14"))
+				Expect(data.Get("subnetwork_other_cidr")).To(BeNil())
+			})
+
+			It("should have the firewall rules in the cluster network", func() {
+				Expect(data.Get("firewall_allow_ssh_id")).ToNot(BeNil())
+				Expect(data.Get("firewall_allow_ssh_target_tags").Value).To(Equal([]string{"ssh"}))
+				Expect(data.Get("firewall_other_id")).To(BeNil())
+			})
+
+			It("should have the kms keys", func() {
+				Expect(data.Get("kms_keyring_platform_id").Value).To(Equal("projects/test-project/locations/europe-west2/keyRings/platform"))
+				Expect(data.Get("kms_key_platform_disk_id").Value).To(Equal("projects/test-project/locations/europe-west2/keyRings/platform/cryptoKeys/disk"))
+				Expect(data.Get("kms_key_ids").Value).To(HaveLen(1))
+			})
+		})
+	})
+})
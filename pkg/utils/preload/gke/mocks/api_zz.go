@@ -0,0 +1,158 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/appvia/terranetes-controller/pkg/utils/preload/gke/api (interfaces: ComputeAPI,ContainerAPI,KMSAPI)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+
+	api "github.com/appvia/terranetes-controller/pkg/utils/preload/gke/api"
+)
+
+// MockComputeAPI is a mock of ComputeAPI interface.
+type MockComputeAPI struct {
+	ctrl     *gomock.Controller
+	recorder *MockComputeAPIMockRecorder
+}
+
+// MockComputeAPIMockRecorder is the mock recorder for MockComputeAPI.
+type MockComputeAPIMockRecorder struct {
+	mock *MockComputeAPI
+}
+
+// NewMockComputeAPI creates a new mock instance.
+func NewMockComputeAPI(ctrl *gomock.Controller) *MockComputeAPI {
+	mock := &MockComputeAPI{ctrl: ctrl}
+	mock.recorder = &MockComputeAPIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockComputeAPI) EXPECT() *MockComputeAPIMockRecorder {
+	return m.recorder
+}
+
+// ListFirewalls mocks base method.
+func (m *MockComputeAPI) ListFirewalls(arg0 context.Context, arg1 string) ([]*api.Firewall, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFirewalls", arg0, arg1)
+	ret0, _ := ret[0].([]*api.Firewall)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFirewalls indicates an expected call of ListFirewalls.
+func (mr *MockComputeAPIMockRecorder) ListFirewalls(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFirewalls", reflect.TypeOf((*MockComputeAPI)(nil).ListFirewalls), arg0, arg1)
+}
+
+// ListSubnetworks mocks base method.
+func (m *MockComputeAPI) ListSubnetworks(arg0 context.Context, arg1, arg2 string) ([]*api.Subnetwork, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSubnetworks", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*api.Subnetwork)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSubnetworks indicates an expected call of ListSubnetworks.
+func (mr *MockComputeAPIMockRecorder) ListSubnetworks(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSubnetworks", reflect.TypeOf((*MockComputeAPI)(nil).ListSubnetworks), arg0, arg1, arg2)
+}
+
+// MockContainerAPI is a mock of ContainerAPI interface.
+type MockContainerAPI struct {
+	ctrl     *gomock.Controller
+	recorder *MockContainerAPIMockRecorder
+}
+
+// MockContainerAPIMockRecorder is the mock recorder for MockContainerAPI.
+type MockContainerAPIMockRecorder struct {
+	mock *MockContainerAPI
+}
+
+// NewMockContainerAPI creates a new mock instance.
+func NewMockContainerAPI(ctrl *gomock.Controller) *MockContainerAPI {
+	mock := &MockContainerAPI{ctrl: ctrl}
+	mock.recorder = &MockContainerAPIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockContainerAPI) EXPECT() *MockContainerAPIMockRecorder {
+	return m.recorder
+}
+
+// GetCluster mocks base method.
+func (m *MockContainerAPI) GetCluster(arg0 context.Context, arg1, arg2, arg3 string) (*api.Cluster, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCluster", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*api.Cluster)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCluster indicates an expected call of GetCluster.
+func (mr *MockContainerAPIMockRecorder) GetCluster(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCluster", reflect.TypeOf((*MockContainerAPI)(nil).GetCluster), arg0, arg1, arg2, arg3)
+}
+
+// MockKMSAPI is a mock of KMSAPI interface.
+type MockKMSAPI struct {
+	ctrl     *gomock.Controller
+	recorder *MockKMSAPIMockRecorder
+}
+
+// MockKMSAPIMockRecorder is the mock recorder for MockKMSAPI.
+type MockKMSAPIMockRecorder struct {
+	mock *MockKMSAPI
+}
+
+// NewMockKMSAPI creates a new mock instance.
+func NewMockKMSAPI(ctrl *gomock.Controller) *MockKMSAPI {
+	mock := &MockKMSAPI{ctrl: ctrl}
+	mock.recorder = &MockKMSAPIMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKMSAPI) EXPECT() *MockKMSAPIMockRecorder {
+	return m.recorder
+}
+
+// ListCryptoKeys mocks base method.
+func (m *MockKMSAPI) ListCryptoKeys(arg0 context.Context, arg1 string) ([]*api.CryptoKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCryptoKeys", arg0, arg1)
+	ret0, _ := ret[0].([]*api.CryptoKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCryptoKeys indicates an expected call of ListCryptoKeys.
+func (mr *MockKMSAPIMockRecorder) ListCryptoKeys(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCryptoKeys", reflect.TypeOf((*MockKMSAPI)(nil).ListCryptoKeys), arg0, arg1)
+}
+
+// ListKeyRings mocks base method.
+func (m *MockKMSAPI) ListKeyRings(arg0 context.Context, arg1, arg2 string) ([]*api.KeyRing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListKeyRings", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*api.KeyRing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListKeyRings indicates an expected call of ListKeyRings.
+func (mr *MockKMSAPIMockRecorder) ListKeyRings(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKeyRings", reflect.TypeOf((*MockKMSAPI)(nil).ListKeyRings), arg0, arg1, arg2)
+}
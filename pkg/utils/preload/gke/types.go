@@ -0,0 +1,34 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package gke
+
+import (
+	"net/http"
+)
+
+// Config is the configuration for the GKE preloader
+type Config struct {
+	// ClusterName is the name of the GKE cluster.
+	ClusterName string
+	// Client is an authenticated http client used to communicate with the Google APIs.
+	Client *http.Client
+	// Project is the Google project the cluster resides in.
+	Project string
+	// Region is the location of the GKE cluster.
+	Region string
+}
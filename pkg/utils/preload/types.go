@@ -20,6 +20,8 @@ package preload
 import (
 	"context"
 	"errors"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
 )
 
 var (
@@ -27,6 +29,24 @@ var (
 	ErrNotReady = errors.New("cloud resources not ready")
 )
 
+// SupportedClouds is the list of cloud providers we can preload contextual data from
+var SupportedClouds = []terraformv1alpha1.ProviderType{
+	terraformv1alpha1.AWSProviderType,
+	terraformv1alpha1.AzureProviderType,
+	terraformv1alpha1.GCPProviderType,
+}
+
+// IsSupported returns true if the cloud provider supports preloading
+func IsSupported(cloud string) bool {
+	for _, x := range SupportedClouds {
+		if string(x) == cloud {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Interface is the external interface for the preload package
 type Interface interface {
 	// Load is used to load the preload data
@@ -0,0 +1,119 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package steps
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// ResultsKey is the key holding the results within the uploaded secret
+const ResultsKey = "results.json"
+
+// MaxTailSize is the maximum size of the stderr retained for a step
+const MaxTailSize = 2048
+
+// Results are the results of the steps run within a job
+type Results struct {
+	// Job is the name of the job the steps were run in
+	Job string `json:"job,omitempty"`
+	// Steps is the results of the steps in the order they finished
+	Steps []Result `json:"steps,omitempty"`
+}
+
+// Result is the outcome of a single step
+type Result struct {
+	// Name is the name of the step
+	Name string `json:"name"`
+	// Duration is the time taken to run the commands of the step
+	Duration time.Duration `json:"duration"`
+	// ExitCode is the exit code of the last command run by the step
+	ExitCode int `json:"exitCode"`
+	// Ignored indicates the step failed but was permitted to continue
+	Ignored bool `json:"ignored,omitempty"`
+	// Retries is the number of times the commands were retried
+	Retries int `json:"retries,omitempty"`
+	// Stderr is the tail of the stderr of the last command run by the step
+	Stderr string `json:"stderr,omitempty"`
+}
+
+// Decode decodes the results
+func Decode(data []byte) (*Results, error) {
+	results := &Results{}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return results, nil
+	}
+	if err := json.Unmarshal(data, results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Encode encodes the results
+func (r *Results) Encode() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// FailedStep returns the first step which failed, ignoring those permitted to fail, if any
+func (r *Results) FailedStep() (Result, bool) {
+	for _, x := range r.Steps {
+		if x.ExitCode != 0 && !x.Ignored {
+			return x, true
+		}
+	}
+
+	return Result{}, false
+}
+
+// TailBuffer is a writer which only retains the last bytes written
+type TailBuffer struct {
+	sync.Mutex
+	// size is the maximum number of bytes retained
+	size int
+	// buffer holds the retained bytes
+	buffer []byte
+}
+
+// NewTailBuffer returns a writer retaining the last size bytes
+func NewTailBuffer(size int) *TailBuffer {
+	return &TailBuffer{size: size}
+}
+
+// Write implements the io.Writer interface
+func (t *TailBuffer) Write(p []byte) (int, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.buffer = append(t.buffer, p...)
+	if len(t.buffer) > t.size {
+		t.buffer = t.buffer[len(t.buffer)-t.size:]
+	}
+
+	return len(p), nil
+}
+
+// String returns the retained bytes
+func (t *TailBuffer) String() string {
+	t.Lock()
+	defer t.Unlock()
+
+	return string(t.buffer)
+}
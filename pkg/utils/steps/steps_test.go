@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package steps
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeEmpty(t *testing.T) {
+	results, err := Decode(nil)
+	require.NoError(t, err)
+	assert.Empty(t, results.Steps)
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	_, err := Decode([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestEncodeDecode(t *testing.T) {
+	results := &Results{
+		Job: "test-plan-1234",
+		Steps: []Result{
+			{Name: "setup", Duration: 2 * time.Second},
+			{Name: "terraform", Duration: time.Minute, ExitCode: 1, Retries: 2, Stderr: "Error: boom"},
+		},
+	}
+	encoded, err := results.Encode()
+	require.NoError(t, err)
+
+	decoded, err := Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, results, decoded)
+}
+
+func TestFailedStep(t *testing.T) {
+	results := &Results{Steps: []Result{{Name: "setup"}, {Name: "terraform"}}}
+	_, found := results.FailedStep()
+	assert.False(t, found)
+
+	results.Steps = append(results.Steps, Result{Name: "hook-lint", ExitCode: 1, Ignored: true})
+	_, found = results.FailedStep()
+	assert.False(t, found)
+
+	results.Steps = append(results.Steps, Result{Name: "costs", ExitCode: 2}, Result{Name: "policy", ExitCode: 1})
+	step, found := results.FailedStep()
+	assert.True(t, found)
+	assert.Equal(t, "costs", step.Name)
+}
+
+func TestTailBuffer(t *testing.T) {
+	tail := NewTailBuffer(10)
+
+	n, err := tail.Write([]byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, "hello", tail.String())
+
+	n, err = tail.Write([]byte(strings.Repeat("x", 20) + "world"))
+	require.NoError(t, err)
+	assert.Equal(t, 25, n)
+	assert.Equal(t, "xxxxxworld", tail.String())
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package terraform
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+	"github.com/appvia/terranetes-controller/pkg/utils"
+)
+
+// StateFormatVersion is the version of the state format supported by both engines
+const StateFormatVersion = 4
+
+var (
+	// engineVersionRegex matches the version banner printed by the engines, i.e. the output
+	// of 'terraform version' or 'tofu version'
+	engineVersionRegex = regexp.MustCompile(`^(Terraform|OpenTofu) v([0-9]+\.[0-9]+\.[0-9]+[-+0-9A-Za-z.]*)`)
+)
+
+// EngineName returns the human readable name of the engine
+func EngineName(engine string) string {
+	switch engine {
+	case terraformv1alpha1.EngineOpenTofu:
+		return "OpenTofu"
+	case terraformv1alpha1.EngineTerraform:
+		return "Terraform"
+	}
+
+	return engine
+}
+
+// EngineBinary returns the name of the binary for the engine
+func EngineBinary(engine string) string {
+	if engine == terraformv1alpha1.EngineOpenTofu {
+		return "tofu"
+	}
+
+	return "terraform"
+}
+
+// ParseEngineVersion is used to scan the output of a version command for the engine and version
+func ParseEngineVersion(in io.Reader) (string, string, error) {
+	scan := bufio.NewScanner(in)
+
+	for scan.Scan() {
+		matches := engineVersionRegex.FindStringSubmatch(strings.TrimSpace(scan.Text()))
+		if len(matches) != 3 {
+			continue
+		}
+		switch matches[1] {
+		case "OpenTofu":
+			return terraformv1alpha1.EngineOpenTofu, matches[2], nil
+		default:
+			return terraformv1alpha1.EngineTerraform, matches[2], nil
+		}
+	}
+	if err := scan.Err(); err != nil {
+		return "", "", err
+	}
+
+	return "", "", errors.New("unable to find the engine version in the output")
+}
+
+// IsEngineCompatible checks the state written by one engine can be read by another. The version
+// is the version of the engine taking over the state, and is ignored when empty or not a
+// semantic version (i.e. latest)
+func IsEngineCompatible(from, to string, state *State, version string) error {
+	switch {
+	case from == to:
+		return nil
+	case state == nil:
+		return nil
+	case state.Version != 0 && state.Version != StateFormatVersion:
+		return fmt.Errorf("state format version %d is not supported by %s", state.Version, EngineName(to))
+	case state.EncryptionVersion != "":
+		return fmt.Errorf("state has been encrypted by %s and cannot be read by %s", EngineName(from), EngineName(to))
+	case version == "", state.TerraformVersion == "":
+		return nil
+	}
+
+	older, err := utils.VersionLessThan(strings.TrimPrefix(version, "v"), state.TerraformVersion)
+	if err != nil {
+		return nil
+	}
+	if older {
+		return fmt.Errorf("state was written by %s v%s, which is newer than %s v%s",
+			EngineName(from), state.TerraformVersion, EngineName(to), strings.TrimPrefix(version, "v"))
+	}
+
+	return nil
+}
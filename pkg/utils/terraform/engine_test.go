@@ -0,0 +1,131 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package terraform
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	terraformv1alpha1 "github.com/appvia/terranetes-controller/pkg/apis/terraform/v1alpha1"
+)
+
+func TestParseEngineVersion(t *testing.T) {
+	cases := []struct {
+		Output  string
+		Engine  string
+		Version string
+	}{
+		{
+			Output:  "Terraform v1.5.7\non linux_amd64\n",
+			Engine:  terraformv1alpha1.EngineTerraform,
+			Version: "1.5.7",
+		},
+		{
+			Output:  "OpenTofu v1.8.5\non linux_amd64\n+ provider registry.opentofu.org/hashicorp/aws v5.0.0\n",
+			Engine:  terraformv1alpha1.EngineOpenTofu,
+			Version: "1.8.5",
+		},
+		{
+			Output:  "Terraform v1.10.0-beta1\non linux_arm64\n",
+			Engine:  terraformv1alpha1.EngineTerraform,
+			Version: "1.10.0-beta1",
+		},
+	}
+	for _, c := range cases {
+		engine, version, err := ParseEngineVersion(strings.NewReader(c.Output))
+		assert.NoError(t, err)
+		assert.Equal(t, c.Engine, engine)
+		assert.Equal(t, c.Version, version)
+	}
+}
+
+func TestParseEngineVersionNotFound(t *testing.T) {
+	engine, version, err := ParseEngineVersion(strings.NewReader("bash: tofu: not found\n"))
+	assert.Error(t, err)
+	assert.Empty(t, engine)
+	assert.Empty(t, version)
+}
+
+func TestEngineBinary(t *testing.T) {
+	assert.Equal(t, "tofu", EngineBinary(terraformv1alpha1.EngineOpenTofu))
+	assert.Equal(t, "terraform", EngineBinary(terraformv1alpha1.EngineTerraform))
+	assert.Equal(t, "terraform", EngineBinary(""))
+}
+
+func TestIsEngineCompatible(t *testing.T) {
+	cases := []struct {
+		From    string
+		To      string
+		State   *State
+		Version string
+		Expect  error
+	}{
+		{
+			From:  terraformv1alpha1.EngineTerraform,
+			To:    terraformv1alpha1.EngineTerraform,
+			State: &State{Version: 5, TerraformVersion: "2.0.0"},
+		},
+		{
+			From: terraformv1alpha1.EngineTerraform,
+			To:   terraformv1alpha1.EngineOpenTofu,
+		},
+		{
+			From:    terraformv1alpha1.EngineTerraform,
+			To:      terraformv1alpha1.EngineOpenTofu,
+			State:   &State{Version: 4, TerraformVersion: "1.5.7"},
+			Version: "1.8.5",
+		},
+		{
+			From:    terraformv1alpha1.EngineTerraform,
+			To:      terraformv1alpha1.EngineOpenTofu,
+			State:   &State{Version: 4, TerraformVersion: "1.5.7"},
+			Version: "latest",
+		},
+		{
+			From:   terraformv1alpha1.EngineTerraform,
+			To:     terraformv1alpha1.EngineOpenTofu,
+			State:  &State{Version: 5},
+			Expect: errors.New("state format version 5 is not supported by OpenTofu"),
+		},
+		{
+			From:    terraformv1alpha1.EngineTerraform,
+			To:      terraformv1alpha1.EngineOpenTofu,
+			State:   &State{Version: 4, TerraformVersion: "1.9.2"},
+			Version: "v1.8.5",
+			Expect:  errors.New("state was written by Terraform v1.9.2, which is newer than OpenTofu v1.8.5"),
+		},
+		{
+			From:    terraformv1alpha1.EngineOpenTofu,
+			To:      terraformv1alpha1.EngineTerraform,
+			State:   &State{EncryptionVersion: "v0"},
+			Version: "1.9.0",
+			Expect:  errors.New("state has been encrypted by OpenTofu and cannot be read by Terraform"),
+		},
+	}
+	for _, c := range cases {
+		err := IsEngineCompatible(c.From, c.To, c.State, c.Version)
+		if c.Expect == nil {
+			assert.NoError(t, err)
+		} else {
+			assert.Equal(t, c.Expect, err)
+		}
+	}
+}
@@ -17,30 +17,303 @@
 
 package terraform
 
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// SeverityError indicates the detection is an error requiring action
+	SeverityError = "error"
+	// SeverityWarning indicates the detection is a warning and only recorded as an event
+	SeverityWarning = "warning"
+)
+
+const (
+	// ClassConflict indicates the resource already exists
+	ClassConflict = "conflict"
+	// ClassCredentials indicates the provider credentials are invalid
+	ClassCredentials = "credentials"
+	// ClassConfiguration indicates the terraform configuration is invalid
+	ClassConfiguration = "configuration"
+	// ClassNetwork indicates a transient network failure
+	ClassNetwork = "network"
+	// ClassPermissions indicates the provider does not have permission
+	ClassPermissions = "permissions"
+	// ClassQuota indicates a cloud quota or limit has been exceeded
+	ClassQuota = "quota"
+	// ClassStateLock indicates the terraform state is locked
+	ClassStateLock = "state-lock"
+	// ClassThrottling indicates the cloud api is rate limiting the requests
+	ClassThrottling = "throttling"
+)
+
 // ErrorDetection defines an error and potential causes for it.
 type ErrorDetection struct {
+	// Name is an optional name for the detector
+	Name string `json:"name,omitempty"`
+	// Class is the classification of the error i.e. quota, permissions, throttling
+	Class string `json:"class,omitempty"`
+	// Provider is the provider the detector applies to, empty or '*' for all
+	Provider string `json:"provider,omitempty"`
 	// Regex is the string we are looking for
-	Regex string
+	Regex string `json:"regex"`
 	// Message is cause of the error
-	Message string
+	Message string `json:"message"`
+	// RemediationURL is an optional link to documentation on resolving the error
+	RemediationURL string `json:"remediationURL,omitempty"`
+	// Severity is the severity of the error i.e. error or warning, defaults to error
+	Severity string `json:"severity,omitempty"`
+	// Retryable indicates the error is transient and the configuration can be retried
+	Retryable bool `json:"retryable,omitempty"`
+}
+
+// Validate checks the detector is valid
+func (e *ErrorDetection) Validate() error {
+	switch {
+	case e.Regex == "":
+		return errors.New("regex is required")
+	case e.Message == "":
+		return errors.New("message is required")
+	case e.Severity != "" && e.Severity != SeverityError && e.Severity != SeverityWarning:
+		return fmt.Errorf("severity must be %s or %s", SeverityError, SeverityWarning)
+	}
+	if _, err := compileDetector(e.Regex); err != nil {
+		return fmt.Errorf("invalid regex: %w", err)
+	}
+
+	return nil
+}
+
+// IsWarning returns true if the detection is a warning
+func (e *ErrorDetection) IsWarning() bool {
+	return e.Severity == SeverityWarning
+}
+
+// Description returns the message along with the remediation link if any
+func (e *ErrorDetection) Description() string {
+	if e.RemediationURL == "" {
+		return e.Message
+	}
+
+	return fmt.Sprintf("%s (see %s)", e.Message, e.RemediationURL)
+}
+
+// ParseDetectors parses a yaml or json list of detectors
+func ParseDetectors(content []byte) ([]ErrorDetection, error) {
+	var list []ErrorDetection
+	if err := yaml.Unmarshal(content, &list); err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if err := list[i].Validate(); err != nil {
+			return nil, fmt.Errorf("detector[%d]: %w", i, err)
+		}
+	}
+
+	return list, nil
+}
+
+// FindDetectors returns the detectors for the provider; the custom detectors are
+// evaluated before the built-in catalogue, permitting them to take precedence
+func FindDetectors(provider string, custom []ErrorDetection) []ErrorDetection {
+	var list []ErrorDetection
+	for _, x := range custom {
+		if x.Provider == "" || x.Provider == "*" || x.Provider == provider {
+			list = append(list, x)
+		}
+	}
+	list = append(list, Detectors[provider]...)
+
+	return append(list, Detectors["*"]...)
+}
+
+// DetectErrors returns the detectors which match the logs
+func DetectErrors(logs string, detectors []ErrorDetection) ([]ErrorDetection, error) {
+	var list []ErrorDetection
+	for _, x := range detectors {
+		re, err := compileDetector(x.Regex)
+		if err != nil {
+			return nil, err
+		}
+		if re.MatchString(logs) {
+			list = append(list, x)
+		}
+	}
+
+	return list, nil
 }
 
 var (
-	// Detectors is the error detection pattern
+	// compiled is a cache of the compiled regexes
+	compiled = map[string]*regexp.Regexp{}
+	// compiledLock protects the cache
+	compiledLock sync.Mutex
+)
+
+// compileDetector returns the compiled regex, caching the result
+func compileDetector(expr string) (*regexp.Regexp, error) {
+	compiledLock.Lock()
+	defer compiledLock.Unlock()
+
+	if re, found := compiled[expr]; found {
+		return re, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	compiled[expr] = re
+
+	return re, nil
+}
+
+var (
+	// Detectors is the built-in catalogue of error detectors, keyed by provider
 	Detectors = map[string][]ErrorDetection{
 		"aws": {
 			{
+				Class:   ClassCredentials,
 				Regex:   "operation error STS: GetCallerIdentity",
 				Message: "AWS Credentials in provider has been missconfigured, contact platform administrator",
 			},
+			{
+				Class:   ClassCredentials,
+				Regex:   "(InvalidClientTokenId|SignatureDoesNotMatch|ExpiredToken|UnrecognizedClientException)",
+				Message: "AWS credentials are invalid or have expired, contact the platform administrator",
+			},
+			{
+				Class:          ClassPermissions,
+				Regex:          "(AccessDenied|UnauthorizedOperation|is not authorized to perform)",
+				Message:        "AWS credentials do not have permission to perform the operation, contact the platform administrator",
+				RemediationURL: "https://docs.aws.amazon.com/IAM/latest/UserGuide/troubleshoot_access-denied.html",
+			},
+			{
+				Class:          ClassQuota,
+				Regex:          "\\b(LimitExceeded|ServiceQuotaExceeded|VcpuLimitExceeded|TooManyBuckets|AddressLimitExceeded|InsufficientInstanceCapacity)",
+				Message:        "AWS service quota or capacity has been exceeded, request a quota increase or choose another region",
+				RemediationURL: "https://docs.aws.amazon.com/general/latest/gr/aws_service_limits.html",
+			},
+			{
+				Class:     ClassThrottling,
+				Regex:     "(RequestLimitExceeded|ThrottlingException|Throttling: Rate exceeded|SlowDown)",
+				Message:   "AWS api requests are being throttled, the operation can be retried",
+				Retryable: true,
+			},
+			{
+				Class:   ClassConflict,
+				Regex:   "(BucketAlreadyExists|BucketAlreadyOwnedByYou)",
+				Message: "The S3 bucket name is already in use, bucket names are global and must be unique",
+			},
+			{
+				Class:   ClassConflict,
+				Regex:   "(EntityAlreadyExists|ResourceAlreadyExistsException|InvalidGroup.Duplicate|DBInstanceAlreadyExists)",
+				Message: "The AWS resource already exists, choose a different name or import the existing resource",
+			},
+		},
+		"google": {
+			{
+				Class:   ClassCredentials,
+				Regex:   "(could not find default credentials|oauth2: cannot fetch token|invalid_grant)",
+				Message: "Google credentials are missing or invalid, contact the platform administrator",
+			},
+			{
+				Class:          ClassPermissions,
+				Regex:          "googleapi: Error 403: .*([Pp]ermission|does not have|forbidden)",
+				Message:        "Google credentials do not have permission to perform the operation, contact the platform administrator",
+				RemediationURL: "https://cloud.google.com/iam/docs/troubleshooting-access",
+			},
+			{
+				Class:   ClassConfiguration,
+				Regex:   "(SERVICE_DISABLED|has not been used in project .* before or it is disabled)",
+				Message: "The Google api required by the configuration is not enabled on the project, contact the platform administrator",
+			},
+			{
+				Class:          ClassQuota,
+				Regex:          "(QUOTA_EXCEEDED|Quota '.*' exceeded|quotaExceeded|ZONE_RESOURCE_POOL_EXHAUSTED)",
+				Message:        "Google quota or capacity has been exceeded, request a quota increase or choose another region",
+				RemediationURL: "https://cloud.google.com/docs/quota",
+			},
+			{
+				Class:     ClassThrottling,
+				Regex:     "(googleapi: Error 429|rateLimitExceeded|userRateLimitExceeded)",
+				Message:   "Google api requests are being throttled, the operation can be retried",
+				Retryable: true,
+			},
+			{
+				Class:   ClassConflict,
+				Regex:   "googleapi: Error 409: .*already exists",
+				Message: "The Google resource already exists, choose a different name or import the existing resource",
+			},
+		},
+		"azurerm": {
+			{
+				Class:   ClassCredentials,
+				Regex:   "(AADSTS[0-9]+|building AzureRM Client|Unable to list provider registration status)",
+				Message: "Azure credentials are invalid or have expired, contact the platform administrator",
+			},
+			{
+				Class:          ClassPermissions,
+				Regex:          "(AuthorizationFailed|LinkedAuthorizationFailed|does not have authorization to perform action)",
+				Message:        "Azure credentials do not have permission to perform the operation, contact the platform administrator",
+				RemediationURL: "https://learn.microsoft.com/azure/role-based-access-control/troubleshooting",
+			},
+			{
+				Class:   ClassConfiguration,
+				Regex:   "MissingSubscriptionRegistration",
+				Message: "The Azure resource provider is not registered on the subscription, contact the platform administrator",
+			},
+			{
+				Class:          ClassQuota,
+				Regex:          "(QuotaExceeded|OperationNotAllowed.*[Qq]uota|SkuNotAvailable)",
+				Message:        "Azure quota has been exceeded or the sku is unavailable, request a quota increase or choose another region",
+				RemediationURL: "https://learn.microsoft.com/azure/quotas/quickstart-increase-quota-portal",
+			},
+			{
+				Class:     ClassThrottling,
+				Regex:     "(StatusCode=429|TooManyRequests|RetryableError.*throttl)",
+				Message:   "Azure api requests are being throttled, the operation can be retried",
+				Retryable: true,
+			},
+			{
+				Class:   ClassConflict,
+				Regex:   "already exists - to be managed via Terraform this resource needs to be imported",
+				Message: "The Azure resource already exists, choose a different name or import the existing resource",
+			},
 		},
-		"google":  {},
-		"azurerm": {},
 		"*": {
 			{
+				Class:   ClassCredentials,
 				Regex:   "error validating provider credentials",
 				Message: "Provider credentials are missconfigured, please contact the platform administrator",
 			},
+			{
+				Class:     ClassStateLock,
+				Regex:     "Error acquiring the state lock",
+				Message:   "The terraform state is locked by another operation, the lock must be released before continuing",
+				Retryable: true,
+			},
+			{
+				Class:     ClassNetwork,
+				Regex:     "(i/o timeout|connection reset by peer|TLS handshake timeout|no such host|connection refused)",
+				Message:   "A network error occurred communicating with the provider, the operation can be retried",
+				Retryable: true,
+			},
+			{
+				Class:     ClassNetwork,
+				Regex:     "(Failed to query available provider packages|Failed to install provider|Error: Failed to download module)",
+				Message:   "Failed to download the terraform providers or modules, check the module source and network access",
+				Retryable: true,
+			},
+			{
+				Class:   ClassConfiguration,
+				Regex:   "No value for required variable",
+				Message: "A required variable for the module has not been provided, check the configuration variables",
+			},
 		},
 	}
 )
@@ -31,6 +31,89 @@ func TestErrorDetection(t *testing.T) {
 			assert.NoError(t, err, "failed to compile %s[%d]: %s", cloud, i, detector.Regex)
 			assert.NotNil(t, re)
 			assert.NotEmpty(t, detector.Message)
+			assert.NotEmpty(t, detector.Class)
+			assert.NoError(t, detector.Validate())
 		}
 	}
 }
+
+func TestErrorDetectionCatalogue(t *testing.T) {
+	cases := []struct {
+		Provider string
+		Logs     string
+		Class    string
+	}{
+		{"aws", "api error AccessDenied: User is not authorized to perform: s3:CreateBucket", ClassPermissions},
+		{"aws", "Error: creating EC2 Instance: VcpuLimitExceeded: You have requested more vCPU capacity", ClassQuota},
+		{"aws", "operation error EC2: RunInstances, api error RequestLimitExceeded", ClassThrottling},
+		{"google", "googleapi: Error 403: Permission 'storage.buckets.create' denied", ClassPermissions},
+		{"google", "Error 403: Quota 'CPUS' exceeded.  Limit: 24.0 in region europe-west2., quotaExceeded", ClassQuota},
+		{"azurerm", "Code=\"AuthorizationFailed\" Message=\"The client does not have authorization", ClassPermissions},
+		{"azurerm", "Code=\"QuotaExceeded\" Message=\"Operation could not be completed", ClassQuota},
+		{"aws", "Error: Error acquiring the state lock", ClassStateLock},
+		{"google", "Error: Error acquiring the state lock", ClassStateLock},
+	}
+	for _, c := range cases {
+		matches, err := DetectErrors(c.Logs, FindDetectors(c.Provider, nil))
+		assert.NoError(t, err)
+		if assert.NotEmpty(t, matches, "case: %s", c.Logs) {
+			assert.Equal(t, c.Class, matches[0].Class, "case: %s", c.Logs)
+		}
+	}
+}
+
+func TestParseDetectors(t *testing.T) {
+	list, err := ParseDetectors([]byte(`
+- name: custom
+  class: quota
+  provider: aws
+  regex: "my error"
+  message: "Something went wrong"
+  remediationURL: https://example.com
+  severity: warning
+  retryable: true
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []ErrorDetection{{
+		Name:           "custom",
+		Class:          ClassQuota,
+		Provider:       "aws",
+		Regex:          "my error",
+		Message:        "Something went wrong",
+		RemediationURL: "https://example.com",
+		Severity:       SeverityWarning,
+		Retryable:      true,
+	}}, list)
+	assert.True(t, list[0].IsWarning())
+	assert.Equal(t, "Something went wrong (see https://example.com)", list[0].Description())
+}
+
+func TestParseDetectorsInvalid(t *testing.T) {
+	cases := map[string]string{
+		`- message: "no regex"`:                        "detector[0]: regex is required",
+		`- regex: "no message"`:                        "detector[0]: message is required",
+		`- {regex: "(", message: "bad"}`:               "detector[0]: invalid regex: error parsing regexp: missing closing ): `(`",
+		`- {regex: "a", message: "b", severity: info}`: "detector[0]: severity must be error or warning",
+	}
+	for content, expected := range cases {
+		_, err := ParseDetectors([]byte(content))
+		assert.Error(t, err)
+		assert.Equal(t, expected, err.Error())
+	}
+}
+
+func TestFindDetectors(t *testing.T) {
+	custom := []ErrorDetection{
+		{Regex: "all", Message: "all"},
+		{Regex: "wildcard", Message: "wildcard", Provider: "*"},
+		{Regex: "aws", Message: "aws", Provider: "aws"},
+		{Regex: "google", Message: "google", Provider: "google"},
+	}
+	list := FindDetectors("aws", custom)
+	assert.Len(t, list, 3+len(Detectors["aws"])+len(Detectors["*"]))
+	assert.Equal(t, "all", list[0].Message)
+	assert.Equal(t, "wildcard", list[1].Message)
+	assert.Equal(t, "aws", list[2].Message)
+
+	assert.Len(t, FindDetectors("unknown", nil), len(Detectors["*"]))
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package terraform
+
+import (
+	"bufio"
+	"strings"
+	"time"
+)
+
+// StateLockError is the message terraform emits when it fails to acquire the state lock
+const StateLockError = "Error acquiring the state lock"
+
+// stateLockTimeFormat is the format terraform uses for the lock creation time
+const stateLockTimeFormat = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+// StateLock is the lock information reported by terraform when the state is locked
+type StateLock struct {
+	// Created is the time the lock was acquired
+	Created time.Time
+	// ID is the unique identifier of the lock
+	ID string
+	// Operation is the terraform operation which acquired the lock
+	Operation string
+	// Path is the location of the state which is locked
+	Path string
+	// Version is the version of terraform which acquired the lock
+	Version string
+	// Who is the holder of the lock
+	Who string
+}
+
+// FindStateLock scans the logs for a state lock error and returns the lock information
+func FindStateLock(logs string) (*StateLock, bool) {
+	if !strings.Contains(logs, StateLockError) {
+		return nil, false
+	}
+
+	lock := &StateLock{}
+	var inside bool
+
+	scanner := bufio.NewScanner(strings.NewReader(logs))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "Lock Info:":
+			inside = true
+			continue
+		case !inside:
+			continue
+		case line == "":
+			inside = false
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.TrimSpace(key) {
+		case "ID":
+			lock.ID = value
+		case "Path":
+			lock.Path = value
+		case "Operation":
+			lock.Operation = value
+		case "Who":
+			lock.Who = value
+		case "Version":
+			lock.Version = value
+		case "Created":
+			if tm, err := time.Parse(stateLockTimeFormat, value); err == nil {
+				lock.Created = tm
+			}
+		}
+	}
+	if lock.ID == "" {
+		return nil, false
+	}
+
+	return lock, true
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+package terraform
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const stateLockLogs = `
+Acquiring state lock. This may take a few moments...
+
+Error: Error acquiring the state lock
+
+Error message: ConditionalCheckFailedException: The conditional request failed
+Lock Info:
+  ID:        3c2d1f6e-0e4a-5b7c-8d9e-1f2a3b4c5d6e
+  Path:      terraform-state/default/bucket.tfstate
+  Operation: OperationTypeApply
+  Who:       terraform@bucket-apply-abcde
+  Version:   1.5.7
+  Created:   2023-09-12 10:14:05.123456789 +0000 UTC
+  Info:
+
+Terraform acquires a state lock to protect the state from being written
+by multiple users at the same time.
+`
+
+func TestFindStateLock(t *testing.T) {
+	lock, found := FindStateLock(stateLockLogs)
+	require.True(t, found)
+	require.NotNil(t, lock)
+
+	assert.Equal(t, "3c2d1f6e-0e4a-5b7c-8d9e-1f2a3b4c5d6e", lock.ID)
+	assert.Equal(t, "terraform-state/default/bucket.tfstate", lock.Path)
+	assert.Equal(t, "OperationTypeApply", lock.Operation)
+	assert.Equal(t, "terraform@bucket-apply-abcde", lock.Who)
+	assert.Equal(t, "1.5.7", lock.Version)
+	assert.Equal(t, time.Date(2023, 9, 12, 10, 14, 5, 123456789, time.UTC), lock.Created.UTC())
+}
+
+func TestFindStateLockNotFound(t *testing.T) {
+	cases := []string{
+		"",
+		"Error: Invalid provider configuration",
+		"Error: Error acquiring the state lock\n\nError message: timeout",
+	}
+	for _, c := range cases {
+		lock, found := FindStateLock(c)
+		assert.False(t, found)
+		assert.Nil(t, lock)
+	}
+}
@@ -34,6 +34,8 @@ type Resource struct {
 
 // OutputValue is a value of the terraform output
 type OutputValue struct {
+	// Sensitive indicates the output has been marked as sensitive
+	Sensitive bool `json:"sensitive,omitempty"`
 	// Value is the value of the output
 	Value interface{} `json:"value,omitempty"`
 }
@@ -49,12 +51,16 @@ func (o *OutputValue) String() string {
 
 // State is the state of the terraform
 type State struct {
+	// EncryptionVersion is set when the state has been encrypted by OpenTofu
+	EncryptionVersion string `json:"encryption_version,omitempty"`
 	// Outputs are the terraform outputs
 	Outputs map[string]OutputValue `json:"outputs"`
 	// Resources is a collection of resources in the state
 	Resources []Resource `json:"resources,omitempty"`
 	// TerraformVersion is the version of terraform used
 	TerraformVersion string `json:"terraform_version,omitempty"`
+	// Version is the version of the state format
+	Version int `json:"version,omitempty"`
 }
 
 // CountResources returns the number of managed resources from the state
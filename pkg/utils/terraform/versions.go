@@ -0,0 +1,137 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/appvia/terranetes-controller/pkg/utils"
+)
+
+// IsVersionConstraint returns true if the version is a constraint rather than an exact version,
+// i.e. ~> 1.6 or >= 1.5, < 1.7
+func IsVersionConstraint(version string) bool {
+	return strings.ContainsAny(version, "~<>=!,")
+}
+
+// ParseVersionConstraint is used to parse a version constraint, the pessimistic operator (~>)
+// follows the terraform semantics, i.e. ~> 1.6 permits any 1.x release from 1.6 onwards
+func ParseVersionConstraint(constraint string) (*semver.Constraints, error) {
+	var list []string
+
+	for _, x := range strings.Split(constraint, ",") {
+		x = strings.TrimSpace(x)
+		if !strings.HasPrefix(x, "~>") {
+			list = append(list, x)
+
+			continue
+		}
+
+		expanded, err := expandPessimisticConstraint(strings.TrimSpace(strings.TrimPrefix(x, "~>")))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, expanded)
+	}
+
+	return semver.NewConstraint(strings.Join(list, ", "))
+}
+
+// ResolveVersion is used to resolve a version constraint to the latest version available which
+// satisfies it. Exact versions are returned as is.
+func ResolveVersion(constraint string, versions []string) (string, error) {
+	if !IsVersionConstraint(constraint) {
+		return constraint, nil
+	}
+	if len(versions) == 0 {
+		return "", errors.New("no versions have been made available to resolve the constraint")
+	}
+
+	c, err := ParseVersionConstraint(constraint)
+	if err != nil {
+		return "", fmt.Errorf("invalid version constraint %q, %w", constraint, err)
+	}
+
+	sorted, err := utils.SortSemverVersions(versions)
+	if err != nil {
+		return "", fmt.Errorf("invalid available version, %w", err)
+	}
+
+	for i := len(sorted) - 1; i >= 0; i-- {
+		v, err := semver.NewVersion(sorted[i])
+		if err != nil {
+			return "", err
+		}
+		if c.Check(v) {
+			return sorted[i], nil
+		}
+	}
+
+	return "", fmt.Errorf("no available version satisfies the constraint %q", constraint)
+}
+
+// expandPessimisticConstraint converts the version following a pessimistic operator into a
+// range, only allowing the right-most component to increment
+func expandPessimisticConstraint(version string) (string, error) {
+	e := strings.Split(version, ".")
+	if len(e) > 3 {
+		return "", fmt.Errorf("invalid version %q in constraint", version)
+	}
+
+	numbers := make([]int, len(e))
+	for i, x := range e {
+		n, err := strconv.Atoi(x)
+		if err != nil {
+			return "", fmt.Errorf("invalid version %q in constraint", version)
+		}
+		numbers[i] = n
+	}
+
+	switch len(numbers) {
+	case 1:
+		return fmt.Sprintf(">= %d", numbers[0]), nil
+	case 2:
+		return fmt.Sprintf(">= %s, < %d.0.0", version, numbers[0]+1), nil
+	}
+
+	return fmt.Sprintf(">= %s, < %d.%d.0", version, numbers[0], numbers[1]+1), nil
+}
+
+// IsVersionCompatible checks the version of the engine is not older than the version which last
+// wrote the state, versions which are not semantic versions (i.e. latest) are ignored
+func IsVersionCompatible(engine, written, version string) error {
+	version = strings.TrimPrefix(version, "v")
+	if written == "" || version == "" {
+		return nil
+	}
+
+	older, err := utils.VersionLessThan(version, written)
+	if err != nil {
+		return nil
+	}
+	if older {
+		return fmt.Errorf("state was written by v%s, which is newer than %s v%s", written, EngineName(engine), version)
+	}
+
+	return nil
+}
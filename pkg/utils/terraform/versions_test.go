@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package terraform
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsVersionConstraint(t *testing.T) {
+	assert.False(t, IsVersionConstraint(""))
+	assert.False(t, IsVersionConstraint("1.6.0"))
+	assert.False(t, IsVersionConstraint("latest"))
+	assert.True(t, IsVersionConstraint("~> 1.6"))
+	assert.True(t, IsVersionConstraint(">= 1.5, < 1.7"))
+	assert.True(t, IsVersionConstraint("!= 1.6.1"))
+}
+
+func TestParseVersionConstraint(t *testing.T) {
+	cases := []struct {
+		Constraint string
+		Version    string
+		Expected   bool
+	}{
+		{Constraint: "~> 1.6", Version: "1.6.0", Expected: true},
+		{Constraint: "~> 1.6", Version: "1.9.2", Expected: true},
+		{Constraint: "~> 1.6", Version: "1.5.7", Expected: false},
+		{Constraint: "~> 1.6", Version: "2.0.0", Expected: false},
+		{Constraint: "~> 1.6.2", Version: "1.6.5", Expected: true},
+		{Constraint: "~> 1.6.2", Version: "1.7.0", Expected: false},
+		{Constraint: "~> 1", Version: "3.1.0", Expected: true},
+		{Constraint: ">= 1.5, < 1.7", Version: "1.6.3", Expected: true},
+		{Constraint: ">= 1.5, < 1.7", Version: "1.7.0", Expected: false},
+		{Constraint: "~> 1.6, != 1.6.1", Version: "1.6.1", Expected: false},
+	}
+	for _, c := range cases {
+		constraint, err := ParseVersionConstraint(c.Constraint)
+		assert.NoError(t, err)
+		assert.NotNil(t, constraint)
+
+		version, err := semver.NewVersion(c.Version)
+		assert.NoError(t, err)
+		assert.Equal(t, c.Expected, constraint.Check(version), "constraint: %s, version: %s", c.Constraint, c.Version)
+	}
+}
+
+func TestParseVersionConstraintInvalid(t *testing.T) {
+	for _, x := range []string{"~> a.b", "~> 1.2.3.4", ">= bad"} {
+		constraint, err := ParseVersionConstraint(x)
+		assert.Error(t, err, "constraint: %s", x)
+		assert.Nil(t, constraint)
+	}
+}
+
+func TestResolveVersion(t *testing.T) {
+	versions := []string{"1.5.7", "1.6.0", "1.6.6", "1.7.1", "2.0.0"}
+
+	cases := []struct {
+		Constraint string
+		Versions   []string
+		Expected   string
+		Error      string
+	}{
+		{Constraint: "1.4.0", Versions: versions, Expected: "1.4.0"},
+		{Constraint: "1.4.0", Expected: "1.4.0"},
+		{Constraint: "~> 1.6", Versions: versions, Expected: "1.7.1"},
+		{Constraint: "~> 1.6.0", Versions: versions, Expected: "1.6.6"},
+		{Constraint: ">= 1.5, < 1.6", Versions: versions, Expected: "1.5.7"},
+		{Constraint: "~> 1.6", Error: "no versions have been made available to resolve the constraint"},
+		{Constraint: "~> 3.0", Versions: versions, Error: "no available version satisfies the constraint \"~> 3.0\""},
+		{Constraint: "~> 1.6", Versions: []string{"bad"}, Error: "invalid available version, Invalid Semantic Version"},
+	}
+	for _, c := range cases {
+		version, err := ResolveVersion(c.Constraint, c.Versions)
+		if c.Error != "" {
+			assert.Error(t, err)
+			assert.Equal(t, c.Error, err.Error())
+
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, c.Expected, version)
+	}
+}
+
+func TestIsVersionCompatible(t *testing.T) {
+	assert.NoError(t, IsVersionCompatible("terraform", "", "1.5.7"))
+	assert.NoError(t, IsVersionCompatible("terraform", "1.5.7", ""))
+	assert.NoError(t, IsVersionCompatible("terraform", "1.5.7", "latest"))
+	assert.NoError(t, IsVersionCompatible("terraform", "1.5.7", "1.5.7"))
+	assert.NoError(t, IsVersionCompatible("terraform", "1.5.7", "v1.6.0"))
+
+	err := IsVersionCompatible("opentofu", "1.8.5", "1.6.0")
+	assert.Error(t, err)
+	assert.Equal(t, "state was written by v1.8.5, which is newer than OpenTofu v1.6.0", err.Error())
+}
@@ -23,6 +23,7 @@ import (
 
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	k8sclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/appvia/terranetes-controller/pkg/cmd"
@@ -32,6 +33,7 @@ import (
 type Factory struct {
 	Config        cmd.Config
 	KubeClient    k8sclient.Interface
+	RESTConfig    *rest.Config
 	RuntimeClient client.Client
 	Streams       genericclioptions.IOStreams
 }
@@ -51,6 +53,11 @@ func (f *Factory) GetKubeClient() (k8sclient.Interface, error) {
 	return f.KubeClient, nil
 }
 
+// GetRESTConfig returns the configuration used to connect to the kubernetes api
+func (f *Factory) GetRESTConfig() (*rest.Config, error) {
+	return f.RESTConfig, nil
+}
+
 // GetStreams returns the input and output streams for the command
 func (f *Factory) GetStreams() genericclioptions.IOStreams {
 	return f.Streams
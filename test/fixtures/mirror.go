@@ -0,0 +1,56 @@
+/*
+ * Copyright (C) 2023  Appvia Ltd <info@appvia.io>
+ *
+ * This program is free software; you can redistribute it and/or
+ * modify it under the terms of the GNU General Public License
+ * as published by the Free Software Foundation; either version 2
+ * of the License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package fixtures
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// NewProviderSigningKey returns a key used to sign the checksums of the provider archives
+func NewProviderSigningKey() (*openpgp.Entity, error) {
+	return openpgp.NewEntity("terranetes", "test", "test@appvia.io", nil)
+}
+
+// NewSignedProviderChecksums returns the SHA256SUMS document for the archives, keyed by the
+// filename, along with the detached signature of the document made by the key
+func NewSignedProviderChecksums(key *openpgp.Entity, archives map[string][]byte) ([]byte, []byte, error) {
+	var filenames []string
+	for filename := range archives {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	document := &bytes.Buffer{}
+	for _, filename := range filenames {
+		sum := sha256.Sum256(archives[filename])
+		fmt.Fprintf(document, "%s  %s\n", hex.EncodeToString(sum[:]), filename)
+	}
+
+	signature := &bytes.Buffer{}
+	if err := openpgp.DetachSign(signature, key, bytes.NewReader(document.Bytes()), nil); err != nil {
+		return nil, nil, err
+	}
+
+	return document.Bytes(), signature.Bytes(), nil
+}
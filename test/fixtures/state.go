@@ -102,11 +102,16 @@ func NewCostsReport(configuration *terraformv1alpha1.Configuration) *v1.Secret {
 
 // NewTerraformState returns a fake state
 func NewTerraformState(configuration *terraformv1alpha1.Configuration) *v1.Secret {
+	return NewTerraformStateFromJSON(configuration, state)
+}
+
+// NewTerraformStateFromJSON returns a state secret containing the given terraform state
+func NewTerraformStateFromJSON(configuration *terraformv1alpha1.Configuration, raw string) *v1.Secret {
 	encoded := &bytes.Buffer{}
 
 	w := gzip.NewWriter(encoded)
 	//nolint:errcheck
-	w.Write([]byte(state))
+	w.Write([]byte(raw))
 	w.Close()
 
 	secret := &v1.Secret{}